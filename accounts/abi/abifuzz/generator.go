@@ -0,0 +1,275 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package abifuzz generates structurally valid random calldata for contract
+// methods described by an ABI, to be used as a fuzzing corpus.
+package abifuzz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// defaultMaxLength is the maximum length of dynamic arrays, byte slices and
+// strings if no explicit limit is configured.
+const defaultMaxLength = 8
+
+// errUnsupportedType is returned if a value is requested for an ABI type which
+// cannot be encoded (e.g. fixed point numbers).
+var errUnsupportedType = errors.New("unsupported abi type")
+
+// errNegativeBound is returned if a per-argument length bound is negative.
+var errNegativeBound = errors.New("negative length bound")
+
+// Config contains the settings to generate calldata with.
+type Config struct {
+	// MaxLength is the upper bound on the length of dynamic arrays, byte slices
+	// and strings. Defaults to 8 if unset.
+	MaxLength int
+
+	// Bounds are per-argument overrides for MaxLength, keyed by the name of the
+	// top level method argument. They apply to all nested dynamic values too.
+	Bounds map[string]int
+
+	// Addresses is the pool of addresses to pick from when generating address
+	// values. If empty, random addresses are generated.
+	Addresses []common.Address
+
+	// EdgeRatio is the probability in [0, 1] of picking a boundary value (zero,
+	// one, minimum, maximum) for integer types instead of a uniformly random one.
+	EdgeRatio float64
+}
+
+// Generator produces random values and calldata matching ABI type definitions.
+type Generator struct {
+	config Config
+	rand   *rand.Rand
+}
+
+// New creates a calldata generator driven by the given randomness source.
+func New(src rand.Source, config Config) *Generator {
+	if config.MaxLength <= 0 {
+		config.MaxLength = defaultMaxLength
+	}
+	return &Generator{
+		config: config,
+		rand:   rand.New(src),
+	}
+}
+
+// NewFromSeed creates a calldata generator driven by a seeded pseudo random
+// source, producing the same sequence of values for the same seed.
+func NewFromSeed(seed int64, config Config) *Generator {
+	return New(rand.NewSource(seed), config)
+}
+
+// NewFromBytes creates a calldata generator whose random decisions are read
+// from the provided input. This allows coverage guided fuzzers to steer the
+// structure of the generated calldata. Once the input is exhausted, all further
+// decisions are zero.
+func NewFromBytes(input []byte, config Config) *Generator {
+	return New(&byteSource{data: input}, config)
+}
+
+// Calldata generates random arguments for the given method and returns them
+// together with the packed calldata, prefixed by the method selector.
+func (g *Generator) Calldata(method *abi.Method) ([]byte, []interface{}, error) {
+	values, err := g.Values(method.Inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(common.CopyBytes(method.ID), packed...), values, nil
+}
+
+// Values generates a random value for every argument in the list. The values
+// have the Go types expected by abi.Arguments.Pack.
+func (g *Generator) Values(args abi.Arguments) ([]interface{}, error) {
+	for name, bound := range g.config.Bounds {
+		if bound < 0 {
+			return nil, fmt.Errorf("%w: %s = %d", errNegativeBound, name, bound)
+		}
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		limit := g.config.MaxLength
+		if bound, ok := g.config.Bounds[arg.Name]; ok {
+			limit = bound
+		}
+		val, err := g.value(arg.Type, limit)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, arg.Type, err)
+		}
+		values[i] = val.Interface()
+	}
+	return values, nil
+}
+
+// value generates a random value of the given ABI type.
+func (g *Generator) value(t abi.Type, limit int) (reflect.Value, error) {
+	if t.T == abi.FixedPointTy {
+		return reflect.Value{}, fmt.Errorf("%w: %s", errUnsupportedType, t)
+	}
+	val := reflect.New(t.GetType()).Elem()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		g.integer(t, val)
+
+	case abi.BoolTy:
+		val.SetBool(g.rand.Intn(2) == 1)
+
+	case abi.StringTy:
+		val.SetString(string(g.bytes(g.rand.Intn(limit + 1))))
+
+	case abi.BytesTy:
+		val.SetBytes(g.bytes(g.rand.Intn(limit + 1)))
+
+	case abi.AddressTy:
+		var addr common.Address
+		if pool := g.config.Addresses; len(pool) > 0 {
+			addr = pool[g.rand.Intn(len(pool))]
+		} else {
+			g.rand.Read(addr[:])
+		}
+		val.Set(reflect.ValueOf(addr))
+
+	case abi.FixedBytesTy, abi.HashTy, abi.FunctionTy:
+		reflect.Copy(val, reflect.ValueOf(g.bytes(val.Len())))
+
+	case abi.SliceTy:
+		n := g.rand.Intn(limit + 1)
+		val.Set(reflect.MakeSlice(val.Type(), n, n))
+		for i := 0; i < n; i++ {
+			elem, err := g.value(*t.Elem, limit)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			val.Index(i).Set(elem)
+		}
+
+	case abi.ArrayTy:
+		for i := 0; i < t.Size; i++ {
+			elem, err := g.value(*t.Elem, limit)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			val.Index(i).Set(elem)
+		}
+
+	case abi.TupleTy:
+		for i, elemType := range t.TupleElems {
+			elem, err := g.value(*elemType, limit)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			val.Field(i).Set(elem)
+		}
+
+	default:
+		return reflect.Value{}, fmt.Errorf("%w: %s", errUnsupportedType, t)
+	}
+	return val, nil
+}
+
+// integer fills val with a random integer fitting into the bit size of t.
+func (g *Generator) integer(t abi.Type, val reflect.Value) {
+	var (
+		signed = t.T == abi.IntTy
+		n      *big.Int
+	)
+	if g.config.EdgeRatio > 0 && g.rand.Float64() < g.config.EdgeRatio {
+		n = edgeValue(t, g.rand.Intn(4))
+	} else {
+		n = new(big.Int).SetBytes(g.bytes(t.Size / 8))
+		if signed && n.Bit(t.Size-1) == 1 {
+			n.Sub(n, new(big.Int).Lsh(common.Big1, uint(t.Size)))
+		}
+	}
+	setInteger(val, n)
+}
+
+// edgeValue returns one of the boundary values of an integer type: zero, one,
+// the maximum and the minimum (or minus one for signed integers).
+func edgeValue(t abi.Type, index int) *big.Int {
+	switch index {
+	case 0:
+		return new(big.Int)
+	case 1:
+		return big.NewInt(1)
+	case 2:
+		if t.T == abi.IntTy {
+			return new(big.Int).Sub(new(big.Int).Lsh(common.Big1, uint(t.Size-1)), common.Big1)
+		}
+		return new(big.Int).Sub(new(big.Int).Lsh(common.Big1, uint(t.Size)), common.Big1)
+	default:
+		if t.T == abi.IntTy {
+			return new(big.Int).Neg(new(big.Int).Lsh(common.Big1, uint(t.Size-1)))
+		}
+		return new(big.Int)
+	}
+}
+
+// setInteger assigns n to an integer value of either a native Go integer type
+// or *big.Int. The caller must ensure that n fits into the type.
+func setInteger(val reflect.Value, n *big.Int) {
+	switch val.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val.SetInt(n.Int64())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val.SetUint(n.Uint64())
+	default:
+		val.Set(reflect.ValueOf(n))
+	}
+}
+
+// bytes returns n random bytes.
+func (g *Generator) bytes(n int) []byte {
+	b := make([]byte, n)
+	g.rand.Read(b)
+	return b
+}
+
+// byteSource is a rand.Source which reads its values from a fixed input.
+type byteSource struct {
+	data []byte
+}
+
+// Int63 implements rand.Source.
+func (s *byteSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 implements rand.Source64.
+func (s *byteSource) Uint64() uint64 {
+	var buf [8]byte
+	n := copy(buf[:], s.data)
+	s.data = s.data[n:]
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// Seed implements rand.Source. It is a noop, the input defines the values.
+func (s *byteSource) Seed(int64) {}
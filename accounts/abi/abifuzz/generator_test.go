@@ -0,0 +1,161 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abifuzz
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testABI = `[{
+	"type": "function",
+	"name": "test",
+	"inputs": [
+		{"name": "amount", "type": "uint256"},
+		{"name": "small", "type": "int24"},
+		{"name": "flag", "type": "bool"},
+		{"name": "to", "type": "address"},
+		{"name": "data", "type": "bytes"},
+		{"name": "tag", "type": "bytes4"},
+		{"name": "name", "type": "string"},
+		{"name": "ids", "type": "uint64[]"},
+		{"name": "pair", "type": "int8[2]"},
+		{"name": "order", "type": "tuple", "components": [
+			{"name": "maker", "type": "address"},
+			{"name": "amounts", "type": "uint128[]"}
+		]}
+	]
+}]`
+
+func newTestMethod(t *testing.T) abi.Method {
+	parsed, err := abi.JSON(strings.NewReader(testABI))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	return parsed.Methods["test"]
+}
+
+// Tests that generated calldata is decodable by the ABI it was generated from.
+func TestCalldataRoundtrip(t *testing.T) {
+	method := newTestMethod(t)
+	pool := []common.Address{{0x01}, {0x02}}
+
+	gen := NewFromSeed(1, Config{Addresses: pool, Bounds: map[string]int{"ids": 2}, EdgeRatio: 0.25})
+	for i := 0; i < 100; i++ {
+		data, values, err := gen.Calldata(&method)
+		if err != nil {
+			t.Fatalf("failed to generate calldata: %v", err)
+		}
+		if !bytes.Equal(data[:4], method.ID) {
+			t.Fatalf("selector mismatch: have %x, want %x", data[:4], method.ID)
+		}
+		unpacked, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			t.Fatalf("failed to unpack calldata: %v", err)
+		}
+		if len(unpacked) != len(values) {
+			t.Fatalf("value count mismatch: have %d, want %d", len(unpacked), len(values))
+		}
+		if to := values[3].(common.Address); to != pool[0] && to != pool[1] {
+			t.Errorf("address not from pool: %x", to)
+		}
+		if ids := values[7].([]uint64); len(ids) > 2 {
+			t.Errorf("bound not respected: have %d elements, want at most 2", len(ids))
+		}
+		for j, want := range values {
+			if j == 9 {
+				continue // Tuple types are reconstructed anonymously by the decoder
+			}
+			if have, ok := unpacked[j].(*big.Int); ok {
+				if have.Cmp(want.(*big.Int)) != 0 {
+					t.Errorf("value %d mismatch: have %v, want %v", j, have, want)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(unpacked[j], want) {
+				t.Errorf("value %d mismatch: have %v, want %v", j, unpacked[j], want)
+			}
+		}
+	}
+}
+
+// Tests that byte driven generators are deterministic and tolerate any input.
+func TestNewFromBytes(t *testing.T) {
+	method := newTestMethod(t)
+	for _, input := range [][]byte{nil, {0xff}, bytes.Repeat([]byte{0xa5, 0x13}, 512)} {
+		first, _, err := NewFromBytes(input, Config{}).Calldata(&method)
+		if err != nil {
+			t.Fatalf("failed to generate calldata: %v", err)
+		}
+		second, _, err := NewFromBytes(input, Config{}).Calldata(&method)
+		if err != nil {
+			t.Fatalf("failed to generate calldata: %v", err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("non-deterministic calldata for input %x", input)
+		}
+	}
+}
+
+// Tests that negative length bounds are rejected instead of panicking.
+func TestNegativeBound(t *testing.T) {
+	method := newTestMethod(t)
+
+	gen := NewFromSeed(1, Config{Bounds: map[string]int{"ids": -1}})
+	if _, _, err := gen.Calldata(&method); !errors.Is(err, errNegativeBound) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNegativeBound)
+	}
+}
+
+// Tests that shrinking finds the minimal values still satisfying the predicate.
+func TestShrink(t *testing.T) {
+	method := newTestMethod(t)
+	limit := big.NewInt(100)
+
+	gen := NewFromSeed(2, Config{})
+	for i := 0; i < 10; i++ {
+		values, err := gen.Values(method.Inputs)
+		if err != nil {
+			t.Fatalf("failed to generate values: %v", err)
+		}
+		values[0] = new(big.Int).Lsh(common.Big1, 200)
+
+		failing := func(values []interface{}) bool {
+			return values[0].(*big.Int).Cmp(limit) > 0
+		}
+		shrunk := Shrink(method.Inputs, values, failing)
+		if have := shrunk[0].(*big.Int); have.Int64() != 101 {
+			t.Errorf("amount not minimised: have %v, want 101", have)
+		}
+		if data := shrunk[4].([]byte); len(data) != 0 {
+			t.Errorf("bytes not minimised: have %x", data)
+		}
+		if ids := shrunk[7].([]uint64); len(ids) != 0 {
+			t.Errorf("slice not minimised: have %v", ids)
+		}
+		if _, err := method.Inputs.Pack(shrunk...); err != nil {
+			t.Errorf("shrunk values not packable: %v", err)
+		}
+	}
+}
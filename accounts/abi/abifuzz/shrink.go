@@ -0,0 +1,155 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abifuzz
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// maxShrinkSteps caps the number of candidates tried while shrinking, to avoid
+// spending unbounded time on pathological inputs.
+const maxShrinkSteps = 10000
+
+// Shrink attempts to minimise a failing set of argument values. It repeatedly
+// simplifies individual values (zeroing integers, truncating byte slices and
+// arrays, dropping elements, ...) and keeps any simplification for which the
+// failing predicate still holds. The minimised values are returned.
+//
+// The values must have been produced for the given arguments, e.g. by Values.
+func Shrink(args abi.Arguments, values []interface{}, failing func([]interface{}) bool) []interface{} {
+	current := append([]interface{}(nil), values...)
+
+	steps := 0
+	for progress := true; progress && steps < maxShrinkSteps; {
+		progress = false
+		for i, arg := range args {
+			for _, cand := range candidates(arg.Type, reflect.ValueOf(current[i])) {
+				if steps++; steps > maxShrinkSteps {
+					break
+				}
+				attempt := append([]interface{}(nil), current...)
+				attempt[i] = cand.Interface()
+				if failing(attempt) {
+					current, progress = attempt, true
+					break
+				}
+			}
+		}
+	}
+	return current
+}
+
+// candidates returns a list of simpler variations of the given value, ordered
+// from the most aggressive to the least aggressive simplification.
+func candidates(t abi.Type, val reflect.Value) []reflect.Value {
+	var cands []reflect.Value
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n := integer(val)
+		if n.Sign() == 0 {
+			return nil
+		}
+		for _, c := range []*big.Int{
+			new(big.Int),
+			new(big.Int).Quo(n, big.NewInt(2)),
+			new(big.Int).Sub(n, big.NewInt(int64(n.Sign()))),
+		} {
+			cand := reflect.New(val.Type()).Elem()
+			setInteger(cand, c)
+			cands = append(cands, cand)
+		}
+
+	case abi.BoolTy:
+		if val.Bool() {
+			cands = append(cands, reflect.ValueOf(false))
+		}
+
+	case abi.StringTy, abi.BytesTy:
+		if n := val.Len(); n > 0 {
+			cands = append(cands, val.Slice(0, 0), val.Slice(0, n/2), val.Slice(0, n-1))
+		}
+
+	case abi.AddressTy, abi.FixedBytesTy, abi.HashTy, abi.FunctionTy:
+		if !val.IsZero() {
+			cands = append(cands, reflect.Zero(val.Type()))
+		}
+
+	case abi.SliceTy:
+		n := val.Len()
+		if n > 0 {
+			cands = append(cands, val.Slice(0, 0), val.Slice(0, n/2))
+			for i := 0; i < n; i++ {
+				cand := reflect.MakeSlice(val.Type(), 0, n-1)
+				cand = reflect.AppendSlice(cand, val.Slice(0, i))
+				cand = reflect.AppendSlice(cand, val.Slice(i+1, n))
+				cands = append(cands, cand)
+			}
+		}
+		cands = append(cands, elementCandidates(*t.Elem, val)...)
+
+	case abi.ArrayTy:
+		cands = append(cands, elementCandidates(*t.Elem, val)...)
+
+	case abi.TupleTy:
+		for i, elemType := range t.TupleElems {
+			for _, elem := range candidates(*elemType, val.Field(i)) {
+				cand := reflect.New(val.Type()).Elem()
+				cand.Set(val)
+				cand.Field(i).Set(elem)
+				cands = append(cands, cand)
+			}
+		}
+	}
+	return cands
+}
+
+// elementCandidates returns the variations of an array or slice where a single
+// element is replaced by one of its simplifications.
+func elementCandidates(elemType abi.Type, val reflect.Value) []reflect.Value {
+	var cands []reflect.Value
+	for i := 0; i < val.Len(); i++ {
+		for _, elem := range candidates(elemType, val.Index(i)) {
+			var cand reflect.Value
+			if val.Kind() == reflect.Slice {
+				cand = reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+				reflect.Copy(cand, val)
+			} else {
+				cand = reflect.New(val.Type()).Elem()
+				cand.Set(val)
+			}
+			cand.Index(i).Set(elem)
+			cands = append(cands, cand)
+		}
+	}
+	return cands
+}
+
+// integer converts a native Go integer or *big.Int value into a big integer.
+func integer(val reflect.Value) *big.Int {
+	switch val.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(val.Int())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(val.Uint())
+	default:
+		return new(big.Int).Set(val.Interface().(*big.Int))
+	}
+}
@@ -163,6 +163,71 @@ func NewKeyedTransactorWithChainID(key *ecdsa.PrivateKey, chainID *big.Int) (*Tr
 	}, nil
 }
 
+// NewLockedKeyTransactor is a utility method to easily create a transaction signer
+// from a private key held in locked memory.
+func NewLockedKeyTransactor(key *crypto.LockedKey, chainID *big.Int) (*TransactOpts, error) {
+	keyAddr := key.Address()
+	if chainID == nil {
+		return nil, ErrNoChainID
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, ErrNotAuthorized
+			}
+			signature, err := key.Sign(signer.Hash(tx).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			return tx.WithSignature(signer, signature)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
+// NewHashSignerTransactor is a utility method to easily create a transaction
+// signer backed by a remote signing service, such as a cloud KMS. The given
+// context is used for all signing requests and is set as the default context
+// of the returned options.
+func NewHashSignerTransactor(ctx context.Context, rs types.HashSigner, chainID *big.Int) (*TransactOpts, error) {
+	keyAddr := rs.Address()
+	if chainID == nil {
+		return nil, ErrNoChainID
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, ErrNotAuthorized
+			}
+			return types.SignTxRemote(ctx, tx, signer, rs)
+		},
+		Context: ctx,
+	}, nil
+}
+
+// NewWalletTransactor is a utility method to easily create a transaction signer
+// from an account of any accounts.Wallet, such as a hardware wallet or a remote
+// signer session.
+func NewWalletTransactor(wallet accounts.Wallet, account accounts.Account, chainID *big.Int) (*TransactOpts, error) {
+	if chainID == nil {
+		return nil, ErrNoChainID
+	}
+	return &TransactOpts{
+		From: account.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account.Address {
+				return nil, ErrNotAuthorized
+			}
+			return wallet.SignTx(account, tx, chainID)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
 // NewClefTransactor is a utility method to easily create a transaction signer
 // with a clef backend.
 func NewClefTransactor(clef *external.ExternalSigner, account accounts.Account) *TransactOpts {
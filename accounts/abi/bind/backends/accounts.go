@@ -0,0 +1,177 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// TestMnemonic is the mnemonic from which the test accounts of the simulated
+// backend are derived. It is the well known development mnemonic also used by
+// other Ethereum tooling, so the test accounts have the same addresses.
+const TestMnemonic = "test test test test test test test test test test test junk"
+
+// TestAccountBalance is the balance test accounts are funded with.
+var TestAccountBalance = new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))
+
+var (
+	// faucetPath is the derivation path of the faucet funding the test accounts,
+	// outside of the range used by the test accounts themselves.
+	faucetPath = accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 1, 0, 0}
+
+	// faucetBalance is the genesis balance of the faucet.
+	faucetBalance = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	testSeed     []byte
+	testSeedOnce sync.Once
+)
+
+// TestAccount is a funded account of the simulated backend.
+type TestAccount struct {
+	Label   string
+	Address common.Address
+	Key     *ecdsa.PrivateKey
+	Auth    *bind.TransactOpts // Keyed transactor of the account
+}
+
+// deriveTestKey derives the key at the given path from the test mnemonic.
+func deriveTestKey(path accounts.DerivationPath) *ecdsa.PrivateKey {
+	testSeedOnce.Do(func() {
+		testSeed = bip39.NewSeed(TestMnemonic, "")
+	})
+	key, err := accounts.DeriveKey(testSeed, path)
+	if err != nil {
+		panic(err) // Cannot happen for the fixed mnemonic
+	}
+	return key
+}
+
+// withFaucet returns a copy of the genesis allocation, extended with the faucet
+// funding the test accounts.
+func withFaucet(alloc core.GenesisAlloc) core.GenesisAlloc {
+	faucet := crypto.PubkeyToAddress(deriveTestKey(faucetPath).PublicKey)
+
+	cpy := make(core.GenesisAlloc, len(alloc)+1)
+	for addr, account := range alloc {
+		cpy[addr] = account
+	}
+	if _, ok := cpy[faucet]; !ok {
+		cpy[faucet] = core.GenesisAccount{Balance: faucetBalance}
+	}
+	return cpy
+}
+
+// Accounts returns n test accounts, deterministically derived from TestMnemonic
+// at m/44'/60'/0'/0/i. Accounts not yet funded by previous calls are funded with
+// TestAccountBalance, committing a block with the funding transactions along
+// with all pending ones. The accounts are labeled "account<i>". Backends created
+// on a database with an existing genesis have no faucet and cannot fund them.
+func (b *SimulatedBackend) Accounts(n int) []*TestAccount {
+	b.mu.Lock()
+	var (
+		funded = len(b.accounts)
+		faucet = deriveTestKey(faucetPath)
+		nonce  = b.pendingState.GetNonce(crypto.PubkeyToAddress(faucet.PublicKey))
+		signer = types.LatestSigner(b.config)
+		price  = big.NewInt(params.GWei)
+		txs    []*types.Transaction
+	)
+	if baseFee := b.pendingBlock.BaseFee(); baseFee != nil {
+		price = new(big.Int).Mul(baseFee, big.NewInt(2))
+	}
+	for i := funded; i < n; i++ {
+		path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+		copy(path, accounts.DefaultBaseDerivationPath)
+		path[len(path)-1] = uint32(i)
+
+		key := deriveTestKey(path)
+		auth, _ := bind.NewKeyedTransactorWithChainID(key, b.config.ChainID)
+		account := &TestAccount{
+			Label:   fmt.Sprintf("account%d", i),
+			Address: crypto.PubkeyToAddress(key.PublicKey),
+			Key:     key,
+			Auth:    auth,
+		}
+		b.accounts = append(b.accounts, account)
+		b.labels[account.Address] = account.Label
+
+		txs = append(txs, types.MustSignNewTx(faucet, signer, &types.LegacyTx{
+			Nonce:    nonce + uint64(i-funded),
+			To:       &account.Address,
+			Value:    TestAccountBalance,
+			Gas:      params.TxGas,
+			GasPrice: price,
+		}))
+	}
+	result := make([]*TestAccount, n)
+	copy(result, b.accounts)
+	b.mu.Unlock()
+
+	if len(txs) > 0 {
+		for _, tx := range txs {
+			if err := b.SendTransaction(context.Background(), tx); err != nil {
+				panic(fmt.Sprintf("failed to fund test account: %v", err))
+			}
+		}
+		b.Commit()
+	}
+	return result
+}
+
+// Label assigns a human readable label to the address, which is used to refer
+// to the address in error messages of the backend.
+func (b *SimulatedBackend) Label(addr common.Address, label string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.labels[addr] = label
+}
+
+// Labels returns the labels of all labeled addresses, e.g. for annotating the
+// output of tracers.
+func (b *SimulatedBackend) Labels() map[common.Address]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	labels := make(map[common.Address]string, len(b.labels))
+	for addr, label := range b.labels {
+		labels[addr] = label
+	}
+	return labels
+}
+
+// describe returns the label and address of a labeled address, or just the
+// address otherwise. The caller must hold the backend lock.
+func (b *SimulatedBackend) describe(addr common.Address) string {
+	if label, ok := b.labels[addr]; ok {
+		return fmt.Sprintf("%s (%s)", label, addr.Hex())
+	}
+	return addr.Hex()
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SetLogger sets the logger which reports every call and transaction executed
+// by the backend, along with the gas used and the outcome. Calldata, results
+// and revert errors are decoded for contracts registered with RegisterABI. A
+// nil logger disables logging again.
+func (b *SimulatedBackend) SetLogger(logger log.Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.logger = logger
+}
+
+// SetWatchpoints sets the storage slots whose reads and writes are reported
+// while the backend executes transactions and calls, nil disables watching. The
+// watchpoints are invoked with the backend locked, so they must not call it.
+func (b *SimulatedBackend) SetWatchpoints(w *state.Watchpoints) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.watchpoints = w
+}
+
+// RegisterABI sets the ABI of the contract at the given address, used to
+// decode the calls and transactions sent to it in the logs of the backend.
+func (b *SimulatedBackend) RegisterABI(addr common.Address, contract *abi.ABI) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.abis[addr] = contract
+}
+
+// logCall reports the execution of a call to the logger, if one is set. The
+// caller must hold the backend lock.
+func (b *SimulatedBackend) logCall(msg string, from common.Address, to *common.Address, data []byte, res *core.ExecutionResult, err error) {
+	if b.logger == nil {
+		return
+	}
+	ctx, method := b.describeCall(from, to, data)
+	switch {
+	case err != nil:
+		b.logger.Warn(msg+" failed", append(ctx, "err", err)...)
+	case res.Failed():
+		ctx = append(ctx, "gas", res.UsedGas, "err", res.Err)
+		b.logger.Warn(msg+" failed", append(ctx, b.describeRevert(to, res.Revert())...)...)
+	default:
+		ctx = append(ctx, "gas", res.UsedGas)
+		b.logger.Info(msg, append(ctx, b.describeResult(method, res.Return())...)...)
+	}
+}
+
+// logTransaction reports the inclusion of a transaction into the pending block
+// to the logger, if one is set. The caller must hold the backend lock.
+func (b *SimulatedBackend) logTransaction(from common.Address, tx *types.Transaction, receipt *types.Receipt) {
+	if b.logger == nil {
+		return
+	}
+	ctx, _ := b.describeCall(from, tx.To(), tx.Data())
+	ctx = append(ctx, "hash", tx.Hash(), "gas", receipt.GasUsed)
+	if tx.To() == nil {
+		ctx = append(ctx, "contract", b.describe(receipt.ContractAddress))
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		b.logger.Warn("Transaction failed", ctx...)
+		return
+	}
+	b.logger.Info("Transaction", ctx...)
+}
+
+// describeCall returns the logging context of a message, decoding the method
+// and arguments if the ABI of the recipient is known.
+func (b *SimulatedBackend) describeCall(from common.Address, to *common.Address, data []byte) ([]interface{}, *abi.Method) {
+	ctx := []interface{}{"from", b.describe(from)}
+	if to == nil {
+		return append(ctx, "to", "create", "size", len(data)), nil
+	}
+	ctx = append(ctx, "to", b.describe(*to))
+	if len(data) < 4 {
+		return ctx, nil
+	}
+	if contract, ok := b.abis[*to]; ok {
+		if method, err := contract.MethodById(data[:4]); err == nil {
+			if args, err := method.Inputs.Unpack(data[4:]); err == nil {
+				return append(ctx, "method", method.Name, "args", args), method
+			}
+		}
+	}
+	return append(ctx, "selector", hexutil.Bytes(data[:4]), "calldata", hexutil.Bytes(data[4:])), nil
+}
+
+// describeResult returns the logging context of the output of a call, decoding
+// it if the called method is known.
+func (b *SimulatedBackend) describeResult(method *abi.Method, output []byte) []interface{} {
+	if method != nil {
+		if result, err := method.Outputs.Unpack(output); err == nil {
+			return []interface{}{"result", result}
+		}
+	}
+	return []interface{}{"output", hexutil.Bytes(output)}
+}
+
+// describeRevert returns the logging context of a revert, decoding the reason
+// string or the custom error of the reverted contract if its ABI is known.
+func (b *SimulatedBackend) describeRevert(to *common.Address, revert []byte) []interface{} {
+	if len(revert) == 0 {
+		return nil
+	}
+	if reason, err := abi.UnpackRevert(revert); err == nil {
+		return []interface{}{"reason", reason}
+	}
+	if to != nil && len(revert) >= 4 {
+		if contract, ok := b.abis[*to]; ok {
+			for _, e := range contract.Errors {
+				if !bytes.Equal(e.ID[:4], revert[:4]) {
+					continue
+				}
+				if args, err := e.Inputs.Unpack(revert[4:]); err == nil {
+					return []interface{}{"error", e.Name, "args", args}
+				}
+			}
+		}
+	}
+	return []interface{}{"revert", hexutil.Bytes(revert)}
+}
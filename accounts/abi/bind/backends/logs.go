@@ -0,0 +1,156 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/bitutil"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// bloomSectionSize is the number of blocks in a bloombits section of the
+	// simulated chain, smaller than on live networks so that the logs of shorter
+	// simulated histories are indexed too.
+	bloomSectionSize = 1024
+
+	// bloomConfirms is the number of blocks a section must be buried under before
+	// it is indexed. Simulated chains only reorg on request, so it is kept low.
+	bloomConfirms = 16
+
+	// bloomServiceThreads is the number of goroutines servicing the bloombits
+	// lookups of all running filters.
+	bloomServiceThreads = 4
+
+	// bloomFilterThreads is the number of goroutines used per filter to multiplex
+	// its requests onto the servicing goroutines.
+	bloomFilterThreads = 3
+
+	// bloomRetrievalBatch is the maximum number of bloom bit retrievals to service
+	// in a single batch.
+	bloomRetrievalBatch = 16
+)
+
+// startBloomHandlers starts the goroutines serving the bloombits retrievals of
+// log filters from the database.
+func (b *SimulatedBackend) startBloomHandlers() {
+	for i := 0; i < bloomServiceThreads; i++ {
+		go func() {
+			for {
+				select {
+				case <-b.closeBloomHandler:
+					return
+
+				case request := <-b.bloomRequests:
+					task := <-request
+					task.Bitsets = make([][]byte, len(task.Sections))
+					for i, section := range task.Sections {
+						head := rawdb.ReadCanonicalHash(b.database, (section+1)*bloomSectionSize-1)
+						compVector, err := rawdb.ReadBloomBits(b.database, task.Bit, section, head)
+						if err != nil {
+							task.Error = err
+							continue
+						}
+						if task.Bitsets[i], err = bitutil.DecompressBytes(compVector, bloomSectionSize/8); err != nil {
+							task.Error = err
+						}
+					}
+					request <- task
+				}
+			}
+		}()
+	}
+}
+
+// serviceFilter multiplexes the bloombits retrievals of a filter onto the bloom
+// handlers of the backend.
+func (b *SimulatedBackend) serviceFilter(ctx context.Context, session *bloombits.MatcherSession) {
+	for i := 0; i < bloomFilterThreads; i++ {
+		go session.Multiplex(bloomRetrievalBatch, time.Duration(0), b.bloomRequests)
+	}
+}
+
+// DecodedLog is a log along with its decoding by the ABI of the contract which
+// emitted it.
+type DecodedLog struct {
+	types.Log
+	Event *abi.Event             // Decoded event, nil if unknown
+	Args  map[string]interface{} // Indexed and non-indexed arguments of the event, nil if unknown
+}
+
+// LogsByTx returns the logs emitted by a pending or mined transaction. The logs
+// of contracts registered with RegisterABI are decoded, except for anonymous
+// events, which cannot be identified.
+func (b *SimulatedBackend) LogsByTx(txHash common.Hash) ([]*DecodedLog, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var receipt *types.Receipt
+	for _, r := range b.pendingReceipts {
+		if r.TxHash == txHash {
+			receipt = r
+			break
+		}
+	}
+	if receipt == nil {
+		if receipt, _, _, _ = rawdb.ReadReceipt(b.database, txHash, b.config); receipt == nil {
+			return nil, ethereum.NotFound
+		}
+	}
+	logs := make([]*DecodedLog, len(receipt.Logs))
+	for i, log := range receipt.Logs {
+		logs[i] = &DecodedLog{Log: *log}
+		logs[i].Event, logs[i].Args = b.decodeLog(log)
+	}
+	return logs, nil
+}
+
+// decodeLog decodes the event and arguments of a log, if the ABI of its emitter
+// is known. The caller must hold the backend lock.
+func (b *SimulatedBackend) decodeLog(log *types.Log) (*abi.Event, map[string]interface{}) {
+	contract, ok := b.abis[log.Address]
+	if !ok || len(log.Topics) == 0 {
+		return nil, nil
+	}
+	event, err := contract.EventByID(log.Topics[0])
+	if err != nil || event.Anonymous {
+		return nil, nil
+	}
+	args := make(map[string]interface{})
+	if len(log.Data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(args, log.Data); err != nil {
+			return nil, nil
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+		return nil, nil
+	}
+	return event, args
+}
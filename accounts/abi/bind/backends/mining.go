@@ -0,0 +1,75 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import "time"
+
+// periodicMiner commits the pending block of a simulated backend on an interval.
+type periodicMiner struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartPeriodicMining commits the pending block every interval, so that block
+// numbers and timestamps advance without the test committing blocks itself.
+// Blocks are committed whether they contain transactions or not. Calling it
+// while periodic mining is active restarts it with the new interval.
+//
+// Note, every block advances the timestamp by the simulated block time, not by
+// the interval. Use AdjustTime to shift the clock further.
+func (b *SimulatedBackend) StartPeriodicMining(interval time.Duration) {
+	b.miningLock.Lock()
+	defer b.miningLock.Unlock()
+
+	b.stopPeriodicMining()
+
+	miner := &periodicMiner{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(miner.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				b.Commit()
+			case <-miner.stop:
+				return
+			}
+		}
+	}()
+	b.miner = miner
+}
+
+// StopPeriodicMining stops committing blocks on an interval. No block is
+// committed after it returns.
+func (b *SimulatedBackend) StopPeriodicMining() {
+	b.miningLock.Lock()
+	defer b.miningLock.Unlock()
+
+	b.stopPeriodicMining()
+}
+
+func (b *SimulatedBackend) stopPeriodicMining() {
+	if b.miner == nil {
+		return
+	}
+	close(b.miner.stop)
+	<-b.miner.done
+	b.miner = nil
+}
@@ -37,7 +37,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/gasestimator"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -71,25 +73,95 @@ type SimulatedBackend struct {
 	events       *filters.EventSystem  // for filtering log events live
 	filterSystem *filters.FilterSystem // for filtering database logs
 
-	config *params.ChainConfig
+	bloomIndexer      *core.ChainIndexer             // Bloombits indexer of the logs in the chain
+	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
+	closeBloomHandler chan struct{}
+
+	config   *params.ChainConfig
+	vmConfig vm.Config
+
+	accounts []*TestAccount            // Test accounts funded so far
+	labels   map[common.Address]string // Human readable labels of addresses
+
+	logger log.Logger                  // Logger of calls and transactions, nil if disabled
+	abis   map[common.Address]*abi.ABI // Contract ABIs to decode logged calls with
+
+	watchpoints *state.Watchpoints // Storage slots whose accesses are reported, nil if none
+
+	miner      *periodicMiner // Periodic block committer, nil if not mining
+	miningLock sync.Mutex
 }
 
 // NewSimulatedBackendWithDatabase creates a new binding backend based on the given database
 // and uses a simulated blockchain for testing purposes.
 // A simulated backend always uses chainID 1337.
 func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	return newSimulatedBackend(database, params.AllEthashProtocolChanges, alloc, gasLimit, vm.Config{})
+}
+
+// NewSimulatedBackendWithVMConfig creates a new binding backend using a simulated
+// blockchain, whose EVM is customized with the given config. This allows enabling
+// extra precompiles, e.g. vm.P256Verify for testing passkey based wallets.
+// A simulated backend always uses chainID 1337.
+func NewSimulatedBackendWithVMConfig(alloc core.GenesisAlloc, gasLimit uint64, vmConfig vm.Config) *SimulatedBackend {
+	return newSimulatedBackend(rawdb.NewMemoryDatabase(), params.AllEthashProtocolChanges, alloc, gasLimit, vmConfig)
+}
+
+// NewSimulatedBackendWithCheatcodes creates a new binding backend using a simulated
+// blockchain with the Foundry style cheatcode precompile installed at
+// vm.CheatcodeAddress, so Solidity tests relying on cheatcodes such as warp, deal,
+// prank or expectRevert can run unmodified. The cheat address is given a dummy
+// code to pass Solidity's existence checks of call targets.
+// A simulated backend always uses chainID 1337.
+func NewSimulatedBackendWithCheatcodes(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	cpy := make(core.GenesisAlloc, len(alloc)+1)
+	for addr, account := range alloc {
+		cpy[addr] = account
+	}
+	cpy[vm.CheatcodeAddress] = core.GenesisAccount{Code: []byte{byte(vm.STOP)}, Balance: new(big.Int)}
+
+	vmConfig := vm.Config{
+		Precompiles: map[common.Address]vm.PrecompiledContract{vm.CheatcodeAddress: new(vm.Cheatcodes)},
+	}
+	return newSimulatedBackend(rawdb.NewMemoryDatabase(), params.AllEthashProtocolChanges, cpy, gasLimit, vmConfig)
+}
+
+// NewSimulatedBackendWithChainConfig creates a new binding backend using a simulated
+// blockchain running the given chain config. This allows testing contracts against
+// experimental forks before they are activated, e.g. by setting EOFTime. As the
+// simulated blockchain uses ethash, the config must not enable Shanghai or later.
+func NewSimulatedBackendWithChainConfig(config *params.ChainConfig, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	return newSimulatedBackend(rawdb.NewMemoryDatabase(), config, alloc, gasLimit, vm.Config{})
+}
+
+func newSimulatedBackend(database ethdb.Database, config *params.ChainConfig, alloc core.GenesisAlloc, gasLimit uint64, vmConfig vm.Config) *SimulatedBackend {
+	// Only fund the faucet if the genesis is created here, a database already
+	// holding a chain must keep matching the caller's genesis.
+	if rawdb.ReadCanonicalHash(database, 0) == (common.Hash{}) {
+		alloc = withFaucet(alloc)
+	}
 	genesis := core.Genesis{
-		Config:   params.AllEthashProtocolChanges,
+		Config:   config,
 		GasLimit: gasLimit,
 		Alloc:    alloc,
 	}
-	blockchain, _ := core.NewBlockChain(database, nil, &genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	blockchain, _ := core.NewBlockChain(database, nil, &genesis, nil, ethash.NewFaker(), vmConfig, nil, nil)
 
 	backend := &SimulatedBackend{
 		database:   database,
 		blockchain: blockchain,
 		config:     genesis.Config,
-	}
+		vmConfig:   vmConfig,
+		labels: map[common.Address]string{
+			crypto.PubkeyToAddress(deriveTestKey(faucetPath).PublicKey): "faucet",
+		},
+		abis:              make(map[common.Address]*abi.ABI),
+		bloomIndexer:      core.NewBloomIndexer(database, bloomSectionSize, bloomConfirms),
+		bloomRequests:     make(chan chan *bloombits.Retrieval),
+		closeBloomHandler: make(chan struct{}),
+	}
+	backend.bloomIndexer.Start(blockchain)
+	backend.startBloomHandlers()
 
 	filterBackend := &filterBackend{database, blockchain, backend}
 	backend.filterSystem = filters.NewFilterSystem(filterBackend, filters.Config{})
@@ -109,8 +181,18 @@ func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBac
 	return NewSimulatedBackendWithDatabase(rawdb.NewMemoryDatabase(), alloc, gasLimit)
 }
 
-// Close terminates the underlying blockchain's update loop.
+// Close stops periodic mining and log indexing, and terminates the underlying
+// blockchain's update loop.
 func (b *SimulatedBackend) Close() error {
+	b.StopPeriodicMining()
+
+	select {
+	case <-b.closeBloomHandler:
+		return nil // Already closed
+	default:
+	}
+	b.bloomIndexer.Close()
+	close(b.closeBloomHandler)
 	b.blockchain.Stop()
 	return nil
 }
@@ -444,7 +526,9 @@ func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallM
 	if err != nil {
 		return nil, err
 	}
+	stateDB.SetWatchpoints(b.watchpoints)
 	res, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), stateDB)
+	b.logCall("Call", call.From, call.To, call.Data, res, err)
 	if err != nil {
 		return nil, err
 	}
@@ -461,7 +545,11 @@ func (b *SimulatedBackend) PendingCallContract(ctx context.Context, call ethereu
 	defer b.mu.Unlock()
 	defer b.pendingState.RevertToSnapshot(b.pendingState.Snapshot())
 
+	b.pendingState.SetWatchpoints(b.watchpoints)
+	defer b.pendingState.SetWatchpoints(nil)
+
 	res, err := b.callContract(ctx, call, b.pendingBlock.Header(), b.pendingState)
+	b.logCall("Pending call", call.From, call.To, call.Data, res, err)
 	if err != nil {
 		return nil, err
 	}
@@ -505,107 +593,38 @@ func (b *SimulatedBackend) EstimateGas(ctx context.Context, call ethereum.CallMs
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Determine the lowest and highest possible gas limits to binary search in between
-	var (
-		lo  uint64 = params.TxGas - 1
-		hi  uint64
-		cap uint64
-	)
-	if call.Gas >= params.TxGas {
-		hi = call.Gas
-	} else {
-		hi = b.pendingBlock.GasLimit()
-	}
-	// Normalize the max fee per gas the call is willing to spend.
-	var feeCap *big.Int
-	if call.GasPrice != nil && (call.GasFeeCap != nil || call.GasTipCap != nil) {
-		return 0, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
-	} else if call.GasPrice != nil {
-		feeCap = call.GasPrice
-	} else if call.GasFeeCap != nil {
-		feeCap = call.GasFeeCap
-	} else {
-		feeCap = common.Big0
-	}
-	// Recap the highest gas allowance with account's balance.
-	if feeCap.BitLen() != 0 {
-		balance := b.pendingState.GetBalance(call.From) // from can't be nil
-		available := new(big.Int).Set(balance)
-		if call.Value != nil {
-			if call.Value.Cmp(available) >= 0 {
-				return 0, core.ErrInsufficientFundsForTransfer
-			}
-			available.Sub(available, call.Value)
-		}
-		allowance := new(big.Int).Div(available, feeCap)
-		if allowance.IsUint64() && hi > allowance.Uint64() {
-			transfer := call.Value
-			if transfer == nil {
-				transfer = new(big.Int)
-			}
-			log.Warn("Gas estimation capped by limited funds", "original", hi, "balance", balance,
-				"sent", transfer, "feecap", feeCap, "fundable", allowance)
-			hi = allowance.Uint64()
-		}
-	}
-	cap = hi
-
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) (bool, *core.ExecutionResult, error) {
-		call.Gas = gas
-
-		snapshot := b.pendingState.Snapshot()
-		res, err := b.callContract(ctx, call, b.pendingBlock.Header(), b.pendingState)
-		b.pendingState.RevertToSnapshot(snapshot)
-
-		if err != nil {
-			if errors.Is(err, core.ErrIntrinsicGas) {
-				return true, nil, nil // Special case, raise gas limit
-			}
-			return true, nil, err // Bail out
-		}
-		return res.Failed(), res, nil
-	}
-	// Execute the binary search and hone in on an executable gas limit
-	for lo+1 < hi {
-		mid := (hi + lo) / 2
-		failed, _, err := executable(mid)
-
-		// If the error is not nil(consensus error), it means the provided message
-		// call or transaction will never be accepted no matter how much gas it is
-		// assigned. Return the error directly, don't struggle any more
-		if err != nil {
-			return 0, err
-		}
-		if failed {
-			lo = mid
-		} else {
-			hi = mid
-		}
+	msg, err := b.callMessage(call)
+	if err != nil {
+		return 0, err
 	}
-	// Reject the transaction as invalid if it still fails at the highest allowance
-	if hi == cap {
-		failed, result, err := executable(hi)
-		if err != nil {
-			return 0, err
-		}
-		if failed {
-			if result != nil && result.Err != vm.ErrOutOfGas {
-				if len(result.Revert()) > 0 {
-					return 0, newRevertError(result)
-				}
-				return 0, result.Err
-			}
-			// Otherwise, the specified gas cap is too low
-			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+	// If no usable gas limit was requested, search up to the block gas limit
+	if call.Gas < params.TxGas {
+		msg.GasLimit = 0
+	}
+	opts := &gasestimator.Options{
+		Config: b.config,
+		Chain:  b.blockchain,
+		Header: b.pendingBlock.Header(),
+		State:  b.pendingState,
+		VMConfig: vm.Config{
+			Debug:       b.vmConfig.Debug,
+			Tracer:      b.vmConfig.Tracer,
+			Precompiles: b.vmConfig.Precompiles,
+		},
+	}
+	estimate, revert, err := gasestimator.Estimate(ctx, msg, opts, 0)
+	if err != nil {
+		if len(revert) > 0 {
+			return 0, newRevertError(&core.ExecutionResult{Err: vm.ErrExecutionReverted, ReturnData: revert})
 		}
+		return 0, err
 	}
-	return hi, nil
+	return estimate, nil
 }
 
-// callContract implements common code between normal and pending contract calls.
-// state is modified during execution, make sure to copy it if necessary.
-func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallMsg, header *types.Header, stateDB *state.StateDB) (*core.ExecutionResult, error) {
+// callMessage converts a contract call into a message executable on top of the
+// current chain head, filling in the gas price fields the active fork requires.
+func (b *SimulatedBackend) callMessage(call ethereum.CallMsg) (*core.Message, error) {
 	// Gas prices post 1559 need to be initialized
 	if call.GasPrice != nil && (call.GasFeeCap != nil || call.GasTipCap != nil) {
 		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
@@ -644,13 +663,7 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 	if call.Value == nil {
 		call.Value = new(big.Int)
 	}
-
-	// Set infinite balance to the fake caller account.
-	from := stateDB.GetOrNewStateObject(call.From)
-	from.SetBalance(math.MaxBig256)
-
-	// Execute the call.
-	msg := &core.Message{
+	return &core.Message{
 		From:              call.From,
 		To:                call.To,
 		Value:             call.Value,
@@ -661,13 +674,31 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 		Data:              call.Data,
 		AccessList:        call.AccessList,
 		SkipAccountChecks: true,
+	}, nil
+}
+
+// callContract implements common code between normal and pending contract calls.
+// state is modified during execution, make sure to copy it if necessary.
+func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallMsg, header *types.Header, stateDB *state.StateDB) (*core.ExecutionResult, error) {
+	msg, err := b.callMessage(call)
+	if err != nil {
+		return nil, err
 	}
+	// Set infinite balance to the fake caller account.
+	from := stateDB.GetOrNewStateObject(call.From)
+	from.SetBalance(math.MaxBig256)
 
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
 	txContext := core.NewEVMTxContext(msg)
 	evmContext := core.NewEVMBlockContext(header, b.blockchain, nil)
-	vmEnv := vm.NewEVM(evmContext, txContext, stateDB, b.config, vm.Config{NoBaseFee: true})
+	vmConfig := vm.Config{
+		Debug:       b.vmConfig.Debug,
+		Tracer:      b.vmConfig.Tracer,
+		NoBaseFee:   true,
+		Precompiles: b.vmConfig.Precompiles,
+	}
+	vmEnv := vm.NewEVM(evmContext, txContext, stateDB, b.config, vmConfig)
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.ApplyMessage(vmEnv, msg, gasPool)
@@ -691,13 +722,15 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	}
 	nonce := b.pendingState.GetNonce(sender)
 	if tx.Nonce() != nonce {
-		return fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce)
+		return fmt.Errorf("invalid transaction nonce for %s: got %d, want %d", b.describe(sender), tx.Nonce(), nonce)
 	}
 	// Include tx in chain
 	blocks, receipts := core.GenerateChain(b.config, block, ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTxWithChain(b.blockchain, tx)
 		}
+		// Only the new transaction is watched, the pending ones were before
+		block.SetWatchpoints(b.watchpoints)
 		block.AddTxWithChain(b.blockchain, tx)
 	})
 	stateDB, _ := b.blockchain.State()
@@ -705,6 +738,12 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), stateDB.Database(), nil)
 	b.pendingReceipts = receipts[0]
+
+	// The receipts were created before the block was sealed, with the hashes of
+	// its intermediate headers
+	b.pendingReceipts.DeriveFields(b.config, b.pendingBlock.Hash(), b.pendingBlock.NumberU64(), b.pendingBlock.BaseFee(), b.pendingBlock.Transactions())
+
+	b.logTransaction(sender, tx, b.pendingReceipts[len(b.pendingReceipts)-1])
 	return nil
 }
 
@@ -834,8 +873,8 @@ func (b *SimulatedBackend) Blockchain() *core.BlockChain {
 	return b.blockchain
 }
 
-// filterBackend implements filters.Backend to support filtering for logs without
-// taking bloom-bits acceleration structures into account.
+// filterBackend implements filters.Backend to support filtering for logs, using
+// the bloombits sections indexed by the simulated backend.
 type filterBackend struct {
 	db      ethdb.Database
 	bc      *core.BlockChain
@@ -912,10 +951,13 @@ func (fb *filterBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event
 	return nullSubscription()
 }
 
-func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) BloomStatus() (uint64, uint64) {
+	sections, _, _ := fb.backend.bloomIndexer.Sections()
+	return bloomSectionSize, sections
+}
 
 func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
-	panic("not supported")
+	fb.backend.serviceFilter(ctx, ms)
 }
 
 func (fb *filterBackend) ChainConfig() *params.ChainConfig {
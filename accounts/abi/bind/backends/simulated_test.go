@@ -32,9 +32,13 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestSimulatedBackend(t *testing.T) {
@@ -141,6 +145,104 @@ func TestNewSimulatedBackend(t *testing.T) {
 	}
 }
 
+func TestSimulatedBackendAccounts(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	accounts := sim.Accounts(2)
+	if len(accounts) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(accounts))
+	}
+	// The accounts match the well known development accounts
+	want := []common.Address{
+		common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+		common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+	}
+	for i, account := range accounts {
+		if account.Address != want[i] || account.Auth.From != want[i] {
+			t.Errorf("account %d: address mismatch: have %x, want %x", i, account.Address, want[i])
+		}
+		balance, _ := sim.BalanceAt(context.Background(), account.Address, nil)
+		if balance.Cmp(TestAccountBalance) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want %v", i, balance, TestAccountBalance)
+		}
+	}
+	// Requesting more accounts returns the same first ones, funding only new ones
+	more := sim.Accounts(3)
+	if more[0] != accounts[0] || more[1] != accounts[1] {
+		t.Error("accounts not stable across calls")
+	}
+	for i, account := range more {
+		balance, _ := sim.BalanceAt(context.Background(), account.Address, nil)
+		if balance.Cmp(TestAccountBalance) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want %v", i, balance, TestAccountBalance)
+		}
+	}
+	// Labels are used in error messages
+	sim.Label(common.Address{0x01}, "token")
+	if labels := sim.Labels(); labels[more[2].Address] != "account2" || labels[common.Address{0x01}] != "token" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+	tx := types.MustSignNewTx(more[0].Key, types.LatestSigner(sim.config), &types.LegacyTx{
+		Nonce:    5,
+		To:       &common.Address{0x01},
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.GWei),
+	})
+	err := sim.SendTransaction(context.Background(), tx)
+	if err == nil || !strings.Contains(err.Error(), "account0 (0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266)") {
+		t.Errorf("unlabeled error: %v", err)
+	}
+}
+
+func TestSimulatedBackendEOF(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	config := *params.AllEthashProtocolChanges
+	config.EOFTime = new(uint64)
+
+	sim := NewSimulatedBackendWithChainConfig(&config, core.GenesisAlloc{testAddr: {Balance: big.NewInt(10000000000000000)}}, 10000000)
+	defer sim.Close()
+
+	// Deploy a container whose function doubles 0x2a, copying it from the data
+	// section of the initcode
+	runtime := (&vm.Container{
+		Types: []*vm.FunctionMetadata{{MaxStackHeight: 2}, {Input: 1, Output: 1, MaxStackHeight: 2}},
+		Code:  [][]byte{common.FromHex("602ab0000160005260206000f3"), common.FromHex("800160015d0001feb1")},
+	}).MarshalBinary()
+	initcode := &vm.Container{
+		Types: []*vm.FunctionMetadata{{MaxStackHeight: 3}},
+		Code:  [][]byte{common.FromHex("60ff60ff60003960ff6000f3")},
+		Data:  runtime,
+	}
+	size := len(initcode.MarshalBinary())
+	initcode.Code[0][1], initcode.Code[0][3], initcode.Code[0][8] = byte(len(runtime)), byte(size-len(runtime)), byte(len(runtime))
+
+	head, _ := sim.HeaderByNumber(context.Background(), nil)
+	gasPrice := new(big.Int).Add(head.BaseFee, big.NewInt(1))
+	tx := types.NewContractCreation(0, new(big.Int), 100000, gasPrice, initcode.MarshalBinary())
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	sim.Commit()
+
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("deployment failed: %v", err)
+	}
+	code, _ := sim.CodeAt(context.Background(), receipt.ContractAddress, nil)
+	if !bytes.Equal(code, runtime) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", code, runtime)
+	}
+	res, err := sim.CallContract(context.Background(), ethereum.CallMsg{To: &receipt.ContractAddress}, nil)
+	if err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	if want := common.LeftPadBytes([]byte{0x54}, 32); !bytes.Equal(res, want) {
+		t.Errorf("call result mismatch: have %x, want %x", res, want)
+	}
+}
+
 func TestAdjustTime(t *testing.T) {
 	sim := NewSimulatedBackend(
 		core.GenesisAlloc{}, 10000000,
@@ -1397,3 +1499,326 @@ func TestAdjustTimeAfterFork(t *testing.T) {
 		t.Errorf("failed to build block on fork")
 	}
 }
+
+func TestLogging(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	var records []*log.Record
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	sim.SetLogger(logger)
+
+	parsed, _ := abi.JSON(strings.NewReader(abiJSON))
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	addr, _, contract, err := bind.DeployContract(auth, parsed, common.FromHex(abiBin), sim)
+	if err != nil {
+		t.Fatalf("could not deploy contract: %v", err)
+	}
+	sim.Commit()
+	sim.RegisterABI(addr, &parsed)
+
+	var res []interface{}
+	if err := contract.Call(&bind.CallOpts{From: testAddr}, &res, "receive", []byte("X")); err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	sim.SetLogger(nil)
+	if _, err := contract.Transact(auth, "receive", []byte("X")); err != nil {
+		t.Fatalf("could not transact: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("have %d log records, want 2", len(records))
+	}
+	context := func(r *log.Record) map[string]interface{} {
+		ctx := make(map[string]interface{})
+		for i := 0; i < len(r.Ctx); i += 2 {
+			ctx[r.Ctx[i].(string)] = r.Ctx[i+1]
+		}
+		return ctx
+	}
+	if records[0].Msg != "Transaction" {
+		t.Errorf("deployment message mismatch: have %q, want %q", records[0].Msg, "Transaction")
+	}
+	if have := context(records[0])["contract"]; have != addr.Hex() {
+		t.Errorf("deployed contract mismatch: have %v, want %v", have, addr.Hex())
+	}
+	if records[1].Msg != "Call" {
+		t.Errorf("call message mismatch: have %q, want %q", records[1].Msg, "Call")
+	}
+	ctx := context(records[1])
+	if ctx["method"] != "receive" {
+		t.Errorf("method mismatch: have %v, want %v", ctx["method"], "receive")
+	}
+	if have, want := ctx["result"], []interface{}{"hello world"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("result mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestCheatcodes(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := NewSimulatedBackendWithCheatcodes(core.GenesisAlloc{
+		testAddr: {Balance: big.NewInt(10000000000000000)},
+	}, 10000000)
+	defer sim.Close()
+
+	// Deal some funds to an account via a cheatcode transaction
+	var (
+		rich  = common.HexToAddress("0x1337")
+		input = append(crypto.Keccak256([]byte("deal(address,uint256)"))[:4], common.LeftPadBytes(rich.Bytes(), 32)...)
+	)
+	input = append(input, common.LeftPadBytes([]byte{0x01, 0x00}, 32)...)
+
+	head, _ := sim.HeaderByNumber(context.Background(), nil)
+	gasPrice := new(big.Int).Add(head.BaseFee, big.NewInt(1))
+	tx, _ := types.SignTx(types.NewTransaction(0, vm.CheatcodeAddress, new(big.Int), 100000, gasPrice, input), types.HomesteadSigner{}, testKey)
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send cheatcode transaction: %v", err)
+	}
+	sim.Commit()
+
+	if receipt, _ := sim.TransactionReceipt(context.Background(), tx.Hash()); receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("cheatcode transaction failed: %v", receipt)
+	}
+	if balance, _ := sim.BalanceAt(context.Background(), rich, nil); balance.Uint64() != 0x100 {
+		t.Fatalf("balance mismatch: have %v, want 256", balance)
+	}
+	if code, _ := sim.CodeAt(context.Background(), vm.CheatcodeAddress, nil); len(code) == 0 {
+		t.Fatalf("cheatcode address has no code")
+	}
+}
+
+func TestPeriodicMining(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	// Blocks must be mined without committing, including sent transactions
+	head, _ := sim.HeaderByNumber(context.Background(), nil)
+	gasPrice := new(big.Int).Add(head.BaseFee, big.NewInt(1))
+	tx, _ := types.SignTx(types.NewTransaction(0, testAddr, big.NewInt(1000), params.TxGas, gasPrice, nil), types.HomesteadSigner{}, testKey)
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	sim.StartPeriodicMining(10 * time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.blockchain.CurrentBlock().Number.Uint64() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("blocks not mined: head %d", sim.blockchain.CurrentBlock().Number)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sim.StopPeriodicMining()
+
+	if _, err := sim.TransactionReceipt(context.Background(), tx.Hash()); err != nil {
+		t.Fatalf("transaction not mined: %v", err)
+	}
+	first, _ := sim.HeaderByNumber(context.Background(), big.NewInt(1))
+	latest, _ := sim.HeaderByNumber(context.Background(), nil)
+	if latest.Time <= first.Time {
+		t.Fatalf("timestamps not advancing: block 1 at %d, head at %d", first.Time, latest.Time)
+	}
+	// No blocks must be mined after stopping
+	number := sim.blockchain.CurrentBlock().Number.Uint64()
+	time.Sleep(50 * time.Millisecond)
+	if have := sim.blockchain.CurrentBlock().Number.Uint64(); have != number {
+		t.Fatalf("blocks mined after stopping: have head %d, want %d", have, number)
+	}
+	// Stopping again and closing while stopped must not block
+	sim.StopPeriodicMining()
+	sim.StartPeriodicMining(time.Hour)
+}
+
+func TestPendingLogFields(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	parsed, _ := abi.JSON(strings.NewReader(callableAbi))
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	_, _, contract, err := bind.DeployContract(auth, parsed, common.FromHex(callableBin), sim)
+	if err != nil {
+		t.Fatalf("deploying contract: %v", err)
+	}
+	sim.Commit()
+
+	for i := 0; i < 3; i++ {
+		if _, err := contract.Transact(auth, "Call"); err != nil {
+			t.Fatalf("transacting: %v", err)
+		}
+	}
+	check := func(logs []types.Log, hash common.Hash) {
+		if len(logs) != 3 {
+			t.Fatalf("log count mismatch: have %d, want 3", len(logs))
+		}
+		for i, log := range logs {
+			if log.BlockHash != hash {
+				t.Errorf("log %d: block hash mismatch: have %x, want %x", i, log.BlockHash, hash)
+			}
+			if log.TxIndex != uint(i) || log.Index != uint(i) {
+				t.Errorf("log %d: index mismatch: have tx %d, log %d", i, log.TxIndex, log.Index)
+			}
+		}
+	}
+	pending := big.NewInt(rpc.PendingBlockNumber.Int64())
+	logs, err := sim.FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: pending, ToBlock: pending})
+	if err != nil {
+		t.Fatalf("filtering pending logs: %v", err)
+	}
+	check(logs, sim.pendingBlock.Hash())
+
+	hash := sim.Commit()
+	if logs, err = sim.FilterLogs(context.Background(), ethereum.FilterQuery{BlockHash: &hash}); err != nil {
+		t.Fatalf("filtering logs: %v", err)
+	}
+	check(logs, hash)
+}
+
+func TestFilterLogsIndexed(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	parsed, _ := abi.JSON(strings.NewReader(callableAbi))
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	addr, _, contract, err := bind.DeployContract(auth, parsed, common.FromHex(callableBin), sim)
+	if err != nil {
+		t.Fatalf("deploying contract: %v", err)
+	}
+	// Emit logs both in the first indexed section and after it
+	var want []common.Hash
+	for i := 1; i < bloomSectionSize+bloomConfirms+10; i++ {
+		if i == 100 || i == bloomSectionSize+5 {
+			tx, err := contract.Transact(auth, "Call")
+			if err != nil {
+				t.Fatalf("transacting: %v", err)
+			}
+			want = append(want, tx.Hash())
+		}
+		sim.Commit()
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if sections, _, _ := sim.bloomIndexer.Sections(); sections > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first section not indexed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	logs, err := sim.FilterLogs(context.Background(), ethereum.FilterQuery{Addresses: []common.Address{addr}})
+	if err != nil {
+		t.Fatalf("filtering logs: %v", err)
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if log.TxHash != want[i] {
+			t.Errorf("log %d: transaction mismatch: have %x, want %x", i, log.TxHash, want[i])
+		}
+	}
+	if logs, _ = sim.FilterLogs(context.Background(), ethereum.FilterQuery{Addresses: []common.Address{testAddr}}); len(logs) != 0 {
+		t.Fatalf("logs of other address found: %d", len(logs))
+	}
+}
+
+func TestLogsByTx(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	parsed, _ := abi.JSON(strings.NewReader(abiJSON))
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	addr, _, contract, err := bind.DeployContract(auth, parsed, common.FromHex(abiBin), sim)
+	if err != nil {
+		t.Fatalf("deploying contract: %v", err)
+	}
+	sim.Commit()
+
+	tx, err := contract.Transact(auth, "receive", []byte("memo"))
+	if err != nil {
+		t.Fatalf("transacting: %v", err)
+	}
+	// Logs of contracts without known ABI are not decoded
+	logs, err := sim.LogsByTx(tx.Hash())
+	if err != nil {
+		t.Fatalf("retrieving pending logs: %v", err)
+	}
+	if len(logs) != 2 || logs[0].Event != nil || logs[0].Args != nil {
+		t.Fatalf("undecodable logs mismatch: %v", logs)
+	}
+	sim.RegisterABI(addr, &parsed)
+	sim.Commit()
+
+	if logs, err = sim.LogsByTx(tx.Hash()); err != nil {
+		t.Fatalf("retrieving logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("log count mismatch: have %d, want 2", len(logs))
+	}
+	if logs[0].Event == nil || logs[0].Event.Name != "received" {
+		t.Fatalf("first event mismatch: have %v, want received", logs[0].Event)
+	}
+	if memo, _ := logs[0].Args["memo"].([]byte); string(memo) != "memo" {
+		t.Errorf("memo mismatch: have %v, want %q", logs[0].Args["memo"], "memo")
+	}
+	if logs[1].Event == nil || logs[1].Event.Name != "receivedAddr" || logs[1].Args["sender"] != testAddr {
+		t.Errorf("second event mismatch: have %v %v", logs[1].Event, logs[1].Args)
+	}
+	if logs[1].TxHash != tx.Hash() || logs[1].Index != 1 {
+		t.Errorf("log fields mismatch: have tx %x, index %d", logs[1].TxHash, logs[1].Index)
+	}
+	if _, err := sim.LogsByTx(common.Hash{1}); err != ethereum.NotFound {
+		t.Fatalf("unknown transaction: have error %v, want %v", err, ethereum.NotFound)
+	}
+}
+
+func TestWatchpoints(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	// The contract stores 42 in slot 0 on deployment and returns it on calls
+	var (
+		code     = common.FromHex("602a600055600b6011600039600b6000f360005460005260206000f3")
+		contract = crypto.CreateAddress(testAddr, 0)
+		events   []state.WatchEvent
+	)
+	w := state.NewWatchpoints(func(ev *state.WatchEvent) { events = append(events, *ev) })
+	w.Add(contract, common.Hash{})
+	sim.SetWatchpoints(w)
+
+	gasPrice, _ := sim.SuggestGasPrice(context.Background())
+	tx, _ := types.SignTx(types.NewContractCreation(0, big.NewInt(0), 100000, gasPrice, code), types.HomesteadSigner{}, testKey)
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("sending transaction: %v", err)
+	}
+	var written bool
+	for _, ev := range events {
+		if ev.Kind == state.WatchWrite && ev.Value == common.BigToHash(big.NewInt(42)) && ev.TxHash == tx.Hash() {
+			written = true
+		}
+	}
+	if !written {
+		t.Fatalf("write not reported: %+v", events)
+	}
+	// Pending calls must report their reads
+	events = nil
+	if _, err := sim.PendingCallContract(context.Background(), ethereum.CallMsg{From: testAddr, To: &contract}); err != nil {
+		t.Fatalf("calling contract: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != state.WatchRead || events[0].Value != common.BigToHash(big.NewInt(42)) {
+		t.Fatalf("read not reported: %+v", events)
+	}
+	// Committing the block must not report the executed transactions again
+	events = nil
+	sim.Commit()
+	if len(events) != 0 {
+		t.Fatalf("accesses reported on commit: %+v", events)
+	}
+}
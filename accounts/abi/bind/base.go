@@ -27,9 +27,11 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 const basefeeWiggleMultiplier = 2
@@ -112,6 +114,8 @@ type BoundContract struct {
 	caller     ContractCaller     // Read interface to interact with the blockchain
 	transactor ContractTransactor // Write interface to interact with the blockchain
 	filterer   ContractFilterer   // Event filtering to interact with the blockchain
+
+	logger log.Logger // Logger to report all interactions to, nil if disabled
 }
 
 // NewBoundContract creates a low level contract interface through which calls
@@ -126,6 +130,13 @@ func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller
 	}
 }
 
+// SetLogger sets the logger which reports every call and transaction made
+// through the contract along with the decoded method, arguments and outcome.
+// A nil logger disables logging.
+func (c *BoundContract) SetLogger(logger log.Logger) {
+	c.logger = logger
+}
+
 // DeployContract deploys a contract onto the Ethereum blockchain and binds the
 // deployment address with a Go wrapper.
 func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
@@ -156,6 +167,25 @@ func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method stri
 	if results == nil {
 		results = new([]interface{})
 	}
+	err := c.call(opts, results, method, params...)
+	if c.logger != nil {
+		ctx := []interface{}{"contract", c.address, "method", method, "args", params}
+		if opts.Pending {
+			ctx = append(ctx, "pending", true)
+		} else if opts.BlockNumber != nil {
+			ctx = append(ctx, "block", opts.BlockNumber)
+		}
+		if err != nil {
+			c.logger.Warn("Contract call failed", append(ctx, "err", err)...)
+		} else {
+			c.logger.Info("Contract call", append(ctx, "result", *results)...)
+		}
+	}
+	return err
+}
+
+// call is the implementation of Call without logging.
+func (c *BoundContract) call(opts *CallOpts, results *[]interface{}, method string, params ...interface{}) error {
 	// Pack the input, call and unpack the results
 	input, err := c.abi.Pack(method, params...)
 	if err != nil {
@@ -174,7 +204,7 @@ func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method stri
 		}
 		output, err = pb.PendingCallContract(ctx, msg)
 		if err != nil {
-			return err
+			return c.wrapRevert(err)
 		}
 		if len(output) == 0 {
 			// Make sure we have a contract to operate on, and bail out otherwise.
@@ -187,7 +217,7 @@ func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method stri
 	} else {
 		output, err = c.caller.CallContract(ctx, msg, opts.BlockNumber)
 		if err != nil {
-			return err
+			return c.wrapRevert(err)
 		}
 		if len(output) == 0 {
 			// Make sure we have a contract to operate on, and bail out otherwise.
@@ -208,6 +238,46 @@ func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method stri
 	return c.abi.UnpackIntoInterface(res[0], method, output)
 }
 
+// RevertError is returned by contract calls which were reverted with revert
+// data, carrying the data decoded against the contract ABI.
+type RevertError struct {
+	Err    error       // Error returned by the backend
+	Revert *abi.Revert // Decoded revert data
+}
+
+// Error implements error.
+func (e *RevertError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the backend.
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// wrapRevert wraps a call error carrying hex encoded revert data, as returned
+// by both RPC and simulated backends, into a RevertError. Other errors are
+// returned unchanged.
+func (c *BoundContract) wrapRevert(err error) error {
+	var de interface{ ErrorData() interface{} }
+	if !errors.As(err, &de) {
+		return err
+	}
+	hex, ok := de.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, derr := hexutil.Decode(hex)
+	if derr != nil || len(data) == 0 {
+		return err
+	}
+	revert, derr := c.abi.UnpackRevert(data)
+	if derr != nil {
+		return err
+	}
+	return &RevertError{Err: err, Revert: revert}
+}
+
 // Transact invokes the (paid) contract method with params as input values.
 func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
 	// Otherwise pack up the parameters and invoke the contract
@@ -217,7 +287,9 @@ func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...in
 	}
 	// todo(rjl493456442) check the method is payable or not,
 	// reject invalid transaction at the first place
-	return c.transact(opts, &c.address, input)
+	tx, err := c.transact(opts, &c.address, input)
+	c.logTransact(tx, err, "method", method, "args", params)
+	return tx, err
 }
 
 // RawTransact initiates a transaction with the given raw calldata as the input.
@@ -225,7 +297,9 @@ func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...in
 func (c *BoundContract) RawTransact(opts *TransactOpts, calldata []byte) (*types.Transaction, error) {
 	// todo(rjl493456442) check the method is payable or not,
 	// reject invalid transaction at the first place
-	return c.transact(opts, &c.address, calldata)
+	tx, err := c.transact(opts, &c.address, calldata)
+	c.logTransact(tx, err, "calldata", hexutil.Bytes(calldata))
+	return tx, err
 }
 
 // Transfer initiates a plain transaction to move funds to the contract, calling
@@ -233,7 +307,9 @@ func (c *BoundContract) RawTransact(opts *TransactOpts, calldata []byte) (*types
 func (c *BoundContract) Transfer(opts *TransactOpts) (*types.Transaction, error) {
 	// todo(rjl493456442) check the payable fallback or receive is defined
 	// or not, reject invalid transaction at the first place
-	return c.transact(opts, &c.address, nil)
+	tx, err := c.transact(opts, &c.address, nil)
+	c.logTransact(tx, err, "value", opts.Value)
+	return tx, err
 }
 
 func (c *BoundContract) createDynamicTx(opts *TransactOpts, contract *common.Address, input []byte, head *types.Header) (*types.Transaction, error) {
@@ -406,6 +482,20 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	return signedTx, nil
 }
 
+// logTransact reports the outcome of a transaction made through the contract
+// to the logger, if one is set.
+func (c *BoundContract) logTransact(tx *types.Transaction, err error, ctx ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	ctx = append([]interface{}{"contract", c.address}, ctx...)
+	if err != nil {
+		c.logger.Warn("Contract transaction failed", append(ctx, "err", err)...)
+		return
+	}
+	c.logger.Info("Contract transaction", append(ctx, "hash", tx.Hash(), "nonce", tx.Nonce(), "gas", tx.Gas(), "value", tx.Value())...)
+}
+
 // FilterLogs filters contract logs for past blocks, returning the necessary
 // channels to construct a strongly typed bound iterator on top of them.
 func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 )
@@ -329,6 +330,39 @@ func TestTransactGasFee(t *testing.T) {
 	assert.True(mt.suggestGasPriceCalled)
 }
 
+func TestContractLogging(t *testing.T) {
+	var msgs []string
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		msgs = append(msgs, r.Msg)
+		return nil
+	}))
+	parsed, _ := abi.JSON(strings.NewReader(`[{"type":"function","name":"something","outputs":[{"type":"uint256"}]}]`))
+	mt := &mockTransactor{gasPrice: big.NewInt(5)}
+	mc := &mockCaller{callContractBytes: common.LeftPadBytes([]byte{1}, 32)}
+	bc := bind.NewBoundContract(common.Address{}, parsed, mc, mt, nil)
+	bc.SetLogger(logger)
+
+	var results []interface{}
+	if err := bc.Call(nil, &results, "something"); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if err := bc.Call(nil, &results, "nothing"); err == nil {
+		t.Fatal("call of unknown method succeeded")
+	}
+	if _, err := bc.Transact(&bind.TransactOpts{Signer: mockSign}, "something"); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	bc.SetLogger(nil)
+	if _, err := bc.Transfer(&bind.TransactOpts{Signer: mockSign}); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	want := []string{"Contract call", "Contract call failed", "Contract transaction"}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("log messages mismatch: have %q, want %q", msgs, want)
+	}
+}
+
 func unpackAndCheck(t *testing.T, bc *bind.BoundContract, expected map[string]interface{}, mockLog types.Log) {
 	received := make(map[string]interface{})
 	if err := bc.UnpackLogIntoMap(received, "received", mockLog); err != nil {
@@ -495,3 +529,43 @@ func TestCrashers(t *testing.T) {
 	abi.JSON(strings.NewReader(`[{"inputs":[{"type":"tuple[]","components":[{"type":"bool","name":"----"}]}]}]`))
 	abi.JSON(strings.NewReader(`[{"inputs":[{"type":"tuple[]","components":[{"type":"bool","name":"foo.Bar"}]}]}]`))
 }
+
+// mockDataError is a call error carrying hex encoded revert data.
+type mockDataError struct {
+	data string
+}
+
+func (e *mockDataError) Error() string          { return "execution reverted" }
+func (e *mockDataError) ErrorData() interface{} { return e.data }
+
+func TestCallRevertError(t *testing.T) {
+	const contractABI = `[
+		{"type":"function","name":"something","inputs":[],"outputs":[]},
+		{"type":"error","name":"Unauthorized","inputs":[{"name":"caller","type":"address"}]}
+	]`
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caller := common.HexToAddress("0x1234")
+	data, _ := parsed.Errors["Unauthorized"].Inputs.Pack(caller)
+	data = append(parsed.Errors["Unauthorized"].ID.Bytes()[:4], data...)
+
+	mc := &mockCaller{callContractErr: &mockDataError{data: hexutil.Encode(data)}}
+	bc := bind.NewBoundContract(common.Address{}, parsed, mc, nil, nil)
+
+	err = bc.Call(nil, nil, "something")
+	var rerr *bind.RevertError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected revert error, got %v", err)
+	}
+	if rerr.Revert.Kind != abi.RevertCustom || rerr.Revert.Error.Name != "Unauthorized" {
+		t.Fatalf("unexpected revert: %v", rerr.Revert)
+	}
+	if have := rerr.Revert.Args[0].(common.Address); have != caller {
+		t.Fatalf("revert argument mismatch: have %x, want %x", have, caller)
+	}
+	if !errors.Is(err, mc.callContractErr) {
+		t.Fatalf("backend error not wrapped")
+	}
+}
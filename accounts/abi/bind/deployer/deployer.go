@@ -0,0 +1,296 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package deployer implements scripted deployments of interdependent contracts.
+//
+// Contracts are declared along with their constructor arguments, which may refer
+// to other contracts of the same deployment. All contracts are deployed through
+// a CREATE2 factory, so their addresses only depend on their code, arguments and
+// salt. This allows computing the whole deployment upfront and makes re-running
+// it idempotent: contracts already present at their address are skipped.
+package deployer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// FactoryAddress is the address of the deterministic deployment proxy, a
+	// CREATE2 factory present at the same address on most chains.
+	FactoryAddress = common.HexToAddress("0x4e59b44847b379578588920ca78fbf26c0b4956c")
+
+	// FactoryCode is the runtime code of the deterministic deployment proxy. It
+	// creates a contract from the init code following the 32 byte salt in the
+	// call data and returns its address. Development chains lacking the factory
+	// can include it in their genesis.
+	FactoryCode = common.FromHex("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf3")
+)
+
+var (
+	// ErrNoFactory is returned if the CREATE2 factory is not deployed.
+	ErrNoFactory = errors.New("deployment factory missing")
+
+	// ErrDeployFailed is returned if a deployment transaction did not result in
+	// code at the expected address.
+	ErrDeployFailed = errors.New("deployment failed")
+)
+
+// Ref refers to the address of another contract of the deployment, by name. It
+// can be used in place of an address in constructor arguments, either directly
+// or in a []Ref standing for an address array.
+type Ref string
+
+// Contract declares a contract to deploy.
+type Contract struct {
+	Name     string        // Unique name of the contract within the deployment
+	ABI      abi.ABI       // ABI of the contract, used to pack the constructor arguments
+	Bytecode []byte        // Creation code of the contract
+	Args     []interface{} // Constructor arguments, possibly referring to other contracts
+	Salt     common.Hash   // Salt of the CREATE2 deployment
+	Deps     []string      // Contracts to deploy beforehand, besides the referenced ones
+}
+
+// Backend is the chain access needed to deploy contracts.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// Deployer deploys a set of interdependent contracts.
+type Deployer struct {
+	backend   Backend
+	factory   common.Address
+	contracts map[string]*Contract
+	order     []string // Names of the contracts in declaration order
+}
+
+// New creates a deployer creating contracts through the CREATE2 factory at the
+// given address, usually FactoryAddress.
+func New(backend Backend, factory common.Address) *Deployer {
+	return &Deployer{
+		backend:   backend,
+		factory:   factory,
+		contracts: make(map[string]*Contract),
+	}
+}
+
+// Add declares a contract to deploy.
+func (d *Deployer) Add(contract Contract) error {
+	if contract.Name == "" {
+		return errors.New("contract without name")
+	}
+	if _, ok := d.contracts[contract.Name]; ok {
+		return fmt.Errorf("contract %q declared twice", contract.Name)
+	}
+	d.contracts[contract.Name] = &contract
+	d.order = append(d.order, contract.Name)
+	return nil
+}
+
+// dependencies returns the names of the contracts the given one depends on.
+func dependencies(contract *Contract) []string {
+	deps := append([]string{}, contract.Deps...)
+	for _, arg := range contract.Args {
+		switch arg := arg.(type) {
+		case Ref:
+			deps = append(deps, string(arg))
+		case []Ref:
+			for _, ref := range arg {
+				deps = append(deps, string(ref))
+			}
+		}
+	}
+	return deps
+}
+
+// sort orders the contracts such that every contract comes after its
+// dependencies, keeping the declaration order otherwise.
+func (d *Deployer) sort() ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var (
+		state = make(map[string]int)
+		order []string
+		visit func(name string, path []string) error
+	)
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", append(path, name))
+		}
+		contract, ok := d.contracts[name]
+		if !ok {
+			return fmt.Errorf("unknown contract %q required by %q", name, path[len(path)-1])
+		}
+		state[name] = visiting
+		for _, dep := range dependencies(contract) {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range d.order {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Plan computes the deployment without sending any transactions, resolving the
+// addresses of all contracts. The returned manifest does not reference any
+// transactions.
+func (d *Deployer) Plan() (*Manifest, error) {
+	order, err := d.sort()
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		Factory:   d.factory,
+		Contracts: make(map[string]*Deployment),
+	}
+	for _, name := range order {
+		contract := d.contracts[name]
+
+		args := make([]interface{}, len(contract.Args))
+		for i, arg := range contract.Args {
+			switch arg := arg.(type) {
+			case Ref:
+				args[i] = manifest.Contracts[string(arg)].Address
+			case []Ref:
+				addrs := make([]common.Address, len(arg))
+				for j, ref := range arg {
+					addrs[j] = manifest.Contracts[string(ref)].Address
+				}
+				args[i] = addrs
+			default:
+				args[i] = arg
+			}
+		}
+		input, err := contract.ABI.Pack("", args...)
+		if err != nil {
+			return nil, fmt.Errorf("contract %q: %v", name, err)
+		}
+		initcode := append(common.CopyBytes(contract.Bytecode), input...)
+		hash := crypto.Keccak256Hash(initcode)
+
+		deployment := &Deployment{
+			Address:      crypto.CreateAddress2(d.factory, contract.Salt, hash[:]),
+			Salt:         contract.Salt,
+			InitCodeHash: hash,
+			Args:         input,
+			Dependencies: dependencies(contract),
+			initcode:     initcode,
+		}
+		manifest.Contracts[name] = deployment
+		manifest.Order = append(manifest.Order, name)
+	}
+	return manifest, nil
+}
+
+// Deploy deploys all contracts not yet deployed, in dependency order, waiting for
+// each deployment to be mined before proceeding with the next. The manifest of
+// the deployment is returned even if it fails midway, with the transactions sent
+// so far.
+func (d *Deployer) Deploy(ctx context.Context, opts *bind.TransactOpts) (*Manifest, error) {
+	manifest, err := d.Plan()
+	if err != nil {
+		return nil, err
+	}
+	code, err := d.backend.CodeAt(ctx, d.factory, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w at %x", ErrNoFactory, d.factory)
+	}
+	factory := bind.NewBoundContract(d.factory, abi.ABI{}, d.backend, d.backend, d.backend)
+
+	for _, name := range manifest.Order {
+		deployment := manifest.Contracts[name]
+
+		code, err := d.backend.CodeAt(ctx, deployment.Address, nil)
+		if err != nil {
+			return manifest, err
+		}
+		if len(code) > 0 {
+			log.Debug("Contract already deployed", "name", name, "address", deployment.Address)
+			continue
+		}
+		txopts := *opts
+		txopts.Context = ctx
+
+		tx, err := factory.RawTransact(&txopts, append(deployment.Salt.Bytes(), deployment.initcode...))
+		if err != nil {
+			return manifest, fmt.Errorf("contract %q: %v", name, err)
+		}
+		hash := tx.Hash()
+		deployment.Transaction = &hash
+
+		receipt, err := bind.WaitMined(ctx, d.backend, tx)
+		if err != nil {
+			return manifest, err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return manifest, fmt.Errorf("%w: contract %q, transaction %x reverted", ErrDeployFailed, name, hash)
+		}
+		if code, err = d.backend.CodeAt(ctx, deployment.Address, nil); err != nil {
+			return manifest, err
+		}
+		if len(code) == 0 {
+			return manifest, fmt.Errorf("%w: contract %q missing at %x", ErrDeployFailed, name, deployment.Address)
+		}
+		log.Info("Deployed contract", "name", name, "address", deployment.Address, "tx", hash)
+	}
+	return manifest, nil
+}
+
+// Manifest describes a deployment. It is meant to be stored as JSON alongside
+// the deployment scripts, recording the addresses of the contracts.
+type Manifest struct {
+	Factory   common.Address         `json:"factory"`   // CREATE2 factory the contracts were deployed through
+	Order     []string               `json:"order"`     // Names of the contracts in deployment order
+	Contracts map[string]*Deployment `json:"contracts"` // Deployments of the contracts by name
+}
+
+// Deployment describes the deployment of a single contract.
+type Deployment struct {
+	Address      common.Address `json:"address"`
+	Salt         common.Hash    `json:"salt"`
+	InitCodeHash common.Hash    `json:"initCodeHash"`
+	Args         hexutil.Bytes  `json:"args"`                   // ABI encoded constructor arguments
+	Dependencies []string       `json:"dependencies,omitempty"` // Contracts deployed beforehand
+	Transaction  *common.Hash   `json:"transaction,omitempty"`  // Deployment transaction, nil if deployed earlier
+
+	initcode []byte
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// Contract without constructor arguments, deploying a single byte of code
+	plainCode = common.FromHex("0x60016000f3")
+
+	// Contract storing the address passed to its constructor in slot 0
+	storeCode = common.FromHex("0x60206020380360003960005160005560016000f3")
+	storeABI  = mustParseABI(`[{"type":"constructor","inputs":[{"name":"target","type":"address"}]}]`)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// committingBackend is a simulated backend mining every transaction right away.
+type committingBackend struct {
+	*backends.SimulatedBackend
+	sent int
+}
+
+func (b *committingBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.sent++
+	b.Commit()
+	return nil
+}
+
+func TestDeploy(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+		FactoryAddress:                        {Code: FactoryCode, Balance: new(big.Int)},
+	}, 10000000)
+	defer sim.Close()
+
+	backend := &committingBackend{SimulatedBackend: sim}
+	opts, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+
+	d := New(backend, FactoryAddress)
+	// Declared out of order on purpose
+	if err := d.Add(Contract{Name: "consumer", ABI: storeABI, Bytecode: storeCode, Args: []interface{}{Ref("provider")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(Contract{Name: "provider", Bytecode: plainCode, Salt: common.Hash{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(Contract{Name: "provider", Bytecode: plainCode}); err == nil {
+		t.Fatal("duplicate contract accepted")
+	}
+	manifest, err := d.Deploy(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("deployment failed: %v", err)
+	}
+	if want := []string{"provider", "consumer"}; strings.Join(manifest.Order, ",") != strings.Join(want, ",") {
+		t.Fatalf("deployment order mismatch: have %v, want %v", manifest.Order, want)
+	}
+	provider, consumer := manifest.Contracts["provider"], manifest.Contracts["consumer"]
+	if provider.Transaction == nil || consumer.Transaction == nil {
+		t.Fatalf("deployment transactions missing")
+	}
+	slot, err := sim.StorageAt(context.Background(), consumer.Address, common.Hash{}, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve storage: %v", err)
+	}
+	if have := common.BytesToAddress(slot); have != provider.Address {
+		t.Fatalf("constructor argument mismatch: have %x, want %x", have, provider.Address)
+	}
+	// Re-running the deployment must not send any transactions
+	rerun, err := d.Deploy(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("repeated deployment failed: %v", err)
+	}
+	if backend.sent != 2 {
+		t.Fatalf("transaction count mismatch: have %d, want 2", backend.sent)
+	}
+	for name, deployment := range rerun.Contracts {
+		if deployment.Transaction != nil {
+			t.Errorf("contract %q redeployed", name)
+		}
+		if deployment.Address != manifest.Contracts[name].Address {
+			t.Errorf("contract %q address mismatch: have %x, want %x", name, deployment.Address, manifest.Contracts[name].Address)
+		}
+	}
+	// The manifest must survive a JSON round trip
+	blob, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if decoded.Contracts["consumer"].Address != consumer.Address || *decoded.Contracts["consumer"].Transaction != *consumer.Transaction {
+		t.Errorf("manifest mismatch after round trip: %s", blob)
+	}
+}
+
+func TestDeployErrors(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	d := New(sim, FactoryAddress)
+	d.Add(Contract{Name: "a", Bytecode: plainCode, Deps: []string{"b"}})
+	d.Add(Contract{Name: "b", ABI: storeABI, Bytecode: storeCode, Args: []interface{}{Ref("a")}})
+	if _, err := d.Plan(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cyclic dependencies: have error %v, want cycle", err)
+	}
+	d = New(sim, FactoryAddress)
+	d.Add(Contract{Name: "a", ABI: storeABI, Bytecode: storeCode, Args: []interface{}{Ref("missing")}})
+	if _, err := d.Plan(); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("missing dependency: have error %v, want unknown contract", err)
+	}
+	d = New(sim, FactoryAddress)
+	d.Add(Contract{Name: "a", Bytecode: plainCode})
+	if _, err := d.Deploy(context.Background(), &bind.TransactOpts{}); !errors.Is(err, ErrNoFactory) {
+		t.Errorf("missing factory: have error %v, want %v", err, ErrNoFactory)
+	}
+}
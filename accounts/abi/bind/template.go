@@ -87,6 +87,7 @@ const tmplSourceGo = `
 package {{.Package}}
 
 import (
+	"context"
 	"math/big"
 	"strings"
 	"errors"
@@ -101,6 +102,7 @@ import (
 
 // Reference imports to suppress errors if they are not otherwise used.
 var (
+	_ = context.Background
 	_ = errors.New
 	_ = big.NewInt
 	_ = strings.NewReader
@@ -554,6 +556,22 @@ var (
 			}), nil
 		}
 
+		// Watch{{.Normalized.Name}}Chan is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.ID}},
+		// delivering the events in order on the returned channel. Failed subscriptions are
+		// re-established and the missed events backfilled; the channel is closed when the
+		// context is cancelled.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Watch{{.Normalized.Name}}Chan(ctx context.Context{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type $structs}}{{end}}{{end}}) (<-chan *{{$contract.Type}}{{.Normalized.Name}}, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			return bind.WatchEvents(ctx, _{{$contract.Type}}.contract, "{{.Original.Name}}", [][]interface{}{ {{- range .Normalized.Inputs}}{{if .Indexed}}{{.Name}}Rule, {{end}}{{end -}} }, _{{$contract.Type}}.Parse{{.Normalized.Name}})
+		}
+
 		// Parse{{.Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.ID}}.
 		//
 		// Solidity: {{.Original.String}}
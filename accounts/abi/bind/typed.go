@@ -0,0 +1,84 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Call invokes a constant method of a contract bound without code generation,
+// e.g. via NewBoundContract, and returns its output as T. Methods returning a
+// single value are converted to T, which must be the Go type of the value or
+// convertible to it, e.g. a named struct matching a returned tuple. Methods
+// returning multiple values are unpacked into the fields of T by name, unless T
+// is []interface{} in which case the raw values are returned.
+//
+//	balance, err := bind.Call[*big.Int](ctx, token, "balanceOf", holder)
+func Call[T any](ctx context.Context, contract *BoundContract, method string, args ...interface{}) (T, error) {
+	var out T
+
+	m, ok := contract.abi.Methods[method]
+	if !ok {
+		return out, fmt.Errorf("method '%s' not found", method)
+	}
+	opts := &CallOpts{Context: ctx}
+
+	var results []interface{}
+	if err := contract.Call(opts, &results, method, args...); err != nil {
+		return out, err
+	}
+	if raw, ok := any(&out).(*[]interface{}); ok {
+		*raw = results
+		return out, nil
+	}
+	switch len(m.Outputs) {
+	case 0:
+		return out, nil
+	case 1:
+		value := reflect.ValueOf(results[0])
+		target := reflect.TypeOf((*T)(nil)).Elem()
+		if !value.Type().ConvertibleTo(target) {
+			return out, fmt.Errorf("cannot convert output %T of method '%s' to %T", results[0], method, out)
+		}
+		return value.Convert(target).Interface().(T), nil
+	default:
+		if err := m.Outputs.Copy(&out, results); err != nil {
+			return out, err
+		}
+		return out, nil
+	}
+}
+
+// Transact invokes a paid method of a contract bound without code generation,
+// packing the arguments against the contract ABI. It is the counterpart of Call
+// for state changing methods and merely spares scripts the method dispatch.
+//
+//	tx, err := bind.Transact(auth, token, "transfer", recipient, amount)
+func Transact(opts *TransactOpts, contract *BoundContract, method string, args ...interface{}) (*types.Transaction, error) {
+	m, ok := contract.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method '%s' not found", method)
+	}
+	if m.IsConstant() {
+		return nil, fmt.Errorf("method '%s' is constant, use Call", method)
+	}
+	return contract.Transact(opts, method, args...)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const typedABI = `[
+	{"type":"function","name":"balance","stateMutability":"view","inputs":[{"name":"holder","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"pair","stateMutability":"view","inputs":[],"outputs":[{"name":"amount","type":"uint256"},{"name":"owner","type":"address"}]},
+	{"type":"function","name":"point","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"tuple","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"}],"outputs":[]}
+]`
+
+func TestTypedCall(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(typedABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		owner = common.HexToAddress("0x1234")
+		ctx   = context.Background()
+		mc    = new(mockCaller)
+		bc    = bind.NewBoundContract(common.Address{}, parsed, mc, nil, nil)
+	)
+	// Single value outputs are returned as is
+	mc.callContractBytes, _ = parsed.Methods["balance"].Outputs.Pack(big.NewInt(42))
+	balance, err := bind.Call[*big.Int](ctx, bc, "balance", owner)
+	if err != nil || balance.Int64() != 42 {
+		t.Fatalf("balance mismatch: have %v, %v, want 42", balance, err)
+	}
+	if _, err := bind.Call[string](ctx, bc, "balance", owner); err == nil {
+		t.Fatalf("mistyped output converted")
+	}
+	// Tuples are converted into matching structs
+	type point struct {
+		X *big.Int `json:"x"`
+		Y *big.Int `json:"y"`
+	}
+	mc.callContractBytes, _ = parsed.Methods["point"].Outputs.Pack(struct {
+		X *big.Int `json:"x"`
+		Y *big.Int `json:"y"`
+	}{big.NewInt(1), big.NewInt(2)})
+	p, err := bind.Call[point](ctx, bc, "point")
+	if err != nil || p.X.Int64() != 1 || p.Y.Int64() != 2 {
+		t.Fatalf("point mismatch: have %v, %v, want (1, 2)", p, err)
+	}
+	// Multiple outputs are unpacked by name, or returned raw
+	mc.callContractBytes, _ = parsed.Methods["pair"].Outputs.Pack(big.NewInt(7), owner)
+	pair, err := bind.Call[struct {
+		Amount *big.Int
+		Owner  common.Address
+	}](ctx, bc, "pair")
+	if err != nil || pair.Amount.Int64() != 7 || pair.Owner != owner {
+		t.Fatalf("pair mismatch: have %v, %v", pair, err)
+	}
+	raw, err := bind.Call[[]interface{}](ctx, bc, "pair")
+	if err != nil || len(raw) != 2 || raw[1].(common.Address) != owner {
+		t.Fatalf("raw output mismatch: have %v, %v", raw, err)
+	}
+	// Unknown and mismatching methods are rejected
+	if _, err := bind.Call[*big.Int](ctx, bc, "missing"); err == nil {
+		t.Fatalf("unknown method called")
+	}
+	if _, err := bind.Transact(&bind.TransactOpts{}, bc, "balance", owner); err == nil {
+		t.Fatalf("constant method transacted")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Etherscan submits verification requests to an Etherscan compatible explorer.
+type Etherscan struct {
+	URL    string       // API endpoint, e.g. "https://api.etherscan.io/api"
+	APIKey string       // API key of the account submitting the request
+	Client *http.Client // HTTP client to use, http.DefaultClient if nil
+}
+
+type etherscanResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Result  string `json:"result"`
+}
+
+func (e *Etherscan) call(ctx context.Context, form url.Values) (string, error) {
+	form.Set("apikey", e.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var res etherscanResponse
+	if err := doJSON(e.Client, req, &res); err != nil {
+		return "", err
+	}
+	if res.Status != "1" {
+		return "", fmt.Errorf("etherscan: %s: %s", res.Message, res.Result)
+	}
+	return res.Result, nil
+}
+
+// Submit sends the payload for verification. It returns the request GUID which
+// can be used to query the outcome through Status.
+func (e *Etherscan) Submit(ctx context.Context, p *Payload) (string, error) {
+	form := url.Values{
+		"module":          {"contract"},
+		"action":          {"verifysourcecode"},
+		"contractaddress": {p.Address.Hex()},
+		"sourceCode":      {string(p.Input)},
+		"codeformat":      {"solidity-standard-json-input"},
+		"contractname":    {p.ContractName},
+		"compilerversion": {p.CompilerVersion},
+		// The misspelling is part of the Etherscan API.
+		"constructorArguements": {hex.EncodeToString(p.ConstructorArgs)},
+	}
+	return e.call(ctx, form)
+}
+
+// Status returns the state of a verification request, e.g. "Pending in queue"
+// or "Pass - Verified".
+func (e *Etherscan) Status(ctx context.Context, guid string) (string, error) {
+	form := url.Values{
+		"module": {"contract"},
+		"action": {"checkverifystatus"},
+		"guid":   {guid},
+	}
+	return e.call(ctx, form)
+}
+
+// Sourcify submits verification requests to a Sourcify server.
+type Sourcify struct {
+	URL    string       // Server URL, e.g. "https://sourcify.dev/server"
+	Client *http.Client // HTTP client to use, http.DefaultClient if nil
+}
+
+// Submit sends the metadata and sources of the payload for verification on the
+// given chain. It returns the match status reported by the server, i.e. "perfect"
+// or "partial".
+func (s *Sourcify) Submit(ctx context.Context, chainID *big.Int, p *Payload) (string, error) {
+	files := map[string]string{"metadata.json": string(p.Metadata)}
+	for path, content := range p.Sources {
+		files[path] = content
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"address": p.Address.Hex(),
+		"chain":   chainID.String(),
+		"files":   files,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.URL, "/")+"/verify", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var res struct {
+		Error  string `json:"error"`
+		Result []struct {
+			Address string `json:"address"`
+			Status  string `json:"status"`
+		} `json:"result"`
+	}
+	if err := doJSON(s.Client, req, &res); err != nil {
+		return "", err
+	}
+	if res.Error != "" {
+		return "", fmt.Errorf("sourcify: %s", res.Error)
+	}
+	if len(res.Result) == 0 {
+		return "", fmt.Errorf("sourcify: empty result")
+	}
+	return res.Result[0].Status, nil
+}
+
+// doJSON performs the request and decodes the JSON response body. Error bodies
+// are decoded as well, since explorers report failures in the response.
+func doJSON(client *http.Client, req *http.Request, result interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("%s: invalid response (%v)", resp.Status, err)
+	}
+	return nil
+}
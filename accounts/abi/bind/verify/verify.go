@@ -0,0 +1,190 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package verify creates source verification requests for contracts deployed
+// through package bind, and submits them to block explorers.
+//
+// The compiler settings are taken from the metadata emitted by solc (e.g. with
+// --combined-json metadata), so the explorer recompiles the sources exactly like
+// the deployed bytecode was built.
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Metadata is the contract metadata produced by the Solidity compiler.
+type Metadata struct {
+	Compiler struct {
+		Version string `json:"version"`
+	} `json:"compiler"`
+	Language string                    `json:"language"`
+	Settings Settings                  `json:"settings"`
+	Sources  map[string]MetadataSource `json:"sources"`
+}
+
+// Settings are the compiler settings recorded in the contract metadata.
+type Settings struct {
+	CompilationTarget map[string]string `json:"compilationTarget"`
+	EVMVersion        string            `json:"evmVersion,omitempty"`
+	Libraries         map[string]string `json:"libraries,omitempty"`
+	Optimizer         json.RawMessage   `json:"optimizer,omitempty"`
+	Remappings        []string          `json:"remappings,omitempty"`
+	Metadata          json.RawMessage   `json:"metadata,omitempty"`
+	ViaIR             bool              `json:"viaIR,omitempty"`
+}
+
+// MetadataSource is a source file entry of the contract metadata. The content is
+// only present if the contract was compiled with --metadata-literal.
+type MetadataSource struct {
+	Keccak256 common.Hash `json:"keccak256"`
+	Content   string      `json:"content,omitempty"`
+	URLs      []string    `json:"urls,omitempty"`
+	License   string      `json:"license,omitempty"`
+}
+
+// ParseMetadata decodes the metadata JSON of a compiled contract.
+func ParseMetadata(metadata string) (*Metadata, error) {
+	var meta Metadata
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+		return nil, err
+	}
+	if len(meta.Settings.CompilationTarget) != 1 {
+		return nil, errors.New("metadata must have exactly one compilation target")
+	}
+	if meta.Compiler.Version == "" {
+		return nil, errors.New("metadata has no compiler version")
+	}
+	return &meta, nil
+}
+
+// Payload contains everything block explorers need to verify the source code of
+// a deployed contract.
+type Payload struct {
+	Address         common.Address
+	ContractName    string // Fully qualified name, i.e. "path/to/File.sol:Contract"
+	CompilerVersion string // Compiler version prefixed with 'v', e.g. "v0.8.19+commit.7dd6d404"
+	ConstructorArgs []byte // ABI encoded constructor arguments
+
+	Input    []byte            // Standard JSON compiler input
+	Metadata []byte            // Raw contract metadata
+	Sources  map[string]string // Source file contents by path
+}
+
+// standardInput is the solc standard JSON input format.
+type standardInput struct {
+	Language string                       `json:"language"`
+	Sources  map[string]map[string]string `json:"sources"`
+	Settings standardSettings             `json:"settings"`
+}
+
+type standardSettings struct {
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	Libraries       map[string]map[string]string   `json:"libraries,omitempty"`
+	Optimizer       json.RawMessage                `json:"optimizer,omitempty"`
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Metadata        json.RawMessage                `json:"metadata,omitempty"`
+	ViaIR           bool                           `json:"viaIR,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// NewPayload assembles the verification payload of a contract deployed at the
+// given address from its compiler metadata. Sources which are not embedded in
+// the metadata must be provided in the sources map, keyed by their path. All
+// source contents are checked against the hashes recorded in the metadata.
+func NewPayload(address common.Address, metadata string, sources map[string]string, constructorArgs []byte) (*Payload, error) {
+	meta, err := ParseMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	p := &Payload{
+		Address:         address,
+		CompilerVersion: "v" + strings.TrimPrefix(meta.Compiler.Version, "v"),
+		ConstructorArgs: common.CopyBytes(constructorArgs),
+		Metadata:        []byte(metadata),
+		Sources:         make(map[string]string),
+	}
+	for path, name := range meta.Settings.CompilationTarget {
+		p.ContractName = path + ":" + name
+	}
+	input := standardInput{
+		Language: meta.Language,
+		Sources:  make(map[string]map[string]string),
+		Settings: standardSettings{
+			EVMVersion: meta.Settings.EVMVersion,
+			Optimizer:  meta.Settings.Optimizer,
+			Remappings: meta.Settings.Remappings,
+			Metadata:   meta.Settings.Metadata,
+			ViaIR:      meta.Settings.ViaIR,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": {"abi", "evm.bytecode", "evm.deployedBytecode", "metadata"}},
+			},
+		},
+	}
+	for path, src := range meta.Sources {
+		content := src.Content
+		if content == "" {
+			var ok bool
+			if content, ok = sources[path]; !ok {
+				return nil, fmt.Errorf("missing source %s", path)
+			}
+		}
+		if hash := crypto.Keccak256Hash([]byte(content)); hash != src.Keccak256 {
+			return nil, fmt.Errorf("source %s hash mismatch: have %x, want %x", path, hash, src.Keccak256)
+		}
+		p.Sources[path] = content
+		input.Sources[path] = map[string]string{"content": content}
+	}
+	// Libraries are recorded as "path:Name" in the metadata, but grouped by path
+	// in the standard JSON input.
+	if len(meta.Settings.Libraries) > 0 {
+		input.Settings.Libraries = make(map[string]map[string]string)
+		for lib, addr := range meta.Settings.Libraries {
+			path, name := "", lib
+			if i := strings.LastIndex(lib, ":"); i >= 0 {
+				path, name = lib[:i], lib[i+1:]
+			}
+			if input.Settings.Libraries[path] == nil {
+				input.Settings.Libraries[path] = make(map[string]string)
+			}
+			input.Settings.Libraries[path][name] = addr
+		}
+	}
+	if p.Input, err = json.Marshal(input); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ConstructorArgs extracts the ABI encoded constructor arguments from a contract
+// creation transaction, as sent by bind.DeployContract with the given bytecode.
+func ConstructorArgs(tx *types.Transaction, bytecode []byte) ([]byte, error) {
+	if tx.To() != nil {
+		return nil, errors.New("not a contract creation")
+	}
+	if !bytes.HasPrefix(tx.Data(), bytecode) {
+		return nil, errors.New("transaction data does not start with the contract bytecode")
+	}
+	return common.CopyBytes(tx.Data()[len(bytecode):]), nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	testSource  = "contract Token { constructor(uint256 supply) {} }"
+	testLibrary = "library Math {}"
+)
+
+var testMetadata = fmt.Sprintf(`{
+	"compiler": {"version": "0.8.19+commit.7dd6d404"},
+	"language": "Solidity",
+	"settings": {
+		"compilationTarget": {"contracts/Token.sol": "Token"},
+		"evmVersion": "paris",
+		"libraries": {"contracts/Math.sol:Math": "0x00000000000000000000000000000000000000aa"},
+		"optimizer": {"enabled": true, "runs": 200},
+		"remappings": []
+	},
+	"sources": {
+		"contracts/Token.sol": {"keccak256": "%s"},
+		"contracts/Math.sol": {"keccak256": "%s", "content": %q}
+	},
+	"version": 1
+}`, crypto.Keccak256Hash([]byte(testSource)).Hex(), crypto.Keccak256Hash([]byte(testLibrary)).Hex(), testLibrary)
+
+func TestNewPayload(t *testing.T) {
+	addr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	p, err := NewPayload(addr, testMetadata, map[string]string{"contracts/Token.sol": testSource}, []byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ContractName != "contracts/Token.sol:Token" || p.CompilerVersion != "v0.8.19+commit.7dd6d404" {
+		t.Errorf("contract mismatch: have %s %s", p.ContractName, p.CompilerVersion)
+	}
+	var input struct {
+		Language string
+		Sources  map[string]struct{ Content string }
+		Settings struct {
+			EVMVersion string
+			Libraries  map[string]map[string]string
+			Optimizer  struct{ Enabled bool }
+		}
+	}
+	if err := json.Unmarshal(p.Input, &input); err != nil {
+		t.Fatal(err)
+	}
+	if input.Sources["contracts/Token.sol"].Content != testSource || input.Sources["contracts/Math.sol"].Content != testLibrary {
+		t.Errorf("sources mismatch: %+v", input.Sources)
+	}
+	if input.Settings.EVMVersion != "paris" || !input.Settings.Optimizer.Enabled {
+		t.Errorf("settings mismatch: %+v", input.Settings)
+	}
+	if input.Settings.Libraries["contracts/Math.sol"]["Math"] != "0x00000000000000000000000000000000000000aa" {
+		t.Errorf("libraries mismatch: %v", input.Settings.Libraries)
+	}
+	// Missing and modified sources must be rejected
+	if _, err := NewPayload(addr, testMetadata, nil, nil); err == nil {
+		t.Error("missing source accepted")
+	}
+	if _, err := NewPayload(addr, testMetadata, map[string]string{"contracts/Token.sol": testSource + " "}, nil); err == nil {
+		t.Error("modified source accepted")
+	}
+}
+
+func TestConstructorArgs(t *testing.T) {
+	code, args := []byte{0x60, 0x80, 0x60, 0x40}, common.LeftPadBytes([]byte{0x2a}, 32)
+	tx := types.NewContractCreation(0, nil, 100000, nil, append(code, args...))
+	have, err := ConstructorArgs(tx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, args) {
+		t.Errorf("args mismatch: have %x, want %x", have, args)
+	}
+	if _, err := ConstructorArgs(tx, []byte{0x00}); err == nil {
+		t.Error("foreign bytecode accepted")
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	addr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	p, err := NewPayload(addr, testMetadata, map[string]string{"contracts/Token.sol": testSource}, []byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/verify" {
+			var req struct {
+				Chain string
+				Files map[string]string
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Chain != "5" || req.Files["metadata.json"] == "" || req.Files["contracts/Token.sol"] != testSource {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"bad request"}`))
+				return
+			}
+			w.Write([]byte(`{"result":[{"address":"0x1000000000000000000000000000000000000001","status":"perfect"}]}`))
+			return
+		}
+		r.ParseForm()
+		switch {
+		case r.Form.Get("apikey") != "key":
+			w.Write([]byte(`{"status":"0","message":"NOTOK","result":"Invalid API Key"}`))
+		case r.Form.Get("action") == "verifysourcecode" && r.Form.Get("constructorArguements") == "01":
+			w.Write([]byte(`{"status":"1","message":"OK","result":"guid"}`))
+		case r.Form.Get("action") == "checkverifystatus" && r.Form.Get("guid") == "guid":
+			w.Write([]byte(`{"status":"1","message":"OK","result":"Pass - Verified"}`))
+		default:
+			w.Write([]byte(`{"status":"0","message":"NOTOK","result":"unexpected request"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	etherscan := &Etherscan{URL: srv.URL + "/api", APIKey: "key"}
+	guid, err := etherscan.Submit(ctx, p)
+	if err != nil || guid != "guid" {
+		t.Fatalf("etherscan submission failed: %v (guid %q)", err, guid)
+	}
+	if status, err := etherscan.Status(ctx, guid); err != nil || status != "Pass - Verified" {
+		t.Fatalf("etherscan status failed: %v (status %q)", err, status)
+	}
+	if _, err := (&Etherscan{URL: srv.URL + "/api"}).Submit(ctx, p); err == nil {
+		t.Fatal("missing API key accepted")
+	}
+	sourcify := &Sourcify{URL: srv.URL}
+	if status, err := sourcify.Submit(ctx, big.NewInt(5), p); err != nil || status != "perfect" {
+		t.Fatalf("sourcify submission failed: %v (status %q)", err, status)
+	}
+	if _, err := sourcify.Submit(ctx, big.NewInt(1), p); err == nil {
+		t.Fatal("sourcify error not reported")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// watchRetryMin is the initial delay before resubscribing to a failed
+	// event subscription.
+	watchRetryMin = time.Second
+
+	// watchRetryMax is the maximum delay between resubscription attempts.
+	watchRetryMax = time.Minute
+)
+
+// logPosition is the position of a log within the chain.
+type logPosition struct {
+	number uint64
+	index  uint
+}
+
+// before reports whether the log precedes the given position.
+func (p logPosition) before(log *types.Log) bool {
+	return log.BlockNumber < p.number || (log.BlockNumber == p.number && log.Index < p.index)
+}
+
+// WatchEvents subscribes to the given contract event and delivers the logs,
+// unpacked by the parse function, on the returned channel. Unlike WatchLogs it
+// takes care of the subscription lifecycle: if the subscription fails (e.g. a
+// websocket connection drops), it is re-established with exponential backoff
+// and the logs missed in the meantime are backfilled. Events are delivered in
+// chain order and exactly once, except for logs reverted by a reorg which are
+// delivered again with Raw.Removed set.
+//
+// Logs failing to unpack are skipped. The channel is closed once the context
+// is cancelled.
+func WatchEvents[T any](ctx context.Context, c *BoundContract, name string, query [][]interface{}, parse func(types.Log) (*T, error)) (<-chan *T, error) {
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{c.abi.Events[name].ID}}, query...)
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, err
+	}
+	config := ethereum.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}
+	logs := make(chan types.Log, 128)
+	sub, err := c.filterer.SubscribeFilterLogs(ctx, config, logs)
+	if err != nil {
+		return nil, err
+	}
+	sink := make(chan *T)
+	go func() {
+		defer close(sink)
+
+		var (
+			next    logPosition // Position of the next log to deliver
+			started bool        // Whether any log was delivered yet
+			retry   = watchRetryMin
+		)
+		deliver := func(raw types.Log) bool {
+			if raw.Removed {
+				if !started {
+					return true
+				}
+				if next.before(&raw) {
+					next = logPosition{raw.BlockNumber, raw.Index}
+				}
+			} else {
+				if started && next.before(&raw) {
+					return true
+				}
+				next, started = logPosition{raw.BlockNumber, raw.Index + 1}, true
+			}
+			event, err := parse(raw)
+			if err != nil {
+				log.Warn("Failed to unpack contract event", "event", name, "block", raw.BlockNumber, "index", raw.Index, "err", err)
+				return true
+			}
+			select {
+			case sink <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case raw := <-logs:
+				if !deliver(raw) {
+					sub.Unsubscribe()
+					return
+				}
+			case err := <-sub.Err():
+				sub.Unsubscribe()
+				log.Warn("Contract event subscription failed", "event", name, "err", err)
+
+				// Resubscribe until successful, then backfill the missed logs
+				for {
+					select {
+					case <-time.After(retry):
+					case <-ctx.Done():
+						return
+					}
+					if retry *= 2; retry > watchRetryMax {
+						retry = watchRetryMax
+					}
+					logs = make(chan types.Log, 128)
+					if sub, err = c.filterer.SubscribeFilterLogs(ctx, config, logs); err != nil {
+						log.Warn("Failed to resubscribe to contract event", "event", name, "err", err)
+						continue
+					}
+					if !started {
+						break
+					}
+					backfill := config
+					backfill.FromBlock = new(big.Int).SetUint64(next.number)
+					missed, err := c.filterer.FilterLogs(ctx, backfill)
+					if err != nil {
+						log.Warn("Failed to backfill contract events", "event", name, "err", err)
+						sub.Unsubscribe()
+						continue
+					}
+					for _, raw := range missed {
+						if !deliver(raw) {
+							sub.Unsubscribe()
+							return
+						}
+					}
+					break
+				}
+				retry = watchRetryMin
+
+			case <-ctx.Done():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return sink, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// mockFilterer is a log filterer whose live subscription can be failed on
+// demand, serving a fixed log history for backfills.
+type mockFilterer struct {
+	history []types.Log
+
+	lock sync.Mutex
+	live chan<- types.Log
+	errc chan error
+	subs int
+}
+
+func (mf *mockFilterer) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, log := range mf.history {
+		if query.FromBlock == nil || log.BlockNumber >= query.FromBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (mf *mockFilterer) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	mf.lock.Lock()
+	defer mf.lock.Unlock()
+
+	mf.live, mf.errc = ch, make(chan error, 1)
+	mf.subs++
+	return &mockSubscription{errc: mf.errc}, nil
+}
+
+func (mf *mockFilterer) send(log types.Log) {
+	mf.lock.Lock()
+	live := mf.live
+	mf.lock.Unlock()
+	live <- log
+}
+
+func (mf *mockFilterer) fail() {
+	mf.lock.Lock()
+	defer mf.lock.Unlock()
+	mf.errc <- errors.New("connection lost")
+}
+
+type mockSubscription struct {
+	errc chan error
+}
+
+func (ms *mockSubscription) Err() <-chan error { return ms.errc }
+func (ms *mockSubscription) Unsubscribe()      {}
+
+// Tests that channel based event watching survives subscription failures,
+// backfilling missed events without delivering duplicates.
+func TestWatchEvents(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"event","name":"Ping","inputs":[]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := parsed.Events["Ping"].ID
+	newLog := func(number uint64) types.Log {
+		return types.Log{Topics: []common.Hash{id}, BlockNumber: number}
+	}
+	filterer := &mockFilterer{history: []types.Log{newLog(1), newLog(2)}}
+	contract := bind.NewBoundContract(common.Address{}, parsed, nil, nil, filterer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := bind.WatchEvents(ctx, contract, "Ping", nil, func(log types.Log) (*types.Log, error) {
+		return &log, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to watch events: %v", err)
+	}
+	next := func() uint64 {
+		select {
+		case event := <-events:
+			return event.BlockNumber
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+			return 0
+		}
+	}
+	filterer.send(newLog(1))
+	if have := next(); have != 1 {
+		t.Fatalf("event mismatch: have block %d, want 1", have)
+	}
+	// Drop the subscription, the missed log should be backfilled
+	filterer.fail()
+	if have := next(); have != 2 {
+		t.Fatalf("backfilled event mismatch: have block %d, want 2", have)
+	}
+	filterer.send(newLog(2))
+	filterer.send(newLog(3))
+	if have := next(); have != 3 {
+		t.Fatalf("event mismatch after resubscription: have block %d, want 3", have)
+	}
+	if filterer.subs != 2 {
+		t.Errorf("subscription count mismatch: have %d, want 2", filterer.subs)
+	}
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("unexpected event after cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("event channel not closed after cancellation")
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultSelectorWords is the dictionary of name fragments searched by default
+// for selector collisions, drawn from commonly used contract interfaces.
+var DefaultSelectorWords = []string{
+	"transfer", "approve", "allowance", "balance", "supply", "total", "mint", "burn",
+	"owner", "admin", "operator", "role", "grant", "revoke", "renounce", "set",
+	"get", "is", "has", "add", "remove", "update", "init", "initialize",
+	"upgrade", "implementation", "proxy", "execute", "call", "multicall", "batch", "safe",
+	"from", "to", "all", "deposit", "withdraw", "claim", "stake", "unstake",
+	"reward", "rewards", "fee", "fees", "price", "rate", "swap", "liquidity",
+	"pool", "pair", "token", "tokens", "amount", "value", "data", "config",
+	"pause", "unpause", "paused", "lock", "unlock", "release", "redeem", "borrow",
+	"repay", "liquidate", "flash", "loan", "vault", "share", "shares", "asset",
+	"convert", "preview", "max", "min", "limit", "nonce", "permit", "delegate",
+	"vote", "propose", "queue", "cancel", "sync", "skim", "sweep", "rescue",
+	"emergency", "recover", "name", "symbol", "decimals", "uri", "account", "user",
+}
+
+// DefaultSelectorArgs are the argument lists searched by default for selector
+// collisions.
+var DefaultSelectorArgs = []string{
+	"", "address", "uint256", "address,uint256", "address,address", "address,address,uint256",
+	"uint256,uint256", "bytes", "bytes32", "bool", "string", "address,bool", "uint8", "address,bytes",
+}
+
+// SelectorSearch is a search for human readable function signatures with a
+// given selector. Candidates are named after the dictionary words and their
+// two word camel case combinations first. Afterwards the words are mutated by
+// appending ever longer base 36 suffixes, e.g. transfer_a3, which covers the
+// full selector space given enough time.
+type SelectorSearch struct {
+	Words   []string // Name fragments, DefaultSelectorWords if empty
+	Args    []string // Comma separated argument type lists, DefaultSelectorArgs if empty
+	Threads int      // Number of search threads, the number of CPUs if zero
+	Limit   int      // Number of signatures to find, 1 if zero
+}
+
+// Find searches for function signatures with the given selector until either
+// the limit of results is reached or the context is cancelled. Signatures
+// found before the cancellation are returned along with the context error.
+// The order of the results is not deterministic if multiple threads are used.
+func (s *SelectorSearch) Find(ctx context.Context, selector [4]byte) ([]string, error) {
+	var (
+		words   = s.Words
+		args    = s.Args
+		threads = s.Threads
+		limit   = s.Limit
+	)
+	if len(words) == 0 {
+		words = DefaultSelectorWords
+	}
+	if len(args) == 0 {
+		args = DefaultSelectorArgs
+	}
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	if limit <= 0 {
+		limit = 1
+	}
+	// Create the search threads and wait until enough signatures are found or
+	// the search is aborted from the outside.
+	var (
+		abort   = make(chan struct{})
+		found   = make(chan string)
+		pend    sync.WaitGroup
+		results []string
+		err     error
+	)
+	gen := &candidateGenerator{words: words, args: args}
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func(id int) {
+			defer pend.Done()
+			gen.search(selector, uint64(id), uint64(threads), abort, found)
+		}(i)
+	}
+	for len(results) < limit && err == nil {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case sig := <-found:
+			results = append(results, sig)
+		}
+	}
+	close(abort)
+	pend.Wait()
+	return results, err
+}
+
+// candidateGenerator enumerates the candidate signatures of a search.
+type candidateGenerator struct {
+	words []string
+	args  []string
+}
+
+// search hashes every threads-th candidate starting at id, sending matches to
+// found until aborted.
+func (g *candidateGenerator) search(selector [4]byte, id, threads uint64, abort chan struct{}, found chan string) {
+	var (
+		hasher = crypto.NewKeccakState()
+		digest = make([]byte, 4)
+		buf    []byte
+	)
+	for n := id; ; n += threads {
+		// Check for termination once in a while, hashing is cheap
+		if n%1024 < threads {
+			select {
+			case <-abort:
+				return
+			default:
+			}
+		}
+		buf = g.candidate(buf[:0], n)
+
+		hasher.Reset()
+		hasher.Write(buf)
+		hasher.Read(digest)
+		if !bytes.Equal(digest, selector[:]) {
+			continue
+		}
+		select {
+		case found <- string(buf):
+		case <-abort:
+			return
+		}
+	}
+}
+
+// candidate appends the n-th candidate signature to buf.
+func (g *candidateGenerator) candidate(buf []byte, n uint64) []byte {
+	var (
+		words = uint64(len(g.words))
+		args  = g.args[n%uint64(len(g.args))]
+	)
+	n /= uint64(len(g.args))
+
+	switch {
+	case n < words:
+		// Dictionary words
+		buf = append(buf, g.words[n]...)
+
+	case n < words+words*words:
+		// Camel case combinations of two dictionary words
+		n -= words
+		buf = append(buf, g.words[n/words]...)
+		buf = appendCapitalized(buf, g.words[n%words])
+
+	default:
+		// Mutations of dictionary words with a base 36 suffix
+		n -= words + words*words
+		buf = append(buf, g.words[n%words]...)
+		buf = append(buf, '_')
+		buf = strconv.AppendUint(buf, n/words, 36)
+	}
+	buf = append(buf, '(')
+	buf = append(buf, args...)
+	return append(buf, ')')
+}
+
+// appendCapitalized appends the word to buf, upper casing its first letter.
+func appendCapitalized(buf []byte, word string) []byte {
+	if len(word) == 0 {
+		return buf
+	}
+	first := word[0]
+	if first >= 'a' && first <= 'z' {
+		first -= 'a' - 'A'
+	}
+	return append(append(buf, first), word[1:]...)
+}
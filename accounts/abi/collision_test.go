@@ -0,0 +1,88 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func selectorOf(sig string) (selector [4]byte) {
+	copy(selector[:], crypto.Keccak256([]byte(sig)))
+	return selector
+}
+
+func TestSelectorCandidates(t *testing.T) {
+	gen := &candidateGenerator{words: []string{"get", "owner"}, args: []string{"", "uint256"}}
+	want := []string{
+		"get()", "get(uint256)", "owner()", "owner(uint256)",
+		"getGet()", "getGet(uint256)", "getOwner()", "getOwner(uint256)",
+		"ownerGet()", "ownerGet(uint256)", "ownerOwner()", "ownerOwner(uint256)",
+		"get_0()", "get_0(uint256)", "owner_0()", "owner_0(uint256)",
+		"get_1()",
+	}
+	for i, sig := range want {
+		if have := string(gen.candidate(nil, uint64(i))); have != sig {
+			t.Errorf("candidate %d mismatch: have %s, want %s", i, have, sig)
+		}
+	}
+	if have := string(gen.candidate(nil, 2*(6+2*10))); have != "get_a()" {
+		t.Errorf("base 36 suffix mismatch: have %s, want get_a()", have)
+	}
+}
+
+func TestSelectorSearch(t *testing.T) {
+	// Dictionary signatures are found right away, by any number of threads
+	for _, threads := range []int{1, 3} {
+		search := &SelectorSearch{Threads: threads}
+		sigs, err := search.Find(context.Background(), selectorOf("transferFrom(address,address,uint256)"))
+		if err != nil {
+			t.Fatalf("threads %d: search failed: %v", threads, err)
+		}
+		if len(sigs) != 1 || sigs[0] != "transferFrom(address,address,uint256)" {
+			t.Errorf("threads %d: unexpected signatures: %v", threads, sigs)
+		}
+	}
+	// Mutated signatures are found by continuing the search
+	search := &SelectorSearch{Words: []string{"foo", "bar"}, Args: []string{"uint256"}}
+	sigs, err := search.Find(context.Background(), selectorOf("bar_1z(uint256)"))
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(sigs) != 1 || sigs[0] != "bar_1z(uint256)" {
+		t.Errorf("unexpected signatures: %v", sigs)
+	}
+}
+
+func TestSelectorSearchCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Signatures found before the cancellation are returned with the error
+	search := &SelectorSearch{Words: []string{"foo"}, Args: []string{""}, Threads: 2, Limit: 2}
+	sigs, err := search.Find(ctx, selectorOf("foo_7()"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(sigs) != 1 || sigs[0] != "foo_7()" {
+		t.Errorf("unexpected signatures: %v", sigs)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// panicSelector is the function selector of the solidity Panic(uint256) error,
+// raised by failing assertions and runtime checks.
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+// panicReasons maps the solidity panic codes to their meaning.
+// See https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "pop on an empty array",
+	0x32: "out-of-bounds array or bytesN access",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// RevertKind is the type of failure a revert was raised with.
+type RevertKind uint8
+
+const (
+	RevertUnknown RevertKind = iota // Revert data not matching any known error
+	RevertReason                    // Error(string) raised by require and revert
+	RevertPanic                     // Panic(uint256) raised by runtime checks
+	RevertCustom                    // Custom error declared in the contract ABI
+)
+
+// Revert is the decoded return data of a reverted call. Depending on Kind, the
+// reason, panic code or custom error fields are set.
+type Revert struct {
+	Kind RevertKind
+	Data []byte // Raw revert data
+
+	Reason string   // Message of an Error(string) revert
+	Code   *big.Int // Code of a Panic(uint256) revert
+
+	Error *Error        // Custom error definition
+	Args  []interface{} // Unpacked arguments of the custom error
+}
+
+// PanicReason returns the meaning of the panic code, or an empty string if the
+// revert is not a panic.
+func (r *Revert) PanicReason() string {
+	if r.Kind != RevertPanic {
+		return ""
+	}
+	if r.Code.IsUint64() {
+		if reason, ok := panicReasons[r.Code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return fmt.Sprintf("unknown panic code: %#x", r.Code)
+}
+
+// String implements fmt.Stringer, formatting the revert the way solidity tools
+// usually display them.
+func (r *Revert) String() string {
+	switch r.Kind {
+	case RevertReason:
+		return fmt.Sprintf("Error(%q)", r.Reason)
+	case RevertPanic:
+		return fmt.Sprintf("Panic(%#x): %s", r.Code, r.PanicReason())
+	case RevertCustom:
+		args := make([]string, len(r.Args))
+		for i, arg := range r.Args {
+			args[i] = fmt.Sprintf("%v", arg)
+		}
+		return fmt.Sprintf("%s(%s)", r.Error.Name, strings.Join(args, ", "))
+	default:
+		return fmt.Sprintf("0x%x", r.Data)
+	}
+}
+
+// UnpackRevert decodes the return data of a reverted call. Besides the builtin
+// Error(string) and Panic(uint256) errors, custom errors declared in the ABI are
+// resolved; the ABI may be nil if those are not needed. Revert data not matching
+// any known error is returned with RevertUnknown, an error is only returned if
+// the data matches an error selector but cannot be unpacked.
+func (abi *ABI) UnpackRevert(data []byte) (*Revert, error) {
+	revert := &Revert{Kind: RevertUnknown, Data: data}
+	if len(data) < 4 {
+		return revert, nil
+	}
+	switch {
+	case bytes.Equal(data[:4], revertSelector):
+		reason, err := UnpackRevert(data)
+		if err != nil {
+			return nil, err
+		}
+		revert.Kind, revert.Reason = RevertReason, reason
+
+	case bytes.Equal(data[:4], panicSelector):
+		typ, _ := NewType("uint256", "", nil)
+		unpacked, err := (Arguments{{Type: typ}}).Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		revert.Kind, revert.Code = RevertPanic, unpacked[0].(*big.Int)
+
+	case abi != nil:
+		errABI, err := abi.ErrorByID(data)
+		if err != nil {
+			return revert, nil
+		}
+		args, err := errABI.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack error %s: %v", errABI.Name, err)
+		}
+		revert.Kind, revert.Error, revert.Args = RevertCustom, errABI, args
+	}
+	return revert, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestUnpackRevertData(t *testing.T) {
+	contract, err := JSON(strings.NewReader(`[{"type":"error","name":"Insufficient","inputs":[{"name":"have","type":"uint256"},{"name":"want","type":"uint256"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input string
+		abi   *ABI
+		kind  RevertKind
+		str   string
+	}{
+		// Error("revert reason")
+		{"08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000", nil, RevertReason, `Error("revert reason")`},
+		// Panic(0x11)
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000011", nil, RevertPanic, "Panic(0x11): arithmetic underflow or overflow"},
+		// Panic(0x99)
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000099", nil, RevertPanic, "Panic(0x99): unknown panic code: 0x99"},
+		// Insufficient(1, 2)
+		{"e86208000000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002", &contract, RevertCustom, "Insufficient(1, 2)"},
+		// Insufficient(1, 2) without an ABI to resolve it
+		{"e86208000000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002", nil, RevertUnknown, ""},
+		// Empty revert
+		{"", &contract, RevertUnknown, "0x"},
+	}
+	for i, tt := range tests {
+		revert, err := tt.abi.UnpackRevert(common.Hex2Bytes(tt.input))
+		if err != nil {
+			t.Fatalf("test %d: failed to unpack: %v", i, err)
+		}
+		if revert.Kind != tt.kind {
+			t.Errorf("test %d: kind mismatch: have %d, want %d", i, revert.Kind, tt.kind)
+		}
+		if tt.str != "" && revert.String() != tt.str {
+			t.Errorf("test %d: string mismatch: have %q, want %q", i, revert.String(), tt.str)
+		}
+	}
+	// Data matching a known selector but failing to unpack must error
+	if _, err := contract.UnpackRevert(common.Hex2Bytes("4e487b71")); err == nil {
+		t.Fatalf("truncated panic unpacked")
+	}
+}
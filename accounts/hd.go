@@ -17,12 +17,19 @@
 package accounts
 
 import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // DefaultRootDerivationPath is the root path to which custom derivation endpoints
@@ -178,3 +185,52 @@ func LedgerLiveIterator(base DerivationPath) func() DerivationPath {
 		return path
 	}
 }
+
+// errInvalidChildKey is returned if a derivation step yields an invalid key,
+// which happens with a probability lower than 1 in 2^127.
+var errInvalidChildKey = errors.New("invalid derived key")
+
+// DeriveKey derives the private key at the given path from a BIP-32 seed, e.g.
+// the seed of a BIP-39 mnemonic.
+func DeriveKey(seed []byte, path DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	var (
+		n     = crypto.S256().Params().N
+		key   = new(big.Int).SetBytes(sum[:32])
+		chain = sum[32:]
+	)
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0x00}, common.LeftPadBytes(key.Bytes(), 32)...)
+		} else {
+			priv, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&priv.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chain)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, errInvalidChildKey
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, errInvalidChildKey
+		}
+		chain = sum[32:]
+	}
+	return crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+}
@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // Tests that HD derivation paths can be correctly parsed into our internal binary
@@ -116,3 +119,25 @@ func TestHdPathIteration(t *testing.T) {
 			"m/44'/60'/8'/0/0", "m/44'/60'/9'/0/0",
 		})
 }
+
+func TestDeriveKey(t *testing.T) {
+	// Test vector 1 of BIP-32
+	seed := common.FromHex("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path DerivationPath
+		key  string
+	}{
+		{DerivationPath{}, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{DerivationPath{0x80000000}, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{DerivationPath{0x80000000, 1}, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+	}
+	for i, tt := range tests {
+		key, err := DeriveKey(seed, tt.path)
+		if err != nil {
+			t.Fatalf("test %d: derivation failed: %v", i, err)
+		}
+		if have := common.Bytes2Hex(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("test %d: key mismatch: have %s, want %s", i, have, tt.key)
+		}
+	}
+}
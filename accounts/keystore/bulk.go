@@ -0,0 +1,234 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// ProgressFunc is called by the bulk operations of the keystore after each
+// processed key, with the number of keys done so far and the total.
+type ProgressFunc func(done, total int)
+
+// BulkImportError is a failure to import one of the keys of a bulk import.
+type BulkImportError struct {
+	Source string // Origin of the key: its index, file or derivation path
+	Err    error
+}
+
+func (e *BulkImportError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e *BulkImportError) Unwrap() error {
+	return e.Err
+}
+
+// BulkImportResult is the outcome of a bulk import. Keys failing to import do
+// not abort the import, but are reported along with the imported ones.
+type BulkImportResult struct {
+	Imported   []accounts.Account // Accounts added to the keystore
+	Duplicates []accounts.Account // Accounts already in the keystore or repeated in the input
+	Failed     []*BulkImportError // Keys that could not be imported
+}
+
+// bulkImport tracks the progress of a bulk import, deduplicating the imported
+// keys by address.
+type bulkImport struct {
+	ks       *KeyStore
+	result   *BulkImportResult
+	seen     map[common.Address]bool
+	progress ProgressFunc
+	total    int
+}
+
+func (ks *KeyStore) newBulkImport(total int, progress ProgressFunc) *bulkImport {
+	return &bulkImport{
+		ks:       ks,
+		result:   new(BulkImportResult),
+		seen:     make(map[common.Address]bool),
+		progress: progress,
+		total:    total,
+	}
+}
+
+// add records the outcome of importing the key from the given source.
+func (b *bulkImport) add(source string, account accounts.Account, err error) {
+	switch {
+	case errors.Is(err, ErrAccountAlreadyExists):
+		b.result.Duplicates = append(b.result.Duplicates, account)
+	case err != nil:
+		b.result.Failed = append(b.result.Failed, &BulkImportError{Source: source, Err: err})
+	default:
+		b.result.Imported = append(b.result.Imported, account)
+	}
+	if account.Address != (common.Address{}) {
+		b.seen[account.Address] = true
+	}
+	if b.progress != nil {
+		b.progress(len(b.result.Imported)+len(b.result.Duplicates)+len(b.result.Failed), b.total)
+	}
+}
+
+// duplicate reports whether the address was already seen in the input, and
+// records it as a duplicate if so.
+func (b *bulkImport) duplicate(source string, addr common.Address) bool {
+	if !b.seen[addr] {
+		return false
+	}
+	b.add(source, accounts.Account{Address: addr}, ErrAccountAlreadyExists)
+	return true
+}
+
+// ImportHexKeys imports the given hex encoded private keys, with or without 0x
+// prefix, encrypting them with the passphrase.
+func (ks *KeyStore) ImportHexKeys(keys []string, passphrase string, progress ProgressFunc) *BulkImportResult {
+	b := ks.newBulkImport(len(keys), progress)
+	for i, hexkey := range keys {
+		source := fmt.Sprintf("key %d", i)
+
+		priv, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexkey), "0x"))
+		if err != nil {
+			b.add(source, accounts.Account{}, err)
+			continue
+		}
+		if !b.duplicate(source, crypto.PubkeyToAddress(priv.PublicKey)) {
+			account, err := ks.ImportECDSA(priv, passphrase)
+			b.add(source, account, err)
+		}
+		crypto.ZeroKey(priv)
+	}
+	return b.result
+}
+
+// ImportKeyDir imports all key files of a keystore directory, decrypting them
+// with the passphrase and encrypting them with the new passphrase. Hidden files,
+// editor backups and subdirectories are skipped like in the keystore itself.
+func (ks *KeyStore) ImportKeyDir(dir string, passphrase, newPassphrase string, progress ProgressFunc) (*BulkImportResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !nonKeyFile(entry) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	b := ks.newBulkImport(len(files), progress)
+	for _, file := range files {
+		keyJSON, err := os.ReadFile(file)
+		if err != nil {
+			b.add(file, accounts.Account{}, err)
+			continue
+		}
+		// Check for duplicates before paying for the decryption
+		if addr, err := keyFileAddress(keyJSON); err == nil {
+			if b.duplicate(file, addr) {
+				continue
+			}
+			if ks.HasAddress(addr) {
+				b.add(file, accounts.Account{Address: addr}, ErrAccountAlreadyExists)
+				continue
+			}
+		}
+		account, err := ks.Import(keyJSON, passphrase, newPassphrase)
+		b.add(file, account, err)
+	}
+	return b.result, nil
+}
+
+// keyFileAddress returns the address stored in plain text in a key file.
+func keyFileAddress(keyJSON []byte) (common.Address, error) {
+	var key struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyJSON, &key); err != nil {
+		return common.Address{}, err
+	}
+	if !common.IsHexAddress(key.Address) {
+		return common.Address{}, errors.New("invalid address")
+	}
+	return common.HexToAddress(key.Address), nil
+}
+
+// ImportMnemonic imports the first n keys derived from a BIP-39 mnemonic and
+// its optional password, iterating the last component of the base derivation
+// path, e.g. accounts.DefaultBaseDerivationPath. The keys are encrypted with the
+// passphrase.
+func (ks *KeyStore) ImportMnemonic(mnemonic, password string, base accounts.DerivationPath, n int, passphrase string, progress ProgressFunc) (*BulkImportResult, error) {
+	if len(base) == 0 {
+		return nil, errors.New("empty derivation path")
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		b    = ks.newBulkImport(n, progress)
+		next = accounts.DefaultIterator(base)
+	)
+	for i := 0; i < n; i++ {
+		path := next()
+		source := path.String()
+
+		priv, err := accounts.DeriveKey(seed, path)
+		if err != nil {
+			b.add(source, accounts.Account{}, err)
+			continue
+		}
+		if !b.duplicate(source, crypto.PubkeyToAddress(priv.PublicKey)) {
+			account, err := ks.ImportECDSA(priv, passphrase)
+			b.add(source, account, err)
+		}
+		crypto.ZeroKey(priv)
+	}
+	return b.result, nil
+}
+
+// ExportAccounts writes the keys of the given accounts into the directory as key
+// files encrypted with newPassphrase, which a keystore can import or use as its
+// key directory. It returns the paths of the written files, stopping at the
+// first account that fails to export.
+func (ks *KeyStore) ExportAccounts(accs []accounts.Account, passphrase, newPassphrase string, dir string, progress ProgressFunc) ([]string, error) {
+	var files []string
+	for i, a := range accs {
+		keyJSON, err := ks.Export(a, passphrase, newPassphrase)
+		if err != nil {
+			return files, fmt.Errorf("account %x: %w", a.Address, err)
+		}
+		file := filepath.Join(dir, keyFileName(a.Address))
+		if err := writeKeyFile(file, keyJSON); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+		if progress != nil {
+			progress(i+1, len(accs))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestImportHexKeys(t *testing.T) {
+	_, ks := tmpKeyStore(t, true)
+
+	var (
+		key  = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+		keys = []string{key, "0x" + key, "invalid", "0x289c2857d4598e37fb9647507e47a309d6133539bf21a8b9cb6df88fd5232032"}
+		done int
+	)
+	res := ks.ImportHexKeys(keys, "foo", func(n, total int) {
+		if total != len(keys) || n != done+1 {
+			t.Fatalf("wrong progress: have %d/%d, want %d/%d", n, total, done+1, len(keys))
+		}
+		done = n
+	})
+	if len(res.Imported) != 2 || len(res.Duplicates) != 1 || len(res.Failed) != 1 {
+		t.Fatalf("wrong import result: have %d imported, %d duplicates, %d failed", len(res.Imported), len(res.Duplicates), len(res.Failed))
+	}
+	if res.Failed[0].Source != "key 2" {
+		t.Fatalf("wrong failed key: have %q, want %q", res.Failed[0].Source, "key 2")
+	}
+	// Importing again only yields duplicates
+	res = ks.ImportHexKeys(keys[:1], "foo", nil)
+	if len(res.Imported) != 0 || len(res.Duplicates) != 1 {
+		t.Fatalf("wrong reimport result: have %d imported, %d duplicates", len(res.Imported), len(res.Duplicates))
+	}
+	if len(ks.Accounts()) != 2 {
+		t.Fatalf("wrong number of accounts: have %d, want 2", len(ks.Accounts()))
+	}
+}
+
+func TestImportMnemonicExportImport(t *testing.T) {
+	_, ks := tmpKeyStore(t, true)
+
+	mnemonic := "test test test test test test test test test test test junk"
+	if _, err := ks.ImportMnemonic(mnemonic+" test", "", accounts.DefaultBaseDerivationPath, 3, "foo", nil); err == nil {
+		t.Fatal("imported invalid mnemonic")
+	}
+	res, err := ks.ImportMnemonic(mnemonic, "", accounts.DefaultBaseDerivationPath, 3, "foo", nil)
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if len(res.Imported) != 3 {
+		t.Fatalf("wrong number of imported accounts: have %d, want 3", len(res.Imported))
+	}
+	want := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	if res.Imported[0].Address != want {
+		t.Fatalf("wrong first account: have %x, want %x", res.Imported[0].Address, want)
+	}
+	// Export two accounts and import them into another keystore
+	dir := t.TempDir()
+	files, err := ks.ExportAccounts(res.Imported[:2], "foo", "bar", dir, nil)
+	if err != nil {
+		t.Fatalf("failed to export accounts: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("wrong number of exported files: have %d, want 2", len(files))
+	}
+	_, other := tmpKeyStore(t, true)
+	_, key, err := ks.getDecryptedKey(res.Imported[1], "foo")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %v", err)
+	}
+	if _, err := other.ImportECDSA(key.PrivateKey, "baz"); err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+	dirRes, err := other.ImportKeyDir(dir, "bar", "baz", nil)
+	if err != nil {
+		t.Fatalf("failed to import key directory: %v", err)
+	}
+	if len(dirRes.Imported) != 1 || dirRes.Imported[0].Address != want || len(dirRes.Duplicates) != 1 || len(dirRes.Failed) != 0 {
+		t.Fatalf("wrong directory import result: %+v", dirRes)
+	}
+	if err := other.Unlock(dirRes.Imported[0], "baz"); err != nil {
+		t.Fatalf("failed to unlock imported account: %v", err)
+	}
+}
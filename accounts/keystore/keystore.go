@@ -242,7 +242,7 @@ func (ks *KeyStore) Delete(a accounts.Account, passphrase string) error {
 	// immediately afterwards.
 	a, key, err := ks.getDecryptedKey(a, passphrase)
 	if key != nil {
-		zeroKey(key.PrivateKey)
+		crypto.ZeroKey(key.PrivateKey)
 	}
 	if err != nil {
 		return err
@@ -296,7 +296,7 @@ func (ks *KeyStore) SignHashWithPassphrase(a accounts.Account, passphrase string
 	if err != nil {
 		return nil, err
 	}
-	defer zeroKey(key.PrivateKey)
+	defer crypto.ZeroKey(key.PrivateKey)
 	return crypto.Sign(hash, key.PrivateKey)
 }
 
@@ -307,7 +307,7 @@ func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string,
 	if err != nil {
 		return nil, err
 	}
-	defer zeroKey(key.PrivateKey)
+	defer crypto.ZeroKey(key.PrivateKey)
 	// Depending on the presence of the chain ID, sign with or without replay protection.
 	signer := types.LatestSignerForChainID(chainID)
 	return types.SignTx(tx, signer, key.PrivateKey)
@@ -350,7 +350,7 @@ func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout t
 		if u.abort == nil {
 			// The address was unlocked indefinitely, so unlocking
 			// it with a timeout would be confusing.
-			zeroKey(key.PrivateKey)
+			crypto.ZeroKey(key.PrivateKey)
 			return nil
 		}
 		// Terminate the expire goroutine and replace it below.
@@ -397,7 +397,7 @@ func (ks *KeyStore) expire(addr common.Address, u *unlocked, timeout time.Durati
 		// because the map stores a new pointer every time the key is
 		// unlocked.
 		if ks.unlocked[addr] == u {
-			zeroKey(u.PrivateKey)
+			crypto.ZeroKey(u.PrivateKey)
 			delete(ks.unlocked, addr)
 		}
 		ks.mu.Unlock()
@@ -437,7 +437,7 @@ func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string)
 func (ks *KeyStore) Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
 	key, err := DecryptKey(keyJSON, passphrase)
 	if key != nil && key.PrivateKey != nil {
-		defer zeroKey(key.PrivateKey)
+		defer crypto.ZeroKey(key.PrivateKey)
 	}
 	if err != nil {
 		return accounts.Account{}, err
@@ -505,11 +505,3 @@ func (ks *KeyStore) isUpdating() bool {
 	defer ks.mu.RUnlock()
 	return ks.updating
 }
-
-// zeroKey zeroes a private key in memory.
-func zeroKey(k *ecdsa.PrivateKey) {
-	b := k.D.Bits()
-	for i := range b {
-		b[i] = 0
-	}
-}
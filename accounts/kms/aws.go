@@ -0,0 +1,212 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package kms implements transaction signing backed by cloud key management
+// services, where the private key never leaves the provider.
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The AWS KMS key spec of Ethereum compatible keys.
+const awsKeySpec = "ECC_SECG_P256K1"
+
+// awsServiceName is the signing name of the KMS service.
+const awsServiceName = "kms"
+
+var (
+	errNoRegion      = errors.New("no AWS region configured")
+	errNoCredentials = errors.New("no AWS credentials configured")
+)
+
+// AWSConfig contains the settings of an AWS KMS backed signer.
+type AWSConfig struct {
+	KeyID    string // Key ID, key ARN or alias of an ECC_SECG_P256K1 key
+	Endpoint string // Endpoint override, defaults to the regional KMS endpoint
+}
+
+// AWSSigner signs digests with a secp256k1 key stored in AWS KMS. It implements
+// types.HashSigner and can therefore be used with types.SignTxRemote and
+// bind.NewHashSignerTransactor.
+type AWSSigner struct {
+	keyID    string
+	region   string
+	endpoint string
+	creds    aws.CredentialsProvider
+	client   aws.HTTPClient
+	signer   *v4.Signer
+
+	pubkey  *ecdsa.PublicKey
+	address common.Address
+}
+
+// NewAWSSigner creates a signer for the given KMS key. The region, credentials
+// and HTTP client are taken from cfg, which is usually obtained through
+// config.LoadDefaultConfig. The public key is fetched from KMS once, to derive
+// the account address.
+func NewAWSSigner(ctx context.Context, cfg aws.Config, conf AWSConfig) (*AWSSigner, error) {
+	if cfg.Region == "" {
+		return nil, errNoRegion
+	}
+	if cfg.Credentials == nil {
+		return nil, errNoCredentials
+	}
+	s := &AWSSigner{
+		keyID:    conf.KeyID,
+		region:   cfg.Region,
+		endpoint: conf.Endpoint,
+		creds:    cfg.Credentials,
+		client:   cfg.HTTPClient,
+		signer:   v4.NewSigner(),
+	}
+	if s.endpoint == "" {
+		s.endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", s.region)
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	pubkey, err := s.fetchPublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.pubkey = pubkey
+	s.address = crypto.PubkeyToAddress(*pubkey)
+	return s, nil
+}
+
+// Address implements types.HashSigner, returning the account of the KMS key.
+func (s *AWSSigner) Address() common.Address {
+	return s.address
+}
+
+// PublicKey returns the public key of the KMS key.
+func (s *AWSSigner) PublicKey() *ecdsa.PublicKey {
+	return s.pubkey
+}
+
+// SignHash implements types.HashSigner, requesting an ECDSA signature over the
+// given digest from KMS.
+func (s *AWSSigner) SignHash(ctx context.Context, hash common.Hash) (*big.Int, *big.Int, error) {
+	req := map[string]interface{}{
+		"KeyId":            s.keyID,
+		"Message":          hash[:],
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}
+	var res struct {
+		Signature []byte
+	}
+	if err := s.call(ctx, "Sign", req, &res); err != nil {
+		return nil, nil, err
+	}
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(res.Signature, &sig); err != nil {
+		return nil, nil, fmt.Errorf("invalid KMS signature: %v", err)
+	}
+	return sig.R, sig.S, nil
+}
+
+// fetchPublicKey retrieves the public key of the configured KMS key.
+func (s *AWSSigner) fetchPublicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	var res struct {
+		KeySpec   string
+		PublicKey []byte
+	}
+	if err := s.call(ctx, "GetPublicKey", map[string]string{"KeyId": s.keyID}, &res); err != nil {
+		return nil, err
+	}
+	if res.KeySpec != "" && res.KeySpec != awsKeySpec {
+		return nil, fmt.Errorf("unsupported KMS key spec %q, want %s", res.KeySpec, awsKeySpec)
+	}
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(res.PublicKey, &info); err != nil {
+		return nil, fmt.Errorf("invalid KMS public key: %v", err)
+	}
+	return crypto.UnmarshalPubkey(info.PublicKey.Bytes)
+}
+
+// call invokes a KMS API action using the JSON 1.1 protocol, signing the
+// request with SigV4.
+func (s *AWSSigner) call(ctx context.Context, action string, args interface{}, result interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), awsServiceName, s.region, time.Now()); err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var kmsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &kmsErr) == nil && kmsErr.Type != "" {
+			return fmt.Errorf("kms %s failed: %s: %s", action, kmsErr.Type, kmsErr.Message)
+		}
+		return fmt.Errorf("kms %s failed: %s", action, resp.Status)
+	}
+	return json.Unmarshal(data, result)
+}
+
+// Ensure AWSSigner can be used wherever a remote signer is expected.
+var _ types.HashSigner = (*AWSSigner)(nil)
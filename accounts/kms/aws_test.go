@@ -0,0 +1,124 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeKMS emulates the subset of the AWS KMS API used by AWSSigner. It always
+// returns high-S signatures to exercise normalization.
+type fakeKMS struct {
+	key *ecdsa.PrivateKey
+}
+
+func (f *fakeKMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+		http.Error(w, `{"__type":"AccessDeniedException","message":"unsigned"}`, http.StatusForbidden)
+		return
+	}
+	var req struct {
+		KeyId   string
+		Message []byte
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "TrentService.GetPublicKey":
+		der, _ := asn1.Marshal(struct {
+			Algorithm pkix.AlgorithmIdentifier
+			PublicKey asn1.BitString
+		}{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}},
+			PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&f.key.PublicKey), BitLength: 65 * 8},
+		})
+		json.NewEncoder(w).Encode(map[string]interface{}{"KeySpec": awsKeySpec, "PublicKey": der})
+	case "TrentService.Sign":
+		sig, _ := crypto.Sign(req.Message, f.key)
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+		s.Sub(crypto.S256().Params().N, s)
+		der, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		json.NewEncoder(w).Encode(map[string]interface{}{"Signature": der})
+	default:
+		http.Error(w, `{"__type":"UnknownOperationException"}`, http.StatusBadRequest)
+	}
+}
+
+func newTestSigner(t *testing.T) (*AWSSigner, *ecdsa.PrivateKey) {
+	key, _ := crypto.GenerateKey()
+	srv := httptest.NewServer(&fakeKMS{key: key})
+	t.Cleanup(srv.Close)
+
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+	}
+	signer, err := NewAWSSigner(context.Background(), cfg, AWSConfig{KeyID: "alias/test", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return signer, key
+}
+
+func TestAWSSignerAddress(t *testing.T) {
+	signer, key := newTestSigner(t)
+	if have, want := signer.Address(), crypto.PubkeyToAddress(key.PublicKey); have != want {
+		t.Fatalf("address mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestAWSSignerSignTx(t *testing.T) {
+	signer, key := newTestSigner(t)
+
+	chainID := big.NewInt(1337)
+	txSigner := types.LatestSignerForChainID(chainID)
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, To: &common.Address{1}, Gas: 21000, GasPrice: big.NewInt(1)})
+
+	signed, err := types.SignTxRemote(context.Background(), tx, txSigner, signer)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	from, err := types.Sender(txSigner, signed)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); from != want {
+		t.Fatalf("sender mismatch: have %x, want %x", from, want)
+	}
+	if got := signed.ChainId(); got.Cmp(chainID) != 0 {
+		t.Fatalf("chain id mismatch: have %v, want %v", got, chainID)
+	}
+	_, _, s := signed.RawSignatureValues()
+	if s.Cmp(new(big.Int).Rsh(crypto.S256().Params().N, 1)) > 0 {
+		t.Fatalf("signature not normalized to low-S")
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package safe implements building, hashing and signing of Gnosis Safe multisig
+// transactions.
+//
+// A Safe transaction is described by a SafeTx EIP-712 message. Owners sign the
+// message hash, either directly or through eth_sign, and the collected
+// signatures are submitted sorted by owner address in an execTransaction call.
+package safe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Operation is the type of call a Safe performs when executing a transaction.
+type Operation uint8
+
+const (
+	Call         Operation = 0
+	DelegateCall Operation = 1
+)
+
+// safeABI contains the Safe contract methods used by this package.
+const safeABI = `[
+	{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[
+		{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+		{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+		{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},
+		{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]},
+	{"type":"function","name":"nonce","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"approveHash","stateMutability":"nonpayable","inputs":[{"name":"hashToApprove","type":"bytes32"}],"outputs":[]}
+]`
+
+// ABI is the parsed interface of the Safe methods used by this package.
+var ABI abi.ABI
+
+func init() {
+	var err error
+	if ABI, err = abi.JSON(strings.NewReader(safeABI)); err != nil {
+		panic(err)
+	}
+}
+
+// safeTxTypes are the EIP-712 types of a Safe transaction.
+var safeTxTypes = []apitypes.Type{
+	{Name: "to", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "data", Type: "bytes"},
+	{Name: "operation", Type: "uint8"},
+	{Name: "safeTxGas", Type: "uint256"},
+	{Name: "baseGas", Type: "uint256"},
+	{Name: "gasPrice", Type: "uint256"},
+	{Name: "gasToken", Type: "address"},
+	{Name: "refundReceiver", Type: "address"},
+	{Name: "nonce", Type: "uint256"},
+}
+
+// Transaction is a Safe transaction. Nil numeric fields are treated as zero.
+type Transaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      Operation
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+func bigOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
+
+// TypedData returns the SafeTx EIP-712 message of the transaction for the Safe
+// deployed at the given address. The chain ID is part of the domain since Safe
+// version 1.3.0, older versions must be given a nil chain ID.
+//
+// The returned message can be passed to external signers supporting
+// eth_signTypedData.
+func (tx *Transaction) TypedData(chainID *big.Int, safe common.Address) apitypes.TypedData {
+	domainTypes := []apitypes.Type{{Name: "verifyingContract", Type: "address"}}
+	domain := apitypes.TypedDataDomain{VerifyingContract: safe.Hex()}
+	if chainID != nil {
+		domainTypes = append([]apitypes.Type{{Name: "chainId", Type: "uint256"}}, domainTypes...)
+		domain.ChainId = (*math.HexOrDecimal256)(chainID)
+	}
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": domainTypes,
+			"SafeTx":       safeTxTypes,
+		},
+		PrimaryType: "SafeTx",
+		Domain:      domain,
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          bigOrZero(tx.Value).String(),
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      fmt.Sprint(tx.Operation),
+			"safeTxGas":      bigOrZero(tx.SafeTxGas).String(),
+			"baseGas":        bigOrZero(tx.BaseGas).String(),
+			"gasPrice":       bigOrZero(tx.GasPrice).String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          bigOrZero(tx.Nonce).String(),
+		},
+	}
+}
+
+// Hash returns the EIP-712 hash of the transaction, which is signed by the Safe
+// owners.
+func (tx *Transaction) Hash(chainID *big.Int, safe common.Address) (common.Hash, error) {
+	hash, _, err := apitypes.TypedDataAndHash(tx.TypedData(chainID, safe))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// ExecData packs the calldata of the execTransaction call submitting the
+// transaction with the given encoded owner signatures.
+func (tx *Transaction) ExecData(signatures []byte) ([]byte, error) {
+	return ABI.Pack("execTransaction", tx.To, bigOrZero(tx.Value), tx.Data, uint8(tx.Operation),
+		bigOrZero(tx.SafeTxGas), bigOrZero(tx.BaseGas), bigOrZero(tx.GasPrice), tx.GasToken, tx.RefundReceiver, signatures)
+}
+
+// Sign signs the transaction hash with the given owner key, returning the
+// signature in the format expected by the Safe contract.
+func Sign(hash common.Hash, key *ecdsa.PrivateKey) ([]byte, error) {
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// EthSignSignature converts a signature of the transaction hash made through
+// eth_sign (i.e. accounts.Wallet.SignText) to the format expected by the Safe
+// contract.
+func EthSignSignature(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] < 27 {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	sig[crypto.RecoveryIDOffset] += 4
+	return sig, nil
+}
+
+// Signatures collects the owner signatures of a Safe transaction.
+type Signatures struct {
+	hash common.Hash
+	sigs map[common.Address][]byte
+}
+
+// NewSignatures creates an empty signature set for the given transaction hash.
+func NewSignatures(hash common.Hash) *Signatures {
+	return &Signatures{hash: hash, sigs: make(map[common.Address][]byte)}
+}
+
+// Add recovers the owner of the given signature and adds it to the set. Both
+// plain signatures of the hash and eth_sign signatures are accepted, see Sign
+// and EthSignSignature.
+func (s *Signatures) Add(sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	var (
+		hash = s.hash[:]
+		rsv  = common.CopyBytes(sig)
+		v    = sig[crypto.RecoveryIDOffset]
+	)
+	switch v {
+	case 27, 28:
+		rsv[crypto.RecoveryIDOffset] = v - 27
+	case 31, 32:
+		rsv[crypto.RecoveryIDOffset] = v - 31
+		hash = accounts.TextHash(hash)
+	default:
+		return common.Address{}, fmt.Errorf("unsupported signature type %d", v)
+	}
+	pub, err := crypto.SigToPub(hash, rsv)
+	if err != nil {
+		return common.Address{}, err
+	}
+	owner := crypto.PubkeyToAddress(*pub)
+	s.sigs[owner] = common.CopyBytes(sig)
+	return owner, nil
+}
+
+// AddApproval adds an owner who approved the transaction hash on chain through
+// approveHash, or who is the sender of the execTransaction call.
+func (s *Signatures) AddApproval(owner common.Address) {
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig[12:32], owner[:])
+	sig[crypto.RecoveryIDOffset] = 1
+	s.sigs[owner] = sig
+}
+
+// Len returns the number of owners in the set.
+func (s *Signatures) Len() int {
+	return len(s.sigs)
+}
+
+// Owners returns the owners in the set in ascending order.
+func (s *Signatures) Owners() []common.Address {
+	owners := make([]common.Address, 0, len(s.sigs))
+	for owner := range s.sigs {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return bytes.Compare(owners[i][:], owners[j][:]) < 0
+	})
+	return owners
+}
+
+// Bytes returns the concatenated signatures sorted by owner address, as required
+// by execTransaction.
+func (s *Signatures) Bytes() ([]byte, error) {
+	if len(s.sigs) == 0 {
+		return nil, errors.New("no signatures")
+	}
+	var out []byte
+	for _, owner := range s.Owners() {
+		out = append(out, s.sigs[owner]...)
+	}
+	return out, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package safe
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testSafe = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	testTx   = &Transaction{
+		To:        common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		Value:     big.NewInt(1e18),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+		Operation: Call,
+		Nonce:     big.NewInt(3),
+	}
+)
+
+// safeTxHash computes the transaction hash the way the Safe contract does.
+func safeTxHash(tx *Transaction, chainID *big.Int, safe common.Address) common.Hash {
+	word := func(x *big.Int) []byte { return math.U256Bytes(new(big.Int).Set(bigOrZero(x))) }
+	addr := func(a common.Address) []byte { return common.LeftPadBytes(a[:], 32) }
+
+	domainSep := crypto.Keccak256(
+		common.FromHex("0x47e79534a245952e8b16893a336b85a3d9ea9fa8c573f3d803afb92a79469218"),
+		word(chainID), addr(safe),
+	)
+	structHash := crypto.Keccak256(
+		common.FromHex("0xbb8310d486368db6bd6f849402fdd73ad53d316b5a4b2644ad6efe0f941286d8"),
+		addr(tx.To), word(tx.Value), crypto.Keccak256(tx.Data), word(big.NewInt(int64(tx.Operation))),
+		word(tx.SafeTxGas), word(tx.BaseGas), word(tx.GasPrice), addr(tx.GasToken), addr(tx.RefundReceiver), word(tx.Nonce),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSep, structHash)
+}
+
+func TestTransactionHash(t *testing.T) {
+	hash, err := testTx.Hash(big.NewInt(1), testSafe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := safeTxHash(testTx, big.NewInt(1), testSafe); hash != want {
+		t.Fatalf("hash mismatch: have %x, want %x", hash, want)
+	}
+	// Different chains must produce different hashes
+	if other, _ := testTx.Hash(big.NewInt(5), testSafe); other == hash {
+		t.Fatal("chain ID not part of the hash")
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	hash, err := testTx.Hash(big.NewInt(1), testSafe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	approver := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	sigs := NewSignatures(hash)
+	sig1, _ := Sign(hash, key1)
+	if owner, err := sigs.Add(sig1); err != nil || owner != crypto.PubkeyToAddress(key1.PublicKey) {
+		t.Fatalf("failed to add signature: %v (owner %x)", err, owner)
+	}
+	// eth_sign signatures sign the text hash of the transaction hash
+	raw, _ := crypto.Sign(accounts.TextHash(hash[:]), key2)
+	sig2, _ := EthSignSignature(raw)
+	if owner, err := sigs.Add(sig2); err != nil || owner != crypto.PubkeyToAddress(key2.PublicKey) {
+		t.Fatalf("failed to add eth_sign signature: %v (owner %x)", err, owner)
+	}
+	sigs.AddApproval(approver)
+
+	if sigs.Len() != 3 {
+		t.Fatalf("signature count mismatch: have %d, want 3", sigs.Len())
+	}
+	enc, err := sigs.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signatures must be sorted by owner, the approver has the lowest address
+	owners := sigs.Owners()
+	if owners[0] != approver || bytes.Compare(owners[1][:], owners[2][:]) >= 0 {
+		t.Fatalf("owners not sorted: %x", owners)
+	}
+	if !bytes.Equal(enc[12:32], approver[:]) || enc[64] != 1 {
+		t.Fatalf("invalid approval signature: %x", enc[:65])
+	}
+	if _, err := sigs.Add(make([]byte, 65)); err == nil {
+		t.Fatal("contract signature accepted")
+	}
+	// Pack the execution call and check that it round trips
+	data, err := testTx.ExecData(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := ABI.Methods["execTransaction"].Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0].(common.Address) != testTx.To || !bytes.Equal(args[2].([]byte), testTx.Data) || !bytes.Equal(args[9].([]byte), enc) {
+		t.Fatalf("execTransaction arguments mismatch: %v", args)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package walletconnect
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// envelopeType0 is the envelope of messages encrypted with a key known to both
+// peers, the only kind exchanged by the sign protocol once paired.
+const envelopeType0 = 0
+
+var errInvalidEnvelope = errors.New("invalid envelope")
+
+// symKey is a symmetric key shared by two peers, identifying their topic.
+type symKey [32]byte
+
+// newSymKey generates a random symmetric key.
+func newSymKey() (symKey, error) {
+	var key symKey
+	_, err := io.ReadFull(rand.Reader, key[:])
+	return key, err
+}
+
+// topic returns the relay topic of the messages encrypted with the key.
+func (k symKey) topic() string {
+	hash := sha256.Sum256(k[:])
+	return hex.EncodeToString(hash[:])
+}
+
+// keyPair is an X25519 key pair used to agree on a session key.
+type keyPair struct {
+	private [32]byte
+	public  [32]byte
+}
+
+// newKeyPair generates a random X25519 key pair.
+func newKeyPair() (*keyPair, error) {
+	kp := new(keyPair)
+	if _, err := io.ReadFull(rand.Reader, kp.private[:]); err != nil {
+		return nil, err
+	}
+	public, err := curve25519.X25519(kp.private[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	copy(kp.public[:], public)
+	return kp, nil
+}
+
+// sharedKey derives the symmetric key shared with the owner of the given public
+// key, as the HKDF-SHA256 expansion of the X25519 shared secret.
+func (kp *keyPair) sharedKey(peer [32]byte) (symKey, error) {
+	var key symKey
+	secret, err := curve25519.X25519(kp.private[:], peer[:])
+	if err != nil {
+		return key, err
+	}
+	_, err = io.ReadFull(hkdf.New(sha256.New, secret, nil, nil), key[:])
+	return key, err
+}
+
+// seal encrypts the message with the key into a base64 encoded type 0 envelope.
+func (k symKey) seal(message []byte) (string, error) {
+	aead, err := chacha20poly1305.New(k[:])
+	if err != nil {
+		return "", err
+	}
+	envelope := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(message)+aead.Overhead())
+	envelope[0] = envelopeType0
+	if _, err := io.ReadFull(rand.Reader, envelope[1:]); err != nil {
+		return "", err
+	}
+	envelope = aead.Seal(envelope, envelope[1:], message, nil)
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// open decrypts a base64 encoded type 0 envelope with the key.
+func (k symKey) open(message string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(k[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < 1+aead.NonceSize()+aead.Overhead() || envelope[0] != envelopeType0 {
+		return nil, errInvalidEnvelope
+	}
+	nonce, sealed := envelope[1:1+aead.NonceSize()], envelope[1+aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package walletconnect
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relayProtocol is the relay protocol announced in pairings and proposals.
+const relayProtocol = "irn"
+
+// Pairing is the out-of-band shared secret connecting two peers, usually shown
+// to the wallet as a QR code of its URI.
+type Pairing struct {
+	Topic  string    // Relay topic of the pairing, derived from the key
+	Key    [32]byte  // Symmetric key encrypting the pairing messages
+	Expiry time.Time // Time the pairing expires if unused
+}
+
+// String returns the WalletConnect v2 URI of the pairing:
+//
+//	wc:{topic}@2?relay-protocol=irn&symKey={key}&expiryTimestamp={expiry}
+func (p *Pairing) String() string {
+	return fmt.Sprintf("wc:%s@2?relay-protocol=%s&symKey=%x&expiryTimestamp=%d", p.Topic, relayProtocol, p.Key, p.Expiry.Unix())
+}
+
+// ParseURI parses a WalletConnect v2 pairing URI.
+func ParseURI(uri string) (*Pairing, error) {
+	if !strings.HasPrefix(uri, "wc:") {
+		return nil, fmt.Errorf("invalid scheme in %q", uri)
+	}
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(uri, "wc:"), "?")
+	topic, version, ok := strings.Cut(path, "@")
+	if !ok || version != "2" {
+		return nil, fmt.Errorf("unsupported version in %q", uri)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	if protocol := query.Get("relay-protocol"); protocol != relayProtocol {
+		return nil, fmt.Errorf("unsupported relay protocol %q", protocol)
+	}
+	key, err := hex.DecodeString(query.Get("symKey"))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid symmetric key in %q", uri)
+	}
+	pairing := &Pairing{Topic: topic}
+	copy(pairing.Key[:], key)
+	if pairing.Topic != symKey(pairing.Key).topic() {
+		return nil, fmt.Errorf("topic %s does not match the symmetric key", topic)
+	}
+	if expiry := query.Get("expiryTimestamp"); expiry != "" {
+		timestamp, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry %q", expiry)
+		}
+		pairing.Expiry = time.Unix(timestamp, 0)
+	}
+	return pairing, nil
+}
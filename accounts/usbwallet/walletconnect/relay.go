@@ -0,0 +1,294 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package walletconnect
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultRelay is the endpoint of the public WalletConnect relay.
+const DefaultRelay = "wss://relay.walletconnect.com"
+
+var errRelayClosed = errors.New("relay connection closed")
+
+// Message is an encrypted message delivered by the relay on a subscribed topic.
+type Message struct {
+	Topic   string
+	Message string // Base64 encoded envelope
+	Tag     int
+}
+
+// Relay is a connection to a WalletConnect relay, through which the peers of a
+// pairing or session exchange their encrypted messages.
+type Relay interface {
+	// Subscribe requests the messages published on the topic to be delivered.
+	Subscribe(ctx context.Context, topic string) error
+
+	// Publish publishes a message on the topic, kept by the relay for the given
+	// time until delivered.
+	Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error
+
+	// Messages returns the channel the messages of the subscribed topics are
+	// delivered on. It is closed when the connection is.
+	Messages() <-chan *Message
+
+	// Close closes the connection.
+	Close() error
+}
+
+// wsRelay is a connection to a relay server speaking the irn protocol over a
+// websocket.
+type wsRelay struct {
+	conn      *websocket.Conn
+	writeLock sync.Mutex
+
+	lock    sync.Mutex
+	nextID  int64
+	pending map[int64]chan *rpcMessage
+
+	messages  chan *Message
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// DialRelay connects to the relay at the given endpoint, authenticating with a
+// fresh client identity on behalf of the WalletConnect cloud project ID.
+func DialRelay(ctx context.Context, endpoint, projectID string) (Relay, error) {
+	auth, err := relayAuth(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set("auth", auth)
+	query.Set("projectId", projectID)
+	u.RawQuery = query.Encode()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r := &wsRelay{
+		conn:     conn,
+		nextID:   time.Now().UnixMilli() * 1000,
+		pending:  make(map[int64]chan *rpcMessage),
+		messages: make(chan *Message, 64),
+		closed:   make(chan struct{}),
+	}
+	go r.readLoop()
+	return r, nil
+}
+
+// Subscribe implements Relay, subscribing to the topic.
+func (r *wsRelay) Subscribe(ctx context.Context, topic string) error {
+	return r.call(ctx, "irn_subscribe", map[string]interface{}{"topic": topic})
+}
+
+// Publish implements Relay, publishing a message on the topic.
+func (r *wsRelay) Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error {
+	return r.call(ctx, "irn_publish", map[string]interface{}{
+		"topic":   topic,
+		"message": message,
+		"ttl":     int64(ttl / time.Second),
+		"tag":     tag,
+		"prompt":  false,
+	})
+}
+
+// Messages implements Relay, returning the channel of delivered messages.
+func (r *wsRelay) Messages() <-chan *Message {
+	return r.messages
+}
+
+// Close implements Relay, closing the websocket.
+func (r *wsRelay) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return r.conn.Close()
+}
+
+// call sends a request to the relay and waits for its acknowledgement.
+func (r *wsRelay) call(ctx context.Context, method string, params interface{}) error {
+	blob, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.nextID++
+	id := r.nextID
+	res := make(chan *rpcMessage, 1)
+	r.pending[id] = res
+	r.lock.Unlock()
+
+	defer func() {
+		r.lock.Lock()
+		delete(r.pending, id)
+		r.lock.Unlock()
+	}()
+	if err := r.write(&rpcMessage{ID: id, JSONRPC: "2.0", Method: method, Params: blob}); err != nil {
+		return err
+	}
+	select {
+	case msg := <-res:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		return nil
+	case <-r.closed:
+		return errRelayClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *wsRelay) write(msg *rpcMessage) error {
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+	return r.conn.WriteJSON(msg)
+}
+
+// readLoop dispatches the responses to pending calls, and delivers the messages
+// pushed by the relay on subscribed topics.
+func (r *wsRelay) readLoop() {
+	defer close(r.messages)
+	defer r.Close()
+
+	for {
+		var msg rpcMessage
+		if err := r.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Method == "" {
+			r.lock.Lock()
+			if res, ok := r.pending[msg.ID]; ok {
+				res <- &msg
+			}
+			r.lock.Unlock()
+			continue
+		}
+		if msg.Method != "irn_subscription" {
+			continue
+		}
+		var params struct {
+			Data struct {
+				Topic   string `json:"topic"`
+				Message string `json:"message"`
+				Tag     int    `json:"tag"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			continue
+		}
+		select {
+		case r.messages <- &Message{Topic: params.Data.Topic, Message: params.Data.Message, Tag: params.Data.Tag}:
+		case <-r.closed:
+			return
+		}
+		if err := r.write(&rpcMessage{ID: msg.ID, JSONRPC: "2.0", Result: json.RawMessage("true")}); err != nil {
+			return
+		}
+	}
+}
+
+// relayAuth creates the JWT authenticating a fresh ed25519 client identity to
+// the relay, as required by the irn protocol.
+func relayAuth(audience string) (string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	subject := make([]byte, 32)
+	if _, err := rand.Read(subject); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss": "did:key:z" + base58Encode(append([]byte{0xed, 0x01}, public...)),
+		"sub": hex.EncodeToString(subject),
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(24 * time.Hour).Unix(),
+	})
+	encoding := base64.RawURLEncoding
+	payload := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	signature := ed25519.Sign(private, []byte(payload))
+	return payload + "." + encoding.EncodeToString(signature), nil
+}
+
+// base58Alphabet is the bitcoin base58 alphabet used by did:key identifiers.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes the data in bitcoin base58.
+func base58Encode(data []byte) string {
+	var (
+		num    = new(big.Int).SetBytes(data)
+		base   = big.NewInt(58)
+		mod    = new(big.Int)
+		result []byte
+	)
+	for num.Sign() > 0 {
+		num.DivMod(num, base, mod)
+		result = append(result, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		result = append(result, base58Alphabet[0])
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result)
+}
+
+// rpcMessage is a JSON-RPC request or response, both as exchanged with the relay
+// and as encrypted between the peers.
+type rpcMessage struct {
+	ID      int64           `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
@@ -0,0 +1,698 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package walletconnect implements a WalletConnect v2 signer, pairing with mobile
+// wallets through a relay server and exposing the accounts of the session as an
+// accounts.Wallet.
+//
+// Like the USB wallets, the keys never leave the device: every signature has to
+// be approved by the user on the phone.
+package walletconnect
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrNoSession is returned when requesting a signature before a wallet has
+	// approved a session, or after the session was ended.
+	ErrNoSession = errors.New("no walletconnect session")
+
+	// ErrWalletStopped is returned when the relay connection of the wallet has
+	// been closed.
+	ErrWalletStopped = errors.New("walletconnect wallet stopped")
+)
+
+// defaultTimeout is the default time the user has to approve a request.
+const defaultTimeout = 5 * time.Minute
+
+// methodTags are the relay tags and message lifetimes of the sign protocol
+// requests. The tag of a response is the tag of its request plus one.
+var methodTags = map[string]struct {
+	tag int
+	ttl time.Duration
+}{
+	"wc_sessionPropose": {1100, 5 * time.Minute},
+	"wc_sessionSettle":  {1102, 5 * time.Minute},
+	"wc_sessionUpdate":  {1104, 24 * time.Hour},
+	"wc_sessionExtend":  {1106, 24 * time.Hour},
+	"wc_sessionRequest": {1108, 5 * time.Minute},
+	"wc_sessionEvent":   {1110, 5 * time.Minute},
+	"wc_sessionDelete":  {1112, 24 * time.Hour},
+	"wc_sessionPing":    {1114, 30 * time.Second},
+}
+
+// sessionMethods are the methods requested from the wallet.
+var sessionMethods = []string{"eth_signTransaction", "personal_sign"}
+
+// sessionEvents are the events requested from the wallet.
+var sessionEvents = []string{"accountsChanged", "chainChanged"}
+
+// Metadata describes a peer of a session to the other side.
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// Config contains the settings of a WalletConnect signer.
+type Config struct {
+	ChainID  *big.Int      // Chain the session is requested for
+	Metadata Metadata      // Description of the application shown by the wallet
+	Timeout  time.Duration // Time the user has to approve a request (default 5 minutes)
+}
+
+// session is a pairing approved by the wallet.
+type session struct {
+	topic    string
+	key      symKey
+	peer     Metadata
+	accounts []common.Address
+	expiry   time.Time
+}
+
+// Wallet is a WalletConnect v2 session with a remote wallet, implementing the
+// accounts.Wallet interface. A session is established by showing the pairing
+// URI returned by Pair to the wallet, and waiting for its approval.
+type Wallet struct {
+	relay  Relay
+	config Config
+
+	lock     sync.Mutex
+	nextID   int64
+	pairing  *Pairing                   // Last pairing offered to a wallet
+	keys     *keyPair                   // Key agreement pair of the pending proposal
+	proposal int64                      // ID of the pending session proposal
+	proposed *session                   // Session approved by the wallet, not yet settled
+	session  *session                   // Session settled by the wallet
+	settled  chan struct{}              // Closed when the pending proposal is settled or rejected
+	rejected error                      // Reason the wallet rejected the proposal
+	pending  map[int64]chan *rpcMessage // Responses awaited from the wallet
+
+	quit chan struct{}
+}
+
+// New creates a WalletConnect signer communicating through the given relay.
+func New(relay Relay, config Config) *Wallet {
+	if config.ChainID == nil {
+		config.ChainID = big.NewInt(1)
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	w := &Wallet{
+		relay:   relay,
+		config:  config,
+		nextID:  time.Now().UnixMilli() * 1000,
+		pending: make(map[int64]chan *rpcMessage),
+		quit:    make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+// Pair proposes a new session, returning the pairing to be shown to the wallet,
+// usually as a QR code of its URI. Any previous session is replaced once the new
+// one is approved.
+func (w *Wallet) Pair(ctx context.Context) (*Pairing, error) {
+	key, err := newSymKey()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := newKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	pairing := &Pairing{Topic: key.topic(), Key: key, Expiry: time.Now().Add(methodTags["wc_sessionPropose"].ttl)}
+	if err := w.relay.Subscribe(ctx, pairing.Topic); err != nil {
+		return nil, err
+	}
+	chain := w.chain()
+	params := map[string]interface{}{
+		"relays": []map[string]string{{"protocol": relayProtocol}},
+		"requiredNamespaces": map[string]interface{}{
+			"eip155": map[string]interface{}{
+				"chains":  []string{chain},
+				"methods": sessionMethods,
+				"events":  sessionEvents,
+			},
+		},
+		"proposer": map[string]interface{}{
+			"publicKey": hex.EncodeToString(keys.public[:]),
+			"metadata":  w.config.Metadata,
+		},
+	}
+	w.lock.Lock()
+	id := w.newID()
+	w.pairing, w.keys, w.proposal = pairing, keys, id
+	w.settled, w.rejected = make(chan struct{}), nil
+	w.lock.Unlock()
+
+	if err := w.send(ctx, pairing.Topic, key, id, "wc_sessionPropose", params); err != nil {
+		return nil, err
+	}
+	return pairing, nil
+}
+
+// WaitSession waits until the wallet approves the last proposed session.
+func (w *Wallet) WaitSession(ctx context.Context) error {
+	w.lock.Lock()
+	settled := w.settled
+	w.lock.Unlock()
+
+	if settled == nil {
+		return errors.New("no pairing proposed")
+	}
+	select {
+	case <-settled:
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		return w.rejected
+	case <-w.quit:
+		return ErrWalletStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Peer returns the metadata of the wallet of the current session.
+func (w *Wallet) Peer() (Metadata, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.session == nil {
+		return Metadata{}, ErrNoSession
+	}
+	return w.session.peer, nil
+}
+
+// URL implements accounts.Wallet, returning the URL of the session.
+func (w *Wallet) URL() accounts.URL {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.session == nil {
+		return accounts.URL{Scheme: "wc"}
+	}
+	return accounts.URL{Scheme: "wc", Path: w.session.topic}
+}
+
+// Status implements accounts.Wallet, returning the state of the session.
+func (w *Wallet) Status() (string, error) {
+	select {
+	case <-w.quit:
+		return "Stopped", ErrWalletStopped
+	default:
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	switch {
+	case w.session != nil:
+		return fmt.Sprintf("Connected to %s", w.session.peer.Name), nil
+	case w.pairing != nil && w.rejected == nil:
+		return "Pairing", nil
+	default:
+		return "Disconnected", w.rejected
+	}
+}
+
+// Open implements accounts.Wallet. Sessions are established through Pair, so
+// this method does nothing.
+func (w *Wallet) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet, ending the session and closing the relay
+// connection.
+func (w *Wallet) Close() error {
+	w.lock.Lock()
+	session := w.session
+	w.session = nil
+	w.lock.Unlock()
+
+	if session != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		reason := map[string]interface{}{"code": 6000, "message": "User disconnected."}
+		if err := w.send(ctx, session.topic, session.key, w.id(), "wc_sessionDelete", reason); err != nil {
+			log.Debug("Failed to end walletconnect session", "err", err)
+		}
+		cancel()
+	}
+	return w.relay.Close()
+}
+
+// Accounts implements accounts.Wallet, returning the accounts the wallet shared
+// for the configured chain.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.session == nil {
+		return nil
+	}
+	url := accounts.URL{Scheme: "wc", Path: w.session.topic}
+	accs := make([]accounts.Account, len(w.session.accounts))
+	for i, addr := range w.session.accounts {
+		accs[i] = accounts.Account{Address: addr, URL: url}
+	}
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not shared by the wallet.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	for _, acc := range w.Accounts() {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == acc.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet. The wallet decides which accounts to share,
+// so derivation is not supported.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet. The wallet decides which accounts to
+// share, so derivation is not supported and this method does nothing.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+// SignData implements accounts.Wallet. Only plain text can be signed, through
+// the personal_sign method of the wallet.
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	if mimeType != accounts.MimetypeTextPlain {
+		return nil, accounts.ErrNotSupported
+	}
+	return w.SignText(account, data)
+}
+
+// SignDataWithPassphrase implements accounts.Wallet. Since the wallet doesn't
+// rely on passphrases, it is silently ignored.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(account, mimeType, data)
+}
+
+// SignText implements accounts.Wallet, requesting the wallet to sign the text
+// with the Ethereum signed message prefix. The signature is checked to be made
+// by the account, and returned in the [R || S || V] format with V 0 or 1.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	var sig hexutil.Bytes
+	if err := w.request("personal_sign", []interface{}{hexutil.Bytes(text), account.Address}, &sig); err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(accounts.TextHash(text), sig)
+	if err != nil {
+		return nil, err
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), signer.Hex())
+	}
+	return sig, nil
+}
+
+// SignTextWithPassphrase implements accounts.Wallet. Since the wallet doesn't
+// rely on passphrases, it is silently ignored.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.SignText(account, text)
+}
+
+// SignTx implements accounts.Wallet, requesting the wallet to sign the
+// transaction through its eth_signTransaction method. The signed transaction is
+// checked to be the requested one, signed by the account. A nil chain ID falls
+// back to the chain of the session.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if chainID == nil {
+		chainID = w.config.ChainID
+	}
+	args := map[string]interface{}{
+		"from":    account.Address,
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"data":    hexutil.Bytes(tx.Data()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"chainId": (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	case types.AccessListTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+		args["accessList"] = tx.AccessList()
+	case types.DynamicFeeTxType:
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+		args["accessList"] = tx.AccessList()
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+	if tx.Type() != types.LegacyTxType {
+		args["type"] = hexutil.Uint64(tx.Type())
+	}
+	var raw hexutil.Bytes
+	if err := w.request("eth_signTransaction", []interface{}{args}, &raw); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("wallet signed a different transaction")
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), sender.Hex())
+	}
+	return signed, nil
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. Since the wallet doesn't
+// rely on passphrases, it is silently ignored.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.SignTx(account, tx, chainID)
+}
+
+// chain returns the CAIP-2 identifier of the configured chain.
+func (w *Wallet) chain() string {
+	return "eip155:" + w.config.ChainID.String()
+}
+
+// newID returns a fresh JSON-RPC message ID. The lock must be held.
+func (w *Wallet) newID() int64 {
+	w.nextID++
+	return w.nextID
+}
+
+// id returns a fresh JSON-RPC message ID.
+func (w *Wallet) id() int64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.newID()
+}
+
+// request sends a session request to the wallet and waits for the user to
+// approve it.
+func (w *Wallet) request(method string, params interface{}, result interface{}) error {
+	w.lock.Lock()
+	session := w.session
+	if session == nil || (!session.expiry.IsZero() && time.Now().After(session.expiry)) {
+		w.lock.Unlock()
+		return ErrNoSession
+	}
+	id := w.newID()
+	res := make(chan *rpcMessage, 1)
+	w.pending[id] = res
+	w.lock.Unlock()
+
+	defer func() {
+		w.lock.Lock()
+		delete(w.pending, id)
+		w.lock.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+	defer cancel()
+
+	request := map[string]interface{}{
+		"request": map[string]interface{}{"method": method, "params": params},
+		"chainId": w.chain(),
+	}
+	if err := w.send(ctx, session.topic, session.key, id, "wc_sessionRequest", request); err != nil {
+		return err
+	}
+	select {
+	case msg := <-res:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-w.quit:
+		return ErrWalletStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send publishes an encrypted request on the topic.
+func (w *Wallet) send(ctx context.Context, topic string, key symKey, id int64, method string, params interface{}) error {
+	blob, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	tags := methodTags[method]
+	return w.publish(ctx, topic, key, &rpcMessage{ID: id, JSONRPC: "2.0", Method: method, Params: blob}, tags.ttl, tags.tag)
+}
+
+// respond publishes an encrypted response to a request of the wallet.
+func (w *Wallet) respond(topic string, key symKey, req *rpcMessage, result interface{}, err *rpcError) {
+	msg := &rpcMessage{ID: req.ID, JSONRPC: "2.0", Error: err}
+	if err == nil {
+		msg.Result, _ = json.Marshal(result)
+	}
+	tags, ok := methodTags[req.Method]
+	if !ok {
+		tags.ttl = 5 * time.Minute
+	} else {
+		tags.tag++
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := w.publish(ctx, topic, key, msg, tags.ttl, tags.tag); err != nil {
+		log.Debug("Failed to respond to walletconnect request", "method", req.Method, "err", err)
+	}
+}
+
+func (w *Wallet) publish(ctx context.Context, topic string, key symKey, msg *rpcMessage, ttl time.Duration, tag int) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	envelope, err := key.seal(blob)
+	if err != nil {
+		return err
+	}
+	return w.relay.Publish(ctx, topic, envelope, ttl, tag)
+}
+
+// loop processes the messages delivered by the relay until it is closed.
+func (w *Wallet) loop() {
+	defer close(w.quit)
+
+	for msg := range w.relay.Messages() {
+		w.lock.Lock()
+		var key *symKey
+		switch {
+		case w.pairing != nil && msg.Topic == w.pairing.Topic:
+			key = (*symKey)(&w.pairing.Key)
+		case w.session != nil && msg.Topic == w.session.topic:
+			key = &w.session.key
+		case w.proposed != nil && msg.Topic == w.proposed.topic:
+			key = &w.proposed.key
+		}
+		w.lock.Unlock()
+
+		if key == nil {
+			log.Debug("Dropping walletconnect message of unknown topic", "topic", msg.Topic)
+			continue
+		}
+		blob, err := key.open(msg.Message)
+		if err != nil {
+			log.Debug("Failed to decrypt walletconnect message", "topic", msg.Topic, "err", err)
+			continue
+		}
+		var payload rpcMessage
+		if err := json.Unmarshal(blob, &payload); err != nil {
+			log.Debug("Failed to decode walletconnect message", "topic", msg.Topic, "err", err)
+			continue
+		}
+		if payload.Method != "" {
+			w.handleRequest(msg.Topic, *key, &payload)
+		} else {
+			w.handleResponse(&payload)
+		}
+	}
+}
+
+// handleResponse processes the response to a proposal or a session request.
+func (w *Wallet) handleResponse(msg *rpcMessage) {
+	w.lock.Lock()
+	if msg.ID != w.proposal {
+		if res, ok := w.pending[msg.ID]; ok {
+			res <- msg
+		}
+		w.lock.Unlock()
+		return
+	}
+	keys := w.keys
+	w.proposal = 0
+	w.lock.Unlock()
+
+	// The wallet answered the proposal, set up the session it will settle
+	var (
+		proposed *session
+		err      error
+	)
+	if msg.Error != nil {
+		err = msg.Error
+	} else {
+		var result struct {
+			ResponderPublicKey string `json:"responderPublicKey"`
+		}
+		if err = json.Unmarshal(msg.Result, &result); err == nil {
+			proposed, err = newSession(keys, result.ResponderPublicKey)
+		}
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = w.relay.Subscribe(ctx, proposed.topic)
+		cancel()
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err != nil {
+		w.rejected = fmt.Errorf("session rejected: %w", err)
+		close(w.settled)
+		return
+	}
+	w.proposed = proposed
+}
+
+// handleRequest processes a request of the wallet.
+func (w *Wallet) handleRequest(topic string, key symKey, msg *rpcMessage) {
+	var params struct {
+		Namespaces map[string]struct {
+			Accounts []string `json:"accounts"`
+		} `json:"namespaces"`
+		Controller struct {
+			Metadata Metadata `json:"metadata"`
+		} `json:"controller"`
+		Expiry int64 `json:"expiry"`
+	}
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			w.respond(topic, key, msg, nil, &rpcError{Code: -32602, Message: err.Error()})
+			return
+		}
+	}
+	w.lock.Lock()
+	switch msg.Method {
+	case "wc_sessionSettle":
+		if w.proposed == nil || w.proposed.topic != topic {
+			w.lock.Unlock()
+			w.respond(topic, key, msg, nil, &rpcError{Code: 7001, Message: "No matching session proposal."})
+			return
+		}
+		// Replace any previous session with the settled one
+		w.session, w.proposed = w.proposed, nil
+		w.session.peer = params.Controller.Metadata
+		w.session.accounts = w.chainAccounts(params.Namespaces["eip155"].Accounts)
+		if params.Expiry != 0 {
+			w.session.expiry = time.Unix(params.Expiry, 0)
+		}
+		close(w.settled)
+
+	case "wc_sessionUpdate":
+		if w.session != nil && w.session.topic == topic {
+			w.session.accounts = w.chainAccounts(params.Namespaces["eip155"].Accounts)
+		}
+	case "wc_sessionExtend":
+		if w.session != nil && w.session.topic == topic && params.Expiry != 0 {
+			w.session.expiry = time.Unix(params.Expiry, 0)
+		}
+	case "wc_sessionDelete":
+		if w.session != nil && w.session.topic == topic {
+			w.session = nil
+		}
+	case "wc_sessionPing", "wc_sessionEvent":
+	default:
+		w.lock.Unlock()
+		w.respond(topic, key, msg, nil, &rpcError{Code: -32601, Message: fmt.Sprintf("Method %s not supported.", msg.Method)})
+		return
+	}
+	w.lock.Unlock()
+	w.respond(topic, key, msg, true, nil)
+}
+
+// chainAccounts returns the addresses of the CAIP-10 account identifiers on the
+// configured chain.
+func (w *Wallet) chainAccounts(ids []string) []common.Address {
+	var (
+		prefix = w.chain() + ":"
+		addrs  []common.Address
+	)
+	for _, id := range ids {
+		if addr := strings.TrimPrefix(id, prefix); addr != id && common.IsHexAddress(addr) {
+			addrs = append(addrs, common.HexToAddress(addr))
+		}
+	}
+	return addrs
+}
+
+// newSession derives the session with the wallet owning the responder key.
+func newSession(keys *keyPair, responder string) (*session, error) {
+	blob, err := hex.DecodeString(responder)
+	if err != nil || len(blob) != 32 {
+		return nil, fmt.Errorf("invalid responder public key %q", responder)
+	}
+	var peer [32]byte
+	copy(peer[:], blob)
+	key, err := keys.sharedKey(peer)
+	if err != nil {
+		return nil, err
+	}
+	return &session{topic: key.topic(), key: key}, nil
+}
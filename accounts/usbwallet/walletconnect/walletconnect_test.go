@@ -0,0 +1,332 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package walletconnect
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// relayHub is an in-memory relay server, keeping all messages published on a
+// topic for later subscribers.
+type relayHub struct {
+	lock   sync.Mutex
+	subs   map[string][]*memRelay
+	stored map[string][]*Message
+}
+
+func newRelayHub() *relayHub {
+	return &relayHub{subs: make(map[string][]*memRelay), stored: make(map[string][]*Message)}
+}
+
+// memRelay is a connection to a relayHub.
+type memRelay struct {
+	hub      *relayHub
+	messages chan *Message
+	once     sync.Once
+}
+
+func (h *relayHub) connect() *memRelay {
+	return &memRelay{hub: h, messages: make(chan *Message, 100)}
+}
+
+func (r *memRelay) Subscribe(ctx context.Context, topic string) error {
+	r.hub.lock.Lock()
+	defer r.hub.lock.Unlock()
+
+	r.hub.subs[topic] = append(r.hub.subs[topic], r)
+	for _, msg := range r.hub.stored[topic] {
+		r.messages <- msg
+	}
+	return nil
+}
+
+func (r *memRelay) Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error {
+	r.hub.lock.Lock()
+	defer r.hub.lock.Unlock()
+
+	msg := &Message{Topic: topic, Message: message, Tag: tag}
+	r.hub.stored[topic] = append(r.hub.stored[topic], msg)
+	for _, sub := range r.hub.subs[topic] {
+		if sub != r {
+			sub.messages <- msg
+		}
+	}
+	return nil
+}
+
+func (r *memRelay) Messages() <-chan *Message { return r.messages }
+
+func (r *memRelay) Close() error {
+	r.hub.lock.Lock()
+	defer r.hub.lock.Unlock()
+
+	for topic, subs := range r.hub.subs {
+		for i, sub := range subs {
+			if sub == r {
+				r.hub.subs[topic] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+	r.once.Do(func() { close(r.messages) })
+	return nil
+}
+
+// testPeer is the wallet side of a session, signing with a local key.
+type testPeer struct {
+	t      *testing.T
+	relay  *memRelay
+	key    *ecdsa.PrivateKey
+	chain  *big.Int
+	tamper bool // Whether to sign a different transaction than requested
+
+	topic      string
+	sessionKey symKey
+}
+
+// pair approves the session proposed through the pairing and settles it.
+func (p *testPeer) pair(pairing *Pairing) {
+	uri, err := ParseURI(pairing.String())
+	if err != nil {
+		p.t.Errorf("failed to parse pairing URI: %v", err)
+		return
+	}
+	p.relay.Subscribe(context.Background(), uri.Topic)
+	proposal := p.receive(uri.Key)
+
+	var params struct {
+		Proposer struct {
+			PublicKey string `json:"publicKey"`
+		} `json:"proposer"`
+	}
+	json.Unmarshal(proposal.Params, &params)
+	var proposer [32]byte
+	blob, _ := hex.DecodeString(params.Proposer.PublicKey)
+	copy(proposer[:], blob)
+
+	keys, _ := newKeyPair()
+	p.sessionKey, _ = keys.sharedKey(proposer)
+	p.topic = p.sessionKey.topic()
+	p.relay.Subscribe(context.Background(), p.topic)
+
+	p.send(uri.Topic, uri.Key, &rpcMessage{ID: proposal.ID, JSONRPC: "2.0", Result: mustJSON(map[string]interface{}{
+		"relay":              map[string]string{"protocol": relayProtocol},
+		"responderPublicKey": hex.EncodeToString(keys.public[:]),
+	})})
+	p.send(p.topic, p.sessionKey, &rpcMessage{ID: 1, JSONRPC: "2.0", Method: "wc_sessionSettle", Params: mustJSON(map[string]interface{}{
+		"relay": map[string]string{"protocol": relayProtocol},
+		"namespaces": map[string]interface{}{
+			"eip155": map[string]interface{}{
+				"accounts": []string{
+					"eip155:" + p.chain.String() + ":" + crypto.PubkeyToAddress(p.key.PublicKey).Hex(),
+					"eip155:5:0x0000000000000000000000000000000000000001",
+				},
+			},
+		},
+		"controller": map[string]interface{}{"metadata": Metadata{Name: "Test Wallet"}},
+		"expiry":     time.Now().Add(time.Hour).Unix(),
+	})})
+	if ack := p.receive(p.sessionKey); string(ack.Result) != "true" {
+		p.t.Errorf("settlement not acknowledged: %+v", ack)
+	}
+}
+
+// serve signs the session requests until the session is deleted.
+func (p *testPeer) serve() {
+	for {
+		req := p.receive(p.sessionKey)
+		if req == nil || req.Method == "wc_sessionDelete" {
+			return
+		}
+		var params struct {
+			Request struct {
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			} `json:"request"`
+		}
+		json.Unmarshal(req.Params, &params)
+
+		res := &rpcMessage{ID: req.ID, JSONRPC: "2.0"}
+		switch params.Request.Method {
+		case "personal_sign":
+			var text hexutil.Bytes
+			json.Unmarshal(params.Request.Params[0], &text)
+			sig, _ := crypto.Sign(accounts.TextHash(text), p.key)
+			sig[crypto.RecoveryIDOffset] += 27
+			res.Result = mustJSON(hexutil.Bytes(sig))
+
+		case "eth_signTransaction":
+			var args struct {
+				To       *common.Address `json:"to"`
+				Gas      hexutil.Uint64  `json:"gas"`
+				GasPrice *hexutil.Big    `json:"gasPrice"`
+				Value    *hexutil.Big    `json:"value"`
+				Data     hexutil.Bytes   `json:"data"`
+				Nonce    hexutil.Uint64  `json:"nonce"`
+			}
+			json.Unmarshal(params.Request.Params[0], &args)
+			if p.tamper {
+				args.Nonce++
+			}
+			tx := types.NewTx(&types.LegacyTx{Nonce: uint64(args.Nonce), To: args.To, Gas: uint64(args.Gas), GasPrice: args.GasPrice.ToInt(), Value: args.Value.ToInt(), Data: args.Data})
+			signed, _ := types.SignTx(tx, types.LatestSignerForChainID(p.chain), p.key)
+			raw, _ := signed.MarshalBinary()
+			res.Result = mustJSON(hexutil.Bytes(raw))
+
+		default:
+			res.Error = &rpcError{Code: 5000, Message: "User rejected."}
+		}
+		p.send(p.topic, p.sessionKey, res)
+	}
+}
+
+func (p *testPeer) send(topic string, key symKey, msg *rpcMessage) {
+	envelope, err := key.seal(mustJSON(msg))
+	if err != nil {
+		p.t.Errorf("failed to seal message: %v", err)
+	}
+	p.relay.Publish(context.Background(), topic, envelope, time.Minute, 0)
+}
+
+func (p *testPeer) receive(key symKey) *rpcMessage {
+	select {
+	case msg, ok := <-p.relay.Messages():
+		if !ok {
+			return nil
+		}
+		blob, err := key.open(msg.Message)
+		if err != nil {
+			p.t.Errorf("failed to open message: %v", err)
+			return nil
+		}
+		res := new(rpcMessage)
+		json.Unmarshal(blob, res)
+		return res
+	case <-time.After(5 * time.Second):
+		p.t.Errorf("message timeout")
+		return nil
+	}
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return blob
+}
+
+func TestSession(t *testing.T) {
+	var (
+		hub     = newRelayHub()
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		chainID = big.NewInt(1337)
+		peer    = &testPeer{t: t, relay: hub.connect(), key: key, chain: chainID}
+		wallet  = New(hub.connect(), Config{ChainID: chainID, Metadata: Metadata{Name: "Test dApp"}})
+	)
+	defer wallet.Close()
+
+	if _, err := wallet.SignText(accounts.Account{Address: addr}, []byte("hello")); !errors.Is(err, accounts.ErrUnknownAccount) {
+		t.Fatalf("signing without session: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	pairing, err := wallet.Pair(context.Background())
+	if err != nil {
+		t.Fatalf("failed to pair: %v", err)
+	}
+	go func() {
+		peer.pair(pairing)
+		peer.serve()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wallet.WaitSession(ctx); err != nil {
+		t.Fatalf("session not settled: %v", err)
+	}
+	if meta, _ := wallet.Peer(); meta.Name != "Test Wallet" {
+		t.Errorf("peer name mismatch: have %q, want %q", meta.Name, "Test Wallet")
+	}
+	accs := wallet.Accounts()
+	if len(accs) != 1 || accs[0].Address != addr {
+		t.Fatalf("accounts mismatch: have %v, want [%x]", accs, addr)
+	}
+	// Sign a message and a transaction through the session
+	sig, err := wallet.SignText(accs[0], []byte("hello"))
+	if err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	if pub, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig); err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Errorf("text signature mismatch: %v", err)
+	}
+	opts, err := bind.NewWalletTransactor(wallet, accs[0], chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(3, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := opts.Signer(addr, tx)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if sender, _ := types.Sender(types.LatestSignerForChainID(chainID), signed); sender != addr || signed.Nonce() != 3 {
+		t.Errorf("signed transaction mismatch: sender %x, nonce %d", sender, signed.Nonce())
+	}
+	// Transactions altered by the wallet must be rejected
+	peer.tamper = true
+	if _, err := opts.Signer(addr, tx); err == nil {
+		t.Errorf("altered transaction accepted")
+	}
+	if _, err := wallet.SignData(accs[0], accounts.MimetypeTypedData, []byte{}); !errors.Is(err, accounts.ErrNotSupported) {
+		t.Errorf("typed data signing: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	key, _ := newSymKey()
+	pairing := &Pairing{Topic: key.topic(), Key: key, Expiry: time.Unix(1700000000, 0)}
+	parsed, err := ParseURI(pairing.String())
+	if err != nil {
+		t.Fatalf("failed to parse URI: %v", err)
+	}
+	if parsed.Topic != pairing.Topic || parsed.Key != pairing.Key || !parsed.Expiry.Equal(pairing.Expiry) {
+		t.Fatalf("pairing mismatch: have %+v, want %+v", parsed, pairing)
+	}
+	for _, uri := range []string{
+		"wc:" + pairing.Topic + "@1?relay-protocol=irn&symKey=" + hex.EncodeToString(key[:]),
+		"wc:" + pairing.Topic + "@2?relay-protocol=waku&symKey=" + hex.EncodeToString(key[:]),
+		"wc:" + pairing.Topic + "@2?relay-protocol=irn&symKey=00",
+		"wc:00@2?relay-protocol=irn&symKey=" + hex.EncodeToString(key[:]),
+	} {
+		if _, err := ParseURI(uri); err == nil {
+			t.Errorf("invalid URI accepted: %s", uri)
+		}
+	}
+}
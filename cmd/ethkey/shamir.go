@@ -0,0 +1,114 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/shamir"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	sharesFlag = &cli.IntFlag{
+		Name:  "shares",
+		Usage: "number of shares to split the keyfile into",
+		Value: 5,
+	}
+	thresholdFlag = &cli.IntFlag{
+		Name:  "threshold",
+		Usage: "number of shares required to reassemble the keyfile",
+		Value: 3,
+	}
+)
+
+var commandSplit = &cli.Command{
+	Name:      "split",
+	Usage:     "split a keyfile into shares",
+	ArgsUsage: "<keyfile>",
+	Description: `
+Split a keyfile into a number of shares using Shamir's secret sharing, any
+threshold of which can reassemble it. The shares are written next to the keyfile
+as <keyfile>.share-<n>. The key remains encrypted, so the password is required
+in addition to the shares to use it.`,
+	Flags: []cli.Flag{
+		sharesFlag,
+		thresholdFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		keyfilepath := ctx.Args().First()
+
+		keyjson, err := os.ReadFile(keyfilepath)
+		if err != nil {
+			utils.Fatalf("Failed to read the keyfile at '%s': %v", keyfilepath, err)
+		}
+		shares, err := shamir.SplitKeyJSON(keyjson, ctx.Int(sharesFlag.Name), ctx.Int(thresholdFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to split keyfile: %v", err)
+		}
+		for i, share := range shares {
+			path := fmt.Sprintf("%s.share-%d", keyfilepath, i+1)
+			if err := os.WriteFile(path, []byte(hexutil.Encode(share)), 0600); err != nil {
+				utils.Fatalf("Failed to write share to '%s': %v", path, err)
+			}
+			fmt.Println("Share written to", path)
+		}
+		return nil
+	},
+}
+
+var commandCombine = &cli.Command{
+	Name:      "combine",
+	Usage:     "reassemble a keyfile from shares",
+	ArgsUsage: "<keyfile> <share> <share> [<share>...]",
+	Description: `
+Reassemble a keyfile split with 'ethkey split' from at least the threshold number
+of its shares, writing it to the given path.`,
+	Action: func(ctx *cli.Context) error {
+		if ctx.Args().Len() < 3 {
+			utils.Fatalf("Usage: ethkey combine <keyfile> <share> <share> [<share>...]")
+		}
+		keyfilepath := ctx.Args().First()
+		if _, err := os.Stat(keyfilepath); err == nil {
+			utils.Fatalf("Keyfile already exists at %s.", keyfilepath)
+		}
+		var shares [][]byte
+		for _, path := range ctx.Args().Slice()[1:] {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				utils.Fatalf("Failed to read share '%s': %v", path, err)
+			}
+			share, err := hexutil.Decode(strings.TrimSpace(string(content)))
+			if err != nil {
+				utils.Fatalf("Invalid share '%s': %v", path, err)
+			}
+			shares = append(shares, share)
+		}
+		keyjson, err := shamir.CombineKeyJSON(shares)
+		if err != nil {
+			utils.Fatalf("Failed to reassemble keyfile: %v", err)
+		}
+		if err := os.WriteFile(keyfilepath, keyjson, 0600); err != nil {
+			utils.Fatalf("Failed to write keyfile to %s: %v", keyfilepath, err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	keyfile := filepath.Join(tmpdir, "the-keyfile")
+	keyjson := []byte(`{"address":"0000000000000000000000000000000000000000","crypto":{},"version":3}`)
+	if err := os.WriteFile(keyfile, keyjson, 0600); err != nil {
+		t.Fatal(err)
+	}
+	split := runEthkey(t, "split", "--shares", "3", "--threshold", "2", keyfile)
+	split.ExpectRegexp(`Share written to .*share-1\nShare written to .*share-2\nShare written to .*share-3\n`)
+	split.ExpectExit()
+
+	restored := filepath.Join(tmpdir, "restored")
+	combine := runEthkey(t, "combine", restored, keyfile+".share-3", keyfile+".share-1")
+	combine.ExpectExit()
+
+	if have, err := os.ReadFile(restored); err != nil || !bytes.Equal(have, keyjson) {
+		t.Fatalf("restored keyfile mismatch: have %s, %v", have, err)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"reflect"
+	"syscall"
 	"unicode"
 
 	"github.com/urfave/cli/v2"
@@ -155,6 +157,36 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	return stack, cfg
 }
 
+// watchRPCReload reloads the HTTP and WebSocket CORS origins, virtual hosts and
+// modules of the running node from the config file and the command line flags
+// whenever the process receives SIGHUP.
+func watchRPCReload(ctx *cli.Context, stack *node.Node) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sighup)
+
+		for range sighup {
+			cfg := gethConfig{
+				Eth:     ethconfig.Defaults,
+				Node:    defaultNodeConfig(),
+				Metrics: metrics.DefaultConfig,
+			}
+			if file := ctx.String(configFileFlag.Name); file != "" {
+				if err := loadConfig(file, &cfg); err != nil {
+					log.Error("Failed to reload config file", "err", err)
+					continue
+				}
+			}
+			utils.SetRPCConfig(ctx, &cfg.Node)
+			if err := stack.ReloadRPC(&cfg.Node); err != nil {
+				log.Error("Failed to reload RPC configuration", "err", err)
+			}
+		}
+	}()
+}
+
 // makeFullNode loads geth configuration and creates the Ethereum backend.
 func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	stack, cfg := makeConfigNode(ctx)
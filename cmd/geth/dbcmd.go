@@ -18,6 +18,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -43,6 +45,30 @@ import (
 )
 
 var (
+	inspectJSONFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the inspection result as JSON instead of a table",
+	}
+	compressDictSizeFlag = &cli.IntFlag{
+		Name:  "dictsize",
+		Usage: "Size of the zstd dictionary trained on the frozen chain data",
+		Value: 110 * 1024,
+	}
+	compressSamplesFlag = &cli.IntFlag{
+		Name:  "samples",
+		Usage: "Number of frozen blocks sampled to train the zstd dictionary",
+		Value: 2000,
+	}
+	compressLevelFlag = &cli.IntFlag{
+		Name:  "level",
+		Usage: "Zstd compression level (1-20)",
+		Value: 9,
+	}
+	compressDisableFlag = &cli.BoolFlag{
+		Name:  "disable",
+		Usage: "Disable compression and decompress all frozen bodies and receipts",
+	}
+
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
 		Name:      "removedb",
@@ -69,6 +95,7 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbCompressAncientsCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -77,6 +104,7 @@ Remove blockchain and state databases`,
 		ArgsUsage: "<prefix> <start>",
 		Flags: flags.Merge([]cli.Flag{
 			utils.SyncModeFlag,
+			inspectJSONFlag,
 		}, utils.NetworkFlags, utils.DatabasePathFlags),
 		Usage:       "Inspect the storage size for each type of data in the database",
 		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.`,
@@ -193,6 +221,23 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabasePathFlags),
 		Description: "Shows metadata about the chain status.",
 	}
+	dbCompressAncientsCmd = &cli.Command{
+		Action: compressAncients,
+		Name:   "compress-ancients",
+		Usage:  "Compress the frozen block bodies and receipts with zstd",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			compressDictSizeFlag,
+			compressSamplesFlag,
+			compressLevelFlag,
+			compressDisableFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `This command trains a zstd dictionary on a sample of the frozen block bodies
+and receipts, configures the freezer to compress all newly frozen items with it and
+rewrites the already frozen ones. Running it again retrains the dictionary. With
+--disable, compression is turned off and all items are decompressed again.
+WARNING: This operation may take a very long time to finish.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -283,7 +328,16 @@ func inspect(ctx *cli.Context) error {
 	db := utils.MakeChainDatabase(ctx, stack, true)
 	defer db.Close()
 
-	return rawdb.InspectDatabase(db, prefix, start)
+	if !ctx.Bool(inspectJSONFlag.Name) {
+		return rawdb.InspectDatabase(db, prefix, start)
+	}
+	stats, err := rawdb.InspectDatabaseStats(db, prefix, start)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
 }
 
 func checkStateContent(ctx *cli.Context) error {
@@ -713,3 +767,58 @@ func showMetaData(ctx *cli.Context) error {
 	table.Render()
 	return nil
 }
+
+func compressAncients(ctx *cli.Context) error {
+	if !ctx.Bool(compressDisableFlag.Name) && ctx.Int(compressSamplesFlag.Name) <= 0 {
+		return fmt.Errorf("invalid sample count %d", ctx.Int(compressSamplesFlag.Name))
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if ctx.Bool(compressDisableFlag.Name) {
+		if err := rawdb.DisableAncientCompression(db); err != nil {
+			return err
+		}
+		log.Info("Decompressing frozen bodies and receipts")
+		return rawdb.RecodeAncients(db)
+	}
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	tail, err := db.Tail()
+	if err != nil {
+		return err
+	}
+	if frozen <= tail {
+		return errors.New("no frozen blocks to train the dictionary on")
+	}
+	// Sample bodies and receipts evenly across the frozen chain segment
+	var (
+		samples [][]byte
+		count   = uint64(ctx.Int(compressSamplesFlag.Name))
+		step    = (frozen - tail) / count
+	)
+	if step == 0 {
+		step = 1
+	}
+	for number := tail; number < frozen; number += step {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if body := rawdb.ReadBodyRLP(db, hash, number); len(body) > 0 {
+			samples = append(samples, body)
+		}
+		if receipts := rawdb.ReadReceiptsRLP(db, hash, number); len(receipts) > 0 {
+			samples = append(samples, receipts)
+		}
+	}
+	log.Info("Training zstd dictionary", "samples", len(samples))
+	dict := rawdb.TrainAncientDictionary(samples, ctx.Int(compressDictSizeFlag.Name))
+	if err := rawdb.EnableAncientCompression(db, dict, ctx.Int(compressLevelFlag.Name)); err != nil {
+		return err
+	}
+	log.Info("Compressing frozen bodies and receipts", "dictsize", len(dict))
+	return rawdb.RecodeAncients(db)
+}
@@ -85,12 +85,20 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolCalldataFloorFlag,
+		utils.TxPoolSenderLimitFlag,
 		utils.SyncModeFlag,
 		utils.SyncTargetFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.HistoryWindowFlag,
+		utils.HistoryBlocksFlag,
+		utils.TxSenderIndexFlag,
+		utils.StateDiffsFlag,
+		utils.StateRetainBlocksFlag,
+		utils.StateRetainAccountsFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
 		utils.LightEgressFlag,
@@ -140,6 +148,7 @@ var (
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperGasLimitFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMOpCountersFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
 		utils.FakePoWFlag,
@@ -173,13 +182,21 @@ var (
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.WSPathPrefixFlag,
+		utils.WSMaxSubscriptionsFlag,
+		utils.WSSubscriptionBufferFlag,
+		utils.WSSlowConsumerPolicyFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCJSTracerTimeoutFlag,
+		utils.RPCJSTracerStepsFlag,
+		utils.RPCJSTracerMemoryFlag,
 		utils.AllowUnprotectedTxs,
+		utils.RPCSlowCallThresholdFlag,
+		utils.RPCAPITokensFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -216,6 +233,8 @@ func init() {
 		removedbCommand,
 		dumpCommand,
 		dumpGenesisCommand,
+		exportStateCommand,
+		exportStateDiffsCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -353,6 +372,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 
 	// Start up the node itself
 	utils.StartNode(ctx, stack, isConsole)
+	watchRPCReload(ctx, stack)
 
 	// Unlock any account specifically requested
 	unlockAccounts(ctx, stack)
@@ -26,8 +26,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/crypto/signify"
 	"github.com/ethereum/go-ethereum/log"
-	"github.com/jedisct1/go-minisign"
 	"github.com/urfave/cli/v2"
 )
 
@@ -130,30 +130,8 @@ func fetch(url string) ([]byte, error) {
 // verifySignature checks that the sigData is a valid signature of the given
 // data, for pubkey GethPubkey
 func verifySignature(pubkeys []string, data, sigdata []byte) error {
-	sig, err := minisign.DecodeSignature(string(sigdata))
-	if err != nil {
-		return err
-	}
-	// find the used key
-	var key *minisign.PublicKey
-	for _, pubkey := range pubkeys {
-		pub, err := minisign.NewPublicKey(pubkey)
-		if err != nil {
-			// our pubkeys should be parseable
-			return err
-		}
-		if pub.KeyId != sig.KeyId {
-			continue
-		}
-		key = &pub
-		break
-	}
-	if key == nil {
-		log.Info("Signing key not trusted", "keyid", keyID(sig.KeyId), "error", err)
-		return errors.New("signature could not be verified")
-	}
-	if ok, err := key.Verify(data, sig); !ok || err != nil {
-		log.Info("Verification failed error", "keyid", keyID(key.KeyId), "error", err)
+	if err := signify.Verify(pubkeys, data, sigdata); err != nil {
+		log.Info("Verification failed error", "error", err)
 		return errors.New("signature could not be verified")
 	}
 	return nil
@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -53,6 +54,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/js"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
@@ -247,6 +249,36 @@ var (
 		Value:    ethconfig.Defaults.TxLookupLimit,
 		Category: flags.EthCategory,
 	}
+	HistoryWindowFlag = &cli.Uint64Flag{
+		Name:     "history.window",
+		Usage:    "Number of recent blocks to keep the bodies and receipts of, pruning older ones (0 = entire chain, unless --history.blocks is set)",
+		Category: flags.EthCategory,
+	}
+	HistoryBlocksFlag = &cli.StringFlag{
+		Name:     "history.blocks",
+		Usage:    "Comma separated block numbers and ranges (e.g. 1000-2000) to keep the bodies and receipts of when pruning the history",
+		Category: flags.EthCategory,
+	}
+	TxSenderIndexFlag = &cli.BoolFlag{
+		Name:     "txsenderindex",
+		Usage:    "Index transactions by sender and nonce within the transaction index (used by eth_getTransactionBySenderAndNonce)",
+		Category: flags.EthCategory,
+	}
+	StateDiffsFlag = &cli.BoolFlag{
+		Name:     "statediffs",
+		Usage:    "Record per-transaction state diffs of processed blocks (exposed via debug_getBlockStateDiffs)",
+		Category: flags.EthCategory,
+	}
+	StateRetainBlocksFlag = &cli.StringFlag{
+		Name:     "state.retainblocks",
+		Usage:    "Comma separated block numbers and ranges (e.g. 1000-2000) to keep the full historical state of when pruning",
+		Category: flags.EthCategory,
+	}
+	StateRetainAccountsFlag = &cli.StringFlag{
+		Name:     "state.retainaccounts",
+		Usage:    "Comma separated accounts to keep the historical state of at all blocks when pruning",
+		Category: flags.EthCategory,
+	}
 	LightKDFFlag = &cli.BoolFlag{
 		Name:     "lightkdf",
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -438,6 +470,18 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolCalldataFloorFlag = &cli.Uint64Flag{
+		Name:     "txpool.calldatafloor",
+		Usage:    "Minimum gas tip (in wei) per started kilobyte of calldata of remote transactions (0 = disabled)",
+		Value:    ethconfig.Defaults.TxPool.CalldataFloor,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolSenderLimitFlag = &cli.Uint64Flag{
+		Name:     "txpool.senderlimit",
+		Usage:    "Number of recent transactions, halving every minute, after which a remote sender is rejected (0 = disabled)",
+		Value:    ethconfig.Defaults.TxPool.SenderLimit,
+		Category: flags.TxPoolCategory,
+	}
 
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
@@ -597,6 +641,11 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMOpCountersFlag = &cli.BoolFlag{
+		Name:     "vm.opcounters",
+		Usage:    "Count the executions, gas and time of each opcode in processed blocks (exposed via debug_opcodeCounters and metrics)",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -617,6 +666,21 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	RPCJSTracerTimeoutFlag = &cli.DurationFlag{
+		Name:     "rpc.jstracer.timeout",
+		Usage:    "Sets a limit on the time JS tracers may spend tracing a transaction (0 = no limit)",
+		Category: flags.APICategory,
+	}
+	RPCJSTracerStepsFlag = &cli.Uint64Flag{
+		Name:     "rpc.jstracer.steps",
+		Usage:    "Sets a limit on the number of JS tracer invocations when tracing a transaction (0 = no limit)",
+		Category: flags.APICategory,
+	}
+	RPCJSTracerMemoryFlag = &cli.Uint64Flag{
+		Name:     "rpc.jstracer.memory",
+		Usage:    "Sets a limit on the approximate memory (in MB) retained by JS tracers when tracing a transaction (0 = no limit)",
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -771,6 +835,22 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	WSMaxSubscriptionsFlag = &cli.IntFlag{
+		Name:     "ws.maxsubs",
+		Usage:    "Maximum number of active subscriptions per websocket connection (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	WSSubscriptionBufferFlag = &cli.IntFlag{
+		Name:     "ws.subbuffer",
+		Usage:    "Number of notifications per subscription queued for slow websocket clients (0 = no queueing)",
+		Category: flags.APICategory,
+	}
+	WSSlowConsumerPolicyFlag = &cli.StringFlag{
+		Name:     "ws.slowconsumer",
+		Usage:    `Handling of slow websocket subscribers with a full queue ("backpressure", "drop" or "disconnect")`,
+		Value:    "backpressure",
+		Category: flags.APICategory,
+	}
 	ExecFlag = &cli.StringFlag{
 		Name:     "exec",
 		Usage:    "Execute JavaScript statement",
@@ -786,6 +866,17 @@ var (
 		Usage:    "Allow for unprotected (non EIP155 signed) transactions to be submitted via RPC",
 		Category: flags.APICategory,
 	}
+	RPCSlowCallThresholdFlag = &cli.DurationFlag{
+		Name:     "rpc.slowcall",
+		Usage:    "Log RPC calls served slower than this threshold (0 = disabled)",
+		Category: flags.APICategory,
+	}
+	RPCAPITokensFlag = &cli.PathFlag{
+		Name:      "rpc.apitokens",
+		Usage:     "Path to a JSON file of API tokens required from HTTP and WebSocket clients, limiting the methods they may call",
+		TakesFile: true,
+		Category:  flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -1209,6 +1300,12 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
+	if ctx.IsSet(RPCSlowCallThresholdFlag.Name) {
+		cfg.RPCSlowCallThreshold = ctx.Duration(RPCSlowCallThresholdFlag.Name)
+	}
+	if ctx.IsSet(RPCAPITokensFlag.Name) {
+		cfg.APITokens = ctx.Path(RPCAPITokensFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1246,6 +1343,15 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(WSPathPrefixFlag.Name) {
 		cfg.WSPathPrefix = ctx.String(WSPathPrefixFlag.Name)
 	}
+	if ctx.IsSet(WSMaxSubscriptionsFlag.Name) {
+		cfg.WSMaxSubscriptions = ctx.Int(WSMaxSubscriptionsFlag.Name)
+	}
+	if ctx.IsSet(WSSubscriptionBufferFlag.Name) {
+		cfg.WSSubscriptionBuffer = ctx.Int(WSSubscriptionBufferFlag.Name)
+	}
+	if ctx.IsSet(WSSlowConsumerPolicyFlag.Name) {
+		cfg.WSSlowConsumerPolicy = ctx.String(WSSlowConsumerPolicyFlag.Name)
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1506,6 +1612,13 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// SetRPCConfig applies the HTTP and WebSocket related command line flags to the
+// node config, e.g. to reload the RPC configuration of a running node.
+func SetRPCConfig(ctx *cli.Context, cfg *node.Config) {
+	setHTTP(ctx, cfg)
+	setWS(ctx, cfg)
+}
+
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	// Skip enabling smartcards if no path is set
 	path := ctx.String(SmartCardDaemonPathFlag.Name)
@@ -1602,6 +1715,12 @@ func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolCalldataFloorFlag.Name) {
+		cfg.CalldataFloor = ctx.Uint64(TxPoolCalldataFloorFlag.Name)
+	}
+	if ctx.IsSet(TxPoolSenderLimitFlag.Name) {
+		cfg.SenderLimit = ctx.Uint64(TxPoolSenderLimitFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1798,6 +1917,22 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.Uint64(HistoryWindowFlag.Name) > 0 || ctx.IsSet(HistoryBlocksFlag.Name) {
+		cfg.HistoryRetention = MakeHistoryRetention(ctx)
+	}
+	if ctx.IsSet(TxSenderIndexFlag.Name) {
+		cfg.TxSenderIndex = ctx.Bool(TxSenderIndexFlag.Name)
+	}
+	if ctx.IsSet(StateDiffsFlag.Name) {
+		cfg.StateDiffs = ctx.Bool(StateDiffsFlag.Name)
+	}
+	if ctx.IsSet(StateRetainBlocksFlag.Name) || ctx.IsSet(StateRetainAccountsFlag.Name) {
+		if cfg.NoPruning {
+			log.Warn("Disabling state retention of archive node")
+		} else {
+			cfg.StateRetention = MakeRetentionPolicy(ctx)
+		}
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
@@ -1832,6 +1967,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMOpCountersFlag.Name) {
+		cfg.EnableOpCounters = ctx.Bool(VMOpCountersFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -1847,6 +1985,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(RPCJSTracerTimeoutFlag.Name) || ctx.IsSet(RPCJSTracerStepsFlag.Name) || ctx.IsSet(RPCJSTracerMemoryFlag.Name) {
+		js.SetLimits(js.Limits{
+			Timeout: ctx.Duration(RPCJSTracerTimeoutFlag.Name),
+			Steps:   ctx.Uint64(RPCJSTracerStepsFlag.Name),
+			Memory:  ctx.Uint64(RPCJSTracerMemoryFlag.Name) * 1024 * 1024,
+		})
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -2246,6 +2391,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
+		StateDiffs:          ctx.Bool(StateDiffsFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -2290,3 +2436,33 @@ func MakeConsolePreloads(ctx *cli.Context) []string {
 	}
 	return preloads
 }
+
+// MakeHistoryRetention creates the block history retention policy configured by
+// the command line flags.
+func MakeHistoryRetention(ctx *cli.Context) *core.HistoryRetention {
+	ranges, err := state.ParseBlockRanges(ctx.String(HistoryBlocksFlag.Name))
+	if err != nil {
+		Fatalf("Invalid --%s: %v", HistoryBlocksFlag.Name, err)
+	}
+	return &core.HistoryRetention{
+		Window: ctx.Uint64(HistoryWindowFlag.Name),
+		Ranges: ranges,
+	}
+}
+
+// MakeRetentionPolicy creates the state retention policy configured by the
+// command line flags.
+func MakeRetentionPolicy(ctx *cli.Context) *state.RetentionPolicy {
+	ranges, err := state.ParseBlockRanges(ctx.String(StateRetainBlocksFlag.Name))
+	if err != nil {
+		Fatalf("Invalid --%s: %v", StateRetainBlocksFlag.Name, err)
+	}
+	policy := &state.RetentionPolicy{Ranges: ranges}
+	for _, account := range SplitAndTrim(ctx.String(StateRetainAccountsFlag.Name)) {
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid --%s: invalid account %q", StateRetainAccountsFlag.Name, account)
+		}
+		policy.Addresses = append(policy.Addresses, common.HexToAddress(account))
+	}
+	return policy
+}
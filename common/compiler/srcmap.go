@@ -0,0 +1,94 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SourceRange is the source location of a single instruction, as recorded in
+// a solc source map.
+type SourceRange struct {
+	Start  int  // Byte offset of the range in the source file
+	Length int  // Length of the range in bytes
+	File   int  // Index of the source file, -1 if the instruction has no source
+	Jump   byte // 'i' for jumps into a function, 'o' for returns, '-' otherwise
+}
+
+// SourceMap maps every instruction of a contract to its source range.
+type SourceMap []SourceRange
+
+// ParseSourceMap decodes a solc source map in its compressed form, i.e. the
+// "s:l:f:j;..." format emitted as srcmap or srcmap-runtime. Omitted fields are
+// inherited from the previous entry.
+func ParseSourceMap(srcmap string) (SourceMap, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		entries = strings.Split(srcmap, ";")
+		ranges  = make(SourceMap, len(entries))
+		last    = SourceRange{File: -1, Jump: '-'}
+	)
+	for i, entry := range entries {
+		fields := strings.Split(entry, ":")
+		for j, field := range fields {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if len(field) != 1 {
+					return nil, fmt.Errorf("entry %d: invalid jump type %q", i, field)
+				}
+				last.Jump = field[0]
+				continue
+			}
+			if j > 3 {
+				break // modifier depth and later additions
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			switch j {
+			case 0:
+				last.Start = n
+			case 1:
+				last.Length = n
+			case 2:
+				last.File = n
+			}
+		}
+		ranges[i] = last
+	}
+	return ranges, nil
+}
+
+// instructionOffsets returns the program counters of the instructions of the
+// code, which source maps are indexed by.
+func instructionOffsets(code []byte) []uint64 {
+	var pcs []uint64
+	for pc := 0; pc < len(code); pc++ {
+		pcs = append(pcs, uint64(pc))
+		if op := code[pc]; op >= 0x60 && op <= 0x7f { // PUSH1 - PUSH32
+			pc += int(op - 0x5f)
+		}
+	}
+	return pcs
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// StandardOutput is the output of a solc --standard-json run, reduced to the
+// parts needed to map executed code back to its source.
+type StandardOutput struct {
+	Errors    []StandardError                        `json:"errors"`
+	Sources   map[string]StandardSource              `json:"sources"`   // Source units by path
+	Contracts map[string]map[string]StandardContract `json:"contracts"` // Contracts by source path and name
+}
+
+// StandardError is a diagnostic reported by the compiler.
+type StandardError struct {
+	Severity         string `json:"severity"`
+	Type             string `json:"type"`
+	FormattedMessage string `json:"formattedMessage"`
+}
+
+// StandardSource is a compiled source unit.
+type StandardSource struct {
+	ID  int             `json:"id"`  // File index used in source maps
+	AST json.RawMessage `json:"ast"` // Compact AST of the source unit
+}
+
+// StandardContract is a compiled contract.
+type StandardContract struct {
+	ABI json.RawMessage `json:"abi"`
+	EVM struct {
+		Bytecode          StandardBytecode  `json:"bytecode"`
+		DeployedBytecode  StandardBytecode  `json:"deployedBytecode"`
+		MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+	} `json:"evm"`
+}
+
+// StandardBytecode is the creation or deployed code of a contract.
+type StandardBytecode struct {
+	Object    string `json:"object"`
+	SourceMap string `json:"sourceMap"`
+
+	// ImmutableReferences are the ranges of the deployed code holding the values
+	// of immutable variables, keyed by the AST ID of the variable. The ranges
+	// are zeroed in the compiled code and filled in on deployment.
+	ImmutableReferences map[string][]ImmutableReference `json:"immutableReferences"`
+}
+
+// ImmutableReference is a range of code holding the value of an immutable.
+type ImmutableReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// ParseStandardJSON parses the output of a solc --standard-json run. Errors
+// reported by the compiler are returned as an error, warnings are retained in
+// the output.
+func ParseStandardJSON(output []byte) (*StandardOutput, error) {
+	var out StandardOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, e := range out.Errors {
+		if e.Severity == "error" {
+			errs = append(errs, strings.TrimSpace(e.FormattedMessage))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("solc: %s", strings.Join(errs, "\n"))
+	}
+	return &out, nil
+}
+
+// Contract returns the compiled contract with the given name, declared in the
+// source file with the given path.
+func (o *StandardOutput) Contract(path, name string) (*StandardContract, error) {
+	contract, ok := o.Contracts[path][name]
+	if !ok {
+		return nil, fmt.Errorf("contract %s:%s not found", path, name)
+	}
+	return &contract, nil
+}
+
+// Code returns the compiled code. Code referencing libraries must be linked
+// first, i.e. have the library placeholders replaced by addresses.
+func (b *StandardBytecode) Code() ([]byte, error) {
+	if strings.Contains(b.Object, "__") {
+		return nil, errors.New("code contains unlinked library references")
+	}
+	return hexutil.Decode("0x" + strings.TrimPrefix(b.Object, "0x"))
+}
+
+// Matches returns whether the code is an instance of the compiled code, i.e.
+// equal to it apart from the values of immutable variables.
+func (b *StandardBytecode) Matches(code []byte) bool {
+	compiled, err := b.Code()
+	if err != nil || len(compiled) != len(code) {
+		return false
+	}
+	masked := make([]byte, len(code))
+	copy(masked, code)
+	for _, refs := range b.ImmutableReferences {
+		for _, ref := range refs {
+			if ref.Start < 0 || ref.Start+ref.Length > len(masked) {
+				return false
+			}
+			copy(masked[ref.Start:ref.Start+ref.Length], make([]byte, ref.Length))
+		}
+	}
+	return bytes.Equal(masked, compiled)
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Function is a function or modifier definition found in a source AST.
+type Function struct {
+	Contract string // Name of the declaring contract, empty for free functions
+	Name     string // Name of the function, or its kind for constructors, fallback and receive functions
+	File     int    // Index of the source file
+	Start    int    // Byte offset of the definition in the source file
+	Length   int    // Length of the definition in bytes
+}
+
+// String returns the qualified name of the function.
+func (f *Function) String() string {
+	if f.Contract == "" {
+		return f.Name
+	}
+	return f.Contract + "." + f.Name
+}
+
+// contains returns whether the definition encloses the given source range.
+func (f *Function) contains(start, length int) bool {
+	return f.Start <= start && start+length <= f.Start+f.Length
+}
+
+// ParseFunctions extracts the function and modifier definitions from the
+// compact AST of a source unit, as emitted by solc --standard-json.
+func ParseFunctions(ast json.RawMessage) ([]*Function, error) {
+	var root interface{}
+	if err := json.Unmarshal(ast, &root); err != nil {
+		return nil, err
+	}
+	var (
+		functions []*Function
+		walk      func(node interface{}, contract string) error
+	)
+	walk = func(node interface{}, contract string) error {
+		switch node := node.(type) {
+		case []interface{}:
+			for _, child := range node {
+				if err := walk(child, contract); err != nil {
+					return err
+				}
+			}
+		case map[string]interface{}:
+			name, _ := node["name"].(string)
+			switch node["nodeType"] {
+			case "ContractDefinition":
+				contract = name
+			case "FunctionDefinition", "ModifierDefinition":
+				if kind, _ := node["kind"].(string); kind == "constructor" || kind == "fallback" || kind == "receive" {
+					name = kind
+				}
+				src, _ := node["src"].(string)
+				fn, err := parseSrc(src)
+				if err != nil {
+					return fmt.Errorf("function %s: %v", name, err)
+				}
+				fn.Contract, fn.Name = contract, name
+				functions = append(functions, fn)
+			}
+			for _, child := range node {
+				if err := walk(child, contract); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// parseSrc decodes the "start:length:file" source range of an AST node.
+func parseSrc(src string) (*Function, error) {
+	fields := strings.Split(src, ":")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid source range %q", src)
+	}
+	var n [3]int
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source range %q", src)
+		}
+		n[i] = v
+	}
+	return &Function{Start: n[0], Length: n[1], File: n[2]}, nil
+}
+
+// Location is the source location of an executed instruction.
+type Location struct {
+	Path     string // Path of the source file
+	Line     int    // Line of the start of the range, 1-based, 0 if the source content is unknown
+	Column   int    // Byte column of the start of the range, 1-based, 0 if the source content is unknown
+	Start    int    // Byte offset of the range in the source file
+	Length   int    // Length of the range in bytes
+	Function string // Qualified name of the innermost enclosing function, empty if none
+	Jump     byte   // 'i' for jumps into a function, 'o' for returns, '-' otherwise
+}
+
+// String returns the location in the path:line:column form, falling back to
+// byte offsets if the source content is unknown.
+func (l *Location) String() string {
+	pos := fmt.Sprintf("%s:%d:%d", l.Path, l.Line, l.Column)
+	if l.Line == 0 {
+		pos = fmt.Sprintf("%s@%d+%d", l.Path, l.Start, l.Length)
+	}
+	if l.Function != "" {
+		pos += " (" + l.Function + ")"
+	}
+	return pos
+}
+
+// Symbolizer maps the program counters of a compiled contract to their source
+// locations and enclosing functions.
+type Symbolizer struct {
+	instructions map[uint64]int      // Instruction indexes by program counter
+	srcmap       SourceMap           // Source ranges of the instructions
+	paths        map[int]string      // Source paths by file index
+	lines        map[int][]int       // Line start offsets by file index, if the content is known
+	functions    map[int][]*Function // Function definitions by file index
+}
+
+// NewSymbolizer creates a symbolizer for the given creation or deployed code of
+// a contract and its source map. The source units are keyed by path, with the
+// optional contents used to resolve line numbers.
+func NewSymbolizer(code []byte, srcmap SourceMap, sources map[string]StandardSource, contents map[string]string) (*Symbolizer, error) {
+	s := &Symbolizer{
+		instructions: make(map[uint64]int),
+		srcmap:       srcmap,
+		paths:        make(map[int]string),
+		lines:        make(map[int][]int),
+		functions:    make(map[int][]*Function),
+	}
+	for i, pc := range instructionOffsets(code) {
+		s.instructions[pc] = i
+	}
+	for path, source := range sources {
+		s.paths[source.ID] = path
+		if content, ok := contents[path]; ok {
+			s.lines[source.ID] = lineStarts(content)
+		}
+		if len(source.AST) == 0 {
+			continue
+		}
+		functions, err := ParseFunctions(source.AST)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, fn := range functions {
+			s.functions[fn.File] = append(s.functions[fn.File], fn)
+		}
+	}
+	return s, nil
+}
+
+// Symbolizer creates a symbolizer for the named contract declared in the source
+// file with the given path, covering its deployed code if deployed is set, or
+// its creation code otherwise. The optional source contents, keyed by path, are
+// used to resolve line numbers.
+func (o *StandardOutput) Symbolizer(path, name string, deployed bool, contents map[string]string) (*Symbolizer, error) {
+	contract, err := o.Contract(path, name)
+	if err != nil {
+		return nil, err
+	}
+	bytecode := contract.EVM.Bytecode
+	if deployed {
+		bytecode = contract.EVM.DeployedBytecode
+	}
+	code, err := bytecode.Code()
+	if err != nil {
+		return nil, err
+	}
+	srcmap, err := ParseSourceMap(bytecode.SourceMap)
+	if err != nil {
+		return nil, err
+	}
+	return NewSymbolizer(code, srcmap, o.Sources, contents)
+}
+
+// Locate returns the source location of the instruction at the given program
+// counter. Instructions generated by the compiler without a source location,
+// and program counters not at an instruction of the code are not located.
+func (s *Symbolizer) Locate(pc uint64) (*Location, bool) {
+	i, ok := s.instructions[pc]
+	if !ok || i >= len(s.srcmap) {
+		return nil, false
+	}
+	r := s.srcmap[i]
+	if r.File < 0 {
+		return nil, false
+	}
+	loc := &Location{
+		Path:   s.paths[r.File],
+		Start:  r.Start,
+		Length: r.Length,
+		Jump:   r.Jump,
+	}
+	if starts, ok := s.lines[r.File]; ok {
+		loc.Line = sort.SearchInts(starts, r.Start+1)
+		loc.Column = r.Start - starts[loc.Line-1] + 1
+	}
+	// Pick the innermost function enclosing the range
+	var innermost *Function
+	for _, fn := range s.functions[r.File] {
+		if fn.contains(r.Start, r.Length) && (innermost == nil || fn.Length < innermost.Length) {
+			innermost = fn
+		}
+	}
+	if innermost != nil {
+		loc.Function = innermost.String()
+	}
+	return loc, true
+}
+
+// lineStarts returns the byte offsets at which the lines of the source start.
+func lineStarts(src string) []int {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testSource = `contract C {
+    uint immutable x = 1;
+    function f() public returns (uint) {
+        return x + 2;
+    }
+}
+`
+
+// testStandardOutput assembles a standard-json output for the test source, with
+// a deployed code of PUSH32 <x> PUSH1 2 ADD STOP.
+func testStandardOutput(t *testing.T) []byte {
+	var (
+		contract = strings.Index(testSource, "contract")
+		function = strings.Index(testSource, "function")
+		body     = strings.Index(testSource, "x + 2")
+		ast      = fmt.Sprintf(`{"nodeType":"SourceUnit","src":"0:%d:0","nodes":[{"nodeType":"ContractDefinition","name":"C","src":"%d:%d:0","nodes":[
+			{"nodeType":"VariableDeclaration","name":"x","src":"17:21:0"},
+			{"nodeType":"FunctionDefinition","name":"f","kind":"function","src":"%d:%d:0","body":{"nodeType":"Block","src":"%d:5:0"}}]}]}`,
+			len(testSource), contract, len(testSource)-contract-1, function, strings.Index(testSource, "}\n}")+1-function, body)
+		srcmap = fmt.Sprintf("%d:%d:0:-;%d:5::i;:::o;-1:0:-1", contract, len(testSource)-contract-1, body)
+		code   = "7f" + strings.Repeat("00", 32) + "6002" + "01" + "00"
+	)
+	return []byte(fmt.Sprintf(`{
+		"errors": [{"severity": "warning", "type": "Warning", "formattedMessage": "unused"}],
+		"sources": {"c.sol": {"id": 0, "ast": %s}},
+		"contracts": {"c.sol": {"C": {"abi": [], "evm": {
+			"bytecode": {"object": "", "sourceMap": ""},
+			"deployedBytecode": {"object": "%s", "sourceMap": "%s", "immutableReferences": {"3": [{"start": 1, "length": 32}]}}
+		}}}}
+	}`, ast, code, srcmap))
+}
+
+func TestSymbolizer(t *testing.T) {
+	out, err := ParseStandardJSON(testStandardOutput(t))
+	if err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	s, err := out.Symbolizer("c.sol", "C", true, map[string]string{"c.sol": testSource})
+	if err != nil {
+		t.Fatalf("failed to create symbolizer: %v", err)
+	}
+	tests := []struct {
+		pc       uint64
+		ok       bool
+		line     int
+		column   int
+		function string
+		jump     byte
+	}{
+		{pc: 0, ok: true, line: 1, column: 1, function: "", jump: '-'},
+		{pc: 33, ok: true, line: 4, column: 16, function: "C.f", jump: 'i'},
+		{pc: 35, ok: true, line: 4, column: 16, function: "C.f", jump: 'o'},
+		{pc: 36, ok: false},   // Compiler generated
+		{pc: 1, ok: false},    // Push data
+		{pc: 1000, ok: false}, // Beyond the code
+	}
+	for i, tt := range tests {
+		loc, ok := s.Locate(tt.pc)
+		if ok != tt.ok {
+			t.Fatalf("test %d: location availability mismatch: have %v, want %v", i, ok, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		if loc.Path != "c.sol" || loc.Line != tt.line || loc.Column != tt.column || loc.Function != tt.function || loc.Jump != tt.jump {
+			t.Errorf("test %d: location mismatch: have %v (jump %c), want c.sol:%d:%d (%s, jump %c)", i, loc, loc.Jump, tt.line, tt.column, tt.function, tt.jump)
+		}
+	}
+}
+
+func TestStandardBytecodeMatches(t *testing.T) {
+	out, err := ParseStandardJSON(testStandardOutput(t))
+	if err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	contract, _ := out.Contract("c.sol", "C")
+	code, err := contract.EVM.DeployedBytecode.Code()
+	if err != nil {
+		t.Fatalf("failed to decode code: %v", err)
+	}
+	// Deployed code holds the value of the immutable
+	deployed := append([]byte{}, code...)
+	deployed[32] = 1
+	if !contract.EVM.DeployedBytecode.Matches(deployed) {
+		t.Errorf("deployed code with immutable value not matched")
+	}
+	deployed[33] = 0x61
+	if contract.EVM.DeployedBytecode.Matches(deployed) {
+		t.Errorf("modified code matched")
+	}
+}
+
+func TestParseStandardJSONErrors(t *testing.T) {
+	output, _ := json.Marshal(map[string]interface{}{
+		"errors": []StandardError{{Severity: "error", Type: "ParserError", FormattedMessage: "ParserError: Expected ';'\n"}},
+	})
+	if _, err := ParseStandardJSON(output); err == nil || !strings.Contains(err.Error(), "Expected ';'") {
+		t.Fatalf("compiler error not reported: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package fifo implements a bounded first-in first-out queue.
+package fifo
+
+// Queue is a first-in first-out queue of bounded capacity, backed by a ring
+// buffer. Pushing into a full queue evicts the oldest item. Queue is not safe
+// for concurrent use.
+type Queue[T any] struct {
+	items []T
+	head  int // Index of the oldest item
+	size  int // Number of items in the queue
+}
+
+// New creates a queue holding at most capacity items.
+func New[T any](capacity int) *Queue[T] {
+	if capacity <= 0 {
+		panic("fifo: capacity must be positive")
+	}
+	return &Queue[T]{items: make([]T, capacity)}
+}
+
+// Push appends an item to the queue. If the queue is full, the oldest item is
+// evicted and returned.
+func (q *Queue[T]) Push(item T) (evicted T, ok bool) {
+	if q.size == len(q.items) {
+		evicted, ok = q.items[q.head], true
+		q.items[q.head] = item
+		q.head = (q.head + 1) % len(q.items)
+		return evicted, ok
+	}
+	q.items[(q.head+q.size)%len(q.items)] = item
+	q.size++
+	return evicted, false
+}
+
+// Pop removes and returns the oldest item of the queue.
+func (q *Queue[T]) Pop() (item T, ok bool) {
+	if q.size == 0 {
+		return item, false
+	}
+	var zero T
+	item, q.items[q.head] = q.items[q.head], zero // Release the reference
+	q.head = (q.head + 1) % len(q.items)
+	q.size--
+	return item, true
+}
+
+// Peek returns the oldest item of the queue without removing it.
+func (q *Queue[T]) Peek() (item T, ok bool) {
+	if q.size == 0 {
+		return item, false
+	}
+	return q.items[q.head], true
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue[T]) Len() int {
+	return q.size
+}
+
+// Cap returns the maximum number of items in the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.items)
+}
+
+// Items returns the items of the queue, oldest first.
+func (q *Queue[T]) Items() []T {
+	items := make([]T, q.size)
+	for i := range items {
+		items[i] = q.items[(q.head+i)%len(q.items)]
+	}
+	return items
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fifo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	q := New[int](3)
+	if _, ok := q.Pop(); ok {
+		t.Fatal("pop from empty queue succeeded")
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := q.Push(i); ok {
+			t.Fatalf("push %d evicted an item", i)
+		}
+	}
+	// Pushing into the full queue evicts the oldest items
+	for i := 3; i < 5; i++ {
+		evicted, ok := q.Push(i)
+		if !ok || evicted != i-3 {
+			t.Fatalf("push %d: evicted mismatch: have %d/%v, want %d/true", i, evicted, ok, i-3)
+		}
+	}
+	if have, want := q.Items(), []int{2, 3, 4}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("items mismatch: have %v, want %v", have, want)
+	}
+	if item, ok := q.Peek(); !ok || item != 2 {
+		t.Fatalf("peek mismatch: have %d/%v, want 2/true", item, ok)
+	}
+	for want := 2; want < 5; want++ {
+		if item, ok := q.Pop(); !ok || item != want {
+			t.Fatalf("pop mismatch: have %d/%v, want %d/true", item, ok, want)
+		}
+	}
+	if q.Len() != 0 || q.Cap() != 3 {
+		t.Fatalf("size mismatch: have %d/%d, want 0/3", q.Len(), q.Cap())
+	}
+}
@@ -30,11 +30,15 @@ type blobType interface {
 // is at capacity, and a new item is added, older items are evicted until the size
 // constraint is met.
 //
-// OBS: This cache assumes that items are content-addressed: keys are unique per content.
-// In other words: two Add(..) with the same key K, will always have the same value V.
-type SizeConstrainedCache[K comparable, V blobType] struct {
+// OBS: Caches created by NewSizeConstrainedCache assume that items are content-addressed:
+// keys are unique per content. In other words: two Add(..) with the same key K, will always
+// have the same value V. Caches created by NewSizeConstrainedCacheFunc allow values to be
+// replaced.
+type SizeConstrainedCache[K comparable, V any] struct {
 	size    uint64
 	maxSize uint64
+	sizeOf  func(V) uint64
+	replace bool // Whether values of present keys may change
 	lru     BasicLRU[K, V]
 	lock    sync.Mutex
 }
@@ -44,13 +48,25 @@ func NewSizeConstrainedCache[K comparable, V blobType](maxSize uint64) *SizeCons
 	return &SizeConstrainedCache[K, V]{
 		size:    0,
 		maxSize: maxSize,
+		sizeOf:  func(v V) uint64 { return uint64(len(v)) },
+		lru:     NewBasicLRU[K, V](math.MaxInt),
+	}
+}
+
+// NewSizeConstrainedCacheFunc creates a new size-constrained LRU cache for values
+// of arbitrary type, measuring the size of values with the given function. The
+// size of a value must not change while it is in the cache.
+func NewSizeConstrainedCacheFunc[K comparable, V any](maxSize uint64, sizeOf func(V) uint64) *SizeConstrainedCache[K, V] {
+	return &SizeConstrainedCache[K, V]{
+		size:    0,
+		maxSize: maxSize,
+		sizeOf:  sizeOf,
+		replace: true,
 		lru:     NewBasicLRU[K, V](math.MaxInt),
 	}
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
-// OBS: This cache assumes that items are content-addressed: keys are unique per content.
-// In other words: two Add(..) with the same key K, will always have the same value V.
 // OBS: The value is _not_ copied on Add, so the caller must not modify it afterwards.
 func (c *SizeConstrainedCache[K, V]) Add(key K, value V) (evicted bool) {
 	c.lock.Lock()
@@ -58,8 +74,14 @@ func (c *SizeConstrainedCache[K, V]) Add(key K, value V) (evicted bool) {
 
 	// Unless it is already present, might need to evict something.
 	// OBS: If it is present, we still call Add internally to bump the recentness.
+	if c.replace {
+		if old, ok := c.lru.Peek(key); ok {
+			c.lru.Remove(key)
+			c.size -= c.sizeOf(old)
+		}
+	}
 	if !c.lru.Contains(key) {
-		targetSize := c.size + uint64(len(value))
+		targetSize := c.size + c.sizeOf(value)
 		for targetSize > c.maxSize {
 			evicted = true
 			_, v, ok := c.lru.RemoveOldest()
@@ -67,7 +89,7 @@ func (c *SizeConstrainedCache[K, V]) Add(key K, value V) (evicted bool) {
 				// list is now empty. Break
 				break
 			}
-			targetSize -= uint64(len(v))
+			targetSize -= c.sizeOf(v)
 		}
 		c.size = targetSize
 	}
@@ -75,6 +97,28 @@ func (c *SizeConstrainedCache[K, V]) Add(key K, value V) (evicted bool) {
 	return evicted
 }
 
+// Remove drops an item from the cache. Returns true if the key was present.
+func (c *SizeConstrainedCache[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	old, ok := c.lru.Peek(key)
+	if !ok {
+		return false
+	}
+	c.lru.Remove(key)
+	c.size -= c.sizeOf(old)
+	return true
+}
+
+// Size returns the total size of the cached values.
+func (c *SizeConstrainedCache[K, V]) Size() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.size
+}
+
 // Get looks up a key's value from the cache.
 func (c *SizeConstrainedCache[K, V]) Get(key K) (V, bool) {
 	c.lock.Lock()
@@ -153,3 +153,31 @@ func TestSizeConstrainedCacheEmpties(t *testing.T) {
 		}
 	}
 }
+
+// This test checks that caches of arbitrary values account for replaced and
+// removed values.
+func TestSizeConstrainedCacheFunc(t *testing.T) {
+	lru := NewSizeConstrainedCacheFunc[int, []int](10, func(v []int) uint64 { return uint64(len(v)) })
+
+	lru.Add(1, make([]int, 4))
+	lru.Add(2, make([]int, 4))
+	if lru.Add(1, make([]int, 2)) {
+		t.Fatal("replacing a value with a smaller one evicted an item")
+	}
+	if size := lru.Size(); size != 6 {
+		t.Fatalf("size mismatch: have %d, want %d", size, 6)
+	}
+	// Adding beyond the limit evicts the least recently used item
+	if !lru.Add(3, make([]int, 5)) {
+		t.Fatal("expected eviction")
+	}
+	if _, ok := lru.Get(2); ok {
+		t.Fatal("least recently used item not evicted")
+	}
+	if !lru.Remove(1) || lru.Remove(1) {
+		t.Fatal("remove mismatch")
+	}
+	if size := lru.Size(); size != 5 {
+		t.Fatalf("size mismatch: have %d, want %d", size, 5)
+	}
+}
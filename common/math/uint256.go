@@ -0,0 +1,120 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package math
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/holiman/uint256"
+)
+
+// MaxU256 is the largest value representable by a uint256.Int.
+var MaxU256 = new(uint256.Int).SetAllOne()
+
+// ToU256 converts x into a uint256.Int. The returned boolean reports whether
+// the conversion overflowed, which happens if x is negative or does not fit
+// into 256 bits. On overflow the result holds the lowest 256 bits of x's
+// two's complement representation, mirroring uint256.FromBig.
+func ToU256(x *big.Int) (*uint256.Int, bool) {
+	if x == nil {
+		return new(uint256.Int), false
+	}
+	u, overflow := uint256.FromBig(x)
+	return u, overflow || x.Sign() < 0
+}
+
+// MustToU256 converts x into a uint256.Int and panics if the value does not
+// fit into the unsigned 256 bit range.
+func MustToU256(x *big.Int) *uint256.Int {
+	u, overflow := ToU256(x)
+	if overflow {
+		panic("big integer out of uint256 range: " + x.String())
+	}
+	return u
+}
+
+// SaturatingToU256 converts x into a uint256.Int, clamping negative values
+// to zero and values exceeding 2^256-1 to MaxU256.
+func SaturatingToU256(x *big.Int) *uint256.Int {
+	switch {
+	case x == nil || x.Sign() <= 0:
+		return new(uint256.Int)
+	case x.BitLen() > 256:
+		return new(uint256.Int).Set(MaxU256)
+	default:
+		u, _ := uint256.FromBig(x)
+		return u
+	}
+}
+
+// FromU256 converts x into a newly allocated big.Int. A nil input is
+// returned as nil.
+func FromU256(x *uint256.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return x.ToBig()
+}
+
+// HexBigToU256 converts a JSON decoded hexutil.Big into a uint256.Int,
+// reporting whether the conversion overflowed.
+func HexBigToU256(x *hexutil.Big) (*uint256.Int, bool) {
+	if x == nil {
+		return new(uint256.Int), false
+	}
+	return ToU256(x.ToInt())
+}
+
+// U256ToHexBig converts x into a hexutil.Big suitable for JSON encoding.
+func U256ToHexBig(x *uint256.Int) *hexutil.Big {
+	if x == nil {
+		return nil
+	}
+	return (*hexutil.Big)(x.ToBig())
+}
+
+// U256SafeAdd returns x+y and checks for overflow.
+func U256SafeAdd(x, y *uint256.Int) (*uint256.Int, bool) {
+	return new(uint256.Int).AddOverflow(x, y)
+}
+
+// U256SafeSub returns x-y and checks for underflow.
+func U256SafeSub(x, y *uint256.Int) (*uint256.Int, bool) {
+	return new(uint256.Int).SubOverflow(x, y)
+}
+
+// U256SafeMul returns x*y and checks for overflow.
+func U256SafeMul(x, y *uint256.Int) (*uint256.Int, bool) {
+	return new(uint256.Int).MulOverflow(x, y)
+}
+
+// U256SaturatingAdd returns x+y, clamped to MaxU256 on overflow.
+func U256SaturatingAdd(x, y *uint256.Int) *uint256.Int {
+	if z, overflow := U256SafeAdd(x, y); !overflow {
+		return z
+	}
+	return new(uint256.Int).Set(MaxU256)
+}
+
+// U256SaturatingSub returns x-y, clamped to zero on underflow.
+func U256SaturatingSub(x, y *uint256.Int) *uint256.Int {
+	if z, underflow := U256SafeSub(x, y); !underflow {
+		return z
+	}
+	return new(uint256.Int)
+}
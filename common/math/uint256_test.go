@@ -0,0 +1,103 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package math
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+)
+
+func TestToU256(t *testing.T) {
+	tests := []struct {
+		input    *big.Int
+		want     *uint256.Int
+		overflow bool
+	}{
+		{nil, uint256.NewInt(0), false},
+		{big.NewInt(0), uint256.NewInt(0), false},
+		{big.NewInt(1), uint256.NewInt(1), false},
+		{new(big.Int).Set(tt256m1), MaxU256, false},
+		{new(big.Int).Set(tt256), uint256.NewInt(0), true},
+		{big.NewInt(-1), MaxU256, true},
+	}
+	for i, test := range tests {
+		have, overflow := ToU256(test.input)
+		if overflow != test.overflow {
+			t.Errorf("test %d: overflow mismatch: have %v, want %v", i, overflow, test.overflow)
+		}
+		if !have.Eq(test.want) {
+			t.Errorf("test %d: value mismatch: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func TestMustToU256(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustToU256 should've panicked on overflowing input")
+		}
+	}()
+	MustToU256(new(big.Int).Set(tt256))
+}
+
+func TestSaturatingToU256(t *testing.T) {
+	tests := []struct {
+		input *big.Int
+		want  *uint256.Int
+	}{
+		{nil, uint256.NewInt(0)},
+		{big.NewInt(-5), uint256.NewInt(0)},
+		{big.NewInt(5), uint256.NewInt(5)},
+		{new(big.Int).Set(tt256m1), MaxU256},
+		{new(big.Int).Set(tt256), MaxU256},
+	}
+	for i, test := range tests {
+		if have := SaturatingToU256(test.input); !have.Eq(test.want) {
+			t.Errorf("test %d: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func TestFromU256(t *testing.T) {
+	if FromU256(nil) != nil {
+		t.Error("expected nil for nil input")
+	}
+	if have := FromU256(MaxU256); have.Cmp(tt256m1) != 0 {
+		t.Errorf("have %v, want %v", have, tt256m1)
+	}
+}
+
+func TestU256SafeArithmetic(t *testing.T) {
+	one := uint256.NewInt(1)
+	if _, overflow := U256SafeAdd(MaxU256, one); !overflow {
+		t.Error("expected addition overflow")
+	}
+	if _, underflow := U256SafeSub(new(uint256.Int), one); !underflow {
+		t.Error("expected subtraction underflow")
+	}
+	if _, overflow := U256SafeMul(MaxU256, uint256.NewInt(2)); !overflow {
+		t.Error("expected multiplication overflow")
+	}
+	if have := U256SaturatingAdd(MaxU256, one); !have.Eq(MaxU256) {
+		t.Errorf("saturating add: have %v, want %v", have, MaxU256)
+	}
+	if have := U256SaturatingSub(one, MaxU256); !have.IsZero() {
+		t.Errorf("saturating sub: have %v, want 0", have)
+	}
+}
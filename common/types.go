@@ -26,6 +26,7 @@ import (
 	"math/big"
 	"math/rand"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -244,12 +245,39 @@ func (a Address) String() string {
 	return a.Hex()
 }
 
+// eip1191Chains is the set of chains which adopted the chain specific address
+// checksums of EIP-1191: RSK mainnet and testnet.
+var eip1191Chains = map[uint64]bool{30: true, 31: true}
+
+// HexWithChainChecksum returns the hex string representation of the address,
+// checksummed according to EIP-1191 for the given chain. The checksum only
+// differs from EIP-55 on chains which adopted EIP-1191, such as RSK, and such
+// addresses fail EIP-55 validation.
+func (a Address) HexWithChainChecksum(chainID uint64) string {
+	if !eip1191Chains[chainID] {
+		return a.Hex()
+	}
+	return string(a.checksumHexWithPrefix([]byte(strconv.FormatUint(chainID, 10))))
+}
+
 func (a *Address) checksumHex() []byte {
+	return a.checksumHexWithPrefix(nil)
+}
+
+// checksumHexWithPrefix computes the mixed-case checksum encoding of the address,
+// hashing the lowercase hex address after the given prefix. An empty prefix gives
+// the EIP-55 encoding, whereas a chain ID prefix gives the EIP-1191 encoding.
+func (a *Address) checksumHexWithPrefix(prefix []byte) []byte {
 	buf := a.hex()
 
 	// compute checksum
 	sha := sha3.NewLegacyKeccak256()
-	sha.Write(buf[2:])
+	if len(prefix) == 0 {
+		sha.Write(buf[2:])
+	} else {
+		sha.Write(prefix)
+		sha.Write(buf)
+	}
 	hash := sha.Sum(nil)
 	for i := 2; i < len(buf); i++ {
 		hashByte := hash[(i-2)/2]
@@ -425,6 +453,12 @@ func (ma *MixedcaseAddress) ValidChecksum() bool {
 	return ma.original == ma.addr.Hex()
 }
 
+// ValidChainChecksum returns true if the address has a valid EIP-1191 checksum
+// for the given chain, which equals the EIP-55 checksum on most chains.
+func (ma *MixedcaseAddress) ValidChainChecksum(chainID uint64) bool {
+	return ma.original == ma.addr.HexWithChainChecksum(chainID)
+}
+
 // Original returns the mixed-case input string
 func (ma *MixedcaseAddress) Original() string {
 	return ma.original
@@ -147,6 +147,42 @@ func TestAddressHexChecksum(t *testing.T) {
 	}
 }
 
+func TestAddressHexChainChecksum(t *testing.T) {
+	var tests = []struct {
+		ChainID uint64
+		Input   string
+		Output  string
+	}{
+		// Test cases from https://github.com/ethereum/EIPs/blob/master/EIPS/eip-1191.md#test-cases
+		{30, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD"},
+		{30, "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "0xFb6916095cA1Df60bb79ce92cE3EA74c37c5d359"},
+		{30, "0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb", "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB"},
+		{30, "0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb", "0xD1220A0Cf47c7B9BE7a2e6ba89F429762E7B9adB"},
+		{31, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aAeb6053F3e94c9b9A09F33669435E7EF1BEaEd"},
+		{31, "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "0xFb6916095CA1dF60bb79CE92ce3Ea74C37c5D359"},
+		{31, "0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb", "0xdbF03B407C01E7cd3cbEa99509D93f8dDDc8C6fB"},
+		{31, "0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb", "0xd1220a0CF47c7B9Be7A2E6Ba89f429762E7b9adB"},
+		// Chains not adopting EIP-1191 use EIP-55 checksums
+		{1, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+	}
+	for i, test := range tests {
+		output := HexToAddress(test.Input).HexWithChainChecksum(test.ChainID)
+		if output != test.Output {
+			t.Errorf("test #%d: failed to match when it should (%s != %s)", i, output, test.Output)
+		}
+		var ma MixedcaseAddress
+		if err := json.Unmarshal([]byte(`"`+test.Output+`"`), &ma); err != nil {
+			t.Fatalf("test #%d: failed to decode address: %v", i, err)
+		}
+		if !ma.ValidChainChecksum(test.ChainID) {
+			t.Errorf("test #%d: valid checksum rejected", i)
+		}
+		if test.ChainID != 1 && ma.ValidChecksum() {
+			t.Errorf("test #%d: chain specific checksum accepted as EIP-55", i)
+		}
+	}
+}
+
 func BenchmarkAddressHex(b *testing.B) {
 	testAddr := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
 	for n := 0; n < b.N; n++ {
@@ -93,34 +93,42 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return snap.signers(), nil
 }
 
+// GetInturnSigner retrieves the signer that is in-turn to seal the block
+// following the specified one.
+func (api *API) GetInturnSigner(number *rpc.BlockNumber) (common.Address, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return the signer from its snapshot
+	if header == nil {
+		return common.Address{}, errUnknownBlock
+	}
+	snap, err := api.clique.SnapshotAt(api.chain, header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return snap.InturnSigner(header.Number.Uint64() + 1), nil
+}
+
 // Proposals returns the current proposals the node tries to uphold and vote on.
 func (api *API) Proposals() map[common.Address]bool {
-	api.clique.lock.RLock()
-	defer api.clique.lock.RUnlock()
-
-	proposals := make(map[common.Address]bool)
-	for address, auth := range api.clique.proposals {
-		proposals[address] = auth
-	}
-	return proposals
+	return api.clique.Proposals()
 }
 
 // Propose injects a new authorization proposal that the signer will attempt to
 // push through.
 func (api *API) Propose(address common.Address, auth bool) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
-
-	api.clique.proposals[address] = auth
+	api.clique.Propose(address, auth)
 }
 
 // Discard drops a currently running proposal, stopping the signer from casting
 // further votes (either for or against).
 func (api *API) Discard(address common.Address) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
-
-	delete(api.clique.proposals, address)
+	api.clique.Discard(address)
 }
 
 type status struct {
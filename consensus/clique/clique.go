@@ -597,6 +597,44 @@ func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// Propose injects a new authorization proposal that the local signer will
+// attempt to push through, voting to add (auth = true) or remove the address
+// whenever it seals a block.
+func (c *Clique) Propose(address common.Address, auth bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.proposals[address] = auth
+}
+
+// Discard drops a currently running proposal, stopping the signer from casting
+// further votes (either for or against).
+func (c *Clique) Discard(address common.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.proposals, address)
+}
+
+// Proposals returns the current proposals the local signer tries to uphold
+// and vote on.
+func (c *Clique) Proposals() map[common.Address]bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	proposals := make(map[common.Address]bool, len(c.proposals))
+	for address, auth := range c.proposals {
+		proposals[address] = auth
+	}
+	return proposals
+}
+
+// SnapshotAt retrieves the authorization snapshot at the given header, which
+// contains the signer set in effect for sealing its children.
+func (c *Clique) SnapshotAt(chain consensus.ChainHeaderReader, header *types.Header) (*Snapshot, error) {
+	return c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
@@ -0,0 +1,215 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package cliquesim implements an in-memory clique network, in which a set of
+// locally held signer keys take turns sealing blocks. It allows tooling for
+// permissioned chains to exercise signer management without running nodes.
+package cliquesim
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Lengths of the fixed extra-data sections of clique headers.
+const (
+	extraVanity = 32
+	extraSeal   = crypto.SignatureLength
+)
+
+// errNoSigner is returned if none of the authorized signers may seal the next
+// block, which can only happen if their keys are not known to the network.
+var errNoSigner = errors.New("no signer allowed to seal")
+
+// Network is a simulated clique network. All proposals made through Propose
+// are voted on by every signer when it's their turn to seal, mimicking a set of
+// nodes configured with the same proposals.
+type Network struct {
+	Genesis *core.Genesis
+	Engine  *clique.Clique
+	Chain   *core.BlockChain
+
+	keys map[common.Address]*ecdsa.PrivateKey
+}
+
+// NewNetwork creates a clique network with the given number of freshly
+// generated initial signers, using a zero block period.
+func NewNetwork(signers int) (*Network, error) {
+	if signers <= 0 {
+		return nil, errors.New("at least one signer required")
+	}
+	n := &Network{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+
+	var addrs []common.Address
+	for i := 0; i < signers; i++ {
+		addrs = append(addrs, n.NewKey())
+	}
+	sortAddresses(addrs)
+
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = &params.CliqueConfig{Period: 0, Epoch: 30000}
+
+	n.Genesis = &core.Genesis{
+		Config:    &config,
+		ExtraData: make([]byte, extraVanity+len(addrs)*common.AddressLength+extraSeal),
+		GasLimit:  params.GenesisGasLimit,
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+		Alloc:     make(core.GenesisAlloc),
+	}
+	for i, addr := range addrs {
+		copy(n.Genesis.ExtraData[extraVanity+i*common.AddressLength:], addr[:])
+	}
+	n.Engine = clique.New(config.Clique, rawdb.NewMemoryDatabase())
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, n.Genesis, nil, n.Engine, vm.Config{}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	n.Chain = chain
+	return n, nil
+}
+
+// Close stops the underlying blockchain.
+func (n *Network) Close() {
+	n.Chain.Stop()
+}
+
+// NewKey generates a new signer key known to the network, without authorizing
+// it. The returned address can be voted in via Propose.
+func (n *Network) NewKey() common.Address {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	n.keys[addr] = key
+	return addr
+}
+
+// Key returns the private key of a signer known to the network.
+func (n *Network) Key(addr common.Address) *ecdsa.PrivateKey {
+	return n.keys[addr]
+}
+
+// Propose makes every signer vote for adding (auth = true) or removing the
+// address, until discarded.
+func (n *Network) Propose(addr common.Address, auth bool) {
+	n.Engine.Propose(addr, auth)
+}
+
+// Discard stops all signers from voting on the address.
+func (n *Network) Discard(addr common.Address) {
+	n.Engine.Discard(addr)
+}
+
+// Snapshot returns the clique snapshot at the current head.
+func (n *Network) Snapshot() (*clique.Snapshot, error) {
+	return n.Engine.SnapshotAt(n.Chain, n.Chain.CurrentHeader())
+}
+
+// Signers returns the current authorized signers, in ascending order.
+func (n *Network) Signers() ([]common.Address, error) {
+	snap, err := n.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return snap.SignerList(), nil
+}
+
+// Commit seals a new empty block on top of the current head and imports it.
+// The block is sealed by the in-turn signer if allowed, otherwise by the first
+// out-of-turn signer that hasn't signed recently.
+func (n *Network) Commit() (*types.Block, error) {
+	parent := n.Chain.CurrentHeader()
+	snap, err := n.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	number := parent.Number.Uint64() + 1
+
+	signer := snap.InturnSigner(number)
+	if n.keys[signer] == nil || !snap.CanSign(number, signer) {
+		signer = common.Address{}
+		for _, candidate := range snap.SignerList() {
+			if n.keys[candidate] != nil && snap.CanSign(number, candidate) {
+				signer = candidate
+				break
+			}
+		}
+		if signer == (common.Address{}) {
+			return nil, errNoSigner
+		}
+	}
+	key := n.keys[signer]
+	n.Engine.Authorize(signer, func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(data), key)
+	})
+	// Assemble the block and have the engine fill in the clique fields
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).SetUint64(number),
+		GasLimit:   parent.GasLimit,
+	}
+	if n.Genesis.Config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(n.Genesis.Config, parent)
+	}
+	if err := n.Engine.Prepare(n.Chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := n.Chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	block, err := n.Engine.FinalizeAndAssemble(n.Chain, header, statedb, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Seal the block directly, without the wait enforced by the engine
+	header = block.Header()
+	sig, err := crypto.Sign(clique.SealHash(header).Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	block = block.WithSeal(header)
+
+	if _, err := n.Chain.InsertChain(types.Blocks{block}); err != nil {
+		return nil, fmt.Errorf("failed to import block %d: %v", number, err)
+	}
+	return block, nil
+}
+
+// sortAddresses sorts the addresses in ascending byte order, as required by the
+// clique extra-data format.
+func sortAddresses(addrs []common.Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return string(addrs[i][:]) < string(addrs[j][:])
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package cliquesim
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+)
+
+func containsSigner(signers []common.Address, addr common.Address) bool {
+	for _, signer := range signers {
+		if signer == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// commitUntil mines blocks until the condition is met or the limit is reached.
+func commitUntil(t *testing.T, n *Network, limit int, cond func([]common.Address) bool) {
+	t.Helper()
+	for i := 0; i < limit; i++ {
+		if _, err := n.Commit(); err != nil {
+			t.Fatalf("failed to commit block: %v", err)
+		}
+		signers, err := n.Signers()
+		if err != nil {
+			t.Fatalf("failed to retrieve signers: %v", err)
+		}
+		if cond(signers) {
+			return
+		}
+	}
+	t.Fatalf("condition not met within %d blocks", limit)
+}
+
+func TestSignerRotation(t *testing.T) {
+	n, err := NewNetwork(3)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer n.Close()
+
+	// Vote in a new signer
+	fresh := n.NewKey()
+	n.Propose(fresh, true)
+	commitUntil(t, n, 10, func(signers []common.Address) bool {
+		return containsSigner(signers, fresh)
+	})
+	n.Discard(fresh)
+
+	// The new signer must take part in sealing
+	api := n.Engine.APIs(n.Chain)[0].Service.(*clique.API)
+	sealed := false
+	for i := 0; i < 8 && !sealed; i++ {
+		next, err := api.GetInturnSigner(nil)
+		if err != nil {
+			t.Fatalf("failed to retrieve in-turn signer: %v", err)
+		}
+		block, err := n.Commit()
+		if err != nil {
+			t.Fatalf("failed to commit block: %v", err)
+		}
+		// In-turn blocks must be sealed by the reported signer
+		author, _ := n.Engine.Author(block.Header())
+		if block.Difficulty().Uint64() == 2 && author != next {
+			t.Fatalf("block %d sealed by %x, in-turn signer %x", block.NumberU64(), author, next)
+		}
+		sealed = author == fresh
+	}
+	if !sealed {
+		t.Fatalf("new signer never sealed a block")
+	}
+	// Vote the new signer out again
+	n.Propose(fresh, false)
+	commitUntil(t, n, 10, func(signers []common.Address) bool {
+		return !containsSigner(signers, fresh)
+	})
+	if signers, _ := n.Signers(); len(signers) != 3 {
+		t.Fatalf("signer count mismatch: have %d, want 3", len(signers))
+	}
+}
@@ -319,6 +319,38 @@ func (s *Snapshot) signers() []common.Address {
 	return sigs
 }
 
+// SignerList retrieves the list of authorized signers in ascending order.
+func (s *Snapshot) SignerList() []common.Address {
+	return s.signers()
+}
+
+// InturnSigner returns the signer that is in-turn to seal the block with the
+// given number, following the snapshot.
+func (s *Snapshot) InturnSigner(number uint64) common.Address {
+	signers := s.signers()
+	if len(signers) == 0 {
+		return common.Address{}
+	}
+	return signers[number%uint64(len(signers))]
+}
+
+// CanSign returns whether the signer is authorized to seal the block with the
+// given number, following the snapshot. Signers that sealed one of the recent
+// blocks must wait for others before being allowed to sign again.
+func (s *Snapshot) CanSign(number uint64, signer common.Address) bool {
+	if _, ok := s.Signers[signer]; !ok {
+		return false
+	}
+	for seen, recent := range s.Recents {
+		if recent == signer {
+			if limit := uint64(len(s.Signers)/2 + 1); number < limit || seen > number-limit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // inturn returns if a signer at a given block height is in-turn or not.
 func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
 	signers, offset := s.signers(), 0
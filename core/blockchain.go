@@ -136,6 +136,11 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	StateDiffs          bool          // Whether to record per-transaction state diffs of processed blocks
+	TxSenderIndex       bool          // Whether to index transactions by sender and nonce along the tx lookups
+
+	Retention *state.RetentionPolicy // Historical state retained by a pruning node, nil if none
+	History   *HistoryRetention      // Block history retained by a node pruning it, nil if none
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
@@ -184,6 +189,9 @@ type BlockChain struct {
 	//  * N:   means N block limit [HEAD-N+1, HEAD] and delete extra indexes
 	//  * nil: disable tx reindexer/deleter, but still index new blocks
 	txLookupLimit uint64
+	txIndexTasks  chan func() // Tasks to run exclusively with the tx indexer, nil if it's disabled
+
+	history *HistoryRetention // Block history retained by the chain, nil if it isn't pruned
 
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
@@ -224,6 +232,11 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	forker     *ForkChoice
 	vmConfig   vm.Config
+
+	opCounting int32                                        // Whether to count the opcodes executed by processed blocks (atomic)
+	opCounters *lru.Cache[common.Hash, *vm.BlockOpCounters] // Opcode counters of recently processed blocks
+	opTotals   vm.OpCounters                                // Opcode counters accumulated since startup
+	opLock     sync.Mutex                                   // Lock protecting the opcode totals
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -270,6 +283,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		blockCache:    lru.NewCache[common.Hash, *types.Block](blockCacheLimit),
 		txLookupCache: lru.NewCache[common.Hash, *rawdb.LegacyTxLookupEntry](txLookupCacheLimit),
 		futureBlocks:  lru.NewCache[common.Hash, *types.Block](maxFutureBlocks),
+		opCounters:    lru.NewCache[common.Hash, *vm.BlockOpCounters](opCountersCacheLimit),
 		engine:        engine,
 		vmConfig:      vmConfig,
 	}
@@ -383,6 +397,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 			}
 		}
 	}
+	// Configure the historical state retained by a partial archive node
+	bc.setupRetention()
+
+	// Configure the block history retained by a node pruning it
+	bc.setupHistoryRetention()
 
 	// Load any existing snapshot, regenerating it if loading failed
 	if bc.cacheConfig.SnapshotLimit > 0 {
@@ -435,10 +454,16 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	// Start tx indexer/unindexer if required.
 	if txLookupLimit != nil {
 		bc.txLookupLimit = *txLookupLimit
+		bc.txIndexTasks = make(chan func())
 
 		bc.wg.Add(1)
 		go bc.maintainTxIndex()
 	}
+	// Start the history pruner if the block history is pruned.
+	if bc.history != nil {
+		bc.wg.Add(1)
+		go bc.maintainHistory()
+	}
 	return bc, nil
 }
 
@@ -738,6 +763,7 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 			rawdb.DeleteBody(db, hash, num)
 			rawdb.DeleteReceipts(db, hash, num)
 		}
+		rawdb.DeleteStateDiffs(db, hash, num)
 		// Todo(rjl493456442) txlookup, bloombits, etc
 	}
 	// If SetHead was only called as a chain reparation method, try to skip
@@ -892,7 +918,7 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	rawdb.WriteHeadHeaderHash(batch, block.Hash())
 	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
-	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	bc.writeTxLookups(batch, block)
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
 
 	// Flush the whole batch into the disk, exit the node if failed
@@ -1144,9 +1170,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		var batch = bc.db.NewBatch()
 		for i, block := range blockChain {
 			if bc.txLookupLimit == 0 || ancientLimit <= bc.txLookupLimit || block.NumberU64() >= ancientLimit-bc.txLookupLimit {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
+				bc.writeTxLookups(batch, block)
 			} else if rawdb.ReadTxIndexTail(bc.db) != nil {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
+				bc.writeTxLookups(batch, block)
 			}
 			stats.processed++
 
@@ -1229,7 +1255,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			// Write all the data out into the database
 			rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
 			rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receiptChain[i])
-			rawdb.WriteTxLookupEntriesByBlock(batch, block) // Always write tx indices for live blocks, we assume they are needed
+			bc.writeTxLookups(batch, block) // Always write tx indices for live blocks, we assume they are needed
 
 			// Write everything belongs to the blocks into the database. So that
 			// we can ensure all components of body is completed(body, receipts,
@@ -1351,6 +1377,15 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	rawdb.WriteBlock(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 	rawdb.WritePreimages(blockBatch, state.Preimages())
+	// Only persist diffs actually recorded, blocks processed elsewhere (e.g. by
+	// the miner) must read as not recorded instead of having no changes.
+	if state.StateDiffsEnabled() {
+		diffs, err := rlp.EncodeToBytes(state.StateDiffs())
+		if err != nil {
+			log.Crit("Failed to encode state diffs", "err", err)
+		}
+		rawdb.WriteStateDiffsRLP(blockBatch, block.Hash(), block.NumberU64(), diffs)
+	}
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
@@ -1363,6 +1398,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if bc.cacheConfig.TrieDirtyDisabled {
 		return bc.triedb.Commit(root, false)
 	}
+	// Flush the historical state a partial archive node retains
+	if err := bc.retainState(block.NumberU64(), root); err != nil {
+		return err
+	}
 	// Full but not archive node, do proper garbage collection
 	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 	bc.triegc.Push(root, -int64(block.NumberU64()))
@@ -1733,6 +1772,10 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		statedb.StartPrefetcher("chain")
 		activeState = statedb
 
+		if bc.cacheConfig.StateDiffs {
+			statedb.EnableStateDiffs()
+		}
+
 		// If we have a followup block, run that against the current state to pre-cache
 		// transactions and probabilistically some of the account/storage trie nodes.
 		var followupInterrupt uint32
@@ -1753,7 +1796,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 
 		// Process block using the parent state as reference point
 		pstart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		vmConfig := bc.vmConfig
+		if atomic.LoadInt32(&bc.opCounting) == 1 {
+			vmConfig.OpCounters = new(vm.BlockOpCounters)
+		}
+		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, vmConfig)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
@@ -1801,6 +1848,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		if err != nil {
 			return it.index, err
 		}
+		if vmConfig.OpCounters != nil {
+			bc.recordOpCounters(block.Hash(), vmConfig.OpCounters)
+		}
 		// Update the metrics touched during block commit
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
 		storageCommitTimer.Update(statedb.StorageCommits)   // Storage commits are complete, we can mark them
@@ -2348,10 +2398,25 @@ func (bc *BlockChain) skipBlock(err error, it *insertIterator) bool {
 	return false
 }
 
+// writeTxLookups writes the transaction lookups of a block, along with the
+// sender and nonce lookups if those are enabled.
+func (bc *BlockChain) writeTxLookups(db ethdb.KeyValueWriter, block *types.Block) {
+	rawdb.WriteTxLookupEntriesByBlock(db, block)
+	if bc.cacheConfig.TxSenderIndex {
+		rawdb.WriteSenderNonceLookupsByBlock(db, types.MakeSigner(bc.chainConfig, block.Number()), block)
+	}
+}
+
 // indexBlocks reindexes or unindexes transactions depending on user configuration
 func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{}) {
 	defer func() { close(done) }()
 
+	// Maintain the sender and nonce lookups along the tx lookups if enabled
+	var senders *params.ChainConfig
+	if bc.cacheConfig.TxSenderIndex {
+		senders = bc.chainConfig
+	}
+
 	// The tail flag is not existent, it means the node is just initialized
 	// and all blocks(may from ancient store) are not indexed yet.
 	if tail == nil {
@@ -2359,7 +2424,7 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 		if bc.txLookupLimit != 0 && head >= bc.txLookupLimit {
 			from = head - bc.txLookupLimit + 1
 		}
-		rawdb.IndexTransactions(bc.db, from, head+1, bc.quit)
+		rawdb.IndexTransactions(bc.db, from, head+1, senders, bc.quit)
 		return
 	}
 	// The tail flag is existent, but the whole chain is required to be indexed.
@@ -2372,17 +2437,17 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 			if end > head+1 {
 				end = head + 1
 			}
-			rawdb.IndexTransactions(bc.db, 0, end, bc.quit)
+			rawdb.IndexTransactions(bc.db, 0, end, senders, bc.quit)
 		}
 		return
 	}
 	// Update the transaction index to the new chain state
 	if head-bc.txLookupLimit+1 < *tail {
 		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, bc.quit)
+		rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, senders, bc.quit)
 	} else {
 		// Unindex a part of stale indices and forward index tail to HEAD-limit
-		rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, bc.quit)
+		rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, senders, bc.quit)
 	}
 }
 
@@ -2402,6 +2467,7 @@ func (bc *BlockChain) maintainTxIndex() {
 	// Listening to chain events and manipulate the transaction indexes.
 	var (
 		done   chan struct{}                  // Non-nil if background unindexing or reindexing routine is active.
+		tasks  []func()                       // External tasks waiting for the active routine to finish
 		headCh = make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
 	)
 	sub := bc.SubscribeChainHeadEvent(headCh)
@@ -2410,6 +2476,13 @@ func (bc *BlockChain) maintainTxIndex() {
 	}
 	defer sub.Unsubscribe()
 
+	runTask := func(task func()) {
+		done = make(chan struct{})
+		go func(done chan struct{}) {
+			defer close(done)
+			task()
+		}(done)
+	}
 	for {
 		select {
 		case head := <-headCh:
@@ -2417,8 +2490,18 @@ func (bc *BlockChain) maintainTxIndex() {
 				done = make(chan struct{})
 				go bc.indexBlocks(rawdb.ReadTxIndexTail(bc.db), head.Block.NumberU64(), done)
 			}
+		case task := <-bc.txIndexTasks:
+			if done == nil {
+				runTask(task)
+			} else {
+				tasks = append(tasks, task)
+			}
 		case <-done:
 			done = nil
+			if len(tasks) > 0 {
+				runTask(tasks[0])
+				tasks = tasks[1:]
+			}
 		case <-bc.quit:
 			if done != nil {
 				log.Info("Waiting background transaction indexer to exit")
@@ -2429,6 +2512,24 @@ func (bc *BlockChain) maintainTxIndex() {
 	}
 }
 
+// RunTxIndexTask executes a task modifying the transaction index in the
+// background, exclusively with the indexing and unindexing runs of the chain, so
+// that they don't race on the index tail. Tasks still pending at shutdown are
+// dropped. If the transaction indexer is disabled, the task is started right
+// away.
+func (bc *BlockChain) RunTxIndexTask(task func()) error {
+	if bc.txIndexTasks == nil {
+		go task()
+		return nil
+	}
+	select {
+	case bc.txIndexTasks <- task:
+		return nil
+	case <-bc.quit:
+		return errChainStopped
+	}
+}
+
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
@@ -0,0 +1,194 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// HistoryRetention selects the block bodies and receipts a node keeps, turning
+// it into a lightweight node serving only the history ranges being studied.
+// Headers are always kept in full.
+//
+//   - The history of the most recent Window blocks is kept, older history is
+//     pruned as the chain progresses. The window always covers the blocks whose
+//     state is held in memory, so that the chain can still be reorged.
+//   - The history of every block within one of the Ranges is kept regardless.
+//
+// Once the history was pruned, the policy is remembered by the database and
+// stays in effect, as the pruned history can't be restored.
+type HistoryRetention struct {
+	Window uint64             `json:"window"`
+	Ranges []state.BlockRange `json:"ranges"`
+}
+
+// window returns the number of recent blocks whose history is kept.
+func (p *HistoryRetention) window() uint64 {
+	if p.Window < TriesInMemory {
+		return TriesInMemory
+	}
+	return p.Window
+}
+
+// Start returns the first block within the window of retained history at the
+// given chain head.
+func (p *HistoryRetention) Start(head uint64) uint64 {
+	if window := p.window(); head >= window {
+		return head - window + 1
+	}
+	return 0
+}
+
+// Retains reports whether the history of the block is retained at the given
+// chain head.
+func (p *HistoryRetention) Retains(number uint64, head uint64) bool {
+	if p == nil || number == 0 || number >= p.Start(head) {
+		return true
+	}
+	for _, r := range p.Ranges {
+		if r.Contains(number) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadHistoryRetention retrieves the history retention policy of the database,
+// or nil if the history isn't pruned.
+func ReadHistoryRetention(db ethdb.KeyValueReader) *HistoryRetention {
+	data := rawdb.ReadHistoryRetention(db)
+	if len(data) == 0 {
+		return nil
+	}
+	policy := new(HistoryRetention)
+	if err := json.Unmarshal(data, policy); err != nil {
+		log.Error("Invalid history retention policy", "err", err)
+		return nil
+	}
+	return policy
+}
+
+// WriteHistoryRetention stores the history retention policy in the database.
+func WriteHistoryRetention(db ethdb.KeyValueWriter, policy *HistoryRetention) {
+	sort.Slice(policy.Ranges, func(i, j int) bool { return policy.Ranges[i].First < policy.Ranges[j].First })
+	data, err := json.Marshal(policy)
+	if err != nil {
+		log.Crit("Failed to encode history retention policy", "err", err)
+	}
+	rawdb.WriteHistoryRetention(db, data)
+}
+
+// setupHistoryRetention stores the configured history retention policy in the
+// database, where the freezer picks it up, or loads the one history was pruned
+// with before.
+func (bc *BlockChain) setupHistoryRetention() {
+	policy := bc.cacheConfig.History
+	if policy == nil {
+		if policy = ReadHistoryRetention(bc.db); policy == nil {
+			return
+		}
+		log.Warn("Block history was pruned before, keeping it pruned", "window", policy.window(), "ranges", len(policy.Ranges))
+	} else {
+		WriteHistoryRetention(bc.db, policy)
+		log.Info("Enabled block history pruning", "window", policy.window(), "ranges", len(policy.Ranges))
+	}
+	bc.history = policy
+}
+
+// HistoryRetention returns the block history retained by the chain, or nil if
+// the history isn't pruned.
+func (bc *BlockChain) HistoryRetention() *HistoryRetention {
+	return bc.history
+}
+
+// maintainHistory prunes the bodies and receipts of the blocks falling out of
+// the retained history as the chain progresses. Pruning runs exclusively with
+// the transaction indexer, as it removes the indices of the pruned blocks.
+func (bc *BlockChain) maintainHistory() {
+	defer bc.wg.Done()
+
+	var (
+		done   chan struct{}                  // Non-nil if background pruning is active
+		headCh = make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+	)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	prune := func(head uint64) {
+		done = make(chan struct{})
+		task := func(done chan struct{}) func() {
+			return func() {
+				defer close(done)
+				bc.pruneHistory(head)
+			}
+		}(done)
+		if bc.txIndexTasks == nil {
+			task()
+		} else if err := bc.RunTxIndexTask(task); err != nil {
+			done = nil
+		}
+	}
+	// Catch up with the current head, the chain might have been imported before
+	// the history got pruned.
+	prune(bc.CurrentBlock().Number.Uint64())
+
+	for {
+		select {
+		case head := <-headCh:
+			if done == nil {
+				prune(head.Block.NumberU64())
+			}
+		case <-done:
+			done = nil
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// pruneHistory prunes the history of the blocks which fell out of the window
+// retained at the given chain head.
+func (bc *BlockChain) pruneHistory(head uint64) {
+	policy := bc.history
+
+	from := uint64(0)
+	if tail := rawdb.ReadHistoryPruneTail(bc.db); tail != nil {
+		from = *tail
+	}
+	to := policy.Start(head)
+	if from >= to {
+		return
+	}
+	// Maintain the sender and nonce lookups along the tx lookups if enabled
+	var senders *params.ChainConfig
+	if bc.cacheConfig.TxSenderIndex {
+		senders = bc.chainConfig
+	}
+	rawdb.PruneHistory(bc.db, from, to, senders, func(number uint64) bool {
+		return policy.Retains(number, head)
+	}, bc.quit)
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// opCountersCacheLimit is the number of recent blocks whose opcode counters are
+// kept in memory.
+const opCountersCacheLimit = 256
+
+// SetOpCounting enables or disables counting the opcodes executed by processed
+// blocks. Counting adds timing overhead to every executed opcode.
+func (bc *BlockChain) SetOpCounting(enabled bool) {
+	var flag int32
+	if enabled {
+		flag = 1
+	}
+	atomic.StoreInt32(&bc.opCounting, flag)
+}
+
+// OpCounting reports whether opcode counting is enabled.
+func (bc *BlockChain) OpCounting() bool {
+	return atomic.LoadInt32(&bc.opCounting) == 1
+}
+
+// GetOpCounters returns the opcode counters of a recently processed block, or
+// nil if the block was not processed with counting enabled.
+func (bc *BlockChain) GetOpCounters(hash common.Hash) *vm.BlockOpCounters {
+	counters, _ := bc.opCounters.Get(hash)
+	return counters
+}
+
+// OpTotals returns the opcode counters accumulated over all blocks processed
+// with counting enabled since startup.
+func (bc *BlockChain) OpTotals() vm.OpCounters {
+	bc.opLock.Lock()
+	defer bc.opLock.Unlock()
+
+	return bc.opTotals
+}
+
+// recordOpCounters stores the opcode counters of a processed block and updates
+// the totals and metrics.
+func (bc *BlockChain) recordOpCounters(hash common.Hash, counters *vm.BlockOpCounters) {
+	bc.opCounters.Add(hash, counters)
+
+	bc.opLock.Lock()
+	bc.opTotals.Merge(&counters.Block)
+	bc.opLock.Unlock()
+
+	if !metrics.Enabled {
+		return
+	}
+	for op, count := range counters.Block.Counts {
+		if count == 0 {
+			continue
+		}
+		name := vm.OpCode(op).String()
+		metrics.GetOrRegisterMeter("chain/opcodes/"+name+"/count", nil).Mark(int64(count))
+		metrics.GetOrRegisterMeter("chain/opcodes/"+name+"/gas", nil).Mark(int64(counters.Block.Gas[op]))
+		metrics.GetOrRegisterMeter("chain/opcodes/"+name+"/time", nil).Mark(int64(counters.Block.Times[op]))
+	}
+}
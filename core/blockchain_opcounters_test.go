@@ -0,0 +1,87 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the opcodes executed by processed blocks are counted if enabled.
+func TestOpCounting(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				address: {Balance: big.NewInt(1000000000000000)},
+				// CALLVALUE NUMBER SSTORE
+				contract: {Code: common.FromHex("0x344355"), Balance: new(big.Int)},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, block *BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(address), contract, big.NewInt(1), 100000, block.header.BaseFee, nil), signer, key)
+			block.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Only the second block is processed with counting enabled
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	chain.SetOpCounting(true)
+	if _, err := chain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if counters := chain.GetOpCounters(blocks[0].Hash()); counters != nil {
+		t.Errorf("opcodes counted with counting disabled")
+	}
+	counters := chain.GetOpCounters(blocks[1].Hash())
+	if counters == nil {
+		t.Fatal("opcodes not counted")
+	}
+	if len(counters.Txs) != 2 {
+		t.Fatalf("have %d transaction counters, want 2", len(counters.Txs))
+	}
+	for i, tx := range blocks[1].Transactions() {
+		if counters.Txs[i].Hash != tx.Hash() || counters.Txs[i].Counts[vm.SSTORE] != 1 {
+			t.Errorf("transaction %d: counters mismatch: %+v", i, counters.Txs[i])
+		}
+	}
+	if totals := chain.OpTotals(); totals.Counts[vm.SSTORE] != 2 || totals.Counts[vm.CALLVALUE] != 2 {
+		t.Errorf("totals mismatch: SSTORE %d, CALLVALUE %d", totals.Counts[vm.SSTORE], totals.Counts[vm.CALLVALUE])
+	}
+}
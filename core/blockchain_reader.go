@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -225,6 +226,25 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// GetStateDiffs retrieves the per-transaction state diffs recorded while
+// processing the given block. Nil is returned if the diffs were not recorded.
+func (bc *BlockChain) GetStateDiffs(hash common.Hash) []*state.StateDiff {
+	number := rawdb.ReadHeaderNumber(bc.db, hash)
+	if number == nil {
+		return nil
+	}
+	data := rawdb.ReadStateDiffsRLP(bc.db, hash, *number)
+	if len(data) == 0 {
+		return nil
+	}
+	var diffs []*state.StateDiff
+	if err := rlp.DecodeBytes(data, &diffs); err != nil {
+		log.Error("Invalid state diffs RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return diffs
+}
+
 // GetUnclesInChain retrieves all the uncles from a given block backwards until
 // a specific distance is reached.
 func (bc *BlockChain) GetUnclesInChain(block *types.Block, length int) []*types.Header {
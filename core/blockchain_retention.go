@@ -0,0 +1,139 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// setupRetention validates the configured state retention policy and stores it
+// in the database, where the offline pruner picks it up.
+func (bc *BlockChain) setupRetention() {
+	policy := bc.cacheConfig.Retention
+	if policy == nil {
+		return
+	}
+	if bc.cacheConfig.TrieDirtyDisabled {
+		log.Warn("Ignoring state retention policy of archive node")
+		bc.cacheConfig.Retention = nil
+		return
+	}
+	// Accounts are retained from the next block on, unless they were already
+	// retained with the same policy before.
+	policy.Since = bc.CurrentBlock().Number.Uint64() + 1
+	if stored := state.ReadRetentionPolicy(bc.db); stored != nil {
+		if stored.SameAddresses(policy) {
+			policy.Since = stored.Since
+		} else if len(stored.Addresses) > 0 {
+			log.Warn("Retained accounts changed, previously retained state will be pruned", "since", policy.Since)
+		}
+	}
+	state.WriteRetentionPolicy(bc.db, policy)
+	log.Info("Enabled state retention", "ranges", len(policy.Ranges), "accounts", len(policy.Addresses), "since", policy.Since)
+}
+
+// RetentionPolicy returns the historical state retained by the chain, or nil if
+// no retention is configured.
+func (bc *BlockChain) RetentionPolicy() *state.RetentionPolicy {
+	return bc.cacheConfig.Retention
+}
+
+// retainState flushes the parts of a freshly committed state selected by the
+// retention policy to disk, where they survive garbage collection.
+func (bc *BlockChain) retainState(number uint64, root common.Hash) error {
+	policy := bc.cacheConfig.Retention
+	if policy == nil {
+		return nil
+	}
+	if policy.RetainsBlock(number) {
+		return bc.triedb.Commit(root, false)
+	}
+	if len(policy.Addresses) == 0 || number < policy.Since {
+		return nil
+	}
+	tr, err := bc.stateCache.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	// Persist the account trie paths, leaving out the rest of the trie, and the
+	// complete storage tries of the retained accounts.
+	batch := bc.db.NewBatch()
+	for _, addr := range policy.Addresses {
+		if err := tr.Prove(crypto.Keccak256(addr.Bytes()), 0, batch); err != nil {
+			return err
+		}
+		acc, err := tr.TryGetAccount(addr)
+		if err != nil {
+			return err
+		}
+		if acc != nil && acc.Root != types.EmptyRootHash {
+			if err := bc.triedb.Commit(acc.Root, false); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Write()
+}
+
+// StateRetained reports whether the state of the block is available. If addr
+// is given, only the state of that account needs to be available, as is the
+// case for the accounts retained by the policy.
+func (bc *BlockChain) StateRetained(header *types.Header, addr *common.Address) (bool, error) {
+	if addr == nil {
+		number := header.Number.Uint64()
+		switch {
+		case !bc.HasState(header.Root):
+			return false, nil
+		case bc.cacheConfig.TrieDirtyDisabled, number+TriesInMemory > bc.CurrentBlock().Number.Uint64():
+			return true, nil
+		}
+		policy := bc.cacheConfig.Retention
+		if policy.RetainsBlock(number) {
+			return true, nil
+		}
+		// The roots of states with retained accounts are present even though the
+		// rest of the state is not, which can't be told apart cheaply.
+		return policy == nil || len(policy.Addresses) == 0 || number < policy.Since, nil
+	}
+	tr, err := bc.stateCache.OpenTrie(header.Root)
+	if err != nil {
+		if errors.As(err, new(*trie.MissingNodeError)) {
+			return false, nil
+		}
+		return false, err
+	}
+	acc, err := tr.TryGetAccount(*addr)
+	if err != nil {
+		if errors.As(err, new(*trie.MissingNodeError)) {
+			return false, nil
+		}
+		return false, err
+	}
+	if acc == nil || acc.Root == types.EmptyRootHash {
+		return true, nil
+	}
+	// Storage tries are only ever flushed in full, their root being present is
+	// enough.
+	return bc.HasState(acc.Root), nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a pruning node retains the full state of the configured block
+// ranges and the state of the configured accounts at all blocks.
+func TestStateRetention(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				address: {Balance: big.NewInt(1000000000000000)},
+				// CALLVALUE NUMBER SSTORE: stores the value sent at the block number
+				contract: {Code: common.FromHex("0x344355"), Balance: new(big.Int)},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		blocks = TriesInMemory + 20
+	)
+	_, chain, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), blocks, func(i int, block *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		block.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(block.TxNonce(address), contract, big.NewInt(1), 100000, block.header.BaseFee, nil), signer, key)
+		block.AddTx(tx)
+	})
+	config := *defaultCacheConfig
+	config.SnapshotLimit = 0
+	config.Retention = &state.RetentionPolicy{
+		Ranges:    []state.BlockRange{{First: 3, Last: 4}},
+		Addresses: []common.Address{contract},
+	}
+	db := rawdb.NewMemoryDatabase()
+	bc, err := NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer bc.Stop()
+
+	if n, err := bc.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if stored := state.ReadRetentionPolicy(db); stored == nil || stored.Since != 1 {
+		t.Fatalf("retention policy not stored: %+v", stored)
+	}
+	tests := []struct {
+		number   uint64
+		full     bool
+		transfer bool // whether the transfer recipient of the block is available
+	}{
+		{number: 3, full: true, transfer: true},
+		{number: 4, full: true, transfer: true},
+		{number: 10, full: false, transfer: false},
+		{number: uint64(blocks) - 5, full: true, transfer: true},
+	}
+	for _, tt := range tests {
+		header := bc.GetHeaderByNumber(tt.number)
+		if full, err := bc.StateRetained(header, nil); err != nil || full != tt.full {
+			t.Errorf("block %d: have full state %v (%v), want %v", tt.number, full, err, tt.full)
+		}
+		if have, err := bc.StateRetained(header, &contract); err != nil || !have {
+			t.Errorf("block %d: have contract state %v (%v), want true", tt.number, have, err)
+		}
+		if tt.number+TriesInMemory > uint64(blocks) {
+			continue // recent state is not yet flushed to disk
+		}
+		// Only the retained state must be readable from disk, the rest must have
+		// been garbage collected.
+		statedb, err := state.New(header.Root, state.NewDatabase(db), nil)
+		if err != nil {
+			t.Fatalf("block %d: failed to open state: %v", tt.number, err)
+		}
+		recipient := common.Address{byte(tt.number)}
+		statedb.GetBalance(recipient)
+		if retained := statedb.Error() == nil; retained != tt.transfer {
+			t.Errorf("block %d: have recipient state %v (%v), want %v", tt.number, retained, statedb.Error(), tt.transfer)
+		}
+		statedb, _ = state.New(header.Root, state.NewDatabase(db), nil)
+		slot := common.BigToHash(new(big.Int).SetUint64(tt.number))
+		if value := statedb.GetState(contract, slot); value != common.BigToHash(big.NewInt(1)) {
+			t.Errorf("block %d: have contract slot %x, want 1", tt.number, value)
+		}
+		if err := statedb.Error(); err != nil {
+			t.Errorf("block %d: failed to read contract state: %v", tt.number, err)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -4340,3 +4341,163 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that state diffs are only persisted for blocks processed with diff
+// recording, blocks written with an unrecorded state (e.g. mined ones) must not
+// be reported as having no changes.
+func TestStateDiffsRecording(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	cacheConfig := *defaultCacheConfig
+	cacheConfig.StateDiffs = true
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Imported blocks have their diffs recorded
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if diffs := chain.GetStateDiffs(blocks[0].Hash()); len(diffs) == 0 {
+		t.Fatalf("imported block has no diffs recorded")
+	}
+	// Blocks written with a state not recording diffs have none stored
+	statedb, err := chain.StateAt(blocks[0].Root())
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	receipts, logs, _, err := chain.Processor().Process(blocks[1], statedb, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	if _, err := chain.WriteBlockAndSetHead(blocks[1], receipts, logs, statedb, false); err != nil {
+		t.Fatalf("failed to write block: %v", err)
+	}
+	if diffs := chain.GetStateDiffs(blocks[1].Hash()); diffs != nil {
+		t.Fatalf("unrecorded block has diffs: %v", diffs)
+	}
+}
+
+// Tests that tasks handed to the transaction indexer are executed, both with the
+// indexer running and disabled.
+func TestRunTxIndexTask(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+
+	for _, limit := range []*uint64{nil, new(uint64)} {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, limit)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		done := make(chan struct{})
+		if err := chain.RunTxIndexTask(func() { close(done) }); err != nil {
+			t.Fatalf("failed to schedule task: %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("task not executed (limit %v)", limit)
+		}
+		chain.Stop()
+
+		if limit != nil {
+			if err := chain.RunTxIndexTask(func() {}); err != errChainStopped {
+				t.Errorf("error mismatch after stop: have %v, want %v", err, errChainStopped)
+			}
+		}
+	}
+}
+
+// Tests that the block history falling out of the retention window is pruned as
+// the chain progresses, keeping the retained ranges.
+func TestHistoryPruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(100000000000000000)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: funds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 160, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	policy := &HistoryRetention{Ranges: []state.BlockRange{{First: 10, Last: 12}}}
+
+	for _, limit := range []*uint64{nil, new(uint64)} {
+		db := rawdb.NewMemoryDatabase()
+		config := *defaultCacheConfig
+		config.History = policy
+
+		chain, err := NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, limit)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+		}
+		start := policy.Start(uint64(len(blocks)))
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			if tail := rawdb.ReadHistoryPruneTail(db); tail != nil && *tail == start {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("history not pruned up to block %d (limit %v)", start, limit)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, block := range blocks {
+			hash, number := block.Hash(), block.NumberU64()
+			retained := number >= start || (number >= 10 && number <= 12)
+			if have := rawdb.HasBody(db, hash, number); have != retained {
+				t.Errorf("block %d: body presence mismatch: have %v, want %v", number, have, retained)
+			}
+			if have := rawdb.HasReceipts(db, hash, number); have != retained {
+				t.Errorf("block %d: receipts presence mismatch: have %v, want %v", number, have, retained)
+			}
+			if have := chain.HasHeader(hash, number); !have {
+				t.Errorf("block %d: header missing", number)
+			}
+			for _, tx := range block.Transactions() {
+				if have := rawdb.ReadTxLookupEntry(db, tx.Hash()) != nil; have != retained {
+					t.Errorf("block %d: tx index presence mismatch: have %v, want %v", number, have, retained)
+				}
+			}
+		}
+		chain.Stop()
+
+		// Ensure the pruned history stays pruned without configuring it again
+		chain, err = NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, limit)
+		if err != nil {
+			t.Fatalf("failed to recreate tester chain: %v", err)
+		}
+		if have := chain.HistoryRetention(); !reflect.DeepEqual(have, policy) {
+			t.Errorf("history retention mismatch: have %+v, want %+v", have, policy)
+		}
+		chain.Stop()
+	}
+}
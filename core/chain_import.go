@@ -0,0 +1,221 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ImportValidation selects how thoroughly imported blocks are validated.
+type ImportValidation int
+
+const (
+	// ImportValidateFull fully validates and executes the imported blocks,
+	// advancing the head block and state.
+	ImportValidateFull ImportValidation = iota
+
+	// ImportValidateHeaders validates the headers including their seals and
+	// checks the bodies against them, but does not execute the blocks. Only
+	// the head header is advanced, the head block and state stay in place.
+	ImportValidateHeaders
+
+	// ImportValidateNone skips seal verification and body checks. The header
+	// chain still enforces that headers link up and obey the basic consensus
+	// rules, but no proof-of-work or signatures are verified. Only the head
+	// header is advanced.
+	ImportValidateNone
+)
+
+// defaultImportBatch is the number of blocks imported at once if no batch size
+// is configured.
+const defaultImportBatch = 2500
+
+// ImportOptions configures a chain import.
+type ImportOptions struct {
+	Validation ImportValidation
+	BatchSize  int // Number of blocks imported at once, 2500 if zero
+
+	// RecoverSenders recovers the senders of all imported transactions in
+	// parallel, caching them in the transactions of the batches passed to
+	// Progress. Full validation always recovers senders.
+	RecoverSenders bool
+
+	// Progress is invoked after each imported batch. Returning an error aborts
+	// the import with that error.
+	Progress func(ImportProgress) error
+}
+
+// ImportProgress reports the progress of a chain import.
+type ImportProgress struct {
+	Batch    []*types.Block // Blocks of the batch just imported
+	Blocks   uint64         // Number of blocks imported so far
+	Txs      uint64         // Number of transactions imported so far
+	Skipped  uint64         // Number of blocks skipped as already present
+	Elapsed  time.Duration  // Time since the start of the import
+	Complete bool           // Whether the stream was fully imported
+}
+
+// ImportChain imports the RLP encoded blocks of the stream, e.g. as exported by
+// geth export, into the chain. The genesis block and blocks already present in
+// the chain are skipped.
+func (bc *BlockChain) ImportChain(r io.Reader, opts ImportOptions) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatch
+	}
+	var (
+		stream   = rlp.NewStream(r, 0)
+		start    = time.Now()
+		progress ImportProgress
+		decoded  uint64
+	)
+	for !progress.Complete {
+		// Load a batch of RLP blocks
+		batch := make([]*types.Block, 0, batchSize)
+		for len(batch) < batchSize {
+			block := new(types.Block)
+			if err := stream.Decode(block); err == io.EOF {
+				progress.Complete = true
+				break
+			} else if err != nil {
+				return fmt.Errorf("at block %d: %v", decoded, err)
+			}
+			decoded++
+			if block.NumberU64() == 0 {
+				continue // don't import the genesis block
+			}
+			batch = append(batch, block)
+		}
+		if len(batch) == 0 && !progress.Complete {
+			continue
+		}
+		// Import the blocks missing from the chain
+		missing := bc.missingBlocks(batch, opts.Validation)
+		progress.Skipped += uint64(len(batch) - len(missing))
+
+		if len(missing) > 0 {
+			if opts.RecoverSenders && opts.Validation != ImportValidateFull {
+				SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, missing[0].Number()), missing)
+			}
+			if err := bc.importBatch(missing, opts.Validation); err != nil {
+				return err
+			}
+		}
+		progress.Batch = missing
+		progress.Blocks += uint64(len(missing))
+		for _, block := range missing {
+			progress.Txs += uint64(len(block.Transactions()))
+		}
+		progress.Elapsed = time.Since(start)
+		if opts.Progress != nil {
+			if err := opts.Progress(progress); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// missingBlocks returns the blocks of the batch from the first one not yet
+// present in the chain on.
+func (bc *BlockChain) missingBlocks(blocks []*types.Block, validation ImportValidation) []*types.Block {
+	head := bc.CurrentBlock()
+	for i, block := range blocks {
+		// Blocks imported without execution have no state
+		if validation != ImportValidateFull {
+			if !bc.HasBlock(block.Hash(), block.NumberU64()) {
+				return blocks[i:]
+			}
+			continue
+		}
+		// If we're behind the chain head, only check block, state is available at head
+		if head.Number.Uint64() > block.NumberU64() {
+			if !bc.HasBlock(block.Hash(), block.NumberU64()) {
+				return blocks[i:]
+			}
+			continue
+		}
+		// If we're above the chain head, state availability is a must
+		if !bc.HasBlockAndState(block.Hash(), block.NumberU64()) {
+			return blocks[i:]
+		}
+	}
+	return nil
+}
+
+// importBatch imports a batch of consecutive blocks with the given validation.
+func (bc *BlockChain) importBatch(blocks []*types.Block, validation ImportValidation) error {
+	if validation == ImportValidateFull {
+		if n, err := bc.InsertChain(blocks); err != nil {
+			return fmt.Errorf("invalid block %d: %v", blocks[n].NumberU64(), err)
+		}
+		return nil
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	checkFreq := 1
+	if validation == ImportValidateNone {
+		checkFreq = 0
+	} else {
+		for _, block := range blocks {
+			if err := checkBodyRoots(block); err != nil {
+				return fmt.Errorf("invalid block %d: %v", block.NumberU64(), err)
+			}
+		}
+	}
+	if n, err := bc.InsertHeaderChain(headers, checkFreq); err != nil {
+		return fmt.Errorf("invalid header %d: %v", headers[n].Number, err)
+	}
+	batch := bc.db.NewBatch()
+	for _, block := range blocks {
+		rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
+	}
+	return batch.Write()
+}
+
+// checkBodyRoots checks that the body of the block matches the roots committed
+// to in its header.
+func checkBodyRoots(block *types.Block) error {
+	header := block.Header()
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root hash mismatch (header value %x, calculated %x)", header.UncleHash, hash)
+	}
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root hash mismatch (header value %x, calculated %x)", header.TxHash, hash)
+	}
+	if header.WithdrawalsHash != nil {
+		if block.Withdrawals() == nil {
+			return errors.New("missing withdrawals in block body")
+		}
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("withdrawals root hash mismatch (header value %x, calculated %x)", *header.WithdrawalsHash, hash)
+		}
+	} else if block.Withdrawals() != nil {
+		return errors.New("withdrawals present in block body")
+	}
+	return nil
+}
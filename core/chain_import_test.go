@@ -0,0 +1,142 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that exported chain segments can be imported with all validation levels.
+func TestImportChain(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, block *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		block.AddTx(tx)
+	})
+	export := new(bytes.Buffer)
+	rlp.Encode(export, gspec.ToBlock())
+	for _, block := range blocks {
+		rlp.Encode(export, block)
+	}
+	for _, validation := range []ImportValidation{ImportValidateFull, ImportValidateHeaders, ImportValidateNone} {
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		var reports []ImportProgress
+		opts := ImportOptions{
+			Validation:     validation,
+			BatchSize:      4,
+			RecoverSenders: true,
+			Progress: func(p ImportProgress) error {
+				for _, block := range p.Batch {
+					for _, tx := range block.Transactions() {
+						if from, _ := types.Sender(signer, tx); from != address {
+							t.Errorf("validation %d: sender mismatch: have %x, want %x", validation, from, address)
+						}
+					}
+				}
+				reports = append(reports, p)
+				return nil
+			},
+		}
+		if err := chain.ImportChain(bytes.NewReader(export.Bytes()), opts); err != nil {
+			t.Fatalf("validation %d: import failed: %v", validation, err)
+		}
+		last := reports[len(reports)-1]
+		if len(reports) != 3 || !last.Complete || last.Blocks != 10 || last.Txs != 10 {
+			t.Errorf("validation %d: progress mismatch: %d reports, last %+v", validation, len(reports), last)
+		}
+		if head := chain.CurrentHeader().Hash(); head != blocks[9].Hash() {
+			t.Errorf("validation %d: head header mismatch: have %x, want %x", validation, head, blocks[9].Hash())
+		}
+		if block := chain.GetBlockByNumber(5); block == nil || block.Hash() != blocks[4].Hash() {
+			t.Errorf("validation %d: block 5 not imported", validation)
+		}
+		wantHead := blocks[9].Hash()
+		if validation != ImportValidateFull {
+			wantHead = chain.Genesis().Hash()
+		}
+		if head := chain.CurrentBlock().Hash(); head != wantHead {
+			t.Errorf("validation %d: head block mismatch: have %x, want %x", validation, head, wantHead)
+		}
+		// Importing again skips all blocks
+		reports = nil
+		if err := chain.ImportChain(bytes.NewReader(export.Bytes()), opts); err != nil {
+			t.Fatalf("validation %d: reimport failed: %v", validation, err)
+		}
+		if last := reports[len(reports)-1]; last.Blocks != 0 || last.Skipped != 10 {
+			t.Errorf("validation %d: reimport progress mismatch: %+v", validation, last)
+		}
+		chain.Stop()
+	}
+}
+
+// Tests that bodies not matching their headers are rejected unless validation
+// is disabled, and that the progress callback can abort the import.
+func TestImportChainInvalid(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, nil)
+
+	tx := types.NewTransaction(0, common.Address{}, new(big.Int), 0, new(big.Int), nil)
+	export := new(bytes.Buffer)
+	for i, block := range blocks {
+		if i == 1 {
+			block = types.NewBlockWithHeader(block.Header()).WithBody([]*types.Transaction{tx}, nil)
+		}
+		rlp.Encode(export, block)
+	}
+	chain, _ := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if err := chain.ImportChain(bytes.NewReader(export.Bytes()), ImportOptions{Validation: ImportValidateHeaders}); err == nil {
+		t.Fatal("invalid body imported")
+	}
+	errAbort := errors.New("abort")
+	opts := ImportOptions{
+		Validation: ImportValidateNone,
+		BatchSize:  1,
+		Progress:   func(ImportProgress) error { return errAbort },
+	}
+	if err := chain.ImportChain(bytes.NewReader(export.Bytes()), opts); err != errAbort {
+		t.Fatalf("error mismatch: have %v, want %v", err, errAbort)
+	}
+	if head := chain.CurrentHeader().Number.Uint64(); head != 1 {
+		t.Fatalf("head mismatch after abort: have %d, want 1", head)
+	}
+}
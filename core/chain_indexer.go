@@ -350,7 +350,10 @@ func (c *ChainIndexer) updateLoop() {
 				// If processing succeeded and no reorgs occurred, mark the section completed
 				if err == nil && (section == 0 || oldHead == c.SectionHead(section-1)) {
 					c.setSectionHead(section, newHead)
-					c.setValidSections(section + 1)
+					if section >= c.storedSections {
+						// Sections may have been added meanwhile, don't roll them back
+						c.setValidSections(section + 1)
+					}
 					if c.storedSections == c.knownSections && updating {
 						updating = false
 						c.log.Info("Finished upgrading chain index")
@@ -439,6 +442,30 @@ func (c *ChainIndexer) Sections() (uint64, uint64, common.Hash) {
 	return c.storedSections, c.storedSections*c.sectionSize - 1, c.SectionHead(c.storedSections - 1)
 }
 
+// AddSection marks a section as processed after its index data was regenerated
+// outside of the indexer, e.g. by a manual rebuild. Sections extending the
+// processed ones are added to them and cascaded to the child indexers, while
+// sections beyond the next unprocessed one are ignored, as the index would not
+// be contiguous.
+func (c *ChainIndexer) AddSection(section uint64, head common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if section > c.storedSections {
+		return
+	}
+	c.setSectionHead(section, head)
+	if section < c.storedSections {
+		return
+	}
+	c.setValidSections(section + 1)
+	c.cascadedHead = c.storedSections*c.sectionSize - 1
+	for _, child := range c.children {
+		c.log.Trace("Cascading chain index update", "head", c.cascadedHead)
+		child.newHead(c.cascadedHead, false)
+	}
+}
+
 // AddChildIndexer adds a child ChainIndexer that can use the output of this one
 func (c *ChainIndexer) AddChildIndexer(indexer *ChainIndexer) {
 	if indexer == c {
@@ -134,6 +134,38 @@ func testChainIndexer(t *testing.T, count int) {
 	}
 }
 
+// Tests that externally regenerated sections are only added if they extend the
+// processed sections of the indexer.
+func TestChainIndexerAddSection(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	defer db.Close()
+
+	const sectionSize = 4
+	backend := &testChainIndexBackend{t: t, processCh: make(chan uint64)}
+	indexer := NewChainIndexer(db, rawdb.NewTable(db, "i"), backend, sectionSize, 0, 0, "indexer")
+	defer indexer.Close()
+
+	heads := make([]common.Hash, 3)
+	for i := range heads {
+		heads[i] = common.Hash{byte(i + 1)}
+		rawdb.WriteCanonicalHash(db, heads[i], uint64(i+1)*sectionSize-1)
+	}
+	check := func(want uint64) {
+		t.Helper()
+		if sections, _, head := indexer.Sections(); sections != want || (want > 0 && head != heads[want-1]) {
+			t.Fatalf("section mismatch: have %d (head %x), want %d", sections, head, want)
+		}
+	}
+	indexer.AddSection(1, heads[1])
+	check(0)
+	indexer.AddSection(0, heads[0])
+	check(1)
+	indexer.AddSection(1, heads[1])
+	check(2)
+	indexer.AddSection(0, heads[0])
+	check(2)
+}
+
 // testChainIndexBackend implements ChainIndexerBackend
 type testChainIndexBackend struct {
 	t                          *testing.T
@@ -125,9 +125,20 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 // the protocol-imposed limitations (gas limit, etc.), there are some
 // further limitations on the content of transactions that can be
 // added. If contract code relies on the BLOCKHASH instruction,
-// the block in chain will be returned.
+// the block in chain will be returned. Any extra precompiles configured
+// in the chain's VM config are enabled too.
 func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
-	b.addTx(bc, vm.Config{}, tx)
+	var vmConfig vm.Config
+	if bc != nil {
+		vmConfig.Precompiles = bc.GetVMConfig().Precompiles
+	}
+	b.addTx(bc, vmConfig, tx)
+}
+
+// SetWatchpoints makes the state of the generated block report the accesses to
+// the slots of the given watchpoints, see state.StateDB.SetWatchpoints.
+func (b *BlockGen) SetWatchpoints(w *state.Watchpoints) {
+	b.statedb.SetWatchpoints(w)
 }
 
 // AddTxWithVMConfig adds a transaction to the generated block. If no coinbase has
@@ -67,6 +67,10 @@ var (
 	// than init code size limit.
 	ErrMaxInitCodeSizeExceeded = errors.New("max initcode size exceeded")
 
+	// ErrTxGasLimitExceeded is returned if a transaction requests more gas than
+	// allowed by the active custom upgrades.
+	ErrTxGasLimitExceeded = errors.New("transaction gas limit exceeded")
+
 	// ErrInsufficientFunds is returned if the total cost of executing a transaction
 	// is higher than the balance of the user's account.
 	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
@@ -20,6 +20,7 @@ package forkid
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash/crc32"
 	"math"
 	"math/big"
@@ -69,6 +70,11 @@ type ID struct {
 	Next uint64  // Block number of the next upcoming fork, or 0 if no forks are known
 }
 
+// String implements fmt.Stringer.
+func (id ID) String() string {
+	return fmt.Sprintf("%x/%d", id.Hash, id.Next)
+}
+
 // Filter is a fork id filter to validate a remotely advertised ID.
 type Filter func(id ID) error
 
@@ -129,6 +135,14 @@ func NewStaticFilter(config *params.ChainConfig, genesis common.Hash) Filter {
 	return newFilter(config, genesis, head)
 }
 
+// NewFilterAt creates a filter validating remote fork IDs against the local
+// chain as of the given head block number and timestamp. It allows checking the
+// compatibility of chains without a local chain instance, e.g. when talking to
+// remote RPC providers.
+func NewFilterAt(config *params.ChainConfig, genesis common.Hash, head, time uint64) Filter {
+	return newFilter(config, genesis, func() (uint64, uint64) { return head, time })
+}
+
 // newFilter is the internal version of NewFilter, taking closures as its arguments
 // instead of a chain. The reason is to allow testing it without having to simulate
 // an entire blockchain.
@@ -270,6 +284,15 @@ func gatherForks(config *params.ChainConfig) ([]uint64, []uint64) {
 			}
 		}
 	}
+	// Custom upgrades split the network just as hard forks do
+	for _, u := range config.Upgrades {
+		if u.Block != nil {
+			forksByBlock = append(forksByBlock, u.Block.Uint64())
+		}
+		if u.Time != nil {
+			forksByTime = append(forksByTime, *u.Time)
+		}
+	}
 	sort.Slice(forksByBlock, func(i, j int) bool { return forksByBlock[i] < forksByBlock[j] })
 	sort.Slice(forksByTime, func(i, j int) bool { return forksByTime[i] < forksByTime[j] })
 
@@ -17,14 +17,16 @@ var _ = (*genesisAccountMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 	type GenesisAccount struct {
-		Code       hexutil.Bytes               `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
-		PrivateKey hexutil.Bytes               `json:"secretKey,omitempty"`
+		Code        hexutil.Bytes               `json:"code,omitempty"`
+		Constructor hexutil.Bytes               `json:"constructor,omitempty"`
+		Storage     map[storageJSON]storageJSON `json:"storage,omitempty"`
+		Balance     *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
+		Nonce       math.HexOrDecimal64         `json:"nonce,omitempty"`
+		PrivateKey  hexutil.Bytes               `json:"secretKey,omitempty"`
 	}
 	var enc GenesisAccount
 	enc.Code = g.Code
+	enc.Constructor = g.Constructor
 	if g.Storage != nil {
 		enc.Storage = make(map[storageJSON]storageJSON, len(g.Storage))
 		for k, v := range g.Storage {
@@ -40,11 +42,12 @@ func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 	type GenesisAccount struct {
-		Code       *hexutil.Bytes              `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      *math.HexOrDecimal64        `json:"nonce,omitempty"`
-		PrivateKey *hexutil.Bytes              `json:"secretKey,omitempty"`
+		Code        *hexutil.Bytes              `json:"code,omitempty"`
+		Constructor *hexutil.Bytes              `json:"constructor,omitempty"`
+		Storage     map[storageJSON]storageJSON `json:"storage,omitempty"`
+		Balance     *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
+		Nonce       *math.HexOrDecimal64        `json:"nonce,omitempty"`
+		PrivateKey  *hexutil.Bytes              `json:"secretKey,omitempty"`
 	}
 	var dec GenesisAccount
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -53,6 +56,9 @@ func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 	if dec.Code != nil {
 		g.Code = *dec.Code
 	}
+	if dec.Constructor != nil {
+		g.Constructor = *dec.Constructor
+	}
 	if dec.Storage != nil {
 		g.Storage = make(map[common.Hash]common.Hash, len(dec.Storage))
 		for k, v := range dec.Storage {
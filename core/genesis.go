@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -116,7 +117,9 @@ func (ga *GenesisAlloc) UnmarshalJSON(data []byte) error {
 }
 
 // deriveHash computes the state root according to the genesis specification.
-func (ga *GenesisAlloc) deriveHash() (common.Hash, error) {
+// The chain config and the genesis header are only used to execute the
+// constructors of the allocated contracts.
+func (ga *GenesisAlloc) deriveHash(config *params.ChainConfig, head *types.Header) (common.Hash, error) {
 	// Create an ephemeral in-memory database for computing hash,
 	// all the derived states will be discarded to not pollute disk.
 	db := state.NewDatabase(rawdb.NewMemoryDatabase())
@@ -124,13 +127,8 @@ func (ga *GenesisAlloc) deriveHash() (common.Hash, error) {
 	if err != nil {
 		return common.Hash{}, err
 	}
-	for addr, account := range *ga {
-		statedb.AddBalance(addr, account.Balance)
-		statedb.SetCode(addr, account.Code)
-		statedb.SetNonce(addr, account.Nonce)
-		for key, value := range account.Storage {
-			statedb.SetState(addr, key, value)
-		}
+	if err := ga.apply(statedb, config, head); err != nil {
+		return common.Hash{}, err
 	}
 	return statedb.Commit(false)
 }
@@ -138,18 +136,13 @@ func (ga *GenesisAlloc) deriveHash() (common.Hash, error) {
 // flush is very similar with deriveHash, but the main difference is
 // all the generated states will be persisted into the given database.
 // Also, the genesis state specification will be flushed as well.
-func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database, blockhash common.Hash) error {
+func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database, config *params.ChainConfig, head *types.Header) error {
 	statedb, err := state.New(common.Hash{}, state.NewDatabaseWithNodeDB(db, triedb), nil)
 	if err != nil {
 		return err
 	}
-	for addr, account := range *ga {
-		statedb.AddBalance(addr, account.Balance)
-		statedb.SetCode(addr, account.Code)
-		statedb.SetNonce(addr, account.Nonce)
-		for key, value := range account.Storage {
-			statedb.SetState(addr, key, value)
-		}
+	if err := ga.apply(statedb, config, head); err != nil {
+		return err
 	}
 	root, err := statedb.Commit(false)
 	if err != nil {
@@ -166,7 +159,38 @@ func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database, blockhas
 	if err != nil {
 		return err
 	}
-	rawdb.WriteGenesisStateSpec(db, blockhash, blob)
+	rawdb.WriteGenesisStateSpec(db, head.Hash(), blob)
+	return nil
+}
+
+// apply writes the allocated accounts into the given state, then deploys the
+// contracts allocated with a constructor.
+func (ga *GenesisAlloc) apply(statedb *state.StateDB, config *params.ChainConfig, head *types.Header) error {
+	var deploys []common.Address
+	for addr, account := range *ga {
+		if len(account.Constructor) > 0 {
+			if len(account.Code) > 0 {
+				return fmt.Errorf("genesis account %x has both code and constructor", addr)
+			}
+			deploys = append(deploys, addr)
+		}
+		statedb.AddBalance(addr, account.Balance)
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	// Constructors may interact with other allocated accounts, deploy in a
+	// deterministic order after all of them exist
+	sort.Slice(deploys, func(i, j int) bool {
+		return bytes.Compare(deploys[i][:], deploys[j][:]) < 0
+	})
+	for _, addr := range deploys {
+		if err := deployGenesisContract(statedb, config, head, addr, (*ga)[addr].Constructor); err != nil {
+			return fmt.Errorf("failed to deploy genesis contract %x: %w", addr, err)
+		}
+	}
 	return nil
 }
 
@@ -202,16 +226,22 @@ func CommitGenesisState(db ethdb.Database, triedb *trie.Database, blockhash comm
 			return errors.New("not found")
 		}
 	}
-	return alloc.flush(db, triedb, blockhash)
+	// The genesis header and config are needed to re-run the constructors
+	head := rawdb.ReadHeader(db, blockhash, 0)
+	if head == nil {
+		return errors.New("genesis header not found")
+	}
+	return alloc.flush(db, triedb, rawdb.ReadChainConfig(db, blockhash), head)
 }
 
 // GenesisAccount is an account in the state of the genesis block.
 type GenesisAccount struct {
-	Code       []byte                      `json:"code,omitempty"`
-	Storage    map[common.Hash]common.Hash `json:"storage,omitempty"`
-	Balance    *big.Int                    `json:"balance" gencodec:"required"`
-	Nonce      uint64                      `json:"nonce,omitempty"`
-	PrivateKey []byte                      `json:"secretKey,omitempty"` // for tests
+	Code        []byte                      `json:"code,omitempty"`
+	Constructor []byte                      `json:"constructor,omitempty"` // Init code executed to deploy the contract, instead of Code
+	Storage     map[common.Hash]common.Hash `json:"storage,omitempty"`
+	Balance     *big.Int                    `json:"balance" gencodec:"required"`
+	Nonce       uint64                      `json:"nonce,omitempty"`
+	PrivateKey  []byte                      `json:"secretKey,omitempty"` // for tests
 }
 
 // field type overrides for gencodec
@@ -228,11 +258,12 @@ type genesisSpecMarshaling struct {
 }
 
 type genesisAccountMarshaling struct {
-	Code       hexutil.Bytes
-	Balance    *math.HexOrDecimal256
-	Nonce      math.HexOrDecimal64
-	Storage    map[storageJSON]storageJSON
-	PrivateKey hexutil.Bytes
+	Code        hexutil.Bytes
+	Constructor hexutil.Bytes
+	Balance     *math.HexOrDecimal256
+	Nonce       math.HexOrDecimal64
+	Storage     map[storageJSON]storageJSON
+	PrivateKey  hexutil.Bytes
 }
 
 // storageJSON represents a 256 bit byte array, but allows less than 256 bits when
@@ -434,10 +465,6 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 
 // ToBlock returns the genesis block according to genesis specification.
 func (g *Genesis) ToBlock() *types.Block {
-	root, err := g.Alloc.deriveHash()
-	if err != nil {
-		panic(err)
-	}
 	head := &types.Header{
 		Number:     new(big.Int).SetUint64(g.Number),
 		Nonce:      types.EncodeNonce(g.Nonce),
@@ -450,7 +477,6 @@ func (g *Genesis) ToBlock() *types.Block {
 		Difficulty: g.Difficulty,
 		MixDigest:  g.Mixhash,
 		Coinbase:   g.Coinbase,
-		Root:       root,
 	}
 	if g.GasLimit == 0 {
 		head.GasLimit = params.GenesisGasLimit
@@ -470,6 +496,11 @@ func (g *Genesis) ToBlock() *types.Block {
 		head.WithdrawalsHash = &types.EmptyWithdrawalsHash
 		withdrawals = make([]*types.Withdrawal, 0)
 	}
+	root, err := g.Alloc.deriveHash(g.Config, head)
+	if err != nil {
+		panic(err)
+	}
+	head.Root = root
 	return types.NewBlock(head, nil, nil, nil, trie.NewStackTrie(nil)).WithWithdrawals(withdrawals)
 }
 
@@ -493,7 +524,7 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *trie.Database) (*types.Block
 	// All the checks has passed, flush the states derived from the genesis
 	// specification as well as the specification itself into the provided
 	// database.
-	if err := g.Alloc.flush(db, triedb, block.Hash()); err != nil {
+	if err := g.Alloc.flush(db, triedb, config, block.Header()); err != nil {
 		return nil, err
 	}
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty())
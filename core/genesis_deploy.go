@@ -0,0 +1,70 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// deployGenesisContract executes the init code of a contract allocated at
+// genesis, setting the returned runtime code as the code of the account. The
+// constructor runs in the context of the genesis block, called by the zero
+// address, the same way a contract creation runs it: the account holds no code
+// during its execution and starts with nonce 1 since EIP-158. Immutables and the
+// storage it initializes thus match a contract deployed by a transaction.
+func deployGenesisContract(statedb *state.StateDB, config *params.ChainConfig, head *types.Header, addr common.Address, initcode []byte) error {
+	if config == nil {
+		config = params.AllEthashProtocolChanges
+	}
+	context := NewEVMBlockContext(head, nil, &head.Coinbase)
+	context.GetHash = func(uint64) common.Hash { return common.Hash{} } // no blocks before genesis
+
+	var (
+		evm    = vm.NewEVM(context, vm.TxContext{GasPrice: new(big.Int)}, statedb, config, vm.Config{})
+		rules  = config.Rules(head.Number, context.Random != nil, head.Time)
+		caller = vm.AccountRef(common.Address{})
+	)
+	statedb.Prepare(rules, caller.Address(), head.Coinbase, &addr, vm.ActivePrecompiles(rules), nil)
+
+	// Contracts created by transactions start with nonce 1 since EIP-158
+	if rules.IsEIP158 && statedb.GetNonce(addr) == 0 {
+		statedb.SetNonce(addr, 1)
+	}
+	// Run the init code as the code of the creation, not of the account
+	contract := vm.NewContract(caller, vm.AccountRef(addr), new(big.Int), head.GasLimit)
+	contract.SetCallCode(&addr, crypto.Keccak256Hash(initcode), initcode)
+
+	code, err := evm.Interpreter().Run(contract, nil, false)
+	if err != nil {
+		return err
+	}
+	if rules.IsEIP158 && len(code) > rules.MaxCodeSize() {
+		return vm.ErrMaxCodeSizeExceeded
+	}
+	if len(code) > 0 && code[0] == 0xEF && rules.IsLondon {
+		return vm.ErrInvalidCode
+	}
+	statedb.SetCode(addr, code)
+	return nil
+}
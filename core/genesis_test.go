@@ -26,6 +26,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
@@ -220,7 +222,7 @@ func TestReadWriteGenesisAlloc(t *testing.T) {
 			{1}: {Balance: big.NewInt(1), Storage: map[common.Hash]common.Hash{{1}: {1}}},
 			{2}: {Balance: big.NewInt(2), Storage: map[common.Hash]common.Hash{{2}: {2}}},
 		}
-		hash, _ = alloc.deriveHash()
+		hash, _ = alloc.deriveHash(nil, &types.Header{Number: new(big.Int)})
 	)
 	blob, _ := json.Marshal(alloc)
 	rawdb.WriteGenesisStateSpec(db, hash, blob)
@@ -243,3 +245,55 @@ func TestReadWriteGenesisAlloc(t *testing.T) {
 		}
 	}
 }
+
+func TestGenesisConstructor(t *testing.T) {
+	// The constructor stores 0x2a in slot 0, its own code size as seen by other
+	// contracts in slot 1 and returns the runtime code appended to the init code
+	runtime := []byte{byte(vm.PUSH1), 0x0, byte(vm.SLOAD), byte(vm.STOP)}
+	initcode := []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x0, byte(vm.SSTORE),
+		byte(vm.ADDRESS), byte(vm.EXTCODESIZE), byte(vm.PUSH1), 0x1, byte(vm.SSTORE),
+		byte(vm.PUSH1), byte(len(runtime)), byte(vm.PUSH1), 22, byte(vm.PUSH1), 0x0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), byte(len(runtime)), byte(vm.PUSH1), 0x0, byte(vm.RETURN),
+	}
+	initcode = append(initcode, runtime...)
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		addr    = common.Address{0xc0, 0xde}
+		genesis = &Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  GenesisAlloc{addr: {Balance: big.NewInt(1), Constructor: initcode}},
+		}
+	)
+	block, err := genesis.Commit(db, trie.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to commit genesis: %v", err)
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	if code := statedb.GetCode(addr); !reflect.DeepEqual(code, runtime) {
+		t.Fatalf("wrong code: have %x, want %x", code, runtime)
+	}
+	if value := statedb.GetState(addr, common.Hash{}); value != common.BigToHash(big.NewInt(0x2a)) {
+		t.Fatalf("wrong storage: have %x, want 0x2a", value)
+	}
+	if size := statedb.GetState(addr, common.BigToHash(big.NewInt(1))); size != (common.Hash{}) {
+		t.Fatalf("constructor observed account code: have size %x, want 0", size)
+	}
+	if nonce := statedb.GetNonce(addr); nonce != 1 {
+		t.Fatalf("wrong nonce: have %d, want 1", nonce)
+	}
+	// Recommitting the stored specification must yield the same state
+	triedb := trie.NewDatabase(rawdb.NewMemoryDatabase())
+	if err := CommitGenesisState(db, triedb, block.Hash()); err != nil {
+		t.Fatalf("failed to recommit genesis state: %v", err)
+	}
+	// Code and constructor are mutually exclusive
+	alloc := GenesisAlloc{addr: {Balance: big.NewInt(1), Code: runtime, Constructor: initcode}}
+	if _, err := alloc.deriveHash(genesis.Config, block.Header()); err == nil {
+		t.Fatal("allocated account with both code and constructor")
+	}
+}
@@ -277,6 +277,25 @@ func WriteTxIndexTail(db ethdb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadHistoryPruneTail retrieves the number of the first block which hasn't been
+// checked for history pruning yet.
+func ReadHistoryPruneTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(historyPruneTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteHistoryPruneTail stores the number of the first block which hasn't been
+// checked for history pruning yet.
+func WriteHistoryPruneTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(historyPruneTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the history prune tail", "err", err)
+	}
+}
+
 // ReadFastTxLookupLimit retrieves the tx lookup limit used in fast sync.
 func ReadFastTxLookupLimit(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(fastTxLookupLimitKey)
@@ -441,10 +460,14 @@ func ReadBodyRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue
 	// the canonical data.
 	var data []byte
 	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
-		// Check if the data is in ancients
+		// Check if the data is in ancients. Nodes pruning their history keep the
+		// retained bodies in leveldb, leaving empty items in the ancients.
 		if isCanon(reader, number, hash) {
 			data, _ = reader.Ancient(ChainFreezerBodiesTable, number)
-			return nil
+			if len(data) > 0 {
+				data = decodeAncient(db, data)
+				return nil
+			}
 		}
 		// If not, try reading from leveldb
 		data, _ = db.Get(blockBodyKey(number, hash))
@@ -460,6 +483,7 @@ func ReadCanonicalBodyRLP(db ethdb.Reader, number uint64) rlp.RawValue {
 	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
 		data, _ = reader.Ancient(ChainFreezerBodiesTable, number)
 		if len(data) > 0 {
+			data = decodeAncient(db, data)
 			return nil
 		}
 		// Block is not in ancients, read from leveldb by hash and number.
@@ -482,7 +506,9 @@ func WriteBodyRLP(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rlp
 // HasBody verifies the existence of a block body corresponding to the hash.
 func HasBody(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if isCanon(db, number, hash) {
-		return true
+		if data, _ := db.Ancient(ChainFreezerBodiesTable, number); len(data) > 0 {
+			return true
+		}
 	}
 	if has, err := db.Has(blockBodyKey(number, hash)); !has || err != nil {
 		return false
@@ -572,7 +598,9 @@ func DeleteTd(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 // to a block.
 func HasReceipts(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if isCanon(db, number, hash) {
-		return true
+		if data, _ := db.Ancient(ChainFreezerReceiptTable, number); len(data) > 0 {
+			return true
+		}
 	}
 	if has, err := db.Has(blockReceiptsKey(number, hash)); !has || err != nil {
 		return false
@@ -584,10 +612,14 @@ func HasReceipts(db ethdb.Reader, hash common.Hash, number uint64) bool {
 func ReadReceiptsRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
 	var data []byte
 	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
-		// Check if the data is in ancients
+		// Check if the data is in ancients. Nodes pruning their history keep the
+		// retained receipts in leveldb, leaving empty items in the ancients.
 		if isCanon(reader, number, hash) {
 			data, _ = reader.Ancient(ChainFreezerReceiptTable, number)
-			return nil
+			if len(data) > 0 {
+				data = decodeAncient(db, data)
+				return nil
+			}
 		}
 		// If not, try reading from leveldb
 		data, _ = db.Get(blockReceiptsKey(number, hash))
@@ -674,6 +706,28 @@ func DeleteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadStateDiffsRLP retrieves the per-transaction state diffs of a block in RLP
+// encoding. State diffs are only available if recording was enabled at the time
+// the block was processed.
+func ReadStateDiffsRLP(db ethdb.KeyValueReader, hash common.Hash, number uint64) rlp.RawValue {
+	data, _ := db.Get(stateDiffsKey(number, hash))
+	return data
+}
+
+// WriteStateDiffsRLP stores the RLP encoded per-transaction state diffs of a block.
+func WriteStateDiffsRLP(db ethdb.KeyValueWriter, hash common.Hash, number uint64, diffs rlp.RawValue) {
+	if err := db.Put(stateDiffsKey(number, hash), diffs); err != nil {
+		log.Crit("Failed to store block state diffs", "err", err)
+	}
+}
+
+// DeleteStateDiffs removes the per-transaction state diffs of a block.
+func DeleteStateDiffs(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(stateDiffsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block state diffs", "err", err)
+	}
+}
+
 // storedReceiptRLP is the storage encoding of a receipt.
 // Re-definition in core/types/receipt.go.
 // TODO: Re-use the existing definition.
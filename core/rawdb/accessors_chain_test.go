@@ -496,6 +496,63 @@ func TestAncientStorage(t *testing.T) {
 	}
 }
 
+// Tests that the chain freezer moves blocks into the ancient store when the
+// history is pruned, leaving the retained bodies and receipts readable.
+func TestPrunedHistoryFreezing(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	var blocks []*types.Block
+	for i := 0; i <= 10; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Extra: []byte("test block")}
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		block := types.NewBlockWithHeader(header)
+		WriteBlock(db, block)
+		WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+		WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(int64(i)))
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		blocks = append(blocks, block)
+	}
+	WriteHeadBlockHash(db, blocks[10].Hash())
+
+	// Prune the history of the first few blocks and freeze most of the chain
+	WriteHistoryRetention(db, []byte(`{"window":128}`))
+	for _, block := range blocks[1:5] {
+		DeleteBody(db, block.Hash(), block.NumberU64())
+		DeleteReceipts(db, block.Hash(), block.NumberU64())
+	}
+	if err := db.(*freezerdb).Freeze(2); err != nil {
+		t.Fatalf("failed to freeze chain: %v", err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 9 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, 9)
+	}
+	for _, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if ReadHeader(db, hash, number) == nil {
+			t.Errorf("block %d: header missing", number)
+		}
+		pruned := number >= 1 && number < 5
+		if have := HasBody(db, hash, number); have == pruned {
+			t.Errorf("block %d: body presence mismatch: have %v, pruned %v", number, have, pruned)
+		}
+		if have := ReadBody(db, hash, number) != nil; have == pruned {
+			t.Errorf("block %d: body retrieval mismatch: have %v, pruned %v", number, have, pruned)
+		}
+		if have := HasReceipts(db, hash, number); have == pruned {
+			t.Errorf("block %d: receipts presence mismatch: have %v, pruned %v", number, have, pruned)
+		}
+		if have := ReadReceiptsRLP(db, hash, number) != nil; have == pruned {
+			t.Errorf("block %d: receipts retrieval mismatch: have %v, pruned %v", number, have, pruned)
+		}
+	}
+}
+
 func TestCanonicalHashIteration(t *testing.T) {
 	var cases = []struct {
 		from, to uint64
@@ -93,6 +93,64 @@ func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	}
 }
 
+// ReadSenderNonceLookup retrieves the hash of the transaction sent by the given
+// account with the given nonce.
+func ReadSenderNonceLookup(db ethdb.KeyValueReader, sender common.Address, nonce uint64) common.Hash {
+	data, _ := db.Get(senderNonceLookupKey(sender, nonce))
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteSenderNonceLookup stores the hash of the transaction sent by the given
+// account with the given nonce.
+func WriteSenderNonceLookup(db ethdb.KeyValueWriter, sender common.Address, nonce uint64, hash common.Hash) {
+	if err := db.Put(senderNonceLookupKey(sender, nonce), hash.Bytes()); err != nil {
+		log.Crit("Failed to store sender nonce lookup entry", "err", err)
+	}
+}
+
+// WriteSenderNonceLookupsByBlock stores the hash of every transaction from a
+// block keyed by its sender and nonce, enabling lookups of replaced or stuck
+// transactions without knowing their hash. Senders are recovered using the
+// given signer, which is cheap for blocks that have already been processed.
+func WriteSenderNonceLookupsByBlock(db ethdb.KeyValueWriter, signer types.Signer, block *types.Block) {
+	for _, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Error("Failed to derive transaction sender", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		WriteSenderNonceLookup(db, sender, tx.Nonce(), tx.Hash())
+	}
+}
+
+// DeleteSenderNonceLookup removes the transaction lookup of a sender and nonce.
+func DeleteSenderNonceLookup(db ethdb.KeyValueWriter, sender common.Address, nonce uint64) {
+	if err := db.Delete(senderNonceLookupKey(sender, nonce)); err != nil {
+		log.Crit("Failed to delete sender nonce lookup entry", "err", err)
+	}
+}
+
+// ReadTransactionBySenderAndNonce retrieves the canonical transaction sent by the
+// given account with the given nonce, along with its positional metadata.
+//
+// Lookup entries are overwritten but not removed on reorgs, so an entry may
+// reference a transaction which is no longer canonical. Such entries are
+// treated as missing.
+func ReadTransactionBySenderAndNonce(db ethdb.Reader, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64) {
+	hash := ReadSenderNonceLookup(db, sender, nonce)
+	if hash == (common.Hash{}) {
+		return nil, common.Hash{}, 0, 0
+	}
+	tx, blockHash, blockNumber, index := ReadTransaction(db, hash)
+	if tx == nil || tx.Nonce() != nonce {
+		return nil, common.Hash{}, 0, 0
+	}
+	return tx, blockHash, blockNumber, index
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -135,6 +136,50 @@ func TestLookupStorage(t *testing.T) {
 	}
 }
 
+// Tests that transactions can be looked up by sender and nonce, and that entries
+// of transactions no longer indexed are ignored.
+func TestSenderNonceLookup(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	tx1, _ := types.SignTx(types.NewTransaction(0, common.Address{0x11}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	tx2, _ := types.SignTx(types.NewTransaction(1, common.Address{0x22}, big.NewInt(2), 21000, big.NewInt(1), nil), signer, key)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(42)}, []*types.Transaction{tx1, tx2}, nil, nil, newHasher())
+
+	WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	WriteBlock(db, block)
+	WriteTxLookupEntriesByBlock(db, block)
+	WriteSenderNonceLookupsByBlock(db, signer, block)
+
+	for i, tx := range block.Transactions() {
+		txn, hash, number, index := ReadTransactionBySenderAndNonce(db, sender, tx.Nonce())
+		if txn == nil || txn.Hash() != tx.Hash() {
+			t.Fatalf("tx #%d: transaction mismatch: have %v, want %x", i, txn, tx.Hash())
+		}
+		if hash != block.Hash() || number != block.NumberU64() || index != uint64(i) {
+			t.Fatalf("tx #%d: positional metadata mismatch: have %x/%d/%d, want %x/%d/%d", i, hash, number, index, block.Hash(), block.NumberU64(), i)
+		}
+	}
+	if txn, _, _, _ := ReadTransactionBySenderAndNonce(db, sender, 2); txn != nil {
+		t.Fatalf("non existent nonce returned transaction: %v", txn)
+	}
+	if txn, _, _, _ := ReadTransactionBySenderAndNonce(db, common.Address{0x11}, 0); txn != nil {
+		t.Fatalf("non existent sender returned transaction: %v", txn)
+	}
+	// Unindexed (e.g. reorged) transactions should not be returned
+	DeleteTxLookupEntry(db, tx1.Hash())
+	if txn, _, _, _ := ReadTransactionBySenderAndNonce(db, sender, 0); txn != nil {
+		t.Fatalf("unindexed transaction returned: %v", txn)
+	}
+	DeleteSenderNonceLookup(db, sender, 1)
+	if hash := ReadSenderNonceLookup(db, sender, 1); hash != (common.Hash{}) {
+		t.Fatalf("deleted lookup returned: %x", hash)
+	}
+}
+
 func TestDeleteBloomBits(t *testing.T) {
 	// Prepare testing data
 	db := NewMemoryDatabase()
@@ -187,3 +187,29 @@ func WriteTransitionStatus(db ethdb.KeyValueWriter, data []byte) {
 		log.Crit("Failed to store the eth2 transition status", "err", err)
 	}
 }
+
+// ReadStateRetention retrieves the serialized state retention policy.
+func ReadStateRetention(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(stateRetentionKey)
+	return data
+}
+
+// WriteStateRetention stores the serialized state retention policy.
+func WriteStateRetention(db ethdb.KeyValueWriter, policy []byte) {
+	if err := db.Put(stateRetentionKey, policy); err != nil {
+		log.Crit("Failed to store state retention policy", "err", err)
+	}
+}
+
+// ReadHistoryRetention retrieves the serialized block history retention policy.
+func ReadHistoryRetention(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(historyRetentionKey)
+	return data
+}
+
+// WriteHistoryRetention stores the serialized block history retention policy.
+func WriteHistoryRetention(db ethdb.KeyValueWriter, policy []byte) {
+	if err := db.Put(historyRetentionKey, policy); err != nil {
+		log.Crit("Failed to store history retention policy", "err", err)
+	}
+}
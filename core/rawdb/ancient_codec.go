@@ -0,0 +1,366 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ancientCodecZstd is the marker byte prefixing ancient bodies and receipts
+// compressed with a zstd dictionary, followed by the dictionary id (uint32 big
+// endian) and the zstd frame. Uncompressed items are RLP lists starting with a
+// byte of at least 0xc0, so the marker is unambiguous and both encodings can be
+// mixed within a table.
+const ancientCodecZstd = 0x01
+
+// Range of the zstd compression levels accepted by the ancient codec, mapped to
+// the closest level of the pure Go zstd implementation.
+const (
+	ancientMinLevel = 1
+	ancientMaxLevel = 20
+)
+
+// ancientCodecTables are the freezer tables the ancient codec applies to.
+var ancientCodecTables = []string{ChainFreezerBodiesTable, ChainFreezerReceiptTable}
+
+// ancientCodecConfig is the persisted configuration of the ancient codec.
+type ancientCodecConfig struct {
+	DictID uint32 // Id of the zstd dictionary to compress with
+	Level  uint32 // Zstd compression level
+}
+
+// ancientEncoderKey identifies a cached zstd encoder.
+type ancientEncoderKey struct {
+	id    uint32
+	level int
+}
+
+// ancientEncoders and ancientDecoders cache the zstd processors of the
+// dictionaries in use, as digesting a dictionary is expensive. Decoding doesn't
+// depend on the compression level, so decoders are cached per dictionary only.
+// Dictionary ids are derived from their content, so the caches are valid across
+// databases. Both processors are safe for concurrent use.
+var (
+	ancientEncoders sync.Map // map[ancientEncoderKey]*zstd.Encoder
+	ancientDecoders sync.Map // map[uint32]*zstd.Decoder
+)
+
+// ancientDictID derives the id of a zstd dictionary from its content.
+func ancientDictID(dict []byte) uint32 {
+	return binary.BigEndian.Uint32(crypto.Keccak256(dict)[:4])
+}
+
+// ReadAncientDictionary retrieves the zstd dictionary with the given id.
+func ReadAncientDictionary(db ethdb.KeyValueReader, id uint32) []byte {
+	data, _ := db.Get(ancientDictKey(id))
+	return data
+}
+
+// WriteAncientDictionary stores a zstd dictionary and returns its id.
+func WriteAncientDictionary(db ethdb.KeyValueWriter, dict []byte) uint32 {
+	id := ancientDictID(dict)
+	if err := db.Put(ancientDictKey(id), dict); err != nil {
+		log.Crit("Failed to store ancient dictionary", "err", err)
+	}
+	return id
+}
+
+// EnableAncientCompression stores the given zstd dictionary and configures the
+// chain freezer to compress all subsequently frozen bodies and receipts with it.
+// Already frozen items are left untouched until RecodeAncients is called.
+func EnableAncientCompression(db ethdb.KeyValueWriter, dict []byte, level int) error {
+	if len(dict) == 0 {
+		return errors.New("empty dictionary")
+	}
+	if level < ancientMinLevel || level > ancientMaxLevel {
+		return fmt.Errorf("invalid compression level %d", level)
+	}
+	id := WriteAncientDictionary(db, dict)
+
+	blob, err := rlp.EncodeToBytes(&ancientCodecConfig{DictID: id, Level: uint32(level)})
+	if err != nil {
+		return err
+	}
+	return db.Put(ancientCodecKey, blob)
+}
+
+// DisableAncientCompression configures the chain freezer to store bodies and
+// receipts uncompressed. Compressed items remain readable.
+func DisableAncientCompression(db ethdb.KeyValueWriter) error {
+	return db.Delete(ancientCodecKey)
+}
+
+// readAncientCodecConfig retrieves the configuration of the ancient codec, or
+// nil if compression is disabled.
+func readAncientCodecConfig(db ethdb.KeyValueReader) (*ancientCodecConfig, error) {
+	blob, err := db.Get(ancientCodecKey)
+	if err != nil || len(blob) == 0 {
+		return nil, nil
+	}
+	config := new(ancientCodecConfig)
+	if err := rlp.DecodeBytes(blob, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readAncientDictionary retrieves the zstd dictionary with the given id, or an
+// error if it's missing.
+func readAncientDictionary(db ethdb.KeyValueReader, id uint32) ([]byte, error) {
+	dict := ReadAncientDictionary(db, id)
+	if len(dict) == 0 {
+		return nil, fmt.Errorf("ancient dictionary %#x missing", id)
+	}
+	return dict, nil
+}
+
+// ancientZstdEncoder returns the zstd encoder of the dictionary with the given
+// id and compression level, loading the dictionary if it's not cached yet.
+func ancientZstdEncoder(db ethdb.KeyValueReader, id uint32, level int) (*zstd.Encoder, error) {
+	key := ancientEncoderKey{id: id, level: level}
+	if enc, ok := ancientEncoders.Load(key); ok {
+		return enc.(*zstd.Encoder), nil
+	}
+	dict, err := readAncientDictionary(db, id)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDictRaw(id, dict), zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	ancientEncoders.Store(key, enc)
+	return enc, nil
+}
+
+// ancientZstdDecoder returns the zstd decoder of the dictionary with the given
+// id, loading the dictionary if it's not cached yet.
+func ancientZstdDecoder(db ethdb.KeyValueReader, id uint32) (*zstd.Decoder, error) {
+	if dec, ok := ancientDecoders.Load(id); ok {
+		return dec.(*zstd.Decoder), nil
+	}
+	dict, err := readAncientDictionary(db, id)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDictRaw(id, dict), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	ancientDecoders.Store(id, dec)
+	return dec, nil
+}
+
+// ancientEncoder converts ancient bodies and receipts into their stored form.
+type ancientEncoder func(blob []byte) ([]byte, error)
+
+// newAncientEncoder creates an encoder for the currently configured codec. The
+// returned encoder leaves items unchanged if compression is disabled.
+func newAncientEncoder(db ethdb.KeyValueReader) (ancientEncoder, error) {
+	config, err := readAncientCodecConfig(db)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return func(blob []byte) ([]byte, error) { return blob, nil }, nil
+	}
+	enc, err := ancientZstdEncoder(db, config.DictID, int(config.Level))
+	if err != nil {
+		return nil, err
+	}
+	return func(blob []byte) ([]byte, error) {
+		out := make([]byte, 5, 5+enc.MaxEncodedSize(len(blob)))
+		out[0] = ancientCodecZstd
+		binary.BigEndian.PutUint32(out[1:], config.DictID)
+		return enc.EncodeAll(blob, out), nil
+	}, nil
+}
+
+// decodeAncientItem converts a stored ancient body or receipt list back into its
+// RLP encoding, decompressing it if needed.
+func decodeAncientItem(db ethdb.KeyValueReader, blob []byte) ([]byte, error) {
+	if len(blob) == 0 || blob[0] != ancientCodecZstd {
+		return blob, nil
+	}
+	if len(blob) < 5 {
+		return nil, errors.New("truncated compressed ancient item")
+	}
+	dec, err := ancientZstdDecoder(db, binary.BigEndian.Uint32(blob[1:5]))
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(blob[5:], nil)
+}
+
+// decodeAncient is decodeAncientItem for the chain accessors, which treat items
+// that cannot be decoded as missing.
+func decodeAncient(db ethdb.KeyValueReader, blob []byte) []byte {
+	data, err := decodeAncientItem(db, blob)
+	if err != nil {
+		log.Error("Failed to decode ancient item", "err", err)
+		return nil
+	}
+	return data
+}
+
+// RecodeAncients rewrites the frozen bodies and receipts with the currently
+// configured codec, compressing, recompressing with a new dictionary or
+// decompressing them as needed. It must be run on a database not in use by a
+// running node, which has to be reopened afterwards for the rewritten tables to
+// take effect.
+func RecodeAncients(db ethdb.Database) error {
+	encode, err := newAncientEncoder(db)
+	if err != nil {
+		return err
+	}
+	config, err := readAncientCodecConfig(db)
+	if err != nil {
+		return err
+	}
+	for _, kind := range ancientCodecTables {
+		err := db.MigrateTable(kind, func(blob []byte) ([]byte, error) {
+			// Skip the empty items of pruned history and those already stored
+			// in the target encoding
+			if len(blob) == 0 {
+				return blob, nil
+			}
+			if config != nil && len(blob) >= 5 && blob[0] == ancientCodecZstd && binary.BigEndian.Uint32(blob[1:5]) == config.DictID {
+				return blob, nil
+			}
+			if config == nil && blob[0] != ancientCodecZstd {
+				return blob, nil
+			}
+			data, err := decodeAncientItem(db, blob)
+			if err != nil {
+				return nil, err
+			}
+			return encode(data)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to recode %s: %v", kind, err)
+		}
+	}
+	return nil
+}
+
+// Parameters of the dictionary trainer.
+const (
+	trainSegmentSize = 128 // Size of the sample segments the dictionary is made of
+	trainDmerSize    = 8   // Length of the substrings segments are scored by
+)
+
+// trainCandidate is a sample segment considered for inclusion in a dictionary.
+type trainCandidate struct {
+	data  []byte
+	score uint64
+}
+
+// trainQueue is a max-heap of dictionary candidates ordered by score.
+type trainQueue []*trainCandidate
+
+func (q trainQueue) Len() int            { return len(q) }
+func (q trainQueue) Less(i, j int) bool  { return q[i].score > q[j].score }
+func (q trainQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *trainQueue) Push(x interface{}) { *q = append(*q, x.(*trainCandidate)) }
+func (q *trainQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// TrainAncientDictionary builds a raw content zstd dictionary of at most size
+// bytes from sample bodies or receipts. Sample segments are scored by how many
+// samples contain each of their substrings and greedily selected, discounting
+// substrings already covered, in the spirit of zstd's COVER algorithm. The most
+// valuable segments are placed last, where zstd references them most cheaply.
+func TrainAncientDictionary(samples [][]byte, size int) []byte {
+	// Count the number of samples each dmer occurs in
+	freqs := make(map[uint64]uint64)
+	for _, sample := range samples {
+		seen := make(map[uint64]struct{})
+		for i := 0; i+trainDmerSize <= len(sample); i++ {
+			dmer := binary.LittleEndian.Uint64(sample[i:])
+			if _, ok := seen[dmer]; !ok {
+				seen[dmer] = struct{}{}
+				freqs[dmer]++
+			}
+		}
+	}
+	score := func(segment []byte) uint64 {
+		var score uint64
+		for i := 0; i+trainDmerSize <= len(segment); i++ {
+			score += freqs[binary.LittleEndian.Uint64(segment[i:])]
+		}
+		return score
+	}
+	// Split the samples into segments and select the best ones greedily. Scores
+	// only ever decrease, so candidates are rescored lazily when popped.
+	queue := new(trainQueue)
+	for _, sample := range samples {
+		for start := 0; start < len(sample); start += trainSegmentSize {
+			end := start + trainSegmentSize
+			if end > len(sample) {
+				end = len(sample)
+			}
+			if seg := sample[start:end]; len(seg) >= trainDmerSize {
+				*queue = append(*queue, &trainCandidate{data: seg, score: score(seg)})
+			}
+		}
+	}
+	heap.Init(queue)
+
+	var (
+		selected [][]byte
+		total    int
+	)
+	for queue.Len() > 0 && total < size {
+		cand := heap.Pop(queue).(*trainCandidate)
+		if cand.score = score(cand.data); cand.score == 0 {
+			continue
+		}
+		if queue.Len() > 0 && cand.score < (*queue)[0].score {
+			heap.Push(queue, cand)
+			continue
+		}
+		data := cand.data
+		if total+len(data) > size {
+			data = data[:size-total]
+		}
+		selected = append(selected, data)
+		total += len(data)
+
+		for i := 0; i+trainDmerSize <= len(cand.data); i++ {
+			delete(freqs, binary.LittleEndian.Uint64(cand.data[i:]))
+		}
+	}
+	dict := make([]byte, 0, total)
+	for i := len(selected) - 1; i >= 0; i-- {
+		dict = append(dict, selected[i]...)
+	}
+	return dict
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that frozen bodies and receipts can be compressed and decompressed in
+// place, remaining readable throughout.
+func TestAncientCompression(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		db  ethdb.Database
+	)
+	// The freezer only picks up recoded tables when reopened
+	reopen := func() {
+		t.Helper()
+		if db != nil {
+			db.Close()
+		}
+		kvdb, err := NewLevelDBDatabase(filepath.Join(dir, "chaindata"), 16, 16, "", false)
+		if err != nil {
+			t.Fatalf("failed to create key-value database: %v", err)
+		}
+		if db, err = NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"), "", false); err != nil {
+			t.Fatalf("failed to create database with ancient backend: %v", err)
+		}
+	}
+	reopen()
+	defer func() { db.Close() }()
+
+	blocks := makeTestBlocks(64, 4)
+	receipts := makeTestReceipts(64, 4)
+	if _, err := WriteAncientBlocks(db, blocks, receipts, big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	var samples [][]byte
+	for _, block := range blocks {
+		samples = append(samples, ReadBodyRLP(db, block.Hash(), block.NumberU64()))
+		samples = append(samples, ReadReceiptsRLP(db, block.Hash(), block.NumberU64()))
+	}
+	dict := TrainAncientDictionary(samples, 4096)
+	if len(dict) == 0 || len(dict) > 4096 {
+		t.Fatalf("invalid dictionary size: %d", len(dict))
+	}
+	check := func(compressed bool) {
+		t.Helper()
+		for i, block := range blocks {
+			raw, _ := db.Ancient(ChainFreezerBodiesTable, block.NumberU64())
+			if have := raw[0] == ancientCodecZstd; have != compressed {
+				t.Fatalf("block %d: compression mismatch: have %v, want %v", i, have, compressed)
+			}
+			if body := ReadBodyRLP(db, block.Hash(), block.NumberU64()); !bytes.Equal(body, samples[2*i]) {
+				t.Fatalf("block %d: body mismatch", i)
+			}
+			if body := ReadCanonicalBodyRLP(db, block.NumberU64()); !bytes.Equal(body, samples[2*i]) {
+				t.Fatalf("block %d: canonical body mismatch", i)
+			}
+			if recs := ReadReceiptsRLP(db, block.Hash(), block.NumberU64()); !bytes.Equal(recs, samples[2*i+1]) {
+				t.Fatalf("block %d: receipts mismatch", i)
+			}
+		}
+	}
+	if err := EnableAncientCompression(db, dict, 3); err != nil {
+		t.Fatalf("failed to enable compression: %v", err)
+	}
+	if err := RecodeAncients(db); err != nil {
+		t.Fatalf("failed to compress ancients: %v", err)
+	}
+	reopen()
+	check(true)
+
+	if err := DisableAncientCompression(db); err != nil {
+		t.Fatalf("failed to disable compression: %v", err)
+	}
+	if err := RecodeAncients(db); err != nil {
+		t.Fatalf("failed to decompress ancients: %v", err)
+	}
+	reopen()
+	check(false)
+}
+
+// Tests that compressed items round-trip and are smaller than the originals.
+func TestAncientEncoder(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var samples [][]byte
+	for _, recs := range makeTestReceipts(16, 8) {
+		stored := make([]*types.ReceiptForStorage, len(recs))
+		for i, receipt := range recs {
+			stored[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		blob, _ := rlp.EncodeToBytes(stored)
+		samples = append(samples, blob)
+	}
+	if err := EnableAncientCompression(db, TrainAncientDictionary(samples, 1024), 3); err != nil {
+		t.Fatalf("failed to enable compression: %v", err)
+	}
+	encode, err := newAncientEncoder(db)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	for i, sample := range samples {
+		enc, err := encode(sample)
+		if err != nil {
+			t.Fatalf("sample %d: failed to encode: %v", i, err)
+		}
+		if len(enc) >= len(sample) {
+			t.Errorf("sample %d: not compressed: %d >= %d", i, len(enc), len(sample))
+		}
+		dec, err := decodeAncientItem(db, enc)
+		if err != nil || !bytes.Equal(dec, sample) {
+			t.Fatalf("sample %d: round trip failed: %v", i, err)
+		}
+	}
+	// Items compressed with an unknown dictionary must not decode
+	if _, err := decodeAncientItem(NewMemoryDatabase(), []byte{ancientCodecZstd, 0xde, 0xad, 0xbe, 0xef, 0x00}); err == nil {
+		t.Fatalf("decoded item with unknown dictionary")
+	}
+}
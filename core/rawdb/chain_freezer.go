@@ -152,11 +152,12 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 			start    = time.Now()
 			first, _ = f.Ancients()
 			limit    = *number - threshold
+			pruning  = len(ReadHistoryRetention(nfdb)) > 0
 		)
 		if limit-first > freezerBatchLimit {
 			limit = first + freezerBatchLimit
 		}
-		ancients, err := f.freezeRange(nfdb, first, limit)
+		ancients, err := f.freezeRange(nfdb, first, limit, pruning)
 		if err != nil {
 			log.Error("Error in block freeze operation", "err", err)
 			backoff = true
@@ -172,10 +173,18 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 		batch := db.NewBatch()
 		for i := 0; i < len(ancients); i++ {
 			// Always keep the genesis block in active database
-			if first+uint64(i) != 0 {
+			if first+uint64(i) == 0 {
+				continue
+			}
+			// If the history is pruned, the bodies and receipts stay in the
+			// active database until the chain prunes them.
+			if pruning {
+				deleteHeaderWithoutNumber(batch, ancients[i], first+uint64(i))
+				DeleteTd(batch, ancients[i], first+uint64(i))
+			} else {
 				DeleteBlockWithoutNumber(batch, ancients[i], first+uint64(i))
-				DeleteCanonicalHash(batch, first+uint64(i))
 			}
+			DeleteCanonicalHash(batch, first+uint64(i))
 		}
 		if err := batch.Write(); err != nil {
 			log.Crit("Failed to delete frozen canonical blocks", "err", err)
@@ -250,9 +259,20 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 	}
 }
 
-func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hashes []common.Hash, err error) {
+// freezeRange moves the canonical blocks in the range [number, limit] into the
+// ancient store, returning the hashes of the frozen blocks.
+//
+// If pruning is set, the node prunes its block history and the bodies and
+// receipts are frozen as empty items, as they may be missing already. Those
+// retained are kept in the key-value store instead, from where they can still
+// be pruned later on.
+func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64, pruning bool) (hashes []common.Hash, err error) {
 	hashes = make([]common.Hash, 0, limit-number)
 
+	encode, err := newAncientEncoder(nfdb)
+	if err != nil {
+		return nil, err
+	}
 	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
 		for ; number <= limit; number++ {
 			// Retrieve all the components of the canonical block.
@@ -264,13 +284,22 @@ func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hash
 			if len(header) == 0 {
 				return fmt.Errorf("block header missing, can't freeze block %d", number)
 			}
-			body := ReadBodyRLP(nfdb, hash, number)
-			if len(body) == 0 {
-				return fmt.Errorf("block body missing, can't freeze block %d", number)
-			}
-			receipts := ReadReceiptsRLP(nfdb, hash, number)
-			if len(receipts) == 0 {
-				return fmt.Errorf("block receipts missing, can't freeze block %d", number)
+			var body, receipts []byte
+			if !pruning {
+				body = ReadBodyRLP(nfdb, hash, number)
+				if len(body) == 0 {
+					return fmt.Errorf("block body missing, can't freeze block %d", number)
+				}
+				receipts = ReadReceiptsRLP(nfdb, hash, number)
+				if len(receipts) == 0 {
+					return fmt.Errorf("block receipts missing, can't freeze block %d", number)
+				}
+				if body, err = encode(body); err != nil {
+					return fmt.Errorf("can't encode body of block %d: %v", number, err)
+				}
+				if receipts, err = encode(receipts); err != nil {
+					return fmt.Errorf("can't encode receipts of block %d: %v", number, err)
+				}
 			}
 			td := ReadTdRLP(nfdb, hash, number)
 			if len(td) == 0 {
@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"math/big"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -84,15 +86,24 @@ func InitDatabaseFromFreezer(db ethdb.Database) {
 }
 
 type blockTxHashes struct {
-	number uint64
-	hashes []common.Hash
+	number  uint64
+	hashes  []common.Hash
+	senders []txSenderNonce // Only populated if the sender index is requested
+}
+
+// txSenderNonce is the sender and nonce of a transaction.
+type txSenderNonce struct {
+	sender common.Address
+	nonce  uint64
+	hash   common.Hash
 }
 
 // iterateTransactions iterates over all transactions in the (canon) block
-// number(s) given, and yields the hashes on a channel. If there is a signal
+// number(s) given, and yields the hashes on a channel. If a chain config is
+// given, the senders of the transactions are recovered too. If there is a signal
 // received from interrupt channel, the iteration will be aborted and result
 // channel will be closed.
-func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool, interrupt chan struct{}) chan *blockTxHashes {
+func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool, config *params.ChainConfig, interrupt chan struct{}) chan *blockTxHashes {
 	// One thread sequentially reads data from db
 	type numberRlp struct {
 		number uint64
@@ -142,18 +153,45 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 			}
 		}()
 		for data := range rlpCh {
+			// The bodies of blocks outside the retained history of nodes pruning
+			// it are missing, they don't have any transactions to iterate.
 			var body types.Body
+			if len(data.rlp) == 0 {
+				select {
+				case hashesCh <- &blockTxHashes{number: data.number}:
+					continue
+				case <-interrupt:
+					return
+				}
+			}
 			if err := rlp.DecodeBytes(data.rlp, &body); err != nil {
 				log.Warn("Failed to decode block body", "block", data.number, "error", err)
 				return
 			}
-			var hashes []common.Hash
+			var (
+				hashes  []common.Hash
+				senders []txSenderNonce
+				signer  types.Signer
+			)
+			if config != nil {
+				signer = types.MakeSigner(config, new(big.Int).SetUint64(data.number))
+			}
 			for _, tx := range body.Transactions {
 				hashes = append(hashes, tx.Hash())
+				if signer == nil {
+					continue
+				}
+				sender, err := types.Sender(signer, tx)
+				if err != nil {
+					log.Error("Failed to derive transaction sender", "hash", tx.Hash(), "err", err)
+					continue
+				}
+				senders = append(senders, txSenderNonce{sender: sender, nonce: tx.Nonce(), hash: tx.Hash()})
 			}
 			result := &blockTxHashes{
-				hashes: hashes,
-				number: data.number,
+				hashes:  hashes,
+				senders: senders,
+				number:  data.number,
 			}
 			// Feed the block to the aggregator, or abort on interrupt
 			select {
@@ -176,15 +214,18 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 // We can write tx index tail flag periodically even without the whole indexing
 // procedure is finished. So that we can resume indexing procedure next time quickly.
 //
+// If a chain config is given, the transactions are also indexed by their sender
+// and nonce.
+//
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+func indexTransactions(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}, hook func(uint64) bool) {
 	// short circuit for invalid range
 	if from >= to {
 		return
 	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, true, interrupt)
+		hashesCh = iterateTransactions(db, from, to, true, config, interrupt)
 		batch    = db.NewBatch()
 		start    = time.Now()
 		logged   = start.Add(-7 * time.Second)
@@ -214,6 +255,9 @@ func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan
 			delivery := queue.PopItem()
 			lastNum = delivery.number
 			WriteTxLookupEntries(batch, delivery.number, delivery.hashes)
+			for _, tx := range delivery.senders {
+				WriteSenderNonceLookup(batch, tx.sender, tx.nonce, tx.hash)
+			}
 			blocks++
 			txs += len(delivery.hashes)
 			// If enough data was accumulated in memory or we're at the last block, dump to disk
@@ -255,28 +299,34 @@ func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan
 // We can write tx index tail flag periodically even without the whole indexing
 // procedure is finished. So that we can resume indexing procedure next time quickly.
 //
+// If a chain config is given, the transactions are also indexed by their sender
+// and nonce.
+//
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func IndexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}) {
-	indexTransactions(db, from, to, interrupt, nil)
+func IndexTransactions(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}) {
+	indexTransactions(db, from, to, config, interrupt, nil)
 }
 
 // indexTransactionsForTesting is the internal debug version with an additional hook.
-func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
-	indexTransactions(db, from, to, interrupt, hook)
+func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}, hook func(uint64) bool) {
+	indexTransactions(db, from, to, config, interrupt, hook)
 }
 
 // unindexTransactions removes txlookup indices of the specified block range.
 //
+// If a chain config is given, the sender and nonce indices of the transactions
+// are also removed.
+//
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+func unindexTransactions(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}, hook func(uint64) bool) {
 	// short circuit for invalid range
 	if from >= to {
 		return
 	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, false, interrupt)
+		hashesCh = iterateTransactions(db, from, to, false, config, interrupt)
 		batch    = db.NewBatch()
 		start    = time.Now()
 		logged   = start.Add(-7 * time.Second)
@@ -303,6 +353,9 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 			delivery := queue.PopItem()
 			nextNum = delivery.number + 1
 			DeleteTxLookupEntries(batch, delivery.hashes)
+			for _, tx := range delivery.senders {
+				DeleteSenderNonceLookup(batch, tx.sender, tx.nonce)
+			}
 			txs += len(delivery.hashes)
 			blocks++
 
@@ -343,13 +396,93 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 // UnindexTransactions removes txlookup indices of the specified block range.
 // The from is included while to is excluded.
 //
+// If a chain config is given, the sender and nonce indices of the transactions
+// are also removed.
+//
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
-func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}) {
-	unindexTransactions(db, from, to, interrupt, nil)
+func UnindexTransactions(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}) {
+	unindexTransactions(db, from, to, config, interrupt, nil)
 }
 
 // unindexTransactionsForTesting is the internal debug version with an additional hook.
-func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
-	unindexTransactions(db, from, to, interrupt, hook)
+func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, interrupt chan struct{}, hook func(uint64) bool) {
+	unindexTransactions(db, from, to, config, interrupt, hook)
+}
+
+// PruneHistory deletes the bodies and receipts of the canonical blocks in the
+// specified range which are not retained, along with the txlookup indices of
+// their transactions. The from is included while to is excluded. Blocks which
+// were frozen in full before the history got pruned are left untouched.
+//
+// The progress is tracked in the database, so pruning can resume from where it
+// was interrupted. If a chain config is given, the sender and nonce indices of
+// the transactions are also removed.
+func PruneHistory(db ethdb.Database, from uint64, to uint64, config *params.ChainConfig, retain func(uint64) bool, interrupt chan struct{}) {
+	var (
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = start.Add(-7 * time.Second)
+		number = from
+		blocks = 0
+	)
+loop:
+	for ; number < to; number++ {
+		select {
+		case <-interrupt:
+			break loop
+		default:
+		}
+		if !retain(number) {
+			hash := ReadCanonicalHash(db, number)
+			if data, _ := db.Get(blockBodyKey(number, hash)); len(data) > 0 {
+				var body types.Body
+				if err := rlp.DecodeBytes(data, &body); err != nil {
+					log.Warn("Failed to decode block body", "block", number, "error", err)
+				}
+				var signer types.Signer
+				if config != nil {
+					signer = types.MakeSigner(config, new(big.Int).SetUint64(number))
+				}
+				for _, tx := range body.Transactions {
+					DeleteTxLookupEntry(batch, tx.Hash())
+					if signer == nil {
+						continue
+					}
+					if sender, err := types.Sender(signer, tx); err == nil {
+						DeleteSenderNonceLookup(batch, sender, tx.Nonce())
+					}
+				}
+				DeleteBody(batch, hash, number)
+				blocks++
+			}
+			if has, _ := db.Has(blockReceiptsKey(number, hash)); has {
+				DeleteReceipts(batch, hash, number)
+			}
+		}
+		// Flush the progress periodically to not redo it after an interruption
+		if batch.ValueSize() >= ethdb.IdealBatchSize || (number+1)%10000 == 0 {
+			WriteHistoryPruneTail(batch, number+1)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed writing batch to db", "error", err)
+				return
+			}
+			batch.Reset()
+		}
+		// If we've spent too much time already, notify the user of what we're doing
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning block history", "blocks", blocks, "number", number, "total", to-from, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	WriteHistoryPruneTail(batch, number)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed writing batch to db", "error", err)
+		return
+	}
+	if number < to {
+		log.Debug("Block history pruning interrupted", "blocks", blocks, "tail", number, "elapsed", common.PrettyDuration(time.Since(start)))
+	} else {
+		log.Debug("Pruned block history", "blocks", blocks, "tail", number, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
 }
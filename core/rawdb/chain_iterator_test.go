@@ -25,6 +25,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestChainIterator(t *testing.T) {
@@ -80,7 +82,7 @@ func TestChainIterator(t *testing.T) {
 	}
 	for i, c := range cases {
 		var numbers []int
-		hashCh := iterateTransactions(chainDb, c.from, c.to, c.reverse, nil)
+		hashCh := iterateTransactions(chainDb, c.from, c.to, c.reverse, nil, nil)
 		if hashCh != nil {
 			for h := range hashCh {
 				numbers = append(numbers, int(h.number))
@@ -162,24 +164,24 @@ func TestIndexTransactions(t *testing.T) {
 			t.Fatalf("Transaction tail mismatch")
 		}
 	}
-	IndexTransactions(chainDb, 5, 11, nil)
+	IndexTransactions(chainDb, 5, 11, nil, nil)
 	verify(5, 11, true, 5)
 	verify(0, 5, false, 5)
 
-	IndexTransactions(chainDb, 0, 5, nil)
+	IndexTransactions(chainDb, 0, 5, nil, nil)
 	verify(0, 11, true, 0)
 
-	UnindexTransactions(chainDb, 0, 5, nil)
+	UnindexTransactions(chainDb, 0, 5, nil, nil)
 	verify(5, 11, true, 5)
 	verify(0, 5, false, 5)
 
-	UnindexTransactions(chainDb, 5, 11, nil)
+	UnindexTransactions(chainDb, 5, 11, nil, nil)
 	verify(0, 11, false, 11)
 
 	// Testing corner cases
 	signal := make(chan struct{})
 	var once sync.Once
-	indexTransactionsForTesting(chainDb, 5, 11, signal, func(n uint64) bool {
+	indexTransactionsForTesting(chainDb, 5, 11, nil, signal, func(n uint64) bool {
 		if n <= 8 {
 			once.Do(func() {
 				close(signal)
@@ -190,11 +192,11 @@ func TestIndexTransactions(t *testing.T) {
 	})
 	verify(9, 11, true, 9)
 	verify(0, 9, false, 9)
-	IndexTransactions(chainDb, 0, 9, nil)
+	IndexTransactions(chainDb, 0, 9, nil, nil)
 
 	signal = make(chan struct{})
 	var once2 sync.Once
-	unindexTransactionsForTesting(chainDb, 0, 11, signal, func(n uint64) bool {
+	unindexTransactionsForTesting(chainDb, 0, 11, nil, signal, func(n uint64) bool {
 		if n >= 8 {
 			once2.Do(func() {
 				close(signal)
@@ -206,3 +208,106 @@ func TestIndexTransactions(t *testing.T) {
 	verify(8, 11, true, 8)
 	verify(0, 8, false, 8)
 }
+
+func TestIndexTransactionSenders(t *testing.T) {
+	chainDb := NewMemoryDatabase()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	config := params.TestChainConfig
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(int64(0))}, nil, nil, nil, newHasher()) // Empty genesis block
+	WriteBlock(chainDb, block)
+	WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+
+	var txs []*types.Transaction
+	for i := uint64(1); i <= 4; i++ {
+		signer := types.MakeSigner(config, new(big.Int).SetUint64(i))
+		tx, _ := types.SignTx(types.NewTransaction(i, common.Address{0x11}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+		txs = append(txs, tx)
+
+		block = types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(i)}, []*types.Transaction{tx}, nil, nil, newHasher())
+		WriteBlock(chainDb, block)
+		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+	}
+	// Without a chain config only the hash lookups are indexed
+	IndexTransactions(chainDb, 0, 5, nil, nil)
+	if hash := ReadSenderNonceLookup(chainDb, sender, 1); hash != (common.Hash{}) {
+		t.Fatalf("sender index written without being requested: %x", hash)
+	}
+	UnindexTransactions(chainDb, 0, 5, nil, nil)
+
+	IndexTransactions(chainDb, 0, 5, config, nil)
+	for _, tx := range txs {
+		if hash := ReadSenderNonceLookup(chainDb, sender, tx.Nonce()); hash != tx.Hash() {
+			t.Fatalf("nonce %d: sender index mismatch: have %x, want %x", tx.Nonce(), hash, tx.Hash())
+		}
+	}
+	UnindexTransactions(chainDb, 0, 3, config, nil)
+	for _, tx := range txs {
+		want := tx.Hash()
+		if tx.Nonce() < 3 {
+			want = common.Hash{}
+		}
+		if hash := ReadSenderNonceLookup(chainDb, sender, tx.Nonce()); hash != want {
+			t.Fatalf("nonce %d: sender index mismatch: have %x, want %x", tx.Nonce(), hash, want)
+		}
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	chainDb := NewMemoryDatabase()
+
+	var blocks []*types.Block
+	to := common.BytesToAddress([]byte{0x11})
+	for i := uint64(0); i <= 10; i++ {
+		var txs []*types.Transaction
+		if i > 0 {
+			txs = append(txs, types.NewTx(&types.LegacyTx{
+				Nonce:    i,
+				GasPrice: big.NewInt(11111),
+				Gas:      1111,
+				To:       &to,
+				Value:    big.NewInt(111),
+			}))
+		}
+		block := types.NewBlock(&types.Header{Number: big.NewInt(int64(i))}, txs, nil, nil, newHasher())
+		WriteBlock(chainDb, block)
+		WriteReceipts(chainDb, block.Hash(), block.NumberU64(), nil)
+		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+		blocks = append(blocks, block)
+	}
+	IndexTransactions(chainDb, 0, 11, nil, nil)
+
+	// Prune everything below block 8 apart from the genesis and blocks 3-4
+	retain := func(number uint64) bool { return number == 0 || number == 3 || number == 4 }
+	PruneHistory(chainDb, 0, 8, nil, retain, nil)
+
+	for _, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		retained := number >= 8 || retain(number)
+		if have := HasBody(chainDb, hash, number); have != retained {
+			t.Errorf("block %d: body presence mismatch: have %v, want %v", number, have, retained)
+		}
+		if have := HasReceipts(chainDb, hash, number); have != retained {
+			t.Errorf("block %d: receipts presence mismatch: have %v, want %v", number, have, retained)
+		}
+		for _, tx := range block.Transactions() {
+			if have := ReadTxLookupEntry(chainDb, tx.Hash()) != nil; have != retained {
+				t.Errorf("block %d: tx index presence mismatch: have %v, want %v", number, have, retained)
+			}
+		}
+	}
+	if tail := ReadHistoryPruneTail(chainDb); tail == nil || *tail != 8 {
+		t.Fatalf("history prune tail mismatch: have %v, want %d", tail, 8)
+	}
+	// Ensure the pruned blocks are skipped when iterating the transactions
+	var numbers []int
+	for h := range iterateTransactions(chainDb, 0, 11, false, nil, nil) {
+		numbers = append(numbers, int(h.number))
+	}
+	sort.Ints(numbers)
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("iterated blocks mismatch: have %v, want %v", numbers, want)
+	}
+}
@@ -432,9 +432,58 @@ func (s *stat) Count() string {
 	return s.count.String()
 }
 
+// stat converts the accumulated statistic into its exported form.
+func (s *stat) stat(database, category string) DatabaseStat {
+	return DatabaseStat{
+		Database: database,
+		Category: category,
+		Size:     s.size,
+		Items:    uint64(s.count),
+	}
+}
+
+// DatabaseStat is the aggregated size of a single category of data within a
+// database, as reported by InspectDatabaseStats.
+type DatabaseStat struct {
+	Database string             `json:"database"`
+	Category string             `json:"category"`
+	Size     common.StorageSize `json:"size"`
+	Items    uint64             `json:"items"`
+}
+
+// DatabaseStats is the machine readable result of a database inspection.
+type DatabaseStats struct {
+	Stats       []DatabaseStat     `json:"stats"`
+	Total       common.StorageSize `json:"total"`
+	Unaccounted DatabaseStat       `json:"unaccounted"`
+}
+
 // InspectDatabase traverses the entire database and checks the size
 // of all different categories of data.
 func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
+	stats, err := InspectDatabaseStats(db, keyPrefix, keyStart)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(stats.Stats))
+	for _, stat := range stats.Stats {
+		rows = append(rows, []string{stat.Database, stat.Category, stat.Size.String(), fmt.Sprintf("%d", stat.Items)})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
+	table.SetFooter([]string{"", "Total", stats.Total.String(), " "})
+	table.AppendBulk(rows)
+	table.Render()
+
+	if stats.Unaccounted.Size > 0 {
+		log.Error("Database contains unaccounted data", "size", stats.Unaccounted.Size, "count", stats.Unaccounted.Items)
+	}
+	return nil
+}
+
+// InspectDatabaseStats traverses the entire database and returns the size of
+// all different categories of data in a machine readable form.
+func InspectDatabaseStats(db ethdb.Database, keyPrefix, keyStart []byte) (*DatabaseStats, error) {
 	it := db.NewIterator(keyPrefix, keyStart)
 	defer it.Release()
 
@@ -447,12 +496,14 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		headers         stat
 		bodies          stat
 		receipts        stat
+		stateDiffs      stat
 		tds             stat
 		numHashPairings stat
 		hashNumPairings stat
 		tries           stat
 		codes           stat
 		txLookups       stat
+		nonceLookups    stat
 		accountSnaps    stat
 		storageSnaps    stat
 		preimages       stat
@@ -485,6 +536,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bodies.Add(size)
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
 			receipts.Add(size)
+		case bytes.HasPrefix(key, stateDiffsPrefix) && len(key) == (len(stateDiffsPrefix)+8+common.HashLength):
+			stateDiffs.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
 			tds.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
@@ -497,6 +550,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			codes.Add(size)
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txLookups.Add(size)
+		case bytes.HasPrefix(key, senderNonceLookupPrefix) && len(key) == (len(senderNonceLookupPrefix)+common.AddressLength+8):
+			nonceLookups.Add(size)
 		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
 			accountSnaps.Add(size)
 		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
@@ -523,13 +578,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bytes.HasPrefix(key, BloomTrieIndexPrefix) ||
 			bytes.HasPrefix(key, BloomTriePrefix): // Bloomtrie sub
 			bloomTrieNodes.Add(size)
+		case bytes.HasPrefix(key, ancientDictPrefix) && len(key) == len(ancientDictPrefix)+4:
+			metadata.Add(size)
 		default:
 			var accounted bool
 			for _, meta := range [][]byte{
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, headFinalizedBlockKey,
 				lastPivotKey, fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
+				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey, stateRetentionKey,
+				ancientCodecKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -547,53 +605,50 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			logged = time.Now()
 		}
 	}
-	// Display the database statistic of key-value store.
-	stats := [][]string{
-		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
-		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
-		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
-		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
-		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
-		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
-		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
-		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
-		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
-		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
-		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
-		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
-		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
-		{"Key-Value store", "Beacon sync headers", beaconHeaders.Size(), beaconHeaders.Count()},
-		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
-		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
-		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
-		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
+	// Assemble the database statistic of key-value store.
+	stats := []DatabaseStat{
+		headers.stat("Key-Value store", "Headers"),
+		bodies.stat("Key-Value store", "Bodies"),
+		receipts.stat("Key-Value store", "Receipt lists"),
+		stateDiffs.stat("Key-Value store", "State diffs"),
+		tds.stat("Key-Value store", "Difficulties"),
+		numHashPairings.stat("Key-Value store", "Block number->hash"),
+		hashNumPairings.stat("Key-Value store", "Block hash->number"),
+		txLookups.stat("Key-Value store", "Transaction index"),
+		nonceLookups.stat("Key-Value store", "Sender nonce index"),
+		bloomBits.stat("Key-Value store", "Bloombit index"),
+		codes.stat("Key-Value store", "Contract codes"),
+		tries.stat("Key-Value store", "Trie nodes"),
+		preimages.stat("Key-Value store", "Trie preimages"),
+		accountSnaps.stat("Key-Value store", "Account snapshot"),
+		storageSnaps.stat("Key-Value store", "Storage snapshot"),
+		beaconHeaders.stat("Key-Value store", "Beacon sync headers"),
+		cliqueSnaps.stat("Key-Value store", "Clique snapshots"),
+		metadata.stat("Key-Value store", "Singleton metadata"),
+		chtTrieNodes.stat("Light client", "CHT trie nodes"),
+		bloomTrieNodes.stat("Light client", "Bloom trie nodes"),
 	}
 	// Inspect all registered append-only file store then.
 	ancients, err := inspectFreezers(db)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, ancient := range ancients {
 		for _, table := range ancient.sizes {
-			stats = append(stats, []string{
-				fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
-				strings.Title(table.name),
-				table.size.String(),
-				fmt.Sprintf("%d", ancient.count()),
+			stats = append(stats, DatabaseStat{
+				Database: fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
+				Category: strings.Title(table.name),
+				Size:     table.size,
+				Items:    ancient.count(),
 			})
 		}
 		total += ancient.size()
 	}
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
-	table.SetFooter([]string{"", "Total", total.String(), " "})
-	table.AppendBulk(stats)
-	table.Render()
-
-	if unaccounted.size > 0 {
-		log.Error("Database contains unaccounted data", "size", unaccounted.size, "count", unaccounted.count)
-	}
-	return nil
+	return &DatabaseStats{
+		Stats:       stats,
+		Total:       total,
+		Unaccounted: unaccounted.stat("Key-Value store", "Unaccounted"),
+	}, nil
 }
 
 // printChainMetadata prints out chain metadata to stderr.
@@ -0,0 +1,277 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/bitutil"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// TxLookupIndex is the name of the transaction hash -> block index.
+	TxLookupIndex = "txlookup"
+
+	// BloomBitsIndex is the name of the rotated bloom bits index used for log
+	// filtering.
+	BloomBitsIndex = "bloombits"
+)
+
+var errRebuildAborted = errors.New("index rebuild aborted")
+
+// IndexRebuildProgress is a snapshot of the state of an index rebuild.
+type IndexRebuildProgress struct {
+	Index     string        // Name of the index being rebuilt
+	From      uint64        // First block of the range (included)
+	To        uint64        // Last block of the range (excluded)
+	Processed uint64        // Number of blocks processed so far
+	Running   bool          // Whether the rebuild is still in progress
+	Aborted   bool          // Whether the rebuild was stopped before completion
+	Err       error         // Failure encountered during the rebuild, if any
+	Elapsed   time.Duration // Time spent rebuilding
+}
+
+// IndexRebuild is a task regenerating the txlookup or the bloombits index for a
+// range of the canonical chain.
+type IndexRebuild struct {
+	db          ethdb.Database
+	index       string
+	from, to    uint64
+	sectionSize uint64                                 // Bloom bits section size, unused for txlookup
+	onSection   func(section uint64, head common.Hash) // Callback for rewritten bloom bits sections
+
+	processed atomic.Uint64
+	start     time.Time
+	end       time.Time
+	err       error
+	lock      sync.Mutex
+
+	started atomic.Bool // Whether the rebuild was run (or stopped before running)
+	quit    chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+}
+
+// NewIndexRebuild creates a rebuild of the given index for the canonical blocks
+// [from, to), which is executed by calling Run.
+//
+// For the txlookup index, the index tail is only moved if the rebuilt range is
+// adjacent to the already indexed section of the chain, so that indexing a range
+// far below the tail does not get undone by the transaction unindexer. The tail
+// is not synchronised with other writers, so the rebuild must not run together
+// with the transaction indexer of the chain.
+//
+// The bloombits index is regenerated in full sections of sectionSize blocks, so
+// the range is widened to the section boundaries. Sections which are not yet
+// complete in the canonical chain are skipped. The optional onSection callback
+// is invoked with the number and head of every rewritten section, allowing the
+// bloom bits indexer to start serving them.
+func NewIndexRebuild(db ethdb.Database, index string, from, to uint64, sectionSize uint64, onSection func(section uint64, head common.Hash)) (*IndexRebuild, error) {
+	if from >= to {
+		return nil, fmt.Errorf("empty block range [%d, %d)", from, to)
+	}
+	switch index {
+	case TxLookupIndex:
+	case BloomBitsIndex:
+		if sectionSize == 0 {
+			return nil, errors.New("zero bloom bits section size")
+		}
+		from = from / sectionSize * sectionSize
+		to = (to + sectionSize - 1) / sectionSize * sectionSize
+	default:
+		return nil, fmt.Errorf("unknown index %q", index)
+	}
+	r := &IndexRebuild{
+		db:          db,
+		index:       index,
+		from:        from,
+		to:          to,
+		sectionSize: sectionSize,
+		onSection:   onSection,
+		start:       time.Now(),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	return r, nil
+}
+
+// Run executes the rebuild on the calling goroutine. It does nothing if the
+// rebuild was already run or stopped.
+func (r *IndexRebuild) Run() {
+	if !r.started.CompareAndSwap(false, true) {
+		return
+	}
+	defer close(r.done)
+
+	log.Info("Rebuilding chain index", "index", r.index, "from", r.from, "to", r.to)
+	var err error
+	switch r.index {
+	case TxLookupIndex:
+		r.rebuildTxLookup()
+	case BloomBitsIndex:
+		err = r.rebuildBloomBits()
+	}
+	r.lock.Lock()
+	r.end, r.err = time.Now(), err
+	r.lock.Unlock()
+
+	switch {
+	case errors.Is(err, errRebuildAborted) || r.aborted():
+		log.Info("Chain index rebuild aborted", "index", r.index, "processed", r.processed.Load(), "elapsed", common.PrettyDuration(time.Since(r.start)))
+	case err != nil:
+		log.Error("Chain index rebuild failed", "index", r.index, "err", err)
+	default:
+		log.Info("Rebuilt chain index", "index", r.index, "processed", r.processed.Load(), "elapsed", common.PrettyDuration(time.Since(r.start)))
+	}
+}
+
+// rebuildTxLookup reindexes the transactions of the range, preserving the index
+// tail unless the range extends the indexed section.
+func (r *IndexRebuild) rebuildTxLookup() {
+	prev := ReadTxIndexTail(r.db)
+	indexTransactions(r.db, r.from, r.to, nil, r.quit, func(uint64) bool {
+		r.processed.Add(1)
+		return true
+	})
+	tail := ReadTxIndexTail(r.db)
+	switch {
+	case prev == nil:
+		// Nothing was indexed before, a partial range must not claim that all
+		// blocks above it are indexed.
+		if err := r.db.Delete(txIndexTailKey); err != nil {
+			log.Crit("Failed to delete transaction index tail", "err", err)
+		}
+	case *prev > r.to || (tail != nil && *tail > *prev):
+		WriteTxIndexTail(r.db, *prev)
+	}
+}
+
+// rebuildBloomBits regenerates the bloom bits of all complete sections in the
+// range.
+func (r *IndexRebuild) rebuildBloomBits() error {
+	for section := r.from / r.sectionSize; section < r.to/r.sectionSize; section++ {
+		// Skip sections which are not complete yet
+		last := (section+1)*r.sectionSize - 1
+		head := ReadCanonicalHash(r.db, last)
+		if head == (common.Hash{}) {
+			break
+		}
+		gen, err := bloombits.NewGenerator(uint(r.sectionSize))
+		if err != nil {
+			return err
+		}
+		for number := section * r.sectionSize; number <= last; number++ {
+			if r.aborted() {
+				return errRebuildAborted
+			}
+			hash := ReadCanonicalHash(r.db, number)
+			header := ReadHeader(r.db, hash, number)
+			if header == nil {
+				return fmt.Errorf("missing header #%d [%x]", number, hash)
+			}
+			if err := gen.AddBloom(uint(number-section*r.sectionSize), header.Bloom); err != nil {
+				return err
+			}
+			r.processed.Add(1)
+		}
+		batch := r.db.NewBatchWithSize((int(r.sectionSize) / 8) * types.BloomBitLength)
+		for i := 0; i < types.BloomBitLength; i++ {
+			bits, err := gen.Bitset(uint(i))
+			if err != nil {
+				return err
+			}
+			WriteBloomBits(batch, uint(i), section, head, bitutil.CompressBytes(bits))
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		if r.onSection != nil {
+			r.onSection(section, head)
+		}
+	}
+	return nil
+}
+
+func (r *IndexRebuild) aborted() bool {
+	select {
+	case <-r.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop aborts the rebuild and waits for it to terminate. Any progress made so
+// far is kept. A rebuild stopped before running is never run.
+func (r *IndexRebuild) Stop() {
+	r.stop.Do(func() { close(r.quit) })
+	if r.started.CompareAndSwap(false, true) {
+		r.lock.Lock()
+		r.end = time.Now()
+		r.lock.Unlock()
+		close(r.done)
+	}
+	<-r.done
+}
+
+// Wait blocks until the rebuild finishes and returns the failure, if any.
+func (r *IndexRebuild) Wait() error {
+	<-r.done
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// Progress returns the current state of the rebuild.
+func (r *IndexRebuild) Progress() IndexRebuildProgress {
+	var running bool
+	select {
+	case <-r.done:
+	default:
+		running = true
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	p := IndexRebuildProgress{
+		Index:     r.index,
+		From:      r.from,
+		To:        r.to,
+		Processed: r.processed.Load(),
+		Running:   running,
+		Aborted:   r.aborted(),
+		Err:       r.err,
+	}
+	if p.Aborted && errors.Is(p.Err, errRebuildAborted) {
+		p.Err = nil
+	}
+	if running {
+		p.Elapsed = time.Since(r.start)
+	} else {
+		p.Elapsed = r.end.Sub(r.start)
+	}
+	return p
+}
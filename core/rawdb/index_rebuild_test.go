@@ -0,0 +1,147 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// startIndexRebuild creates an index rebuild and runs it in the background.
+func startIndexRebuild(db ethdb.Database, index string, from, to uint64, sectionSize uint64, onSection func(uint64, common.Hash)) (*IndexRebuild, error) {
+	rebuild, err := NewIndexRebuild(db, index, from, to, sectionSize, onSection)
+	if err != nil {
+		return nil, err
+	}
+	go rebuild.Run()
+	return rebuild, nil
+}
+
+func newRebuildTestChain(t *testing.T) (ethdb.Database, []*types.Block) {
+	db := NewMemoryDatabase()
+	to := common.BytesToAddress([]byte{0x11})
+
+	var blocks []*types.Block
+	for i := uint64(0); i <= 10; i++ {
+		var txs []*types.Transaction
+		if i > 0 {
+			txs = append(txs, types.NewTx(&types.LegacyTx{Nonce: i, GasPrice: big.NewInt(1), Gas: 21000, To: &to}))
+		}
+		header := &types.Header{Number: new(big.Int).SetUint64(i)}
+		header.Bloom.Add(to.Bytes())
+		block := types.NewBlock(header, txs, nil, nil, newHasher())
+		WriteBlock(db, block)
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		blocks = append(blocks, block)
+	}
+	return db, blocks
+}
+
+func TestIndexRebuildTxLookup(t *testing.T) {
+	db, blocks := newRebuildTestChain(t)
+
+	rebuild, err := startIndexRebuild(db, TxLookupIndex, 3, 7, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuild.Wait(); err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	if p := rebuild.Progress(); p.Running || p.Aborted || p.Processed != 4 {
+		t.Errorf("progress mismatch: %+v", p)
+	}
+	for _, block := range blocks[1:] {
+		number := ReadTxLookupEntry(db, block.Transactions()[0].Hash())
+		if indexed := block.NumberU64() >= 3 && block.NumberU64() < 7; indexed != (number != nil) {
+			t.Errorf("block %d: index mismatch, have %v want %v", block.NumberU64(), number != nil, indexed)
+		}
+	}
+	// A partial range must not set the tail of an unindexed chain
+	if tail := ReadTxIndexTail(db); tail != nil {
+		t.Errorf("tail set to %d", *tail)
+	}
+	// Extending the indexed section moves the tail, ranges below it don't
+	WriteTxIndexTail(db, 7)
+	rebuild, _ = startIndexRebuild(db, TxLookupIndex, 1, 2, 0, nil)
+	rebuild.Wait()
+	if tail := ReadTxIndexTail(db); tail == nil || *tail != 7 {
+		t.Errorf("tail mismatch after detached range: have %v, want 7", tail)
+	}
+	rebuild, _ = startIndexRebuild(db, TxLookupIndex, 5, 8, 0, nil)
+	rebuild.Wait()
+	if tail := ReadTxIndexTail(db); tail == nil || *tail != 5 {
+		t.Errorf("tail mismatch after adjacent range: have %v, want 5", tail)
+	}
+}
+
+func TestIndexRebuildBloomBits(t *testing.T) {
+	db, blocks := newRebuildTestChain(t)
+
+	// Sections of 8 blocks, only [0, 8) is complete
+	var sections []uint64
+	rebuild, err := startIndexRebuild(db, BloomBitsIndex, 2, 11, 8, func(section uint64, head common.Hash) {
+		if head != blocks[(section+1)*8-1].Hash() {
+			t.Errorf("section %d: head mismatch", section)
+		}
+		sections = append(sections, section)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuild.Wait(); err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	if p := rebuild.Progress(); p.From != 0 || p.To != 16 || p.Processed != 8 {
+		t.Errorf("progress mismatch: %+v", p)
+	}
+	if len(sections) != 1 || sections[0] != 0 {
+		t.Errorf("reported sections mismatch: have %v, want [0]", sections)
+	}
+	if _, err := ReadBloomBits(db, 0, 0, blocks[7].Hash()); err != nil {
+		t.Errorf("missing bloom bits: %v", err)
+	}
+	it := db.NewIterator(bloomBitsKey(0, 1, common.Hash{})[:11], nil)
+	if it.Next() {
+		t.Error("incomplete section indexed")
+	}
+	it.Release()
+	if _, err := startIndexRebuild(db, "unknown", 0, 1, 4, nil); err == nil {
+		t.Error("unknown index accepted")
+	}
+}
+
+func TestIndexRebuildStopBeforeRun(t *testing.T) {
+	db, blocks := newRebuildTestChain(t)
+
+	rebuild, err := NewIndexRebuild(db, TxLookupIndex, 1, 11, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuild.Stop()
+	rebuild.Run()
+
+	if p := rebuild.Progress(); p.Running || !p.Aborted || p.Processed != 0 {
+		t.Errorf("progress mismatch: %+v", p)
+	}
+	if ReadTxLookupEntry(db, blocks[1].Transactions()[0].Hash()) != nil {
+		t.Error("stopped rebuild indexed transactions")
+	}
+}
@@ -84,6 +84,19 @@ var (
 	// transitionStatusKey tracks the eth2 transition status.
 	transitionStatusKey = []byte("eth2-transition")
 
+	// stateRetentionKey tracks the state retention policy of pruning nodes.
+	stateRetentionKey = []byte("StateRetention")
+
+	// ancientCodecKey tracks the codec applied to ancient bodies and receipts.
+	ancientCodecKey = []byte("AncientCodec")
+
+	// historyRetentionKey tracks the block history retention policy of nodes
+	// pruning old block bodies and receipts.
+	historyRetentionKey = []byte("HistoryRetention")
+
+	// historyPruneTailKey tracks the first block not yet checked for history pruning.
+	historyPruneTailKey = []byte("HistoryPruneTail")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -92,6 +105,7 @@ var (
 
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	stateDiffsPrefix    = []byte("D") // stateDiffsPrefix + num (uint64 big endian) + hash -> per-transaction state diffs
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
@@ -100,6 +114,8 @@ var (
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
 
+	senderNonceLookupPrefix = []byte("N") // senderNonceLookupPrefix + sender + nonce (uint64 big endian) -> transaction hash
+
 	// Path-based trie node scheme.
 	trieNodeAccountPrefix = []byte("A") // trieNodeAccountPrefix + hexPath -> trie node
 	trieNodeStoragePrefix = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
@@ -121,6 +137,8 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	ancientDictPrefix = []byte("ancient-dict-") // ancientDictPrefix + dict id (uint32 big endian) -> zstd dictionary
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
@@ -175,11 +193,21 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// stateDiffsKey = stateDiffsPrefix + num (uint64 big endian) + hash
+func stateDiffsKey(number uint64, hash common.Hash) []byte {
+	return append(append(stateDiffsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// senderNonceLookupKey = senderNonceLookupPrefix + sender + nonce (uint64 big endian)
+func senderNonceLookupKey(sender common.Address, nonce uint64) []byte {
+	return append(append(senderNonceLookupPrefix, sender.Bytes()...), encodeBlockNumber(nonce)...)
+}
+
 // accountSnapshotKey = SnapshotAccountPrefix + hash
 func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
@@ -248,3 +276,11 @@ func accountTrieNodeKey(path []byte) []byte {
 func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
 	return append(append(trieNodeStoragePrefix, accountHash.Bytes()...), path...)
 }
+
+// ancientDictKey = ancientDictPrefix + dict id (uint32 big endian)
+func ancientDictKey(id uint32) []byte {
+	key := make([]byte, len(ancientDictPrefix)+4)
+	copy(key, ancientDictPrefix)
+	binary.BigEndian.PutUint32(key[len(ancientDictPrefix):], id)
+	return key
+}
@@ -211,7 +211,11 @@ func (ch codeChange) dirtied() *common.Address {
 }
 
 func (ch storageChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
+	obj := s.getStateObject(*ch.account)
+	if s.watching(*ch.account, ch.key) {
+		s.watch(WatchRevert, *ch.account, ch.key, obj.GetState(s.db, ch.key), ch.prevalue)
+	}
+	obj.setState(ch.key, ch.prevalue)
 }
 
 func (ch storageChange) dirtied() *common.Address {
@@ -29,8 +29,10 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -327,6 +329,11 @@ func (p *Pruner) Prune(root common.Hash) error {
 	if err := extractGenesis(p.db, p.stateBloom); err != nil {
 		return err
 	}
+	// Traverse the historical states retained by the node, put their entries
+	// into the bloom filter too.
+	if err := extractRetained(p.db, p.chainHeader.Number.Uint64(), p.stateBloom); err != nil {
+		return err
+	}
 	filterName := bloomFilterName(p.config.Datadir, root)
 
 	log.Info("Writing state bloom to disk", "name", filterName)
@@ -418,7 +425,13 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	if genesis == nil {
 		return errors.New("missing genesis block")
 	}
-	t, err := trie.NewStateTrie(trie.StateTrieID(genesis.Root()), trie.NewDatabase(db))
+	return extractState(db, genesis.Root(), stateBloom)
+}
+
+// extractState loads the state with the given root and commits all the state
+// entries into the given bloomfilter.
+func extractState(db ethdb.Database, root common.Hash, stateBloom *stateBloom) error {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), trie.NewDatabase(db))
 	if err != nil {
 		return err
 	}
@@ -437,29 +450,97 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 			if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
 				return err
 			}
-			if acc.Root != types.EmptyRootHash {
-				id := trie.StorageTrieID(genesis.Root(), common.BytesToHash(accIter.LeafKey()), acc.Root)
-				storageTrie, err := trie.NewStateTrie(id, trie.NewDatabase(db))
+			if err := extractAccount(db, root, common.BytesToHash(accIter.LeafKey()), &acc, stateBloom); err != nil {
+				return err
+			}
+		}
+	}
+	return accIter.Error()
+}
+
+// extractAccount commits the storage trie and the code of the account into the
+// given bloomfilter.
+func extractAccount(db ethdb.Database, root common.Hash, accountHash common.Hash, acc *types.StateAccount, stateBloom *stateBloom) error {
+	if acc.Root != types.EmptyRootHash {
+		id := trie.StorageTrieID(root, accountHash, acc.Root)
+		storageTrie, err := trie.NewStateTrie(id, trie.NewDatabase(db))
+		if err != nil {
+			return err
+		}
+		storageIter := storageTrie.NodeIterator(nil)
+		for storageIter.Next(true) {
+			hash := storageIter.Hash()
+			if hash != (common.Hash{}) {
+				stateBloom.Put(hash.Bytes(), nil)
+			}
+		}
+		if storageIter.Error() != nil {
+			return storageIter.Error()
+		}
+	}
+	if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+		stateBloom.Put(acc.CodeHash, nil)
+	}
+	return nil
+}
+
+// extractRetained commits the historical state entries retained by the state
+// retention policy of the node into the given bloomfilter. Only the canonical
+// states up to the given head are considered.
+func extractRetained(db ethdb.Database, head uint64, stateBloom *stateBloom) error {
+	policy := state.ReadRetentionPolicy(db)
+	if policy == nil {
+		return nil
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		states int
+	)
+	for number := uint64(1); number <= head; number++ {
+		retainBlock := policy.RetainsBlock(number)
+		if !retainBlock && (len(policy.Addresses) == 0 || number < policy.Since) {
+			continue
+		}
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, number), number)
+		if header == nil || !rawdb.HasLegacyTrieNode(db, header.Root) {
+			continue // state was never retained, e.g. blocks were snap synced
+		}
+		if retainBlock {
+			if err := extractState(db, header.Root, stateBloom); err != nil {
+				return err
+			}
+		} else {
+			t, err := trie.NewStateTrie(trie.StateTrieID(header.Root), trie.NewDatabase(db))
+			if err != nil {
+				return err
+			}
+			for _, addr := range policy.Addresses {
+				accountHash := crypto.Keccak256Hash(addr.Bytes())
+				if err := t.Prove(accountHash.Bytes(), 0, stateBloom); err != nil {
+					return err
+				}
+				acc, err := t.TryGetAccount(addr)
 				if err != nil {
 					return err
 				}
-				storageIter := storageTrie.NodeIterator(nil)
-				for storageIter.Next(true) {
-					hash := storageIter.Hash()
-					if hash != (common.Hash{}) {
-						stateBloom.Put(hash.Bytes(), nil)
+				if acc != nil {
+					if err := extractAccount(db, header.Root, accountHash, acc, stateBloom); err != nil {
+						return err
 					}
 				}
-				if storageIter.Error() != nil {
-					return storageIter.Error()
-				}
-			}
-			if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
-				stateBloom.Put(acc.CodeHash, nil)
 			}
 		}
+		states++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Marking retained state", "number", number, "head", head, "states", states, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
 	}
-	return accIter.Error()
+	if states > 0 {
+		log.Info("Marked retained state", "states", states, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
 }
 
 func bloomFilterName(datadir string, hash common.Hash) string {
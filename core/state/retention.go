@@ -0,0 +1,182 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// BlockRange is an inclusive range of block numbers.
+type BlockRange struct {
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+}
+
+// Contains reports whether the number is within the range.
+func (r BlockRange) Contains(number uint64) bool {
+	return number >= r.First && number <= r.Last
+}
+
+// String implements fmt.Stringer.
+func (r BlockRange) String() string {
+	if r.First == r.Last {
+		return strconv.FormatUint(r.First, 10)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// ParseBlockRanges parses a comma separated list of block numbers and inclusive
+// ranges, e.g. "1000-2000,4711".
+func ParseBlockRanges(input string) ([]BlockRange, error) {
+	var ranges []BlockRange
+	for _, field := range strings.Split(input, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(field, "-")
+		from, err := strconv.ParseUint(strings.TrimSpace(first), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block range %q", field)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.ParseUint(strings.TrimSpace(last), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid block range %q", field)
+			}
+		}
+		if to < from {
+			return nil, fmt.Errorf("invalid block range %q: end before start", field)
+		}
+		ranges = append(ranges, BlockRange{First: from, Last: to})
+	}
+	return ranges, nil
+}
+
+// RetentionPolicy selects the historical state a pruning node keeps on top of
+// the recent state, turning it into a partial archive node:
+//
+//   - The full state of every block within one of the Ranges is persisted.
+//   - For every other block, only the state of the Addresses is persisted,
+//     i.e. their account trie paths and storage tries. Reading other accounts
+//     of such a state fails with a missing trie node error.
+//
+// Retained state is exempt from offline pruning.
+type RetentionPolicy struct {
+	Ranges    []BlockRange     `json:"ranges"`
+	Addresses []common.Address `json:"addresses"`
+
+	// Since is the first block from which the state of the addresses has been
+	// retained. It is maintained by the chain and reset if the addresses change.
+	Since uint64 `json:"since"`
+}
+
+// RetainsBlock reports whether the full state of the block is retained.
+func (p *RetentionPolicy) RetainsBlock(number uint64) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Ranges {
+		if r.Contains(number) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetainsAccount reports whether the state of the account at the block is
+// retained.
+func (p *RetentionPolicy) RetainsAccount(number uint64, addr common.Address) bool {
+	if p.RetainsBlock(number) {
+		return true
+	}
+	if p == nil || number < p.Since {
+		return false
+	}
+	for _, a := range p.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// SameAddresses reports whether both policies retain the same set of accounts.
+func (p *RetentionPolicy) SameAddresses(other *RetentionPolicy) bool {
+	if len(p.Addresses) != len(other.Addresses) {
+		return false
+	}
+	set := make(map[common.Address]struct{}, len(p.Addresses))
+	for _, addr := range p.Addresses {
+		set[addr] = struct{}{}
+	}
+	for _, addr := range other.Addresses {
+		if _, ok := set[addr]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitize sorts the ranges and removes duplicate addresses.
+func (p *RetentionPolicy) sanitize() {
+	sort.Slice(p.Ranges, func(i, j int) bool { return p.Ranges[i].First < p.Ranges[j].First })
+
+	seen := make(map[common.Address]struct{})
+	addrs := p.Addresses[:0]
+	for _, addr := range p.Addresses {
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = struct{}{}
+			addrs = append(addrs, addr)
+		}
+	}
+	p.Addresses = addrs
+}
+
+// ReadRetentionPolicy retrieves the retention policy the state in the database
+// was written with, or nil if none was configured.
+func ReadRetentionPolicy(db ethdb.KeyValueReader) *RetentionPolicy {
+	data := rawdb.ReadStateRetention(db)
+	if len(data) == 0 {
+		return nil
+	}
+	policy := new(RetentionPolicy)
+	if err := json.Unmarshal(data, policy); err != nil {
+		log.Error("Invalid state retention policy", "err", err)
+		return nil
+	}
+	return policy
+}
+
+// WriteRetentionPolicy stores the retention policy in the database.
+func WriteRetentionPolicy(db ethdb.KeyValueWriter, policy *RetentionPolicy) {
+	policy.sanitize()
+	data, err := json.Marshal(policy)
+	if err != nil {
+		log.Crit("Failed to encode state retention policy", "err", err)
+	}
+	rawdb.WriteStateRetention(db, data)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestParseBlockRanges(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []BlockRange
+		fail  bool
+	}{
+		{input: "", want: nil},
+		{input: "5", want: []BlockRange{{5, 5}}},
+		{input: "1000-2000, 4711", want: []BlockRange{{1000, 2000}, {4711, 4711}}},
+		{input: "10 - 20,", want: []BlockRange{{10, 20}}},
+		{input: "20-10", fail: true},
+		{input: "a-b", fail: true},
+		{input: "1-", fail: true},
+	}
+	for _, tt := range tests {
+		have, err := ParseBlockRanges(tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("input %q: expected error, have %v", tt.input, have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("input %q: unexpected error: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("input %q: have %v, want %v", tt.input, have, tt.want)
+		}
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	var (
+		retained = common.Address{0x01}
+		other    = common.Address{0x02}
+		policy   = &RetentionPolicy{
+			Ranges:    []BlockRange{{100, 200}, {10, 10}},
+			Addresses: []common.Address{retained, retained},
+			Since:     50,
+		}
+	)
+	tests := []struct {
+		number  uint64
+		addr    common.Address
+		block   bool
+		account bool
+	}{
+		{number: 10, addr: other, block: true, account: true},
+		{number: 11, addr: retained, block: false, account: false},
+		{number: 60, addr: retained, block: false, account: true},
+		{number: 60, addr: other, block: false, account: false},
+		{number: 150, addr: other, block: true, account: true},
+		{number: 201, addr: other, block: false, account: false},
+	}
+	for _, tt := range tests {
+		if have := policy.RetainsBlock(tt.number); have != tt.block {
+			t.Errorf("block %d: have retained %v, want %v", tt.number, have, tt.block)
+		}
+		if have := policy.RetainsAccount(tt.number, tt.addr); have != tt.account {
+			t.Errorf("block %d account %x: have retained %v, want %v", tt.number, tt.addr, have, tt.account)
+		}
+	}
+	var none *RetentionPolicy
+	if none.RetainsBlock(10) || none.RetainsAccount(10, retained) {
+		t.Error("nil policy retains state")
+	}
+	// Check that the policy is sanitized and survives a database roundtrip
+	db := rawdb.NewMemoryDatabase()
+	if ReadRetentionPolicy(db) != nil {
+		t.Fatal("retention policy found in empty database")
+	}
+	WriteRetentionPolicy(db, policy)
+	stored := ReadRetentionPolicy(db)
+	want := &RetentionPolicy{
+		Ranges:    []BlockRange{{10, 10}, {100, 200}},
+		Addresses: []common.Address{retained},
+		Since:     50,
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("stored policy mismatch: have %+v, want %+v", stored, want)
+	}
+	if !stored.SameAddresses(policy) || stored.SameAddresses(&RetentionPolicy{Addresses: []common.Address{other}}) {
+		t.Error("address set comparison mismatch")
+	}
+}
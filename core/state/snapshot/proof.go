@@ -0,0 +1,176 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// RangeProof is a contiguous range of trie leaves retrieved from the snapshot,
+// along with the Merkle proofs of its boundaries against the trie root. It is
+// the same construct the snap protocol uses to transfer state.
+type RangeProof struct {
+	Root   common.Hash   // Root of the trie the range is proven against
+	Origin common.Hash   // Key the range starts at, proven by the first edge proof
+	Keys   []common.Hash // Hashed keys of the leaves in the range
+	Values [][]byte      // Trie values of the leaves: consensus encoded accounts or RLP encoded slots
+	Proof  [][]byte      // Trie nodes proving the origin and the last key
+}
+
+// Verify checks that the range is a valid range of the trie with the given root,
+// usually p.Root as obtained from a trusted source, returning whether the trie
+// contains more leaves after the range.
+func (p *RangeProof) Verify(root common.Hash) (bool, error) {
+	// Empty tries have no proof nodes, in which case the range must be the full trie
+	var proof ethdb.KeyValueReader
+	if len(p.Proof) > 0 {
+		db := memorydb.New()
+		for _, node := range p.Proof {
+			db.Put(crypto.Keccak256(node), node)
+		}
+		proof = db
+	}
+	var (
+		keys = make([][]byte, len(p.Keys))
+		last = p.Origin
+	)
+	for i, key := range p.Keys {
+		keys[i] = common.CopyBytes(key[:])
+		last = key
+	}
+	return trie.VerifyRangeProof(root, p.Origin[:], last[:], keys, p.Values, proof)
+}
+
+// AccountRange retrieves the accounts of the state with the given root, starting
+// at the origin hash, and proves the range against the state root. Accounts are
+// collected until the first one at or beyond the limit hash is included, or the
+// maximum number of accounts is reached. Zero max means no maximum.
+//
+// The accounts are returned in consensus encoding, so the proof can be verified
+// without any snapshot specific knowledge.
+func (t *Tree) AccountRange(root common.Hash, origin, limit common.Hash, max int) (*RangeProof, error) {
+	tr, err := trie.New(trie.StateTrieID(root), t.triedb)
+	if err != nil {
+		return nil, err
+	}
+	it, err := t.AccountIterator(root, origin)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	result := &RangeProof{Root: root, Origin: origin}
+	for it.Next() {
+		account, err := FullAccountRLP(it.Account())
+		if err != nil {
+			return nil, err
+		}
+		hash := it.Hash()
+		result.Keys = append(result.Keys, hash)
+		result.Values = append(result.Values, account)
+
+		if bytes.Compare(hash[:], limit[:]) >= 0 || len(result.Keys) == max {
+			break
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if err := proveRange(tr, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StorageRange retrieves the storage slots of an account in the state with the
+// given root, starting at the origin hash, and proves the range against the
+// storage root of the account. Slots are collected until the first one at or
+// beyond the limit hash is included, or the maximum number of slots is reached.
+// Zero max means no maximum.
+func (t *Tree) StorageRange(root common.Hash, account common.Hash, origin, limit common.Hash, max int) (*RangeProof, error) {
+	snap := t.Snapshot(root)
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	acc, err := snap.Account(account)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account %#x missing", account)
+	}
+	storageRoot := common.BytesToHash(acc.Root)
+	if len(acc.Root) == 0 {
+		storageRoot = types.EmptyRootHash
+	}
+	tr, err := trie.New(trie.StorageTrieID(root, account, storageRoot), t.triedb)
+	if err != nil {
+		return nil, err
+	}
+	it, err := t.StorageIterator(root, account, origin)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	result := &RangeProof{Root: storageRoot, Origin: origin}
+	for it.Next() {
+		hash := it.Hash()
+		result.Keys = append(result.Keys, hash)
+		result.Values = append(result.Values, common.CopyBytes(it.Slot()))
+
+		if bytes.Compare(hash[:], limit[:]) >= 0 || len(result.Keys) == max {
+			break
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if err := proveRange(tr, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// proveRange adds the Merkle proofs of the origin and the last key of the range
+// to it.
+func proveRange(tr *trie.Trie, result *RangeProof) error {
+	proof := memorydb.New()
+	if err := tr.Prove(result.Origin[:], 0, proof); err != nil {
+		return err
+	}
+	if n := len(result.Keys); n > 0 {
+		if err := tr.Prove(result.Keys[n-1][:], 0, proof); err != nil {
+			return err
+		}
+	}
+	it := proof.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		result.Proof = append(result.Proof, common.CopyBytes(it.Value()))
+	}
+	return it.Error()
+}
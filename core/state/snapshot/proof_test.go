@@ -0,0 +1,132 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that range proofs generated from the snapshot verify against the tries.
+func TestRangeProofs(t *testing.T) {
+	var (
+		helper = newHelper()
+		keys   []string
+		vals   []string
+	)
+	for i := 0; i < 16; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+		vals = append(vals, fmt.Sprintf("val-%d", i))
+	}
+	stRoot := helper.makeStorageTrie(common.Hash{}, hashData([]byte("acc-0")), keys, vals, true)
+	for i := 0; i < 16; i++ {
+		acc := &Account{Balance: big.NewInt(int64(i)), Root: types.EmptyRootHash.Bytes(), CodeHash: types.EmptyCodeHash.Bytes()}
+		if i == 0 {
+			acc.Root = stRoot
+		}
+		helper.addTrieAccount(fmt.Sprintf("acc-%d", i), acc)
+	}
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatal("snapshot generation failed")
+	}
+	snaps := &Tree{diskdb: helper.diskdb, triedb: helper.triedb, layers: map[common.Hash]snapshot{root: snap}}
+
+	// Walk the account range in chunks, checking every chunk against the state root
+	var (
+		origin common.Hash
+		total  int
+	)
+	for {
+		proof, err := snaps.AccountRange(root, origin, rangeProofLimit, 5)
+		if err != nil {
+			t.Fatalf("failed to retrieve account range: %v", err)
+		}
+		if proof.Root != root {
+			t.Fatalf("proof root mismatch: have %x, want %x", proof.Root, root)
+		}
+		more, err := proof.Verify(root)
+		if err != nil {
+			t.Fatalf("account range from %x failed to verify: %v", origin, err)
+		}
+		total += len(proof.Keys)
+		if !more {
+			break
+		}
+		origin = incHash(proof.Keys[len(proof.Keys)-1])
+	}
+	if total != 16 {
+		t.Fatalf("account count mismatch: have %d, want 16", total)
+	}
+	// A range capped by a limit must stop at the first account beyond it
+	all, _ := snaps.AccountRange(root, common.Hash{}, rangeProofLimit, 0)
+	limited, err := snaps.AccountRange(root, common.Hash{}, all.Keys[3], 0)
+	if err != nil {
+		t.Fatalf("failed to retrieve limited account range: %v", err)
+	}
+	if len(limited.Keys) != 4 {
+		t.Fatalf("limited range length mismatch: have %d, want 4", len(limited.Keys))
+	}
+	if more, err := limited.Verify(root); err != nil || !more {
+		t.Fatalf("limited range verification mismatch: have more %v err %v, want more true", more, err)
+	}
+	// Tampered ranges must be rejected
+	limited.Values[1] = limited.Values[2]
+	if _, err := limited.Verify(root); err == nil {
+		t.Fatal("tampered account range verified")
+	}
+	// The storage range must verify against the storage root of the account
+	proof, err := snaps.StorageRange(root, hashData([]byte("acc-0")), common.Hash{1}, rangeProofLimit, 0)
+	if err != nil {
+		t.Fatalf("failed to retrieve storage range: %v", err)
+	}
+	if proof.Root != common.BytesToHash(stRoot) {
+		t.Fatalf("storage proof root mismatch: have %x, want %x", proof.Root, stRoot)
+	}
+	if more, err := proof.Verify(proof.Root); err != nil || more {
+		t.Fatalf("storage range verification mismatch: have more %v err %v, want more false", more, err)
+	}
+	// Accounts without storage have empty, but provable, storage ranges
+	proof, err = snaps.StorageRange(root, hashData([]byte("acc-1")), common.Hash{}, rangeProofLimit, 0)
+	if err != nil {
+		t.Fatalf("failed to retrieve empty storage range: %v", err)
+	}
+	if len(proof.Keys) != 0 || proof.Root != types.EmptyRootHash {
+		t.Fatalf("empty storage range mismatch: %d keys, root %x", len(proof.Keys), proof.Root)
+	}
+	if more, err := proof.Verify(proof.Root); err != nil || more {
+		t.Fatalf("empty storage range verification mismatch: have more %v err %v, want more false", more, err)
+	}
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+}
+
+// rangeProofLimit is the limit covering the whole key space.
+var rangeProofLimit = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// incHash returns the hash following h.
+func incHash(h common.Hash) common.Hash {
+	return common.BytesToHash(incKey(common.CopyBytes(h[:])))
+}
@@ -18,6 +18,7 @@
 package state
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -103,6 +104,13 @@ type StateDB struct {
 	// Transient storage
 	transientStorage transientStorage
 
+	// Per-transaction state diffs, only recorded if explicitly enabled
+	diffsEnabled bool
+	diffs        []*StateDiff
+
+	// Watched storage slots whose accesses are reported, if any
+	watchpoints *Watchpoints
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -276,6 +284,47 @@ func (s *StateDB) GetBalance(addr common.Address) *big.Int {
 	return common.Big0
 }
 
+// GetBalances retrieves the balances of the given accounts, 0 for non-existent
+// ones. Unlike GetBalance, the accounts are not loaded into the live object set.
+// They are read in the order of their hashes, so consecutive lookups share the
+// trie nodes and snapshot layers already resolved by their neighbours.
+func (s *StateDB) GetBalances(addrs []common.Address) []*big.Int {
+	type lookup struct {
+		index int
+		hash  common.Hash
+	}
+	var (
+		balances = make([]*big.Int, len(addrs))
+		lookups  = make([]lookup, 0, len(addrs))
+	)
+	for i, addr := range addrs {
+		if obj := s.stateObjects[addr]; obj != nil {
+			balances[i] = common.Big0
+			if !obj.deleted {
+				balances[i] = obj.Balance()
+			}
+			continue
+		}
+		lookups = append(lookups, lookup{index: i, hash: crypto.HashData(s.hasher, addr.Bytes())})
+	}
+	sort.Slice(lookups, func(i, j int) bool {
+		return bytes.Compare(lookups[i].hash[:], lookups[j].hash[:]) < 0
+	})
+	for _, l := range lookups {
+		balances[l.index] = common.Big0
+
+		data, err := s.readAccount(addrs[l.index], l.hash)
+		if err != nil {
+			s.setError(fmt.Errorf("GetBalances (%x) error: %w", addrs[l.index].Bytes(), err))
+			continue
+		}
+		if data != nil {
+			balances[l.index] = data.Balance
+		}
+	}
+	return balances
+}
+
 func (s *StateDB) GetNonce(addr common.Address) uint64 {
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
@@ -314,13 +363,27 @@ func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 	return common.BytesToHash(stateObject.CodeHash())
 }
 
+// GetStorageRoot retrieves the storage root of the given account, or the empty
+// root hash if the account does not exist. Storage changes not yet hashed by
+// IntermediateRoot are not reflected.
+func (s *StateDB) GetStorageRoot(addr common.Address) common.Hash {
+	stateObject := s.getStateObject(addr)
+	if stateObject == nil {
+		return types.EmptyRootHash
+	}
+	return stateObject.data.Root
+}
+
 // GetState retrieves a value from the given account's storage trie.
 func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
-	stateObject := s.getStateObject(addr)
-	if stateObject != nil {
-		return stateObject.GetState(s.db, hash)
+	var value common.Hash
+	if stateObject := s.getStateObject(addr); stateObject != nil {
+		value = stateObject.GetState(s.db, hash)
 	}
-	return common.Hash{}
+	if s.watching(addr, hash) {
+		s.watch(WatchRead, addr, hash, value, value)
+	}
+	return value
 }
 
 // GetProof returns the Merkle proof for a given account.
@@ -433,6 +496,9 @@ func (s *StateDB) SetCode(addr common.Address, code []byte) {
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
+		if s.watching(addr, key) {
+			s.watch(WatchWrite, addr, key, stateObject.GetState(s.db, key), value)
+		}
 		stateObject.SetState(s.db, key, value)
 	}
 }
@@ -557,19 +623,37 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	if obj := s.stateObjects[addr]; obj != nil {
 		return obj
 	}
-	// If no live objects are available, attempt to use snapshots
-	var data *types.StateAccount
+	// If no live objects are available, load the account from the snapshot
+	// or the trie
+	data, err := s.readAccount(addr, crypto.HashData(s.hasher, addr.Bytes()))
+	if err != nil {
+		s.setError(fmt.Errorf("getDeleteStateObject (%x) error: %w", addr.Bytes(), err))
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	// Insert into the live set
+	obj := newObject(s, addr, *data)
+	s.setStateObject(obj)
+	return obj
+}
+
+// readAccount loads the account with the given address and address hash from
+// the snapshot if available, falling back to the trie. Nil is returned if the
+// account does not exist.
+func (s *StateDB) readAccount(addr common.Address, addrHash common.Hash) (*types.StateAccount, error) {
 	if s.snap != nil {
 		start := time.Now()
-		acc, err := s.snap.Account(crypto.HashData(s.hasher, addr.Bytes()))
+		acc, err := s.snap.Account(addrHash)
 		if metrics.EnabledExpensive {
 			s.SnapshotAccountReads += time.Since(start)
 		}
 		if err == nil {
 			if acc == nil {
-				return nil
+				return nil, nil
 			}
-			data = &types.StateAccount{
+			data := &types.StateAccount{
 				Nonce:    acc.Nonce,
 				Balance:  acc.Balance,
 				CodeHash: acc.CodeHash,
@@ -581,28 +665,16 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 			if data.Root == (common.Hash{}) {
 				data.Root = types.EmptyRootHash
 			}
+			return data, nil
 		}
 	}
 	// If snapshot unavailable or reading from it failed, load from the database
-	if data == nil {
-		start := time.Now()
-		var err error
-		data, err = s.trie.TryGetAccount(addr)
-		if metrics.EnabledExpensive {
-			s.AccountReads += time.Since(start)
-		}
-		if err != nil {
-			s.setError(fmt.Errorf("getDeleteStateObject (%x) error: %w", addr.Bytes(), err))
-			return nil
-		}
-		if data == nil {
-			return nil
-		}
+	start := time.Now()
+	data, err := s.trie.TryGetAccount(addr)
+	if metrics.EnabledExpensive {
+		s.AccountReads += time.Since(start)
 	}
-	// Insert into the live set
-	obj := newObject(s, addr, *data)
-	s.setStateObject(obj)
-	return obj
+	return data, err
 }
 
 func (s *StateDB) setStateObject(object *stateObject) {
@@ -711,6 +783,9 @@ func (s *StateDB) Copy() *StateDB {
 		preimages:            make(map[common.Hash][]byte, len(s.preimages)),
 		journal:              newJournal(),
 		hasher:               crypto.NewKeccakState(),
+		diffsEnabled:         s.diffsEnabled,
+		watchpoints:          s.watchpoints,
+		diffs:                append([]*StateDiff(nil), s.diffs...),
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range s.journal.dirties {
@@ -832,6 +907,9 @@ func (s *StateDB) GetRefund() uint64 {
 // the journal as well as the refunds. Finalise, however, will not push any updates
 // into the tries just yet. Only IntermediateRoot or Commit will do that.
 func (s *StateDB) Finalise(deleteEmptyObjects bool) {
+	if s.diffsEnabled {
+		s.recordStateDiff(deleteEmptyObjects)
+	}
 	addressesToPrefetch := make([][]byte, 0, len(s.journal.dirties))
 	for addr := range s.journal.dirties {
 		obj, exist := s.stateObjects[addr]
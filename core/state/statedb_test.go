@@ -31,6 +31,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -998,3 +999,51 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
 	}
 }
+
+func TestGetBalances(t *testing.T) {
+	var (
+		memdb    = rawdb.NewMemoryDatabase()
+		db       = NewDatabase(memdb)
+		state, _ = New(common.Hash{}, db, nil)
+		missing  = common.BytesToAddress([]byte("missing"))
+		addrs    = []common.Address{missing}
+		want     = []*big.Int{common.Big0}
+	)
+	for i := 1; i <= 64; i++ {
+		addr := common.BytesToAddress([]byte{byte(i), 0xff})
+		state.SetBalance(addr, big.NewInt(int64(i)))
+		addrs, want = append(addrs, addr), append(want, big.NewInt(int64(i)))
+	}
+	root, _ := state.Commit(false)
+	state.Database().TrieDB().Commit(root, false)
+
+	snaps, _ := snapshot.New(snapshot.Config{CacheSize: 16}, memdb, db.TrieDB(), root)
+	for _, snaps := range []*snapshot.Tree{nil, snaps} {
+		state, _ := New(root, db, snaps)
+
+		// Accounts modified in memory take precedence over the persisted ones
+		state.SetBalance(addrs[1], big.NewInt(100))
+		state.Suicide(addrs[2])
+		state.Finalise(true)
+
+		balances := state.GetBalances(addrs)
+		if err := state.Error(); err != nil {
+			t.Fatalf("snapshot %v: state error: %v", snaps != nil, err)
+		}
+		for i, addr := range addrs {
+			expect := want[i]
+			switch i {
+			case 1:
+				expect = big.NewInt(100)
+			case 2:
+				expect = common.Big0
+			}
+			if balances[i].Cmp(expect) != 0 {
+				t.Errorf("snapshot %v, account %x: balance mismatch: have %v, want %v", snaps != nil, addr, balances[i], expect)
+			}
+		}
+		if len(state.stateObjects) != 2 {
+			t.Errorf("snapshot %v: accounts loaded into the live set: have %d, want 2", snaps != nil, len(state.stateObjects))
+		}
+	}
+}
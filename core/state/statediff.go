@@ -0,0 +1,241 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// StateDiff is the set of state changes made by a single transaction. The diff
+// recorded after the last transaction of a block (if any) contains the changes
+// made by the consensus engine during block finalization (e.g. rewards or
+// withdrawals) and carries an empty transaction hash.
+type StateDiff struct {
+	TxHash   common.Hash    `json:"txHash"`
+	TxIndex  uint64         `json:"txIndex"`
+	Accounts []*AccountDiff `json:"accounts"` // Modified accounts, sorted by address
+}
+
+// AccountDiff is the set of changes made to a single account. Fields which were
+// not modified are left nil.
+type AccountDiff struct {
+	Address common.Address `json:"address"`
+	Created bool           `json:"created,omitempty"` // Account was created (or re-created on top of an old one)
+	Deleted bool           `json:"deleted,omitempty"` // Account was self-destructed or removed as empty
+
+	Balance *BalanceDiff `json:"balance,omitempty" rlp:"nil"`
+	Nonce   *NonceDiff   `json:"nonce,omitempty" rlp:"nil"`
+	Code    *CodeDiff    `json:"code,omitempty" rlp:"nil"`
+	Storage []SlotDiff   `json:"storage,omitempty"` // Modified storage slots, sorted by key
+}
+
+// BalanceDiff is the balance of an account before and after a transaction.
+type BalanceDiff struct {
+	From *big.Int
+	To   *big.Int
+}
+
+// MarshalJSON implements json.Marshaler, encoding the balances as hex strings.
+func (d *BalanceDiff) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		From *hexutil.Big `json:"from"`
+		To   *hexutil.Big `json:"to"`
+	}{(*hexutil.Big)(d.From), (*hexutil.Big)(d.To)})
+}
+
+// NonceDiff is the nonce of an account before and after a transaction.
+type NonceDiff struct {
+	From uint64
+	To   uint64
+}
+
+// MarshalJSON implements json.Marshaler, encoding the nonces as hex strings.
+func (d *NonceDiff) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		From hexutil.Uint64 `json:"from"`
+		To   hexutil.Uint64 `json:"to"`
+	}{hexutil.Uint64(d.From), hexutil.Uint64(d.To)})
+}
+
+// CodeDiff is the code of an account before and after a transaction.
+type CodeDiff struct {
+	From hexutil.Bytes `json:"from"`
+	To   hexutil.Bytes `json:"to"`
+}
+
+// SlotDiff is the value of a storage slot before and after a transaction.
+type SlotDiff struct {
+	Key  common.Hash `json:"key"`
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// EnableStateDiffs turns on per-transaction state diff recording. Every time
+// the state is finalised, the changes accumulated in the journal since the
+// previous finalisation are recorded as a new StateDiff.
+func (s *StateDB) EnableStateDiffs() {
+	s.diffsEnabled = true
+}
+
+// StateDiffsEnabled reports whether state diffs are being recorded.
+func (s *StateDB) StateDiffsEnabled() bool {
+	return s.diffsEnabled
+}
+
+// StateDiffs returns the state diffs recorded so far, in execution order.
+func (s *StateDB) StateDiffs() []*StateDiff {
+	return s.diffs
+}
+
+// accountDiffBuilder accumulates the pre-transaction values of an account while
+// walking the journal. Only the first journal entry of each kind holds the value
+// from before the transaction, the later ones are intermediate states.
+type accountDiffBuilder struct {
+	diff  *AccountDiff
+	fresh bool // Account did not exist before the transaction
+	slots map[common.Hash]common.Hash
+}
+
+// recordStateDiff assembles the state diff of the current transaction out of
+// the journal. It must be called before the journal is cleared by Finalise.
+func (s *StateDB) recordStateDiff(deleteEmptyObjects bool) {
+	var (
+		builders = make(map[common.Address]*accountDiffBuilder)
+		builder  = func(addr common.Address) *accountDiffBuilder {
+			b, ok := builders[addr]
+			if !ok {
+				b = &accountDiffBuilder{
+					diff:  &AccountDiff{Address: addr},
+					slots: make(map[common.Hash]common.Hash),
+				}
+				builders[addr] = b
+			}
+			return b
+		}
+		setBalance = func(b *accountDiffBuilder, prev *big.Int) {
+			if b.diff.Balance == nil {
+				b.diff.Balance = &BalanceDiff{From: new(big.Int).Set(prev)}
+			}
+		}
+	)
+	for _, entry := range s.journal.entries {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			b := builder(*ch.account)
+			b.diff.Created, b.fresh = true, true
+			setBalance(b, common.Big0)
+			if b.diff.Nonce == nil {
+				b.diff.Nonce = &NonceDiff{}
+			}
+			if b.diff.Code == nil {
+				b.diff.Code = &CodeDiff{}
+			}
+		case resetObjectChange:
+			b := builder(ch.prev.address)
+			b.diff.Created = true
+			setBalance(b, ch.prev.Balance())
+			if b.diff.Nonce == nil {
+				b.diff.Nonce = &NonceDiff{From: ch.prev.Nonce()}
+			}
+			if b.diff.Code == nil {
+				b.diff.Code = &CodeDiff{From: common.CopyBytes(ch.prev.Code(s.db))}
+			}
+		case suicideChange:
+			setBalance(builder(*ch.account), ch.prevbalance)
+		case balanceChange:
+			setBalance(builder(*ch.account), ch.prev)
+		case nonceChange:
+			if b := builder(*ch.account); b.diff.Nonce == nil {
+				b.diff.Nonce = &NonceDiff{From: ch.prev}
+			}
+		case codeChange:
+			if b := builder(*ch.account); b.diff.Code == nil {
+				b.diff.Code = &CodeDiff{From: common.CopyBytes(ch.prevcode)}
+			}
+		case storageChange:
+			b := builder(*ch.account)
+			if _, ok := b.slots[ch.key]; !ok {
+				b.slots[ch.key] = ch.prevalue
+			}
+		case touchChange:
+			// Touched empty accounts may get deleted, track them too
+			builder(*ch.account)
+		}
+	}
+	diff := &StateDiff{
+		TxHash:  s.thash,
+		TxIndex: uint64(s.txIndex),
+	}
+	for addr, b := range builders {
+		obj, exist := s.stateObjects[addr]
+		if !exist {
+			continue // ripemd special case, see Finalise
+		}
+		ad := b.diff
+		ad.Deleted = obj.suicided || (deleteEmptyObjects && obj.empty())
+		if b.fresh && ad.Deleted {
+			continue // Account created and deleted within the transaction
+		}
+
+		if ad.Balance != nil {
+			ad.Balance.To = new(big.Int).Set(obj.Balance())
+			if ad.Balance.From.Cmp(ad.Balance.To) == 0 {
+				ad.Balance = nil
+			}
+		}
+		if ad.Nonce != nil {
+			ad.Nonce.To = obj.Nonce()
+			if ad.Nonce.From == ad.Nonce.To {
+				ad.Nonce = nil
+			}
+		}
+		if ad.Code != nil {
+			if !bytes.Equal(obj.CodeHash(), types.EmptyCodeHash.Bytes()) {
+				ad.Code.To = common.CopyBytes(obj.Code(s.db))
+			}
+			if bytes.Equal(ad.Code.From, ad.Code.To) {
+				ad.Code = nil
+			}
+		}
+		for key, prev := range b.slots {
+			if value := obj.GetState(s.db, key); value != prev {
+				ad.Storage = append(ad.Storage, SlotDiff{Key: key, From: prev, To: value})
+			}
+		}
+		sort.Slice(ad.Storage, func(i, j int) bool {
+			return bytes.Compare(ad.Storage[i].Key[:], ad.Storage[j].Key[:]) < 0
+		})
+		if !ad.Created && !ad.Deleted && ad.Balance == nil && ad.Nonce == nil && ad.Code == nil && len(ad.Storage) == 0 {
+			continue
+		}
+		diff.Accounts = append(diff.Accounts, ad)
+	}
+	if len(diff.Accounts) == 0 {
+		return
+	}
+	sort.Slice(diff.Accounts, func(i, j int) bool {
+		return bytes.Compare(diff.Accounts[i].Address[:], diff.Accounts[j].Address[:]) < 0
+	})
+	s.diffs = append(s.diffs, diff)
+}
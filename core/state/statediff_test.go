@@ -0,0 +1,121 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that state diffs are recorded per transaction, contain the values from
+// before and after each transaction and ignore reverted changes.
+func TestStateDiffs(t *testing.T) {
+	var (
+		addr1 = common.Address{0x01}
+		addr2 = common.Address{0x02}
+		slot  = common.Hash{0x0a}
+	)
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetBalance(addr1, big.NewInt(100))
+	state.SetState(addr1, slot, common.Hash{0x01})
+	state.Finalise(true)
+
+	// Nothing recorded until enabled
+	if diffs := state.StateDiffs(); len(diffs) != 0 {
+		t.Fatalf("unexpected diffs before enabling: %d", len(diffs))
+	}
+	state.EnableStateDiffs()
+
+	// First transaction: transfer with an intermediate storage change
+	state.SetTxContext(common.Hash{0xaa}, 0)
+	state.SubBalance(addr1, big.NewInt(10))
+	state.AddBalance(addr2, big.NewInt(10))
+	state.SetNonce(addr1, 1)
+	state.SetState(addr1, slot, common.Hash{0x02})
+	state.SetState(addr1, slot, common.Hash{0x03})
+
+	// Reverted changes must not show up
+	snap := state.Snapshot()
+	state.SetState(addr1, common.Hash{0x0b}, common.Hash{0x01})
+	state.RevertToSnapshot(snap)
+	state.Finalise(true)
+
+	// Second transaction: no-op storage write, balance round trip
+	state.SetTxContext(common.Hash{0xbb}, 1)
+	state.SetState(addr1, slot, common.Hash{0x03})
+	state.AddBalance(addr2, big.NewInt(1))
+	state.SubBalance(addr2, big.NewInt(1))
+	state.SetNonce(addr1, 2)
+	state.Finalise(true)
+
+	// Finalisation without changes must not add an entry
+	state.Finalise(true)
+
+	diffs := state.StateDiffs()
+	if len(diffs) != 2 {
+		t.Fatalf("diff count mismatch: have %d, want 2", len(diffs))
+	}
+	first := diffs[0]
+	if first.TxHash != (common.Hash{0xaa}) || first.TxIndex != 0 {
+		t.Errorf("tx context mismatch: have %x/%d", first.TxHash, first.TxIndex)
+	}
+	if len(first.Accounts) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(first.Accounts))
+	}
+	acc1, acc2 := first.Accounts[0], first.Accounts[1]
+	if acc1.Address != addr1 || acc2.Address != addr2 {
+		t.Fatalf("account order mismatch: have %x, %x", acc1.Address, acc2.Address)
+	}
+	if acc1.Balance == nil || acc1.Balance.From.Int64() != 100 || acc1.Balance.To.Int64() != 90 {
+		t.Errorf("balance diff mismatch: have %+v", acc1.Balance)
+	}
+	if acc1.Nonce == nil || acc1.Nonce.From != 0 || acc1.Nonce.To != 1 {
+		t.Errorf("nonce diff mismatch: have %+v", acc1.Nonce)
+	}
+	if len(acc1.Storage) != 1 {
+		t.Fatalf("storage diff count mismatch: have %d, want 1", len(acc1.Storage))
+	}
+	if want := (SlotDiff{Key: slot, From: common.Hash{0x01}, To: common.Hash{0x03}}); acc1.Storage[0] != want {
+		t.Errorf("storage diff mismatch: have %+v, want %+v", acc1.Storage[0], want)
+	}
+	if !acc2.Created || acc2.Balance == nil || acc2.Balance.From.Sign() != 0 || acc2.Balance.To.Int64() != 10 {
+		t.Errorf("created account diff mismatch: have %+v", acc2)
+	}
+	second := diffs[1]
+	if len(second.Accounts) != 1 {
+		t.Fatalf("account count mismatch: have %d, want 1", len(second.Accounts))
+	}
+	if acc := second.Accounts[0]; acc.Address != addr1 || acc.Balance != nil || len(acc.Storage) != 0 || acc.Nonce == nil {
+		t.Errorf("no-op changes not filtered: have %+v", acc)
+	}
+	// Ensure the diffs survive a storage round trip
+	blob, err := rlp.EncodeToBytes(diffs)
+	if err != nil {
+		t.Fatalf("failed to encode diffs: %v", err)
+	}
+	var dec []*StateDiff
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatalf("failed to decode diffs: %v", err)
+	}
+	if len(dec) != 2 || dec[0].Accounts[0].Balance.To.Int64() != 90 || dec[1].Accounts[0].Balance != nil {
+		t.Errorf("decoded diffs mismatch")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WatchKind is the kind of access to a watched storage slot.
+type WatchKind int
+
+const (
+	WatchRead   WatchKind = iota // Slot was read
+	WatchWrite                   // Slot was written, possibly with its current value
+	WatchRevert                  // Write to the slot was reverted
+)
+
+// String implements fmt.Stringer.
+func (k WatchKind) String() string {
+	switch k {
+	case WatchRead:
+		return "read"
+	case WatchWrite:
+		return "write"
+	case WatchRevert:
+		return "revert"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent is an access to a watched storage slot.
+type WatchEvent struct {
+	Kind    WatchKind
+	Address common.Address
+	Slot    common.Hash
+	Prev    common.Hash // Value of the slot before the access
+	Value   common.Hash // Value read, written or restored by a revert
+	TxHash  common.Hash // Transaction during which the access happened, if any
+	TxIndex int
+}
+
+// Watchpoints is a set of storage slots whose accesses are reported to a callback
+// by the state databases watching them, see StateDB.SetWatchpoints. Slots can be
+// added and removed at any time, also during execution.
+type Watchpoints struct {
+	fn func(*WatchEvent)
+
+	lock  sync.RWMutex
+	slots map[common.Address]map[common.Hash]struct{}
+}
+
+// NewWatchpoints creates an empty set of watchpoints reporting to the given
+// callback. The callback is invoked synchronously during execution and must not
+// modify the state.
+func NewWatchpoints(fn func(*WatchEvent)) *Watchpoints {
+	return &Watchpoints{
+		fn:    fn,
+		slots: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// Add starts watching the given storage slot.
+func (w *Watchpoints) Add(addr common.Address, slot common.Hash) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.slots[addr] == nil {
+		w.slots[addr] = make(map[common.Hash]struct{})
+	}
+	w.slots[addr][slot] = struct{}{}
+}
+
+// Remove stops watching the given storage slot.
+func (w *Watchpoints) Remove(addr common.Address, slot common.Hash) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.slots[addr], slot)
+	if len(w.slots[addr]) == 0 {
+		delete(w.slots, addr)
+	}
+}
+
+// Watched reports whether the given storage slot is watched.
+func (w *Watchpoints) Watched(addr common.Address, slot common.Hash) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, ok := w.slots[addr][slot]
+	return ok
+}
+
+// SetWatchpoints makes the state database report the accesses to the slots of
+// the given watchpoints, nil disables watching. Reads include those made to
+// meter the gas of storage writes.
+func (s *StateDB) SetWatchpoints(w *Watchpoints) {
+	s.watchpoints = w
+}
+
+// watching reports whether accesses to the given storage slot are reported.
+func (s *StateDB) watching(addr common.Address, slot common.Hash) bool {
+	return s.watchpoints != nil && s.watchpoints.Watched(addr, slot)
+}
+
+// watch reports an access to a watched storage slot.
+func (s *StateDB) watch(kind WatchKind, addr common.Address, slot, prev, value common.Hash) {
+	s.watchpoints.fn(&WatchEvent{
+		Kind:    kind,
+		Address: addr,
+		Slot:    slot,
+		Prev:    prev,
+		Value:   value,
+		TxHash:  s.thash,
+		TxIndex: s.txIndex,
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that reads, writes and reverted writes of watched storage slots are
+// reported, and that accesses to other slots are not.
+func TestWatchpoints(t *testing.T) {
+	var (
+		addr  = common.Address{0x01}
+		slot  = common.Hash{0x0a}
+		other = common.Hash{0x0b}
+	)
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetNonce(addr, 1)
+	state.SetState(addr, slot, common.Hash{0x01})
+	state.Finalise(true)
+
+	var events []WatchEvent
+	w := NewWatchpoints(func(ev *WatchEvent) { events = append(events, *ev) })
+	w.Add(addr, slot)
+	state.SetWatchpoints(w)
+	state.SetTxContext(common.Hash{0xaa}, 1)
+
+	state.GetState(addr, slot)
+	state.SetState(addr, slot, common.Hash{0x02})
+	state.SetState(addr, other, common.Hash{0x02})
+	snap := state.Snapshot()
+	state.SetState(addr, slot, common.Hash{0x03})
+	state.RevertToSnapshot(snap)
+
+	// Removed watchpoints must not be reported anymore
+	w.Remove(addr, slot)
+	state.GetState(addr, slot)
+
+	want := []WatchEvent{
+		{Kind: WatchRead, Address: addr, Slot: slot, Prev: common.Hash{0x01}, Value: common.Hash{0x01}},
+		{Kind: WatchWrite, Address: addr, Slot: slot, Prev: common.Hash{0x01}, Value: common.Hash{0x02}},
+		{Kind: WatchWrite, Address: addr, Slot: slot, Prev: common.Hash{0x02}, Value: common.Hash{0x03}},
+		{Kind: WatchRevert, Address: addr, Slot: slot, Prev: common.Hash{0x03}, Value: common.Hash{0x02}},
+	}
+	for i := range want {
+		want[i].TxHash, want[i].TxIndex = common.Hash{0xaa}, 1
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("wrong events:\nhave %+v\nwant %+v", events, want)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Witness is the part of a state accessed through a WitnessDatabase, along with
+// the trie nodes proving it against the state root. It holds everything needed
+// to repeat the accesses without the rest of the state.
+type Witness struct {
+	Root     common.Hash                      // Root of the state the accesses are proven against
+	Accounts map[common.Address][]common.Hash // Accessed accounts with their accessed storage slots
+	Codes    [][]byte                         // Code of the accessed contracts
+	Nodes    [][]byte                         // Trie nodes proving the accessed accounts and slots
+}
+
+// WitnessDatabase wraps a state database, recording the accounts, storage slots
+// and code accessed through it.
+//
+// Only accesses going through the tries are seen, so the state it is used with
+// must not be backed by a snapshot.
+type WitnessDatabase struct {
+	Database
+
+	accounts map[common.Hash]common.Address           // Accessed accounts by address hash
+	storage  map[common.Hash]map[common.Hash]struct{} // Accessed slots by owner address hash
+	codes    map[common.Hash][]byte                   // Accessed code by code hash
+	lock     sync.Mutex
+}
+
+// NewWitnessDatabase creates a state database recording the accesses to db.
+func NewWitnessDatabase(db Database) *WitnessDatabase {
+	return &WitnessDatabase{
+		Database: db,
+		accounts: make(map[common.Hash]common.Address),
+		storage:  make(map[common.Hash]map[common.Hash]struct{}),
+		codes:    make(map[common.Hash][]byte),
+	}
+}
+
+// OpenTrie opens the main account trie, recording the accounts accessed in it.
+func (db *WitnessDatabase) OpenTrie(root common.Hash) (Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &witnessTrie{Trie: tr, db: db}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, recording the slots
+// accessed in it.
+func (db *WitnessDatabase) OpenStorageTrie(stateRoot common.Hash, addrHash, root common.Hash) (Trie, error) {
+	tr, err := db.Database.OpenStorageTrie(stateRoot, addrHash, root)
+	if err != nil {
+		return nil, err
+	}
+	return &witnessTrie{Trie: tr, db: db, owner: &addrHash}, nil
+}
+
+// CopyTrie returns an independent copy of the given trie, still recording the
+// accesses to it.
+func (db *WitnessDatabase) CopyTrie(t Trie) Trie {
+	if wt, ok := t.(*witnessTrie); ok {
+		return &witnessTrie{Trie: db.Database.CopyTrie(wt.Trie), db: db, owner: wt.owner}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// ContractCode retrieves a particular contract's code, recording it.
+func (db *WitnessDatabase) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(addrHash, codeHash)
+	if err == nil {
+		db.lock.Lock()
+		db.codes[codeHash] = code
+		db.lock.Unlock()
+	}
+	return code, err
+}
+
+// ContractCodeSize retrieves a particular contract's code size, recording the
+// code, as proving its size requires it.
+func (db *WitnessDatabase) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+// Witness returns the accesses recorded so far, proven against the given state
+// root, which is usually the root of the state the accesses were made in.
+func (db *WitnessDatabase) Witness(root common.Hash) (*Witness, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	tr, err := trie.NewStateTrie(trie.StateTrieID(root), db.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	var (
+		proof   = memorydb.New()
+		witness = &Witness{Root: root, Accounts: make(map[common.Address][]common.Hash)}
+	)
+	for addrHash, addr := range db.accounts {
+		if err := tr.Prove(addrHash[:], 0, proof); err != nil {
+			return nil, err
+		}
+		witness.Accounts[addr] = nil
+	}
+	for owner, slots := range db.storage {
+		acc, err := tr.TryGetAccountByHash(owner)
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil || acc.Root == types.EmptyRootHash {
+			continue // Slots of new accounts or empty storage are proven by the account
+		}
+		st, err := trie.NewStateTrie(trie.StorageTrieID(root, owner, acc.Root), db.TrieDB())
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]common.Hash, 0, len(slots))
+		for slot := range slots {
+			if err := st.Prove(crypto.Keccak256(slot[:]), 0, proof); err != nil {
+				return nil, err
+			}
+			keys = append(keys, slot)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+		if addr, ok := db.accounts[owner]; ok {
+			witness.Accounts[addr] = keys
+		}
+	}
+	it := proof.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		witness.Nodes = append(witness.Nodes, common.CopyBytes(it.Value()))
+	}
+	hashes := make([]common.Hash, 0, len(db.codes))
+	for hash := range db.codes {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	for _, hash := range hashes {
+		witness.Codes = append(witness.Codes, db.codes[hash])
+	}
+	return witness, nil
+}
+
+// witnessTrie wraps a trie opened by a WitnessDatabase, recording the keys
+// accessed in it.
+type witnessTrie struct {
+	Trie
+	db    *WitnessDatabase
+	owner *common.Hash // Address hash of the storage trie's account, nil for the account trie
+}
+
+func (t *witnessTrie) recordAccount(addr common.Address) {
+	t.db.lock.Lock()
+	defer t.db.lock.Unlock()
+
+	t.db.accounts[crypto.Keccak256Hash(addr[:])] = addr
+}
+
+func (t *witnessTrie) recordSlot(key []byte) {
+	if t.owner == nil {
+		return
+	}
+	t.db.lock.Lock()
+	defer t.db.lock.Unlock()
+
+	slots := t.db.storage[*t.owner]
+	if slots == nil {
+		slots = make(map[common.Hash]struct{})
+		t.db.storage[*t.owner] = slots
+	}
+	slots[common.BytesToHash(key)] = struct{}{}
+}
+
+func (t *witnessTrie) TryGet(key []byte) ([]byte, error) {
+	t.recordSlot(key)
+	return t.Trie.TryGet(key)
+}
+
+func (t *witnessTrie) TryUpdate(key, value []byte) error {
+	t.recordSlot(key)
+	return t.Trie.TryUpdate(key, value)
+}
+
+func (t *witnessTrie) TryDelete(key []byte) error {
+	t.recordSlot(key)
+	return t.Trie.TryDelete(key)
+}
+
+func (t *witnessTrie) TryGetAccount(address common.Address) (*types.StateAccount, error) {
+	t.recordAccount(address)
+	return t.Trie.TryGetAccount(address)
+}
+
+func (t *witnessTrie) TryUpdateAccount(address common.Address, account *types.StateAccount) error {
+	t.recordAccount(address)
+	return t.Trie.TryUpdateAccount(address, account)
+}
+
+func (t *witnessTrie) TryDeleteAccount(address common.Address) error {
+	t.recordAccount(address)
+	return t.Trie.TryDeleteAccount(address)
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestWitness(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x01")
+		sender   = common.HexToAddress("0x02")
+		missing  = common.HexToAddress("0x03")
+		unread   = common.HexToAddress("0x04")
+		slot     = common.HexToHash("0x01")
+		empty    = common.HexToHash("0x02")
+		code     = []byte{0x60, 0x00, 0x54}
+	)
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := New(common.Hash{}, db, nil)
+	statedb.SetCode(contract, code)
+	statedb.SetState(contract, slot, common.HexToHash("0xff"))
+	statedb.SetState(contract, common.HexToHash("0x03"), common.HexToHash("0xee"))
+	statedb.SetBalance(sender, big.NewInt(100))
+	statedb.SetBalance(unread, big.NewInt(1))
+	root, _ := statedb.Commit(false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	// Access part of the state through a witness database
+	wdb := NewWitnessDatabase(db)
+	statedb, _ = New(root, wdb, nil)
+	statedb.GetState(contract, slot)
+	statedb.GetState(contract, empty)
+	statedb.GetCode(contract)
+	statedb.SubBalance(sender, big.NewInt(10))
+	statedb.GetBalance(missing)
+	statedb.IntermediateRoot(true)
+
+	witness, err := wdb.Witness(root)
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	if len(witness.Codes) != 1 || !bytes.Equal(witness.Codes[0], code) {
+		t.Fatalf("code mismatch: have %x, want [%x]", witness.Codes, code)
+	}
+	want := map[common.Address][]common.Hash{contract: {slot, empty}, sender: nil, missing: nil}
+	if len(witness.Accounts) != len(want) {
+		t.Fatalf("accessed account count mismatch: have %d, want %d", len(witness.Accounts), len(want))
+	}
+	for addr, slots := range want {
+		have, ok := witness.Accounts[addr]
+		if !ok {
+			t.Fatalf("account %x not recorded", addr)
+		}
+		if len(have) != len(slots) {
+			t.Fatalf("account %x slots mismatch: have %x, want %x", addr, have, slots)
+		}
+		for i := range slots {
+			if have[i] != slots[i] {
+				t.Fatalf("account %x slots mismatch: have %x, want %x", addr, have, slots)
+			}
+		}
+	}
+	// Every access must be provable by the witness nodes alone
+	proof := memorydb.New()
+	for _, node := range witness.Nodes {
+		proof.Put(crypto.Keccak256(node), node)
+	}
+	for _, addr := range []common.Address{contract, sender, missing} {
+		if _, err := trie.VerifyProof(root, crypto.Keccak256(addr[:]), proof); err != nil {
+			t.Fatalf("account %x not proven: %v", addr, err)
+		}
+	}
+	if _, err := trie.VerifyProof(root, crypto.Keccak256(unread[:]), proof); err == nil {
+		t.Fatalf("unaccessed account proven")
+	}
+	orig, _ := New(root, db, nil)
+	storage, _ := orig.StorageTrie(contract)
+	if value, err := trie.VerifyProof(storage.Hash(), crypto.Keccak256(slot[:]), proof); err != nil || value == nil {
+		t.Fatalf("slot not proven: %v", err)
+	}
+	if value, err := trie.VerifyProof(storage.Hash(), crypto.Keccak256(empty[:]), proof); err != nil || value != nil {
+		t.Fatalf("empty slot not proven: %x, %v", value, err)
+	}
+}
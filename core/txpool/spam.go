@@ -0,0 +1,215 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// ErrSpam is returned if a remote transaction is rejected by the spam filters
+// of the pool.
+var ErrSpam = errors.New("transaction rejected by spam filter")
+
+var spamTxMeter = metrics.NewRegisteredMeter("txpool/spam", nil)
+
+// SpamFilter scores remote transactions entering the pool after they passed
+// validation. The scores of all filters are summed up, transactions reaching a
+// total score of 1 being rejected. A filter may thus reject transactions on its
+// own by scoring them 1, or contribute a partial score.
+//
+// Filters are called with the pool lock held, so they must be fast and must not
+// call back into the pool.
+type SpamFilter interface {
+	// Name identifies the filter in errors and metrics.
+	Name() string
+
+	// Score rates a transaction, higher scores being more likely spam.
+	Score(tx *types.Transaction, from common.Address) float64
+}
+
+// SpamFeedback is implemented by dynamic spam filters adapting to the outcome of
+// the admission of remote transactions, nil errors reporting their acceptance.
+type SpamFeedback interface {
+	Feedback(tx *types.Transaction, from common.Address, err error)
+}
+
+// spamCheck scores a remote transaction with the configured filters, returning
+// an error if it is rejected.
+func (pool *TxPool) spamCheck(tx *types.Transaction, from common.Address) error {
+	var (
+		total   float64
+		culprit []string
+	)
+	for _, filter := range pool.spamFilters {
+		if score := filter.Score(tx, from); score > 0 {
+			total += score
+			culprit = append(culprit, filter.Name())
+		}
+	}
+	if total < 1 {
+		return nil
+	}
+	spamTxMeter.Mark(1)
+	for _, name := range culprit {
+		metrics.GetOrRegisterMeter("txpool/spam/"+name, nil).Mark(1)
+	}
+	return fmt.Errorf("%w: score %.2f (%s)", ErrSpam, total, strings.Join(culprit, ", "))
+}
+
+// spamFeedback reports the outcome of the admission of a remote transaction to
+// the dynamic spam filters.
+func (pool *TxPool) spamFeedback(tx *types.Transaction, err error) {
+	from, serr := types.Sender(pool.signer, tx)
+	if serr != nil {
+		return
+	}
+	for _, filter := range pool.spamFilters {
+		if feedback, ok := filter.(SpamFeedback); ok {
+			feedback.Feedback(tx, from, err)
+		}
+	}
+}
+
+// CalldataFeeFloor is a static spam filter requiring the gas tip of transactions
+// to grow with the size of their calldata, rejecting transactions below it.
+type CalldataFeeFloor struct {
+	Base  *big.Int // Minimum gas tip of all transactions
+	PerKB *big.Int // Gas tip required on top for every started kilobyte of calldata
+}
+
+// Name implements SpamFilter.
+func (f *CalldataFeeFloor) Name() string {
+	return "calldata"
+}
+
+// Score implements SpamFilter.
+func (f *CalldataFeeFloor) Score(tx *types.Transaction, from common.Address) float64 {
+	floor := new(big.Int)
+	if f.Base != nil {
+		floor.Set(f.Base)
+	}
+	if kb := (len(tx.Data()) + 1023) / 1024; kb > 0 && f.PerKB != nil {
+		floor.Add(floor, new(big.Int).Mul(f.PerKB, big.NewInt(int64(kb))))
+	}
+	if tx.GasTipCapIntCmp(floor) < 0 {
+		return 1
+	}
+	return 0
+}
+
+// senderFailureCost is the cost of a rejected admission charged by the sender
+// reputation filter configured via Config.SenderLimit.
+const senderFailureCost = 4
+
+// reputationPruneSize is the number of tracked senders above which senders whose
+// cost has decayed are forgotten.
+const reputationPruneSize = 4096
+
+// SenderReputation is a dynamic spam filter rejecting the transactions of senders
+// submitting too many transactions, or too many failing ones. Every admission
+// costs the sender 1, or FailureCost if the transaction is rejected, with past
+// costs decaying exponentially. Senders are scored by their cost relative to
+// the limit.
+type SenderReputation struct {
+	limit       float64       // Cost at which transactions of a sender are rejected
+	halfLife    time.Duration // Time in which the costs of past admissions halve
+	failureCost float64       // Cost of a rejected admission
+	clock       mclock.Clock
+
+	senders   map[common.Address]*reputation
+	lastPrune mclock.AbsTime
+	lock      sync.Mutex
+}
+
+// reputation is the decaying cost of the admissions of a sender.
+type reputation struct {
+	cost    float64
+	updated mclock.AbsTime
+}
+
+// NewSenderReputation creates a sender reputation filter rejecting the
+// transactions of senders whose admissions cost more than the limit, costs
+// halving in the given time.
+func NewSenderReputation(limit float64, halfLife time.Duration, failureCost float64) *SenderReputation {
+	return newSenderReputation(limit, halfLife, failureCost, mclock.System{})
+}
+
+func newSenderReputation(limit float64, halfLife time.Duration, failureCost float64, clock mclock.Clock) *SenderReputation {
+	return &SenderReputation{
+		limit:       limit,
+		halfLife:    halfLife,
+		failureCost: failureCost,
+		clock:       clock,
+		senders:     make(map[common.Address]*reputation),
+		lastPrune:   clock.Now(),
+	}
+}
+
+// Name implements SpamFilter.
+func (r *SenderReputation) Name() string {
+	return "reputation"
+}
+
+// Score implements SpamFilter.
+func (r *SenderReputation) Score(tx *types.Transaction, from common.Address) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.cost(from, r.clock.Now()) / r.limit
+}
+
+// Feedback implements SpamFeedback, charging the sender for the admission.
+func (r *SenderReputation) Feedback(tx *types.Transaction, from common.Address, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+	cost := r.cost(from, now) + 1
+	if err != nil {
+		cost += r.failureCost - 1
+	}
+	r.senders[from] = &reputation{cost: cost, updated: now}
+
+	if len(r.senders) > reputationPruneSize && now.Sub(r.lastPrune) > r.halfLife {
+		for addr := range r.senders {
+			if r.cost(addr, now) < 0.01 {
+				delete(r.senders, addr)
+			}
+		}
+		r.lastPrune = now
+	}
+}
+
+// cost returns the decayed cost of the past admissions of the sender.
+func (r *SenderReputation) cost(from common.Address, now mclock.AbsTime) float64 {
+	rep := r.senders[from]
+	if rep == nil {
+		return 0
+	}
+	return rep.cost * math.Exp2(-float64(now.Sub(rep.updated))/float64(r.halfLife))
+}
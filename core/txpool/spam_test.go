@@ -0,0 +1,132 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSpamFilterCalldataFloor(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(10000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.CalldataFloor = 10
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// Remote transactions below the floor of their calldata are rejected
+	if err := pool.AddRemote(pricedDataTransaction(0, 100000, big.NewInt(1), key, 1500)); !errors.Is(err, ErrSpam) {
+		t.Fatalf("cheap calldata accepted: have %v, want %v", err, ErrSpam)
+	}
+	if err := pool.AddRemote(pricedDataTransaction(0, 100000, big.NewInt(20), key, 1500)); err != nil {
+		t.Fatalf("priced calldata rejected: %v", err)
+	}
+	// Local transactions are exempt
+	if err := pool.AddLocal(pricedDataTransaction(1, 100000, big.NewInt(1), key, 1500)); err != nil {
+		t.Fatalf("local calldata rejected: %v", err)
+	}
+}
+
+// testSpamFilter is a spam filter scoring transactions by their nonce.
+type testSpamFilter struct {
+	admitted, rejected int
+}
+
+func (f *testSpamFilter) Name() string { return "test" }
+
+func (f *testSpamFilter) Score(tx *types.Transaction, from common.Address) float64 {
+	return float64(tx.Nonce()) / 2
+}
+
+func (f *testSpamFilter) Feedback(tx *types.Transaction, from common.Address, err error) {
+	if err == nil {
+		f.admitted++
+	} else {
+		f.rejected++
+	}
+}
+
+func TestSpamFilterPluggable(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(10000000, statedb, new(event.Feed))
+
+	filter := new(testSpamFilter)
+	config := testTxPoolConfig
+	config.SpamFilters = []SpamFilter{filter}
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		err := pool.AddRemote(transaction(nonce, 100000, key))
+		if spam := errors.Is(err, ErrSpam); spam != (nonce >= 2) {
+			t.Fatalf("nonce %d: spam rejection mismatch: have %v", nonce, err)
+		}
+	}
+	if filter.admitted != 2 || filter.rejected != 2 {
+		t.Fatalf("feedback mismatch: have %d/%d admitted/rejected, want 2/2", filter.admitted, filter.rejected)
+	}
+}
+
+func TestSenderReputation(t *testing.T) {
+	var (
+		clock  = new(mclock.Simulated)
+		filter = newSenderReputation(4, time.Minute, 3, clock)
+		sender = common.Address{0x01}
+		other  = common.Address{0x02}
+	)
+	// Successful admissions cost 1, failing ones 3
+	filter.Feedback(nil, sender, nil)
+	filter.Feedback(nil, sender, ErrUnderpriced)
+	if score := filter.Score(nil, sender); score != 1 {
+		t.Fatalf("score mismatch: have %v, want 1", score)
+	}
+	if score := filter.Score(nil, other); score != 0 {
+		t.Fatalf("unknown sender score mismatch: have %v, want 0", score)
+	}
+	// Costs halve with every half-life
+	clock.Run(time.Minute)
+	if score := filter.Score(nil, sender); score != 0.5 {
+		t.Fatalf("decayed score mismatch: have %v, want 0.5", score)
+	}
+	clock.Run(time.Minute)
+	if score := filter.Score(nil, sender); score != 0.25 {
+		t.Fatalf("decayed score mismatch: have %v, want 0.25", score)
+	}
+}
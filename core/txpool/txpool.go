@@ -175,6 +175,11 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	CalldataFloor uint64 // Minimum gas tip per started kilobyte of calldata of remote transactions (0 = disabled)
+	SenderLimit   uint64 // Number of recent transactions after which a remote sender is rejected, halving every minute (0 = disabled)
+
+	SpamFilters []SpamFilter `toml:"-"` // Additional filters scoring remote transactions
 }
 
 // DefaultConfig contains the default configurations for the transaction
@@ -255,6 +260,10 @@ type TxPool struct {
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
 	shanghai bool // Fork indicator whether we are in the Shanghai stage.
 
+	txGasLimit uint64 // Transaction gas limit of the active custom upgrades (0 = unlimited)
+
+	spamFilters []SpamFilter // Filters scoring remote transactions at ingress
+
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *noncer        // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
@@ -315,6 +324,14 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	if config.CalldataFloor > 0 {
+		pool.spamFilters = append(pool.spamFilters, &CalldataFeeFloor{PerKB: new(big.Int).SetUint64(config.CalldataFloor)})
+	}
+	if config.SenderLimit > 0 {
+		pool.spamFilters = append(pool.spamFilters, NewSenderReputation(float64(config.SenderLimit), time.Minute, senderFailureCost))
+	}
+	pool.spamFilters = append(pool.spamFilters, config.SpamFilters...)
+
 	pool.priced = newPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock())
 
@@ -592,6 +609,42 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// LocalTransactions retrieves all currently known local transactions, grouped by
+// origin account and sorted by nonce. These are the transactions journaled to
+// survive node restarts.
+func (pool *TxPool) LocalTransactions() map[common.Address]types.Transactions {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.local()
+}
+
+// EvictLocal removes a local transaction from the pool, demoting subsequent ones
+// of the same account to the future queue. The journal is rotated immediately,
+// so the transaction is not resurrected by a restart. Returns false if no local
+// transaction with the given hash is known.
+func (pool *TxPool) EvictLocal(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all.Get(hash)
+	if tx == nil {
+		return false
+	}
+	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
+	if !pool.locals.contains(addr) {
+		return false
+	}
+	pool.removeTx(hash, false)
+
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate local tx journal", "err", err)
+		}
+	}
+	return true
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -620,6 +673,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentMaxGas < tx.Gas() {
 		return ErrGasLimit
 	}
+	// Ensure the transaction doesn't exceed the limit of the custom upgrades.
+	if pool.txGasLimit != 0 && pool.txGasLimit < tx.Gas() {
+		return fmt.Errorf("%w: gas %v limit %v", core.ErrTxGasLimitExceeded, tx.Gas(), pool.txGasLimit)
+	}
 	// Sanity check for extremely large numbers
 	if tx.GasFeeCap().BitLen() > 256 {
 		return core.ErrFeeCapVeryHigh
@@ -695,6 +752,10 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	// the sender is marked as local previously, treat it as the local transaction.
 	isLocal := local || pool.locals.containsTx(tx)
 
+	// Let the spam filters learn from the admission of remote transactions
+	if !isLocal && len(pool.spamFilters) > 0 {
+		defer func() { pool.spamFeedback(tx, err) }()
+	}
 	// If the transaction fails basic validation, discard it
 	if err := pool.validateTx(tx, isLocal); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
@@ -705,6 +766,14 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	// already validated by this point
 	from, _ := types.Sender(pool.signer, tx)
 
+	// Reject remote transactions scored as spam
+	if !isLocal {
+		if err := pool.spamCheck(tx, from); err != nil {
+			log.Trace("Discarding spam transaction", "hash", hash, "err", err)
+			return false, err
+		}
+	}
+
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -1377,6 +1446,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.eip2718 = pool.chainconfig.IsBerlin(next)
 	pool.eip1559 = pool.chainconfig.IsLondon(next)
 	pool.shanghai = pool.chainconfig.IsShanghai(uint64(time.Now().Unix()))
+
+	rules := pool.chainconfig.Rules(next, true, uint64(time.Now().Unix()))
+	pool.txGasLimit = rules.TxGasLimit()
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -2365,6 +2366,78 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that evicted local transactions are removed from the journal too, so
+// they are not resurrected by a restart.
+func TestEvictLocal(t *testing.T) {
+	t.Parallel()
+
+	journal := filepath.Join(t.TempDir(), "transactions.rlp")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Journal = journal
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	locals := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), local),
+		pricedTransaction(1, 100000, big.NewInt(1), local),
+		pricedTransaction(2, 100000, big.NewInt(1), local),
+	}
+	for _, tx := range locals {
+		if err := pool.AddLocal(tx); err != nil {
+			t.Fatalf("failed to add local transaction: %v", err)
+		}
+	}
+	rtx := pricedTransaction(0, 100000, big.NewInt(1), remote)
+	if err := pool.addRemoteSync(rtx); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if txs := pool.LocalTransactions()[crypto.PubkeyToAddress(local.PublicKey)]; len(txs) != 3 {
+		t.Fatalf("local transactions mismatched: have %d, want %d", len(txs), 3)
+	}
+	// Remote and unknown transactions cannot be evicted
+	if pool.EvictLocal(rtx.Hash()) {
+		t.Fatalf("remote transaction evicted")
+	}
+	if pool.EvictLocal(common.Hash{0x01}) {
+		t.Fatalf("unknown transaction evicted")
+	}
+	// Evict the middle transaction, the subsequent one becomes non-executable
+	if !pool.EvictLocal(locals[1].Hash()) {
+		t.Fatalf("local transaction not evicted")
+	}
+	if pool.Has(locals[1].Hash()) {
+		t.Fatalf("evicted transaction still in pool")
+	}
+	pending, queued := pool.Stats()
+	if pending != 2 || queued != 1 {
+		t.Fatalf("pool stats mismatched: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.Stop()
+
+	// Restart the pool and ensure the evicted transaction stays gone
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pool.Has(locals[1].Hash()) {
+		t.Fatalf("evicted transaction resurrected")
+	}
+	if !pool.Has(locals[0].Hash()) || !pool.Has(locals[2].Hash()) {
+		t.Fatalf("local transactions not resurrected")
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
@@ -19,6 +19,7 @@ package types
 import (
 	"bytes"
 	"hash"
+	"io"
 	"math/big"
 	"reflect"
 	"testing"
@@ -342,3 +343,22 @@ func TestRlpDecodeParentHash(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the generated header encoder does not allocate.
+func TestHeaderEncodeAllocs(t *testing.T) {
+	withdrawalsHash := common.Hash{0x01}
+	header := &Header{
+		Difficulty:      big.NewInt(131072),
+		Number:          big.NewInt(100),
+		GasLimit:        30_000_000,
+		Extra:           []byte("extra"),
+		BaseFee:         big.NewInt(params.InitialBaseFee),
+		WithdrawalsHash: &withdrawalsHash,
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		rlp.Encode(io.Discard, header)
+	})
+	if allocs != 0 {
+		t.Fatalf("header encoding allocated %v times", allocs)
+	}
+}
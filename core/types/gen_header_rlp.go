@@ -54,7 +54,7 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 	}
 	if _tmp2 {
 		if obj.WithdrawalsHash == nil {
-			w.Write([]byte{0x80})
+			w.Write(rlp.EmptyString)
 		} else {
 			w.WriteBytes(obj.WithdrawalsHash[:])
 		}
@@ -111,3 +111,88 @@ func DeriveSha(list DerivableList, hasher TrieHasher) common.Hash {
 	}
 	return hasher.Hash()
 }
+
+// DeriveTxRoot computes the transaction trie root of the given transactions,
+// as stored in the TxHash field of block headers. The hasher is usually a fresh
+// trie.StackTrie.
+func DeriveTxRoot(txs []*Transaction, hasher TrieHasher) common.Hash {
+	return DeriveSha(Transactions(txs), hasher)
+}
+
+// DeriveReceiptRoot computes the receipt trie root of the given receipts, as
+// stored in the ReceiptHash field of block headers.
+func DeriveReceiptRoot(receipts []*Receipt, hasher TrieHasher) common.Hash {
+	return DeriveSha(Receipts(receipts), hasher)
+}
+
+// DeriveWithdrawalsRoot computes the withdrawal trie root of the given
+// withdrawals, as stored in the WithdrawalsHash field of block headers.
+func DeriveWithdrawalsRoot(withdrawals []*Withdrawal, hasher TrieHasher) common.Hash {
+	return DeriveSha(Withdrawals(withdrawals), hasher)
+}
+
+// encodedList is a derivable list of already encoded items.
+type encodedList [][]byte
+
+func (l encodedList) Len() int                           { return len(l) }
+func (l encodedList) EncodeIndex(i int, w *bytes.Buffer) { w.Write(l[i]) }
+
+// RootBuilder computes the ordered trie root of a list which is built up item
+// by item, e.g. the transactions and receipts of a block under construction.
+// Items are encoded once as they are appended and the root is only recomputed
+// when it is requested after the list changed.
+//
+// RootBuilder is not safe for concurrent use.
+type RootBuilder struct {
+	hasher TrieHasher
+	items  encodedList
+	root   *common.Hash // Cached root of the current items, nil if stale
+}
+
+// NewRootBuilder creates a root builder using the given trie hasher.
+func NewRootBuilder(hasher TrieHasher) *RootBuilder {
+	return &RootBuilder{hasher: hasher}
+}
+
+// Len returns the number of items appended so far.
+func (b *RootBuilder) Len() int {
+	return len(b.items)
+}
+
+// append encodes the single element of list and adds it to the builder.
+func (b *RootBuilder) append(list DerivableList) {
+	buf := encodeBufferPool.Get().(*bytes.Buffer)
+	defer encodeBufferPool.Put(buf)
+
+	b.items = append(b.items, encodeForDerive(list, 0, buf))
+	b.root = nil
+}
+
+// AppendTransaction adds a transaction to the end of the list.
+func (b *RootBuilder) AppendTransaction(tx *Transaction) {
+	b.append(Transactions{tx})
+}
+
+// AppendReceipt adds a receipt to the end of the list.
+func (b *RootBuilder) AppendReceipt(receipt *Receipt) {
+	b.append(Receipts{receipt})
+}
+
+// AppendWithdrawal adds a withdrawal to the end of the list.
+func (b *RootBuilder) AppendWithdrawal(withdrawal *Withdrawal) {
+	b.append(Withdrawals{withdrawal})
+}
+
+// Root returns the trie root of the items appended so far.
+func (b *RootBuilder) Root() common.Hash {
+	if b.root == nil {
+		root := DeriveSha(b.items, b.hasher)
+		b.root = &root
+	}
+	return *b.root
+}
+
+// Reset drops all appended items.
+func (b *RootBuilder) Reset() {
+	b.items, b.root = b.items[:0], nil
+}
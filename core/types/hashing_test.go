@@ -143,6 +143,33 @@ func TestDerivableList(t *testing.T) {
 	}
 }
 
+// Tests that the incremental root builder tracks the root of the full list as
+// items are appended across the single byte index boundary.
+func TestRootBuilder(t *testing.T) {
+	txs, err := genTxs(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := types.NewRootBuilder(trie.NewStackTrie(nil))
+	if root := builder.Root(); root != types.EmptyTxsHash {
+		t.Fatalf("empty root mismatch: have %x, want %x", root, types.EmptyTxsHash)
+	}
+	for i, tx := range txs {
+		builder.AppendTransaction(tx)
+		if i%10 != 0 && i != len(txs)-1 {
+			continue
+		}
+		want := types.DeriveTxRoot(txs[:i+1], trie.NewStackTrie(nil))
+		if have := builder.Root(); have != want {
+			t.Fatalf("%d txs: root mismatch: have %x, want %x", i+1, have, want)
+		}
+	}
+	builder.Reset()
+	if builder.Len() != 0 || builder.Root() != types.EmptyTxsHash {
+		t.Fatalf("builder not reset")
+	}
+}
+
 func genTxs(num uint64) (types.Transactions, error) {
 	key, err := crypto.HexToECDSA("deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
 	if err != nil {
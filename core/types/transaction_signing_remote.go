@@ -0,0 +1,106 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrRemoteSignature is returned if the signature produced by a remote
+	// signing service is malformed or does not belong to the expected account.
+	ErrRemoteSignature = errors.New("invalid remote signature")
+
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1halfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// HashSigner is implemented by signing services that keep the private key out
+// of process, such as cloud key management systems or hardware security
+// modules. Such services typically only return the raw (r, s) pair of an
+// ECDSA signature over a digest, without the recovery id Ethereum requires.
+type HashSigner interface {
+	// Address returns the account address of the key held by the service.
+	Address() common.Address
+
+	// SignHash produces a secp256k1 signature over the given 32 byte digest.
+	// The returned s value need not be normalized.
+	SignHash(ctx context.Context, hash common.Hash) (r, s *big.Int, err error)
+}
+
+// HashSignerFunc adapts a signing callback into a HashSigner.
+type HashSignerFunc struct {
+	From common.Address
+	Sign func(ctx context.Context, hash common.Hash) (r, s *big.Int, err error)
+}
+
+// Address implements HashSigner.
+func (f HashSignerFunc) Address() common.Address { return f.From }
+
+// SignHash implements HashSigner.
+func (f HashSignerFunc) SignHash(ctx context.Context, hash common.Hash) (*big.Int, *big.Int, error) {
+	return f.Sign(ctx, hash)
+}
+
+// SignHashRemote requests a signature over hash from the remote signer and
+// converts it into the 65 byte [R || S || V] format used by Ethereum. The s
+// value is normalized into the lower half of the curve order as mandated by
+// EIP-2, and the recovery id is derived by matching the recovered public key
+// against the signer's address.
+func SignHashRemote(ctx context.Context, hash common.Hash, rs HashSigner) ([]byte, error) {
+	r, s, err := rs.SignHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, ErrRemoteSignature
+	}
+	if s.Cmp(secp256k1halfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+
+	want := rs.Address()
+	for v := byte(0); v < 2; v++ {
+		sig[crypto.RecoveryIDOffset] = v
+		pub, err := crypto.SigToPub(hash[:], sig)
+		if err != nil {
+			continue
+		}
+		if crypto.PubkeyToAddress(*pub) == want {
+			return sig, nil
+		}
+	}
+	return nil, ErrRemoteSignature
+}
+
+// SignTxRemote signs the transaction using the given signer and remote
+// signing service.
+func SignTxRemote(ctx context.Context, tx *Transaction, s Signer, rs HashSigner) (*Transaction, error) {
+	sig, err := SignHashRemote(ctx, s.Hash(tx), rs)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(s, sig)
+}
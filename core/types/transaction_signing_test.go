@@ -17,6 +17,7 @@
 package types
 
 import (
+	"context"
 	"errors"
 	"math/big"
 	"testing"
@@ -136,3 +137,42 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+func TestSignTxRemote(t *testing.T) {
+	key, addr := defaultTestKey()
+	signer := NewLondonSigner(big.NewInt(18))
+
+	// Remote signer returning the raw (r, s) pair with s in the upper half
+	remote := HashSignerFunc{
+		From: addr,
+		Sign: func(ctx context.Context, hash common.Hash) (*big.Int, *big.Int, error) {
+			sig, err := crypto.Sign(hash[:], key)
+			if err != nil {
+				return nil, nil, err
+			}
+			s := new(big.Int).SetBytes(sig[32:64])
+			return new(big.Int).SetBytes(sig[:32]), s.Sub(secp256k1N, s), nil
+		},
+	}
+	tx := NewTx(&DynamicFeeTx{ChainID: big.NewInt(18), Nonce: 3, Gas: 21000})
+	signed, err := SignTxRemote(context.Background(), tx, signer, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := Sender(signer, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("expected from and address to be equal. Got %x want %x", from, addr)
+	}
+	if _, _, s := signed.RawSignatureValues(); s.Cmp(secp256k1halfN) > 0 {
+		t.Error("expected low-S signature")
+	}
+
+	// Signatures by a different key must be rejected
+	remote.From = common.Address{1}
+	if _, err := SignTxRemote(context.Background(), tx, signer, remote); !errors.Is(err, ErrRemoteSignature) {
+		t.Errorf("expected error %v, got %v", ErrRemoteSignature, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStepLimitReached   = errors.New("execution step limit reached")
+	ErrMemoryLimitReached = errors.New("execution memory limit reached")
+	ErrExecutionAborted   = errors.New("execution aborted")
+)
+
+// budgetCheckInterval is the number of steps between two checks of the budget
+// context, which is too costly to consult on every instruction.
+const budgetCheckInterval = 1024
+
+// Budget limits the resources a transaction may consume in the EVM, independent
+// of its gas. It is meant for services simulating untrusted transactions with
+// large gas allowances, which pathological code could otherwise keep busy for a
+// long time. The step and memory limits are deterministic, the context is not.
+//
+// A transaction exceeding its budget fails with an error which is not caught by
+// the calling frames, but aborts the whole execution.
+type Budget struct {
+	Steps   uint64          // Maximum number of instructions executed across all call frames, 0 for no limit
+	Memory  uint64          // Maximum memory in bytes held by all active call frames at once, 0 for no limit
+	Context context.Context // Context whose cancellation or deadline aborts the execution, nil for none
+}
+
+// budgetTracker accounts the resources consumed by a transaction against its
+// budget.
+type budgetTracker struct {
+	limit  *Budget
+	done   <-chan struct{} // Done channel of the budget context, nil if never done
+	steps  uint64          // Instructions executed so far
+	memory uint64          // Memory held by the active call frames
+	err    error           // Error the execution was aborted with, sticky
+}
+
+// newBudgetTracker creates a tracker for a transaction executed with the given
+// budget, or nil if there is no budget.
+func newBudgetTracker(limit *Budget) *budgetTracker {
+	if limit == nil {
+		return nil
+	}
+	t := &budgetTracker{limit: limit}
+	if limit.Context != nil {
+		t.done = limit.Context.Done()
+	}
+	return t
+}
+
+// step accounts for the execution of an instruction.
+func (t *budgetTracker) step() error {
+	if t.err != nil {
+		return t.err
+	}
+	t.steps++
+	if t.limit.Steps != 0 && t.steps > t.limit.Steps {
+		t.err = ErrStepLimitReached
+		return t.err
+	}
+	if t.done != nil && t.steps%budgetCheckInterval == 0 {
+		select {
+		case <-t.done:
+			t.err = fmt.Errorf("%w: %v", ErrExecutionAborted, t.limit.Context.Err())
+			return t.err
+		default:
+		}
+	}
+	return nil
+}
+
+// grow accounts for the expansion of a call frame's memory.
+func (t *budgetTracker) grow(size uint64) error {
+	if t.limit.Memory != 0 && t.memory+size > t.limit.Memory {
+		t.err = ErrMemoryLimitReached
+		return t.err
+	}
+	t.memory += size
+	return nil
+}
+
+// release accounts for the memory of a returning call frame.
+func (t *budgetTracker) release(size uint64) {
+	t.memory -= size
+}
@@ -0,0 +1,366 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// CheatcodeAddress is the address of the cheatcode precompile. It matches the
+// HEVM_ADDRESS used by Foundry and DappTools, so tests written against their
+// cheatcodes run unmodified.
+var CheatcodeAddress = common.HexToAddress("0x7109709ECfa91a80626fF3989D68f67F5b1DD12D")
+
+var (
+	errCheatcodeStateless = errors.New("cheatcodes require EVM access")
+	errCheatcodeInput     = errors.New("invalid cheatcode input")
+)
+
+// revertReasonSelector is the selector of Solidity's Error(string).
+var revertReasonSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+// cheatcode is the implementation of a single cheatcode, given the EVM, the
+// calling contract and the call arguments without the selector.
+type cheatcode func(evm *EVM, caller common.Address, args []byte) ([]byte, error)
+
+// cheatcodes maps the selectors of the supported cheatcodes to their
+// implementations.
+var cheatcodes = map[[4]byte]cheatcode{
+	cheatSelector("warp(uint256)"):                  cheatWarp,
+	cheatSelector("roll(uint256)"):                  cheatRoll,
+	cheatSelector("deal(address,uint256)"):          cheatDeal,
+	cheatSelector("store(address,bytes32,bytes32)"): cheatStore,
+	cheatSelector("load(address,bytes32)"):          cheatLoad,
+	cheatSelector("etch(address,bytes)"):            cheatEtch,
+	cheatSelector("prank(address)"):                 cheatPrank(false),
+	cheatSelector("startPrank(address)"):            cheatPrank(true),
+	cheatSelector("stopPrank()"):                    cheatStopPrank,
+	cheatSelector("expectRevert()"):                 cheatExpectRevert(false),
+	cheatSelector("expectRevert(bytes)"):            cheatExpectRevert(true),
+	cheatSelector("expectRevert(bytes4)"):           cheatExpectRevertSelector,
+}
+
+func cheatSelector(signature string) (selector [4]byte) {
+	copy(selector[:], crypto.Keccak256([]byte(signature)))
+	return selector
+}
+
+// Cheatcodes implements a subset of the Foundry cheatcodes as a precompile, to be
+// installed at CheatcodeAddress via Config.Precompiles. It allows manipulating
+// the block context, account state and the caller of subsequent calls, and must
+// never be enabled outside of tests. Supported are warp, roll, deal, store, load,
+// etch, prank, startPrank, stopPrank and expectRevert.
+//
+// The block context changes made by warp and roll persist for the lifetime of
+// the EVM, usually the remainder of the transaction or call. Pranks and expected
+// reverts apply to subsequent plain and static calls made by the contract which
+// invoked the cheatcode.
+type Cheatcodes struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *Cheatcodes) RequiredGas(input []byte) uint64 {
+	return 0
+}
+
+// Run fails, as the cheatcodes cannot operate without access to the EVM.
+func (c *Cheatcodes) Run(input []byte) ([]byte, error) {
+	return nil, errCheatcodeStateless
+}
+
+// RunStateful executes the cheatcode selected by the input.
+func (c *Cheatcodes) RunStateful(evm *EVM, caller common.Address, input []byte) ([]byte, error) {
+	if len(input) < 4 {
+		return nil, errCheatcodeInput
+	}
+	cheat, ok := cheatcodes[*(*[4]byte)(input[:4])]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cheatcode %#x", input[:4])
+	}
+	return cheat(evm, caller, input[4:])
+}
+
+// cheatState tracks the cheats affecting subsequent calls.
+type cheatState struct {
+	prank  *prank          // Caller override of subsequent calls, nil if none
+	expect *expectedRevert // Revert expected from the next call, nil if none
+}
+
+// prank overrides the caller of calls made from a contract.
+type prank struct {
+	origin     common.Address // Contract whose calls are pranked
+	sender     common.Address // Caller the calls are made from instead
+	depth      int            // Call depth of the pranked contract
+	persistent bool           // Whether the prank lasts until stopPrank
+}
+
+// expectedRevert is a revert expected from the next call of a contract.
+type expectedRevert struct {
+	origin   common.Address // Contract expecting the revert
+	depth    int            // Call depth of the expecting contract
+	data     []byte         // Expected revert data, nil if any revert is fine
+	selector bool           // Whether data is a selector to match the revert data against
+}
+
+// match reports whether the revert data of a call fulfils the expectation.
+// Expected data matches the revert data itself or a revert reason of the same
+// text, an expected selector matches the beginning of the revert data.
+func (e *expectedRevert) match(ret []byte) bool {
+	switch {
+	case e.data == nil:
+		return true
+	case e.selector:
+		return bytes.HasPrefix(ret, e.data)
+	case bytes.Equal(ret, e.data):
+		return true
+	default:
+		reason, ok := unpackRevertReason(ret)
+		return ok && reason == string(e.data)
+	}
+}
+
+// applyCheats applies the pending pranks and revert expectations to a call made
+// by caller at the current depth. It returns the caller to use and, if a revert
+// is expected, a function checking the outcome of the call against it.
+func (evm *EVM) applyCheats(caller ContractRef, addr common.Address) (ContractRef, func([]byte, error) ([]byte, error)) {
+	if evm.cheats == nil || addr == CheatcodeAddress {
+		return caller, nil
+	}
+	origin := caller.Address()
+
+	if p := evm.cheats.prank; p != nil && p.depth == evm.depth && p.origin == origin {
+		if !p.persistent {
+			evm.cheats.prank = nil
+		}
+		caller = AccountRef(p.sender)
+	}
+	e := evm.cheats.expect
+	if e == nil || e.depth != evm.depth || e.origin != origin {
+		return caller, nil
+	}
+	evm.cheats.expect = nil
+
+	snapshot := evm.StateDB.Snapshot()
+	return caller, func(ret []byte, err error) ([]byte, error) {
+		if err == nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			return packRevertReason("call did not revert as expected"), ErrExecutionReverted
+		}
+		if !e.match(ret) {
+			return packRevertReason(fmt.Sprintf("unexpected revert data %#x", ret)), ErrExecutionReverted
+		}
+		return nil, nil
+	}
+}
+
+// cheatcodeState returns the cheat state of the EVM, creating it if needed.
+func (evm *EVM) cheatcodeState() *cheatState {
+	if evm.cheats == nil {
+		evm.cheats = new(cheatState)
+	}
+	return evm.cheats
+}
+
+func cheatWarp(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	time, err := cheatWord(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !time.IsUint64() {
+		return nil, errCheatcodeInput
+	}
+	evm.Context.Time = time.Uint64()
+	return nil, nil
+}
+
+func cheatRoll(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	number, err := cheatWord(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	evm.Context.BlockNumber = number
+	return nil, nil
+}
+
+func cheatDeal(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	addr, err := cheatAddress(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := cheatWord(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	evm.StateDB.SubBalance(addr, evm.StateDB.GetBalance(addr))
+	evm.StateDB.AddBalance(addr, balance)
+	return nil, nil
+}
+
+func cheatStore(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	addr, err := cheatAddress(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 96 {
+		return nil, errCheatcodeInput
+	}
+	evm.StateDB.SetState(addr, common.BytesToHash(args[32:64]), common.BytesToHash(args[64:96]))
+	return nil, nil
+}
+
+func cheatLoad(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	addr, err := cheatAddress(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 64 {
+		return nil, errCheatcodeInput
+	}
+	value := evm.StateDB.GetState(addr, common.BytesToHash(args[32:64]))
+	return value[:], nil
+}
+
+func cheatEtch(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	addr, err := cheatAddress(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	code, err := cheatBytes(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if !evm.StateDB.Exist(addr) {
+		evm.StateDB.CreateAccount(addr)
+	}
+	evm.StateDB.SetCode(addr, common.CopyBytes(code))
+	return nil, nil
+}
+
+func cheatPrank(persistent bool) cheatcode {
+	return func(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+		sender, err := cheatAddress(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		evm.cheatcodeState().prank = &prank{
+			origin:     caller,
+			sender:     sender,
+			depth:      evm.depth,
+			persistent: persistent,
+		}
+		return nil, nil
+	}
+}
+
+func cheatStopPrank(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	if evm.cheats != nil {
+		evm.cheats.prank = nil
+	}
+	return nil, nil
+}
+
+func cheatExpectRevert(withData bool) cheatcode {
+	return func(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+		expect := &expectedRevert{origin: caller, depth: evm.depth}
+		if withData {
+			data, err := cheatBytes(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			expect.data = common.CopyBytes(data)
+		}
+		evm.cheatcodeState().expect = expect
+		return nil, nil
+	}
+}
+
+func cheatExpectRevertSelector(evm *EVM, caller common.Address, args []byte) ([]byte, error) {
+	if len(args) < 32 {
+		return nil, errCheatcodeInput
+	}
+	evm.cheatcodeState().expect = &expectedRevert{
+		origin:   caller,
+		depth:    evm.depth,
+		data:     common.CopyBytes(args[:4]),
+		selector: true,
+	}
+	return nil, nil
+}
+
+// cheatWord returns the n-th 32 byte word of the ABI encoded arguments.
+func cheatWord(args []byte, n int) (*big.Int, error) {
+	if len(args) < (n+1)*32 {
+		return nil, errCheatcodeInput
+	}
+	return new(big.Int).SetBytes(args[n*32 : (n+1)*32]), nil
+}
+
+// cheatAddress returns the n-th ABI encoded argument as an address.
+func cheatAddress(args []byte, n int) (common.Address, error) {
+	if len(args) < (n+1)*32 {
+		return common.Address{}, errCheatcodeInput
+	}
+	return common.BytesToAddress(args[n*32 : (n+1)*32]), nil
+}
+
+// cheatBytes returns the dynamic bytes argument whose offset is the n-th word
+// of the ABI encoded arguments.
+func cheatBytes(args []byte, n int) ([]byte, error) {
+	offset, err := cheatWord(args, n)
+	if err != nil {
+		return nil, err
+	}
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(args))-32 {
+		return nil, errCheatcodeInput
+	}
+	start := offset.Uint64() + 32
+	size := new(big.Int).SetBytes(args[start-32 : start])
+	if !size.IsUint64() || size.Uint64() > uint64(len(args))-start {
+		return nil, errCheatcodeInput
+	}
+	return args[start : start+size.Uint64()], nil
+}
+
+// packRevertReason encodes a revert reason as Solidity's Error(string).
+func packRevertReason(reason string) []byte {
+	size := (len(reason) + 31) / 32 * 32
+
+	out := make([]byte, 4+64+size)
+	copy(out, revertReasonSelector)
+	out[4+31] = 0x20
+	binary.BigEndian.PutUint64(out[4+56:], uint64(len(reason)))
+	copy(out[4+64:], reason)
+	return out
+}
+
+// unpackRevertReason decodes revert data encoded as Solidity's Error(string).
+func unpackRevertReason(data []byte) (string, bool) {
+	if !bytes.HasPrefix(data, revertReasonSelector) {
+		return "", false
+	}
+	reason, err := cheatBytes(data[4:], 0)
+	if err != nil {
+		return "", false
+	}
+	return string(reason), true
+}
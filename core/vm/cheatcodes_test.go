@@ -0,0 +1,136 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// cheatInput ABI encodes a cheatcode invocation with static arguments.
+func cheatInput(signature string, args ...[]byte) []byte {
+	selector := cheatSelector(signature)
+	input := selector[:]
+	for _, arg := range args {
+		input = append(input, common.LeftPadBytes(arg, 32)...)
+	}
+	return input
+}
+
+func TestCheatcodes(t *testing.T) {
+	var (
+		tester   = AccountRef(common.HexToAddress("0x7e57"))
+		alice    = common.HexToAddress("0xa11ce")
+		caller   = common.HexToAddress("0xca11e7")
+		clock    = common.HexToAddress("0xc10c")
+		reverter = common.HexToAddress("0x7e7e7e")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(caller, hexutil.MustDecode("0x3360005260206000f3")) // return CALLER
+	statedb.SetCode(clock, hexutil.MustDecode("0x4260005260206000f3"))  // return TIMESTAMP
+	statedb.SetCode(reverter, hexutil.MustDecode("0x60006000fd"))       // revert without data
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: new(big.Int),
+	}
+	evm := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{
+		Precompiles: map[common.Address]PrecompiledContract{CheatcodeAddress: new(Cheatcodes)},
+	})
+	call := func(addr common.Address, input []byte) ([]byte, error) {
+		ret, _, err := evm.Call(tester, addr, input, 1000000, new(big.Int))
+		return ret, err
+	}
+	cheat := func(input []byte) []byte {
+		ret, err := call(CheatcodeAddress, input)
+		if err != nil {
+			t.Fatalf("cheatcode %x failed: %v", input[:4], err)
+		}
+		return ret
+	}
+	// Block context and state manipulation
+	cheat(cheatInput("warp(uint256)", []byte{0x12, 0x34}))
+	if ret, _ := call(clock, nil); new(big.Int).SetBytes(ret).Uint64() != 0x1234 {
+		t.Errorf("warp: timestamp mismatch: have %x, want 0x1234", ret)
+	}
+	cheat(cheatInput("deal(address,uint256)", alice.Bytes(), []byte{100}))
+	if have := statedb.GetBalance(alice); have.Uint64() != 100 {
+		t.Errorf("deal: balance mismatch: have %v, want 100", have)
+	}
+	cheat(cheatInput("store(address,bytes32,bytes32)", alice.Bytes(), []byte{1}, []byte{2}))
+	if have := cheat(cheatInput("load(address,bytes32)", alice.Bytes(), []byte{1})); !bytes.Equal(have, common.LeftPadBytes([]byte{2}, 32)) {
+		t.Errorf("store/load: slot mismatch: have %x, want 2", have)
+	}
+	// Pranks change the caller of the next call or all calls until stopped
+	cheat(cheatInput("prank(address)", alice.Bytes()))
+	for i, want := range []common.Address{alice, tester.Address()} {
+		if ret, _ := call(caller, nil); common.BytesToAddress(ret) != want {
+			t.Errorf("prank call %d: caller mismatch: have %x, want %x", i, ret, want)
+		}
+	}
+	cheat(cheatInput("startPrank(address)", alice.Bytes()))
+	for i := 0; i < 2; i++ {
+		if ret, _ := call(caller, nil); common.BytesToAddress(ret) != alice {
+			t.Errorf("persistent prank call %d: caller mismatch: have %x, want %x", i, ret, alice)
+		}
+	}
+	cheat(cheatInput("stopPrank()"))
+	if ret, _ := call(caller, nil); common.BytesToAddress(ret) != tester.Address() {
+		t.Errorf("stopped prank: caller mismatch: have %x, want %x", ret, tester.Address())
+	}
+	// Expected reverts turn reverts into success and success into reverts
+	cheat(cheatInput("expectRevert()"))
+	if _, err := call(reverter, nil); err != nil {
+		t.Errorf("expected revert: have %v, want success", err)
+	}
+	if _, err := call(reverter, nil); err != ErrExecutionReverted {
+		t.Errorf("unexpected revert: have %v, want %v", err, ErrExecutionReverted)
+	}
+	cheat(cheatInput("expectRevert()"))
+	ret, err := call(caller, nil)
+	if reason, _ := unpackRevertReason(ret); err != ErrExecutionReverted || reason != "call did not revert as expected" {
+		t.Errorf("missing revert: have %v %q, want %v", err, reason, ErrExecutionReverted)
+	}
+	cheat(cheatInput("expectRevert(bytes4)", []byte{0xde, 0xad, 0xbe, 0xef}))
+	if _, err := call(reverter, nil); err != ErrExecutionReverted {
+		t.Errorf("mismatching revert: have %v, want %v", err, ErrExecutionReverted)
+	}
+	// Unknown cheatcodes and stateless execution must fail
+	if _, err := call(CheatcodeAddress, cheatInput("ffi(string[])")); err == nil {
+		t.Errorf("unsupported cheatcode succeeded")
+	}
+	if _, err := new(Cheatcodes).Run(cheatInput("warp(uint256)", []byte{1})); err == nil {
+		t.Errorf("stateless cheatcode succeeded")
+	}
+}
+
+func TestRevertReasonEncoding(t *testing.T) {
+	for _, reason := range []string{"", "short", "a reason longer than a single thirty two byte word"} {
+		have, ok := unpackRevertReason(packRevertReason(reason))
+		if !ok || have != reason {
+			t.Errorf("reason mismatch: have %q, want %q", have, reason)
+		}
+	}
+}
@@ -58,6 +58,12 @@ type Contract struct {
 	CodeAddr *common.Address
 	Input    []byte
 
+	// Container is the parsed EOF container if the code is EOF formatted.
+	// In that case, execution happens within the current code section.
+	Container   *Container
+	codeSection uint64
+	returnStack []returnContext
+
 	Gas   uint64
 	value *big.Int
 }
@@ -141,15 +147,27 @@ func (c *Contract) AsDelegate() *Contract {
 	return c
 }
 
-// GetOp returns the n'th element in the contract's byte array
+// GetOp returns the n'th element in the contract's byte array, or in the
+// current code section for EOF contracts.
 func (c *Contract) GetOp(n uint64) OpCode {
-	if n < uint64(len(c.Code)) {
-		return OpCode(c.Code[n])
+	code := c.Code
+	if c.Container != nil {
+		code = c.Container.Code[c.codeSection]
+	}
+	if n < uint64(len(code)) {
+		return OpCode(code[n])
 	}
 
 	return STOP
 }
 
+// returnContext is an entry of the EOF return stack, pushed by CALLF and
+// popped by RETF.
+type returnContext struct {
+	section uint64
+	pc      uint64
+}
+
 // Caller returns the caller of the contract.
 //
 // Caller will recursively call caller when the contract is a delegate
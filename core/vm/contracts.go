@@ -40,6 +40,14 @@ type PrecompiledContract interface {
 	Run(input []byte) ([]byte, error) // Run runs the precompiled contract
 }
 
+// StatefulPrecompiledContract is a precompiled contract requiring access to the
+// executing EVM and the calling contract, e.g. to modify the state directly.
+// Such contracts are executed via RunStateful instead of Run.
+type StatefulPrecompiledContract interface {
+	PrecompiledContract
+	RunStateful(evm *EVM, caller common.Address, input []byte) ([]byte, error)
+}
+
 // PrecompiledContractsHomestead contains the default set of pre-compiled Ethereum
 // contracts used in the Frontier and Homestead releases.
 var PrecompiledContractsHomestead = map[common.Address]PrecompiledContract{
@@ -128,16 +136,62 @@ func init() {
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	var active []common.Address
 	switch {
 	case rules.IsBerlin:
-		return PrecompiledAddressesBerlin
+		active = PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
-		return PrecompiledAddressesIstanbul
+		active = PrecompiledAddressesIstanbul
 	case rules.IsByzantium:
-		return PrecompiledAddressesByzantium
+		active = PrecompiledAddressesByzantium
 	default:
-		return PrecompiledAddressesHomestead
+		active = PrecompiledAddressesHomestead
+	}
+	extra := upgradePrecompiles(rules)
+	if len(extra) == 0 {
+		return active
+	}
+	addrs := make([]common.Address, len(active), len(active)+len(extra))
+	copy(addrs, active)
+	for _, addr := range extra {
+		if !containsAddress(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// upgradePrecompiles returns the addresses of the known precompiles enabled by
+// the active custom upgrades.
+func upgradePrecompiles(rules params.Rules) []common.Address {
+	var addrs []common.Address
+	for _, u := range rules.Upgrades {
+		for _, addr := range u.Precompiles {
+			if _, ok := knownPrecompile(addr); ok {
+				addrs = append(addrs, addr)
+			}
+		}
 	}
+	return addrs
+}
+
+// knownPrecompile looks up a precompiled contract implemented by the EVM, be it
+// part of a hard fork or not.
+func knownPrecompile(addr common.Address) (PrecompiledContract, bool) {
+	if p, ok := PrecompiledContractsBerlin[addr]; ok {
+		return p, true
+	}
+	p, ok := PrecompiledContractsBLS[addr]
+	return p, ok
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
 }
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
@@ -155,6 +209,22 @@ func RunPrecompiledContract(p PrecompiledContract, input []byte, suppliedGas uin
 	return output, suppliedGas, err
 }
 
+// runPrecompiledContract runs a precompiled contract called by caller, executing
+// stateful contracts with access to the EVM.
+func (evm *EVM) runPrecompiledContract(p PrecompiledContract, caller common.Address, input []byte, suppliedGas uint64) (ret []byte, remainingGas uint64, err error) {
+	sp, ok := p.(StatefulPrecompiledContract)
+	if !ok {
+		return RunPrecompiledContract(p, input, suppliedGas)
+	}
+	gasCost := sp.RequiredGas(input)
+	if suppliedGas < gasCost {
+		return nil, 0, ErrOutOfGas
+	}
+	suppliedGas -= gasCost
+	output, err := sp.RunStateful(evm, caller, input)
+	return output, suppliedGas, err
+}
+
 // ECRECOVER implemented as a native contract.
 type ecrecover struct{}
 
@@ -395,40 +465,10 @@ func (c *bigModExp) Run(input []byte) ([]byte, error) {
 	return common.LeftPadBytes(v, int(modLen)), nil
 }
 
-// newCurvePoint unmarshals a binary blob into a bn256 elliptic curve point,
-// returning it, or an error if the point is invalid.
-func newCurvePoint(blob []byte) (*bn256.G1, error) {
-	p := new(bn256.G1)
-	if _, err := p.Unmarshal(blob); err != nil {
-		return nil, err
-	}
-	return p, nil
-}
-
-// newTwistPoint unmarshals a binary blob into a bn256 elliptic curve point,
-// returning it, or an error if the point is invalid.
-func newTwistPoint(blob []byte) (*bn256.G2, error) {
-	p := new(bn256.G2)
-	if _, err := p.Unmarshal(blob); err != nil {
-		return nil, err
-	}
-	return p, nil
-}
-
 // runBn256Add implements the Bn256Add precompile, referenced by both
 // Byzantium and Istanbul operations.
 func runBn256Add(input []byte) ([]byte, error) {
-	x, err := newCurvePoint(getData(input, 0, 64))
-	if err != nil {
-		return nil, err
-	}
-	y, err := newCurvePoint(getData(input, 64, 64))
-	if err != nil {
-		return nil, err
-	}
-	res := new(bn256.G1)
-	res.Add(x, y)
-	return res.Marshal(), nil
+	return bn256.ECAdd(input)
 }
 
 // bn256Add implements a native elliptic curve point addition conforming to
@@ -460,13 +500,7 @@ func (c *bn256AddByzantium) Run(input []byte) ([]byte, error) {
 // runBn256ScalarMul implements the Bn256ScalarMul precompile, referenced by
 // both Byzantium and Istanbul operations.
 func runBn256ScalarMul(input []byte) ([]byte, error) {
-	p, err := newCurvePoint(getData(input, 0, 64))
-	if err != nil {
-		return nil, err
-	}
-	res := new(bn256.G1)
-	res.ScalarMult(p, new(big.Int).SetBytes(getData(input, 64, 32)))
-	return res.Marshal(), nil
+	return bn256.ECMul(input)
 }
 
 // bn256ScalarMulIstanbul implements a native elliptic curve scalar
@@ -495,46 +529,10 @@ func (c *bn256ScalarMulByzantium) Run(input []byte) ([]byte, error) {
 	return runBn256ScalarMul(input)
 }
 
-var (
-	// true32Byte is returned if the bn256 pairing check succeeds.
-	true32Byte = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
-
-	// false32Byte is returned if the bn256 pairing check fails.
-	false32Byte = make([]byte, 32)
-
-	// errBadPairingInput is returned if the bn256 pairing input is invalid.
-	errBadPairingInput = errors.New("bad elliptic curve pairing size")
-)
-
 // runBn256Pairing implements the Bn256Pairing precompile, referenced by both
 // Byzantium and Istanbul operations.
 func runBn256Pairing(input []byte) ([]byte, error) {
-	// Handle some corner cases cheaply
-	if len(input)%192 > 0 {
-		return nil, errBadPairingInput
-	}
-	// Convert the input into a set of coordinates
-	var (
-		cs []*bn256.G1
-		ts []*bn256.G2
-	)
-	for i := 0; i < len(input); i += 192 {
-		c, err := newCurvePoint(input[i : i+64])
-		if err != nil {
-			return nil, err
-		}
-		t, err := newTwistPoint(input[i+64 : i+192])
-		if err != nil {
-			return nil, err
-		}
-		cs = append(cs, c)
-		ts = append(ts, t)
-	}
-	// Execute the pairing checks and return the results
-	if bn256.PairingCheck(cs, ts) {
-		return true32Byte, nil
-	}
-	return false32Byte, nil
+	return bn256.ECPairing(input)
 }
 
 // bn256PairingIstanbul implements a pairing pre-compile for the bn256 curve
@@ -1048,3 +1046,38 @@ func (c *bls12381MapG2) Run(input []byte) ([]byte, error) {
 	// Encode the G2 point to 256 bytes
 	return g.EncodePoint(r), nil
 }
+
+// P256VerifyAddress is the address at which the secp256r1 signature verifier
+// proposed by EIP-7212 is expected to be deployed.
+var P256VerifyAddress = common.BytesToAddress([]byte{0x01, 0x00})
+
+// P256Verify implements the secp256r1 (NIST P-256) signature verification
+// precompile proposed by EIP-7212. It is not active in any fork, but may be
+// enabled via Config.Precompiles, e.g. to test passkey based smart wallets.
+type P256Verify struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *P256Verify) RequiredGas(input []byte) uint64 {
+	return params.P256VerifyGas
+}
+
+// Run verifies a signature given as (hash, r, s, x, y), each 32 bytes. It
+// returns 1 as a 32 byte word if the signature is valid and empty output in
+// all other cases, including malformed input.
+func (c *P256Verify) Run(input []byte) ([]byte, error) {
+	const p256VerifyInputLength = 160
+	if len(input) != p256VerifyInputLength {
+		return nil, nil
+	}
+	var (
+		hash = input[:32]
+		r    = new(big.Int).SetBytes(input[32:64])
+		s    = new(big.Int).SetBytes(input[64:96])
+		x    = new(big.Int).SetBytes(input[96:128])
+		y    = new(big.Int).SetBytes(input[128:160])
+	)
+	if !crypto.VerifyP256(hash, r, s, x, y) {
+		return nil, nil
+	}
+	return common.LeftPadBytes([]byte{1}, 32), nil
+}
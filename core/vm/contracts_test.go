@@ -65,6 +65,7 @@ var allPrecompiles = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{16}):   &bls12381Pairing{},
 	common.BytesToAddress([]byte{17}):   &bls12381MapG1{},
 	common.BytesToAddress([]byte{18}):   &bls12381MapG2{},
+	P256VerifyAddress:                   &P256Verify{},
 }
 
 // EIP-152 test vectors
@@ -272,6 +273,9 @@ func TestPrecompileBlake2FMalformedInput(t *testing.T) {
 
 func TestPrecompiledEcrecover(t *testing.T) { testJson("ecRecover", "01", t) }
 
+func TestPrecompiledP256Verify(t *testing.T)      { testJson("p256Verify", "100", t) }
+func BenchmarkPrecompiledP256Verify(b *testing.B) { benchJson("p256Verify", "100", b) }
+
 func testJson(name, addr string, t *testing.T) {
 	tests, err := loadJson(name)
 	if err != nil {
@@ -0,0 +1,112 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OpCounters aggregates the executions of each opcode: how often it ran, the gas
+// charged for it and the time spent executing it. The time of call and create
+// opcodes includes the execution of the called code.
+//
+// OpCounters is not safe for concurrent use.
+type OpCounters struct {
+	Counts [256]uint64
+	Gas    [256]uint64
+	Times  [256]time.Duration
+}
+
+// record accounts a single execution of the opcode.
+func (c *OpCounters) record(op OpCode, gas uint64, elapsed time.Duration) {
+	c.Counts[op]++
+	c.Gas[op] += gas
+	c.Times[op] += elapsed
+}
+
+// Merge adds the executions of other to the counters.
+func (c *OpCounters) Merge(other *OpCounters) {
+	for i := range c.Counts {
+		c.Counts[i] += other.Counts[i]
+		c.Gas[i] += other.Gas[i]
+		c.Times[i] += other.Times[i]
+	}
+}
+
+// OpStat is the aggregated execution statistics of a single opcode.
+type OpStat struct {
+	Op    string        `json:"op"`
+	Count uint64        `json:"count"`
+	Gas   uint64        `json:"gas"`
+	Time  time.Duration `json:"time"` // Total execution time in nanoseconds
+}
+
+// Stats returns the statistics of the executed opcodes, ordered by decreasing
+// execution time.
+func (c *OpCounters) Stats() []OpStat {
+	var stats []OpStat
+	for i, count := range c.Counts {
+		if count == 0 {
+			continue
+		}
+		stats = append(stats, OpStat{
+			Op:    OpCode(i).String(),
+			Count: count,
+			Gas:   c.Gas[i],
+			Time:  c.Times[i],
+		})
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Time > stats[j].Time
+	})
+	return stats
+}
+
+// TxOpCounters are the opcode counters of a single transaction.
+type TxOpCounters struct {
+	Hash common.Hash
+	OpCounters
+}
+
+// BlockOpCounters collects the opcode counters of a block, broken down by
+// transaction. Set as Config.OpCounters, the interpreter records all executed
+// opcodes into it.
+type BlockOpCounters struct {
+	Block OpCounters
+	Txs   []*TxOpCounters
+
+	current *OpCounters // Counters of the transaction being executed
+}
+
+// BeginTx starts recording the opcodes of the transaction with the given hash.
+func (b *BlockOpCounters) BeginTx(hash common.Hash) {
+	tx := &TxOpCounters{Hash: hash}
+	b.Txs = append(b.Txs, tx)
+	b.current = &tx.OpCounters
+}
+
+// record accounts a single execution of the opcode to the block and to the
+// current transaction.
+func (b *BlockOpCounters) record(op OpCode, gas uint64, elapsed time.Duration) {
+	b.Block.record(op, gas, elapsed)
+	if b.current != nil {
+		b.current.record(op, gas, elapsed)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestOpCounters(t *testing.T) {
+	var (
+		address = common.BytesToAddress([]byte("contract"))
+		vmctx   = BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		}
+		counters = new(BlockOpCounters)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	// PUSH1 1 PUSH1 2 ADD PUSH1 0 SSTORE STOP
+	statedb.SetCode(address, common.FromHex("0x6001600201600055"+"00"))
+	statedb.Finalise(true)
+
+	evm := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{OpCounters: counters})
+	for _, hash := range []common.Hash{{0x01}, {0x02}} {
+		counters.BeginTx(hash)
+		if _, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int)); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	want := map[OpCode]uint64{PUSH1: 6, ADD: 2, SSTORE: 2, STOP: 2}
+	for op, count := range want {
+		if have := counters.Block.Counts[op]; have != count {
+			t.Errorf("%v: have %d executions, want %d", op, have, count)
+		}
+	}
+	if have, want := counters.Block.Gas[PUSH1], 6*GasFastestStep; have != want {
+		t.Errorf("PUSH1 gas: have %d, want %d", have, want)
+	}
+	// The first call sets the slot, the second one doesn't change it
+	if first, second := counters.Txs[0].Gas[SSTORE], counters.Txs[1].Gas[SSTORE]; first <= second {
+		t.Errorf("SSTORE gas: have %d then %d, want decreasing", first, second)
+	}
+	if len(counters.Txs) != 2 || counters.Txs[1].Hash != (common.Hash{0x02}) || counters.Txs[1].Counts[ADD] != 1 {
+		t.Errorf("transaction counters mismatch: %+v", counters.Txs)
+	}
+	stats := counters.Block.Stats()
+	if len(stats) != len(want) {
+		t.Fatalf("have %d opcode stats, want %d", len(stats), len(want))
+	}
+	for i := 1; i < len(stats); i++ {
+		if stats[i].Time > stats[i-1].Time {
+			t.Errorf("stats not ordered by time: %v", stats)
+		}
+	}
+	var totals OpCounters
+	totals.Merge(&counters.Block)
+	totals.Merge(&counters.Txs[0].OpCounters)
+	if totals.Counts[PUSH1] != 9 {
+		t.Errorf("merged PUSH1 count: have %d, want 9", totals.Counts[PUSH1])
+	}
+}
@@ -17,10 +17,13 @@
 package vm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 )
@@ -49,6 +52,39 @@ func EnableEIP(eipNum int, jt *JumpTable) error {
 	return nil
 }
 
+func init() {
+	// Let chain configurations with unknown instruction set changes be rejected
+	// when loaded instead of failing them during execution
+	params.RegisterUpgradeChecks(ValidEip, func(name string) bool {
+		_, ok := stringToOp[name]
+		return ok
+	})
+}
+
+// applyUpgrades returns a copy of the jump table with the EIPs and disabled
+// opcodes of the given custom upgrades applied, in order. Configurations are
+// checked for unknown EIPs and opcodes when loaded, so failures are not expected
+// here.
+func applyUpgrades(jt *JumpTable, upgrades []*params.Upgrade) *JumpTable {
+	jt = copyJumpTable(jt)
+	for _, u := range upgrades {
+		for _, eip := range u.EIPs {
+			if err := EnableEIP(eip, jt); err != nil {
+				log.Error("Upgrade EIP activation failed", "upgrade", u.Name, "eip", eip, "error", err)
+			}
+		}
+		for _, name := range u.DisabledOpcodes {
+			op, ok := stringToOp[name]
+			if !ok {
+				log.Error("Upgrade disables unknown opcode", "upgrade", u.Name, "opcode", name)
+				continue
+			}
+			jt[op] = &operation{execute: opUndefined, maxStack: maxStack(0, 0), undefined: true}
+		}
+	}
+	return jt
+}
+
 func ValidEip(eipNum int) bool {
 	_, ok := activators[eipNum]
 	return ok
@@ -241,3 +277,146 @@ func enable3860(jt *JumpTable) {
 	jt[CREATE].dynamicGas = gasCreateEip3860
 	jt[CREATE2].dynamicGas = gasCreate2Eip3860
 }
+
+// enable3670 applies EIP-3670 (EOF - Code Validation)
+// - Removes the instructions which are not allowed in EOF code
+func enable3670(jt *JumpTable) {
+	for _, op := range []OpCode{JUMP, JUMPI, PC, CALLCODE, SELFDESTRUCT} {
+		jt[op] = &operation{execute: opUndefined, maxStack: maxStack(0, 0), undefined: true}
+	}
+}
+
+// enable4200 applies EIP-4200 (EOF - Static relative jumps)
+// - Adds RJUMP, RJUMPI and RJUMPV with relative, immediate jump targets
+func enable4200(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: GasFastishStep,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+}
+
+// enable4750 applies EIP-4750 (EOF - Functions)
+// - Adds CALLF and RETF to call into and return from code sections
+func enable4750(jt *JumpTable) {
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+}
+
+// makeEOFPush creates a push instruction for EOF code. Validation guarantees
+// that the immediate is not truncated.
+func makeEOFPush(size uint64) executionFunc {
+	return func(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+		code := scope.Contract.Container.Code[scope.Contract.codeSection]
+		scope.Stack.push(new(uint256.Int).SetBytes(code[*pc+1 : *pc+1+size]))
+		*pc += size
+		return nil, nil
+	}
+}
+
+// opRjump implements the RJUMP opcode
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if atomic.LoadInt32(&interpreter.evm.abort) != 0 {
+		return nil, errStopToken
+	}
+	var (
+		code   = scope.Contract.Container.Code[scope.Contract.codeSection]
+		offset = parseInt16(code[*pc+1:])
+	)
+	// The offset is relative to the end of the instruction, minus one as the
+	// pc will be increased by the interpreter loop
+	*pc = uint64(int64(*pc) + 2 + int64(offset))
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	cond := scope.Stack.pop()
+	if cond.IsZero() {
+		*pc += 2 // skip the immediate
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Container.Code[scope.Contract.codeSection]
+		count = uint64(code[*pc+1])
+		idx   = scope.Stack.pop()
+	)
+	if !idx.IsUint64() || idx.Uint64() >= count {
+		// Out of bounds, fall through to the next instruction
+		*pc += 1 + count*2
+		return nil, nil
+	}
+	if atomic.LoadInt32(&interpreter.evm.abort) != 0 {
+		return nil, errStopToken
+	}
+	offset := parseInt16(code[*pc+2+2*idx.Uint64():])
+	*pc = uint64(int64(*pc) + 1 + int64(count*2) + int64(offset))
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		code     = contract.Container.Code[contract.codeSection]
+		idx      = binary.BigEndian.Uint16(code[*pc+1:])
+		typ      = contract.Container.Types[idx]
+	)
+	if len(contract.returnStack) >= maxReturnStack {
+		return nil, ErrReturnStackExceeded
+	}
+	if limit := int(params.StackLimit) - int(typ.MaxStackHeight) + int(typ.Input); scope.Stack.len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: limit}
+	}
+	contract.returnStack = append(contract.returnStack, returnContext{
+		section: contract.codeSection,
+		pc:      *pc + 3,
+	})
+	contract.codeSection = uint64(idx)
+	*pc = 0
+	*pc -= 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	contract := scope.Contract
+	if len(contract.returnStack) == 0 {
+		// Returning from the first code section ends execution
+		return nil, errStopToken
+	}
+	last := contract.returnStack[len(contract.returnStack)-1]
+	contract.returnStack = contract.returnStack[:len(contract.returnStack)-1]
+
+	contract.codeSection = last.section
+	*pc = last.pc - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
@@ -0,0 +1,418 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	offsetVersion   = 2
+	offsetTypesKind = 3
+	offsetCodeKind  = 6
+
+	kindTypes = 1
+	kindCode  = 2
+	kindData  = 3
+
+	eofFormatByte = 0xef
+	eof1Version   = 1
+
+	maxInputItems   = 127
+	maxOutputItems  = 127
+	maxStackHeight  = 1023
+	maxCodeSections = 1024
+	maxReturnStack  = 1024
+)
+
+var (
+	ErrInvalidMagic           = errors.New("invalid magic")
+	ErrInvalidVersion         = errors.New("invalid version")
+	ErrMissingTypeHeader      = errors.New("missing type header")
+	ErrInvalidTypeSize        = errors.New("invalid type section size")
+	ErrMissingCodeHeader      = errors.New("missing code header")
+	ErrInvalidCodeHeader      = errors.New("invalid code header")
+	ErrInvalidCodeSize        = errors.New("invalid code size")
+	ErrMissingDataHeader      = errors.New("missing data header")
+	ErrMissingTerminator      = errors.New("missing header terminator")
+	ErrTooManyInputs          = errors.New("invalid type content, too many inputs")
+	ErrTooManyOutputs         = errors.New("invalid type content, too many outputs")
+	ErrInvalidSection0Type    = errors.New("invalid section 0 type, input and output should be zero")
+	ErrTooLargeMaxStackHeight = errors.New("invalid type content, max stack height exceeds limit")
+	ErrInvalidContainerSize   = errors.New("invalid container size")
+	ErrUndefinedInstruction   = errors.New("undefined instruction")
+	ErrTruncatedImmediate     = errors.New("truncated immediate")
+	ErrInvalidSectionArgument = errors.New("invalid section argument")
+	ErrInvalidJumpDest        = errors.New("invalid jump destination")
+	ErrInvalidBranchCount     = errors.New("invalid number of branches in jump table")
+	ErrInvalidOutputs         = errors.New("invalid number of outputs")
+	ErrInvalidMaxStackHeight  = errors.New("invalid max stack height")
+	ErrConflictingStack       = errors.New("conflicting stack height")
+	ErrUnreachableCode        = errors.New("unreachable code")
+	ErrNoTerminalInstruction  = errors.New("expected terminal instruction")
+	ErrEOFStackUnderflow      = errors.New("stack underflow")
+	ErrReturnStackExceeded    = errors.New("return stack limit reached")
+)
+
+// FunctionMetadata is an entry of the EOF types section, describing the stack
+// behaviour of a code section.
+type FunctionMetadata struct {
+	Input          uint8
+	Output         uint8
+	MaxStackHeight uint16
+}
+
+// Container is an EOF container object.
+type Container struct {
+	Types []*FunctionMetadata
+	Code  [][]byte
+	Data  []byte
+}
+
+// hasEOFMagic reports whether the code starts with the EOF prefix 0xEF00.
+func hasEOFMagic(code []byte) bool {
+	return len(code) >= 2 && code[0] == eofFormatByte && code[1] == 0
+}
+
+// isEOFVersion1 reports whether the code is an EOF container of version 1.
+func isEOFVersion1(code []byte) bool {
+	return hasEOFMagic(code) && len(code) > offsetVersion && code[offsetVersion] == eof1Version
+}
+
+// MarshalBinary encodes an EOF container into binary format.
+func (c *Container) MarshalBinary() []byte {
+	b := []byte{eofFormatByte, 0, eof1Version}
+
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Types)*4))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Code)))
+	for _, code := range c.Code {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Data)))
+	b = append(b, 0) // terminator
+
+	for _, typ := range c.Types {
+		b = append(b, typ.Input, typ.Output)
+		b = binary.BigEndian.AppendUint16(b, typ.MaxStackHeight)
+	}
+	for _, code := range c.Code {
+		b = append(b, code...)
+	}
+	return append(b, c.Data...)
+}
+
+// UnmarshalBinary decodes an EOF container and checks its header. The code of
+// the sections is not validated, see ValidateCode.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	if !hasEOFMagic(b) {
+		return fmt.Errorf("%w: want %x", ErrInvalidMagic, []byte{eofFormatByte, 0})
+	}
+	if !isEOFVersion1(b) {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidVersion, versionByte(b), eof1Version)
+	}
+	// Parse the type section header
+	kind, typesSize, err := parseSection(b, offsetTypesKind)
+	if err != nil || kind != kindTypes {
+		return fmt.Errorf("%w: found section kind %x instead", ErrMissingTypeHeader, kind)
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return fmt.Errorf("%w: type section size must be divisible by 4, have %d", ErrInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return fmt.Errorf("%w: type section must not exceed 4*1024, have %d", ErrInvalidTypeSize, typesSize)
+	}
+	// Parse the code section header
+	kind, codeSizes, err := parseSectionList(b, offsetCodeKind)
+	if err != nil || kind != kindCode {
+		return fmt.Errorf("%w: found section kind %x instead", ErrMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/4 {
+		return fmt.Errorf("%w: mismatch of code sections count and type signatures, types %d, code %d", ErrInvalidCodeHeader, typesSize/4, len(codeSizes))
+	}
+	// Parse the data section header
+	offsetDataKind := offsetCodeKind + 3 + 2*len(codeSizes)
+	kind, dataSize, err := parseSection(b, offsetDataKind)
+	if err != nil || kind != kindData {
+		return fmt.Errorf("%w: found section kind %x instead", ErrMissingDataHeader, kind)
+	}
+	// Check for the terminator and the total container size
+	offsetTerminator := offsetDataKind + 3
+	if len(b) <= offsetTerminator || b[offsetTerminator] != 0 {
+		return ErrMissingTerminator
+	}
+	expectedSize := offsetTerminator + 1 + typesSize + dataSize
+	for _, size := range codeSizes {
+		expectedSize += size
+	}
+	if len(b) != expectedSize {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidContainerSize, len(b), expectedSize)
+	}
+	// Parse the types section
+	idx := offsetTerminator + 1
+	types := make([]*FunctionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &FunctionMetadata{
+			Input:          b[idx+i*4],
+			Output:         b[idx+i*4+1],
+			MaxStackHeight: binary.BigEndian.Uint16(b[idx+i*4+2:]),
+		}
+		if sig.Input > maxInputItems {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyInputs, i, sig.Input)
+		}
+		if sig.Output > maxOutputItems {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyOutputs, i, sig.Output)
+		}
+		if sig.MaxStackHeight > maxStackHeight {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooLargeMaxStackHeight, i, sig.MaxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].Input != 0 || types[0].Output != 0 {
+		return fmt.Errorf("%w: have %d, %d", ErrInvalidSection0Type, types[0].Input, types[0].Output)
+	}
+	idx += typesSize
+
+	// Parse the code sections
+	code := make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		if size == 0 {
+			return fmt.Errorf("%w for section %d: size must not be 0", ErrInvalidCodeSize, i)
+		}
+		code[i] = b[idx : idx+size]
+		idx += size
+	}
+	c.Types, c.Code, c.Data = types, code, b[idx:idx+dataSize]
+	return nil
+}
+
+// ValidateCode validates the code sections of the container against the given
+// EOF instruction set.
+func (c *Container) ValidateCode(jt *JumpTable) error {
+	for i, code := range c.Code {
+		if err := validateCode(code, i, c.Types, jt); err != nil {
+			return fmt.Errorf("code section %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:])), nil
+}
+
+// parseSectionList decodes a (kind, len, []sizes) section list from an EOF
+// header.
+func parseSectionList(b []byte, idx int) (kind int, sizes []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	kind, count := int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:]))
+	if count == 0 || count > maxCodeSections {
+		return kind, nil, fmt.Errorf("%w: invalid number of code sections %d", ErrInvalidCodeHeader, count)
+	}
+	if idx+3+2*count > len(b) {
+		return kind, nil, io.ErrUnexpectedEOF
+	}
+	sizes = make([]int, count)
+	for i := range sizes {
+		sizes[i] = int(binary.BigEndian.Uint16(b[idx+3+2*i:]))
+	}
+	return kind, sizes, nil
+}
+
+func versionByte(b []byte) int {
+	if len(b) <= offsetVersion {
+		return -1
+	}
+	return int(b[offsetVersion])
+}
+
+// validateCode validates the code of a single section according to EIP-3670,
+// EIP-4200, EIP-4750 and EIP-5450.
+func validateCode(code []byte, section int, types []*FunctionMetadata, jt *JumpTable) error {
+	// Collect the instruction boundaries and check the immediates
+	var (
+		instr = make([]bool, len(code))
+		dests []int
+	)
+	for i := 0; i < len(code); {
+		op := OpCode(code[i])
+		if op != INVALID && jt[op].undefined {
+			return fmt.Errorf("%w: op %s, pos %d", ErrUndefinedInstruction, op, i)
+		}
+		instr[i] = true
+		size := immediateSize(code, i)
+		if i+size >= len(code) && size > 0 {
+			return fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+		}
+		switch op {
+		case RJUMP, RJUMPI:
+			dests = append(dests, i+3+int(parseInt16(code[i+1:])))
+		case RJUMPV:
+			count := int(code[i+1])
+			if count == 0 {
+				return fmt.Errorf("%w: pos %d", ErrInvalidBranchCount, i)
+			}
+			for j := 0; j < count; j++ {
+				dests = append(dests, i+size+1+int(parseInt16(code[i+2+2*j:])))
+			}
+		case CALLF:
+			if idx := int(binary.BigEndian.Uint16(code[i+1:])); idx >= len(types) {
+				return fmt.Errorf("%w: arg %d, last %d, pos %d", ErrInvalidSectionArgument, idx, len(types)-1, i)
+			}
+		}
+		i += size + 1
+	}
+	for _, dest := range dests {
+		if dest < 0 || dest >= len(code) || !instr[dest] {
+			return fmt.Errorf("%w: target %d", ErrInvalidJumpDest, dest)
+		}
+	}
+	return validateStack(code, section, types, jt)
+}
+
+// validateStack checks that every instruction is reachable and executed with
+// a consistent stack height, which stays within the bounds declared in the
+// types section.
+func validateStack(code []byte, section int, types []*FunctionMetadata, jt *JumpTable) error {
+	var (
+		heights = make([]int, len(code))
+		queue   = []int{0}
+		meta    = types[section]
+		max     = int(meta.Input)
+	)
+	for i := range heights {
+		heights[i] = -1
+	}
+	heights[0] = int(meta.Input)
+
+	for len(queue) > 0 {
+		pos := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		var (
+			op     = OpCode(code[pos])
+			height = heights[pos]
+			next   = pos + immediateSize(code, pos) + 1
+			succs  []int
+		)
+		switch op {
+		case CALLF:
+			target := types[binary.BigEndian.Uint16(code[pos+1:])]
+			if height < int(target.Input) {
+				return fmt.Errorf("%w: at pos %d", ErrEOFStackUnderflow, pos)
+			}
+			height += int(target.Output) - int(target.Input)
+		case RETF:
+			if height != int(meta.Output) {
+				return fmt.Errorf("%w: have %d, want %d, at pos %d", ErrInvalidOutputs, height, meta.Output, pos)
+			}
+		default:
+			pops := jt[op].minStack
+			pushes := int(params.StackLimit) + jt[op].minStack - jt[op].maxStack
+			if height < pops {
+				return fmt.Errorf("%w: at pos %d", ErrEOFStackUnderflow, pos)
+			}
+			height += pushes - pops
+		}
+		if height > max {
+			max = height
+		}
+		switch op {
+		case STOP, RETURN, REVERT, INVALID, RETF:
+		case RJUMP:
+			succs = []int{next + int(parseInt16(code[pos+1:]))}
+		case RJUMPI:
+			succs = []int{next, next + int(parseInt16(code[pos+1:]))}
+		case RJUMPV:
+			succs = []int{next}
+			for i := 0; i < int(code[pos+1]); i++ {
+				succs = append(succs, next+int(parseInt16(code[pos+2+2*i:])))
+			}
+		default:
+			succs = []int{next}
+		}
+		for _, succ := range succs {
+			if succ >= len(code) {
+				return fmt.Errorf("%w: op %s, pos %d", ErrNoTerminalInstruction, op, pos)
+			}
+			switch heights[succ] {
+			case -1:
+				heights[succ] = height
+				queue = append(queue, succ)
+			case height:
+			default:
+				return fmt.Errorf("%w: have %d, want %d, at pos %d", ErrConflictingStack, height, heights[succ], succ)
+			}
+		}
+	}
+	for pos := 0; pos < len(code); pos += immediateSize(code, pos) + 1 {
+		if heights[pos] == -1 {
+			return fmt.Errorf("%w: pos %d", ErrUnreachableCode, pos)
+		}
+	}
+	if max > maxStackHeight || max != int(meta.MaxStackHeight) {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidMaxStackHeight, meta.MaxStackHeight, max)
+	}
+	return nil
+}
+
+// immediateSize returns the number of immediate bytes following the op at the
+// given position.
+func immediateSize(code []byte, pos int) int {
+	switch op := OpCode(code[pos]); {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op - PUSH0)
+	case op == RJUMP, op == RJUMPI, op == CALLF:
+		return 2
+	case op == RJUMPV:
+		if pos+1 < len(code) {
+			return 1 + 2*int(code[pos+1])
+		}
+		return 1
+	}
+	return 0
+}
+
+// parseInt16 decodes a big endian signed 16 bit immediate.
+func parseInt16(b []byte) int16 {
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+// validateEOF parses and validates an EOF container.
+func validateEOF(code []byte, jt *JumpTable) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := c.ValidateCode(jt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
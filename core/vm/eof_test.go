@@ -0,0 +1,239 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// eofFuncContainer doubles 0x2a in a function and returns the result.
+var eofFuncContainer = &Container{
+	Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}, {Input: 1, Output: 1, MaxStackHeight: 2}},
+	Code: [][]byte{
+		common.FromHex("602a" + "b00001" + "600052" + "60206000f3"), // CALLF 1 with 0x2a, return the result
+		common.FromHex("8001" + "6001" + "5d0001" + "fe" + "b1"),    // DUP1 ADD, RJUMPI over INVALID to RETF
+	},
+}
+
+// eofSwitchContainer selects the second branch of a jump table, returning 0x2a.
+var eofSwitchContainer = &Container{
+	Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+	Code: [][]byte{
+		common.FromHex("6001" + "5e0200000005" + "60006000fd" + "602a600052" + "60206000f3"),
+	},
+	Data: []byte{0x01, 0x02},
+}
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, c := range []*Container{eofFuncContainer, eofSwitchContainer} {
+		var have Container
+		if err := have.UnmarshalBinary(c.MarshalBinary()); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if !reflect.DeepEqual(c.Types, have.Types) || !reflect.DeepEqual(c.Code, have.Code) || !bytes.Equal(c.Data, have.Data) {
+			t.Errorf("test %d: container mismatch: have %+v, want %+v", i, have, c)
+		}
+	}
+}
+
+func TestEOFHeaderErrors(t *testing.T) {
+	valid := eofSwitchContainer.MarshalBinary()
+	modify := func(pos int, b byte) []byte {
+		code := common.CopyBytes(valid)
+		code[pos] = b
+		return code
+	}
+	tests := []struct {
+		code []byte
+		err  error
+	}{
+		{common.FromHex("ef"), ErrInvalidMagic},
+		{modify(1, 0x01), ErrInvalidMagic},
+		{modify(2, 0x02), ErrInvalidVersion},
+		{valid[:5], ErrMissingTypeHeader},
+		{modify(3, kindCode), ErrMissingTypeHeader},
+		{modify(5, 0x03), ErrInvalidTypeSize},
+		{modify(6, kindData), ErrMissingCodeHeader},
+		{modify(8, 0x02), ErrInvalidCodeHeader},
+		{modify(11, kindCode), ErrMissingDataHeader},
+		{modify(14, 0x01), ErrMissingTerminator},
+		{valid[:len(valid)-1], ErrInvalidContainerSize},
+		{append(common.CopyBytes(valid), 0x00), ErrInvalidContainerSize},
+		{modify(15, 0x01), ErrInvalidSection0Type},
+		{modify(17, 0x04), ErrTooLargeMaxStackHeight},
+		{(&Container{Types: []*FunctionMetadata{{}}, Code: [][]byte{{}}}).MarshalBinary(), ErrInvalidCodeSize},
+	}
+	for i, tt := range tests {
+		var c Container
+		if err := c.UnmarshalBinary(tt.code); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestEOFValidation(t *testing.T) {
+	jt := newEOFInstructionSet(&londonInstructionSet)
+
+	section0 := func(code string, maxStack uint16) *Container {
+		return &Container{
+			Types: []*FunctionMetadata{{MaxStackHeight: maxStack}},
+			Code:  [][]byte{common.FromHex(code)},
+		}
+	}
+	tests := []struct {
+		container *Container
+		err       error
+	}{
+		{eofFuncContainer, nil},
+		{eofSwitchContainer, nil},
+		{section0("600156", 1), ErrUndefinedInstruction},          // JUMP
+		{section0("6000ff", 1), ErrUndefinedInstruction},          // SELFDESTRUCT
+		{section0("0c00", 0), ErrUndefinedInstruction},            // undefined opcode
+		{section0("60", 0), ErrTruncatedImmediate},                // PUSH1 without immediate
+		{section0("5e0000", 0), ErrInvalidBranchCount},            // empty jump table
+		{section0("600050", 1), ErrNoTerminalInstruction},         // falls off the end
+		{section0("5c0001600000", 1), ErrInvalidJumpDest},         // RJUMP into immediate
+		{section0("5cfff000", 0), ErrInvalidJumpDest},             // RJUMP before code start
+		{section0("b0000100", 0), ErrInvalidSectionArgument},      // CALLF to missing section
+		{section0("0000", 0), ErrUnreachableCode},                 // STOP STOP
+		{section0("0100", 0), ErrEOFStackUnderflow},               // ADD on empty stack
+		{section0("60015cfffb", 1), ErrConflictingStack},          // loop growing the stack
+		{section0("60016001015000", 1), ErrInvalidMaxStackHeight}, // max stack too low
+		{section0("60015d000000", 1), nil},                        // RJUMPI with zero offset
+		{section0("60015dfffb00", 1), nil},                        // loop with constant stack
+		{section0("fe", 0), nil},                                  // INVALID is terminating
+		{&Container{
+			Types: []*FunctionMetadata{{}, {Output: 1}},
+			Code:  [][]byte{common.FromHex("00"), common.FromHex("b1")},
+		}, ErrInvalidOutputs}, // RETF with too few outputs
+	}
+	for i, tt := range tests {
+		var c Container
+		if err := c.UnmarshalBinary(tt.container.MarshalBinary()); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if err := c.ValidateCode(jt); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// eofInitcode returns EOF initcode deploying the given code.
+func eofInitcode(code []byte) []byte {
+	initcode := &Container{
+		Types: []*FunctionMetadata{{MaxStackHeight: 3}},
+		Code:  [][]byte{append(common.FromHex("60ff60ff600039"), common.FromHex("60ff6000f3")...)},
+		Data:  code,
+	}
+	size := len(initcode.MarshalBinary())
+	initcode.Code[0][1] = byte(len(code))
+	initcode.Code[0][3] = byte(size - len(code))
+	initcode.Code[0][8] = byte(len(code))
+	return initcode.MarshalBinary()
+}
+
+func newEOFTestEVM(eof bool) (*EVM, *state.StateDB) {
+	config := *params.AllEthashProtocolChanges
+	if eof {
+		config.EOFTime = new(uint64)
+	}
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(1),
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	return NewEVM(vmctx, TxContext{}, statedb, &config, Config{}), statedb
+}
+
+func TestEOFExecution(t *testing.T) {
+	want := common.LeftPadBytes([]byte{0x2a * 2}, 32)
+	for i, c := range []*Container{eofFuncContainer, eofSwitchContainer} {
+		evm, statedb := newEOFTestEVM(true)
+		address := common.BytesToAddress([]byte("contract"))
+		statedb.SetCode(address, c.MarshalBinary())
+
+		ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int))
+		if err != nil {
+			t.Fatalf("test %d: call failed: %v", i, err)
+		}
+		if i == 1 {
+			want = common.LeftPadBytes([]byte{0x2a}, 32)
+		}
+		if !bytes.Equal(ret, want) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, ret, want)
+		}
+	}
+	// Without the fork, EOF code is executed as legacy code and hits 0xEF
+	evm, statedb := newEOFTestEVM(false)
+	address := common.BytesToAddress([]byte("contract"))
+	statedb.SetCode(address, eofFuncContainer.MarshalBinary())
+	if _, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int)); err == nil {
+		t.Error("EOF code executed without the fork")
+	}
+}
+
+func TestEOFCreation(t *testing.T) {
+	runtime := eofFuncContainer.MarshalBinary()
+	invalid := eofFuncContainer.MarshalBinary()
+	invalid[len(invalid)-1] = byte(ADD) // RETF replaced by ADD, falling off the end of the code
+
+	tests := []struct {
+		initcode []byte
+		eof      bool
+		err      error
+	}{
+		{eofInitcode(runtime), true, nil},
+		{eofInitcode([]byte{byte(STOP)}), true, ErrInvalidEOFCode},      // legacy code deployed by EOF
+		{eofInitcode(invalid), true, ErrInvalidEOFCode},                 // invalid EOF code deployed
+		{invalid, true, ErrInvalidEOFCode},                              // invalid initcode
+		{common.FromHex("60ef60005360016000f3"), true, ErrInvalidCode},  // legacy initcode deploying 0xEF
+		{eofInitcode(runtime), false, &ErrInvalidOpCode{opcode: 0xef}},  // EOF initcode without the fork
+		{common.FromHex("60ef60005360016000f3"), false, ErrInvalidCode}, // legacy initcode without the fork
+	}
+	for i, tt := range tests {
+		evm, statedb := newEOFTestEVM(tt.eof)
+		_, addr, _, err := evm.Create(AccountRef(common.Address{}), tt.initcode, 1000000, new(big.Int))
+		if tt.err == nil {
+			if err != nil {
+				t.Fatalf("test %d: create failed: %v", i, err)
+			}
+			if code := statedb.GetCode(addr); !bytes.Equal(code, runtime) {
+				t.Errorf("test %d: deployed code mismatch: have %x, want %x", i, code, runtime)
+			}
+			continue
+		}
+		if _, ok := tt.err.(*ErrInvalidOpCode); ok {
+			if _, ok := err.(*ErrInvalidOpCode); !ok {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
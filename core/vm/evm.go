@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"fmt"
 	"math/big"
 	"sync/atomic"
 
@@ -41,6 +42,9 @@ type (
 )
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
+	if p, ok := evm.Config.Precompiles[addr]; ok {
+		return p, true
+	}
 	var precompiles map[common.Address]PrecompiledContract
 	switch {
 	case evm.chainRules.IsBerlin:
@@ -52,8 +56,33 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
-	p, ok := precompiles[addr]
-	return p, ok
+	if p, ok := precompiles[addr]; ok {
+		return p, true
+	}
+	// Custom upgrades may enable any known precompile
+	for _, u := range evm.chainRules.Upgrades {
+		for _, enabled := range u.Precompiles {
+			if enabled == addr {
+				return knownPrecompile(addr)
+			}
+		}
+	}
+	return nil, false
+}
+
+// ActivePrecompiles returns the addresses of the precompiles enabled by the given
+// rules, extended with the extra precompiles configured for this EVM.
+func (evm *EVM) ActivePrecompiles(rules params.Rules) []common.Address {
+	active := ActivePrecompiles(rules)
+	if len(evm.Config.Precompiles) == 0 {
+		return active
+	}
+	addrs := make([]common.Address, len(active), len(active)+len(evm.Config.Precompiles))
+	copy(addrs, active)
+	for addr := range evm.Config.Precompiles {
+		addrs = append(addrs, addr)
+	}
+	return addrs
 }
 
 // BlockContext provides the EVM with auxiliary information. Once provided
@@ -120,6 +149,12 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// cheats holds the pranks and expected reverts set up via the cheatcode
+	// precompile, nil if it was never invoked.
+	cheats *cheatState
+	// budget accounts the resources consumed by the current transaction, nil if
+	// its execution is not limited beyond gas.
+	budget *budgetTracker
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -132,6 +167,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		Config:      config,
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time),
+		budget:      newBudgetTracker(config.Budget),
 	}
 	evm.interpreter = NewEVMInterpreter(evm)
 	return evm
@@ -142,6 +178,8 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+	evm.cheats = nil
+	evm.budget = newBudgetTracker(evm.Config.Budget)
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -177,6 +215,11 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	// Apply the pranks and revert expectations set up via cheatcodes
+	caller, expectRevert := evm.applyCheats(caller, addr)
+	if expectRevert != nil {
+		defer func() { ret, err = expectRevert(ret, err) }()
+	}
 	// Fail if we're trying to transfer more than the available balance
 	if value.Sign() != 0 && !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, ErrInsufficientBalance
@@ -219,7 +262,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	}
 
 	if isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(p, caller.Address(), input, gas)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -282,7 +325,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(p, caller.Address(), input, gas)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
@@ -327,7 +370,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(p, caller.Address(), input, gas)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and make initialise the delegate values
@@ -354,6 +397,11 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	// Apply the pranks and revert expectations set up via cheatcodes
+	caller, expectRevert := evm.applyCheats(caller, addr)
+	if expectRevert != nil {
+		defer func() { ret, err = expectRevert(ret, err) }()
+	}
 	// We take a snapshot here. This is a bit counter-intuitive, and could probably be skipped.
 	// However, even a staticcall is considered a 'touch'. On mainnet, static calls were introduced
 	// after all empty accounts were deleted, so this is not required. However, if we omit this,
@@ -376,7 +424,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompiledContract(p, caller.Address(), input, gas)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
 		// leak the 'contract' to the outer scope, and make allocation for 'contract'
@@ -459,15 +507,27 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	ret, err := evm.interpreter.Run(contract, nil, false)
+	// EOF initcode must be a valid container, which is checked before running it.
+	var (
+		ret []byte
+		err = evm.interpreter.validateContainer(contract)
+	)
+	if err == nil {
+		ret, err = evm.interpreter.Run(contract, nil, false)
+	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
-	if err == nil && evm.chainRules.IsEIP158 && len(ret) > params.MaxCodeSize {
+	if err == nil && evm.chainRules.IsEIP158 && len(ret) > evm.chainRules.MaxCodeSize() {
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+	if err == nil && contract.Container != nil {
+		// EOF initcode may only deploy valid EOF code.
+		if _, verr := validateEOF(ret, evm.interpreter.eofTable); verr != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidEOFCode, verr)
+		}
+	} else if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+		// Reject code starting with 0xEF if EIP-3541 is enabled.
 		err = ErrInvalidCode
 	}
 
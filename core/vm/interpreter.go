@@ -17,6 +17,9 @@
 package vm
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -30,6 +33,11 @@ type Config struct {
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int     // Additional EIPS that are to be enabled
+
+	OpCounters *BlockOpCounters // Per-opcode execution counters, nil if disabled
+	Budget     *Budget          // Resource limits of a transaction independent of gas, nil if unlimited
+
+	Precompiles map[common.Address]PrecompiledContract // Additional precompiles to enable, overriding the fork defaults
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -42,8 +50,11 @@ type ScopeContext struct {
 
 // EVMInterpreter represents an EVM interpreter
 type EVMInterpreter struct {
-	evm   *EVM
-	table *JumpTable
+	evm      *EVM
+	table    *JumpTable
+	eofTable *JumpTable // Instruction set of EOF code, nil if EOF is not enabled
+
+	containers map[common.Hash]*Container // Validated EOF containers by code hash
 
 	hasher    crypto.KeccakState // Keccak256 hasher instance shared across opcodes
 	hasherBuf common.Hash        // Keccak256 hasher result array shared aross opcodes
@@ -94,7 +105,40 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
-	return &EVMInterpreter{evm: evm, table: table}
+
+	// Apply the instruction set changes of the active custom upgrades
+	if len(evm.chainRules.Upgrades) > 0 {
+		table = applyUpgrades(table, evm.chainRules.Upgrades)
+	}
+
+	interpreter := &EVMInterpreter{evm: evm, table: table}
+	if evm.chainRules.IsEOF {
+		interpreter.eofTable = newEOFInstructionSet(table)
+		interpreter.containers = make(map[common.Hash]*Container)
+	}
+	return interpreter
+}
+
+// validateContainer parses and validates the contract's code if it is EOF
+// formatted, storing the resulting container in the contract. Validation
+// results are cached for code deployed in the state.
+func (in *EVMInterpreter) validateContainer(contract *Contract) error {
+	if in.eofTable == nil || contract.Container != nil || !hasEOFMagic(contract.Code) {
+		return nil
+	}
+	if c, ok := in.containers[contract.CodeHash]; ok && contract.CodeHash != (common.Hash{}) {
+		contract.Container = c
+		return nil
+	}
+	c, err := validateEOF(contract.Code, in.eofTable)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEOFCode, err)
+	}
+	if contract.CodeHash != (common.Hash{}) {
+		in.containers[contract.CodeHash] = c
+	}
+	contract.Container = c
+	return nil
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -123,6 +167,16 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	// EOF code runs with its own instruction set.
+	table := in.table
+	if in.eofTable != nil {
+		if err := in.validateContainer(contract); err != nil {
+			return nil, err
+		}
+		if contract.Container != nil {
+			table = in.eofTable
+		}
+	}
 
 	var (
 		op          OpCode        // current opcode
@@ -152,6 +206,12 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	}()
 	contract.Input = input
 
+	budget := in.evm.budget
+	if budget != nil {
+		defer func() {
+			budget.release(uint64(mem.Len()))
+		}()
+	}
 	if in.evm.Config.Debug {
 		defer func() {
 			if err != nil {
@@ -172,10 +232,15 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			// Capture pre-execution values for tracing.
 			logged, pcCopy, gasCopy = false, pc, contract.Gas
 		}
+		if budget != nil {
+			if err := budget.step(); err != nil {
+				return nil, err
+			}
+		}
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
-		operation := in.table[op]
+		operation := table[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := stack.len(); sLen < operation.minStack {
@@ -217,6 +282,11 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 				in.evm.Config.Tracer.CaptureState(pc, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
 				logged = true
 			}
+			if budget != nil && memorySize > uint64(mem.Len()) {
+				if err := budget.grow(memorySize - uint64(mem.Len())); err != nil {
+					return nil, err
+				}
+			}
 			if memorySize > 0 {
 				mem.Resize(memorySize)
 			}
@@ -225,7 +295,13 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			logged = true
 		}
 		// execute the operation
-		res, err = operation.execute(&pc, in, callContext)
+		if counters := in.evm.Config.OpCounters; counters != nil {
+			start := time.Now()
+			res, err = operation.execute(&pc, in, callContext)
+			counters.record(op, cost, time.Since(start))
+		} else {
+			res, err = operation.execute(&pc, in, callContext)
+		}
 		if err != nil {
 			break
 		}
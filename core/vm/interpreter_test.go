@@ -17,6 +17,8 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
@@ -74,3 +76,124 @@ func TestLoopInterrupt(t *testing.T) {
 		}
 	}
 }
+
+func TestUpgradeOverrides(t *testing.T) {
+	var (
+		config  = *params.AllEthashProtocolChanges
+		address = common.BytesToAddress([]byte("contract"))
+		bls     = common.BytesToAddress([]byte{10})
+		maxSize = uint64(1)
+		statedb = func() StateDB {
+			statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+			statedb.CreateAccount(address)
+			statedb.SetCode(address, []byte{byte(CHAINID)})
+			return statedb
+		}()
+	)
+	config.Upgrades = []*params.Upgrade{{
+		Name:            "experiment",
+		Block:           big.NewInt(10),
+		MaxCodeSize:     &maxSize,
+		Precompiles:     []common.Address{bls},
+		DisabledOpcodes: []string{"CHAINID"},
+	}}
+	for _, number := range []int64{9, 10} {
+		vmctx := BlockContext{
+			BlockNumber: big.NewInt(number),
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		}
+		var (
+			evm      = NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+			active   = number >= 10
+			_, isBLS = evm.precompile(bls)
+		)
+		if isBLS != active {
+			t.Errorf("block %d: precompile activation mismatch: have %v, want %v", number, isBLS, active)
+		}
+		if have := containsAddress(evm.ActivePrecompiles(evm.chainRules), bls); have != active {
+			t.Errorf("block %d: active precompiles mismatch: have %v, want %v", number, have, active)
+		}
+		_, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int))
+		if disabled := err != nil; disabled != active {
+			t.Errorf("block %d: opcode disabling mismatch: have %v, want %v", number, err, active)
+		}
+		// Deploy code returning two bytes: push1 2 push1 0 return
+		_, _, _, err = evm.Create(AccountRef(common.Address{}), []byte{byte(PUSH1), 2, byte(PUSH1), 0, byte(RETURN)}, 100000, new(big.Int))
+		if limited := err == ErrMaxCodeSizeExceeded; limited != active {
+			t.Errorf("block %d: code size limit mismatch: have %v, want %v", number, err, active)
+		}
+	}
+	// The global jump tables must not be affected
+	if mergeInstructionSet[CHAINID].undefined {
+		t.Fatalf("upgrade polluted the global jump table")
+	}
+}
+
+// Tests that custom upgrades changing the instruction set in ways unknown to the
+// EVM are rejected when the chain configuration is checked.
+func TestUpgradeChecks(t *testing.T) {
+	tests := []struct {
+		upgrade *params.Upgrade
+		valid   bool
+	}{
+		{&params.Upgrade{EIPs: []int{3855}, DisabledOpcodes: []string{"CHAINID"}}, true},
+		{&params.Upgrade{EIPs: []int{1}}, false},
+		{&params.Upgrade{DisabledOpcodes: []string{"NOSUCHOP"}}, false},
+	}
+	for i, tt := range tests {
+		tt.upgrade.Name, tt.upgrade.Block = "experiment", big.NewInt(10)
+		config := &params.ChainConfig{Upgrades: []*params.Upgrade{tt.upgrade}}
+		if err := config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	var (
+		loop   = common.BytesToAddress([]byte("loop"))
+		memory = common.BytesToAddress([]byte("memory"))
+		caller = common.BytesToAddress([]byte("caller"))
+		vmctx  = BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	// infinite loop using JUMP: push(2) jumpdest dup1 jump
+	statedb.SetCode(loop, common.Hex2Bytes("60025b8056"))
+	// mstore(0x100000, 0)
+	statedb.SetCode(memory, common.Hex2Bytes("60006210000052"))
+	// call(gas, loop, 0, 0, 0, 0, 0) stop: the failed call must not be caught
+	statedb.SetCode(caller, append(append(common.Hex2Bytes("600060006000600060007"+"3"), loop.Bytes()...), common.Hex2Bytes("5af100")...))
+	statedb.Finalise(true)
+
+	expired, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		budget *Budget
+		target common.Address
+		gas    uint64
+		want   error
+	}{
+		{&Budget{Steps: 1000}, loop, 10_000_000, ErrStepLimitReached},
+		{&Budget{Steps: 1000}, caller, 10_000_000, ErrStepLimitReached},
+		{&Budget{Steps: 1000}, memory, 10_000_000, nil},
+		{&Budget{Memory: 1 << 20}, memory, 10_000_000, ErrMemoryLimitReached},
+		{&Budget{Memory: 1<<20 + 32}, memory, 10_000_000, nil},
+		{&Budget{Context: expired}, loop, math.MaxUint64, ErrExecutionAborted},
+	}
+	for i, tt := range tests {
+		evm := NewEVM(vmctx, TxContext{}, statedb.Copy(), params.AllEthashProtocolChanges, Config{Budget: tt.budget})
+		_, _, err := evm.Call(AccountRef(common.Address{}), tt.target, nil, tt.gas, new(big.Int))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, tt.want)
+		}
+		if evm.budget.memory != 0 {
+			t.Errorf("test %d: memory not released: %d", i, evm.budget.memory)
+		}
+	}
+}
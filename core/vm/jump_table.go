@@ -42,6 +42,9 @@ type operation struct {
 
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
+
+	// undefined denotes if the instruction is not officially defined in the jump table
+	undefined bool
 }
 
 var (
@@ -79,6 +82,21 @@ func validate(jt JumpTable) JumpTable {
 	return jt
 }
 
+// newEOFInstructionSet returns the instructions available to EOF formatted code
+// on top of the given legacy instruction set.
+func newEOFInstructionSet(base *JumpTable) *JumpTable {
+	instructionSet := copyJumpTable(base)
+	for op := PUSH1; op <= PUSH32; op++ {
+		// Immediates are read from the current code section
+		instructionSet[op].execute = makeEOFPush(uint64(op - PUSH0))
+	}
+	enable3670(instructionSet) // EOF - Code Validation
+	enable4200(instructionSet) // EOF - Static relative jumps
+	enable4750(instructionSet) // EOF - Functions
+	validate(*instructionSet)
+	return instructionSet
+}
+
 func newShanghaiInstructionSet() JumpTable {
 	instructionSet := newMergeInstructionSet()
 	enable3855(&instructionSet) // PUSH0 instruction
@@ -1045,7 +1063,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined, maxStack: maxStack(0, 0)}
+			tbl[i] = &operation{execute: opUndefined, maxStack: maxStack(0, 0), undefined: true}
 		}
 	}
 
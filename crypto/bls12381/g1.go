@@ -158,6 +158,76 @@ func (g *G1) EncodePoint(p *PointG1) []byte {
 	return out
 }
 
+// FromCompressed decodes a point from its 48 byte compressed form, following
+// the zcash serialization format. The point is checked to be in the correct
+// subgroup.
+func (g *G1) FromCompressed(compressed []byte) (*PointG1, error) {
+	if len(compressed) != 48 {
+		return nil, errors.New("input string should be equal to 48 bytes")
+	}
+	compression, infinity, largest := compressed[0]&(1<<7) != 0, compressed[0]&(1<<6) != 0, compressed[0]&(1<<5) != 0
+	if !compression {
+		return nil, errors.New("compression flag should be set")
+	}
+	if infinity {
+		if largest {
+			return nil, errors.New("infinity point flag should be set alone")
+		}
+		for i := 1; i < 48; i++ {
+			if compressed[i] != 0 {
+				return nil, errors.New("infinity point flag should be set alone")
+			}
+		}
+		return g.Zero(), nil
+	}
+	in := make([]byte, 48)
+	copy(in, compressed)
+	in[0] &= 0x1f
+	x, err := fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// Solve the curve equation y^2 = x^3 + 4 for y
+	y := new(fe)
+	square(y, x)
+	mul(y, y, x)
+	add(y, y, b)
+	if !sqrt(y, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	if isLexicographicallyLargest(y) != largest {
+		neg(y, y)
+	}
+	p := &PointG1{*x, *y, *new(fe).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not on correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed encodes a point into its 48 byte compressed form, following
+// the zcash serialization format.
+func (g *G1) ToCompressed(p *PointG1) []byte {
+	out := make([]byte, 48)
+	if g.IsZero(p) {
+		out[0] |= 1 << 6
+	} else {
+		g.Affine(p)
+		copy(out, toBytes(&p[0]))
+		if isLexicographicallyLargest(&p[1]) {
+			out[0] |= 1 << 5
+		}
+	}
+	out[0] |= 1 << 7
+	return out
+}
+
+// isLexicographicallyLargest reports whether the field element is larger than
+// its negation.
+func isLexicographicallyLargest(e *fe) bool {
+	return toBig(e).Cmp(pMinus1Over2) > 0
+}
+
 // New creates a new G1 Point which is equal to zero in other words point at infinity.
 func (g *G1) New() *PointG1 {
 	return g.Zero()
@@ -178,6 +178,79 @@ func (g *G2) EncodePoint(p *PointG2) []byte {
 	return out
 }
 
+// FromCompressed decodes a point from its 96 byte compressed form, following
+// the zcash serialization format. The point is checked to be in the correct
+// subgroup.
+func (g *G2) FromCompressed(compressed []byte) (*PointG2, error) {
+	if len(compressed) != 96 {
+		return nil, errors.New("input string should be equal to 96 bytes")
+	}
+	compression, infinity, largest := compressed[0]&(1<<7) != 0, compressed[0]&(1<<6) != 0, compressed[0]&(1<<5) != 0
+	if !compression {
+		return nil, errors.New("compression flag should be set")
+	}
+	if infinity {
+		if largest {
+			return nil, errors.New("infinity point flag should be set alone")
+		}
+		for i := 1; i < 96; i++ {
+			if compressed[i] != 0 {
+				return nil, errors.New("infinity point flag should be set alone")
+			}
+		}
+		return g.Zero(), nil
+	}
+	in := make([]byte, 96)
+	copy(in, compressed)
+	in[0] &= 0x1f
+	x, err := g.f.fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// Solve the curve equation y^2 = x^3 + 4(u + 1) for y
+	y := new(fe2)
+	g.f.square(y, x)
+	g.f.mul(y, y, x)
+	g.f.add(y, y, b2)
+	if !g.f.sqrt(y, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	if isLexicographicallyLargest2(y) != largest {
+		g.f.neg(y, y)
+	}
+	p := &PointG2{*x, *y, *new(fe2).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not on correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed encodes a point into its 96 byte compressed form, following
+// the zcash serialization format.
+func (g *G2) ToCompressed(p *PointG2) []byte {
+	out := make([]byte, 96)
+	if g.IsZero(p) {
+		out[0] |= 1 << 6
+	} else {
+		g.Affine(p)
+		copy(out, g.f.toBytes(&p[0]))
+		if isLexicographicallyLargest2(&p[1]) {
+			out[0] |= 1 << 5
+		}
+	}
+	out[0] |= 1 << 7
+	return out
+}
+
+// isLexicographicallyLargest2 reports whether the extension field element is
+// larger than its negation, comparing the imaginary parts first.
+func isLexicographicallyLargest2(e *fe2) bool {
+	if !e[1].isZero() {
+		return isLexicographicallyLargest(&e[1])
+	}
+	return isLexicographicallyLargest(&e[0])
+}
+
 // New creates a new G2 Point which is equal to zero in other words point at infinity.
 func (g *G2) New() *PointG2 {
 	return new(PointG2).Zero()
@@ -55,6 +55,30 @@ func TestG2Serialization(t *testing.T) {
 	}
 }
 
+func TestG2CompressedSerialization(t *testing.T) {
+	g2 := NewG2()
+	for i := 0; i < fuz; i++ {
+		a := g2.rand()
+		b, err := g2.FromCompressed(g2.ToCompressed(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g2.Equal(a, b) {
+			t.Fatal("bad compressed serialization from/to")
+		}
+	}
+	zero := g2.ToCompressed(g2.Zero())
+	if zero[0] != 0xc0 {
+		t.Fatal("bad compressed encoding of the point at infinity")
+	}
+	if p, err := g2.FromCompressed(zero); err != nil || !g2.IsZero(p) {
+		t.Fatal("bad compressed decoding of the point at infinity")
+	}
+	if _, err := g2.FromCompressed(g2.ToBytes(g2.one())[:96]); err == nil {
+		t.Fatal("uncompressed encoding accepted")
+	}
+}
+
 func TestG2IsOnCurve(t *testing.T) {
 	g := NewG2()
 	zero := g.Zero()
@@ -255,6 +279,33 @@ func TestG2MapToCurve(t *testing.T) {
 	}
 }
 
+func TestExpandMsgXMD(t *testing.T) {
+	// Test vector from RFC 9380, appendix K.1
+	out, err := expandMsgXMD(nil, []byte("QUUX-V01-CS02-with-expander-SHA256-128"), 0x20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.FromHex("68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"); !bytes.Equal(out, want) {
+		t.Fatalf("expand message mismatch: have %x, want %x", out, want)
+	}
+}
+
+func TestG2HashToCurve(t *testing.T) {
+	// Test vector from RFC 9380, appendix J.10.1
+	g := NewG2()
+	p, err := g.HashToCurve(nil, []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.FromHex("05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d" + "0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a")
+	if have := g.ToBytes(p)[:96]; !bytes.Equal(have, want) {
+		t.Fatalf("hash to curve mismatch: have %x, want %x", have, want)
+	}
+	if !g.InCorrectSubgroup(p) {
+		t.Fatal("hashed point not in the correct subgroup")
+	}
+}
+
 func BenchmarkG2Add(t *testing.B) {
 	g2 := NewG2()
 	a, b, c := g2.rand(), g2.rand(), PointG2{}
@@ -0,0 +1,104 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// HashToCurve hashes a message to a G2 point with the BLS12381G2_XMD:SHA-256_SSWU_RO_
+// suite of RFC 9380, using the given domain separation tag.
+func (g *G2) HashToCurve(msg, dst []byte) (*PointG2, error) {
+	elems, err := hashToFp(msg, dst, 4)
+	if err != nil {
+		return nil, err
+	}
+	// MapToCurve clears the cofactor of both points, which is equivalent to
+	// clearing it once on their sum.
+	u0 := append(elems[1], elems[0]...)
+	u1 := append(elems[3], elems[2]...)
+	p0, err := g.MapToCurve(u0)
+	if err != nil {
+		return nil, err
+	}
+	p1, err := g.MapToCurve(u1)
+	if err != nil {
+		return nil, err
+	}
+	return g.Affine(g.Add(p0, p0, p1)), nil
+}
+
+// hashToFp hashes a message to count base field elements, returned in their
+// 48 byte big endian encoding.
+func hashToFp(msg, dst []byte, count int) ([][]byte, error) {
+	const l = 64 // ceil((ceil(log2(p)) + k) / 8) with k = 128
+	uniform, err := expandMsgXMD(msg, dst, count*l)
+	if err != nil {
+		return nil, err
+	}
+	p := modulus.big()
+	elems := make([][]byte, count)
+	for i := range elems {
+		e := new(big.Int).SetBytes(uniform[i*l : (i+1)*l])
+		e.Mod(e, p)
+		elems[i] = e.FillBytes(make([]byte, 48))
+	}
+	return elems, nil
+}
+
+// expandMsgXMD implements expand_message_xmd of RFC 9380 with SHA-256.
+func expandMsgXMD(msg, dst []byte, outLen int) ([]byte, error) {
+	const b = sha256.Size
+	ell := (outLen + b - 1) / b
+	if ell > 255 || outLen > 65535 {
+		return nil, errors.New("requested output too long")
+	}
+	if len(dst) > 255 {
+		return nil, errors.New("domain separation tag too long")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, h.BlockSize())) // Z_pad
+	h.Write(msg)
+	h.Write([]byte{byte(outLen >> 8), byte(outLen), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*b)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		h.Reset()
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:outLen], nil
+}
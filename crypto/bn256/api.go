@@ -0,0 +1,193 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bn256
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Sizes of the binary encodings used by the EVM precompiles (EIP-196, EIP-197).
+const (
+	G1Size     = 64              // Uncompressed G1 point: x || y
+	G2Size     = 128             // Uncompressed G2 point: x.imag || x.real || y.imag || y.real
+	ScalarSize = 32              // Big endian scalar
+	PairSize   = G1Size + G2Size // One (G1, G2) pair of a pairing check
+)
+
+var (
+	// ErrBadPairingInput is returned if the pairing input is not a multiple of
+	// the pair size.
+	ErrBadPairingInput = errors.New("bad elliptic curve pairing size")
+)
+
+// UnmarshalG1 decodes a 64 byte G1 point, returning an error if the point is
+// not on the curve. The all-zero encoding denotes the point at infinity.
+func UnmarshalG1(blob []byte) (*G1, error) {
+	p := new(G1)
+	if _, err := p.Unmarshal(blob); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UnmarshalG2 decodes a 128 byte G2 point, returning an error if the point is
+// not on the twist or not in the correct subgroup. The all-zero encoding denotes
+// the point at infinity.
+func UnmarshalG2(blob []byte) (*G2, error) {
+	p := new(G2)
+	if _, err := p.Unmarshal(blob); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NegG1 returns the negation of the G1 point a.
+func NegG1(a *G1) *G1 {
+	return new(G1).Neg(a)
+}
+
+// ECAdd adds two G1 points, exactly as the ecAdd precompile at address 0x06.
+// The input is right padded with zeroes (or truncated) to 128 bytes.
+func ECAdd(input []byte) ([]byte, error) {
+	x, err := UnmarshalG1(getData(input, 0, G1Size))
+	if err != nil {
+		return nil, err
+	}
+	y, err := UnmarshalG1(getData(input, G1Size, G1Size))
+	if err != nil {
+		return nil, err
+	}
+	res := new(G1)
+	res.Add(x, y)
+	return res.Marshal(), nil
+}
+
+// ECMul multiplies a G1 point by a scalar, exactly as the ecMul precompile at
+// address 0x07. The input is right padded with zeroes (or truncated) to 96 bytes.
+func ECMul(input []byte) ([]byte, error) {
+	p, err := UnmarshalG1(getData(input, 0, G1Size))
+	if err != nil {
+		return nil, err
+	}
+	res := new(G1)
+	res.ScalarMult(p, new(big.Int).SetBytes(getData(input, G1Size, ScalarSize)))
+	return res.Marshal(), nil
+}
+
+// ECPairing runs a pairing check over a list of (G1, G2) pairs, exactly as the
+// ecPairing precompile at address 0x08. The result is a 32 byte big endian 1 if
+// the product of the pairings is the identity, 0 otherwise. An empty input is
+// considered a successful check.
+func ECPairing(input []byte) ([]byte, error) {
+	// Handle some corner cases cheaply
+	if len(input)%PairSize > 0 {
+		return nil, ErrBadPairingInput
+	}
+	// Convert the input into a set of coordinates
+	var (
+		cs []*G1
+		ts []*G2
+	)
+	for i := 0; i < len(input); i += PairSize {
+		c, err := UnmarshalG1(input[i : i+G1Size])
+		if err != nil {
+			return nil, err
+		}
+		t, err := UnmarshalG2(input[i+G1Size : i+PairSize])
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+		ts = append(ts, t)
+	}
+	// Execute the pairing checks and return the results. The result is freshly
+	// allocated on every call, so callers are free to modify it.
+	res := make([]byte, 32)
+	if PairingCheck(cs, ts) {
+		res[31] = 1
+	}
+	return res, nil
+}
+
+// HashToG1 deterministically maps an arbitrary message to a G1 point, using
+// try-and-increment over keccak256(domain || msg || counter). As G1 has a
+// cofactor of one, every curve point found this way is in the group.
+//
+// The mapping is simple to replicate in Solidity, but it is not constant time
+// and is not the RFC 9380 hash-to-curve construction, so it must not be used
+// with secret inputs.
+func HashToG1(domain, msg []byte) *G1 {
+	var (
+		hasher = sha3.NewLegacyKeccak256()
+		ctr    [4]byte
+		x      = new(big.Int)
+		rhs    = new(big.Int)
+		y      = new(big.Int)
+		three  = big.NewInt(3)
+	)
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(ctr[:], i)
+
+		hasher.Reset()
+		hasher.Write(domain)
+		hasher.Write(msg)
+		hasher.Write(ctr[:])
+		x.SetBytes(hasher.Sum(nil))
+		x.Mod(x, P)
+
+		// Check whether x^3 + 3 is a quadratic residue
+		rhs.Exp(x, three, P)
+		rhs.Add(rhs, three)
+		rhs.Mod(rhs, P)
+		if y.ModSqrt(rhs, P) == nil {
+			continue
+		}
+		// Pick the smaller root to make the result unique
+		if alt := new(big.Int).Sub(P, y); alt.Cmp(y) < 0 {
+			y = alt
+		}
+		blob := make([]byte, G1Size)
+		x.FillBytes(blob[:32])
+		y.FillBytes(blob[32:])
+
+		p, err := UnmarshalG1(blob)
+		if err != nil {
+			panic(err) // Can't happen, the point is on the curve
+		}
+		return p
+	}
+}
+
+// getData returns a slice from the data based on the start and size and pads
+// up to size with zero's.
+func getData(data []byte, start uint64, size uint64) []byte {
+	length := uint64(len(data))
+	if start > length {
+		start = length
+	}
+	end := start + size
+	if end > length {
+		end = length
+	}
+	padded := make([]byte, size)
+	copy(padded, data[start:end])
+	return padded
+}
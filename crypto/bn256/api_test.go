@@ -0,0 +1,101 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bn256
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+var (
+	pairingTrue  = append(make([]byte, 31), 1)
+	pairingFalse = make([]byte, 32)
+)
+
+func TestECPairingBilinearity(t *testing.T) {
+	a, b := big.NewInt(7), big.NewInt(11)
+
+	// e(a*G1, b*G2) * e(-(a*b)*G1, G2) == 1
+	p1 := new(G1).ScalarBaseMult(a)
+	q1 := new(G2).ScalarBaseMult(b)
+	p2 := NegG1(new(G1).ScalarBaseMult(new(big.Int).Mul(a, b)))
+	q2 := new(G2).ScalarBaseMult(big.NewInt(1))
+
+	var input []byte
+	input = append(input, p1.Marshal()...)
+	input = append(input, q1.Marshal()...)
+	input = append(input, p2.Marshal()...)
+	input = append(input, q2.Marshal()...)
+
+	res, err := ECPairing(input)
+	if err != nil {
+		t.Fatalf("pairing failed: %v", err)
+	}
+	if !bytes.Equal(res, pairingTrue) {
+		t.Fatalf("pairing check failed: %x", res)
+	}
+	// Mutating a result must not leak into subsequent ones
+	res[31] = 0
+	if res, _ = ECPairing(input); !bytes.Equal(res, pairingTrue) {
+		t.Fatalf("pairing result aliased across calls: %x", res)
+	}
+	// Breaking the relation must fail the check
+	copy(input[PairSize:], new(G1).ScalarBaseMult(a).Marshal())
+	if res, _ = ECPairing(input); !bytes.Equal(res, pairingFalse) {
+		t.Fatalf("invalid pairing check passed")
+	}
+	if _, err := ECPairing(input[1:]); err != ErrBadPairingInput {
+		t.Fatalf("unexpected error: have %v, want %v", err, ErrBadPairingInput)
+	}
+}
+
+func TestECAddMul(t *testing.T) {
+	g := new(G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+
+	sum, err := ECAdd(append(append([]byte{}, g...), g...))
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	prod, err := ECMul(append(append([]byte{}, g...), big.NewInt(2).FillBytes(make([]byte, ScalarSize))...))
+	if err != nil {
+		t.Fatalf("mul failed: %v", err)
+	}
+	if !bytes.Equal(sum, prod) {
+		t.Fatalf("G+G != 2G: %x != %x", sum, prod)
+	}
+	// Short inputs are zero padded, so adding nothing yields infinity
+	if res, err := ECAdd(nil); err != nil || !bytes.Equal(res, make([]byte, G1Size)) {
+		t.Fatalf("empty add mismatch: %x, %v", res, err)
+	}
+}
+
+func TestHashToG1(t *testing.T) {
+	p1 := HashToG1([]byte("domain"), []byte("message"))
+	p2 := HashToG1([]byte("domain"), []byte("message"))
+	if !bytes.Equal(p1.Marshal(), p2.Marshal()) {
+		t.Fatalf("hash to curve not deterministic")
+	}
+	p3 := HashToG1([]byte("other"), []byte("message"))
+	if bytes.Equal(p1.Marshal(), p3.Marshal()) {
+		t.Fatalf("domain not separated")
+	}
+	// The result must be a valid group element: p*Order == infinity
+	if res := new(G1).ScalarMult(p1, Order).Marshal(); !bytes.Equal(res, make([]byte, G1Size)) {
+		t.Fatalf("point not in G1")
+	}
+}
@@ -12,6 +12,12 @@ import (
 	bn256cf "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
 )
 
+// Order is the number of elements in both G1 and G2.
+var Order = bn256cf.Order
+
+// P is the modulus of the base field of the curve.
+var P = bn256cf.P
+
 // G1 is an abstract cyclic group. The zero value is suitable for use as the
 // output of an operation, but cannot be used as an input.
 type G1 = bn256cf.G1
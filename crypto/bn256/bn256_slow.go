@@ -10,6 +10,12 @@ package bn256
 
 import bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
 
+// Order is the number of elements in both G1 and G2.
+var Order = bn256.Order
+
+// P is the modulus of the base field of the curve.
+var P = bn256.P
+
 // G1 is an abstract cyclic group. The zero value is suitable for use as the
 // output of an operation, but cannot be used as an input.
 type G1 = bn256.G1
@@ -0,0 +1,279 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package commitreveal implements hash commitments and a commit-reveal protocol
+// producing RANDAO style shared randomness.
+//
+// Commitments are keccak256 hashes of the tightly packed arguments, matching
+// keccak256(abi.encodePacked(...)) in Solidity, so they can be checked by
+// contracts on chain.
+package commitreveal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Commit returns the commitment to the value with the given salt, i.e. the
+// keccak256 hash of value and salt.
+func Commit(value, salt common.Hash) common.Hash {
+	return crypto.Keccak256Hash(value[:], salt[:])
+}
+
+// CommitFor returns the commitment of the sender to the value with the given
+// salt, i.e. the keccak256 hash of sender, value and salt. Binding commitments
+// to their sender keeps others from replaying them.
+func CommitFor(sender common.Address, value, salt common.Hash) common.Hash {
+	return crypto.Keccak256Hash(sender[:], value[:], salt[:])
+}
+
+// Verify checks that the commitment opens to the value with the given salt.
+func Verify(commitment, value, salt common.Hash) bool {
+	return Commit(value, salt) == commitment
+}
+
+// VerifyFor checks that the commitment of the sender opens to the value with the
+// given salt.
+func VerifyFor(commitment common.Hash, sender common.Address, value, salt common.Hash) bool {
+	return CommitFor(sender, value, salt) == commitment
+}
+
+// NewSalt returns a random salt. Salts must never be reused, as they are what
+// keeps values with few possibilities from being brute forced.
+func NewSalt() (common.Hash, error) {
+	var salt common.Hash
+	if _, err := rand.Read(salt[:]); err != nil {
+		return common.Hash{}, err
+	}
+	return salt, nil
+}
+
+// Opening is a committed value along with the salt needed to reveal it.
+type Opening struct {
+	Value common.Hash
+	Salt  common.Hash
+}
+
+// NewOpening creates an opening of the value with a random salt.
+func NewOpening(value common.Hash) (*Opening, error) {
+	salt, err := NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	return &Opening{Value: value, Salt: salt}, nil
+}
+
+// Commitment returns the commitment of the sender to the opened value.
+func (o *Opening) Commitment(sender common.Address) common.Hash {
+	return CommitFor(sender, o.Value, o.Salt)
+}
+
+// Phase is a phase of a commit-reveal round.
+type Phase int
+
+const (
+	PhaseCommit Phase = iota // Participants submit commitments
+	PhaseReveal              // Participants open their commitments
+	PhaseDone                // The round is finalized
+)
+
+// String implements fmt.Stringer.
+func (p Phase) String() string {
+	switch p {
+	case PhaseCommit:
+		return "commit"
+	case PhaseReveal:
+		return "reveal"
+	case PhaseDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrWrongPhase       = errors.New("commitreveal: wrong phase")
+	ErrAlreadyCommitted = errors.New("commitreveal: participant already committed")
+	ErrNotCommitted     = errors.New("commitreveal: participant did not commit")
+	ErrAlreadyRevealed  = errors.New("commitreveal: participant already revealed")
+	ErrInvalidOpening   = errors.New("commitreveal: opening does not match commitment")
+	ErrNoReveals        = errors.New("commitreveal: no commitment revealed")
+)
+
+// Round is a single round of the commit-reveal protocol between participants
+// identified by their addresses. Commitments are bound to their sender, see
+// CommitFor.
+//
+// The result of a round is the RANDAO mix of all revealed values: the XOR of
+// their keccak256 hashes. Participants can bias the result by withholding their
+// reveal, so callers should penalize participants failing to reveal.
+type Round struct {
+	phase       Phase
+	commitments map[common.Address]common.Hash
+	reveals     map[common.Address]common.Hash
+	mix         common.Hash
+	lock        sync.Mutex
+}
+
+// NewRound creates a round in the commit phase.
+func NewRound() *Round {
+	return &Round{
+		commitments: make(map[common.Address]common.Hash),
+		reveals:     make(map[common.Address]common.Hash),
+	}
+}
+
+// Phase returns the current phase of the round.
+func (r *Round) Phase() Phase {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.phase
+}
+
+// Commit records the commitment of a participant.
+func (r *Round) Commit(participant common.Address, commitment common.Hash) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.phase != PhaseCommit {
+		return ErrWrongPhase
+	}
+	if _, ok := r.commitments[participant]; ok {
+		return ErrAlreadyCommitted
+	}
+	r.commitments[participant] = commitment
+	return nil
+}
+
+// StartReveal ends the commit phase, no more commitments are accepted.
+func (r *Round) StartReveal() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.phase != PhaseCommit {
+		return ErrWrongPhase
+	}
+	r.phase = PhaseReveal
+	return nil
+}
+
+// Reveal opens the commitment of a participant.
+func (r *Round) Reveal(participant common.Address, opening *Opening) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.phase != PhaseReveal {
+		return ErrWrongPhase
+	}
+	commitment, ok := r.commitments[participant]
+	if !ok {
+		return ErrNotCommitted
+	}
+	if _, ok := r.reveals[participant]; ok {
+		return ErrAlreadyRevealed
+	}
+	if !VerifyFor(commitment, participant, opening.Value, opening.Salt) {
+		return ErrInvalidOpening
+	}
+	r.reveals[participant] = opening.Value
+	return nil
+}
+
+// Finalize ends the reveal phase and returns the RANDAO mix of the revealed
+// values. It fails if no commitment was revealed.
+func (r *Round) Finalize() (common.Hash, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.phase != PhaseReveal {
+		return common.Hash{}, ErrWrongPhase
+	}
+	if len(r.reveals) == 0 {
+		return common.Hash{}, ErrNoReveals
+	}
+	var mix common.Hash
+	for _, value := range r.reveals {
+		hash := crypto.Keccak256Hash(value[:])
+		for i := range mix {
+			mix[i] ^= hash[i]
+		}
+	}
+	r.mix = mix
+	r.phase = PhaseDone
+	return mix, nil
+}
+
+// Result returns the RANDAO mix of a finalized round.
+func (r *Round) Result() (common.Hash, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.phase != PhaseDone {
+		return common.Hash{}, ErrWrongPhase
+	}
+	return r.mix, nil
+}
+
+// Committed returns the participants that committed, ordered by address.
+func (r *Round) Committed() []common.Address {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return sortedAddresses(r.commitments, nil)
+}
+
+// Revealed returns the participants that revealed their commitments and their
+// revealed values.
+func (r *Round) Revealed() map[common.Address]common.Hash {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	reveals := make(map[common.Address]common.Hash, len(r.reveals))
+	for addr, value := range r.reveals {
+		reveals[addr] = value
+	}
+	return reveals
+}
+
+// Unrevealed returns the participants that committed but did not reveal yet,
+// ordered by address.
+func (r *Round) Unrevealed() []common.Address {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return sortedAddresses(r.commitments, r.reveals)
+}
+
+// sortedAddresses returns the addresses of set not in exclude, in order.
+func sortedAddresses(set, exclude map[common.Address]common.Hash) []common.Address {
+	addrs := make([]common.Address, 0, len(set))
+	for addr := range set {
+		if _, ok := exclude[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
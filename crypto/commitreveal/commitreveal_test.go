@@ -0,0 +1,142 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package commitreveal
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCommit(t *testing.T) {
+	var (
+		value  = common.HexToHash("0x01")
+		salt   = common.HexToHash("0x02")
+		sender = common.HexToAddress("0x03")
+	)
+	// keccak256(abi.encodePacked(value, salt)) and the sender bound variant
+	if have, want := Commit(value, salt), crypto.Keccak256Hash(append(value.Bytes(), salt.Bytes()...)); have != want {
+		t.Errorf("commitment mismatch: have %x, want %x", have, want)
+	}
+	packed := append(append(sender.Bytes(), value.Bytes()...), salt.Bytes()...)
+	if have, want := CommitFor(sender, value, salt), crypto.Keccak256Hash(packed); have != want {
+		t.Errorf("sender commitment mismatch: have %x, want %x", have, want)
+	}
+	if !Verify(Commit(value, salt), value, salt) {
+		t.Error("valid opening rejected")
+	}
+	if Verify(Commit(value, salt), value, common.Hash{}) {
+		t.Error("opening with wrong salt accepted")
+	}
+	if VerifyFor(CommitFor(sender, value, salt), common.Address{}, value, salt) {
+		t.Error("opening for wrong sender accepted")
+	}
+	a, _ := NewSalt()
+	b, _ := NewSalt()
+	if a == b || a == (common.Hash{}) {
+		t.Errorf("salts not random: %x %x", a, b)
+	}
+}
+
+func TestRound(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0xa1")
+		bob   = common.HexToAddress("0xb0b")
+		carol = common.HexToAddress("0xc0")
+		round = NewRound()
+	)
+	openings := make(map[common.Address]*Opening)
+	for i, addr := range []common.Address{carol, alice, bob} {
+		opening, err := NewOpening(common.BigToHash(big.NewInt(int64(i + 1))))
+		if err != nil {
+			t.Fatalf("failed to create opening: %v", err)
+		}
+		openings[addr] = opening
+		if err := round.Commit(addr, opening.Commitment(addr)); err != nil {
+			t.Fatalf("commit of %x failed: %v", addr, err)
+		}
+	}
+	if err := round.Commit(alice, common.Hash{}); !errors.Is(err, ErrAlreadyCommitted) {
+		t.Errorf("double commit: have %v, want %v", err, ErrAlreadyCommitted)
+	}
+	if err := round.Reveal(alice, openings[alice]); !errors.Is(err, ErrWrongPhase) {
+		t.Errorf("early reveal: have %v, want %v", err, ErrWrongPhase)
+	}
+	if _, err := round.Finalize(); !errors.Is(err, ErrWrongPhase) {
+		t.Errorf("early finalize: have %v, want %v", err, ErrWrongPhase)
+	}
+	if err := round.StartReveal(); err != nil {
+		t.Fatalf("failed to start reveal phase: %v", err)
+	}
+	if phase := round.Phase(); phase != PhaseReveal {
+		t.Fatalf("have phase %v, want %v", phase, PhaseReveal)
+	}
+	if err := round.Commit(common.Address{}, common.Hash{}); !errors.Is(err, ErrWrongPhase) {
+		t.Errorf("late commit: have %v, want %v", err, ErrWrongPhase)
+	}
+	// Bob may not reveal Alice's value, nor replay her commitment
+	if err := round.Reveal(bob, openings[alice]); !errors.Is(err, ErrInvalidOpening) {
+		t.Errorf("foreign reveal: have %v, want %v", err, ErrInvalidOpening)
+	}
+	if err := round.Reveal(common.Address{}, openings[alice]); !errors.Is(err, ErrNotCommitted) {
+		t.Errorf("reveal without commitment: have %v, want %v", err, ErrNotCommitted)
+	}
+	for _, addr := range []common.Address{alice, bob} {
+		if err := round.Reveal(addr, openings[addr]); err != nil {
+			t.Fatalf("reveal of %x failed: %v", addr, err)
+		}
+	}
+	if err := round.Reveal(alice, openings[alice]); !errors.Is(err, ErrAlreadyRevealed) {
+		t.Errorf("double reveal: have %v, want %v", err, ErrAlreadyRevealed)
+	}
+	if have, want := round.Committed(), []common.Address{alice, carol, bob}; !reflect.DeepEqual(have, want) {
+		t.Errorf("committed mismatch: have %x, want %x", have, want)
+	}
+	if have, want := round.Unrevealed(), []common.Address{carol}; !reflect.DeepEqual(have, want) {
+		t.Errorf("unrevealed mismatch: have %x, want %x", have, want)
+	}
+	mix, err := round.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize: %v", err)
+	}
+	var want common.Hash
+	for _, addr := range []common.Address{alice, bob} {
+		hash := crypto.Keccak256Hash(openings[addr].Value[:])
+		for i := range want {
+			want[i] ^= hash[i]
+		}
+	}
+	if mix != want {
+		t.Errorf("mix mismatch: have %x, want %x", mix, want)
+	}
+	if result, err := round.Result(); err != nil || result != mix {
+		t.Errorf("result mismatch: have %x (%v), want %x", result, err, mix)
+	}
+	if len(round.Revealed()) != 2 {
+		t.Errorf("have %d reveals, want 2", len(round.Revealed()))
+	}
+	// Rounds without any reveal can't be finalized
+	empty := NewRound()
+	empty.StartReveal()
+	if _, err := empty.Finalize(); !errors.Is(err, ErrNoReveals) {
+		t.Errorf("empty finalize: have %v, want %v", err, ErrNoReveals)
+	}
+}
@@ -282,3 +282,16 @@ func zeroBytes(bytes []byte) {
 		bytes[i] = 0
 	}
 }
+
+// ZeroKey overwrites the private scalar of the given key with zeros. The key must
+// not be used afterwards.
+func ZeroKey(k *ecdsa.PrivateKey) {
+	if k == nil || k.D == nil {
+		return
+	}
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+	k.D.SetUint64(0)
+}
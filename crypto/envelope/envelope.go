@@ -0,0 +1,264 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package envelope implements a versioned container for signed off-chain
+// messages. An envelope carries everything needed to verify the signature:
+// the claimed signer, the signing mode determining how the payload is hashed,
+// and for EIP-712 messages the signing domain.
+//
+// Envelopes are serialized canonically as an RLP list:
+//
+//	[version, mode, signer, chainID, payload, signature, domain?]
+package envelope
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Version1 is the current envelope version.
+const Version1 = 1
+
+// Mode determines how the payload of an envelope is turned into the digest
+// covered by the signature.
+type Mode uint8
+
+const (
+	// ModeRawHash signs the payload directly, which must be a 32 byte hash.
+	ModeRawHash Mode = iota
+
+	// ModePersonal signs the payload as a personal_sign (EIP-191 version 0x45)
+	// message.
+	ModePersonal
+
+	// ModeTypedData signs the payload as the hash of an EIP-712 struct, under
+	// the domain of the envelope.
+	ModeTypedData
+)
+
+// String implements fmt.Stringer.
+func (m Mode) String() string {
+	switch m {
+	case ModeRawHash:
+		return "raw"
+	case ModePersonal:
+		return "personal"
+	case ModeTypedData:
+		return "typed"
+	default:
+		return fmt.Sprintf("mode(%d)", uint8(m))
+	}
+}
+
+var (
+	ErrUnknownVersion   = errors.New("unknown envelope version")
+	ErrUnknownMode      = errors.New("unknown signing mode")
+	ErrInvalidPayload   = errors.New("invalid payload for signing mode")
+	ErrMissingDomain    = errors.New("typed data envelope without domain")
+	ErrUnexpectedDomain = errors.New("domain in non-typed data envelope")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignerMismatch   = errors.New("signature does not match signer")
+)
+
+// Domain is an EIP-712 signing domain. Zero valued fields are omitted from the
+// domain, the chain ID of the domain is the one of the envelope. Note that the
+// chain ID is only covered by the signature in typed data mode.
+type Domain struct {
+	Name              string
+	Version           string
+	VerifyingContract common.Address
+	Salt              common.Hash
+}
+
+// Envelope is a signed off-chain message.
+type Envelope struct {
+	Version   uint8
+	Mode      Mode
+	Signer    common.Address
+	ChainID   *big.Int // Chain the message is intended for, nil or zero if chain agnostic
+	Payload   []byte
+	Signature []byte  // 65 byte [R || S || V] signature, V being 0/1 or 27/28
+	Domain    *Domain `rlp:"optional"` // Signing domain, only set in typed data mode
+}
+
+// NewTypedData creates an unsigned envelope for the given EIP-712 typed data.
+// The envelope carries the hash of the message struct as payload.
+func NewTypedData(data apitypes.TypedData) (*Envelope, error) {
+	structHash, err := data.HashStruct(data.PrimaryType, data.Message)
+	if err != nil {
+		return nil, err
+	}
+	domain := &Domain{
+		Name:    data.Domain.Name,
+		Version: data.Domain.Version,
+	}
+	if data.Domain.VerifyingContract != "" {
+		if !common.IsHexAddress(data.Domain.VerifyingContract) {
+			return nil, fmt.Errorf("invalid verifying contract %q", data.Domain.VerifyingContract)
+		}
+		domain.VerifyingContract = common.HexToAddress(data.Domain.VerifyingContract)
+	}
+	if data.Domain.Salt != "" {
+		salt, err := hexutil.Decode(data.Domain.Salt)
+		if err != nil || len(salt) != common.HashLength {
+			return nil, fmt.Errorf("invalid salt %q", data.Domain.Salt)
+		}
+		domain.Salt = common.BytesToHash(salt)
+	}
+	env := &Envelope{Version: Version1, Mode: ModeTypedData, Payload: structHash, Domain: domain}
+	if data.Domain.ChainId != nil {
+		env.ChainID = (*big.Int)(data.Domain.ChainId)
+	}
+	return env, nil
+}
+
+// SigningHash returns the digest covered by the signature of the envelope.
+func (e *Envelope) SigningHash() (common.Hash, error) {
+	if e.Version != Version1 {
+		return common.Hash{}, fmt.Errorf("%w: %d", ErrUnknownVersion, e.Version)
+	}
+	if e.Mode != ModeTypedData && e.Domain != nil {
+		return common.Hash{}, ErrUnexpectedDomain
+	}
+	switch e.Mode {
+	case ModeRawHash:
+		if len(e.Payload) != common.HashLength {
+			return common.Hash{}, fmt.Errorf("%w: have %d bytes, want %d", ErrInvalidPayload, len(e.Payload), common.HashLength)
+		}
+		return common.BytesToHash(e.Payload), nil
+
+	case ModePersonal:
+		return common.BytesToHash(accounts.TextHash(e.Payload)), nil
+
+	case ModeTypedData:
+		if e.Domain == nil {
+			return common.Hash{}, ErrMissingDomain
+		}
+		if len(e.Payload) != common.HashLength {
+			return common.Hash{}, fmt.Errorf("%w: have %d bytes, want %d", ErrInvalidPayload, len(e.Payload), common.HashLength)
+		}
+		separator, err := e.domainSeparator()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return crypto.Keccak256Hash([]byte{0x19, 0x01}, separator, e.Payload), nil
+
+	default:
+		return common.Hash{}, fmt.Errorf("%w: %d", ErrUnknownMode, e.Mode)
+	}
+}
+
+// domainSeparator returns the EIP-712 hash of the envelope domain.
+func (e *Envelope) domainSeparator() ([]byte, error) {
+	var (
+		types  []apitypes.Type
+		domain apitypes.TypedDataDomain
+	)
+	if e.Domain.Name != "" {
+		types = append(types, apitypes.Type{Name: "name", Type: "string"})
+		domain.Name = e.Domain.Name
+	}
+	if e.Domain.Version != "" {
+		types = append(types, apitypes.Type{Name: "version", Type: "string"})
+		domain.Version = e.Domain.Version
+	}
+	if e.ChainID != nil && e.ChainID.Sign() != 0 {
+		types = append(types, apitypes.Type{Name: "chainId", Type: "uint256"})
+		domain.ChainId = (*math.HexOrDecimal256)(e.ChainID)
+	}
+	if e.Domain.VerifyingContract != (common.Address{}) {
+		types = append(types, apitypes.Type{Name: "verifyingContract", Type: "address"})
+		domain.VerifyingContract = e.Domain.VerifyingContract.Hex()
+	}
+	if e.Domain.Salt != (common.Hash{}) {
+		types = append(types, apitypes.Type{Name: "salt", Type: "bytes32"})
+		domain.Salt = e.Domain.Salt.Hex()
+	}
+	data := apitypes.TypedData{
+		Types:  apitypes.Types{"EIP712Domain": types},
+		Domain: domain,
+	}
+	return data.HashStruct("EIP712Domain", domain.Map())
+}
+
+// Sign signs the envelope with the given key, setting the signer and the
+// signature.
+func (e *Envelope) Sign(key *ecdsa.PrivateKey) error {
+	e.Signer = crypto.PubkeyToAddress(key.PublicKey)
+	hash, err := e.SigningHash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	e.Signature = sig
+	return nil
+}
+
+// Verify checks that the envelope is well formed and signed by its signer.
+func (e *Envelope) Verify() error {
+	hash, err := e.SigningHash()
+	if err != nil {
+		return err
+	}
+	if len(e.Signature) != crypto.SignatureLength {
+		return fmt.Errorf("%w: invalid length %d", ErrInvalidSignature, len(e.Signature))
+	}
+	sig := common.CopyBytes(e.Signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if crypto.PubkeyToAddress(*pubkey) != e.Signer {
+		return ErrSignerMismatch
+	}
+	return nil
+}
+
+// MarshalBinary returns the canonical encoding of the envelope.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	return rlp.EncodeToBytes(e)
+}
+
+// UnmarshalBinary decodes an envelope from its canonical encoding. Envelopes
+// of unknown versions are rejected.
+func (e *Envelope) UnmarshalBinary(b []byte) error {
+	var dec Envelope
+	if err := rlp.DecodeBytes(b, &dec); err != nil {
+		return err
+	}
+	if dec.Version != Version1 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, dec.Version)
+	}
+	*e = dec
+	return nil
+}
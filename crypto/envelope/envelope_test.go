@@ -0,0 +1,188 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+// testTypedData is the mail example of the EIP-712 specification.
+var testTypedData = apitypes.TypedData{
+	Types: apitypes.Types{
+		"EIP712Domain": {
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "chainId", Type: "uint256"},
+			{Name: "verifyingContract", Type: "address"},
+		},
+		"Person": {
+			{Name: "name", Type: "string"},
+			{Name: "wallet", Type: "address"},
+		},
+		"Mail": {
+			{Name: "from", Type: "Person"},
+			{Name: "to", Type: "Person"},
+			{Name: "contents", Type: "string"},
+		},
+	},
+	PrimaryType: "Mail",
+	Domain: apitypes.TypedDataDomain{
+		Name:              "Ether Mail",
+		Version:           "1",
+		ChainId:           math.NewHexOrDecimal256(1),
+		VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+	},
+	Message: apitypes.TypedDataMessage{
+		"from":     map[string]interface{}{"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to":       map[string]interface{}{"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!",
+	},
+}
+
+func TestSigningHash(t *testing.T) {
+	// Typed data digests must match the EIP-712 implementation of the signer
+	env, err := NewTypedData(testTypedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := env.SigningHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := apitypes.TypedDataAndHash(testTypedData)
+	if !bytes.Equal(hash[:], want) {
+		t.Errorf("typed data hash mismatch: have %x, want %x", hash, want)
+	}
+	if want := common.FromHex("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"); !bytes.Equal(hash[:], want) {
+		t.Errorf("typed data hash mismatch with specification: have %x, want %x", hash, want)
+	}
+	// Personal messages are hashed as by personal_sign
+	env = &Envelope{Version: Version1, Mode: ModePersonal, Payload: []byte("hello")}
+	if hash, _ := env.SigningHash(); !bytes.Equal(hash[:], accounts.TextHash([]byte("hello"))) {
+		t.Errorf("personal hash mismatch: have %x", hash)
+	}
+	// Malformed envelopes are rejected
+	tests := []struct {
+		env *Envelope
+		err error
+	}{
+		{&Envelope{Version: 2, Mode: ModeRawHash, Payload: make([]byte, 32)}, ErrUnknownVersion},
+		{&Envelope{Version: Version1, Mode: 3}, ErrUnknownMode},
+		{&Envelope{Version: Version1, Mode: ModeRawHash, Payload: make([]byte, 31)}, ErrInvalidPayload},
+		{&Envelope{Version: Version1, Mode: ModeTypedData, Payload: make([]byte, 32)}, ErrMissingDomain},
+		{&Envelope{Version: Version1, Mode: ModeTypedData, Payload: []byte{1}, Domain: &Domain{}}, ErrInvalidPayload},
+		{&Envelope{Version: Version1, Mode: ModePersonal, Domain: &Domain{}}, ErrUnexpectedDomain},
+	}
+	for i, tt := range tests {
+		if _, err := tt.env.SigningHash(); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	typed, _ := NewTypedData(testTypedData)
+	envs := []*Envelope{
+		{Version: Version1, Mode: ModeRawHash, Payload: crypto.Keccak256([]byte("raw"))},
+		{Version: Version1, Mode: ModePersonal, ChainID: big.NewInt(5), Payload: []byte("personal")},
+		typed,
+	}
+	for i, env := range envs {
+		if err := env.Sign(testKey); err != nil {
+			t.Fatalf("test %d: failed to sign: %v", i, err)
+		}
+		if err := env.Verify(); err != nil {
+			t.Fatalf("test %d: valid envelope rejected: %v", i, err)
+		}
+		// Signatures with a 0/1 recovery id are accepted as well
+		cpy := *env
+		cpy.Signature = common.CopyBytes(env.Signature)
+		cpy.Signature[crypto.RecoveryIDOffset] -= 27
+		if err := cpy.Verify(); err != nil {
+			t.Errorf("test %d: 0/1 recovery id rejected: %v", i, err)
+		}
+		cpy = *env
+		cpy.Payload = append(common.CopyBytes(env.Payload[1:]), 0x00)
+		if err := cpy.Verify(); !errors.Is(err, ErrSignerMismatch) {
+			t.Errorf("test %d: tampered payload: have %v, want %v", i, err, ErrSignerMismatch)
+		}
+		cpy = *env
+		cpy.Signer = common.Address{0x01}
+		if err := cpy.Verify(); !errors.Is(err, ErrSignerMismatch) {
+			t.Errorf("test %d: wrong signer: have %v, want %v", i, err, ErrSignerMismatch)
+		}
+		cpy = *env
+		cpy.Signature = env.Signature[:64]
+		if err := cpy.Verify(); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("test %d: short signature: have %v, want %v", i, err, ErrInvalidSignature)
+		}
+	}
+	// The chain ID is covered by typed data signatures
+	cpy := *typed
+	cpy.ChainID = big.NewInt(5)
+	if err := cpy.Verify(); !errors.Is(err, ErrSignerMismatch) {
+		t.Errorf("changed chain ID: have %v, want %v", err, ErrSignerMismatch)
+	}
+}
+
+func TestMarshaling(t *testing.T) {
+	typed, _ := NewTypedData(testTypedData)
+	envs := []*Envelope{
+		{Version: Version1, Mode: ModeRawHash, Payload: crypto.Keccak256([]byte("raw"))},
+		{Version: Version1, Mode: ModePersonal, ChainID: big.NewInt(5), Payload: []byte("personal")},
+		typed,
+	}
+	for i, env := range envs {
+		if err := env.Sign(testKey); err != nil {
+			t.Fatalf("test %d: failed to sign: %v", i, err)
+		}
+		enc, err := env.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: failed to encode: %v", i, err)
+		}
+		var dec Envelope
+		if err := dec.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("test %d: failed to decode: %v", i, err)
+		}
+		if err := dec.Verify(); err != nil {
+			t.Errorf("test %d: decoded envelope rejected: %v", i, err)
+		}
+		if !reflect.DeepEqual(dec.Domain, env.Domain) {
+			t.Errorf("test %d: domain mismatch: have %+v, want %+v", i, dec.Domain, env.Domain)
+		}
+		// The encoding is canonical
+		if reenc, _ := dec.MarshalBinary(); !bytes.Equal(reenc, enc) {
+			t.Errorf("test %d: re-encoding mismatch: have %x, want %x", i, reenc, enc)
+		}
+	}
+	enc, _ := (&Envelope{Version: 2}).MarshalBinary()
+	if err := new(Envelope).UnmarshalBinary(enc); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnknownVersion)
+	}
+}
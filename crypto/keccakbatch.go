@@ -0,0 +1,93 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import "encoding/binary"
+
+// keccakRate is the number of bytes absorbed per Keccak256 permutation.
+const keccakRate = 136
+
+// Keccak256Batch calculates the Keccak256 hashes of the given inputs.
+//
+// It is meant for workloads hashing large numbers of equally sized inputs, such
+// as mining vanity addresses or storage slots. On amd64 with AVX2, runs of four
+// consecutive inputs of equal length are hashed at once, with the four Keccak
+// states interleaved in the lanes of SIMD registers. Other inputs fall back to
+// hashing the inputs one by one.
+//
+// AVX2 is the only accelerated instruction set: on all other platforms, arm64
+// (NEON) included, every input is hashed one by one, no faster than Keccak256.
+func Keccak256Batch(inputs [][]byte) [][32]byte {
+	var (
+		hashes = make([][32]byte, len(inputs))
+		hasher KeccakState
+	)
+	for i := 0; i < len(inputs); i++ {
+		if useKeccakx4 && i%4 == 0 && i+4 <= len(inputs) {
+			n := len(inputs[i])
+			if len(inputs[i+1]) == n && len(inputs[i+2]) == n && len(inputs[i+3]) == n {
+				keccak256x4((*[4][]byte)(inputs[i:i+4]), (*[4][32]byte)(hashes[i:i+4]))
+				i += 3
+				continue
+			}
+		}
+		if hasher == nil {
+			hasher = NewKeccakState()
+		}
+		hasher.Reset()
+		hasher.Write(inputs[i])
+		hasher.Read(hashes[i][:])
+	}
+	return hashes
+}
+
+// keccak256x4 hashes four inputs of equal length at once.
+func keccak256x4(inputs *[4][]byte, hashes *[4][32]byte) {
+	var (
+		state [25][4]uint64
+		n     = len(inputs[0])
+		off   int
+	)
+	// Absorb all full blocks, one permutation per block of all four inputs
+	for ; off+keccakRate <= n; off += keccakRate {
+		for k, input := range inputs {
+			for j := 0; j < keccakRate/8; j++ {
+				state[j][k] ^= binary.LittleEndian.Uint64(input[off+8*j:])
+			}
+		}
+		keccakF1600x4(&state)
+	}
+	// Absorb the padded remainder, which has the same length for all inputs
+	for k, input := range inputs {
+		var block [keccakRate]byte
+		copy(block[:], input[off:])
+		block[n-off] ^= 0x01
+		block[keccakRate-1] ^= 0x80
+
+		for j := 0; j < keccakRate/8; j++ {
+			state[j][k] ^= binary.LittleEndian.Uint64(block[8*j:])
+		}
+	}
+	keccakF1600x4(&state)
+
+	// Squeeze the hashes, which fit in a single block
+	for k := range hashes {
+		for j := 0; j < 4; j++ {
+			binary.LittleEndian.PutUint64(hashes[k][8*j:], state[j][k])
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build amd64 && !gccgo && !appengine
+// +build amd64,!gccgo,!appengine
+
+#include "textflag.h"
+
+// Round constants of Keccak-f[1600], broadcast into all lanes by the iota step.
+DATA ·keccakRoundConstants<>+0x00(SB)/8, $0x0000000000000001
+DATA ·keccakRoundConstants<>+0x08(SB)/8, $0x0000000000008082
+DATA ·keccakRoundConstants<>+0x10(SB)/8, $0x800000000000808a
+DATA ·keccakRoundConstants<>+0x18(SB)/8, $0x8000000080008000
+DATA ·keccakRoundConstants<>+0x20(SB)/8, $0x000000000000808b
+DATA ·keccakRoundConstants<>+0x28(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstants<>+0x30(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstants<>+0x38(SB)/8, $0x8000000000008009
+DATA ·keccakRoundConstants<>+0x40(SB)/8, $0x000000000000008a
+DATA ·keccakRoundConstants<>+0x48(SB)/8, $0x0000000000000088
+DATA ·keccakRoundConstants<>+0x50(SB)/8, $0x0000000080008009
+DATA ·keccakRoundConstants<>+0x58(SB)/8, $0x000000008000000a
+DATA ·keccakRoundConstants<>+0x60(SB)/8, $0x000000008000808b
+DATA ·keccakRoundConstants<>+0x68(SB)/8, $0x800000000000008b
+DATA ·keccakRoundConstants<>+0x70(SB)/8, $0x8000000000008089
+DATA ·keccakRoundConstants<>+0x78(SB)/8, $0x8000000000008003
+DATA ·keccakRoundConstants<>+0x80(SB)/8, $0x8000000000008002
+DATA ·keccakRoundConstants<>+0x88(SB)/8, $0x8000000000000080
+DATA ·keccakRoundConstants<>+0x90(SB)/8, $0x000000000000800a
+DATA ·keccakRoundConstants<>+0x98(SB)/8, $0x800000008000000a
+DATA ·keccakRoundConstants<>+0xa0(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstants<>+0xa8(SB)/8, $0x8000000000008080
+DATA ·keccakRoundConstants<>+0xb0(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstants<>+0xb8(SB)/8, $0x8000000080008008
+GLOBL ·keccakRoundConstants<>(SB), (NOPTR+RODATA), $192
+
+// The state is an array of 25 lanes of four interleaved Keccak states, so lane i
+// of all four states is held in one 256 bit vector at offset 32*i, with lane i
+// being A[x, y] for i = x + 5*y.
+#define LANE(x, y) (32*((x)+5*(y)))
+
+// ROTL rotates the 64 bit elements of reg left by n bits, using tmp.
+#define ROTL(n, reg, tmp) \
+	VPSLLQ $(n), reg, tmp; \
+	VPSRLQ $(64-(n)), reg, reg; \
+	VPOR   tmp, reg, reg
+
+// THETA_COLUMN computes the parity of the column x into reg.
+#define THETA_COLUMN(x, reg) \
+	VMOVDQU LANE(x, 0)(DI), reg; \
+	VPXOR   LANE(x, 1)(DI), reg, reg; \
+	VPXOR   LANE(x, 2)(DI), reg, reg; \
+	VPXOR   LANE(x, 3)(DI), reg, reg; \
+	VPXOR   LANE(x, 4)(DI), reg, reg
+
+// THETA_EFFECT computes the effect D = prev ^ ROTL(next, 1) into reg.
+#define THETA_EFFECT(prev, next, reg) \
+	VPSLLQ $1, next, reg; \
+	VPSRLQ $63, next, Y15; \
+	VPOR   Y15, reg, reg; \
+	VPXOR  prev, reg, reg
+
+// RHO_PI applies the theta effect d to lane A[x, y], rotates it by n bits and
+// moves it to its new position B[y, 2x+3y] in the scratch space.
+#define RHO_PI(x, y, d, n) \
+	VMOVDQU LANE(x, y)(DI), Y10; \
+	VPXOR   d, Y10, Y10; \
+	ROTL(n, Y10, Y11); \
+	VMOVDQU Y10, LANE(y, (2*(x)+3*(y))%5)(SP)
+
+// RHO_PI_0 is RHO_PI for lane A[0, 0], which is not rotated.
+#define RHO_PI_0(d) \
+	VMOVDQU LANE(0, 0)(DI), Y10; \
+	VPXOR   d, Y10, Y10; \
+	VMOVDQU Y10, LANE(0, 0)(SP)
+
+// CHI_ROW applies the nonlinear chi step to row y of the scratch space, writing
+// the result back into the state.
+#define CHI_ROW(y) \
+	VMOVDQU LANE(0, y)(SP), Y0; \
+	VMOVDQU LANE(1, y)(SP), Y1; \
+	VMOVDQU LANE(2, y)(SP), Y2; \
+	VMOVDQU LANE(3, y)(SP), Y3; \
+	VMOVDQU LANE(4, y)(SP), Y4; \
+	VPANDN  Y2, Y1, Y10; \
+	VPXOR   Y0, Y10, Y10; \
+	VMOVDQU Y10, LANE(0, y)(DI); \
+	VPANDN  Y3, Y2, Y10; \
+	VPXOR   Y1, Y10, Y10; \
+	VMOVDQU Y10, LANE(1, y)(DI); \
+	VPANDN  Y4, Y3, Y10; \
+	VPXOR   Y2, Y10, Y10; \
+	VMOVDQU Y10, LANE(2, y)(DI); \
+	VPANDN  Y0, Y4, Y10; \
+	VPXOR   Y3, Y10, Y10; \
+	VMOVDQU Y10, LANE(3, y)(DI); \
+	VPANDN  Y1, Y0, Y10; \
+	VPXOR   Y4, Y10, Y10; \
+	VMOVDQU Y10, LANE(4, y)(DI)
+
+// func keccakF1600x4(state *[25][4]uint64)
+TEXT ·keccakF1600x4(SB), $800-8
+	MOVQ state+0(FP), DI
+	LEAQ ·keccakRoundConstants<>(SB), SI
+	MOVQ $24, CX
+
+loop:
+	// Theta: column parities in Y0-Y4, their effects in Y5-Y9
+	THETA_COLUMN(0, Y0)
+	THETA_COLUMN(1, Y1)
+	THETA_COLUMN(2, Y2)
+	THETA_COLUMN(3, Y3)
+	THETA_COLUMN(4, Y4)
+	THETA_EFFECT(Y4, Y1, Y5)
+	THETA_EFFECT(Y0, Y2, Y6)
+	THETA_EFFECT(Y1, Y3, Y7)
+	THETA_EFFECT(Y2, Y4, Y8)
+	THETA_EFFECT(Y3, Y0, Y9)
+
+	// Rho and pi: rotate and permute the lanes into the scratch space
+	RHO_PI_0(Y5)
+	RHO_PI(1, 0, Y6, 1)
+	RHO_PI(2, 0, Y7, 62)
+	RHO_PI(3, 0, Y8, 28)
+	RHO_PI(4, 0, Y9, 27)
+	RHO_PI(0, 1, Y5, 36)
+	RHO_PI(1, 1, Y6, 44)
+	RHO_PI(2, 1, Y7, 6)
+	RHO_PI(3, 1, Y8, 55)
+	RHO_PI(4, 1, Y9, 20)
+	RHO_PI(0, 2, Y5, 3)
+	RHO_PI(1, 2, Y6, 10)
+	RHO_PI(2, 2, Y7, 43)
+	RHO_PI(3, 2, Y8, 25)
+	RHO_PI(4, 2, Y9, 39)
+	RHO_PI(0, 3, Y5, 41)
+	RHO_PI(1, 3, Y6, 45)
+	RHO_PI(2, 3, Y7, 15)
+	RHO_PI(3, 3, Y8, 21)
+	RHO_PI(4, 3, Y9, 8)
+	RHO_PI(0, 4, Y5, 18)
+	RHO_PI(1, 4, Y6, 2)
+	RHO_PI(2, 4, Y7, 61)
+	RHO_PI(3, 4, Y8, 56)
+	RHO_PI(4, 4, Y9, 14)
+
+	// Chi: combine the lanes of each row back into the state
+	CHI_ROW(0)
+	CHI_ROW(1)
+	CHI_ROW(2)
+	CHI_ROW(3)
+	CHI_ROW(4)
+
+	// Iota: mix the round constant into lane A[0, 0]
+	VPBROADCASTQ (SI), Y10
+	VPXOR        LANE(0, 0)(DI), Y10, Y10
+	VMOVDQU      Y10, LANE(0, 0)(DI)
+
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop
+
+	VZEROUPPER
+	RET
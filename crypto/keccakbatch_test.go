@@ -0,0 +1,86 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestKeccak256Batch(t *testing.T) {
+	rand := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rand.Read(b)
+		return b
+	}
+	// Batches of equally sized inputs around the block boundaries
+	var batches [][][]byte
+	for _, n := range []int{0, 1, 20, 32, 64, 135, 136, 137, 271, 272, 1000} {
+		for _, count := range []int{1, 4, 7, 8} {
+			batch := make([][]byte, count)
+			for i := range batch {
+				batch[i] = random(n)
+			}
+			batches = append(batches, batch)
+		}
+	}
+	// Batches mixing lengths, within and across groups of four
+	batches = append(batches,
+		[][]byte{random(32), random(32), random(31), random(32), random(64), random(64), random(64), random(64), random(1)},
+		[][]byte{random(200), random(200), random(200), random(200), nil, {}, nil, {}},
+		nil,
+	)
+	for i, batch := range batches {
+		hashes := Keccak256Batch(batch)
+		if len(hashes) != len(batch) {
+			t.Fatalf("batch %d: hash count mismatch: have %d, want %d", i, len(hashes), len(batch))
+		}
+		for j, input := range batch {
+			if want := Keccak256(input); !bytes.Equal(hashes[j][:], want) {
+				t.Errorf("batch %d, input %d (len %d): hash mismatch: have %x, want %x", i, j, len(input), hashes[j], want)
+			}
+		}
+	}
+}
+
+func BenchmarkKeccak256Batch(b *testing.B) {
+	for _, size := range []int{20, 64, 200} {
+		inputs := make([][]byte, 1024)
+		for i := range inputs {
+			inputs[i] = make([]byte, size)
+			inputs[i][0] = byte(i)
+		}
+		b.Run(fmt.Sprintf("batch/%d", size), func(b *testing.B) {
+			b.SetBytes(int64(size * len(inputs)))
+			for i := 0; i < b.N; i++ {
+				Keccak256Batch(inputs)
+			}
+		})
+		b.Run(fmt.Sprintf("single/%d", size), func(b *testing.B) {
+			b.SetBytes(int64(size * len(inputs)))
+			hasher := NewKeccakState()
+			for i := 0; i < b.N; i++ {
+				for _, input := range inputs {
+					HashData(hasher, input)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Keccak256Hasher computes the Keccak256 hash of data written to it in pieces,
+// so large payloads can be hashed as they are streamed, without holding all of
+// them in memory. It implements hash.Hash and thus io.Writer.
+type Keccak256Hasher struct {
+	state KeccakState
+}
+
+// NewKeccak256Hasher creates a Keccak256 hasher.
+func NewKeccak256Hasher() *Keccak256Hasher {
+	return &Keccak256Hasher{state: NewKeccakState()}
+}
+
+// Write adds more data to the hashed stream. It never returns an error.
+func (h *Keccak256Hasher) Write(p []byte) (int, error) {
+	return h.state.Write(p)
+}
+
+// Sum appends the hash of the data written so far to b and returns the resulting
+// slice. It does not change the state, so more data can be written afterwards.
+func (h *Keccak256Hasher) Sum(b []byte) []byte {
+	return h.state.Sum(b)
+}
+
+// SumHash returns the hash of the data written so far. It does not change the
+// state, so more data can be written afterwards.
+func (h *Keccak256Hasher) SumHash() (hash common.Hash) {
+	h.state.Sum(hash[:0])
+	return hash
+}
+
+// Reset discards the data written so far.
+func (h *Keccak256Hasher) Reset() {
+	h.state.Reset()
+}
+
+// Size returns the length of the hash in bytes.
+func (h *Keccak256Hasher) Size() int {
+	return h.state.Size()
+}
+
+// BlockSize returns the rate of the sponge, the amount of data hashed at once.
+func (h *Keccak256Hasher) BlockSize() int {
+	return h.state.BlockSize()
+}
+
+// Keccak256Reader calculates the Keccak256 hash of all data read from r until
+// EOF, streaming it through the hasher.
+func Keccak256Reader(r io.Reader) (common.Hash, error) {
+	h := NewKeccak256Hasher()
+	if _, err := io.Copy(h, r); err != nil {
+		return common.Hash{}, err
+	}
+	return h.SumHash(), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"io"
+	"testing"
+)
+
+var _ hash.Hash = (*Keccak256Hasher)(nil)
+
+func TestKeccak256HasherStream(t *testing.T) {
+	data := bytes.Repeat([]byte("streamed keccak "), 1000)
+
+	h := NewKeccak256Hasher()
+	for i := 0; i < len(data); i += 333 {
+		end := i + 333
+		if end > len(data) {
+			end = len(data)
+		}
+		h.Write(data[i:end])
+	}
+	want := Keccak256Hash(data)
+	if have := h.SumHash(); have != want {
+		t.Fatalf("hash mismatch: have %x, want %x", have, want)
+	}
+	if have := h.Sum([]byte{0x01}); !bytes.Equal(have, append([]byte{0x01}, want[:]...)) {
+		t.Fatalf("appended hash mismatch: have %x, want 01%x", have, want)
+	}
+	// Summing does not change the state
+	h.Write([]byte("more"))
+	if have, want := h.SumHash(), Keccak256Hash(data, []byte("more")); have != want {
+		t.Fatalf("continued hash mismatch: have %x, want %x", have, want)
+	}
+	h.Reset()
+	if have, want := h.SumHash(), Keccak256Hash(); have != want {
+		t.Fatalf("reset hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestKeccak256Reader(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab}, 100000)
+
+	have, err := Keccak256Reader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to hash reader: %v", err)
+	}
+	if want := Keccak256Hash(data); have != want {
+		t.Fatalf("hash mismatch: have %x, want %x", have, want)
+	}
+	failure := errors.New("read failure")
+	if _, err := Keccak256Reader(io.MultiReader(bytes.NewReader(data), &failingReader{failure})); err != failure {
+		t.Fatalf("wrong error: have %v, want %v", err, failure)
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
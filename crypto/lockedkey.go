@@ -0,0 +1,182 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+var (
+	errKeyDestroyed = errors.New("locked key destroyed")
+
+	secp256k1NBytes = math.PaddedBigBytes(secp256k1N, 32)
+)
+
+// LockedKey is a secp256k1 private key held in memory outside of the Go heap,
+// which is locked into RAM where the platform allows it. The key is stored
+// masked with a random pad and is only unmasked into the same memory region for
+// the duration of a signing operation, so the plain private scalar never ends up
+// in garbage collected memory.
+//
+// A LockedKey must be released with Destroy once it is no longer needed. It is
+// safe for concurrent use.
+type LockedKey struct {
+	mem    []byte // masked key | pad | scratch space for the unmasked key
+	locked bool   // whether mem is locked into RAM
+	pub    ecdsa.PublicKey
+	addr   common.Address
+	lock   sync.Mutex
+}
+
+func newLockedKey() (*LockedKey, error) {
+	mem, locked, err := allocLocked(3 * 32)
+	if err != nil {
+		return nil, fmt.Errorf("can't allocate key memory: %v", err)
+	}
+	return &LockedKey{mem: mem, locked: locked}, nil
+}
+
+// NewLockedKey moves the given private key into locked memory. The private
+// scalar of the given key is zeroed, it must not be used afterwards.
+func NewLockedKey(key *ecdsa.PrivateKey) (*LockedKey, error) {
+	if key == nil || key.D == nil {
+		return nil, errors.New("invalid private key")
+	}
+	k, err := newLockedKey()
+	if err != nil {
+		return nil, err
+	}
+	key.D.FillBytes(k.scratch())
+	if err := k.seal(); err != nil {
+		k.Destroy()
+		return nil, err
+	}
+	k.setPublic(new(big.Int).Set(key.X), new(big.Int).Set(key.Y))
+	ZeroKey(key)
+	return k, nil
+}
+
+// GenerateLockedKey creates a new random private key directly in locked memory.
+func GenerateLockedKey() (*LockedKey, error) {
+	k, err := newLockedKey()
+	if err != nil {
+		return nil, err
+	}
+	seckey := k.scratch()
+	for {
+		if _, err := io.ReadFull(rand.Reader, seckey); err != nil {
+			k.Destroy()
+			return nil, err
+		}
+		if bytes.Compare(seckey, secp256k1NBytes) < 0 && !bytes.Equal(seckey, make([]byte, 32)) {
+			break
+		}
+	}
+	x, y := S256().ScalarBaseMult(seckey)
+	if err := k.seal(); err != nil {
+		k.Destroy()
+		return nil, err
+	}
+	k.setPublic(x, y)
+	return k, nil
+}
+
+func (k *LockedKey) masked() []byte  { return k.mem[:32] }
+func (k *LockedKey) pad() []byte     { return k.mem[32:64] }
+func (k *LockedKey) scratch() []byte { return k.mem[64:] }
+
+// seal masks the key held in the scratch space with a fresh random pad.
+func (k *LockedKey) seal() error {
+	if _, err := io.ReadFull(rand.Reader, k.pad()); err != nil {
+		return err
+	}
+	masked, pad, scratch := k.masked(), k.pad(), k.scratch()
+	for i := range scratch {
+		masked[i] = scratch[i] ^ pad[i]
+	}
+	zeroBytes(scratch)
+	return nil
+}
+
+// unseal unmasks the key into the scratch space.
+func (k *LockedKey) unseal() []byte {
+	masked, pad, scratch := k.masked(), k.pad(), k.scratch()
+	for i := range scratch {
+		scratch[i] = masked[i] ^ pad[i]
+	}
+	return scratch
+}
+
+func (k *LockedKey) setPublic(x, y *big.Int) {
+	k.pub = ecdsa.PublicKey{Curve: S256(), X: x, Y: y}
+	k.addr = PubkeyToAddress(k.pub)
+}
+
+// Public returns the public key belonging to the private key.
+func (k *LockedKey) Public() *ecdsa.PublicKey {
+	return &ecdsa.PublicKey{Curve: k.pub.Curve, X: new(big.Int).Set(k.pub.X), Y: new(big.Int).Set(k.pub.Y)}
+}
+
+// Address returns the Ethereum address of the key.
+func (k *LockedKey) Address() common.Address {
+	return k.addr
+}
+
+// Locked reports whether the key memory is locked into RAM. Locking may fail if
+// the process exceeds its locked memory limit, or on unsupported platforms.
+func (k *LockedKey) Locked() bool {
+	return k.locked
+}
+
+// Sign calculates an ECDSA signature of the given digest like Sign does, in the
+// [R || S || V] format where V is 0 or 1.
+func (k *LockedKey) Sign(digestHash []byte) ([]byte, error) {
+	if len(digestHash) != DigestLength {
+		return nil, fmt.Errorf("hash is required to be exactly %d bytes (%d)", DigestLength, len(digestHash))
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.mem == nil {
+		return nil, errKeyDestroyed
+	}
+	seckey := k.unseal()
+	defer zeroBytes(seckey)
+	return signSeckey(digestHash, seckey)
+}
+
+// Destroy wipes the key and releases its memory. The key can't be used for
+// signing afterwards.
+func (k *LockedKey) Destroy() {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.mem != nil {
+		freeLocked(k.mem, k.locked)
+		k.mem = nil
+	}
+}
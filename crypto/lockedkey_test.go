@@ -0,0 +1,93 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLockedKey(t *testing.T) {
+	key, _ := HexToECDSA(testPrivHex)
+	reference, _ := HexToECDSA(testPrivHex)
+
+	locked, err := NewLockedKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locked.Destroy()
+
+	if key.D.Sign() != 0 {
+		t.Error("source key not zeroed")
+	}
+	if locked.Address() != common.HexToAddress(testAddrHex) {
+		t.Errorf("address mismatch: have %x, want %s", locked.Address(), testAddrHex)
+	}
+	// The key must not be stored in plain text
+	if bytes.Contains(locked.mem, common.FromHex(testPrivHex)) {
+		t.Error("key stored unmasked")
+	}
+	// Signatures must match the ones made with the plain key (RFC6979 nonces)
+	msg := Keccak256([]byte("foo"))
+	have, err := locked.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Sign(msg, reference)
+	if !bytes.Equal(have, want) {
+		t.Errorf("signature mismatch: have %x, want %x", have, want)
+	}
+	if !bytes.Equal(locked.scratch(), make([]byte, 32)) {
+		t.Error("scratch space not wiped after signing")
+	}
+	locked.Destroy()
+	if _, err := locked.Sign(msg); err != errKeyDestroyed {
+		t.Errorf("destroyed key error mismatch: have %v, want %v", err, errKeyDestroyed)
+	}
+}
+
+func TestGenerateLockedKey(t *testing.T) {
+	locked, err := GenerateLockedKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locked.Destroy()
+
+	msg := Keccak256([]byte("foo"))
+	sig, err := locked.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := SigToPub(msg, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PubkeyToAddress(*pub) != locked.Address() {
+		t.Errorf("recovered address mismatch: have %x, want %x", PubkeyToAddress(*pub), locked.Address())
+	}
+}
+
+func TestZeroKey(t *testing.T) {
+	key, _ := HexToECDSA(testPrivHex)
+	ZeroKey(key)
+	if key.D.Sign() != 0 {
+		t.Errorf("key not zeroed: %x", key.D)
+	}
+	ZeroKey(nil)
+}
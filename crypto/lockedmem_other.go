@@ -0,0 +1,31 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package crypto
+
+// allocLocked falls back to heap memory on platforms without mmap support. The
+// contents are still masked and wiped, but may be moved by the runtime.
+func allocLocked(size int) ([]byte, bool, error) {
+	return make([]byte, size), false, nil
+}
+
+// freeLocked wipes memory obtained from allocLocked.
+func freeLocked(mem []byte, locked bool) {
+	zeroBytes(mem)
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package crypto
+
+import "golang.org/x/sys/unix"
+
+// allocLocked maps anonymous memory outside of the Go heap and tries to lock it
+// into RAM so it is never written to swap.
+func allocLocked(size int) ([]byte, bool, error) {
+	mem, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, false, err
+	}
+	// Locking fails if RLIMIT_MEMLOCK is exhausted, the memory is still usable.
+	locked := unix.Mlock(mem) == nil
+	return mem, locked, nil
+}
+
+// freeLocked wipes and releases memory obtained from allocLocked.
+func freeLocked(mem []byte, locked bool) {
+	zeroBytes(mem)
+	if locked {
+		unix.Munlock(mem)
+	}
+	unix.Munmap(mem)
+}
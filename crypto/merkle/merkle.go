@@ -0,0 +1,252 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package merkle implements keccak256 Merkle trees with sorted pair hashing,
+// whose proofs and multiproofs can be verified by the MerkleProof library of
+// OpenZeppelin Contracts.
+//
+// Trees are stored in the array layout of the OpenZeppelin merkle-tree library:
+// the root is at index 0, the children of node i at 2i+1 and 2i+2, and leaf i
+// at index len(tree)-1-i.
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	errEmptyTree         = errors.New("merkle: tree without leaves")
+	errInvalidIndex      = errors.New("merkle: leaf index out of range")
+	errDuplicate         = errors.New("merkle: duplicate leaf index")
+	errInvalidMultiProof = errors.New("merkle: invalid multiproof")
+)
+
+// HashPair returns the hash of the sorted pair of nodes, as computed by the
+// OpenZeppelin verifier.
+func HashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// Tree is a Merkle tree over a list of leaf hashes.
+type Tree struct {
+	nodes []common.Hash
+}
+
+// New builds a tree over the given leaves, which are used in the given order.
+func New(leaves []common.Hash) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, errEmptyTree
+	}
+	nodes := make([]common.Hash, 2*len(leaves)-1)
+	for i, leaf := range leaves {
+		nodes[len(nodes)-1-i] = leaf
+	}
+	for i := len(nodes) - 1 - len(leaves); i >= 0; i-- {
+		nodes[i] = HashPair(nodes[2*i+1], nodes[2*i+2])
+	}
+	return &Tree{nodes: nodes}, nil
+}
+
+// newFromNodes creates a tree from its array representation, checking the
+// consistency of the inner nodes.
+func newFromNodes(nodes []common.Hash) (*Tree, error) {
+	if len(nodes)%2 == 0 {
+		return nil, fmt.Errorf("merkle: invalid tree size %d", len(nodes))
+	}
+	for i := 0; 2*i+2 < len(nodes); i++ {
+		if HashPair(nodes[2*i+1], nodes[2*i+2]) != nodes[i] {
+			return nil, fmt.Errorf("merkle: invalid inner node %d", i)
+		}
+	}
+	return &Tree{nodes: nodes}, nil
+}
+
+// Root returns the root hash of the tree.
+func (t *Tree) Root() common.Hash {
+	return t.nodes[0]
+}
+
+// Len returns the number of leaves in the tree.
+func (t *Tree) Len() int {
+	return (len(t.nodes) + 1) / 2
+}
+
+// Leaf returns the hash of the leaf at the given index.
+func (t *Tree) Leaf(index int) common.Hash {
+	return t.nodes[t.nodeIndex(index)]
+}
+
+// nodeIndex returns the position of a leaf in the array representation.
+func (t *Tree) nodeIndex(index int) int {
+	return len(t.nodes) - 1 - index
+}
+
+// Proof returns the proof of the leaf at the given index, ordered from the leaf
+// to the root.
+func (t *Tree) Proof(index int) ([]common.Hash, error) {
+	if index < 0 || index >= t.Len() {
+		return nil, errInvalidIndex
+	}
+	var proof []common.Hash
+	for i := t.nodeIndex(index); i > 0; i = (i - 1) / 2 {
+		proof = append(proof, t.nodes[sibling(i)])
+	}
+	return proof, nil
+}
+
+// MultiProof is a proof of multiple leaves at once. The leaves are ordered as
+// expected by the OpenZeppelin verifier, which is not necessarily the order in
+// which they were requested.
+type MultiProof struct {
+	Leaves     []common.Hash
+	Proof      []common.Hash
+	ProofFlags []bool
+}
+
+// MultiProof returns the proof of the leaves at the given indices.
+func (t *Tree) MultiProof(indices []int) (*MultiProof, error) {
+	stack := make([]int, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= t.Len() {
+			return nil, errInvalidIndex
+		}
+		stack[i] = t.nodeIndex(index)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(stack)))
+	for i := 1; i < len(stack); i++ {
+		if stack[i] == stack[i-1] {
+			return nil, errDuplicate
+		}
+	}
+	mp := &MultiProof{Leaves: make([]common.Hash, len(stack))}
+	for i, node := range stack {
+		mp.Leaves[i] = t.nodes[node]
+	}
+	// Walk up the tree, taking the sibling from the queue of pending nodes if
+	// it is known and from the proof otherwise.
+	for len(stack) > 0 && stack[0] > 0 {
+		node := stack[0]
+		stack = stack[1:]
+
+		if len(stack) > 0 && stack[0] == sibling(node) {
+			mp.ProofFlags = append(mp.ProofFlags, true)
+			stack = stack[1:]
+		} else {
+			mp.ProofFlags = append(mp.ProofFlags, false)
+			mp.Proof = append(mp.Proof, t.nodes[sibling(node)])
+		}
+		stack = append(stack, (node-1)/2)
+	}
+	if len(indices) == 0 {
+		mp.Proof = append(mp.Proof, t.nodes[0])
+	}
+	return mp, nil
+}
+
+// sibling returns the index of the sibling of a non-root node.
+func sibling(i int) int {
+	if i%2 == 1 {
+		return i + 1
+	}
+	return i - 1
+}
+
+// ProcessProof returns the root reconstructed from a leaf and its proof.
+func ProcessProof(leaf common.Hash, proof []common.Hash) common.Hash {
+	node := leaf
+	for _, sibling := range proof {
+		node = HashPair(node, sibling)
+	}
+	return node
+}
+
+// VerifyProof checks that the proof proves the leaf to be part of the tree with
+// the given root.
+func VerifyProof(root, leaf common.Hash, proof []common.Hash) bool {
+	return ProcessProof(leaf, proof) == root
+}
+
+// ProcessMultiProof returns the root reconstructed from a multiproof, following
+// the algorithm of the OpenZeppelin verifier.
+func ProcessMultiProof(mp *MultiProof) (common.Hash, error) {
+	var (
+		leaves = len(mp.Leaves)
+		total  = len(mp.ProofFlags)
+	)
+	if leaves+len(mp.Proof)-1 != total {
+		return common.Hash{}, errInvalidMultiProof
+	}
+	var (
+		hashes                     = make([]common.Hash, total)
+		leafPos, hashPos, proofPos int
+	)
+	// next consumes the next leaf, or the next hash computed by step i once the
+	// leaves are exhausted.
+	next := func(i int) (common.Hash, bool) {
+		if leafPos < leaves {
+			leafPos++
+			return mp.Leaves[leafPos-1], true
+		}
+		if hashPos >= i {
+			return common.Hash{}, false
+		}
+		hashPos++
+		return hashes[hashPos-1], true
+	}
+	for i := 0; i < total; i++ {
+		a, ok := next(i)
+		if !ok {
+			return common.Hash{}, errInvalidMultiProof
+		}
+		var b common.Hash
+		if mp.ProofFlags[i] {
+			if b, ok = next(i); !ok {
+				return common.Hash{}, errInvalidMultiProof
+			}
+		} else {
+			if proofPos >= len(mp.Proof) {
+				return common.Hash{}, errInvalidMultiProof
+			}
+			b = mp.Proof[proofPos]
+			proofPos++
+		}
+		hashes[i] = HashPair(a, b)
+	}
+	switch {
+	case total > 0:
+		return hashes[total-1], nil
+	case leaves > 0:
+		return mp.Leaves[0], nil
+	default:
+		return mp.Proof[0], nil
+	}
+}
+
+// VerifyMultiProof checks that the multiproof proves its leaves to be part of
+// the tree with the given root.
+func VerifyMultiProof(root common.Hash, mp *MultiProof) bool {
+	reconstructed, err := ProcessMultiProof(mp)
+	return err == nil && reconstructed == root
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package merkle
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testLeaves(n int) []common.Hash {
+	leaves := make([]common.Hash, n)
+	for i := range leaves {
+		leaves[i] = crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes())
+	}
+	return leaves
+}
+
+func TestProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		tree, err := New(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatalf("n=%d: proof %d failed: %v", n, i, err)
+			}
+			if !VerifyProof(tree.Root(), tree.Leaf(i), proof) {
+				t.Errorf("n=%d: proof %d rejected", n, i)
+			}
+			if VerifyProof(tree.Root(), common.Hash{0x01}, proof) {
+				t.Errorf("n=%d: proof %d accepted for wrong leaf", n, i)
+			}
+		}
+	}
+}
+
+func TestMultiProofs(t *testing.T) {
+	tree, _ := New(testLeaves(7))
+
+	for _, indices := range [][]int{{}, {0}, {6}, {0, 1}, {5, 2, 3}, {0, 1, 2, 3, 4, 5, 6}, {1, 6}} {
+		mp, err := tree.MultiProof(indices)
+		if err != nil {
+			t.Fatalf("%v: multiproof failed: %v", indices, err)
+		}
+		if len(mp.Leaves) != len(indices) {
+			t.Fatalf("%v: leaf count mismatch: have %d", indices, len(mp.Leaves))
+		}
+		if !VerifyMultiProof(tree.Root(), mp) {
+			t.Errorf("%v: multiproof rejected", indices)
+		}
+		if len(mp.Proof) > 0 {
+			mp.Proof[0] = common.Hash{0x01}
+			if VerifyMultiProof(tree.Root(), mp) {
+				t.Errorf("%v: tampered multiproof accepted", indices)
+			}
+		}
+	}
+	if _, err := tree.MultiProof([]int{1, 1}); err != errDuplicate {
+		t.Errorf("duplicate indices: have %v, want %v", err, errDuplicate)
+	}
+	// Malformed multiproofs must not crash the verifier
+	malformed := &MultiProof{Leaves: testLeaves(2), ProofFlags: []bool{true, true}, Proof: []common.Hash{{}}}
+	if _, err := ProcessMultiProof(malformed); err != errInvalidMultiProof {
+		t.Errorf("malformed multiproof: have %v, want %v", err, errInvalidMultiProof)
+	}
+}
+
+// Tests the standard tree against the example of the OpenZeppelin merkle-tree
+// library.
+func TestStandardTree(t *testing.T) {
+	amount1, _ := new(big.Int).SetString("5000000000000000000", 10)
+	amount2, _ := new(big.Int).SetString("2500000000000000000", 10)
+	values := [][]interface{}{
+		{common.HexToAddress("0x1111111111111111111111111111111111111111"), amount1},
+		{common.HexToAddress("0x2222222222222222222222222222222222222222"), amount2},
+	}
+	tree, err := NewStandardTree([]string{"address", "uint256"}, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToHash("0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77"); tree.Root() != want {
+		t.Fatalf("root mismatch: have %x, want %x", tree.Root(), want)
+	}
+	for i := range values {
+		proof, err := tree.Proof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, _ := tree.LeafHash(values[i])
+		if !VerifyProof(tree.Root(), leaf, proof) {
+			t.Errorf("proof %d rejected", i)
+		}
+	}
+	mp, err := tree.MultiProof([]int{0, 1})
+	if err != nil || !VerifyMultiProof(tree.Root(), mp) {
+		t.Errorf("multiproof rejected: %v", err)
+	}
+	// The dump must load back into an identical tree
+	dump, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded StandardTree
+	if err := json.Unmarshal(dump, &loaded); err != nil {
+		t.Fatalf("failed to load dump: %v\n%s", err, dump)
+	}
+	if loaded.Root() != tree.Root() || !reflect.DeepEqual(loaded.values, tree.values) || !reflect.DeepEqual(loaded.indices, tree.indices) {
+		t.Errorf("loaded tree mismatch:\n%s", dump)
+	}
+}
+
+func TestStandardTreeLoad(t *testing.T) {
+	tree, err := NewStandardTree([]string{"uint8", "bool", "bytes4", "string"}, [][]interface{}{
+		{uint8(1), true, [4]byte{1, 2, 3, 4}, "a"},
+		{uint8(2), false, [4]byte{5, 6, 7, 8}, "b"},
+		{uint8(3), true, [4]byte{}, "c"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, _ := json.Marshal(tree)
+
+	var loaded StandardTree
+	if err := json.Unmarshal(dump, &loaded); err != nil {
+		t.Fatalf("failed to load dump: %v\n%s", err, dump)
+	}
+	if !reflect.DeepEqual(loaded.values, tree.values) {
+		t.Errorf("values mismatch: have %v, want %v", loaded.values, tree.values)
+	}
+	// Tampered dumps are rejected
+	var raw map[string]interface{}
+	json.Unmarshal(dump, &raw)
+	raw["values"].([]interface{})[0].(map[string]interface{})["value"].([]interface{})[3] = "x"
+	tampered, _ := json.Marshal(raw)
+	if err := json.Unmarshal(tampered, &loaded); err == nil {
+		t.Error("tampered dump loaded")
+	}
+}
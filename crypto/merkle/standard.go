@@ -0,0 +1,359 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package merkle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// standardFormat is the format identifier of serialized standard trees.
+const standardFormat = "standard-v1"
+
+// StandardTree is a Merkle tree over ABI encoded values, compatible with the
+// StandardMerkleTree of the OpenZeppelin merkle-tree library. Leaves are the
+// double keccak256 hashes of the encoded values, sorted by hash.
+//
+// Values are given as the Go types used by the abi package, e.g. common.Address
+// for address and *big.Int for uint256.
+type StandardTree struct {
+	tree     *Tree
+	encoding []string
+	args     abi.Arguments
+	values   [][]interface{}
+	indices  []int // Tree index of each value
+}
+
+// NewStandardTree builds a standard tree over the values, each of which is
+// encoded with the given leaf encoding, e.g. ["address", "uint256"].
+func NewStandardTree(encoding []string, values [][]interface{}) (*StandardTree, error) {
+	args, err := leafArguments(encoding)
+	if err != nil {
+		return nil, err
+	}
+	type hashedValue struct {
+		index int
+		hash  common.Hash
+	}
+	hashed := make([]hashedValue, len(values))
+	for i, value := range values {
+		hash, err := leafHash(args, value)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+		hashed[i] = hashedValue{index: i, hash: hash}
+	}
+	sort.SliceStable(hashed, func(i, j int) bool {
+		return bytes.Compare(hashed[i].hash[:], hashed[j].hash[:]) < 0
+	})
+	leaves := make([]common.Hash, len(hashed))
+	for i, h := range hashed {
+		leaves[i] = h.hash
+	}
+	tree, err := New(leaves)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]int, len(values))
+	for leaf, h := range hashed {
+		indices[h.index] = tree.nodeIndex(leaf)
+	}
+	return &StandardTree{
+		tree:     tree,
+		encoding: encoding,
+		args:     args,
+		values:   values,
+		indices:  indices,
+	}, nil
+}
+
+// leafArguments parses a leaf encoding.
+func leafArguments(encoding []string) (abi.Arguments, error) {
+	args := make(abi.Arguments, len(encoding))
+	for i, typ := range encoding {
+		t, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf type %q: %v", typ, err)
+		}
+		args[i] = abi.Argument{Type: t}
+	}
+	return args, nil
+}
+
+// leafHash returns the double keccak256 hash of the encoded value.
+func leafHash(args abi.Arguments, value []interface{}) (common.Hash, error) {
+	enc, err := args.Pack(value...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(crypto.Keccak256(enc)), nil
+}
+
+// Root returns the root hash of the tree.
+func (t *StandardTree) Root() common.Hash {
+	return t.tree.Root()
+}
+
+// Len returns the number of values in the tree.
+func (t *StandardTree) Len() int {
+	return len(t.values)
+}
+
+// Value returns the value with the given index.
+func (t *StandardTree) Value(index int) []interface{} {
+	return t.values[index]
+}
+
+// LeafHash returns the leaf hash of a value, which does not need to be in the
+// tree.
+func (t *StandardTree) LeafHash(value []interface{}) (common.Hash, error) {
+	return leafHash(t.args, value)
+}
+
+// leafIndex returns the leaf index of the value with the given index.
+func (t *StandardTree) leafIndex(index int) (int, error) {
+	if index < 0 || index >= len(t.values) {
+		return 0, errInvalidIndex
+	}
+	return t.tree.nodeIndex(t.indices[index]), nil
+}
+
+// Proof returns the proof of the value with the given index.
+func (t *StandardTree) Proof(index int) ([]common.Hash, error) {
+	leaf, err := t.leafIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return t.tree.Proof(leaf)
+}
+
+// MultiProof returns the proof of the values with the given indices. The leaves
+// of the proof are ordered as expected by the verifier.
+func (t *StandardTree) MultiProof(indices []int) (*MultiProof, error) {
+	leaves := make([]int, len(indices))
+	for i, index := range indices {
+		leaf, err := t.leafIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+	return t.tree.MultiProof(leaves)
+}
+
+// standardTreeJSON is the serialization format of the OpenZeppelin library.
+type standardTreeJSON struct {
+	Format       string          `json:"format"`
+	Tree         []common.Hash   `json:"tree"`
+	Values       []standardValue `json:"values"`
+	LeafEncoding []string        `json:"leafEncoding"`
+}
+
+type standardValue struct {
+	Value     []json.RawMessage `json:"value"`
+	TreeIndex int               `json:"treeIndex"`
+}
+
+// MarshalJSON implements json.Marshaler, producing the dump format of the
+// OpenZeppelin library.
+func (t *StandardTree) MarshalJSON() ([]byte, error) {
+	enc := standardTreeJSON{
+		Format:       standardFormat,
+		Tree:         t.tree.nodes,
+		Values:       make([]standardValue, len(t.values)),
+		LeafEncoding: t.encoding,
+	}
+	for i, value := range t.values {
+		fields := make([]json.RawMessage, len(value))
+		for j, field := range value {
+			raw, err := json.Marshal(encodeField(field))
+			if err != nil {
+				return nil, err
+			}
+			fields[j] = raw
+		}
+		enc.Values[i] = standardValue{Value: fields, TreeIndex: t.indices[i]}
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, loading a tree dumped by the
+// OpenZeppelin library. The tree is validated against its values.
+func (t *StandardTree) UnmarshalJSON(input []byte) error {
+	var dec standardTreeJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Format != standardFormat {
+		return fmt.Errorf("unknown tree format %q", dec.Format)
+	}
+	if len(dec.Tree) == 0 {
+		return errEmptyTree
+	}
+	tree, err := newFromNodes(dec.Tree)
+	if err != nil {
+		return err
+	}
+	args, err := leafArguments(dec.LeafEncoding)
+	if err != nil {
+		return err
+	}
+	var (
+		values  = make([][]interface{}, len(dec.Values))
+		indices = make([]int, len(dec.Values))
+	)
+	for i, v := range dec.Values {
+		if len(v.Value) != len(args) {
+			return fmt.Errorf("value %d: have %d fields, want %d", i, len(v.Value), len(args))
+		}
+		values[i] = make([]interface{}, len(args))
+		for j, raw := range v.Value {
+			if values[i][j], err = decodeField(args[j].Type, raw); err != nil {
+				return fmt.Errorf("value %d field %d: %v", i, j, err)
+			}
+		}
+		// Leaves are in the second half of the tree
+		if v.TreeIndex < len(dec.Tree)/2 || v.TreeIndex >= len(dec.Tree) {
+			return fmt.Errorf("value %d: invalid tree index %d", i, v.TreeIndex)
+		}
+		hash, err := leafHash(args, values[i])
+		if err != nil {
+			return fmt.Errorf("value %d: %v", i, err)
+		}
+		if hash != dec.Tree[v.TreeIndex] {
+			return fmt.Errorf("value %d: leaf hash mismatch", i)
+		}
+		indices[i] = v.TreeIndex
+	}
+	*t = StandardTree{
+		tree:     tree,
+		encoding: dec.LeafEncoding,
+		args:     args,
+		values:   values,
+		indices:  indices,
+	}
+	return nil
+}
+
+// encodeField converts a value field to its JSON representation in the dump
+// format: numbers as decimal strings and binary data as hex strings.
+func encodeField(field interface{}) interface{} {
+	switch v := field.(type) {
+	case common.Address:
+		return v.Hex()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case string, bool:
+		return v
+	}
+	rv := reflect.ValueOf(field)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(field)
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+	}
+	return field
+}
+
+// decodeField converts a value field from its JSON representation to the Go
+// type expected by the abi package.
+func decodeField(typ abi.Type, raw json.RawMessage) (interface{}, error) {
+	switch typ.T {
+	case abi.AddressTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		return common.HexToAddress(s), nil
+
+	case abi.UintTy, abi.IntTy:
+		// Numbers are usually strings, but may be plain JSON numbers
+		s := string(raw)
+		if len(raw) > 0 && raw[0] == '"' {
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+		}
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %s", raw)
+		}
+		if typ.Size > 64 {
+			return n, nil
+		}
+		rtyp := typ.GetType()
+		if typ.T == abi.UintTy {
+			if n.Sign() < 0 || !n.IsUint64() || reflect.Zero(rtyp).OverflowUint(n.Uint64()) {
+				return nil, fmt.Errorf("number %s out of range for %s", n, typ)
+			}
+			return reflect.ValueOf(n.Uint64()).Convert(rtyp).Interface(), nil
+		}
+		if !n.IsInt64() || reflect.Zero(rtyp).OverflowInt(n.Int64()) {
+			return nil, fmt.Errorf("number %s out of range for %s", n, typ)
+		}
+		return reflect.ValueOf(n.Int64()).Convert(rtyp).Interface(), nil
+
+	case abi.BoolTy:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+
+	case abi.StringTy:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+
+	case abi.BytesTy:
+		var b hexutil.Bytes
+		err := json.Unmarshal(raw, &b)
+		return []byte(b), err
+
+	case abi.FixedBytesTy:
+		var b hexutil.Bytes
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, err
+		}
+		if len(b) != typ.Size {
+			return nil, fmt.Errorf("invalid length %d for %s", len(b), typ)
+		}
+		arr := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(arr, reflect.ValueOf([]byte(b)))
+		return arr.Interface(), nil
+	}
+	return nil, errors.New("unsupported leaf type " + typ.String())
+}
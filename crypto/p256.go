@@ -0,0 +1,53 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+)
+
+// VerifyP256 checks an ECDSA signature over the secp256r1 (NIST P-256) curve,
+// following the validation rules of the EIP-7212 precompile:
+//
+//   - the hash must be 32 bytes long,
+//   - r and s must be in the range [1, n-1], where n is the curve order,
+//   - the public key (x, y) must be a point on the curve, not the point at
+//     infinity.
+//
+// Unlike secp256k1 signatures, s is not required to be in the lower half of the
+// curve order, since P-256 signatures produced by secure enclaves and passkeys
+// are not normalised.
+func VerifyP256(hash []byte, r, s, x, y *big.Int) bool {
+	if len(hash) != 32 || r == nil || s == nil || x == nil || y == nil {
+		return false
+	}
+	curve := elliptic.P256()
+	params := curve.Params()
+
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(params.N) >= 0 || s.Cmp(params.N) >= 0 {
+		return false
+	}
+	if x.Sign() < 0 || y.Sign() < 0 || x.Cmp(params.P) >= 0 || y.Cmp(params.P) >= 0 {
+		return false
+	}
+	if (x.Sign() == 0 && y.Sign() == 0) || !curve.IsOnCurve(x, y) {
+		return false
+	}
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, hash, r, s)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyP256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("passkey"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyP256(hash[:], r, s, key.X, key.Y) {
+		t.Fatal("valid signature rejected")
+	}
+	n := elliptic.P256().Params().N
+	if !VerifyP256(hash[:], r, new(big.Int).Sub(n, s), key.X, key.Y) {
+		t.Error("valid high-s signature rejected")
+	}
+	for i, tt := range []struct {
+		hash       []byte
+		r, s, x, y *big.Int
+	}{
+		{hash[:31], r, s, key.X, key.Y},                                             // short hash
+		{sha256.New().Sum(nil), r, s, key.X, key.Y},                                 // different hash
+		{hash[:], new(big.Int), s, key.X, key.Y},                                    // zero r
+		{hash[:], r, new(big.Int), key.X, key.Y},                                    // zero s
+		{hash[:], new(big.Int).Add(r, n), s, key.X, key.Y},                          // r overflow
+		{hash[:], r, new(big.Int).Add(s, n), key.X, key.Y},                          // s overflow
+		{hash[:], r, s, key.X, new(big.Int).Add(key.Y, big.NewInt(1))},              // point not on curve
+		{hash[:], r, s, new(big.Int), new(big.Int)},                                 // point at infinity
+		{hash[:], r, s, key.X, new(big.Int).Neg(key.Y)},                             // negative coordinate
+		{hash[:], r, s, nil, key.Y},                                                 // missing coordinate
+		{hash[:], r, s, key.X, new(big.Int).Sub(elliptic.P256().Params().P, key.Y)}, // negated point
+	} {
+		if VerifyP256(tt.hash, tt.r, tt.s, tt.x, tt.y) {
+			t.Errorf("test %d: invalid signature accepted", i)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	recoverHitMeter  = metrics.NewRegisteredMeter("crypto/recover/hit", nil)
+	recoverMissMeter = metrics.NewRegisteredMeter("crypto/recover/miss", nil)
+)
+
+// recoverKey identifies a public key recovery by the signed hash and the
+// signature.
+type recoverKey struct {
+	hash common.Hash
+	sig  [SignatureLength]byte
+}
+
+// CachingRecoverer recovers public keys from signatures like Ecrecover and
+// SigToPub, caching the results of the most recent recoveries. It pays off
+// where the same signatures are verified repeatedly, e.g. when transactions
+// are reprocessed after reorgs or readmitted to the pool. Failed recoveries
+// are not cached.
+//
+// It is safe for concurrent use.
+type CachingRecoverer struct {
+	cache *lru.Cache[recoverKey, []byte] // Uncompressed public keys
+}
+
+// NewCachingRecoverer creates a recoverer caching the given number of public
+// keys.
+func NewCachingRecoverer(size int) *CachingRecoverer {
+	return &CachingRecoverer{cache: lru.NewCache[recoverKey, []byte](size)}
+}
+
+// Ecrecover returns the uncompressed public key that created the given signature.
+func (r *CachingRecoverer) Ecrecover(hash, sig []byte) ([]byte, error) {
+	// Malformed inputs are left to fail in the recovery itself
+	if len(hash) != DigestLength || len(sig) != SignatureLength {
+		return Ecrecover(hash, sig)
+	}
+	var key recoverKey
+	copy(key.hash[:], hash)
+	copy(key.sig[:], sig)
+
+	if pub, ok := r.cache.Get(key); ok {
+		recoverHitMeter.Mark(1)
+		return common.CopyBytes(pub), nil
+	}
+	recoverMissMeter.Mark(1)
+
+	pub, err := Ecrecover(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Add(key, common.CopyBytes(pub))
+	return pub, nil
+}
+
+// SigToPub returns the public key that created the given signature.
+func (r *CachingRecoverer) SigToPub(hash, sig []byte) (*ecdsa.PublicKey, error) {
+	pub, err := r.Ecrecover(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalPubkey(pub)
+}
+
+// Len returns the number of cached public keys.
+func (r *CachingRecoverer) Len() int {
+	return r.cache.Len()
+}
+
+// Purge drops all cached public keys.
+func (r *CachingRecoverer) Purge() {
+	r.cache.Purge()
+}
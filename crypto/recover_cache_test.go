@@ -0,0 +1,65 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCachingRecoverer(t *testing.T) {
+	r := NewCachingRecoverer(1)
+
+	for i := 0; i < 2; i++ {
+		pub, err := r.Ecrecover(testmsg, testsig)
+		if err != nil {
+			t.Fatalf("recovery %d failed: %v", i, err)
+		}
+		if !bytes.Equal(pub, testpubkey) {
+			t.Fatalf("recovery %d: pubkey mismatch: have %x, want %x", i, pub, testpubkey)
+		}
+		// Modifying the result must not affect the cache
+		pub[1] ^= 0xff
+	}
+	if r.Len() != 1 {
+		t.Fatalf("wrong cache size: have %d, want 1", r.Len())
+	}
+	pub, err := r.SigToPub(testmsg, testsig)
+	if err != nil {
+		t.Fatalf("SigToPub failed: %v", err)
+	}
+	if !bytes.Equal(FromECDSAPub(pub), testpubkey) {
+		t.Fatalf("SigToPub: pubkey mismatch: have %x, want %x", FromECDSAPub(pub), testpubkey)
+	}
+	// Failed recoveries are not cached
+	badsig := common.CopyBytes(testsig)
+	copy(badsig[:32], make([]byte, 32))
+	if _, err := r.Ecrecover(testmsg, badsig); err == nil {
+		t.Fatal("recovered public key from invalid signature")
+	}
+	// Malformed inputs bypass the cache
+	r.Ecrecover(testmsg[:31], testsig)
+	if r.Len() != 1 {
+		t.Fatalf("wrong cache size after failed and malformed recoveries: have %d, want 1", r.Len())
+	}
+	r.Purge()
+	if r.Len() != 0 {
+		t.Fatalf("wrong cache size after purge: have %d, want 0", r.Len())
+	}
+}
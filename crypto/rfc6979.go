@@ -0,0 +1,210 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// DeterministicNonces reports whether Sign derives the signature nonce from the
+// private key and the digest as specified by RFC6979, rather than from a random
+// source. This holds for both the libsecp256k1 and the pure Go signers, so the
+// same digest and key always yield the same signature.
+const DeterministicNonces = true
+
+var (
+	errInvalidEntropy    = errors.New("extra entropy must be 32 bytes")
+	errCommitmentInvalid = errors.New("nonce commitment mismatch")
+	errSignatureFault    = errors.New("signature not produced with the committed nonce")
+)
+
+// SignWithEntropy calculates an ECDSA signature like Sign, mixing 32 bytes of
+// extra entropy into the RFC6979 nonce derivation as specified by section 3.6 of
+// the RFC. This matches libsecp256k1 signing with the entropy as nonce data, and
+// is meant for testing parity with hardware signers hedging their nonces. Nil
+// entropy yields the same signature as Sign.
+func SignWithEntropy(digestHash []byte, prv *ecdsa.PrivateKey, entropy []byte) ([]byte, error) {
+	if len(digestHash) != DigestLength {
+		return nil, fmt.Errorf("hash is required to be exactly %d bytes (%d)", DigestLength, len(digestHash))
+	}
+	if entropy != nil && len(entropy) != 32 {
+		return nil, errInvalidEntropy
+	}
+	if prv.D.Sign() <= 0 || prv.D.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("invalid private key")
+	}
+	seckey := math.PaddedBigBytes(prv.D, 32)
+	defer zeroBytes(seckey)
+
+	sig, k := signRFC6979(digestHash, seckey, entropy)
+	defer k.Zero()
+
+	auditSignature(digestHash, entropy, sig, k)
+	return sig, nil
+}
+
+// signRFC6979 calculates an ECDSA signature with a RFC6979 nonce, returning the
+// signature in the [R || S || V] format along with the nonce.
+func signRFC6979(hash, seckey, entropy []byte) ([]byte, *btcec.ModNScalar) {
+	var d, e btcec.ModNScalar
+	d.SetByteSlice(seckey)
+	e.SetByteSlice(hash)
+	defer d.Zero()
+
+	for iteration := uint32(0); ; iteration++ {
+		k := btcec.NonceRFC6979(seckey, hash, entropy, nil, iteration)
+
+		// R = kG, r = R.x mod n
+		var R btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(k, &R)
+		R.ToAffine()
+
+		var r btcec.ModNScalar
+		x := R.X.Bytes()
+		overflow := r.SetBytes(x)
+		if r.IsZero() {
+			k.Zero()
+			continue
+		}
+		// s = k^-1 (e + r*d) mod n
+		var s, kinv btcec.ModNScalar
+		kinv.Set(k).InverseNonConst()
+		s.Mul2(&r, &d).Add(&e).Mul(&kinv)
+		if s.IsZero() {
+			k.Zero()
+			continue
+		}
+		// The recovery id encodes the parity of R.y and whether R.x overflowed
+		v := byte(0)
+		if R.Y.IsOdd() {
+			v |= 1
+		}
+		if overflow != 0 {
+			v |= 2
+		}
+		// Enforce the low S form, which flips the parity of R.y
+		if s.IsOverHalfOrder() {
+			s.Negate()
+			v ^= 1
+		}
+		sig := make([]byte, SignatureLength)
+		r.PutBytesUnchecked(sig[:32])
+		s.PutBytesUnchecked(sig[32:64])
+		sig[RecoveryIDOffset] = v
+		return sig, k
+	}
+}
+
+// SignatureAudit is the audit record of a signature, see SetSignatureAuditor.
+type SignatureAudit struct {
+	Digest     []byte      // Signed digest
+	Entropy    []byte      // Extra entropy mixed into the nonce, nil for Sign
+	Signature  []byte      // Signature in the [R || S || V] format
+	Commitment common.Hash // Keccak256 hash of the nonce and the digest
+}
+
+// signatureAuditor is the auditor receiving the records of produced signatures.
+var signatureAuditor atomic.Pointer[func(*SignatureAudit)]
+
+// SetSignatureAuditor enables the signature audit mode, in which the given
+// function receives an audit record for every signature produced by Sign,
+// SignWithEntropy and LockedKey.Sign. Nil disables the audit mode.
+//
+// The record holds a commitment to the nonce rather than the nonce itself, which
+// would reveal the private key. Signatures can later be checked for faults in
+// their computation with VerifySignatureAudit, given the private key. Auditing
+// derives the nonce a second time, roughly doubling the cost of signing.
+func SetSignatureAuditor(fn func(*SignatureAudit)) {
+	if fn == nil {
+		signatureAuditor.Store(nil)
+		return
+	}
+	signatureAuditor.Store(&fn)
+}
+
+// auditing reports whether the signature audit mode is enabled.
+func auditing() bool {
+	return signatureAuditor.Load() != nil
+}
+
+// auditSignature reports a signature produced with the given nonce to the
+// auditor, if any.
+func auditSignature(hash, entropy, sig []byte, k *btcec.ModNScalar) {
+	fn := signatureAuditor.Load()
+	if fn == nil {
+		return
+	}
+	(*fn)(&SignatureAudit{
+		Digest:     common.CopyBytes(hash),
+		Entropy:    common.CopyBytes(entropy),
+		Signature:  common.CopyBytes(sig),
+		Commitment: nonceCommitment(k, hash),
+	})
+}
+
+// auditSeckeySignature reports a signature produced from a raw private key to
+// the auditor, if any.
+func auditSeckeySignature(hash, seckey, sig []byte) {
+	if !auditing() {
+		return
+	}
+	_, k := signRFC6979(hash, seckey, nil)
+	defer k.Zero()
+
+	auditSignature(hash, nil, sig, k)
+}
+
+// nonceCommitment computes the commitment to a signature nonce.
+func nonceCommitment(k *btcec.ModNScalar, hash []byte) common.Hash {
+	nonce := k.Bytes()
+	defer zeroBytes(nonce[:])
+
+	return Keccak256Hash(nonce[:], hash)
+}
+
+// VerifySignatureAudit checks that the audited signature was correctly computed
+// with the RFC6979 nonce of the given private key, and that the commitment of
+// the audit record matches that nonce. A failure indicates a faulty signer,
+// which may have leaked the private key through the signature.
+func VerifySignatureAudit(audit *SignatureAudit, prv *ecdsa.PrivateKey) error {
+	if len(audit.Digest) != DigestLength {
+		return fmt.Errorf("hash is required to be exactly %d bytes (%d)", DigestLength, len(audit.Digest))
+	}
+	if audit.Entropy != nil && len(audit.Entropy) != 32 {
+		return errInvalidEntropy
+	}
+	seckey := math.PaddedBigBytes(prv.D, 32)
+	defer zeroBytes(seckey)
+
+	sig, k := signRFC6979(audit.Digest, seckey, audit.Entropy)
+	defer k.Zero()
+
+	if nonceCommitment(k, audit.Digest) != audit.Commitment {
+		return errCommitmentInvalid
+	}
+	if len(audit.Signature) != SignatureLength || string(sig) != string(audit.Signature) {
+		return errSignatureFault
+	}
+	return nil
+}
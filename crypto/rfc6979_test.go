@@ -0,0 +1,107 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSignWithEntropy(t *testing.T) {
+	key, _ := HexToECDSA(testPrivHex)
+	digest := Keccak256([]byte("foo"))
+
+	// Without entropy, the signature must match the one of Sign
+	want, err := Sign(digest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err := SignWithEntropy(digest, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("signature mismatch: have %x, want %x", have, want)
+	}
+	// Entropy must change the signature deterministically, keeping it valid
+	entropy := Keccak256([]byte("entropy"))
+	sig1, err := SignWithEntropy(digest, key, entropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, _ := SignWithEntropy(digest, key, entropy)
+	if !bytes.Equal(sig1, sig2) {
+		t.Fatalf("signature not deterministic: %x != %x", sig1, sig2)
+	}
+	if bytes.Equal(sig1, want) {
+		t.Fatal("entropy ignored")
+	}
+	pub, err := SigToPub(digest, sig1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PubkeyToAddress(*pub) != PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("wrong signer recovered: have %x, want %x", PubkeyToAddress(*pub), PubkeyToAddress(key.PublicKey))
+	}
+	if _, err := SignWithEntropy(digest, key, entropy[:16]); err != errInvalidEntropy {
+		t.Fatalf("short entropy: have %v, want %v", err, errInvalidEntropy)
+	}
+}
+
+func TestSignatureAudit(t *testing.T) {
+	var audits []*SignatureAudit
+	SetSignatureAuditor(func(audit *SignatureAudit) { audits = append(audits, audit) })
+	defer SetSignatureAuditor(nil)
+
+	key, _ := HexToECDSA(testPrivHex)
+	digest := Keccak256([]byte("foo"))
+
+	if _, err := Sign(digest, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignWithEntropy(digest, key, Keccak256([]byte("entropy"))); err != nil {
+		t.Fatal(err)
+	}
+	if len(audits) != 2 {
+		t.Fatalf("wrong number of audits: have %d, want 2", len(audits))
+	}
+	for i, audit := range audits {
+		if err := VerifySignatureAudit(audit, key); err != nil {
+			t.Fatalf("audit %d: verification failed: %v", i, err)
+		}
+	}
+	// Faulty signatures and commitments must be detected
+	faulty := *audits[0]
+	faulty.Signature = common.CopyBytes(faulty.Signature)
+	faulty.Signature[40] ^= 0x01
+	if err := VerifySignatureAudit(&faulty, key); err != errSignatureFault {
+		t.Fatalf("faulty signature: have %v, want %v", err, errSignatureFault)
+	}
+	faulty = *audits[0]
+	faulty.Commitment[0] ^= 0x01
+	if err := VerifySignatureAudit(&faulty, key); err != errCommitmentInvalid {
+		t.Fatalf("faulty commitment: have %v, want %v", err, errCommitmentInvalid)
+	}
+	// Auditing must stop once disabled
+	SetSignatureAuditor(nil)
+	Sign(digest, key)
+	if len(audits) != 2 {
+		t.Fatalf("audit after disabling: have %d audits, want 2", len(audits))
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ring implements linkable ring signatures over secp256k1 keys.
+//
+// A ring signature proves that the message was signed by the private key of one
+// of a set of public keys, the ring, without revealing which. The scheme is the
+// LSAG construction of Liu, Wei and Wong: every signature carries a key image,
+// which is the same for all signatures made by a key, whatever the ring and the
+// message. Signatures can thus be linked to each other, e.g. to reject double
+// votes, while staying anonymous.
+//
+// As Ethereum accounts are secp256k1 keys, rings can be made of the public keys
+// of existing accounts, e.g. recovered from their transaction signatures.
+package ring
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	errEmptyRing      = errors.New("empty ring")
+	errSignerNotFound = errors.New("signing key not in ring")
+	errInvalidKey     = errors.New("invalid public key in ring")
+)
+
+// KeyImage is the compressed curve point tagging the signatures made by a key.
+type KeyImage [33]byte
+
+// Signature is a linkable ring signature.
+type Signature struct {
+	Image KeyImage   // Key image of the signing key
+	C     *big.Int   // Challenge of the first ring member
+	R     []*big.Int // Responses of the ring members
+}
+
+// point is a point of the secp256k1 curve in affine coordinates.
+type point struct {
+	x, y *big.Int
+}
+
+// Sign creates a ring signature of the hash of msg with the private key, which
+// must belong to one of the public keys of the ring.
+func Sign(msg []byte, ring []*ecdsa.PublicKey, key *ecdsa.PrivateKey) (*Signature, error) {
+	if len(ring) == 0 {
+		return nil, errEmptyRing
+	}
+	signer := -1
+	for i, pub := range ring {
+		if !validKey(pub) {
+			return nil, errInvalidKey
+		}
+		if pub.X.Cmp(key.X) == 0 && pub.Y.Cmp(key.Y) == 0 {
+			signer = i
+		}
+	}
+	if signer < 0 {
+		return nil, errSignerNotFound
+	}
+	var (
+		n     = crypto.S256().Params().N
+		hp    = hashToPoint(&key.PublicKey)
+		image = mul(hp, key.D)
+		sig   = &Signature{Image: compress(image), R: make([]*big.Int, len(ring))}
+		cs    = make([]*big.Int, len(ring))
+		hash  = ringHash(msg, ring, sig.Image)
+	)
+	// Commit to a random nonce at the signer's position, then go around the ring
+	// filling the other positions with random responses
+	alpha, err := randScalar()
+	if err != nil {
+		return nil, err
+	}
+	next := (signer + 1) % len(ring)
+	cs[next] = challenge(hash, baseMul(alpha), mul(hp, alpha))
+
+	for i := next; i != signer; i = (i + 1) % len(ring) {
+		if sig.R[i], err = randScalar(); err != nil {
+			return nil, err
+		}
+		l, r := commitments(ring[i], image, sig.R[i], cs[i])
+		cs[(i+1)%len(ring)] = challenge(hash, l, r)
+	}
+	// Close the ring with the response of the signer
+	resp := new(big.Int).Mul(cs[signer], key.D)
+	resp.Sub(alpha, resp)
+	resp.Mod(resp, n)
+	if resp.Sign() == 0 {
+		return nil, errors.New("zero response")
+	}
+	sig.R[signer] = resp
+	sig.C = cs[0]
+	return sig, nil
+}
+
+// Verify checks that the signature is a valid ring signature of msg by one of
+// the keys of the ring.
+func Verify(msg []byte, ring []*ecdsa.PublicKey, sig *Signature) bool {
+	if len(ring) == 0 || len(sig.R) != len(ring) || !validScalar(sig.C) {
+		return false
+	}
+	for _, pub := range ring {
+		if !validKey(pub) {
+			return false
+		}
+	}
+	image, err := crypto.DecompressPubkey(sig.Image[:])
+	if err != nil {
+		return false
+	}
+	var (
+		hash = ringHash(msg, ring, sig.Image)
+		c    = sig.C
+	)
+	for i, pub := range ring {
+		if !validScalar(sig.R[i]) {
+			return false
+		}
+		l, r := commitments(pub, point{image.X, image.Y}, sig.R[i], c)
+		if l.x == nil || r.x == nil {
+			return false
+		}
+		c = challenge(hash, l, r)
+	}
+	return c.Cmp(sig.C) == 0
+}
+
+// Linked reports whether the two signatures were made by the same key.
+func Linked(a, b *Signature) bool {
+	return a.Image == b.Image
+}
+
+// Image returns the key image of the private key, which tags all signatures
+// made by it.
+func Image(key *ecdsa.PrivateKey) KeyImage {
+	return compress(mul(hashToPoint(&key.PublicKey), key.D))
+}
+
+// Bytes encodes the signature as the key image, followed by the challenge and
+// the responses as 32 byte big endian integers.
+func (s *Signature) Bytes() []byte {
+	enc := make([]byte, 0, len(s.Image)+32*(len(s.R)+1))
+	enc = append(enc, s.Image[:]...)
+	enc = append(enc, math.PaddedBigBytes(s.C, 32)...)
+	for _, r := range s.R {
+		enc = append(enc, math.PaddedBigBytes(r, 32)...)
+	}
+	return enc
+}
+
+// ParseSignature decodes a signature encoded by Bytes.
+func ParseSignature(enc []byte) (*Signature, error) {
+	if len(enc) < len(KeyImage{})+64 || (len(enc)-len(KeyImage{}))%32 != 0 {
+		return nil, fmt.Errorf("invalid signature length %d", len(enc))
+	}
+	sig := new(Signature)
+	copy(sig.Image[:], enc)
+	enc = enc[len(sig.Image):]
+
+	sig.C = new(big.Int).SetBytes(enc[:32])
+	for enc = enc[32:]; len(enc) > 0; enc = enc[32:] {
+		sig.R = append(sig.R, new(big.Int).SetBytes(enc[:32]))
+	}
+	return sig, nil
+}
+
+// commitments computes the commitments r*G + c*P and r*Hp(P) + c*I of a ring
+// member with public key P, given the key image I. The points are nil if they
+// are at infinity.
+func commitments(pub *ecdsa.PublicKey, image point, r, c *big.Int) (point, point) {
+	l := add(baseMul(r), mul(point{pub.X, pub.Y}, c))
+	return l, add(mul(hashToPoint(pub), r), mul(image, c))
+}
+
+// ringHash hashes the message along with the ring and the key image, binding
+// the challenges to them.
+func ringHash(msg []byte, ring []*ecdsa.PublicKey, image KeyImage) []byte {
+	data := make([]byte, 0, 33*(len(ring)+1)+len(msg))
+	for _, pub := range ring {
+		data = append(data, crypto.CompressPubkey(pub)...)
+	}
+	data = append(data, image[:]...)
+	data = append(data, msg...)
+	return crypto.Keccak256(data)
+}
+
+// challenge derives the challenge of the next ring member from the commitments
+// of the current one.
+func challenge(hash []byte, l, r point) *big.Int {
+	c := new(big.Int).SetBytes(crypto.Keccak256(hash, marshal(l), marshal(r)))
+	return c.Mod(c, crypto.S256().Params().N)
+}
+
+// hashToPoint maps a public key to a curve point whose discrete logarithm is
+// unknown, by hashing it to x coordinates until one is on the curve.
+func hashToPoint(pub *ecdsa.PublicKey) point {
+	var (
+		params = crypto.S256().Params()
+		// p = 3 mod 4, so square roots are powers of (p+1)/4
+		exp  = new(big.Int).Rsh(new(big.Int).Add(params.P, big.NewInt(1)), 2)
+		seed = crypto.CompressPubkey(pub)
+	)
+	for counter := byte(0); ; counter++ {
+		x := new(big.Int).SetBytes(crypto.Keccak256(seed, []byte{counter}))
+		x.Mod(x, params.P)
+
+		// y^2 = x^3 + 7
+		y2 := new(big.Int).Mul(x, x)
+		y2.Mul(y2, x)
+		y2.Add(y2, params.B)
+		y2.Mod(y2, params.P)
+
+		y := new(big.Int).Exp(y2, exp, params.P)
+		if new(big.Int).Mod(new(big.Int).Mul(y, y), params.P).Cmp(y2) != 0 {
+			continue
+		}
+		if y.Bit(0) != 0 {
+			y.Sub(params.P, y)
+		}
+		return point{x, y}
+	}
+}
+
+// baseMul returns k*G.
+func baseMul(k *big.Int) point {
+	x, y := crypto.S256().ScalarBaseMult(math.PaddedBigBytes(k, 32))
+	return point{x, y}
+}
+
+// mul returns k*p, with nil coordinates if either is at infinity.
+func mul(p point, k *big.Int) point {
+	if p.x == nil {
+		return p
+	}
+	x, y := crypto.S256().ScalarMult(p.x, p.y, math.PaddedBigBytes(k, 32))
+	if x == nil || (x.Sign() == 0 && y.Sign() == 0) {
+		return point{}
+	}
+	return point{x, y}
+}
+
+// add returns p+q, with nil coordinates if either or the sum is at infinity.
+func add(p, q point) point {
+	if p.x == nil || q.x == nil {
+		return point{}
+	}
+	x, y := crypto.S256().Add(p.x, p.y, q.x, q.y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return point{}
+	}
+	return point{x, y}
+}
+
+// compress encodes a point in compressed form.
+func compress(p point) KeyImage {
+	var image KeyImage
+	copy(image[:], crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: p.x, Y: p.y}))
+	return image
+}
+
+// marshal encodes a point in uncompressed form, or as empty if at infinity.
+func marshal(p point) []byte {
+	if p.x == nil {
+		return nil
+	}
+	return append(math.PaddedBigBytes(p.x, 32), math.PaddedBigBytes(p.y, 32)...)
+}
+
+// randScalar returns a random non-zero scalar.
+func randScalar() (*big.Int, error) {
+	n := crypto.S256().Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// validScalar reports whether k is a non-zero scalar of the curve.
+func validScalar(k *big.Int) bool {
+	return k != nil && k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
+
+// validKey reports whether the public key is a point of the curve.
+func validKey(pub *ecdsa.PublicKey) bool {
+	return pub != nil && pub.X != nil && pub.Y != nil && crypto.S256().IsOnCurve(pub.X, pub.Y)
+}
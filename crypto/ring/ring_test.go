@@ -0,0 +1,142 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ring
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newRing(t *testing.T, n int) ([]*ecdsa.PrivateKey, []*ecdsa.PublicKey) {
+	var (
+		keys = make([]*ecdsa.PrivateKey, n)
+		ring = make([]*ecdsa.PublicKey, n)
+	)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], ring[i] = key, &key.PublicKey
+	}
+	return keys, ring
+}
+
+func TestSignVerify(t *testing.T) {
+	keys, ring := newRing(t, 5)
+	msg := []byte("vote: yes")
+
+	for i, key := range keys {
+		sig, err := Sign(msg, ring, key)
+		if err != nil {
+			t.Fatalf("signer %d: failed to sign: %v", i, err)
+		}
+		if !Verify(msg, ring, sig) {
+			t.Fatalf("signer %d: valid signature rejected", i)
+		}
+		if sig.Image != Image(key) {
+			t.Fatalf("signer %d: key image mismatch: have %x, want %x", i, sig.Image, Image(key))
+		}
+		dec, err := ParseSignature(sig.Bytes())
+		if err != nil {
+			t.Fatalf("signer %d: failed to decode signature: %v", i, err)
+		}
+		if !Verify(msg, ring, dec) {
+			t.Fatalf("signer %d: decoded signature rejected", i)
+		}
+		// Any change of the message, the ring or the signature must be rejected
+		if Verify([]byte("vote: no"), ring, sig) {
+			t.Fatalf("signer %d: signature of another message accepted", i)
+		}
+		reordered := append([]*ecdsa.PublicKey{ring[len(ring)-1]}, ring[:len(ring)-1]...)
+		if Verify(msg, reordered, sig) {
+			t.Fatalf("signer %d: signature accepted for reordered ring", i)
+		}
+		if Verify(msg, ring[:len(ring)-1], sig) {
+			t.Fatalf("signer %d: signature accepted for smaller ring", i)
+		}
+		tampered := *sig
+		tampered.R = append([]*big.Int{}, sig.R...)
+		tampered.R[(i+1)%len(ring)] = new(big.Int).Add(sig.R[(i+1)%len(ring)], big.NewInt(1))
+		if Verify(msg, ring, &tampered) {
+			t.Fatalf("signer %d: tampered signature accepted", i)
+		}
+		tampered = *sig
+		tampered.Image = Image(keys[(i+1)%len(keys)])
+		if Verify(msg, ring, &tampered) {
+			t.Fatalf("signer %d: signature with foreign key image accepted", i)
+		}
+	}
+}
+
+func TestSingleKeyRing(t *testing.T) {
+	keys, ring := newRing(t, 1)
+	sig, err := Sign([]byte("hello"), ring, keys[0])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !Verify([]byte("hello"), ring, sig) {
+		t.Fatalf("valid signature rejected")
+	}
+}
+
+func TestLinked(t *testing.T) {
+	keys, ring := newRing(t, 4)
+	_, other := newRing(t, 3)
+	other = append(other, ring[0])
+
+	a, err := Sign([]byte("a"), ring, keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Sign([]byte("b"), other, keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Sign([]byte("a"), ring, keys[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Linked(a, b) {
+		t.Fatalf("signatures of the same key not linked")
+	}
+	if Linked(a, c) {
+		t.Fatalf("signatures of different keys linked")
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	keys, ring := newRing(t, 3)
+	outsider, _ := crypto.GenerateKey()
+
+	if _, err := Sign(nil, nil, keys[0]); err != errEmptyRing {
+		t.Fatalf("empty ring: have error %v, want %v", err, errEmptyRing)
+	}
+	if _, err := Sign(nil, ring, outsider); err != errSignerNotFound {
+		t.Fatalf("outsider: have error %v, want %v", err, errSignerNotFound)
+	}
+	invalid := append(ring, &ecdsa.PublicKey{Curve: crypto.S256(), X: big.NewInt(1), Y: big.NewInt(1)})
+	if _, err := Sign(nil, invalid, keys[0]); err != errInvalidKey {
+		t.Fatalf("invalid key: have error %v, want %v", err, errInvalidKey)
+	}
+	if _, err := ParseSignature(make([]byte, 33+32)); err == nil {
+		t.Fatalf("signature without responses accepted")
+	}
+}
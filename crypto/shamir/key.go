@@ -0,0 +1,82 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package shamir
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SplitKey divides an ECDSA private key into n shares, any k of which can
+// reconstruct it.
+func SplitKey(key *ecdsa.PrivateKey, n, k int) ([][]byte, error) {
+	secret := crypto.FromECDSA(key)
+	defer zero(secret)
+
+	return Split(secret, n, k)
+}
+
+// CombineKey reconstructs an ECDSA private key from the given shares. As an
+// invalid scalar is the only detectable failure, callers should compare the
+// address of the key against the expected one.
+func CombineKey(shares [][]byte) (*ecdsa.PrivateKey, error) {
+	secret, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(secret)
+
+	key, err := crypto.ToECDSA(secret)
+	if err != nil {
+		return nil, fmt.Errorf("shamir: invalid reconstructed key: %v", err)
+	}
+	return key, nil
+}
+
+// SplitKeyJSON divides an encrypted keystore file into n shares, any k of which
+// can reconstruct it. Reconstructing the key thus requires both the threshold of
+// shares and the passphrase.
+func SplitKeyJSON(keyjson []byte, n, k int) ([][]byte, error) {
+	if !json.Valid(keyjson) {
+		return nil, fmt.Errorf("shamir: invalid key JSON")
+	}
+	return Split(keyjson, n, k)
+}
+
+// CombineKeyJSON reconstructs an encrypted keystore file from the given shares.
+// Supplying fewer shares than the threshold is detected with high probability,
+// as the result is not valid JSON.
+func CombineKeyJSON(shares [][]byte) ([]byte, error) {
+	keyjson, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(keyjson) {
+		return nil, fmt.Errorf("shamir: reconstructed key JSON invalid, too few shares?")
+	}
+	return keyjson, nil
+}
+
+// zero overwrites a byte slice holding secret material.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package shamir implements Shamir's secret sharing over GF(2^8), splitting a
+// secret into n shares of which any k suffice to reconstruct it, while fewer
+// than k reveal nothing about it.
+//
+// Every byte of the secret is shared independently with its own random polynomial
+// of degree k-1. A share is the evaluation of all polynomials at the same point,
+// followed by the point itself, so it is one byte longer than the secret.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidThreshold is returned if the threshold is out of range.
+	ErrInvalidThreshold = errors.New("shamir: threshold must be between 2 and the number of shares")
+
+	// ErrTooManyShares is returned if more than 255 shares are requested.
+	ErrTooManyShares = errors.New("shamir: at most 255 shares supported")
+
+	// ErrEmptySecret is returned when attempting to split an empty secret.
+	ErrEmptySecret = errors.New("shamir: empty secret")
+
+	// ErrTooFewShares is returned if less than two shares are combined.
+	ErrTooFewShares = errors.New("shamir: at least two shares required")
+
+	// ErrShareMismatch is returned if the combined shares differ in length.
+	ErrShareMismatch = errors.New("shamir: shares of different lengths")
+)
+
+// expTable and logTable are the exponential and logarithm tables of GF(2^8)
+// with the AES reduction polynomial x^8 + x^4 + x^3 + x + 1, using 3 as the
+// generator.
+var expTable, logTable [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+
+		// Multiply by the generator 3 = x + 1
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	expTable[255] = expTable[0]
+}
+
+// mul multiplies two field elements.
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+// div divides two field elements. The divisor must not be zero.
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+255-int(logTable[b]))%255]
+}
+
+// evaluate evaluates the polynomial with the given coefficients, lowest order
+// first, at x.
+func evaluate(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// Split divides the secret into n shares, any k of which can reconstruct it.
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if n > 255 {
+		return nil, ErrTooManyShares
+	}
+	if k < 2 || k > n {
+		return nil, ErrInvalidThreshold
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+	coeffs := make([]byte, k)
+	defer func() {
+		for i := range coeffs {
+			coeffs[i] = 0
+		}
+	}()
+	for i, b := range secret {
+		coeffs[0] = b
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share[i] = evaluate(coeffs, share[len(secret)])
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from the given shares. It cannot detect that
+// fewer shares than the threshold were supplied, in which case the result is
+// random garbage; callers should validate the reconstructed secret if possible.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrTooFewShares
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.New("shamir: share too short")
+	}
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool)
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, ErrShareMismatch
+		}
+		x := share[size-1]
+		if x == 0 {
+			return nil, errors.New("shamir: invalid share point")
+		}
+		if seen[x] {
+			return nil, fmt.Errorf("shamir: duplicate share %d", x)
+		}
+		seen[x], xs[i] = true, x
+	}
+	// Interpolate the polynomials at zero using Lagrange's formula
+	secret := make([]byte, size-1)
+	for i, xi := range xs {
+		// basis = prod(xj / (xj - xi)) for j != i, subtraction being xor
+		basis := byte(1)
+		for j, xj := range xs {
+			if i != j {
+				basis = mul(basis, div(xj, xj^xi))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= mul(shares[i][b], basis)
+		}
+	}
+	return secret, nil
+}
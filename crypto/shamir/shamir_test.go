@@ -0,0 +1,121 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package shamir
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestFieldArithmetic(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if have := div(mul(byte(a), byte(b)), byte(b)); have != byte(a) {
+				t.Fatalf("(%d*%d)/%d = %d", a, b, b, have)
+			}
+		}
+	}
+	// 0x57 * 0x83 = 0xc1 is the worked example of the AES specification
+	if have := mul(0x57, 0x83); have != 0xc1 {
+		t.Fatalf("0x57*0x83 mismatch: have %#x, want 0xc1", have)
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	// Every subset of at least k shares must reconstruct the secret
+	for mask := 0; mask < 1<<len(shares); mask++ {
+		var subset [][]byte
+		for i, share := range shares {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, share)
+			}
+		}
+		if len(subset) < 2 {
+			continue
+		}
+		have, err := Combine(subset)
+		if err != nil {
+			t.Fatalf("mask %b: failed to combine: %v", mask, err)
+		}
+		if ok := bytes.Equal(have, secret); ok != (len(subset) >= 3) {
+			t.Errorf("mask %b: reconstruction mismatch: have %q, %d shares", mask, have, len(subset))
+		}
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	tests := []struct {
+		secret []byte
+		n, k   int
+		err    error
+	}{
+		{nil, 3, 2, ErrEmptySecret},
+		{[]byte{1}, 3, 1, ErrInvalidThreshold},
+		{[]byte{1}, 3, 4, ErrInvalidThreshold},
+		{[]byte{1}, 256, 2, ErrTooManyShares},
+	}
+	for i, tt := range tests {
+		if _, err := Split(tt.secret, tt.n, tt.k); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	shares, _ := Split([]byte{1, 2, 3}, 3, 2)
+	if _, err := Combine(shares[:1]); err != ErrTooFewShares {
+		t.Errorf("single share: have %v, want %v", err, ErrTooFewShares)
+	}
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Errorf("duplicate shares combined")
+	}
+	if _, err := Combine([][]byte{shares[0], shares[1][1:]}); err != ErrShareMismatch {
+		t.Errorf("mismatched shares: have %v, want %v", err, ErrShareMismatch)
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	shares, err := SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatalf("failed to split key: %v", err)
+	}
+	restored, err := CombineKey(shares[1:])
+	if err != nil {
+		t.Fatalf("failed to combine key: %v", err)
+	}
+	if !key.Equal(restored) {
+		t.Fatalf("restored key mismatch")
+	}
+	keyjson := []byte(`{"address":"0000000000000000000000000000000000000000","crypto":{},"version":3}`)
+	shares, err = SplitKeyJSON(keyjson, 4, 3)
+	if err != nil {
+		t.Fatalf("failed to split key JSON: %v", err)
+	}
+	if have, err := CombineKeyJSON(shares[:3]); err != nil || !bytes.Equal(have, keyjson) {
+		t.Fatalf("key JSON mismatch: have %s, %v", have, err)
+	}
+	if _, err := CombineKeyJSON(shares[:2]); err == nil {
+		t.Fatalf("key JSON combined from too few shares")
+	}
+}
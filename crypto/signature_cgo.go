@@ -58,7 +58,17 @@ func Sign(digestHash []byte, prv *ecdsa.PrivateKey) (sig []byte, err error) {
 	}
 	seckey := math.PaddedBigBytes(prv.D, prv.Params().BitSize/8)
 	defer zeroBytes(seckey)
-	return secp256k1.Sign(digestHash, seckey)
+	return signSeckey(digestHash, seckey)
+}
+
+// signSeckey calculates an ECDSA signature with a raw 32 byte private key.
+func signSeckey(digestHash, seckey []byte) ([]byte, error) {
+	sig, err := secp256k1.Sign(digestHash, seckey)
+	if err != nil {
+		return nil, err
+	}
+	auditSeckeySignature(digestHash, seckey, sig)
+	return sig, nil
 }
 
 // VerifySignature checks that the given public key created signature over digest.
@@ -82,7 +82,33 @@ func Sign(hash []byte, prv *ecdsa.PrivateKey) ([]byte, error) {
 		return nil, fmt.Errorf("invalid private key")
 	}
 	defer priv.Zero()
-	sig, err := btc_ecdsa.SignCompact(&priv, hash, false) // ref uncompressed pubkey
+
+	sig, err := signCompact(hash, &priv)
+	if err == nil && auditing() {
+		seckey := priv.Key.Bytes()
+		auditSeckeySignature(hash, seckey[:], sig)
+		zeroBytes(seckey[:])
+	}
+	return sig, err
+}
+
+// signSeckey calculates an ECDSA signature with a raw 32 byte private key.
+func signSeckey(hash, seckey []byte) ([]byte, error) {
+	var priv btcec.PrivateKey
+	if overflow := priv.Key.SetByteSlice(seckey); overflow || priv.Key.IsZero() {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	defer priv.Zero()
+
+	sig, err := signCompact(hash, &priv)
+	if err == nil {
+		auditSeckeySignature(hash, seckey, sig)
+	}
+	return sig, err
+}
+
+func signCompact(hash []byte, priv *btcec.PrivateKey) ([]byte, error) {
+	sig, err := btc_ecdsa.SignCompact(priv, hash, false) // ref uncompressed pubkey
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,237 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	errInvalidPubKey    = errors.New("invalid public key")
+	errInvalidSignature = errors.New("invalid signature format")
+	errUntrustedKey     = errors.New("signing key not trusted")
+	errBadSignature     = errors.New("signature verification failed")
+)
+
+const (
+	untrustedPrefix = "untrusted comment: "
+	trustedPrefix   = "trusted comment: "
+)
+
+// PublicKey is an Ed25519 public key in the format used by both the 'signify'
+// and the 'minisign' tools.
+type PublicKey struct {
+	KeyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// ParsePublicKey decodes a base64 public key. It also accepts the contents of a
+// public key file, i.e. the key preceded by an untrusted comment line.
+func ParsePublicKey(key string) (*PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(key), "\n")
+	keydata, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return nil, err
+	}
+	if len(keydata) != 42 || string(keydata[:2]) != "Ed" {
+		return nil, errInvalidPubKey
+	}
+	pub := &PublicKey{key: ed25519.PublicKey(keydata[10:])}
+	copy(pub.KeyID[:], keydata[2:10])
+	return pub, nil
+}
+
+// String returns the key ID as printed by the signing tools.
+func (k *PublicKey) String() string {
+	// Key IDs are printed in reverse byte order.
+	var rev [8]byte
+	for i := range k.KeyID {
+		rev[len(rev)-1-i] = k.KeyID[i]
+	}
+	return fmt.Sprintf("%X", rev)
+}
+
+// Signature is a decoded signature file. Signatures created by 'signify' only
+// consist of the untrusted comment and the data signature, 'minisign' adds a
+// trusted comment, which is authenticated by a second signature.
+type Signature struct {
+	UntrustedComment string
+	TrustedComment   string
+
+	algorithm  [2]byte
+	keyID      [8]byte
+	sig        []byte
+	commentSig []byte
+}
+
+// ParseSignature decodes the contents of a signature file.
+func ParseSignature(data []byte) (*Signature, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 2 && len(lines) != 4 {
+		return nil, errInvalidSignature
+	}
+	if !strings.HasPrefix(lines[0], untrustedPrefix) {
+		return nil, errInvalidSignature
+	}
+	sig := &Signature{UntrustedComment: strings.TrimPrefix(lines[0], untrustedPrefix)}
+
+	bin, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(bin) != 10+ed25519.SignatureSize {
+		return nil, errInvalidSignature
+	}
+	copy(sig.algorithm[:], bin[:2])
+	copy(sig.keyID[:], bin[2:10])
+	sig.sig = bin[10:]
+
+	if string(sig.algorithm[:]) != "Ed" && string(sig.algorithm[:]) != "ED" {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", sig.algorithm[:])
+	}
+	if len(lines) == 4 {
+		if !strings.HasPrefix(lines[2], trustedPrefix) {
+			return nil, errInvalidSignature
+		}
+		sig.TrustedComment = strings.TrimPrefix(lines[2], trustedPrefix)
+		if sig.commentSig, err = base64.StdEncoding.DecodeString(lines[3]); err != nil {
+			return nil, err
+		}
+		if len(sig.commentSig) != ed25519.SignatureSize {
+			return nil, errInvalidSignature
+		}
+	}
+	return sig, nil
+}
+
+// Verify checks that the signature is a valid signature of data made with the
+// key. If the signature has a trusted comment, its signature is checked too.
+func (k *PublicKey) Verify(data []byte, sig *Signature) error {
+	if k.KeyID != sig.keyID {
+		return fmt.Errorf("%w: signed by %X", errUntrustedKey, sig.keyID)
+	}
+	// Signatures made in prehashed mode sign the BLAKE2b digest of the data.
+	if string(sig.algorithm[:]) == "ED" {
+		digest := blake2b.Sum512(data)
+		data = digest[:]
+	}
+	if !ed25519.Verify(k.key, data, sig.sig) {
+		return errBadSignature
+	}
+	if sig.commentSig != nil {
+		msg := append(append([]byte{}, sig.sig...), sig.TrustedComment...)
+		if !ed25519.Verify(k.key, msg, sig.commentSig) {
+			return fmt.Errorf("%w: trusted comment", errBadSignature)
+		}
+	}
+	return nil
+}
+
+// Verify checks that sigdata holds a valid signature of data, made by any of the
+// given trusted public keys.
+func Verify(pubkeys []string, data, sigdata []byte) error {
+	sig, err := ParseSignature(sigdata)
+	if err != nil {
+		return err
+	}
+	for _, pubkey := range pubkeys {
+		key, err := ParsePublicKey(pubkey)
+		if err != nil {
+			return err
+		}
+		if key.KeyID == sig.keyID {
+			return key.Verify(data, sig)
+		}
+	}
+	return errUntrustedKey
+}
+
+// VerifyFile checks the signature of a file against the trusted public keys. The
+// signature is read from the file path with the given extension appended, e.g.
+// ".sig" or ".minisig".
+func VerifyFile(pubkeys []string, path, ext string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sigdata, err := os.ReadFile(path + ext)
+	if err != nil {
+		return err
+	}
+	return Verify(pubkeys, data, sigdata)
+}
+
+// Checksums is a set of SHA256 file checksums, as created by the 'sha256sum' tool.
+type Checksums map[string]string
+
+// ParseChecksums decodes a checksum list with lines of the form
+// "<hex digest>  <file name>". Empty lines and lines starting with '#' are
+// ignored.
+func ParseChecksums(data []byte) (Checksums, error) {
+	sums := make(Checksums)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: invalid checksum entry", i+1)
+		}
+		digest, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("line %d: invalid SHA256 digest %q", i+1, fields[0])
+		}
+		sums[name] = digest
+	}
+	return sums, nil
+}
+
+// Verify hashes the content read from r and checks it against the checksum of
+// the given file name.
+func (sums Checksums) Verify(name string, r io.Reader) error {
+	want, ok := sums[name]
+	if !ok {
+		return fmt.Errorf("no checksum for %s", name)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if have := hex.EncodeToString(h.Sum(nil)); have != want {
+		return fmt.Errorf("invalid file hash %s for %s", have, name)
+	}
+	return nil
+}
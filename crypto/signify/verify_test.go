@@ -0,0 +1,103 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signify
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "snapshot.tar")
+	data := []byte("chain snapshot")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignFile(file, file+".minisig", testSecKey, "untrusted", "file:snapshot.tar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFile([]string{testPubKey}, file, ".minisig"); err != nil {
+		t.Fatalf("failed to verify signature: %v", err)
+	}
+	sigdata, err := os.ReadFile(file + ".minisig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ParseSignature(sigdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.UntrustedComment != "untrusted" || sig.TrustedComment != "file:snapshot.tar" {
+		t.Errorf("comment mismatch: have %q/%q", sig.UntrustedComment, sig.TrustedComment)
+	}
+	// Modified data, trusted comments and unknown keys must be rejected
+	if err := Verify([]string{testPubKey}, []byte("chain snapshoT"), sigdata); !errors.Is(err, errBadSignature) {
+		t.Errorf("tampered data error mismatch: have %v, want %v", err, errBadSignature)
+	}
+	tampered := bytes.Replace(sigdata, []byte("file:snapshot.tar"), []byte("file:snapshot.tgz"), 1)
+	if err := Verify([]string{testPubKey}, data, tampered); !errors.Is(err, errBadSignature) {
+		t.Errorf("tampered comment error mismatch: have %v, want %v", err, errBadSignature)
+	}
+	if err := Verify(nil, data, sigdata); !errors.Is(err, errUntrustedKey) {
+		t.Errorf("untrusted key error mismatch: have %v, want %v", err, errUntrustedKey)
+	}
+	// Signify signatures lack the trusted comment lines
+	lines := strings.SplitAfter(string(sigdata), "\n")
+	if err := Verify([]string{testPubKey}, data, []byte(lines[0]+lines[1])); err != nil {
+		t.Errorf("failed to verify signature without trusted comment: %v", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := ParsePublicKey("untrusted comment: minisign public key\n" + testPubKey + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.String() != "8E452FABB6153DC0" {
+		t.Errorf("key id mismatch: have %s", key)
+	}
+	if _, err := ParsePublicKey(testSecKey); err == nil {
+		t.Error("secret key accepted as public key")
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	sums, err := ParseChecksums([]byte(`# trusted setup
+cb4d7d7b2f3a0b0af1a6e8d35b1cc89a1ad4d6a06a0b1d13da1d1c1b3e25fb1a  other.txt
+b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9 *hello.txt
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sums.Verify("hello.txt", strings.NewReader("hello world")); err != nil {
+		t.Errorf("failed to verify checksum: %v", err)
+	}
+	if err := sums.Verify("hello.txt", strings.NewReader("hello world!")); err == nil {
+		t.Error("wrong content verified")
+	}
+	if err := sums.Verify("missing.txt", strings.NewReader("")); err == nil {
+		t.Error("missing checksum verified")
+	}
+	if _, err := ParseChecksums([]byte("abcd  file")); err == nil {
+		t.Error("short digest accepted")
+	}
+}
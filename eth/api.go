@@ -33,9 +33,13 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -243,6 +247,18 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// LocalTransactions returns the local transactions of the pool, which are
+// journaled to survive node restarts until included or evicted.
+func (api *AdminAPI) LocalTransactions() map[common.Address]types.Transactions {
+	return api.eth.TxPool().LocalTransactions()
+}
+
+// EvictLocalTransaction removes a local transaction from the pool and its
+// journal. Returns false if no local transaction with the given hash is known.
+func (api *AdminAPI) EvictLocalTransaction(hash common.Hash) bool {
+	return api.eth.TxPool().EvictLocal(hash)
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -336,6 +352,112 @@ func (api *DebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error)
 	return results, nil
 }
 
+// GetBlockStateDiffs returns the per-transaction state diffs recorded while the
+// given block was processed. The last entry, if its transaction hash is empty,
+// holds the changes made during block finalization (rewards, withdrawals).
+//
+// Diffs are only available for blocks processed while recording was enabled
+// via the --statediffs flag.
+func (api *DebugAPI) GetBlockStateDiffs(blockNr rpc.BlockNumber) ([]*state.StateDiff, error) {
+	var header *types.Header
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		return nil, errors.New("state diffs are not available for the pending block")
+	case rpc.LatestBlockNumber:
+		header = api.eth.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		header = api.eth.blockchain.CurrentFinalBlock()
+	case rpc.SafeBlockNumber:
+		header = api.eth.blockchain.CurrentSafeBlock()
+	default:
+		header = api.eth.blockchain.GetHeaderByNumber(uint64(blockNr))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	diffs := api.eth.blockchain.GetStateDiffs(header.Hash())
+	if diffs == nil {
+		return nil, fmt.Errorf("state diffs of block #%d not recorded", header.Number)
+	}
+	return diffs, nil
+}
+
+// StateRetention returns the historical state retained by the node on top of
+// the recent state, or nil if none is retained. Archive nodes retain all state
+// and return nil as well.
+func (api *DebugAPI) StateRetention() *state.RetentionPolicy {
+	return api.eth.blockchain.RetentionPolicy()
+}
+
+// IsStateRetained reports whether the state of the given block is available.
+// If an account is given, only the state of that account is checked, which is
+// available for the accounts retained by partial archive nodes.
+func (api *DebugAPI) IsStateRetained(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, account *common.Address) (bool, error) {
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return false, err
+	}
+	if header == nil {
+		return false, errors.New("block not found")
+	}
+	return api.eth.blockchain.StateRetained(header, account)
+}
+
+// SetOpcodeCounting enables or disables counting the opcodes executed by the
+// blocks the node processes.
+func (api *DebugAPI) SetOpcodeCounting(enabled bool) {
+	api.eth.blockchain.SetOpCounting(enabled)
+}
+
+// TxOpcodeCounters are the opcode statistics of a transaction.
+type TxOpcodeCounters struct {
+	Hash common.Hash `json:"hash"`
+	Ops  []vm.OpStat `json:"ops"`
+}
+
+// BlockOpcodeCounters are the opcode statistics of a block, broken down by
+// transaction.
+type BlockOpcodeCounters struct {
+	Hash   common.Hash         `json:"hash"`
+	Number hexutil.Uint64      `json:"number"`
+	Ops    []vm.OpStat         `json:"ops"`
+	Txs    []*TxOpcodeCounters `json:"transactions"`
+}
+
+// OpcodeCounters returns the opcode statistics of the given block. They are
+// only available for recently processed blocks, and only if opcode counting was
+// enabled when they were processed.
+func (api *DebugAPI) OpcodeCounters(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockOpcodeCounters, error) {
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	counters := api.eth.blockchain.GetOpCounters(header.Hash())
+	if counters == nil {
+		return nil, fmt.Errorf("opcodes of block #%d not counted", header.Number)
+	}
+	result := &BlockOpcodeCounters{
+		Hash:   header.Hash(),
+		Number: hexutil.Uint64(header.Number.Uint64()),
+		Ops:    counters.Block.Stats(),
+		Txs:    make([]*TxOpcodeCounters, len(counters.Txs)),
+	}
+	for i, tx := range counters.Txs {
+		result.Txs[i] = &TxOpcodeCounters{Hash: tx.Hash, Ops: tx.Stats()}
+	}
+	return result, nil
+}
+
+// OpcodeTotals returns the opcode statistics accumulated over all blocks
+// processed with opcode counting enabled since the node started.
+func (api *DebugAPI) OpcodeTotals() []vm.OpStat {
+	totals := api.eth.blockchain.OpTotals()
+	return totals.Stats()
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -399,6 +521,97 @@ func (api *DebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, start hex
 	return stateDb.IteratorDump(opts), nil
 }
 
+// RangeProofResult is the result of a debug_accountRangeProof or
+// debug_storageRangeProof API call.
+type RangeProofResult struct {
+	Root   common.Hash     `json:"root"`   // Root of the trie the range is proven against
+	Origin common.Hash     `json:"origin"` // Key the range starts at
+	Keys   []common.Hash   `json:"keys"`   // Hashed keys of the leaves in the range
+	Values []hexutil.Bytes `json:"values"` // Trie values of the leaves
+	Proof  []hexutil.Bytes `json:"proof"`  // Trie nodes proving the edges of the range
+}
+
+func newRangeProofResult(proof *snapshot.RangeProof) *RangeProofResult {
+	result := &RangeProofResult{
+		Root:   proof.Root,
+		Origin: proof.Origin,
+		Keys:   proof.Keys,
+		Values: make([]hexutil.Bytes, len(proof.Values)),
+		Proof:  make([]hexutil.Bytes, len(proof.Proof)),
+	}
+	for i, value := range proof.Values {
+		result.Values[i] = value
+	}
+	for i, node := range proof.Proof {
+		result.Proof[i] = node
+	}
+	return result
+}
+
+// rangeProofLimit is the default limit of range proofs, covering the whole trie.
+var rangeProofLimit = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// rangeProofSnapshots returns the snapshot tree along with the state root of the
+// given block, for serving range proofs.
+func (api *DebugAPI) rangeProofSnapshots(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*snapshot.Tree, common.Hash, error) {
+	snaps := api.eth.blockchain.Snapshots()
+	if snaps == nil {
+		return nil, common.Hash{}, errors.New("snapshots disabled")
+	}
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if header == nil {
+		return nil, common.Hash{}, errors.New("block not found")
+	}
+	return snaps, header.Root, nil
+}
+
+// AccountRangeProof retrieves a range of accounts of the state at the given block
+// from the snapshot, along with the Merkle proofs of its edges, starting at the
+// origin hash. Accounts are returned until the first one at or beyond the limit
+// hash, which defaults to the maximum hash, or until maxResults accounts.
+func (api *DebugAPI) AccountRangeProof(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, origin common.Hash, limit *common.Hash, maxResults int) (*RangeProofResult, error) {
+	snaps, root, err := api.rangeProofSnapshots(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil {
+		limit = &rangeProofLimit
+	}
+	if maxResults > AccountRangeMaxResults || maxResults <= 0 {
+		maxResults = AccountRangeMaxResults
+	}
+	proof, err := snaps.AccountRange(root, origin, *limit, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	return newRangeProofResult(proof), nil
+}
+
+// StorageRangeProof retrieves a range of storage slots of an account in the state
+// at the given block from the snapshot, along with the Merkle proofs of its edges
+// against the storage root of the account. The range is delimited the same way
+// as in AccountRangeProof.
+func (api *DebugAPI) StorageRangeProof(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, address common.Address, origin common.Hash, limit *common.Hash, maxResults int) (*RangeProofResult, error) {
+	snaps, root, err := api.rangeProofSnapshots(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil {
+		limit = &rangeProofLimit
+	}
+	if maxResults > AccountRangeMaxResults || maxResults <= 0 {
+		maxResults = AccountRangeMaxResults
+	}
+	proof, err := snaps.StorageRange(root, crypto.Keccak256Hash(address[:]), origin, *limit, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	return newRangeProofResult(proof), nil
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -612,3 +825,89 @@ func (api *DebugAPI) SetTrieFlushInterval(interval string) error {
 	api.eth.blockchain.SetTrieFlushInterval(t)
 	return nil
 }
+
+// IndexRebuildStatus is the progress of a chain index rebuild as reported by the
+// debug API.
+type IndexRebuildStatus struct {
+	Index     string         `json:"index"`
+	From      hexutil.Uint64 `json:"from"`
+	To        hexutil.Uint64 `json:"to"`
+	Processed hexutil.Uint64 `json:"processed"`
+	Running   bool           `json:"running"`
+	Aborted   bool           `json:"aborted"`
+	Error     string         `json:"error,omitempty"`
+	Elapsed   string         `json:"elapsed"`
+}
+
+func newIndexRebuildStatus(p rawdb.IndexRebuildProgress) *IndexRebuildStatus {
+	status := &IndexRebuildStatus{
+		Index:     p.Index,
+		From:      hexutil.Uint64(p.From),
+		To:        hexutil.Uint64(p.To),
+		Processed: hexutil.Uint64(p.Processed),
+		Running:   p.Running,
+		Aborted:   p.Aborted,
+		Elapsed:   common.PrettyDuration(p.Elapsed).String(),
+	}
+	if p.Err != nil {
+		status.Error = p.Err.Error()
+	}
+	return status
+}
+
+// StartIndexRebuild regenerates the "txlookup" or the "bloombits" (log filter)
+// index for the canonical blocks [from, to) in the background. If to is omitted,
+// the range extends to the current head. Only one rebuild can run at a time.
+func (api *DebugAPI) StartIndexRebuild(index string, from hexutil.Uint64, to *hexutil.Uint64) (*IndexRebuildStatus, error) {
+	end := api.eth.blockchain.CurrentBlock().Number.Uint64() + 1
+	if to != nil && uint64(*to) < end {
+		end = uint64(*to)
+	}
+	api.eth.lock.Lock()
+	defer api.eth.lock.Unlock()
+
+	if api.eth.indexRebuild != nil && api.eth.indexRebuild.Progress().Running {
+		return nil, errors.New("index rebuild already running")
+	}
+	rebuild, err := rawdb.NewIndexRebuild(api.eth.chainDb, index, uint64(from), end, params.BloomBitsBlocks, api.eth.bloomIndexer.AddSection)
+	if err != nil {
+		return nil, err
+	}
+	// The transaction index tail is shared with the chain's own indexer, so run
+	// the txlookup rebuild on it. Rebuilt bloom bits sections are handed to the
+	// bloom indexer, which serializes them with its own processing.
+	if index == rawdb.TxLookupIndex {
+		if err := api.eth.blockchain.RunTxIndexTask(rebuild.Run); err != nil {
+			return nil, err
+		}
+	} else {
+		go rebuild.Run()
+	}
+	api.eth.indexRebuild = rebuild
+	return newIndexRebuildStatus(rebuild.Progress()), nil
+}
+
+// StopIndexRebuild aborts the running index rebuild. The blocks indexed so far
+// are kept.
+func (api *DebugAPI) StopIndexRebuild() (*IndexRebuildStatus, error) {
+	api.eth.lock.Lock()
+	defer api.eth.lock.Unlock()
+
+	if api.eth.indexRebuild == nil {
+		return nil, errors.New("no index rebuild started")
+	}
+	api.eth.indexRebuild.Stop()
+	return newIndexRebuildStatus(api.eth.indexRebuild.Progress()), nil
+}
+
+// IndexRebuildStatus returns the progress of the last started index rebuild, or
+// null if none was started.
+func (api *DebugAPI) IndexRebuildStatus() *IndexRebuildStatus {
+	api.eth.lock.RLock()
+	defer api.eth.lock.RUnlock()
+
+	if api.eth.indexRebuild == nil {
+		return nil
+	}
+	return newIndexRebuildStatus(api.eth.indexRebuild.Progress())
+}
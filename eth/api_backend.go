@@ -295,6 +295,11 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return tx, blockHash, blockNumber, index, nil
 }
 
+func (b *EthAPIBackend) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransactionBySenderAndNonce(b.eth.ChainDb(), sender, nonce)
+	return tx, blockHash, blockNumber, index, nil
+}
+
 func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.eth.txPool.Nonce(addr), nil
 }
@@ -84,6 +84,7 @@ type Ethereum struct {
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
+	indexRebuild      *rawdb.IndexRebuild // Manually triggered index rebuild, if any
 
 	APIBackend *EthAPIBackend
 
@@ -191,6 +192,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
+			StateDiffs:          config.StateDiffs,
+			TxSenderIndex:       config.TxSenderIndex,
+			Retention:           config.StateRetention,
+			History:             config.HistoryRetention,
 		}
 	)
 	// Override the chain config with provided settings.
@@ -202,6 +207,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	eth.blockchain.SetOpCounting(config.EnableOpCounters)
 	eth.bloomIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
@@ -527,6 +533,11 @@ func (s *Ethereum) Stop() error {
 	s.handler.Stop()
 
 	// Then stop everything else.
+	s.lock.Lock()
+	if s.indexRebuild != nil {
+		s.indexRebuild.Stop()
+	}
+	s.lock.Unlock()
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
@@ -0,0 +1,234 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
+)
+
+// SimulatedConsensusAPI is a mock of the engine API driving a simulated backend
+// instead of a full node. It allows consensus layer clients and staking tools
+// to run integration tests against the engine API methods without a running
+// geth instance. It can be served over RPC by registering it in the "engine"
+// namespace of an rpc.Server.
+//
+// The simulated chain is not a proof-of-stake chain, so the mock is lenient:
+//   - Payloads are assembled from the pending block of the simulated backend, so
+//     the timestamp, fee recipient and randomness of the payload attributes are
+//     only used to derive the payload ID. Withdrawals are not supported.
+//   - A payload is sealed by committing the pending block when it is first
+//     retrieved, hence the new block becomes the head before the consensus
+//     client calls newPayload and forkchoiceUpdated with it.
+//   - Payloads not built by the mock cannot be executed and are rejected.
+type SimulatedConsensusAPI struct {
+	sim *backends.SimulatedBackend
+
+	lock      sync.Mutex
+	requested map[engine.PayloadID]common.Hash                      // Parent hashes of requested payloads
+	payloads  map[engine.PayloadID]*engine.ExecutionPayloadEnvelope // Payloads already built
+}
+
+// NewSimulatedConsensusAPI creates a mock engine API on top of the given simulated
+// backend.
+func NewSimulatedConsensusAPI(sim *backends.SimulatedBackend) *SimulatedConsensusAPI {
+	return &SimulatedConsensusAPI{
+		sim:       sim,
+		requested: make(map[engine.PayloadID]common.Hash),
+		payloads:  make(map[engine.PayloadID]*engine.ExecutionPayloadEnvelope),
+	}
+}
+
+// ForkchoiceUpdatedV1 sets the head, safe and finalized blocks of the simulated
+// chain and, if payload attributes are given, starts building a new payload on
+// top of the head.
+func (api *SimulatedConsensusAPI) ForkchoiceUpdatedV1(update engine.ForkchoiceStateV1, payloadAttributes *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+	if payloadAttributes != nil && payloadAttributes.Withdrawals != nil {
+		return engine.STATUS_INVALID, engine.InvalidParams.With(errors.New("withdrawals not supported in V1"))
+	}
+	return api.forkchoiceUpdated(update, payloadAttributes)
+}
+
+// ForkchoiceUpdatedV2 is equivalent to V1 with the addition of withdrawals in the
+// payload attributes, which are rejected as the simulated chain is pre-shanghai.
+func (api *SimulatedConsensusAPI) ForkchoiceUpdatedV2(update engine.ForkchoiceStateV1, payloadAttributes *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+	if payloadAttributes != nil && payloadAttributes.Withdrawals != nil {
+		return engine.STATUS_INVALID, engine.InvalidParams.With(errors.New("withdrawals before shanghai"))
+	}
+	return api.forkchoiceUpdated(update, payloadAttributes)
+}
+
+func (api *SimulatedConsensusAPI) forkchoiceUpdated(update engine.ForkchoiceStateV1, payloadAttributes *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	log.Trace("Simulated engine API request received", "method", "ForkchoiceUpdated", "head", update.HeadBlockHash, "finalized", update.FinalizedBlockHash, "safe", update.SafeBlockHash)
+	if update.HeadBlockHash == (common.Hash{}) {
+		return engine.STATUS_INVALID, nil
+	}
+	chain := api.sim.Blockchain()
+
+	block := chain.GetBlockByHash(update.HeadBlockHash)
+	if block == nil {
+		return engine.STATUS_SYNCING, nil
+	}
+	if chain.CurrentBlock().Hash() != block.Hash() {
+		if _, err := chain.SetCanonical(block); err != nil {
+			return engine.STATUS_INVALID, err
+		}
+		api.sim.Rollback()
+	}
+	if update.FinalizedBlockHash != (common.Hash{}) {
+		header := chain.GetHeaderByHash(update.FinalizedBlockHash)
+		if header == nil || chain.GetCanonicalHash(header.Number.Uint64()) != header.Hash() {
+			return engine.STATUS_INVALID, engine.InvalidForkChoiceState.With(errors.New("final block not in canonical chain"))
+		}
+		chain.SetFinalized(header)
+	}
+	if update.SafeBlockHash != (common.Hash{}) {
+		header := chain.GetHeaderByHash(update.SafeBlockHash)
+		if header == nil || chain.GetCanonicalHash(header.Number.Uint64()) != header.Hash() {
+			return engine.STATUS_INVALID, engine.InvalidForkChoiceState.With(errors.New("safe block not in canonical chain"))
+		}
+		chain.SetSafe(header)
+	}
+	valid := engine.ForkChoiceResponse{
+		PayloadStatus: engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &update.HeadBlockHash},
+	}
+	if payloadAttributes != nil {
+		if payloadAttributes.Timestamp <= block.Time() {
+			return valid, engine.InvalidPayloadAttributes.With(errors.New("invalid timestamp"))
+		}
+		args := &miner.BuildPayloadArgs{
+			Parent:       update.HeadBlockHash,
+			Timestamp:    payloadAttributes.Timestamp,
+			FeeRecipient: payloadAttributes.SuggestedFeeRecipient,
+			Random:       payloadAttributes.Random,
+		}
+		id := args.Id()
+		api.requested[id] = update.HeadBlockHash
+		valid.PayloadID = &id
+	}
+	return valid, nil
+}
+
+// GetPayloadV1 returns the payload with the given id, sealing it if needed.
+func (api *SimulatedConsensusAPI) GetPayloadV1(payloadID engine.PayloadID) (*engine.ExecutableData, error) {
+	data, err := api.getPayload(payloadID)
+	if err != nil {
+		return nil, err
+	}
+	return data.ExecutionPayload, nil
+}
+
+// GetPayloadV2 returns the payload with the given id, sealing it if needed.
+func (api *SimulatedConsensusAPI) GetPayloadV2(payloadID engine.PayloadID) (*engine.ExecutionPayloadEnvelope, error) {
+	return api.getPayload(payloadID)
+}
+
+func (api *SimulatedConsensusAPI) getPayload(payloadID engine.PayloadID) (*engine.ExecutionPayloadEnvelope, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	log.Trace("Simulated engine API request received", "method", "GetPayload", "id", payloadID)
+	if data, ok := api.payloads[payloadID]; ok {
+		return data, nil
+	}
+	parent, ok := api.requested[payloadID]
+	if !ok {
+		return nil, engine.UnknownPayload
+	}
+	// Seal the pending block if it still builds on the requested parent
+	chain := api.sim.Blockchain()
+	if chain.CurrentBlock().Hash() != parent {
+		return nil, engine.UnknownPayload
+	}
+	block := chain.GetBlockByHash(api.sim.Commit())
+
+	fees := new(big.Int)
+	for i, receipt := range chain.GetReceiptsByHash(block.Hash()) {
+		tip := block.Transactions()[i].EffectiveGasTipValue(block.BaseFee())
+		fees.Add(fees, tip.Mul(tip, new(big.Int).SetUint64(receipt.GasUsed)))
+	}
+	data := engine.BlockToExecutableData(block, fees)
+
+	delete(api.requested, payloadID)
+	api.payloads[payloadID] = data
+	return data, nil
+}
+
+// NewPayloadV1 validates a payload against the simulated chain. Only payloads
+// built by the mock itself are considered valid.
+func (api *SimulatedConsensusAPI) NewPayloadV1(params engine.ExecutableData) (engine.PayloadStatusV1, error) {
+	if params.Withdrawals != nil {
+		return engine.PayloadStatusV1{Status: engine.INVALID}, engine.InvalidParams.With(errors.New("withdrawals not supported in V1"))
+	}
+	return api.newPayload(params)
+}
+
+// NewPayloadV2 is equivalent to V1, with withdrawals rejected as the simulated
+// chain is pre-shanghai.
+func (api *SimulatedConsensusAPI) NewPayloadV2(params engine.ExecutableData) (engine.PayloadStatusV1, error) {
+	if params.Withdrawals != nil {
+		return engine.PayloadStatusV1{Status: engine.INVALID}, engine.InvalidParams.With(errors.New("non-nil withdrawals pre-shanghai"))
+	}
+	return api.newPayload(params)
+}
+
+func (api *SimulatedConsensusAPI) newPayload(params engine.ExecutableData) (engine.PayloadStatusV1, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	log.Trace("Simulated engine API request received", "method", "NewPayload", "number", params.Number, "hash", params.BlockHash)
+	chain := api.sim.Blockchain()
+
+	if block := chain.GetBlockByHash(params.BlockHash); block != nil {
+		hash := block.Hash()
+		return engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &hash}, nil
+	}
+	parent := chain.GetBlockByHash(params.ParentHash)
+	if parent == nil {
+		return engine.PayloadStatusV1{Status: engine.SYNCING}, nil
+	}
+	err := errors.New("payload not built by the simulated backend")
+	if _, decErr := engine.ExecutableDataToBlock(params); decErr != nil {
+		err = fmt.Errorf("invalid payload: %v", decErr)
+	}
+	errMsg := err.Error()
+	hash := parent.Hash()
+	return engine.PayloadStatusV1{Status: engine.INVALID, LatestValidHash: &hash, ValidationError: &errMsg}, nil
+}
+
+// ExchangeCapabilities returns the engine API methods supported by the mock.
+func (api *SimulatedConsensusAPI) ExchangeCapabilities([]string) []string {
+	return []string{
+		"engine_forkchoiceUpdatedV1",
+		"engine_forkchoiceUpdatedV2",
+		"engine_getPayloadV1",
+		"engine_getPayloadV2",
+		"engine_newPayloadV1",
+		"engine_newPayloadV2",
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests a full block production round through the simulated engine API.
+func TestSimulatedConsensusAPI(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}}, 10_000_000)
+	defer sim.Close()
+
+	api := NewSimulatedConsensusAPI(sim)
+	genesis := sim.Blockchain().CurrentBlock()
+
+	// Unknown payloads and heads should be rejected
+	if _, err := api.GetPayloadV1(engine.PayloadID{1}); err != engine.UnknownPayload {
+		t.Fatalf("unknown payload error mismatch: have %v, want %v", err, engine.UnknownPayload)
+	}
+	resp, err := api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: common.Hash{1}}, nil)
+	if err != nil || resp.PayloadStatus.Status != engine.SYNCING {
+		t.Fatalf("unknown head status mismatch: have %v (%v), want %v", resp.PayloadStatus.Status, err, engine.SYNCING)
+	}
+	// Request a payload, add a transaction and seal it
+	resp, err = api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: genesis.Hash()}, &engine.PayloadAttributes{Timestamp: genesis.Time + 12})
+	if err != nil {
+		t.Fatalf("failed to update forkchoice: %v", err)
+	}
+	if resp.PayloadStatus.Status != engine.VALID || resp.PayloadID == nil {
+		t.Fatalf("forkchoice response mismatch: have %v, id %v", resp.PayloadStatus.Status, resp.PayloadID)
+	}
+	signer := types.LatestSigner(sim.Blockchain().Config())
+	tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+		ChainID:   sim.Blockchain().Config().ChainID,
+		Gas:       params.TxGas,
+		GasFeeCap: big.NewInt(2 * params.InitialBaseFee),
+		GasTipCap: big.NewInt(params.GWei),
+		To:        &common.Address{2},
+		Value:     big.NewInt(1),
+	})
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	payload, err := api.GetPayloadV2(*resp.PayloadID)
+	if err != nil {
+		t.Fatalf("failed to get payload: %v", err)
+	}
+	if len(payload.ExecutionPayload.Transactions) != 1 {
+		t.Fatalf("payload transaction count mismatch: have %d, want 1", len(payload.ExecutionPayload.Transactions))
+	}
+	if want := new(big.Int).Mul(big.NewInt(params.GWei), big.NewInt(int64(params.TxGas))); payload.BlockValue.Cmp(want) != 0 {
+		t.Fatalf("block value mismatch: have %v, want %v", payload.BlockValue, want)
+	}
+	// Repeated retrievals should return the same payload
+	again, err := api.GetPayloadV1(*resp.PayloadID)
+	if err != nil || again.BlockHash != payload.ExecutionPayload.BlockHash {
+		t.Fatalf("repeated payload mismatch: have %v (%v), want %v", again, err, payload.ExecutionPayload.BlockHash)
+	}
+	// Import the payload and set it as the head
+	status, err := api.NewPayloadV1(*payload.ExecutionPayload)
+	if err != nil || status.Status != engine.VALID {
+		t.Fatalf("payload status mismatch: have %v (%v), want %v", status.Status, err, engine.VALID)
+	}
+	head := payload.ExecutionPayload.BlockHash
+	resp, err = api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: head, SafeBlockHash: head, FinalizedBlockHash: genesis.Hash()}, nil)
+	if err != nil || resp.PayloadStatus.Status != engine.VALID {
+		t.Fatalf("forkchoice status mismatch: have %v (%v), want %v", resp.PayloadStatus.Status, err, engine.VALID)
+	}
+	if have := sim.Blockchain().CurrentSafeBlock().Hash(); have != head {
+		t.Fatalf("safe block mismatch: have %x, want %x", have, head)
+	}
+	// Payloads not built by the mock cannot be executed
+	forged := *payload.ExecutionPayload
+	forged.BlockHash = common.Hash{3}
+	if status, _ := api.NewPayloadV1(forged); status.Status != engine.INVALID {
+		t.Fatalf("forged payload status mismatch: have %v, want %v", status.Status, engine.INVALID)
+	}
+	// Rewinding the head should drop the block from the canonical chain
+	resp, err = api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: genesis.Hash()}, nil)
+	if err != nil || resp.PayloadStatus.Status != engine.VALID {
+		t.Fatalf("rewind status mismatch: have %v (%v), want %v", resp.PayloadStatus.Status, err, engine.VALID)
+	}
+	if have := sim.Blockchain().CurrentBlock().Hash(); have != genesis.Hash() {
+		t.Fatalf("head mismatch after rewind: have %x, want %x", have, genesis.Hash())
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -127,6 +128,8 @@ type Downloader struct {
 	committed       int32
 	ancientLimit    uint64 // The maximum block number which can be regarded as ancient data.
 
+	history *core.HistoryRetention // Block history to retrieve the content of in snap sync, nil for all
+
 	// Channels
 	headerProcCh chan *headerTask // Channel to feed the header processor new tasks
 
@@ -245,6 +248,14 @@ func New(checkpoint uint64, stateDb ethdb.Database, mux *event.TypeMux, chain Bl
 	return dl
 }
 
+// SetHistoryRetention limits snap sync to only retrieve the block bodies and
+// receipts retained by the policy, importing the other blocks as headers only.
+// The retained window is always extended to cover the snap sync pivot block.
+// A nil policy retrieves the entire chain.
+func (d *Downloader) SetHistoryRetention(policy *core.HistoryRetention) {
+	d.history = policy
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -613,6 +624,20 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td, ttd *
 		} else if d.ancientLimit > 0 {
 			log.Debug("Enabling direct-ancient mode", "ancient", d.ancientLimit)
 		}
+		// If the block history is pruned, skip the content retrieval of the blocks
+		// not retained. The pruned history is left out of the ancient store by the
+		// freezer, so direct ancient insertion is disabled too.
+		if history := d.history; history != nil {
+			start := history.Start(height)
+			if pivotNumber := pivot.Number.Uint64(); start > pivotNumber {
+				start = pivotNumber
+			}
+			d.ancientLimit = 0
+			d.queue.RetainHistory(func(number uint64) bool {
+				return number >= start || history.Retains(number, height)
+			})
+			log.Info("Limiting block history retrieval", "start", start, "ranges", len(history.Ranges))
+		}
 		// Rewind the ancient store and blockchain if reorg happens.
 		if origin+1 < frozen {
 			if err := d.lightchain.SetHead(origin); err != nil {
@@ -1770,15 +1795,29 @@ func (d *Downloader) commitSnapSyncData(results []*fetchResult, stateSync *state
 		"firstnum", first.Number, "firsthash", first.Hash(),
 		"lastnumn", last.Number, "lasthash", last.Hash(),
 	)
-	blocks := make([]*types.Block, len(results))
-	receipts := make([]types.Receipts, len(results))
-	for i, result := range results {
-		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles).WithWithdrawals(result.Withdrawals)
-		receipts[i] = result.Receipts
-	}
-	if index, err := d.blockchain.InsertReceiptChain(blocks, receipts, d.ancientLimit); err != nil {
-		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
-		return fmt.Errorf("%w: %v", errInvalidChain, err)
+	// The blocks outside the retained history were imported as headers only,
+	// insert the contiguous segments of retained blocks in between.
+	for len(results) > 0 {
+		if results[0].Pruned {
+			results = results[1:]
+			continue
+		}
+		var (
+			blocks   []*types.Block
+			receipts []types.Receipts
+		)
+		for _, result := range results {
+			if result.Pruned {
+				break
+			}
+			blocks = append(blocks, types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles).WithWithdrawals(result.Withdrawals))
+			receipts = append(receipts, result.Receipts)
+		}
+		if index, err := d.blockchain.InsertReceiptChain(blocks, receipts, d.ancientLimit); err != nil {
+			log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
+			return fmt.Errorf("%w: %v", errInvalidChain, err)
+		}
+		results = results[len(blocks):]
 	}
 	return nil
 }
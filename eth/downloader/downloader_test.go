@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
@@ -460,6 +461,57 @@ func testCanonSync(t *testing.T, protocol uint, mode SyncMode) {
 	assertOwnChain(t, tester, len(chain.blocks))
 }
 
+// Tests that snap sync with a history retention policy only retrieves block
+// bodies and receipts for the recent blocks and the retained ranges, importing
+// the other ones as headers only.
+func TestHistoryRetentionSync66(t *testing.T) { testHistoryRetentionSync(t, eth.ETH66) }
+func TestHistoryRetentionSync67(t *testing.T) { testHistoryRetentionSync(t, eth.ETH67) }
+
+func testHistoryRetentionSync(t *testing.T, protocol uint) {
+	tester := newTester(t)
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	tester.newPeer("peer", protocol, chain.blocks[1:])
+
+	policy := &core.HistoryRetention{
+		Window: uint64(4 * fsMinFullBlocks),
+		Ranges: []state.BlockRange{{First: 100, Last: 200}, {First: 5000, Last: 5000}},
+	}
+	core.WriteHistoryRetention(tester.downloader.stateDB, policy)
+	tester.downloader.SetHistoryRetention(policy)
+	if err := tester.sync("peer", nil, SnapSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, len(chain.blocks))
+
+	head := uint64(len(chain.blocks) - 1)
+	verify := func() {
+		for _, block := range chain.blocks[1:] {
+			number := block.NumberU64()
+			if !tester.chain.HasHeader(block.Hash(), number) {
+				t.Fatalf("block %d: header missing", number)
+			}
+			if have, want := tester.chain.HasBlock(block.Hash(), number), policy.Retains(number, head); have != want {
+				t.Fatalf("block %d: body presence mismatch: have %v, want %v", number, have, want)
+			}
+			if have, want := rawdb.HasReceipts(tester.downloader.stateDB, block.Hash(), number), policy.Retains(number, head); have != want {
+				t.Fatalf("block %d: receipts presence mismatch: have %v, want %v", number, have, want)
+			}
+		}
+	}
+	verify()
+
+	// Ensure the partial history can be moved into the ancient store
+	if err := tester.downloader.stateDB.(interface{ Freeze(uint64) error }).Freeze(64); err != nil {
+		t.Fatalf("failed to freeze chain: %v", err)
+	}
+	if frozen, _ := tester.downloader.stateDB.Ancients(); frozen != head-64+1 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, head-64+1)
+	}
+	verify()
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling66Full(t *testing.T) { testThrottling(t, eth.ETH66, FullSync) }
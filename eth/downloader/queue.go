@@ -62,6 +62,7 @@ type fetchRequest struct {
 // all outstanding pieces complete and the result as a whole can be processed.
 type fetchResult struct {
 	pending int32 // Flag telling what deliveries are outstanding
+	Pruned  bool  // Flag whether the block content is not retained, only the header is imported
 
 	Header       *types.Header
 	Uncles       []*types.Header
@@ -70,9 +71,13 @@ type fetchResult struct {
 	Withdrawals  types.Withdrawals
 }
 
-func newFetchResult(header *types.Header, fastSync bool) *fetchResult {
+func newFetchResult(header *types.Header, fastSync bool, pruned bool) *fetchResult {
 	item := &fetchResult{
 		Header: header,
+		Pruned: pruned,
+	}
+	if pruned {
+		return item
 	}
 	if !header.EmptyBody() {
 		item.pending |= (1 << bodyType)
@@ -140,6 +145,8 @@ type queue struct {
 	resultCache *resultStore       // Downloaded but not yet delivered fetch results
 	resultSize  common.StorageSize // Approximate size of a block (exponential moving average)
 
+	retain func(number uint64) bool // Filter of the blocks to retrieve the content of, nil for all
+
 	lock   *sync.RWMutex
 	active *sync.Cond
 	closed bool
@@ -184,6 +191,24 @@ func (q *queue) Reset(blockCacheLimit int, thresholdInitialSize int) {
 
 	q.resultCache = newResultStore(blockCacheLimit)
 	q.resultCache.SetThrottleThreshold(uint64(thresholdInitialSize))
+	q.retain = nil
+}
+
+// RetainHistory limits the content retrieval to the blocks accepted by the
+// filter, the others are delivered as headers only. The filter is cleared when
+// the queue is reset.
+func (q *queue) RetainHistory(retain func(number uint64) bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.retain = retain
+}
+
+// pruned reports whether the content of the block is not retained.
+//
+// Note, this method expects the queue lock to be already held.
+func (q *queue) pruned(header *types.Header) bool {
+	return q.retain != nil && !q.retain(header.Number.Uint64())
 }
 
 // Close marks the end of the sync, unblocking Results.
@@ -317,7 +342,7 @@ func (q *queue) Schedule(headers []*types.Header, hashes []common.Hash, from uin
 			q.blockTaskQueue.Push(header, -int64(header.Number.Uint64()))
 		}
 		// Queue for receipt retrieval
-		if q.mode == SnapSync && !header.EmptyReceipts() {
+		if q.mode == SnapSync && !header.EmptyReceipts() && !q.pruned(header) {
 			if _, ok := q.receiptTaskPool[hash]; ok {
 				log.Warn("Header already scheduled for receipt fetch", "number", header.Number, "hash", hash)
 			} else {
@@ -511,7 +536,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 		// we can ask the resultcache if this header is within the
 		// "prioritized" segment of blocks. If it is not, we need to throttle
 
-		stale, throttle, item, err := q.resultCache.AddFetch(header, q.mode == SnapSync)
+		stale, throttle, item, err := q.resultCache.AddFetch(header, q.mode == SnapSync, q.pruned(header))
 		if stale {
 			// Don't put back in the task queue, this item has already been
 			// delivered upstream
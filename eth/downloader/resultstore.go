@@ -68,7 +68,8 @@ func (r *resultStore) SetThrottleThreshold(threshold uint64) uint64 {
 }
 
 // AddFetch adds a header for body/receipt fetching. This is used when the queue
-// wants to reserve headers for fetching.
+// wants to reserve headers for fetching. If the block content isn't retained, the
+// result is complete with the header alone.
 //
 // It returns the following:
 //
@@ -76,7 +77,7 @@ func (r *resultStore) SetThrottleThreshold(threshold uint64) uint64 {
 //	throttled - if true, the store is at capacity, this particular header is not prio now
 //	item      - the result to store data into
 //	err       - any error that occurred
-func (r *resultStore) AddFetch(header *types.Header, fastSync bool) (stale, throttled bool, item *fetchResult, err error) {
+func (r *resultStore) AddFetch(header *types.Header, fastSync bool, pruned bool) (stale, throttled bool, item *fetchResult, err error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
@@ -86,7 +87,7 @@ func (r *resultStore) AddFetch(header *types.Header, fastSync bool) (stale, thro
 		return stale, throttled, item, err
 	}
 	if item == nil {
-		item = newFetchResult(header, fastSync)
+		item = newFetchResult(header, fastSync, pruned)
 		r.items[index] = item
 	}
 	return stale, throttled, item, err
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -134,6 +135,7 @@ type Config struct {
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	TxSenderIndex bool   `toml:",omitempty"` // Whether to index transactions by sender and nonce along the tx indices.
 
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
@@ -167,6 +169,13 @@ type Config struct {
 	TrieTimeout             time.Duration
 	SnapshotCache           int
 	Preimages               bool
+	StateDiffs              bool `toml:",omitempty"` // Whether to record per-transaction state diffs during block processing
+
+	// StateRetention is the historical state kept by pruning nodes.
+	StateRetention *state.RetentionPolicy `toml:",omitempty"`
+
+	// HistoryRetention is the block history kept by nodes pruning it.
+	HistoryRetention *core.HistoryRetention `toml:",omitempty"`
 
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
@@ -186,6 +195,9 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables per-opcode execution counters of processed blocks
+	EnableOpCounters bool `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -879,6 +879,101 @@ func TestLightFilterLogs(t *testing.T) {
 	}
 }
 
+// TestLogTail tests that a log tail delivers the historical logs followed by the
+// new ones, dropping the duplicates and the removals of undelivered logs.
+func TestLogTail(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+		signer       = types.HomesteadSigner{}
+		addr         = common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+		key, _  = crypto.GenerateKey()
+		genesis = &core.Genesis{Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		receipts []*types.Receipt
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *core.BlockGen) {
+		receipt := &types.Receipt{Logs: []*types.Log{{Address: addr, Topics: []common.Hash{}, Data: []byte{}, BlockNumber: uint64(i + 1)}}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+		b.AddUncheckedReceipt(receipt)
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: uint64(i), To: &common.Address{}, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee(), Data: nil}), signer, key)
+		b.AddTx(tx)
+	})
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), []*types.Receipt{receipts[i]})
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan types.Log)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logTail", map[string]interface{}{"fromBlock": "0x1"})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	newLog := func(number uint64, hash common.Hash, removed bool) *types.Log {
+		return &types.Log{Address: addr, Topics: []common.Hash{}, Data: []byte{}, BlockNumber: number, BlockHash: hash, Removed: removed}
+	}
+	expect := func(number uint64, hash common.Hash, removed bool) {
+		t.Helper()
+		select {
+		case log := <-logs:
+			if log.BlockNumber != number || log.BlockHash != hash || log.Removed != removed {
+				t.Fatalf("log mismatch: have block %d %x removed %v, want block %d %x removed %v", log.BlockNumber, log.BlockHash, log.Removed, number, hash, removed)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("log of block %d %x not delivered", number, hash)
+		}
+	}
+	// Announce a duplicate of a historical log along with a new one
+	backend.logsFeed.Send([]*types.Log{
+		newLog(3, blocks[2].Hash(), false),
+		newLog(5, common.Hash{0x05}, false),
+	})
+	for _, block := range blocks {
+		expect(block.NumberU64(), block.Hash(), false)
+	}
+	expect(5, common.Hash{0x05}, false)
+
+	// Announce the removal of a historical log along with an undelivered one
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: []*types.Log{
+		newLog(4, blocks[3].Hash(), true),
+		newLog(2, common.Hash{0x02}, true),
+	}})
+	expect(4, blocks[3].Hash(), true)
+
+	// Announce the replacement of the removed block
+	backend.logsFeed.Send([]*types.Log{
+		newLog(4, common.Hash{0x04}, false),
+	})
+	expect(4, common.Hash{0x04}, false)
+
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log delivered: block %d %x removed %v", log.BlockNumber, log.BlockHash, log.Removed)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // TestPendingTxFilterDeadlock tests if the event loop hangs when pending
 // txes arrive at the same time that one of multiple filters is timing out.
 // Please refer to #22131 for more details.
@@ -0,0 +1,231 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// logTailChunk is the number of blocks whose historical logs are retrieved
+	// and delivered at once by a log tail.
+	logTailChunk = 2048
+
+	// logTailReorgWindow is the number of most recent historical blocks a log tail
+	// tracks to reconcile them with reorgs announced by the live subscription.
+	logTailReorgWindow = 1024
+)
+
+// LogTail creates a subscription that delivers the historical logs matching the
+// given filter criteria, starting at its fromBlock, and then seamlessly continues
+// with the logs of new blocks. No logs are skipped or delivered twice across the
+// switch; logs of historical or new blocks removed by a reorg are delivered again
+// with the removed property set to true.
+//
+// Only the fromBlock may be set, with "latest" or an absent fromBlock skipping
+// the historical part altogether.
+func (api *FilterAPI) LogTail(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit.BlockHash != nil {
+		return nil, errors.New("log tail cannot be limited to a block hash")
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Int64() != rpc.LatestBlockNumber.Int64() {
+		return nil, errors.New("log tail cannot be limited by toBlock")
+	}
+	// Subscribe to new logs before looking up the head, so that every block is
+	// either covered by the historical logs or by the subscription.
+	var (
+		live        = ethereum.FilterQuery{Addresses: crit.Addresses, Topics: crit.Topics}
+		matchedLogs = make(chan []*types.Log)
+	)
+	logsSub, err := api.events.SubscribeLogs(live, matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+	head := api.sys.backend.CurrentHeader().Number.Uint64()
+	begin, err := api.logTailStart(ctx, crit, head)
+	if err != nil {
+		logsSub.Unsubscribe()
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer logsSub.Unsubscribe()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var (
+			batches = make(chan []*types.Log)
+			done    = make(chan error, 1)
+			tail    = &logTail{head: head, seen: make(map[common.Hash]bool)}
+			queue   [][]*types.Log
+		)
+		go func() {
+			done <- api.historicalLogs(ctx, begin, head, crit, batches)
+		}()
+		for {
+			select {
+			case logs := <-batches:
+				for _, log := range logs {
+					tail.historical(log)
+					notifier.Notify(rpcSub.ID, log)
+				}
+			case err := <-done:
+				if err != nil {
+					log.Debug("Failed to retrieve historical logs", "from", begin, "to", head, "err", err)
+					return
+				}
+				// Historical logs delivered, catch up with the queued new ones
+				for _, logs := range queue {
+					for _, log := range tail.filter(logs) {
+						notifier.Notify(rpcSub.ID, log)
+					}
+				}
+				queue, done = nil, nil
+			case logs := <-matchedLogs:
+				if done != nil {
+					queue = append(queue, logs)
+					continue
+				}
+				for _, log := range tail.filter(logs) {
+					notifier.Notify(rpcSub.ID, log)
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// logTailStart resolves the first block whose logs a log tail delivers.
+func (api *FilterAPI) logTailStart(ctx context.Context, crit FilterCriteria, head uint64) (uint64, error) {
+	if crit.FromBlock == nil {
+		return head + 1, nil
+	}
+	switch number := rpc.BlockNumber(crit.FromBlock.Int64()); number {
+	case rpc.LatestBlockNumber:
+		return head + 1, nil
+	case rpc.PendingBlockNumber:
+		return 0, errors.New("log tail cannot start at the pending block")
+	case rpc.FinalizedBlockNumber, rpc.SafeBlockNumber:
+		header, err := api.sys.backend.HeaderByNumber(ctx, number)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, errors.New("fromBlock not found")
+		}
+		return header.Number.Uint64(), nil
+	default:
+		if number < 0 {
+			return 0, fmt.Errorf("invalid fromBlock %d", number)
+		}
+		return uint64(number), nil
+	}
+}
+
+// historicalLogs retrieves the logs matching the criteria between the begin and
+// end blocks in chunks, delivering them to the given channel.
+func (api *FilterAPI) historicalLogs(ctx context.Context, begin, end uint64, crit FilterCriteria, batches chan<- []*types.Log) error {
+	for from := begin; from <= end; from += logTailChunk {
+		to := from + logTailChunk - 1
+		if to > end {
+			to = end
+		}
+		logs, err := api.sys.NewRangeFilter(int64(from), int64(to), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		select {
+		case batches <- logs:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// logTail reconciles the logs of new blocks with the historical logs delivered
+// up to the head block at the time of subscribing.
+//
+// Logs of blocks above the head are never covered by the historical logs and are
+// passed through. For blocks up to the head, the new logs are either duplicates
+// of the historical ones, or belong to blocks replacing them in a reorg, which
+// is told apart by the hashes of the blocks whose logs have been delivered. Only
+// the most recent logTailReorgWindow blocks are tracked, logs of older blocks are
+// passed through.
+type logTail struct {
+	head uint64
+	seen map[common.Hash]bool // Blocks whose logs have been delivered
+}
+
+// historical records the delivery of a historical log.
+func (t *logTail) historical(log *types.Log) {
+	if log.BlockNumber+logTailReorgWindow > t.head {
+		t.seen[log.BlockHash] = true
+	}
+}
+
+// filter drops the new logs already delivered as historical logs, as well as the
+// removals of logs that were never delivered.
+func (t *logTail) filter(logs []*types.Log) []*types.Log {
+	var (
+		result    []*types.Log
+		delivered = make(map[common.Hash]bool)
+	)
+	for _, log := range logs {
+		if log.BlockNumber > t.head || log.BlockNumber+logTailReorgWindow <= t.head {
+			result = append(result, log)
+			continue
+		}
+		if log.Removed != t.seen[log.BlockHash] {
+			continue
+		}
+		delivered[log.BlockHash] = !log.Removed
+		result = append(result, log)
+	}
+	// Update the tracked blocks only after the whole batch, as a block's logs
+	// are delivered or removed together
+	for hash, ok := range delivered {
+		if ok {
+			t.seen[hash] = true
+		} else {
+			delete(t.seen, hash)
+		}
+	}
+	return result
+}
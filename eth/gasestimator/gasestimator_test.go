@@ -0,0 +1,168 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasestimator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testChain is a minimal core.ChainContext without any historical headers.
+type testChain struct{}
+
+func (testChain) Engine() consensus.Engine                    { return ethash.NewFaker() }
+func (testChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+func newTestOptions(t *testing.T, alloc map[common.Address][]byte) *Options {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	for addr, code := range alloc {
+		statedb.SetCode(addr, code)
+	}
+	return &Options{
+		Config: params.TestChainConfig,
+		Chain:  testChain{},
+		Header: &types.Header{
+			Number:     big.NewInt(1),
+			GasLimit:   30_000_000,
+			Difficulty: big.NewInt(0),
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+		},
+		State: statedb,
+	}
+}
+
+func TestEstimateTransfer(t *testing.T) {
+	opts := newTestOptions(t, nil)
+	call := &core.Message{
+		From:              common.Address{1},
+		To:                &common.Address{2},
+		Value:             new(big.Int),
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		SkipAccountChecks: true,
+	}
+	gas, ret, err := Estimate(context.Background(), call, opts, 0)
+	if err != nil {
+		t.Fatalf("failed to estimate gas: %v", err)
+	}
+	if gas != params.TxGas {
+		t.Errorf("gas estimate mismatch: have %d, want %d", gas, params.TxGas)
+	}
+	if len(ret) != 0 {
+		t.Errorf("unexpected return data: %x", ret)
+	}
+}
+
+func TestEstimateReturnData(t *testing.T) {
+	var (
+		returner = common.Address{0xaa}
+		reverter = common.Address{0xbb}
+	)
+	opts := newTestOptions(t, map[common.Address][]byte{
+		// PUSH1 0x2a PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+		returner: common.FromHex("602a60005260206000f3"),
+		// PUSH1 0x2a PUSH1 0 MSTORE PUSH1 32 PUSH1 0 REVERT
+		reverter: common.FromHex("602a60005260206000fd"),
+	})
+	call := &core.Message{
+		From:              common.Address{1},
+		To:                &returner,
+		Value:             new(big.Int),
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		SkipAccountChecks: true,
+	}
+	gas, ret, err := Estimate(context.Background(), call, opts, 0)
+	if err != nil {
+		t.Fatalf("failed to estimate gas: %v", err)
+	}
+	if gas <= params.TxGas {
+		t.Errorf("gas estimate too low: %d", gas)
+	}
+	if want := common.LeftPadBytes([]byte{0x2a}, 32); !bytes.Equal(ret, want) {
+		t.Errorf("return data mismatch: have %x, want %x", ret, want)
+	}
+	call.To = &reverter
+	_, ret, err = Estimate(context.Background(), call, opts, 0)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		t.Fatalf("error mismatch: have %v, want %v", err, vm.ErrExecutionReverted)
+	}
+	if want := common.LeftPadBytes([]byte{0x2a}, 32); !bytes.Equal(ret, want) {
+		t.Errorf("revert data mismatch: have %x, want %x", ret, want)
+	}
+}
+
+func TestEstimateGasCap(t *testing.T) {
+	opts := newTestOptions(t, nil)
+	call := &core.Message{
+		From:              common.Address{1},
+		To:                &common.Address{2},
+		Value:             new(big.Int),
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		SkipAccountChecks: true,
+	}
+	if _, _, err := Estimate(context.Background(), call, opts, params.TxGas-1); err == nil {
+		t.Fatal("expected estimation to fail below the intrinsic gas")
+	}
+}
+
+func TestEstimateTxGasLimit(t *testing.T) {
+	opts := newTestOptions(t, nil)
+
+	config := *opts.Config
+	limit := uint64(1_000_000)
+	config.Upgrades = []*params.Upgrade{{Name: "limit", Block: big.NewInt(0), TxGasLimit: &limit}}
+	opts.Config = &config
+
+	call := &core.Message{
+		From:              common.Address{1},
+		To:                &common.Address{2},
+		Value:             new(big.Int),
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		SkipAccountChecks: true,
+	}
+	// The search must start below the transaction gas limit, not the block's
+	gas, _, err := Estimate(context.Background(), call, opts, 0)
+	if err != nil {
+		t.Fatalf("failed to estimate gas: %v", err)
+	}
+	if gas != params.TxGas {
+		t.Errorf("gas estimate mismatch: have %d, want %d", gas, params.TxGas)
+	}
+}
@@ -117,6 +117,9 @@ type handler struct {
 
 	requiredBlocks map[uint64]common.Hash
 
+	txPropFeed  event.Feed              // Feed of transaction announcements and transfers of peers
+	txPropScope event.SubscriptionScope // Subscriptions to the transaction propagation feed
+
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
@@ -193,6 +196,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	}
 	// Construct the downloader (long sync)
 	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.eventMux, h.chain, nil, h.removePeer, success)
+	h.downloader.SetHistoryRetention(h.chain.HistoryRetention())
 	if ttd := h.chain.Config().TerminalTotalDifficulty; ttd != nil {
 		if h.chain.Config().TerminalTotalDifficultyPassed {
 			log.Info("Chain post-merge, sync via beacon client")
@@ -574,6 +578,7 @@ func (h *handler) Stop() {
 	h.peers.close()
 	h.peerWG.Wait()
 
+	h.txPropScope.Close()
 	log.Info("Ethereum protocol stopped")
 }
 
@@ -68,15 +68,19 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.handleBlockBroadcast(peer, packet.Block, packet.TD)
 
 	case *eth.NewPooledTransactionHashesPacket66:
+		(*handler)(h).notifyTxPropagation(peer, TxAnnouncement, *packet)
 		return h.txFetcher.Notify(peer.ID(), *packet)
 
 	case *eth.NewPooledTransactionHashesPacket68:
+		(*handler)(h).notifyTxPropagation(peer, TxAnnouncement, packet.Hashes)
 		return h.txFetcher.Notify(peer.ID(), packet.Hashes)
 
 	case *eth.TransactionsPacket:
+		(*handler)(h).notifyTxTransfer(peer, TxBroadcast, *packet)
 		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
 
 	case *eth.PooledTransactionsPacket:
+		(*handler)(h).notifyTxTransfer(peer, TxDelivery, *packet)
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
 	default:
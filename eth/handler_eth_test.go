@@ -758,3 +758,63 @@ func testBroadcastMalformedBlock(t *testing.T, protocol uint) {
 		}
 	}
 }
+
+// Tests that transaction announcements and transfers received from peers are
+// streamed to the propagation firehose.
+func TestTxPropagationFirehose(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandler()
+	defer handler.close()
+
+	handler.handler.acceptTxs = 1 // mark synced to accept transactions
+
+	events := make(chan []TxPropagation, 2)
+	sub := handler.handler.SubscribeTxPropagation(events)
+	defer sub.Unsubscribe()
+
+	p2pSrc, p2pSink := p2p.MsgPipe()
+	defer p2pSrc.Close()
+	defer p2pSink.Close()
+
+	src := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{1}, "", nil, p2pSrc), p2pSrc, handler.txpool)
+	sink := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{2}, "", nil, p2pSink), p2pSink, handler.txpool)
+	defer src.Close()
+	defer sink.Close()
+
+	go handler.handler.runEthPeer(sink, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(handler.handler), peer)
+	})
+	var (
+		genesis = handler.chain.Genesis()
+		head    = handler.chain.CurrentBlock()
+		td      = handler.chain.GetTd(head.Hash(), head.Number.Uint64())
+	)
+	if err := src.Handshake(1, td, head.Hash(), genesis.Hash(), forkid.NewIDWithChain(handler.chain), forkid.NewFilter(handler.chain)); err != nil {
+		t.Fatalf("failed to run protocol handshake")
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+
+	announced := common.Hash{0xaa}
+	announce := eth.NewPooledTransactionHashesPacket68{Types: []byte{types.LegacyTxType}, Sizes: []uint32{100}, Hashes: []common.Hash{announced}}
+	if err := p2p.Send(p2pSrc, eth.NewPooledTransactionHashesMsg, announce); err != nil {
+		t.Fatalf("failed to announce transaction: %v", err)
+	}
+	if err := src.SendTransactions([]*types.Transaction{tx}); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	for _, want := range []TxPropagation{{Kind: TxAnnouncement, Hash: announced}, {Kind: TxBroadcast, Hash: tx.Hash()}} {
+		select {
+		case batch := <-events:
+			if len(batch) != 1 {
+				t.Fatalf("%s: event count mismatch: have %d, want 1", want.Kind, len(batch))
+			}
+			if have := batch[0]; have.Kind != want.Kind || have.Hash != want.Hash || have.Peer != sink.ID() || have.Time.IsZero() {
+				t.Errorf("event mismatch: have %+v, want %s of %x from %s", have, want.Kind, want.Hash, sink.ID())
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no %s event received within 2 seconds", want.Kind)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// errUnknownPeer is returned if a request is made to a peer not registered
+	// with the client.
+	errUnknownPeer = errors.New("unknown peer")
+
+	// errPeerDropped is returned for pending requests of a peer that got
+	// unregistered before delivering a response.
+	errPeerDropped = errors.New("peer dropped")
+
+	// errStateUnavailable is returned if the remote peer signalled that it does
+	// not have the requested state available (e.g. pruned or not yet synced).
+	errStateUnavailable = errors.New("state unavailable")
+)
+
+// AccountRange is a verified, consecutive range of accounts retrieved from a
+// remote peer.
+type AccountRange struct {
+	Hashes   []common.Hash         // Account hashes, in increasing order
+	Accounts []*types.StateAccount // Accounts belonging to the hashes
+	More     bool                  // Whether more accounts exist after the range
+}
+
+// StorageRange is a verified, consecutive range of storage slots of a single
+// account retrieved from a remote peer.
+type StorageRange struct {
+	Hashes []common.Hash // Slot hashes, in increasing order
+	Slots  [][]byte      // RLP encoded slot values belonging to the hashes
+	More   bool          // Whether more slots exist after the range
+}
+
+// clientResponse is the data delivered by a peer for a pending client request.
+type clientResponse struct {
+	hashes [][]common.Hash
+	values [][][]byte
+	proof  [][]byte
+	err    error
+}
+
+// clientRequest tracks a pending network request of the client.
+type clientRequest struct {
+	peer    string
+	code    uint64
+	deliver chan *clientResponse
+}
+
+// Client is a lightweight `snap` protocol client, which retrieves slices of the
+// state from remote peers and verifies them against a state root using the
+// Merkle proofs attached to the responses. Contrary to the Syncer, it does not
+// persist anything or try to reconstruct the full state, making it suitable for
+// analysis tools needing a trustworthy view into a small portion of the state.
+//
+// The client can either be attached to a p2p.Server via Protocols, or fed with
+// peers and packets from an external protocol handler.
+type Client struct {
+	peers   map[string]SyncPeer       // Currently connected peers to retrieve data from
+	pending map[uint64]*clientRequest // Requests waiting for a response
+	lock    sync.Mutex
+}
+
+// NewClient creates a new snap protocol client.
+func NewClient() *Client {
+	return &Client{
+		peers:   make(map[string]SyncPeer),
+		pending: make(map[uint64]*clientRequest),
+	}
+}
+
+// Register injects a new data source into the client's peer set.
+func (c *Client) Register(peer SyncPeer) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := peer.ID()
+	if _, ok := c.peers[id]; ok {
+		return errors.New("already registered")
+	}
+	c.peers[id] = peer
+	return nil
+}
+
+// Unregister removes a data source from the client's peer set, failing all
+// requests still pending on it.
+func (c *Client) Unregister(id string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.peers[id]; !ok {
+		return errors.New("not registered")
+	}
+	delete(c.peers, id)
+
+	for reqid, req := range c.pending {
+		if req.peer == id {
+			delete(c.pending, reqid)
+			req.deliver <- &clientResponse{err: errPeerDropped}
+		}
+	}
+	return nil
+}
+
+// Peers returns the identifiers of all registered peers.
+func (c *Client) Peers() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ids := make([]string, 0, len(c.peers))
+	for id := range c.peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Protocols constructs the P2P protocol definitions for running the client as
+// a standalone `snap` participant. Inbound data requests from remote peers are
+// answered with empty responses. The dnsdisc iterator may be nil.
+func (c *Client) Protocols(dnsdisc enode.Iterator) []p2p.Protocol {
+	if dnsdisc == nil {
+		dnsdisc = enode.IterNodes(nil)
+	}
+	return MakeProtocols((*clientBackend)(c), dnsdisc)
+}
+
+// AccountRange retrieves the accounts of the state identified by root, in the
+// hash range [origin, limit], from the given peer. The response is verified
+// against the state root before being returned.
+func (c *Client) AccountRange(ctx context.Context, peer string, root common.Hash, origin, limit common.Hash, bytes uint64) (*AccountRange, error) {
+	res, err := c.request(ctx, peer, AccountRangeMsg, func(p SyncPeer, id uint64) error {
+		return p.RequestAccountRange(id, root, origin, limit, bytes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	hashes, accounts := res.hashes[0], res.values[0]
+	if len(hashes) == 0 && len(res.proof) == 0 {
+		return nil, errStateUnavailable
+	}
+	more, err := verifyRange(root, origin[:], hashes, accounts, res.proof)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountRange{
+		Hashes:   hashes,
+		Accounts: make([]*types.StateAccount, len(accounts)),
+		More:     more,
+	}
+	for i, blob := range accounts {
+		result.Accounts[i] = new(types.StateAccount)
+		if err := rlp.DecodeBytes(blob, result.Accounts[i]); err != nil {
+			return nil, fmt.Errorf("invalid account %x: %v", hashes[i], err)
+		}
+	}
+	return result, nil
+}
+
+// StorageRange retrieves the storage slots of a single account, in the hash
+// range [origin, limit], from the given peer. The state root identifies the
+// state to serve from, while the storage root (found in the account itself) is
+// used to verify the response.
+func (c *Client) StorageRange(ctx context.Context, peer string, root common.Hash, account common.Hash, storageRoot common.Hash, origin, limit common.Hash, bytes uint64) (*StorageRange, error) {
+	res, err := c.request(ctx, peer, StorageRangesMsg, func(p SyncPeer, id uint64) error {
+		return p.RequestStorageRanges(id, root, []common.Hash{account}, origin[:], limit[:], bytes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.hashes) == 0 {
+		if len(res.proof) == 0 {
+			// An empty response for an empty storage trie is valid, anything
+			// else means the peer doesn't have the state.
+			if storageRoot == types.EmptyRootHash {
+				return &StorageRange{}, nil
+			}
+			return nil, errStateUnavailable
+		}
+		res.hashes, res.values = [][]common.Hash{nil}, [][][]byte{nil}
+	}
+	if len(res.hashes) != 1 {
+		return nil, fmt.Errorf("%w: %d storage ranges for 1 account", errBadRequest, len(res.hashes))
+	}
+	hashes, slots := res.hashes[0], res.values[0]
+
+	var more bool
+	if len(res.proof) == 0 {
+		// The peer delivered the entire storage trie, verify it as a whole
+		_, err = trie.VerifyRangeProof(storageRoot, nil, nil, hashesToKeys(hashes), slots, nil)
+	} else {
+		more, err = verifyRange(storageRoot, origin[:], hashes, slots, res.proof)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &StorageRange{Hashes: hashes, Slots: slots, More: more}, nil
+}
+
+// ByteCodes retrieves contract codes by hash from the given peer. Codes not
+// delivered by the peer are nil in the result. Every returned code is checked
+// against its requested hash.
+func (c *Client) ByteCodes(ctx context.Context, peer string, hashes []common.Hash, bytes uint64) ([][]byte, error) {
+	res, err := c.request(ctx, peer, ByteCodesMsg, func(p SyncPeer, id uint64) error {
+		return p.RequestByteCodes(id, hashes, bytes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Codes are delivered in request order, but may skip some of the hashes
+	var (
+		codes  = make([][]byte, len(hashes))
+		hasher = crypto.NewKeccakState()
+		hash   common.Hash
+		j      int
+	)
+	for _, code := range res.values[0] {
+		hasher.Reset()
+		hasher.Write(code)
+		hasher.Read(hash[:])
+
+		for j < len(hashes) && hash != hashes[j] {
+			j++
+		}
+		if j == len(hashes) {
+			return nil, fmt.Errorf("%w: unexpected bytecode", errBadRequest)
+		}
+		codes[j] = code
+		j++
+	}
+	return codes, nil
+}
+
+// request sends a network request to the given peer and waits for the reply.
+func (c *Client) request(ctx context.Context, peer string, code uint64, send func(p SyncPeer, id uint64) error) (*clientResponse, error) {
+	c.lock.Lock()
+	p, ok := c.peers[peer]
+	if !ok {
+		c.lock.Unlock()
+		return nil, errUnknownPeer
+	}
+	var reqid uint64
+	for {
+		reqid = uint64(rand.Int63())
+		if reqid == 0 {
+			continue
+		}
+		if _, ok := c.pending[reqid]; ok {
+			continue
+		}
+		break
+	}
+	req := &clientRequest{
+		peer:    peer,
+		code:    code,
+		deliver: make(chan *clientResponse, 1),
+	}
+	c.pending[reqid] = req
+	c.lock.Unlock()
+
+	if err := send(p, reqid); err != nil {
+		c.lock.Lock()
+		delete(c.pending, reqid)
+		c.lock.Unlock()
+		return nil, err
+	}
+	select {
+	case res := <-req.deliver:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return res, nil
+	case <-ctx.Done():
+		c.lock.Lock()
+		delete(c.pending, reqid)
+		c.lock.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// deliver hands a response over to the request waiting for it.
+func (c *Client) deliver(peer SyncPeer, code uint64, id uint64, res *clientResponse) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	req, ok := c.pending[id]
+	if !ok || req.peer != peer.ID() || req.code != code {
+		// Request stale, perhaps the caller gave up but the peer came through in the end
+		peer.Log().Debug("Unexpected snap client response", "reqid", id)
+		return nil
+	}
+	delete(c.pending, id)
+	req.deliver <- res
+	return nil
+}
+
+// OnAccounts is a callback method to invoke when a range of accounts are
+// received from a remote peer.
+func (c *Client) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	return c.deliver(peer, AccountRangeMsg, id, &clientResponse{
+		hashes: [][]common.Hash{hashes},
+		values: [][][]byte{accounts},
+		proof:  proof,
+	})
+}
+
+// OnStorage is a callback method to invoke when ranges of storage slots
+// are received from a remote peer.
+func (c *Client) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) error {
+	return c.deliver(peer, StorageRangesMsg, id, &clientResponse{
+		hashes: hashes,
+		values: slots,
+		proof:  proof,
+	})
+}
+
+// OnByteCodes is a callback method to invoke when a batch of contract
+// bytes codes are received from a remote peer.
+func (c *Client) OnByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error {
+	return c.deliver(peer, ByteCodesMsg, id, &clientResponse{
+		values: [][][]byte{bytecodes},
+	})
+}
+
+// verifyRange checks a range of consecutive trie leaves against the given root
+// using the boundary proofs, returning whether more leaves follow the range.
+func verifyRange(root common.Hash, origin []byte, hashes []common.Hash, values [][]byte, proof [][]byte) (bool, error) {
+	keys := hashesToKeys(hashes)
+
+	nodes := make(light.NodeList, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	var end []byte
+	if len(keys) > 0 {
+		end = keys[len(keys)-1]
+	}
+	return trie.VerifyRangeProof(root, origin, end, keys, values, nodes.NodeSet())
+}
+
+func hashesToKeys(hashes []common.Hash) [][]byte {
+	keys := make([][]byte, len(hashes))
+	for i, key := range hashes {
+		keys[i] = common.CopyBytes(key[:])
+	}
+	return keys
+}
+
+// clientBackend adapts the Client to the Backend interface, so it can be run
+// by the protocol handler directly.
+type clientBackend Client
+
+// Chain returns nil as the client does not serve any state.
+func (b *clientBackend) Chain() *core.BlockChain { return nil }
+
+// RunPeer registers the peer with the client for the duration of the connection.
+func (b *clientBackend) RunPeer(peer *Peer, handler Handler) error {
+	c := (*Client)(b)
+	if err := c.Register(peer); err != nil {
+		return err
+	}
+	defer c.Unregister(peer.ID())
+	return handler(peer)
+}
+
+// PeerInfo retrieves all known `snap` information about a peer.
+func (b *clientBackend) PeerInfo(id enode.ID) interface{} { return nil }
+
+// Handle forwards a response packet to the client.
+func (b *clientBackend) Handle(peer *Peer, packet Packet) error {
+	c := (*Client)(b)
+	switch packet := packet.(type) {
+	case *AccountRangePacket:
+		hashes, accounts, err := packet.Unpack()
+		if err != nil {
+			return err
+		}
+		return c.OnAccounts(peer, packet.ID, hashes, accounts, packet.Proof)
+
+	case *StorageRangesPacket:
+		hashset, slotset := packet.Unpack()
+		return c.OnStorage(peer, packet.ID, hashset, slotset, packet.Proof)
+
+	case *ByteCodesPacket:
+		return c.OnByteCodes(peer, packet.ID, packet.Codes)
+
+	default:
+		return fmt.Errorf("unexpected snap packet type: %T", packet)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// clientTestPeer serves requests of a Client from the data of a testPeer.
+type clientTestPeer struct {
+	*testPeer
+	client  *Client
+	corrupt bool // Whether to drop an account from the middle of responses
+}
+
+func (p *clientTestPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	go func() {
+		keys, vals, proofs := createAccountRequestResponse(p.testPeer, root, origin, limit, bytes)
+		if p.corrupt && len(keys) > 2 {
+			keys = append(keys[:1], keys[2:]...)
+			vals = append(vals[:1], vals[2:]...)
+		}
+		p.client.OnAccounts(p, id, keys, vals, proofs)
+	}()
+	return nil
+}
+
+func (p *clientTestPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	go func() {
+		hashes, slots, proofs := createStorageRequestResponse(p.testPeer, root, accounts, origin, limit, bytes)
+		p.client.OnStorage(p, id, hashes, slots, proofs)
+	}()
+	return nil
+}
+
+func (p *clientTestPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	go func() {
+		var codes [][]byte
+		for _, hash := range hashes {
+			codes = append(codes, getCodeByHash(hash))
+		}
+		p.client.OnByteCodes(p, id, codes)
+	}()
+	return nil
+}
+
+func (p *clientTestPeer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error {
+	return errors.New("not supported")
+}
+
+func newClientTestPeer(t *testing.T, id string, client *Client) (*clientTestPeer, common.Hash) {
+	_, accTrie, elems, storageTries, storageElems := makeAccountTrieWithStorage(100, 50, true, false)
+
+	peer := &clientTestPeer{testPeer: newTestPeer(id, t, func() {}), client: client}
+	peer.accountTrie = accTrie
+	peer.accountValues = elems
+	peer.setStorageTries(storageTries)
+	peer.storageValues = storageElems
+
+	if err := client.Register(peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	return peer, accTrie.Hash()
+}
+
+func TestClientAccountRange(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient()
+	peer, root := newClientTestPeer(t, "peer-0000", client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Retrieve the entire account trie in chunks
+	var (
+		origin common.Hash
+		total  int
+	)
+	for {
+		res, err := client.AccountRange(ctx, "peer-0000", root, origin, maxHash, 2000)
+		if err != nil {
+			t.Fatalf("failed to retrieve accounts: %v", err)
+		}
+		total += len(res.Hashes)
+		if !res.More {
+			break
+		}
+		origin = incHash(res.Hashes[len(res.Hashes)-1])
+	}
+	if total != len(peer.accountValues) {
+		t.Fatalf("account count mismatch: have %d, want %d", total, len(peer.accountValues))
+	}
+	// Corrupt responses must fail verification
+	peer.corrupt = true
+	if _, err := client.AccountRange(ctx, "peer-0000", root, common.Hash{}, maxHash, 2000); err == nil {
+		t.Fatalf("corrupt account range accepted")
+	}
+	// Requests to unknown peers must fail immediately
+	if _, err := client.AccountRange(ctx, "peer-0001", root, common.Hash{}, maxHash, 2000); !errors.Is(err, errUnknownPeer) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errUnknownPeer)
+	}
+}
+
+func TestClientStorageAndCode(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient()
+	_, root := newClientTestPeer(t, "peer-0000", client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accounts, err := client.AccountRange(ctx, "peer-0000", root, common.Hash{}, maxHash, 500)
+	if err != nil {
+		t.Fatalf("failed to retrieve accounts: %v", err)
+	}
+	account, hash := accounts.Accounts[0], accounts.Hashes[0]
+
+	// Retrieve the storage in small chunks to force proofs
+	var (
+		origin common.Hash
+		total  int
+	)
+	for {
+		res, err := client.StorageRange(ctx, "peer-0000", root, hash, account.Root, origin, maxHash, 500)
+		if err != nil {
+			t.Fatalf("failed to retrieve storage: %v", err)
+		}
+		total += len(res.Hashes)
+		if !res.More {
+			break
+		}
+		origin = incHash(res.Hashes[len(res.Hashes)-1])
+	}
+	if total != 50 {
+		t.Fatalf("slot count mismatch: have %d, want %d", total, 50)
+	}
+	// Retrieve the code of the account
+	codes, err := client.ByteCodes(ctx, "peer-0000", []common.Hash{common.BytesToHash(account.CodeHash)}, 500)
+	if err != nil {
+		t.Fatalf("failed to retrieve code: %v", err)
+	}
+	if len(codes) != 1 || codes[0] == nil {
+		t.Fatalf("code not delivered")
+	}
+	if common.BytesToHash(account.CodeHash) == types.EmptyCodeHash {
+		t.Fatalf("test account has no code")
+	}
+}
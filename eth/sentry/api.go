@@ -0,0 +1,131 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// API is the RPC interface of a sentry, through which an external process
+// consumes the network messages and talks back to the peers.
+type API struct {
+	sentry *Sentry
+}
+
+// Messages creates a subscription delivering the messages received from the
+// peers. If codes are given, only messages with those codes are delivered.
+func (api *API) Messages(ctx context.Context, codes []hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	filter := make(map[uint64]bool, len(codes))
+	for _, code := range codes {
+		filter[uint64(code)] = true
+	}
+	var (
+		rpcSub = notifier.CreateSubscription()
+		msgs   = make(chan *Message, 256)
+		sub    = api.sentry.SubscribeMessages(msgs)
+	)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case msg := <-msgs:
+				if len(filter) == 0 || filter[msg.Code] {
+					notifier.Notify(rpcSub.ID, msg)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// PeerEvents creates a subscription delivering peer connections and
+// disconnections.
+func (api *API) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		rpcSub = notifier.CreateSubscription()
+		events = make(chan *PeerEvent, 16)
+		sub    = api.sentry.SubscribePeers(events)
+	)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Peers returns the connected peers.
+func (api *API) Peers() []*PeerInfo {
+	return api.sentry.Peers()
+}
+
+// SendMessage sends a raw message to a peer.
+func (api *API) SendMessage(id enode.ID, code hexutil.Uint64, data hexutil.Bytes) error {
+	return api.sentry.Send(id, uint64(code), data)
+}
+
+// Broadcast sends a raw message to all peers, returning the number of peers it
+// was sent to.
+func (api *API) Broadcast(code hexutil.Uint64, data hexutil.Bytes) int {
+	return api.sentry.Broadcast(uint64(code), data)
+}
+
+// Disconnect drops a peer.
+func (api *API) Disconnect(id enode.ID) error {
+	return api.sentry.Disconnect(id)
+}
+
+// SetStatus updates the head of the chain advertised to new peers.
+func (api *API) SetStatus(td *hexutil.Big, head common.Hash, number, time hexutil.Uint64) error {
+	if td == nil {
+		return errors.New("missing total difficulty")
+	}
+	api.sentry.SetStatus(Status{
+		TD:     new(big.Int).Set((*big.Int)(td)),
+		Head:   head,
+		Number: uint64(number),
+		Time:   uint64(time),
+	})
+	return nil
+}
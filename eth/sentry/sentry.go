@@ -0,0 +1,333 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sentry implements a sentry node: a trimmed `eth` protocol endpoint that
+// maintains connectivity with the network without a chain of its own, relaying
+// the raw protocol messages of its peers to an external process and sending the
+// messages of that process back.
+//
+// Nothing received is validated beyond the status handshake. The external process
+// is responsible for serving and validating the data, and for keeping the status
+// advertised to the peers up to date. It is connected through the node's RPC
+// transports, preferably IPC or websocket, under the "sentry" namespace.
+package sentry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxMessageSize is the maximum size of a relayed message, matching the limit of
+// the `eth` protocol.
+const maxMessageSize = 10 * 1024 * 1024
+
+var (
+	errUnknownPeer = errors.New("unknown peer")
+	errMsgTooLarge = errors.New("message too large")
+)
+
+// Config contains the settings of a sentry.
+type Config struct {
+	NetworkID   uint64              // Network the peers must be on
+	ChainConfig *params.ChainConfig // Chain configuration to derive fork IDs from
+	Genesis     common.Hash         // Genesis block the peers must share
+}
+
+// Status is the head of the chain advertised to the peers in the handshake.
+type Status struct {
+	TD     *big.Int    // Total difficulty of the head block
+	Head   common.Hash // Hash of the head block
+	Number uint64      // Number of the head block
+	Time   uint64      // Timestamp of the head block
+}
+
+// Message is a raw `eth` protocol message received from a peer.
+type Message struct {
+	Peer    enode.ID      `json:"peer"`
+	Version uint          `json:"version"` // Negotiated `eth` protocol version
+	Code    uint64        `json:"code"`
+	Data    hexutil.Bytes `json:"data"` // RLP encoded payload
+}
+
+// PeerEvent is posted when a peer completes the handshake or disconnects.
+type PeerEvent struct {
+	Peer    enode.ID `json:"peer"`
+	Dropped bool     `json:"dropped"`
+	Error   string   `json:"error,omitempty"` // Reason of the disconnection, if any
+}
+
+// PeerInfo describes a connected peer.
+type PeerInfo struct {
+	ID      enode.ID    `json:"id"`
+	Name    string      `json:"name"`
+	Remote  string      `json:"remoteAddress"`
+	Version uint        `json:"version"`
+	Head    common.Hash `json:"head"` // Head announced by the peer in its handshake
+}
+
+// peer is a connection to a peer that completed the handshake.
+type peer struct {
+	*p2p.Peer
+	rw      p2p.MsgReadWriter
+	version uint
+	head    common.Hash
+}
+
+// Sentry relays the `eth` protocol messages of its peers to subscribers and sends
+// their messages back to the peers.
+type Sentry struct {
+	config Config
+
+	lock   sync.RWMutex
+	status Status
+	peers  map[enode.ID]*peer
+
+	msgFeed  event.Feed
+	peerFeed event.Feed
+}
+
+// New creates a sentry advertising the genesis block as its head, until the
+// status is updated by SetStatus.
+func New(config Config) *Sentry {
+	return &Sentry{
+		config: config,
+		status: Status{TD: new(big.Int), Head: config.Genesis},
+		peers:  make(map[enode.ID]*peer),
+	}
+}
+
+// Register creates a sentry and registers its protocols and APIs on the node.
+func Register(stack *node.Node, config Config) *Sentry {
+	s := New(config)
+	stack.RegisterProtocols(s.Protocols())
+	stack.RegisterAPIs(s.APIs())
+	return s
+}
+
+// Protocols returns the `eth` protocol versions relayed by the sentry.
+func (s *Sentry) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(eth.ProtocolVersions))
+	for i, version := range eth.ProtocolVersions {
+		version := version // Closure
+
+		protocols[i] = p2p.Protocol{
+			Name:    eth.ProtocolName,
+			Version: version,
+			Length:  17, // Message count of all supported versions
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return s.runPeer(p, rw, version)
+			},
+			PeerInfo: func(id enode.ID) interface{} {
+				s.lock.RLock()
+				defer s.lock.RUnlock()
+
+				if p := s.peers[id]; p != nil {
+					return p.info()
+				}
+				return nil
+			},
+		}
+	}
+	return protocols
+}
+
+// APIs returns the RPC APIs of the sentry.
+func (s *Sentry) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "sentry",
+		Service:   &API{sentry: s},
+	}}
+}
+
+// Status returns the status advertised to new peers.
+func (s *Sentry) Status() Status {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.status
+}
+
+// SetStatus updates the status advertised to new peers. Connected peers learn
+// about new heads from the block announcements sent to them.
+func (s *Sentry) SetStatus(status Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+}
+
+// Peers returns the connected peers, ordered by ID.
+func (s *Sentry) Peers() []*PeerInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	infos := make([]*PeerInfo, 0, len(s.peers))
+	for _, p := range s.peers {
+		infos = append(infos, p.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID.String() < infos[j].ID.String()
+	})
+	return infos
+}
+
+// Send sends a raw message to a peer.
+func (s *Sentry) Send(id enode.ID, code uint64, data []byte) error {
+	s.lock.RLock()
+	p := s.peers[id]
+	s.lock.RUnlock()
+
+	if p == nil {
+		return errUnknownPeer
+	}
+	return p2p.Send(p.rw, code, rlp.RawValue(data))
+}
+
+// Broadcast sends a raw message to all peers, returning the number of peers it
+// was sent to successfully.
+func (s *Sentry) Broadcast(code uint64, data []byte) int {
+	s.lock.RLock()
+	peers := make([]*peer, 0, len(s.peers))
+	for _, p := range s.peers {
+		peers = append(peers, p)
+	}
+	s.lock.RUnlock()
+
+	var sent int
+	for _, p := range peers {
+		if err := p2p.Send(p.rw, code, rlp.RawValue(data)); err != nil {
+			p.Log().Debug("Failed to broadcast sentry message", "code", code, "err", err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// Disconnect drops a peer, e.g. after the external process found its data to be
+// invalid.
+func (s *Sentry) Disconnect(id enode.ID) error {
+	s.lock.RLock()
+	p := s.peers[id]
+	s.lock.RUnlock()
+
+	if p == nil {
+		return errUnknownPeer
+	}
+	p.Disconnect(p2p.DiscUselessPeer)
+	return nil
+}
+
+// SubscribeMessages subscribes to the messages received from the peers.
+func (s *Sentry) SubscribeMessages(ch chan<- *Message) event.Subscription {
+	return s.msgFeed.Subscribe(ch)
+}
+
+// SubscribePeers subscribes to peer connections and disconnections.
+func (s *Sentry) SubscribePeers(ch chan<- *PeerEvent) event.Subscription {
+	return s.peerFeed.Subscribe(ch)
+}
+
+// runPeer performs the status handshake with a peer and relays its messages
+// until it disconnects.
+func (s *Sentry) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter, version uint) error {
+	status := s.Status()
+	var (
+		forkID = forkid.NewID(s.config.ChainConfig, s.config.Genesis, status.Number, status.Time)
+		filter = forkid.NewFilterAt(s.config.ChainConfig, s.config.Genesis, status.Number, status.Time)
+		ep     = eth.NewPeer(version, p, rw, nopTxPool{})
+	)
+	defer ep.Close()
+
+	if err := ep.Handshake(s.config.NetworkID, status.TD, status.Head, s.config.Genesis, forkID, filter); err != nil {
+		p.Log().Debug("Sentry handshake failed", "err", err)
+		return err
+	}
+	head, _ := ep.Head()
+	sp := &peer{Peer: p, rw: rw, version: version, head: head}
+
+	s.lock.Lock()
+	if s.peers[p.ID()] != nil {
+		s.lock.Unlock()
+		return p2p.DiscAlreadyConnected
+	}
+	s.peers[p.ID()] = sp
+	s.lock.Unlock()
+	s.peerFeed.Send(&PeerEvent{Peer: p.ID()})
+
+	err := s.relay(sp)
+
+	s.lock.Lock()
+	delete(s.peers, p.ID())
+	s.lock.Unlock()
+
+	event := &PeerEvent{Peer: p.ID(), Dropped: true}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.peerFeed.Send(event)
+	return err
+}
+
+// relay forwards the messages of the peer to the subscribers.
+func (s *Sentry) relay(p *peer) error {
+	for {
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > maxMessageSize {
+			return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+		}
+		data, err := io.ReadAll(msg.Payload)
+		msg.Discard()
+		if err != nil {
+			return err
+		}
+		s.msgFeed.Send(&Message{Peer: p.ID(), Version: p.version, Code: msg.Code, Data: data})
+	}
+}
+
+func (p *peer) info() *PeerInfo {
+	return &PeerInfo{
+		ID:      p.ID(),
+		Name:    p.Fullname(),
+		Remote:  p.RemoteAddr().String(),
+		Version: p.version,
+		Head:    p.head,
+	}
+}
+
+// nopTxPool is the transaction pool of the `eth` peers of a sentry, which never
+// broadcast transactions on their own.
+type nopTxPool struct{}
+
+func (nopTxPool) Get(hash common.Hash) *types.Transaction { return nil }
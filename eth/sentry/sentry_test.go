@@ -0,0 +1,157 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sentry
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func newTestSentry() *Sentry {
+	return New(Config{NetworkID: 1, ChainConfig: params.MainnetChainConfig, Genesis: params.MainnetGenesisHash})
+}
+
+// handshake performs the remote side of the status handshake.
+func handshake(t *testing.T, rw p2p.MsgReadWriter, genesis common.Hash) *eth.StatusPacket {
+	t.Helper()
+
+	go p2p.Send(rw, eth.StatusMsg, &eth.StatusPacket{
+		ProtocolVersion: eth.ETH67,
+		NetworkID:       1,
+		TD:              big.NewInt(1),
+		Head:            common.Hash{0x02},
+		Genesis:         genesis,
+		ForkID:          forkid.NewID(params.MainnetChainConfig, params.MainnetGenesisHash, 0, 0),
+	})
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	var status eth.StatusPacket
+	if err := msg.Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	return &status
+}
+
+func TestRelay(t *testing.T) {
+	var (
+		sentry = newTestSentry()
+		msgs   = make(chan *Message, 1)
+		events = make(chan *PeerEvent, 2)
+		id     = enode.ID{0x01}
+	)
+	sentry.SubscribeMessages(msgs)
+	sentry.SubscribePeers(events)
+	sentry.SetStatus(Status{TD: big.NewInt(100), Head: common.Hash{0x03}, Number: 1})
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- sentry.runPeer(p2p.NewPeer(id, "test", nil), app, eth.ETH67) }()
+
+	if status := handshake(t, net, params.MainnetGenesisHash); status.Head != (common.Hash{0x03}) || status.TD.Uint64() != 100 {
+		t.Fatalf("advertised status mismatch: head %x, td %v", status.Head, status.TD)
+	}
+	select {
+	case ev := <-events:
+		if ev.Peer != id || ev.Dropped {
+			t.Fatalf("unexpected peer event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer connection not announced")
+	}
+	if peers := sentry.Peers(); len(peers) != 1 || peers[0].Head != (common.Hash{0x02}) {
+		t.Fatalf("peers mismatch: %v", peers)
+	}
+	// Messages of the peer must be relayed raw
+	go p2p.Send(net, eth.TransactionsMsg, []uint{1, 2})
+	select {
+	case msg := <-msgs:
+		want, _ := rlp.EncodeToBytes([]uint{1, 2})
+		if msg.Peer != id || msg.Code != eth.TransactionsMsg || !bytes.Equal(msg.Data, want) {
+			t.Fatalf("relayed message mismatch: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not relayed")
+	}
+	// Messages of the external process must be sent raw
+	request, _ := rlp.EncodeToBytes([]uint{7})
+	go sentry.Send(id, eth.GetBlockHeadersMsg, request)
+
+	msg, err := net.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read sent message: %v", err)
+	}
+	data, _ := io.ReadAll(msg.Payload)
+	if msg.Code != eth.GetBlockHeadersMsg || !bytes.Equal(data, request) {
+		t.Fatalf("sent message mismatch: code %d, data %x", msg.Code, data)
+	}
+	if err := sentry.Send(enode.ID{0xff}, eth.GetBlockHeadersMsg, request); err != errUnknownPeer {
+		t.Fatalf("sending to unknown peer: have %v, want %v", err, errUnknownPeer)
+	}
+	// Disconnection must be announced
+	net.Close()
+	select {
+	case ev := <-events:
+		if ev.Peer != id || !ev.Dropped {
+			t.Fatalf("unexpected peer event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer disconnection not announced")
+	}
+	<-errc
+	if peers := sentry.Peers(); len(peers) != 0 {
+		t.Fatalf("dropped peer still listed: %v", peers)
+	}
+}
+
+func TestHandshakeMismatch(t *testing.T) {
+	sentry := newTestSentry()
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	defer net.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- sentry.runPeer(p2p.NewPeer(enode.ID{0x01}, "test", nil), app, eth.ETH67) }()
+
+	handshake(t, net, common.Hash{0xff})
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("peer on a different genesis accepted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handshake not rejected")
+	}
+	if peers := sentry.Peers(); len(peers) != 0 {
+		t.Fatalf("rejected peer listed: %v", peers)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package coverage implements an EVM tracer recording the code coverage of
+// contracts, e.g. while running a test suite against the simulated backend:
+//
+//	tracer := coverage.New()
+//	sim := backends.NewSimulatedBackendWithVMConfig(alloc, gasLimit, vm.Config{Debug: true, Tracer: tracer})
+//	... run the tests ...
+//	tracer.Contract(code).WriteSourceLcov(out, srcmap, sources)
+package coverage
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tracer is a vm.EVMLogger aggregating the executed program counters of all
+// contracts it sees, identified by the hash of their code. Creation code is
+// tracked separately from the deployed code. Tracer is safe for concurrent use.
+type Tracer struct {
+	contracts map[common.Hash]*Contract
+	frames    []*Contract // Coverage of the active call frames, resolved lazily
+	lock      sync.Mutex
+}
+
+// New creates a coverage tracer.
+func New() *Tracer {
+	return &Tracer{contracts: make(map[common.Hash]*Contract)}
+}
+
+// Contract returns the coverage collected for the given code, or nil if the
+// code was never executed.
+func (t *Tracer) Contract(code []byte) *Contract {
+	return t.ContractByHash(crypto.Keccak256Hash(code))
+}
+
+// ContractByHash returns the coverage collected for the code with the given
+// hash, or nil if the code was never executed.
+func (t *Tracer) ContractByHash(hash common.Hash) *Contract {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if c, ok := t.contracts[hash]; ok {
+		return c.copy()
+	}
+	return nil
+}
+
+// Contracts returns the coverage of all executed code, keyed by code hash.
+func (t *Tracer) Contracts() map[common.Hash]*Contract {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	contracts := make(map[common.Hash]*Contract, len(t.contracts))
+	for hash, c := range t.contracts {
+		contracts[hash] = c.copy()
+	}
+	return contracts
+}
+
+// Reset drops all collected coverage.
+func (t *Tracer) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.contracts = make(map[common.Hash]*Contract)
+}
+
+// CaptureTxStart implements vm.EVMLogger.
+func (t *Tracer) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements vm.EVMLogger.
+func (t *Tracer) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements vm.EVMLogger, opening the top call frame.
+func (t *Tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.frames = append(t.frames[:0], nil)
+}
+
+// CaptureEnd implements vm.EVMLogger.
+func (t *Tracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.frames = t.frames[:0]
+}
+
+// CaptureEnter implements vm.EVMLogger, opening a nested call frame.
+func (t *Tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.frames = append(t.frames, nil)
+}
+
+// CaptureExit implements vm.EVMLogger.
+func (t *Tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.frames) > 0 {
+		t.frames = t.frames[:len(t.frames)-1]
+	}
+}
+
+// CaptureState implements vm.EVMLogger, recording the executed instruction.
+func (t *Tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.frames) == 0 {
+		return
+	}
+	c := t.frames[len(t.frames)-1]
+	if c == nil {
+		// First instruction of the frame, look up the executed code. Creation
+		// code has no hash assigned yet.
+		hash := scope.Contract.CodeHash
+		if hash == (common.Hash{}) {
+			hash = crypto.Keccak256Hash(scope.Contract.Code)
+		}
+		if c = t.contracts[hash]; c == nil {
+			c = &Contract{Code: common.CopyBytes(scope.Contract.Code), hits: make(map[uint64]uint64)}
+			t.contracts[hash] = c
+		}
+		t.frames[len(t.frames)-1] = c
+	}
+	c.hits[pc]++
+}
+
+// CaptureFault implements vm.EVMLogger.
+func (t *Tracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// Contract is the coverage of a piece of EVM bytecode.
+type Contract struct {
+	Code []byte
+	hits map[uint64]uint64 // Execution counts by program counter
+}
+
+func (c *Contract) copy() *Contract {
+	cpy := &Contract{Code: c.Code, hits: make(map[uint64]uint64, len(c.hits))}
+	for pc, n := range c.hits {
+		cpy.hits[pc] = n
+	}
+	return cpy
+}
+
+// Hits returns how often the instruction at the given program counter was
+// executed.
+func (c *Contract) Hits(pc uint64) uint64 {
+	return c.hits[pc]
+}
+
+// Instructions returns the program counters of all instructions in the code,
+// skipping over push data.
+func (c *Contract) Instructions() []uint64 {
+	var pcs []uint64
+	for pc := uint64(0); pc < uint64(len(c.Code)); pc++ {
+		pcs = append(pcs, pc)
+		if op := vm.OpCode(c.Code[pc]); op.IsPush() {
+			pc += uint64(op - vm.PUSH0)
+		}
+	}
+	return pcs
+}
+
+// Covered returns the number of executed instructions and the total number of
+// instructions in the code.
+func (c *Contract) Covered() (covered, total int) {
+	pcs := c.Instructions()
+	for _, pc := range pcs {
+		if c.hits[pc] > 0 {
+			covered++
+		}
+	}
+	return covered, len(pcs)
+}
+
+// BasicBlock is a sequence of instructions without jumps into or out of it,
+// apart from its entry and exit.
+type BasicBlock struct {
+	Start uint64 // Program counter of the first instruction
+	End   uint64 // Program counter of the last instruction
+	Hits  uint64 // Execution count of the block
+}
+
+// BasicBlocks splits the code into basic blocks. Blocks start at the code
+// start, at every JUMPDEST and after every instruction altering the control
+// flow.
+func (c *Contract) BasicBlocks() []BasicBlock {
+	var (
+		blocks []BasicBlock
+		pcs    = c.Instructions()
+	)
+	for i, pc := range pcs {
+		op := vm.OpCode(c.Code[pc])
+		if i == 0 || op == vm.JUMPDEST || endsBlock(vm.OpCode(c.Code[pcs[i-1]])) {
+			blocks = append(blocks, BasicBlock{Start: pc, Hits: c.hits[pc]})
+		}
+		blocks[len(blocks)-1].End = pc
+	}
+	return blocks
+}
+
+// endsBlock reports whether the instruction ends a basic block.
+func endsBlock(op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.JUMP, vm.JUMPI, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return true
+	}
+	return false
+}
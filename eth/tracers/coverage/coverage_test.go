@@ -0,0 +1,153 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testCode reverts if called without calldata, and stops otherwise.
+//
+//	CALLDATASIZE PUSH1 9 JUMPI PUSH1 0 PUSH1 0 REVERT JUMPDEST STOP
+var testCode = common.FromHex("3660095760006000fd5b00")
+
+func TestCoverage(t *testing.T) {
+	tracer := New()
+	cfg := &runtime.Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}}
+
+	runtime.Execute(testCode, nil, cfg)
+	c := tracer.Contract(testCode)
+	if c == nil {
+		t.Fatal("no coverage recorded")
+	}
+	if covered, total := c.Covered(); covered != 6 || total != 8 {
+		t.Errorf("coverage mismatch: have %d/%d, want 6/8", covered, total)
+	}
+	// Coverage is aggregated over multiple executions of the same code
+	runtime.Execute(testCode, []byte{0x01}, cfg)
+	c = tracer.Contract(testCode)
+	if covered, total := c.Covered(); covered != 8 || total != 8 {
+		t.Errorf("coverage mismatch: have %d/%d, want 8/8", covered, total)
+	}
+	want := []BasicBlock{{Start: 0, End: 3, Hits: 2}, {Start: 4, End: 8, Hits: 1}, {Start: 9, End: 10, Hits: 1}}
+	if blocks := c.BasicBlocks(); !reflect.DeepEqual(blocks, want) {
+		t.Errorf("basic blocks mismatch: have %+v, want %+v", blocks, want)
+	}
+	tracer.Reset()
+	if tracer.Contract(testCode) != nil {
+		t.Error("coverage not reset")
+	}
+}
+
+func TestParseSourceMap(t *testing.T) {
+	srcmap, err := ParseSourceMap("0:10:0:-;;5:2;:::i;-1:3:-1:o:1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SourceMap{
+		{Start: 0, Length: 10, File: 0, Jump: '-'},
+		{Start: 0, Length: 10, File: 0, Jump: '-'},
+		{Start: 5, Length: 2, File: 0, Jump: '-'},
+		{Start: 5, Length: 2, File: 0, Jump: 'i'},
+		{Start: -1, Length: 3, File: -1, Jump: 'o'},
+		{Start: -1, Length: 3, File: -1, Jump: 'o'},
+	}
+	if !reflect.DeepEqual(srcmap, want) {
+		t.Errorf("source map mismatch: have %+v, want %+v", srcmap, want)
+	}
+	if _, err := ParseSourceMap("0:x:0"); err == nil {
+		t.Error("invalid source map accepted")
+	}
+}
+
+func TestWriteLcov(t *testing.T) {
+	tracer := New()
+	runtime.Execute(testCode, nil, &runtime.Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}})
+	c := tracer.Contract(testCode)
+
+	var buf bytes.Buffer
+	if err := c.WriteLcov(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "DA:10,0\nDA:11,0\nLF:8\nLH:6\n") {
+		t.Errorf("unexpected bytecode lcov output:\n%s", out)
+	}
+	// Map the instructions to three lines of a source file
+	srcmap, _ := ParseSourceMap("0:1:0;;;2:1;;;4:1;")
+	buf.Reset()
+	if err := c.WriteSourceLcov(&buf, srcmap, []Source{{Path: "Test.sol", Content: "a\nb\nc\n"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "TN:\nSF:Test.sol\nDA:1,1\nDA:2,1\nDA:3,0\nLF:3\nLH:2\nend_of_record\n"
+	if buf.String() != want {
+		t.Errorf("source lcov mismatch:\nhave:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if err := c.WriteSourceLcov(&buf, srcmap, nil); err == nil {
+		t.Error("missing source accepted")
+	}
+}
+
+func TestSimulatedBackendCoverage(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		tracer = New()
+		alloc  = core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}
+		sim    = backends.NewSimulatedBackendWithVMConfig(alloc, 10000000, vm.Config{Debug: true, Tracer: tracer})
+	)
+	defer sim.Close()
+
+	// Deploy the test code, copying it from the end of the initcode
+	initcode := append(common.FromHex("600b600c600039600b6000f3"), testCode...)
+	auth, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	contract, _, _, err := bind.DeployContract(auth, abi.ABI{}, initcode, sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+
+	// Coverage is collected both from transactions and calls
+	if _, err := sim.CallContract(context.Background(), ethereum.CallMsg{To: &contract, Data: []byte{0x01}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c := tracer.Contract(initcode); c == nil {
+		t.Error("no coverage of the initcode")
+	}
+	c := tracer.Contract(testCode)
+	if c == nil {
+		t.Fatal("no coverage of the deployed code")
+	}
+	if covered, total := c.Covered(); covered != 5 || total != 8 {
+		t.Errorf("coverage mismatch: have %d/%d, want 5/8", covered, total)
+	}
+}
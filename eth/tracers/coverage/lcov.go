@@ -0,0 +1,138 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SourceRange is the source location of a single instruction, as recorded in
+// a solc source map.
+type SourceRange = compiler.SourceRange
+
+// SourceMap maps every instruction of a contract to its source range.
+type SourceMap = compiler.SourceMap
+
+// ParseSourceMap decodes a solc source map in its compressed form, i.e. the
+// "s:l:f:j;..." format emitted as srcmap or srcmap-runtime.
+func ParseSourceMap(srcmap string) (SourceMap, error) {
+	return compiler.ParseSourceMap(srcmap)
+}
+
+// Source is a source file referenced by a source map.
+type Source struct {
+	Path    string
+	Content string
+}
+
+// WriteLcov writes the bytecode level coverage of the contract in lcov format.
+// The code hash is used as the file name, with every instruction reported as a
+// line numbered by its program counter plus one.
+func (c *Contract) WriteLcov(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "TN:\nSF:%x\n", crypto.Keccak256(c.Code))
+
+	pcs := c.Instructions()
+	hit := 0
+	for _, pc := range pcs {
+		n := c.hits[pc]
+		if n > 0 {
+			hit++
+		}
+		fmt.Fprintf(bw, "DA:%d,%d\n", pc+1, n)
+	}
+	fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(pcs), hit)
+	return bw.Flush()
+}
+
+// WriteSourceLcov writes the source level coverage of the contract in lcov
+// format. The source map must belong to the traced code, sources are indexed
+// by the file IDs used in the source map. A source line counts as executed as
+// often as the most executed instruction starting on it.
+func (c *Contract) WriteSourceLcov(w io.Writer, srcmap SourceMap, sources []Source) error {
+	pcs := c.Instructions()
+	if len(srcmap) > len(pcs) {
+		return fmt.Errorf("source map has %d entries, code only %d instructions", len(srcmap), len(pcs))
+	}
+	// Aggregate the instruction hits by source line. The source map does not
+	// cover the metadata appended to the code.
+	var (
+		lines  = make(map[int]map[int]uint64)
+		starts = make(map[int][]int)
+	)
+	for i, r := range srcmap {
+		if r.File < 0 {
+			continue
+		}
+		if r.File >= len(sources) {
+			return fmt.Errorf("instruction %d: unknown source file %d", i, r.File)
+		}
+		if _, ok := starts[r.File]; !ok {
+			starts[r.File] = lineStarts(sources[r.File].Content)
+			lines[r.File] = make(map[int]uint64)
+		}
+		line := sort.SearchInts(starts[r.File], r.Start+1)
+		if n := c.hits[pcs[i]]; n > lines[r.File][line] {
+			lines[r.File][line] = n
+		} else if _, ok := lines[r.File][line]; !ok {
+			lines[r.File][line] = 0
+		}
+	}
+	files := make([]int, 0, len(lines))
+	for file := range lines {
+		files = append(files, file)
+	}
+	sort.Ints(files)
+
+	bw := bufio.NewWriter(w)
+	for _, file := range files {
+		numbers := make([]int, 0, len(lines[file]))
+		for line := range lines[file] {
+			numbers = append(numbers, line)
+		}
+		sort.Ints(numbers)
+
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", sources[file].Path)
+		hit := 0
+		for _, line := range numbers {
+			n := lines[file][line]
+			if n > 0 {
+				hit++
+			}
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, n)
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(numbers), hit)
+	}
+	return bw.Flush()
+}
+
+// lineStarts returns the byte offsets at which the lines of the source start.
+func lineStarts(src string) []int {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
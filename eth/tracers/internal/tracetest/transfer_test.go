@@ -0,0 +1,141 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+func TestTransferTracer(t *testing.T) {
+	var (
+		to        = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		helper    = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+		recipient = common.HexToAddress("0x000000000000000000000000000000000000f00d")
+		sender    = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		receiver  = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		topic     = crypto.Keccak256([]byte("Transfer(address,address,uint256)"))
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+		Value:    big.NewInt(10),
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(1),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        5,
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	// transferLog emits an ERC-20 transfer of 100 tokens from sender to receiver
+	transferLog := []byte{byte(vm.PUSH1), 0x64, byte(vm.PUSH1), 0x0, byte(vm.MSTORE), byte(vm.PUSH20)}
+	transferLog = append(transferLog, receiver.Bytes()...)
+	transferLog = append(transferLog, byte(vm.PUSH20))
+	transferLog = append(transferLog, sender.Bytes()...)
+	transferLog = append(transferLog, byte(vm.PUSH32))
+	transferLog = append(transferLog, topic...)
+	transferLog = append(transferLog, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.LOG3))
+
+	// The contract emits a token transfer, sends 3 wei to the recipient and then
+	// calls the helper, which emits a token transfer before reverting
+	code := append([]byte{}, transferLog...)
+	code = append(code, byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), 0x3, byte(vm.PUSH20))
+	code = append(code, recipient.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	code = append(code, byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH20))
+	code = append(code, helper.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))
+
+	helperCode := append([]byte{}, transferLog...)
+	helperCode = append(helperCode, byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT))
+
+	alloc := core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		helper: core.GenesisAccount{Nonce: 1, Code: helperCode},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	tracer, err := tracers.DefaultDirectory.New("transferTracer", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create transfer tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := core.TransactionToMessage(tx, signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var transfers []struct {
+		Type  string
+		Token *common.Address
+		From  common.Address
+		To    common.Address
+		Value *hexutil.Big
+	}
+	if err := json.Unmarshal(res, &transfers); err != nil {
+		t.Fatalf("failed to decode trace result: %v", err)
+	}
+	if len(transfers) != 3 {
+		t.Fatalf("wrong number of transfers: have %d, want 3: %s", len(transfers), res)
+	}
+	if tr := transfers[0]; tr.Type != "ether" || tr.Token != nil || tr.From != origin || tr.To != to || tr.Value.ToInt().Int64() != 10 {
+		t.Fatalf("wrong transaction value transfer: %+v", tr)
+	}
+	if tr := transfers[1]; tr.Type != "erc20" || tr.Token == nil || *tr.Token != to || tr.From != sender || tr.To != receiver || tr.Value.ToInt().Int64() != 100 {
+		t.Fatalf("wrong token transfer: %+v", tr)
+	}
+	if tr := transfers[2]; tr.Type != "ether" || tr.From != to || tr.To != recipient || tr.Value.ToInt().Int64() != 3 {
+		t.Fatalf("wrong call value transfer: %+v", tr)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+func TestWatchTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		helper = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(1),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        5,
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	// The contract reads and writes slot 0, then calls the helper, which writes
+	// its own slot 0 before reverting
+	code := []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH20),
+	}
+	code = append(code, helper.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))
+
+	helperCode := []byte{
+		byte(vm.PUSH1), 0x7, byte(vm.PUSH1), 0x0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT),
+	}
+	alloc := core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		helper: core.GenesisAccount{Nonce: 1, Code: helperCode},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	config := fmt.Sprintf(`{"slots":[{"address":"%s","slot":"0x0000000000000000000000000000000000000000000000000000000000000000"},{"address":"%s","slot":"0x0000000000000000000000000000000000000000000000000000000000000000"}]}`, to, helper)
+	tracer, err := tracers.DefaultDirectory.New("watchTracer", nil, json.RawMessage(config))
+	if err != nil {
+		t.Fatalf("failed to create watch tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := core.TransactionToMessage(tx, signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var accesses []struct {
+		Type     string
+		Address  common.Address
+		Value    common.Hash
+		Prev     *common.Hash
+		Caller   common.Address
+		Depth    int
+		Reverted bool
+	}
+	if err := json.Unmarshal(res, &accesses); err != nil {
+		t.Fatalf("failed to decode trace result: %v", err)
+	}
+	if len(accesses) != 3 {
+		t.Fatalf("wrong number of accesses: have %d, want 3: %s", len(accesses), res)
+	}
+	if a := accesses[0]; a.Type != "SLOAD" || a.Address != to || a.Value != (common.Hash{}) || a.Prev != nil || a.Depth != 1 {
+		t.Fatalf("wrong read access: %+v", a)
+	}
+	if a := accesses[1]; a.Type != "SSTORE" || a.Address != to || a.Value != common.BigToHash(big.NewInt(0x2a)) || a.Prev == nil || *a.Prev != (common.Hash{}) || a.Reverted {
+		t.Fatalf("wrong write access: %+v", a)
+	}
+	if a := accesses[2]; a.Type != "SSTORE" || a.Address != helper || a.Caller != to || a.Depth != 2 || !a.Reverted {
+		t.Fatalf("wrong reverted write access: %+v", a)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/dop251/goja"
 
@@ -112,6 +113,13 @@ type jsTracer struct {
 	err               error                 // Any error that should stop tracing
 	obj               *goja.Object          // Trace object
 
+	// Resource accounting
+	limits    Limits        // Resource limits of the tracer
+	watchdog  *time.Timer   // Timer interrupting tracer code running out of time
+	elapsed   time.Duration // Time spent executing tracer code
+	steps     uint64        // Number of tracer hook invocations
+	truncated error         // Limit exceeded by the tracer, stopping tracing
+
 	// Methods exposed by tracer
 	result goja.Callable
 	fault  goja.Callable
@@ -143,9 +151,12 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 	// By default field names are exported to JS as is, i.e. capitalized.
 	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
 	t := &jsTracer{
-		vm:  vm,
-		ctx: make(map[string]goja.Value),
+		vm:     vm,
+		ctx:    make(map[string]goja.Value),
+		limits: currentLimits(),
 	}
+	t.watchdog = time.AfterFunc(time.Hour, func() { vm.Interrupt(errTimeLimit) })
+	t.watchdog.Stop()
 	if ctx == nil {
 		ctx = new(tracers.Context)
 	}
@@ -159,7 +170,9 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 
 	t.setTypeConverters()
 	t.setBuiltinFunctions()
-	ret, err := vm.RunString("(" + code + ")")
+	ret, err := t.guard(func() (goja.Value, error) {
+		return vm.RunString("(" + code + ")")
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +207,9 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 		if cfg != nil {
 			cfgStr = string(cfg)
 		}
-		if _, err := setup(obj, vm.ToValue(cfgStr)); err != nil {
+		if _, err := t.guard(func() (goja.Value, error) {
+			return setup(obj, vm.ToValue(cfgStr))
+		}); err != nil {
 			return nil, err
 		}
 	}
@@ -258,7 +273,7 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	if !t.traceStep {
 		return
 	}
-	if t.err != nil {
+	if t.err != nil || t.truncated != nil {
 		return
 	}
 
@@ -273,19 +288,19 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	log.refund = t.env.StateDB.GetRefund()
 	log.depth = depth
 	log.err = err
-	if _, err := t.step(t.obj, t.logValue, t.dbValue); err != nil {
+	if err := t.invoke(t.step, t.logValue, t.dbValue); err != nil {
 		t.onError("step", err)
 	}
 }
 
 // CaptureFault implements the Tracer interface to trace an execution fault
 func (t *jsTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
-	if t.err != nil {
+	if t.err != nil || t.truncated != nil {
 		return
 	}
 	// Other log fields have been already set as part of the last CaptureState.
 	t.log.err = err
-	if _, err := t.fault(t.obj, t.logValue, t.dbValue); err != nil {
+	if err := t.invoke(t.fault, t.logValue, t.dbValue); err != nil {
 		t.onError("fault", err)
 	}
 }
@@ -303,7 +318,7 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 	if !t.traceFrame {
 		return
 	}
-	if t.err != nil {
+	if t.err != nil || t.truncated != nil {
 		return
 	}
 
@@ -317,7 +332,7 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 		t.frame.value = new(big.Int).SetBytes(value.Bytes())
 	}
 
-	if _, err := t.enter(t.obj, t.frameValue); err != nil {
+	if err := t.invoke(t.enter, t.frameValue); err != nil {
 		t.onError("enter", err)
 	}
 }
@@ -328,20 +343,32 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	if !t.traceFrame {
 		return
 	}
+	if t.truncated != nil {
+		return
+	}
 
 	t.frameResult.gasUsed = uint(gasUsed)
 	t.frameResult.output = common.CopyBytes(output)
 	t.frameResult.err = err
 
-	if _, err := t.exit(t.obj, t.frameResultValue); err != nil {
+	if err := t.invoke(t.exit, t.frameResultValue); err != nil {
 		t.onError("exit", err)
 	}
 }
 
-// GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
+// GetResult calls the Javascript 'result' function and returns its value, or any accumulated error.
+// If the tracer exceeded its resource limits, the partial result is returned marked as truncated.
 func (t *jsTracer) GetResult() (json.RawMessage, error) {
+	if t.truncated != nil {
+		// Grant the result function a fresh budget to report what was traced
+		t.ctx["truncated"] = t.vm.ToValue(t.truncated.Error())
+		t.elapsed = 0
+		t.vm.ClearInterrupt()
+	}
 	ctx := t.vm.ToValue(t.ctx)
-	res, err := t.result(t.obj, ctx, t.dbValue)
+	res, err := t.guard(func() (goja.Value, error) {
+		return t.result(t.obj, ctx, t.dbValue)
+	})
 	if err != nil {
 		return nil, wrapError("result", err)
 	}
@@ -349,6 +376,9 @@ func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.truncated != nil {
+		return markTruncated(encoded, t.truncated.Error())
+	}
 	return json.RawMessage(encoded), t.err
 }
 
@@ -361,7 +391,11 @@ func (t *jsTracer) Stop(err error) {
 // and returns an error. It in turn pings the EVM to cancel its
 // execution.
 func (t *jsTracer) onError(context string, err error) {
-	t.err = wrapError(context, err)
+	if isLimitError(err) {
+		t.truncated = err
+	} else {
+		t.err = wrapError(context, err)
+	}
 	// `env` is set on CaptureStart which comes before any JS execution.
 	// So it should be non-nil.
 	t.env.Cancel()
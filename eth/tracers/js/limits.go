@@ -0,0 +1,207 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package js
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// memoryCheckInterval is the number of tracer hook invocations between two
+// measurements of the tracer state size. Measuring walks the entire state, so
+// it is done only periodically.
+const memoryCheckInterval = 1024
+
+var (
+	errTimeLimit   = errors.New("time limit exceeded")
+	errStepLimit   = errors.New("step limit exceeded")
+	errMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// Limits bounds the resources a JS tracer may consume while tracing a single
+// transaction, protecting the node from tracers which run for too long or hoard
+// memory, be it by accident or by design. Zero values disable the limits.
+//
+// Tracers exceeding a limit are not invoked anymore and the traced execution is
+// aborted. Their result function is still called, with a budget of its own and
+// with ctx.truncated set to the reason, and the result is marked as truncated.
+type Limits struct {
+	Timeout time.Duration // Time spent executing tracer code
+	Steps   uint64        // Number of invocations of the tracer hooks
+	Memory  uint64        // Approximate size of the tracer state in bytes
+}
+
+var (
+	limits     Limits
+	limitsLock sync.RWMutex
+)
+
+// SetLimits sets the resource limits of the JS tracers created afterwards.
+func SetLimits(l Limits) {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+
+	limits = l
+}
+
+// currentLimits returns the resource limits of newly created JS tracers.
+func currentLimits() Limits {
+	limitsLock.RLock()
+	defer limitsLock.RUnlock()
+
+	return limits
+}
+
+// guard runs a piece of tracer code, accounting for the resources it uses. The
+// code is interrupted if it runs out of time. A limit error is returned if any
+// of the limits is exceeded, before or during the run.
+func (t *jsTracer) guard(run func() (goja.Value, error)) (goja.Value, error) {
+	if t.limits.Timeout == 0 {
+		return run()
+	}
+	remaining := t.limits.Timeout - t.elapsed
+	if remaining <= 0 {
+		return nil, errTimeLimit
+	}
+	t.watchdog.Reset(remaining)
+	start := time.Now()
+	res, err := run()
+	if !t.watchdog.Stop() {
+		// The watchdog fired, possibly after the code finished. Either way
+		// the budget is depleted, make sure no interrupt lingers.
+		t.vm.ClearInterrupt()
+		t.elapsed = t.limits.Timeout
+	} else {
+		t.elapsed += time.Since(start)
+	}
+	var interrupt *goja.InterruptedError
+	if errors.As(err, &interrupt) && interrupt.Value() == errTimeLimit {
+		return nil, errTimeLimit
+	}
+	return res, err
+}
+
+// invoke calls one of the tracer hooks, enforcing the resource limits.
+func (t *jsTracer) invoke(fn goja.Callable, args ...goja.Value) error {
+	t.steps++
+	if t.limits.Steps != 0 && t.steps > t.limits.Steps {
+		return errStepLimit
+	}
+	_, err := t.guard(func() (goja.Value, error) {
+		return fn(t.obj, args...)
+	})
+	if err != nil {
+		return err
+	}
+	if t.limits.Memory != 0 && t.steps%memoryCheckInterval == 0 {
+		return t.checkMemory()
+	}
+	return nil
+}
+
+// checkMemory measures the size of the tracer state, failing if it exceeds the
+// memory limit. Measuring may call into accessors defined by the tracer, so it
+// is guarded just as any other tracer code.
+func (t *jsTracer) checkMemory() error {
+	var size uint64
+	_, err := t.guard(func() (goja.Value, error) {
+		size = jsSize(t.obj, make(map[*goja.Object]struct{}), t.limits.Memory)
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	if size > t.limits.Memory {
+		return errMemoryLimit
+	}
+	return nil
+}
+
+// jsSize approximates the memory retained by a JS value, stopping once the size
+// exceeds the given bound. Objects reachable through multiple paths are counted
+// only once. Only the enumerable properties are followed, so state hidden away
+// in closures or collections is not accounted for.
+func jsSize(v goja.Value, seen map[*goja.Object]struct{}, bound uint64) uint64 {
+	const wordSize = 8
+
+	if v == nil {
+		return 0
+	}
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		if s, ok := v.Export().(string); ok {
+			return wordSize + uint64(len(s))
+		}
+		return wordSize
+	}
+	if _, ok := seen[obj]; ok {
+		return wordSize
+	}
+	seen[obj] = struct{}{}
+
+	// Binary buffers are opaque, but report their size
+	switch class := obj.ClassName(); {
+	case class == "ArrayBuffer":
+		return wordSize + uint64(len(obj.Export().(goja.ArrayBuffer).Bytes()))
+	case class != "Array" && strings.HasSuffix(class, "Array"):
+		return wordSize + uint64(obj.Get("byteLength").ToInteger())
+	}
+	size := uint64(wordSize)
+	for _, key := range obj.Keys() {
+		size += wordSize + uint64(len(key)) + jsSize(obj.Get(key), seen, bound)
+		if size > bound {
+			break
+		}
+	}
+	return size
+}
+
+// isLimitError reports whether the error was caused by exceeding a resource
+// limit rather than by the tracer itself.
+func isLimitError(err error) bool {
+	return err == errTimeLimit || err == errStepLimit || err == errMemoryLimit
+}
+
+// markTruncated marks a tracer result as truncated. Objects get an additional
+// truncated field holding the reason, other results are wrapped into an object.
+func markTruncated(result json.RawMessage, reason string) (json.RawMessage, error) {
+	marker, err := json.Marshal(reason)
+	if err != nil {
+		return nil, err
+	}
+	result = bytes.TrimSpace(result)
+	if len(result) < 2 || result[0] != '{' {
+		return json.Marshal(struct {
+			Result    json.RawMessage `json:"result"`
+			Truncated string          `json:"truncated"`
+		}{result, reason})
+	}
+	// Append the field to the object, retaining the order of the others
+	marked := append([]byte{}, result[:len(result)-1]...)
+	if len(bytes.TrimSpace(result[1:len(result)-1])) > 0 {
+		marked = append(marked, ',')
+	}
+	marked = append(marked, `"truncated":`...)
+	marked = append(marked, marker...)
+	return append(marked, '}'), nil
+}
@@ -317,3 +317,60 @@ func TestSetup(t *testing.T) {
 		t.Errorf("tracer returned wrong result. have: %s, want: \"bar\"\n", string(have))
 	}
 }
+
+func TestLimits(t *testing.T) {
+	// Contract looping until it runs out of gas
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x0, byte(vm.JUMP)}
+
+	tests := []struct {
+		limits   Limits
+		contract []byte
+		code     string
+		want     string
+	}{
+		{ // Limits not hit
+			limits: Limits{Timeout: time.Second, Steps: 10, Memory: 1024 * 1024},
+			code:   "{steps: 0, step: function() { this.steps++ }, fault: function() {}, result: function() { return this.steps }}",
+			want:   "3",
+		},
+		{
+			limits:   Limits{Steps: 10},
+			contract: loop,
+			code:     "{steps: 0, step: function() { this.steps++ }, fault: function() {}, result: function(ctx) { return {steps: this.steps, reason: ctx.truncated} }}",
+			want:     `{"steps":10,"reason":"step limit exceeded","truncated":"step limit exceeded"}`,
+		},
+		{
+			limits:   Limits{Timeout: 50 * time.Millisecond},
+			contract: loop,
+			code:     "{steps: 0, step: function() { if (++this.steps == 3) { while (true) {} } }, fault: function() {}, result: function() { return this.steps }}",
+			want:     `{"result":3,"truncated":"time limit exceeded"}`,
+		},
+		{
+			limits:   Limits{Memory: 64 * 1024},
+			contract: loop,
+			code:     "{data: [], step: function() { this.data.push('0123456789abcdef') }, fault: function() {}, result: function() { return this.data.length }}",
+			want:     `{"result":2048,"truncated":"memory limit exceeded"}`,
+		},
+	}
+	for i, tt := range tests {
+		SetLimits(tt.limits)
+		tracer, err := newJsTracer(tt.code, nil, nil)
+		SetLimits(Limits{})
+		if err != nil {
+			t.Fatalf("test %d: failed to create tracer: %v", i, err)
+		}
+		have, err := runTrace(tracer, testCtx(), params.TestChainConfig, tt.contract)
+		if err != nil {
+			t.Fatalf("test %d: trace failed: %v", i, err)
+		}
+		if string(have) != tt.want {
+			t.Errorf("test %d: have result %s, want %s", i, have, tt.want)
+		}
+	}
+	// Tracers hanging during construction are stopped too
+	SetLimits(Limits{Timeout: 50 * time.Millisecond})
+	defer SetLimits(Limits{})
+	if _, err := newJsTracer("(function() { while (true) {} })()", nil, nil); err != errTimeLimit {
+		t.Errorf("hanging construction: have error %v, want %v", err, errTimeLimit)
+	}
+}
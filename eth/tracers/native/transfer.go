@@ -0,0 +1,260 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("transferTracer", newTransferTracer, false)
+}
+
+var (
+	// Transfer(address,address,uint256) of ERC-20 and ERC-721, the latter with
+	// the token ID indexed
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// TransferSingle(address,address,address,uint256,uint256) of ERC-1155
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+
+	// TransferBatch(address,address,address,uint256[],uint256[]) of ERC-1155
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// Kinds of transfers reported by the transfer tracer.
+const (
+	transferEther   = "ether"
+	transferERC20   = "erc20"
+	transferERC721  = "erc721"
+	transferERC1155 = "erc1155"
+)
+
+// transfer is a movement of ether or tokens between two accounts.
+type transfer struct {
+	Kind    string          `json:"type"`
+	Token   *common.Address `json:"token,omitempty"` // Token contract, nil for ether
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Value   *hexutil.Big    `json:"value"`             // Amount transferred, 1 for ERC-721 tokens
+	TokenID *hexutil.Big    `json:"tokenId,omitempty"` // Token ID of ERC-721 and ERC-1155 tokens
+}
+
+// transferTracer collects the ether and token transfers made by a transaction
+// in execution order: ether sent along with calls, contract creations and self
+// destructs, and the ERC-20, ERC-721 and ERC-1155 transfer events. Transfers
+// of call frames that failed are omitted, as are the transaction fees.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "transferTracer"})
+//	[{
+//	  type: "ether",
+//	  from: "0x...",
+//	  to: "0x...",
+//	  value: "0xde0b6b3a7640000"
+//	}, {
+//	  type: "erc20",
+//	  token: "0x...",
+//	  from: "0x...",
+//	  to: "0x...",
+//	  value: "0x5f5e100"
+//	}]
+type transferTracer struct {
+	noopTracer
+	transfers []*transfer
+	frames    []int // Index of the first transfer of each active call frame
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newTransferTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &transferTracer{transfers: []*transfer{}}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *transferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, len(t.transfers))
+	t.addEther(from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *transferTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, len(t.transfers))
+
+	// Delegate calls carry the value of their parent, and call code sends the
+	// value to the caller itself
+	if typ == vm.CALL || typ == vm.CREATE || typ == vm.CREATE2 || typ == vm.SELFDESTRUCT {
+		t.addEther(from, to, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *transferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+// exit leaves the current call frame, dropping its transfers if it failed.
+func (t *transferTracer) exit(err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if err != nil {
+		t.transfers = t.transfers[:start]
+	}
+}
+
+// addEther records an ether transfer, if any value is sent.
+func (t *transferTracer) addEther(from, to common.Address, value *big.Int) {
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	t.transfers = append(t.transfers, &transfer{
+		Kind:  transferEther,
+		From:  from,
+		To:    to,
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *transferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	// All transfer events have at least the sender and recipient indexed
+	if op != vm.LOG3 && op != vm.LOG4 {
+		return
+	}
+	var (
+		stack  = scope.Stack
+		topics = make([]common.Hash, int(op-vm.LOG0))
+	)
+	for i := range topics {
+		topics[i] = common.Hash(stack.Back(2 + i).Bytes32())
+	}
+	// Only read the memory of transfer events, whose data is small
+	if topics[0] != transferTopic && topics[0] != transferSingleTopic && topics[0] != transferBatchTopic {
+		return
+	}
+	offset, size := stack.Back(0), stack.Back(1)
+	if !offset.IsUint64() || !size.IsUint64() {
+		return
+	}
+	data := scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+	t.transfers = append(t.transfers, decodeTransfers(scope.Contract.Address(), topics, data)...)
+}
+
+// decodeTransfers decodes the token transfers of a log, returning nil if the log
+// is not a well-formed transfer event.
+func decodeTransfers(token common.Address, topics []common.Hash, data []byte) []*transfer {
+	var (
+		from = common.BytesToAddress(topics[1][:])
+		to   = common.BytesToAddress(topics[2][:])
+	)
+	newTransfer := func(kind string, value, id *big.Int) *transfer {
+		return &transfer{Kind: kind, Token: &token, From: from, To: to, Value: (*hexutil.Big)(value), TokenID: (*hexutil.Big)(id)}
+	}
+	switch {
+	case topics[0] == transferTopic && len(topics) == 3 && len(data) == 32:
+		return []*transfer{newTransfer(transferERC20, new(big.Int).SetBytes(data), nil)}
+
+	case topics[0] == transferTopic && len(topics) == 4 && len(data) == 0:
+		return []*transfer{newTransfer(transferERC721, big.NewInt(1), topics[3].Big())}
+
+	case topics[0] == transferSingleTopic && len(topics) == 4 && len(data) == 64:
+		from, to = common.BytesToAddress(topics[2][:]), common.BytesToAddress(topics[3][:])
+		return []*transfer{newTransfer(transferERC1155, new(big.Int).SetBytes(data[32:]), new(big.Int).SetBytes(data[:32]))}
+
+	case topics[0] == transferBatchTopic && len(topics) == 4:
+		from, to = common.BytesToAddress(topics[2][:]), common.BytesToAddress(topics[3][:])
+		ids, ok := decodeWordArray(data, 0)
+		if !ok {
+			return nil
+		}
+		values, ok := decodeWordArray(data, 32)
+		if !ok || len(values) != len(ids) {
+			return nil
+		}
+		transfers := make([]*transfer, len(ids))
+		for i := range ids {
+			transfers[i] = newTransfer(transferERC1155, values[i], ids[i])
+		}
+		return transfers
+	}
+	return nil
+}
+
+// decodeWordArray decodes an ABI encoded uint256[] whose offset is stored at the
+// given position of the data.
+func decodeWordArray(data []byte, pos int) ([]*big.Int, bool) {
+	word := func(at uint64) (*big.Int, bool) {
+		if at+32 > uint64(len(data)) {
+			return nil, false
+		}
+		return new(big.Int).SetBytes(data[at : at+32]), true
+	}
+	offset, ok := word(uint64(pos))
+	if !ok || !offset.IsUint64() {
+		return nil, false
+	}
+	length, ok := word(offset.Uint64())
+	if !ok || !length.IsUint64() || length.Uint64() > uint64(len(data))/32 {
+		return nil, false
+	}
+	words := make([]*big.Int, length.Uint64())
+	for i := range words {
+		if words[i], ok = word(offset.Uint64() + 32*uint64(i+1)); !ok {
+			return nil, false
+		}
+	}
+	return words, true
+}
+
+// GetResult returns the json-encoded list of transfers, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *transferTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.transfers)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *transferTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("watchTracer", newWatchTracer, false)
+}
+
+// watchpoint is a storage slot watched by the watch tracer.
+type watchpoint struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+}
+
+type watchTracerConfig struct {
+	Slots []watchpoint `json:"slots"` // Storage slots to report the accesses of
+}
+
+// watchAccess is a read or write of a watched storage slot.
+type watchAccess struct {
+	Op       vm.OpCode       `json:"-"`
+	Address  common.Address  `json:"address"`
+	Slot     common.Hash     `json:"slot"`
+	Value    common.Hash     `json:"value"`          // Value read or written
+	Prev     *common.Hash    `json:"prev,omitempty"` // Value before a write
+	Code     *common.Address `json:"code,omitempty"` // Contract whose code accessed the slot, if delegated
+	Caller   common.Address  `json:"caller"`
+	PC       uint64          `json:"pc"`
+	Depth    int             `json:"depth"`
+	Reverted bool            `json:"reverted,omitempty"` // Whether the frame of a write reverted
+}
+
+// MarshalJSON implements json.Marshaler, encoding the opcode as its name.
+func (a *watchAccess) MarshalJSON() ([]byte, error) {
+	type access watchAccess
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*access
+	}{a.Op.String(), (*access)(a)})
+}
+
+// watchTracer reports the reads and writes of a set of storage slots during the
+// execution of a transaction, along with the contract code and call frame that
+// accessed them.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "watchTracer", tracerConfig: {slots: [{address: "0x...", slot: "0x0"}]}})
+//	[{
+//	  type: "SSTORE",
+//	  address: "0x...",
+//	  slot: "0x0000000000000000000000000000000000000000000000000000000000000000",
+//	  value: "0x0000000000000000000000000000000000000000000000000000000000000001",
+//	  prev: "0x0000000000000000000000000000000000000000000000000000000000000000",
+//	  caller: "0x...",
+//	  pc: 42,
+//	  depth: 1
+//	}]
+type watchTracer struct {
+	noopTracer
+	env      *vm.EVM
+	watched  map[common.Address]map[common.Hash]bool
+	accesses []*watchAccess
+	frames   []int // Index of the first access of each active call frame
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newWatchTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config watchTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Slots) == 0 {
+		return nil, errors.New("no storage slots to watch")
+	}
+	t := &watchTracer{
+		watched:  make(map[common.Address]map[common.Hash]bool),
+		accesses: []*watchAccess{},
+	}
+	for _, w := range config.Slots {
+		if t.watched[w.Address] == nil {
+			t.watched[w.Address] = make(map[common.Hash]bool)
+		}
+		t.watched[w.Address][w.Slot] = true
+	}
+	return t, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *watchTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.frames = append(t.frames, len(t.accesses))
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *watchTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *watchTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, len(t.accesses))
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *watchTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+// exit leaves the current call frame, marking its writes reverted if it failed.
+func (t *watchTracer) exit(err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if err == nil {
+		return
+	}
+	for _, access := range t.accesses[start:] {
+		if access.Op == vm.SSTORE {
+			access.Reverted = true
+		}
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *watchTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	if op != vm.SLOAD && op != vm.SSTORE {
+		return
+	}
+	var (
+		addr = scope.Contract.Address()
+		slot = common.Hash(scope.Stack.Back(0).Bytes32())
+	)
+	if !t.watched[addr][slot] {
+		return
+	}
+	access := &watchAccess{
+		Op:      op,
+		Address: addr,
+		Slot:    slot,
+		Caller:  scope.Contract.Caller(),
+		PC:      pc,
+		Depth:   depth,
+	}
+	if code := scope.Contract.CodeAddr; code != nil && *code != addr {
+		access.Code = code
+	}
+	current := t.env.StateDB.GetState(addr, slot)
+	if op == vm.SLOAD {
+		access.Value = current
+	} else {
+		access.Value = common.Hash(scope.Stack.Back(1).Bytes32())
+		access.Prev = &current
+	}
+	t.accesses = append(t.accesses, access)
+}
+
+// GetResult returns the json-encoded list of storage accesses, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *watchTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.accesses)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *watchTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Kinds of transaction propagation events.
+const (
+	TxAnnouncement = "announcement" // Transaction hash announced by the peer
+	TxBroadcast    = "broadcast"    // Transaction broadcast in full by the peer
+	TxDelivery     = "delivery"     // Transaction delivered by the peer upon request
+)
+
+// TxPropagation is a single transaction announcement or transfer received from
+// a remote peer.
+type TxPropagation struct {
+	Peer string      `json:"peer"` // ID of the remote peer
+	Kind string      `json:"kind"` // Kind of the event: announcement, broadcast or delivery
+	Hash common.Hash `json:"hash"` // Hash of the transaction
+	Time time.Time   `json:"time"` // Local time the packet was received at
+}
+
+// SubscribeTxPropagation subscribes to the transaction announcements and
+// transfers received from remote peers. Every packet is delivered as a batch of
+// events, one for each transaction in it, before the transactions are processed.
+func (h *handler) SubscribeTxPropagation(ch chan<- []TxPropagation) event.Subscription {
+	return h.txPropScope.Track(h.txPropFeed.Subscribe(ch))
+}
+
+// notifyTxPropagation posts a transaction propagation event for every hash in
+// a packet received from a peer, if anyone is listening.
+func (h *handler) notifyTxPropagation(peer *eth.Peer, kind string, hashes []common.Hash) {
+	if h.txPropScope.Count() == 0 || len(hashes) == 0 {
+		return
+	}
+	var (
+		now    = time.Now()
+		id     = peer.ID()
+		events = make([]TxPropagation, len(hashes))
+	)
+	for i, hash := range hashes {
+		events[i] = TxPropagation{Peer: id, Kind: kind, Hash: hash, Time: now}
+	}
+	h.txPropFeed.Send(events)
+}
+
+// notifyTxTransfer posts a transaction propagation event for every transaction
+// in a packet received from a peer, if anyone is listening.
+func (h *handler) notifyTxTransfer(peer *eth.Peer, kind string, txs []*types.Transaction) {
+	if h.txPropScope.Count() == 0 {
+		return
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	h.notifyTxPropagation(peer, kind, hashes)
+}
+
+// TxPropagation creates a subscription streaming every transaction announcement
+// and transfer received from remote peers, along with the peer and the time of
+// arrival. It allows measuring propagation latencies across the network without
+// resorting to packet captures.
+func (api *DebugAPI) TxPropagation(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan []TxPropagation, 128)
+		sub := api.eth.handler.SubscribeTxPropagation(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case events := <-events:
+				for _, event := range events {
+					notifier.Notify(rpcSub.ID, event)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
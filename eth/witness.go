@@ -0,0 +1,130 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ExecutionWitness is everything needed to execute a block without access to
+// the chain state: the parts of the pre-state it accesses, proven against the
+// state root of the parent, and the ancestor headers whose hashes it accesses.
+type ExecutionWitness struct {
+	Headers []*types.Header                  `json:"headers"` // Parent and ancestors down to the oldest one accessed
+	Codes   []hexutil.Bytes                  `json:"codes"`   // Code of the accessed contracts
+	State   []hexutil.Bytes                  `json:"state"`   // Trie nodes proving the accessed accounts and slots
+	Keys    map[common.Address][]common.Hash `json:"keys"`    // Accessed accounts with their accessed storage slots
+}
+
+// ExecutionWitness re-executes the given block on the state of its parent and
+// returns the execution witness of the block. The state of the parent must be
+// available.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*ExecutionWitness, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not executed")
+	}
+	chain := api.eth.blockchain
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent #%d not found", block.NumberU64()-1)
+	}
+	// Execute the block on the parent state without snapshot, so that every
+	// access goes through the recorded tries
+	db := state.NewWitnessDatabase(chain.StateCache())
+	statedb, err := state.New(parent.Root, db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("state of #%d not available: %v", parent.Number, err)
+	}
+	recorder := &blockHashRecorder{parent: parent.Number.Uint64(), oldest: parent.Number.Uint64()}
+	if _, _, _, err := chain.Processor().Process(block, statedb, vm.Config{Debug: true, Tracer: recorder}); err != nil {
+		return nil, err
+	}
+	if root := statedb.IntermediateRoot(chain.Config().IsEIP158(block.Number())); root != block.Root() {
+		return nil, fmt.Errorf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	witness, err := db.Witness(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	result := &ExecutionWitness{
+		Codes: make([]hexutil.Bytes, len(witness.Codes)),
+		State: make([]hexutil.Bytes, len(witness.Nodes)),
+		Keys:  witness.Accounts,
+	}
+	for i, code := range witness.Codes {
+		result.Codes[i] = code
+	}
+	for i, node := range witness.Nodes {
+		result.State[i] = node
+	}
+	for header := parent; ; {
+		result.Headers = append(result.Headers, header)
+		if header.Number.Uint64() <= recorder.oldest {
+			break
+		}
+		if header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1); header == nil {
+			return nil, errors.New("ancestor header not found")
+		}
+	}
+	return result, nil
+}
+
+// blockHashRecorder is an EVM logger tracking the oldest block whose hash is
+// accessed by the BLOCKHASH opcode.
+type blockHashRecorder struct {
+	parent uint64 // Number of the parent of the executed block
+	oldest uint64 // Oldest block whose hash is accessed, at most the parent
+}
+
+func (r *blockHashRecorder) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if op != vm.BLOCKHASH || err != nil {
+		return
+	}
+	// Only the 256 most recent blocks are accessible, the rest is zero anyway
+	number := scope.Stack.Back(0)
+	if number.IsUint64() && number.Uint64() < r.oldest && number.Uint64()+255 >= r.parent {
+		r.oldest = number.Uint64()
+	}
+}
+
+func (r *blockHashRecorder) CaptureTxStart(gasLimit uint64) {}
+func (r *blockHashRecorder) CaptureTxEnd(restGas uint64)    {}
+func (r *blockHashRecorder) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+func (r *blockHashRecorder) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+func (r *blockHashRecorder) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (r *blockHashRecorder) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (r *blockHashRecorder) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
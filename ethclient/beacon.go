@@ -0,0 +1,128 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ErrBeaconRootNotFound is returned by BeaconRoot if the beacon roots contract
+// holds no root for the requested timestamp, either because no block was built
+// at that time or because the root was already overwritten.
+var ErrBeaconRootNotFound = errors.New("beacon root not found")
+
+// WithdrawalsByBlock returns the withdrawals of the block with the given number,
+// checked against the withdrawals root in the block header. If number is nil,
+// the withdrawals of the latest known block are returned. Blocks before the
+// Shanghai fork have no withdrawals, in which case nil is returned.
+func (ec *Client) WithdrawalsByBlock(ctx context.Context, number *big.Int) (types.Withdrawals, error) {
+	var raw json.RawMessage
+	if err := ec.call(ctx, &raw, "eth_getBlockByNumber", toBlockNumArg(number), false); err != nil {
+		return nil, err
+	}
+	var head *types.Header
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ethereum.NotFound
+	}
+	var body rpcBlock
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	if err := verifyWithdrawals(head, body.Withdrawals); err != nil {
+		return nil, err
+	}
+	return body.Withdrawals, nil
+}
+
+// verifyWithdrawals checks the withdrawals against the root committed to in
+// the header.
+func verifyWithdrawals(head *types.Header, withdrawals types.Withdrawals) error {
+	if head.WithdrawalsHash == nil {
+		if len(withdrawals) > 0 {
+			return fmt.Errorf("server returned withdrawals but block header indicates none")
+		}
+		return nil
+	}
+	if root := types.DeriveSha(withdrawals, trie.NewStackTrie(nil)); root != *head.WithdrawalsHash {
+		return fmt.Errorf("withdrawals root mismatch: have %x, want %x", root, *head.WithdrawalsHash)
+	}
+	return nil
+}
+
+// BeaconRoot returns the parent beacon block root stored by the EIP-4788 beacon
+// roots contract for the given timestamp, as of the block with the given number.
+// If number is nil, the state of the latest known block is used. Only the roots
+// of the last HistoricalRootsModulus timestamps are retained by the contract.
+func (ec *Client) BeaconRoot(ctx context.Context, timestamp uint64, number *big.Int) (common.Hash, error) {
+	// Empty slots would match a zero timestamp, which the contract rejects too
+	if timestamp == 0 {
+		return common.Hash{}, ErrBeaconRootNotFound
+	}
+	timeSlot, rootSlot := beaconRootSlots(timestamp)
+
+	var stored, root hexutil.Bytes
+	reqs := []rpc.BatchElem{
+		{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{params.BeaconRootsStorageAddress, timeSlot, toBlockNumArg(number)},
+			Result: &stored,
+		},
+		{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{params.BeaconRootsStorageAddress, rootSlot, toBlockNumArg(number)},
+			Result: &root,
+		},
+	}
+	if err := ec.batchCall(ctx, reqs); err != nil {
+		return common.Hash{}, err
+	}
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			return common.Hash{}, reqs[i].Error
+		}
+	}
+	// The timestamp slot is overwritten along with the root, a mismatch means
+	// the root belongs to a different timestamp.
+	if new(big.Int).SetBytes(stored).Cmp(new(big.Int).SetUint64(timestamp)) != 0 {
+		return common.Hash{}, ErrBeaconRootNotFound
+	}
+	return common.BytesToHash(root), nil
+}
+
+// beaconRootSlots returns the storage slots of the beacon roots contract holding
+// the timestamp and the root for the given timestamp.
+func beaconRootSlots(timestamp uint64) (timeSlot, rootSlot common.Hash) {
+	index := timestamp % params.HistoricalRootsModulus
+	timeSlot = common.BigToHash(new(big.Int).SetUint64(index))
+	rootSlot = common.BigToHash(new(big.Int).SetUint64(index + params.HistoricalRootsModulus))
+	return timeSlot, rootSlot
+}
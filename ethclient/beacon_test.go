@@ -0,0 +1,93 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The beacon roots contract in the test genesis holds a root for its timestamp.
+const testBeaconTime = 9000
+
+var (
+	testBeaconRoot                         = common.HexToHash("0xbeac")
+	testBeaconTimeSlot, testBeaconRootSlot = beaconRootSlots(testBeaconTime)
+)
+
+func testWithdrawals(t *testing.T, client *rpc.Client) {
+	ec := NewClient(client)
+
+	// The test chain predates Shanghai
+	withdrawals, err := ec.WithdrawalsByBlock(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("withdrawals query failed: %v", err)
+	}
+	if withdrawals != nil {
+		t.Fatalf("unexpected withdrawals: %v", withdrawals)
+	}
+	if _, err := ec.WithdrawalsByBlock(context.Background(), big.NewInt(1000)); err != ethereum.NotFound {
+		t.Fatalf("error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+}
+
+func testBeaconRootAt(t *testing.T, client *rpc.Client) {
+	ec := NewClient(client)
+
+	root, err := ec.BeaconRoot(context.Background(), testBeaconTime, nil)
+	if err != nil {
+		t.Fatalf("beacon root query failed: %v", err)
+	}
+	if root != testBeaconRoot {
+		t.Fatalf("beacon root mismatch: have %x, want %x", root, testBeaconRoot)
+	}
+	// The same slots are used one buffer length later, with a different timestamp
+	for _, timestamp := range []uint64{0, testBeaconTime + 1, testBeaconTime + params.HistoricalRootsModulus} {
+		if _, err := ec.BeaconRoot(context.Background(), timestamp, nil); err != ErrBeaconRootNotFound {
+			t.Errorf("timestamp %d: error mismatch: have %v, want %v", timestamp, err, ErrBeaconRootNotFound)
+		}
+	}
+}
+
+func TestVerifyWithdrawals(t *testing.T) {
+	withdrawals := types.Withdrawals{
+		{Index: 0, Validator: 1, Address: common.Address{0xaa}, Amount: 32},
+		{Index: 1, Validator: 2, Address: common.Address{0xbb}, Amount: 64},
+	}
+	root := types.DeriveSha(withdrawals, trie.NewStackTrie(nil))
+
+	if err := verifyWithdrawals(&types.Header{WithdrawalsHash: &root}, withdrawals); err != nil {
+		t.Fatalf("valid withdrawals rejected: %v", err)
+	}
+	if err := verifyWithdrawals(&types.Header{WithdrawalsHash: &root}, withdrawals[:1]); err == nil {
+		t.Error("omitted withdrawal accepted")
+	}
+	if err := verifyWithdrawals(&types.Header{}, withdrawals); err == nil {
+		t.Error("withdrawals accepted in a pre-Shanghai block")
+	}
+	if err := verifyWithdrawals(&types.Header{}, nil); err != nil {
+		t.Errorf("pre-Shanghai block rejected: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Capability is an optional feature an RPC provider may or may not offer.
+type Capability string
+
+const (
+	CapDebug               Capability = "debug"                  // debug namespace, e.g. debug_traceTransaction
+	CapTrace               Capability = "trace"                  // OpenEthereum style trace namespace
+	CapBlockReceipts       Capability = "eth_getBlockReceipts"   // all receipts of a block in one call
+	CapFeeHistoryRewards   Capability = "eth_feeHistory"         // fee history with reward percentiles
+	CapPendingTransactions Capability = "newPendingTransactions" // pending transaction subscriptions
+)
+
+// Capabilities lists all the capabilities probed by DetectCapabilities.
+var Capabilities = []Capability{CapDebug, CapTrace, CapBlockReceipts, CapFeeHistoryRewards, CapPendingTransactions}
+
+// methodNotFoundCode is the JSON-RPC error code of calls to unknown methods.
+const methodNotFoundCode = -32601
+
+// errUnknownCapability is returned when probing an unknown capability.
+var errUnknownCapability = errors.New("unknown capability")
+
+// Supports reports whether the provider offers the given capability. The first
+// query of a capability probes the provider, the outcome of which is cached for
+// the lifetime of the client. Probes failing due to connectivity problems are
+// not cached and report the capability as unsupported.
+func (ec *Client) Supports(ctx context.Context, capability Capability) bool {
+	ec.capsLock.Lock()
+	supported, known := ec.caps[capability]
+	ec.capsLock.Unlock()
+
+	if known {
+		return supported
+	}
+	supported, err := ec.probe(ctx, capability)
+	if err != nil {
+		return false
+	}
+	ec.capsLock.Lock()
+	if ec.caps == nil {
+		ec.caps = make(map[Capability]bool)
+	}
+	ec.caps[capability] = supported
+	ec.capsLock.Unlock()
+
+	return supported
+}
+
+// DetectCapabilities probes all known capabilities of the provider, typically
+// right after connecting, and returns the supported ones. An error is returned
+// if any probe fails due to connectivity problems.
+func (ec *Client) DetectCapabilities(ctx context.Context) ([]Capability, error) {
+	var supported []Capability
+	for _, capability := range Capabilities {
+		ok, err := ec.probe(ctx, capability)
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe %s: %w", capability, err)
+		}
+		ec.capsLock.Lock()
+		if ec.caps == nil {
+			ec.caps = make(map[Capability]bool)
+		}
+		ec.caps[capability] = ok
+		ec.capsLock.Unlock()
+
+		if ok {
+			supported = append(supported, capability)
+		}
+	}
+	return supported, nil
+}
+
+// probe queries the provider for a capability. Methods are called with cheap
+// arguments which may well be rejected, only a method-not-found error denoting
+// a missing capability. Any other error from the provider counts as support,
+// whereas transport errors are returned.
+func (ec *Client) probe(ctx context.Context, capability Capability) (bool, error) {
+	switch capability {
+	case CapDebug:
+		return ec.probeMethod(ctx, "debug_traceTransaction", common.Hash{})
+	case CapTrace:
+		return ec.probeMethod(ctx, "trace_transaction", common.Hash{})
+	case CapBlockReceipts:
+		return ec.probeMethod(ctx, "eth_getBlockReceipts", "earliest")
+	case CapFeeHistoryRewards:
+		var res feeHistoryResultMarshaling
+		err := ec.call(ctx, &res, "eth_feeHistory", hexutil.Uint(1), "latest", []float64{50})
+		if err != nil {
+			return methodSupported(err)
+		}
+		return len(res.Reward) > 0, nil
+	case CapPendingTransactions:
+		sub, err := ec.subscribe(ctx, make(chan common.Hash), "newPendingTransactions")
+		if err != nil {
+			if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+				return false, nil
+			}
+			return methodSupported(err)
+		}
+		sub.Unsubscribe()
+		return true, nil
+	default:
+		return false, errUnknownCapability
+	}
+}
+
+// probeMethod calls a method to find out whether the provider implements it.
+func (ec *Client) probeMethod(ctx context.Context, method string, args ...interface{}) (bool, error) {
+	var res interface{}
+	if err := ec.call(ctx, &res, method, args...); err != nil {
+		return methodSupported(err)
+	}
+	return true, nil
+}
+
+// methodSupported interprets a failed probe call. Errors reported by the
+// provider denote support unless the method is not found, other errors are
+// returned as is.
+func methodSupported(err error) (bool, error) {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() != methodNotFoundCode, nil
+	}
+	return false, err
+}
+
+// BlockReceipts returns the receipts of all transactions in the block with the
+// given hash. Providers supporting eth_getBlockReceipts are queried in a single
+// call, otherwise the receipts are retrieved in a batch.
+func (ec *Client) BlockReceipts(ctx context.Context, hash common.Hash) ([]*types.Receipt, error) {
+	if ec.Supports(ctx, CapBlockReceipts) {
+		var receipts []*types.Receipt
+		if err := ec.call(ctx, &receipts, "eth_getBlockReceipts", hash); err != nil {
+			return nil, err
+		}
+		return receipts, nil
+	}
+	block, err := ec.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txs      = block.Transactions()
+		receipts = make([]*types.Receipt, len(txs))
+		reqs     = make([]rpc.BatchElem, len(txs))
+	)
+	for i, tx := range txs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{tx.Hash()},
+			Result: &receipts[i],
+		}
+	}
+	if err := ec.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, req.Error
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("receipt of transaction %x missing", txs[i].Hash())
+		}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package deposit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// contractABI is the subset of the deposit contract ABI used by the binding.
+const contractABI = `[
+	{"name":"deposit","type":"function","stateMutability":"payable","inputs":[{"name":"pubkey","type":"bytes"},{"name":"withdrawal_credentials","type":"bytes"},{"name":"signature","type":"bytes"},{"name":"deposit_data_root","type":"bytes32"}],"outputs":[]},
+	{"name":"get_deposit_root","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+	{"name":"get_deposit_count","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes"}]}
+]`
+
+// Contract is a binding to the beacon chain deposit contract.
+type Contract struct {
+	contract    *bind.BoundContract
+	forkVersion [4]byte
+}
+
+// NewContract binds the deposit contract at the given address. Deposits are
+// verified against the genesis fork version of the network before submission.
+func NewContract(address common.Address, forkVersion [4]byte, backend bind.ContractBackend) (*Contract, error) {
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, err
+	}
+	return &Contract{
+		contract:    bind.NewBoundContract(address, parsed, backend, backend, backend),
+		forkVersion: forkVersion,
+	}, nil
+}
+
+// Deposit submits the deposit to the contract, attaching the deposit amount as
+// value. The deposit is rejected before submission if its signature does not
+// verify, as the beacon chain would ignore it while still burning the funds.
+func (c *Contract) Deposit(opts *bind.TransactOpts, d *Data) (*types.Transaction, error) {
+	if d.Amount < MinAmount {
+		return nil, fmt.Errorf("deposit amount %d gwei below minimum %d gwei", d.Amount, uint64(MinAmount))
+	}
+	if err := d.Verify(c.forkVersion); err != nil {
+		return nil, err
+	}
+	value := new(big.Int).Mul(new(big.Int).SetUint64(d.Amount), big.NewInt(params.GWei))
+	if opts.Value != nil && opts.Value.Cmp(value) != 0 {
+		return nil, fmt.Errorf("transaction value %v does not match deposit amount %v", opts.Value, value)
+	}
+	txOpts := *opts
+	txOpts.Value = value
+	return c.contract.Transact(&txOpts, "deposit", d.Pubkey[:], d.WithdrawalCredentials[:], d.Signature[:], d.Root())
+}
+
+// DepositRoot returns the root of the deposit Merkle tree, mixed in with the
+// number of deposits.
+func (c *Contract) DepositRoot(opts *bind.CallOpts) (common.Hash, error) {
+	var out []interface{}
+	if err := c.contract.Call(opts, &out, "get_deposit_root"); err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(*abi.ConvertType(out[0], new([32]byte)).(*[32]byte)), nil
+}
+
+// DepositCount returns the number of deposits made to the contract.
+func (c *Contract) DepositCount(opts *bind.CallOpts) (uint64, error) {
+	var out []interface{}
+	if err := c.contract.Call(opts, &out, "get_deposit_count"); err != nil {
+		return 0, err
+	}
+	count := *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+	if len(count) != 8 {
+		return 0, fmt.Errorf("invalid deposit count length %d", len(count))
+	}
+	return binary.LittleEndian.Uint64(count), nil
+}
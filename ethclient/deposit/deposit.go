@@ -0,0 +1,186 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package deposit implements the construction, verification and submission of
+// beacon chain deposits through the deposit contract.
+package deposit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// Deposit contract addresses and genesis fork versions of the known networks.
+var (
+	MainnetContract = common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
+	GoerliContract  = common.HexToAddress("0xff50ed3d0ec03aC01D4C79aAd74928BFF48a7b2b")
+	SepoliaContract = common.HexToAddress("0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D")
+
+	MainnetForkVersion = [4]byte{0x00, 0x00, 0x00, 0x00}
+	GoerliForkVersion  = [4]byte{0x00, 0x00, 0x10, 0x20}
+	SepoliaForkVersion = [4]byte{0x90, 0x00, 0x00, 0x69}
+)
+
+// MinAmount is the smallest deposit accepted by the deposit contract, in gwei.
+const MinAmount = 1_000_000_000
+
+var (
+	// domainDeposit is the signature domain type of deposits.
+	domainDeposit = [4]byte{0x03, 0x00, 0x00, 0x00}
+
+	// signatureDST is the domain separation tag of the proof of possession
+	// ciphersuite used by the beacon chain.
+	signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+var (
+	errInvalidPubkey    = errors.New("invalid deposit public key")
+	errInvalidSignature = errors.New("invalid deposit signature")
+)
+
+// Data is the deposit data submitted to the deposit contract.
+type Data struct {
+	Pubkey                [48]byte
+	WithdrawalCredentials [32]byte
+	Amount                uint64 // Deposit amount in gwei
+	Signature             [96]byte
+}
+
+// BLSWithdrawalCredentials returns the withdrawal credentials committing to a
+// BLS withdrawal key.
+func BLSWithdrawalCredentials(pubkey [48]byte) [32]byte {
+	creds := sha256.Sum256(pubkey[:])
+	creds[0] = 0x00
+	return creds
+}
+
+// ExecutionWithdrawalCredentials returns the withdrawal credentials paying out
+// to an execution layer address.
+func ExecutionWithdrawalCredentials(addr common.Address) [32]byte {
+	var creds [32]byte
+	creds[0] = 0x01
+	copy(creds[12:], addr[:])
+	return creds
+}
+
+// MessageRoot returns the SSZ hash tree root of the deposit message, i.e. the
+// deposit data without its signature.
+func (d *Data) MessageRoot() [32]byte {
+	return hashPair(hashPair(d.pubkeyRoot(), d.WithdrawalCredentials), hashPair(d.amountLeaf(), [32]byte{}))
+}
+
+// Root returns the SSZ hash tree root of the deposit data, which has to be
+// submitted to the deposit contract along with the data.
+func (d *Data) Root() [32]byte {
+	return hashPair(hashPair(d.pubkeyRoot(), d.WithdrawalCredentials), hashPair(d.amountLeaf(), d.signatureRoot()))
+}
+
+// SigningRoot returns the root signed by the deposit signature on the network
+// with the given genesis fork version.
+func (d *Data) SigningRoot(forkVersion [4]byte) [32]byte {
+	return hashPair(d.MessageRoot(), ComputeDomain(forkVersion))
+}
+
+// Sign derives the public key from the given BLS secret key and signs the
+// deposit for the network with the given genesis fork version.
+func (d *Data) Sign(secret *big.Int, forkVersion [4]byte) error {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	pubkey := g1.MulScalar(g1.New(), g1.One(), secret)
+	if g1.IsZero(pubkey) {
+		return errInvalidPubkey
+	}
+	copy(d.Pubkey[:], g1.ToCompressed(pubkey))
+
+	root := d.SigningRoot(forkVersion)
+	msg, err := g2.HashToCurve(root[:], signatureDST)
+	if err != nil {
+		return err
+	}
+	copy(d.Signature[:], g2.ToCompressed(g2.MulScalar(g2.New(), msg, secret)))
+	return nil
+}
+
+// Verify checks the deposit signature against the public key on the network
+// with the given genesis fork version. Deposits with an invalid signature are
+// accepted by the deposit contract, but ignored by the beacon chain.
+func (d *Data) Verify(forkVersion [4]byte) error {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	pubkey, err := g1.FromCompressed(d.Pubkey[:])
+	if err != nil || g1.IsZero(pubkey) {
+		return errInvalidPubkey
+	}
+	sig, err := g2.FromCompressed(d.Signature[:])
+	if err != nil {
+		return errInvalidSignature
+	}
+	root := d.SigningRoot(forkVersion)
+	msg, err := g2.HashToCurve(root[:], signatureDST)
+	if err != nil {
+		return err
+	}
+	// e(pubkey, H(m)) == e(G1, sig)
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(pubkey, msg)
+	engine.AddPairInv(g1.One(), sig)
+	if !engine.Check() {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// ComputeDomain returns the deposit signature domain of the network with the
+// given genesis fork version. Deposits are valid across forks, so the domain
+// is computed with an empty genesis validators root.
+func ComputeDomain(forkVersion [4]byte) [32]byte {
+	var version [32]byte
+	copy(version[:], forkVersion[:])
+	forkDataRoot := hashPair(version, [32]byte{})
+
+	var domain [32]byte
+	copy(domain[:], domainDeposit[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain
+}
+
+func (d *Data) pubkeyRoot() [32]byte {
+	var a, b [32]byte
+	copy(a[:], d.Pubkey[:32])
+	copy(b[:], d.Pubkey[32:])
+	return hashPair(a, b)
+}
+
+func (d *Data) signatureRoot() [32]byte {
+	var a, b, c [32]byte
+	copy(a[:], d.Signature[:32])
+	copy(b[:], d.Signature[32:64])
+	copy(c[:], d.Signature[64:])
+	return hashPair(hashPair(a, b), hashPair(c, [32]byte{}))
+}
+
+func (d *Data) amountLeaf() [32]byte {
+	var leaf [32]byte
+	binary.LittleEndian.PutUint64(leaf[:], d.Amount)
+	return leaf
+}
+
+func hashPair(a, b [32]byte) [32]byte {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
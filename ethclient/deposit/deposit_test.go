@@ -0,0 +1,160 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package deposit
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func newTestDeposit(t *testing.T, forkVersion [4]byte) *Data {
+	d := &Data{
+		WithdrawalCredentials: ExecutionWithdrawalCredentials(common.HexToAddress("0x1234")),
+		Amount:                32 * MinAmount,
+	}
+	if err := d.Sign(big.NewInt(0x2a2a2a), forkVersion); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestVerify(t *testing.T) {
+	d := newTestDeposit(t, GoerliForkVersion)
+	if err := d.Verify(GoerliForkVersion); err != nil {
+		t.Fatalf("valid deposit rejected: %v", err)
+	}
+	if err := d.Verify(MainnetForkVersion); err == nil {
+		t.Error("deposit verified on the wrong network")
+	}
+	tampered := *d
+	tampered.Amount++
+	if err := tampered.Verify(GoerliForkVersion); err == nil {
+		t.Error("tampered amount accepted")
+	}
+	tampered = *d
+	tampered.Signature[95] ^= 0x01
+	if err := tampered.Verify(GoerliForkVersion); err == nil {
+		t.Error("tampered signature accepted")
+	}
+	tampered = *d
+	tampered.Pubkey = [48]byte{0xc0}
+	if err := tampered.Verify(GoerliForkVersion); err == nil {
+		t.Error("public key at infinity accepted")
+	}
+}
+
+func TestRoots(t *testing.T) {
+	d := newTestDeposit(t, MainnetForkVersion)
+	if d.Root() == d.MessageRoot() {
+		t.Error("signature not committed to in the deposit data root")
+	}
+	// The signing root must not depend on the signature
+	signed := d.SigningRoot(MainnetForkVersion)
+	d.Signature = [96]byte{}
+	if d.SigningRoot(MainnetForkVersion) != signed {
+		t.Error("signing root depends on the signature")
+	}
+	// Mainnet deposit domain, as used by the deposit CLI
+	want := common.FromHex("03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9")
+	if domain := ComputeDomain(MainnetForkVersion); !bytes.Equal(domain[:], want) {
+		t.Errorf("mainnet domain mismatch: have %x, want %x", domain, want)
+	}
+}
+
+func TestWithdrawalCredentials(t *testing.T) {
+	creds := ExecutionWithdrawalCredentials(common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"))
+	if want := common.FromHex("0x01000000000000000000000000000000219ab540356cbb839cbe05303d7705fa"); !bytes.Equal(creds[:], want) {
+		t.Errorf("execution credentials mismatch: have %x, want %x", creds, want)
+	}
+	if creds := BLSWithdrawalCredentials([48]byte{0x01}); creds[0] != 0x00 {
+		t.Errorf("invalid BLS credentials prefix %#x", creds[0])
+	}
+}
+
+// returnCode returns contract code returning the given data on any call.
+func returnCode(data []byte) []byte {
+	code := []byte{
+		byte(0x60), byte(len(data)), 0x60, 0x0c, 0x60, 0x00, 0x39, // CODECOPY(0, 12, len)
+		byte(0x60), byte(len(data)), 0x60, 0x00, 0xf3, // RETURN(0, len)
+	}
+	return append(code, data...)
+}
+
+func TestContract(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		root     = common.HexToHash("0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e")
+		count    = common.FromHex("0000000000000000000000000000000000000000000000000000000000000020" + "0000000000000000000000000000000000000000000000000000000000000008" + "0500000000000000000000000000000000000000000000000000000000000000")
+		rootAddr = common.HexToAddress("0x1001")
+		cntAddr  = common.HexToAddress("0x1002")
+		sinkAddr = common.HexToAddress("0x1003")
+	)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr:     {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		rootAddr: {Code: returnCode(root[:]), Balance: new(big.Int)},
+		cntAddr:  {Code: returnCode(count), Balance: new(big.Int)},
+		sinkAddr: {Code: []byte{0x00}, Balance: new(big.Int)},
+	}, 10000000)
+	defer sim.Close()
+
+	c, _ := NewContract(rootAddr, MainnetForkVersion, sim)
+	if have, err := c.DepositRoot(nil); err != nil || have != root {
+		t.Errorf("deposit root mismatch: have %x (%v), want %x", have, err, root)
+	}
+	c, _ = NewContract(cntAddr, MainnetForkVersion, sim)
+	if have, err := c.DepositCount(nil); err != nil || have != 5 {
+		t.Errorf("deposit count mismatch: have %d (%v), want 5", have, err)
+	}
+	// Submit a deposit to a contract accepting any call
+	c, _ = NewContract(sinkAddr, MainnetForkVersion, sim)
+	auth, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+
+	d := newTestDeposit(t, MainnetForkVersion)
+	if _, err := c.Deposit(auth, &Data{Amount: MinAmount - 1}); err == nil {
+		t.Error("deposit below minimum accepted")
+	}
+	if _, err := c.Deposit(auth, newTestDeposit(t, GoerliForkVersion)); err == nil {
+		t.Error("deposit for the wrong network accepted")
+	}
+	tx, err := c.Deposit(auth, d)
+	if err != nil {
+		t.Fatalf("deposit failed: %v", err)
+	}
+	sim.Commit()
+
+	if want := new(big.Int).Mul(big.NewInt(32), big.NewInt(params.Ether)); tx.Value().Cmp(want) != 0 {
+		t.Errorf("deposit value mismatch: have %v, want %v", tx.Value(), want)
+	}
+	dataRoot := d.Root()
+	if !bytes.Equal(tx.Data()[4+3*32:4+4*32], dataRoot[:]) {
+		t.Error("deposit data root missing from the call data")
+	}
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil || receipt.Status != 1 {
+		t.Errorf("deposit transaction failed: %v", err)
+	}
+}
@@ -23,17 +23,30 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
+	"sync"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
 )
 
 // Client defines typed wrappers for the Ethereum RPC API.
 type Client struct {
 	c *rpc.Client
+
+	caps     map[Capability]bool // Cached capabilities of the provider
+	capsLock sync.Mutex
+
+	lenient       LenientMode // Nonstandard number encodings accepted from the provider
+	lenientLock   sync.Mutex
+	lenientWarned sync.Map // Methods and fields whose nonstandard numbers were logged
+
+	limiter     *rate.Limiter // Limiter of the requests to the provider, if any
+	limiterLock sync.Mutex
 }
 
 // Dial connects a client to the given URL.
@@ -51,7 +64,7 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client) *Client {
-	return &Client{c}
+	return &Client{c: c}
 }
 
 func (ec *Client) Close() {
@@ -63,7 +76,7 @@ func (ec *Client) Close() {
 // ChainID retrieves the current chain ID for transaction replay protection.
 func (ec *Client) ChainID(ctx context.Context) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_chainId")
+	err := ec.call(ctx, &result, "eth_chainId")
 	if err != nil {
 		return nil, err
 	}
@@ -90,14 +103,14 @@ func (ec *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Bl
 // BlockNumber returns the most recent block number
 func (ec *Client) BlockNumber(ctx context.Context) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_blockNumber")
+	err := ec.call(ctx, &result, "eth_blockNumber")
 	return uint64(result), err
 }
 
 // PeerCount returns the number of p2p peers as reported by the net_peerCount method.
 func (ec *Client) PeerCount(ctx context.Context) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "net_peerCount")
+	err := ec.call(ctx, &result, "net_peerCount")
 	return uint64(result), err
 }
 
@@ -110,7 +123,7 @@ type rpcBlock struct {
 
 func (ec *Client) getBlock(ctx context.Context, method string, args ...interface{}) (*types.Block, error) {
 	var raw json.RawMessage
-	err := ec.c.CallContext(ctx, &raw, method, args...)
+	err := ec.call(ctx, &raw, method, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +167,7 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 				Result: &uncles[i],
 			}
 		}
-		if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
+		if err := ec.batchCall(ctx, reqs); err != nil {
 			return nil, err
 		}
 		for i := range reqs {
@@ -180,7 +193,7 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 // HeaderByHash returns the block header with the given hash.
 func (ec *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
 	var head *types.Header
-	err := ec.c.CallContext(ctx, &head, "eth_getBlockByHash", hash, false)
+	err := ec.call(ctx, &head, "eth_getBlockByHash", hash, false)
 	if err == nil && head == nil {
 		err = ethereum.NotFound
 	}
@@ -191,7 +204,7 @@ func (ec *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.He
 // nil, the latest known header is returned.
 func (ec *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	var head *types.Header
-	err := ec.c.CallContext(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false)
+	err := ec.call(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false)
 	if err == nil && head == nil {
 		err = ethereum.NotFound
 	}
@@ -219,7 +232,25 @@ func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
 // TransactionByHash returns the transaction with the given hash.
 func (ec *Client) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
 	var json *rpcTransaction
-	err = ec.c.CallContext(ctx, &json, "eth_getTransactionByHash", hash)
+	err = ec.call(ctx, &json, "eth_getTransactionByHash", hash)
+	if err != nil {
+		return nil, false, err
+	} else if json == nil {
+		return nil, false, ethereum.NotFound
+	} else if _, r, _ := json.tx.RawSignatureValues(); r == nil {
+		return nil, false, fmt.Errorf("server returned transaction without signature")
+	}
+	if json.From != nil && json.BlockHash != nil {
+		setSenderFromServer(json.tx, *json.From, *json.BlockHash)
+	}
+	return json.tx, json.BlockNumber == nil, nil
+}
+
+// TransactionBySenderAndNonce returns the transaction sent by the given account with
+// the given nonce. Included transactions are returned in preference to pending ones.
+func (ec *Client) TransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (tx *types.Transaction, isPending bool, err error) {
+	var json *rpcTransaction
+	err = ec.call(ctx, &json, "eth_getTransactionBySenderAndNonce", sender, hexutil.Uint64(nonce))
 	if err != nil {
 		return nil, false, err
 	} else if json == nil {
@@ -251,7 +282,7 @@ func (ec *Client) TransactionSender(ctx context.Context, tx *types.Transaction,
 		Hash common.Hash
 		From common.Address
 	}
-	if err = ec.c.CallContext(ctx, &meta, "eth_getTransactionByBlockHashAndIndex", block, hexutil.Uint64(index)); err != nil {
+	if err = ec.call(ctx, &meta, "eth_getTransactionByBlockHashAndIndex", block, hexutil.Uint64(index)); err != nil {
 		return common.Address{}, err
 	}
 	if meta.Hash == (common.Hash{}) || meta.Hash != tx.Hash() {
@@ -263,14 +294,14 @@ func (ec *Client) TransactionSender(ctx context.Context, tx *types.Transaction,
 // TransactionCount returns the total number of transactions in the given block.
 func (ec *Client) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
 	var num hexutil.Uint
-	err := ec.c.CallContext(ctx, &num, "eth_getBlockTransactionCountByHash", blockHash)
+	err := ec.call(ctx, &num, "eth_getBlockTransactionCountByHash", blockHash)
 	return uint(num), err
 }
 
 // TransactionInBlock returns a single transaction at index in the given block.
 func (ec *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
 	var json *rpcTransaction
-	err := ec.c.CallContext(ctx, &json, "eth_getTransactionByBlockHashAndIndex", blockHash, hexutil.Uint64(index))
+	err := ec.call(ctx, &json, "eth_getTransactionByBlockHashAndIndex", blockHash, hexutil.Uint64(index))
 	if err != nil {
 		return nil, err
 	}
@@ -289,7 +320,7 @@ func (ec *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash,
 // Note that the receipt is not available for pending transactions.
 func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	var r *types.Receipt
-	err := ec.c.CallContext(ctx, &r, "eth_getTransactionReceipt", txHash)
+	err := ec.call(ctx, &r, "eth_getTransactionReceipt", txHash)
 	if err == nil {
 		if r == nil {
 			return nil, ethereum.NotFound
@@ -302,7 +333,7 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 // no sync currently running, it returns nil.
 func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
 	var raw json.RawMessage
-	if err := ec.c.CallContext(ctx, &raw, "eth_syncing"); err != nil {
+	if err := ec.call(ctx, &raw, "eth_syncing"); err != nil {
 		return nil, err
 	}
 	// Handle the possible response types
@@ -320,7 +351,7 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 // SubscribeNewHead subscribes to notifications about the current blockchain head
 // on the given channel.
 func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
-	return ec.c.EthSubscribe(ctx, ch, "newHeads")
+	return ec.subscribe(ctx, ch, "newHeads")
 }
 
 // State Access
@@ -329,7 +360,7 @@ func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header)
 func (ec *Client) NetworkID(ctx context.Context) (*big.Int, error) {
 	version := new(big.Int)
 	var ver string
-	if err := ec.c.CallContext(ctx, &ver, "net_version"); err != nil {
+	if err := ec.call(ctx, &ver, "net_version"); err != nil {
 		return nil, err
 	}
 	if _, ok := version.SetString(ver, 10); !ok {
@@ -342,15 +373,71 @@ func (ec *Client) NetworkID(ctx context.Context) (*big.Int, error) {
 // The block number can be nil, in which case the balance is taken from the latest known block.
 func (ec *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, toBlockNumArg(blockNumber))
+	err := ec.call(ctx, &result, "eth_getBalance", account, toBlockNumArg(blockNumber))
 	return (*big.Int)(&result), err
 }
 
+// BalancesAt returns the wei balances of the given accounts, in the order of the
+// accounts. The block number can be nil, in which case the balances are taken from
+// the latest known block.
+func (ec *Client) BalancesAt(ctx context.Context, accounts []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	var result []*hexutil.Big
+	if err := ec.call(ctx, &result, "eth_getBalances", accounts, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if len(result) != len(accounts) {
+		return nil, fmt.Errorf("server returned %d balances for %d accounts", len(result), len(accounts))
+	}
+	balances := make([]*big.Int, len(result))
+	for i, balance := range result {
+		balances[i] = (*big.Int)(balance)
+	}
+	return balances, nil
+}
+
+// Account is the state of an account as returned by AccountAt.
+type Account struct {
+	Balance     *big.Int
+	Nonce       uint64
+	CodeHash    common.Hash
+	StorageRoot common.Hash
+}
+
+type rpcAccount struct {
+	Balance     *hexutil.Big   `json:"balance"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+}
+
+// AccountAt returns the balance, nonce, code hash and storage root of the given
+// account in a single call. The block number can be nil, in which case the account
+// is taken from the latest known block.
+func (ec *Client) AccountAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*Account, error) {
+	return ec.getAccount(ctx, account, toBlockNumArg(blockNumber))
+}
+
+func (ec *Client) getAccount(ctx context.Context, account common.Address, block string) (*Account, error) {
+	var result *rpcAccount
+	if err := ec.call(ctx, &result, "eth_getAccount", account, block); err != nil {
+		return nil, err
+	}
+	if result == nil || result.Balance == nil {
+		return nil, ethereum.NotFound
+	}
+	return &Account{
+		Balance:     (*big.Int)(result.Balance),
+		Nonce:       uint64(result.Nonce),
+		CodeHash:    result.CodeHash,
+		StorageRoot: result.StorageRoot,
+	}, nil
+}
+
 // StorageAt returns the value of key in the contract storage of the given account.
 // The block number can be nil, in which case the value is taken from the latest known block.
 func (ec *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
+	err := ec.call(ctx, &result, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
 	return result, err
 }
 
@@ -358,15 +445,99 @@ func (ec *Client) StorageAt(ctx context.Context, account common.Address, key com
 // The block number can be nil, in which case the code is taken from the latest known block.
 func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getCode", account, toBlockNumArg(blockNumber))
+	err := ec.call(ctx, &result, "eth_getCode", account, toBlockNumArg(blockNumber))
 	return result, err
 }
 
+// codeProbeBatchSize is the maximum number of accounts probed in a single
+// batch request by CodeHashes.
+const codeProbeBatchSize = 256
+
+// accountProbe is the subset of the eth_getProof response needed to check for
+// code and storage existence without transferring the bytecode itself.
+type accountProbe struct {
+	CodeHash    common.Hash `json:"codeHash"`
+	StorageHash common.Hash `json:"storageHash"`
+}
+
+// HasCode reports whether the given account has contract code deployed. The
+// block number can be nil, in which case the latest known block is used.
+func (ec *Client) HasCode(ctx context.Context, account common.Address, blockNumber *big.Int) (bool, error) {
+	hashes, err := ec.CodeHashes(ctx, []common.Address{account}, blockNumber)
+	if err != nil {
+		return false, err
+	}
+	return hashes[0] != types.EmptyCodeHash, nil
+}
+
+// CodeHashes retrieves the code hashes of the given accounts using batched
+// requests, without fetching the bytecode itself. Accounts without code (or
+// non-existent ones) report types.EmptyCodeHash. The block number can be nil,
+// in which case the latest known block is used.
+func (ec *Client) CodeHashes(ctx context.Context, accounts []common.Address, blockNumber *big.Int) ([]common.Hash, error) {
+	probes, err := ec.probeAccounts(ctx, accounts, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]common.Hash, len(probes))
+	for i, probe := range probes {
+		hashes[i] = probe.CodeHash
+		if hashes[i] == (common.Hash{}) {
+			hashes[i] = types.EmptyCodeHash
+		}
+	}
+	return hashes, nil
+}
+
+// HasStorage reports whether the given account has any non-empty storage
+// slots. The block number can be nil, in which case the latest known block is
+// used.
+func (ec *Client) HasStorage(ctx context.Context, account common.Address, blockNumber *big.Int) (bool, error) {
+	probes, err := ec.probeAccounts(ctx, []common.Address{account}, blockNumber)
+	if err != nil {
+		return false, err
+	}
+	root := probes[0].StorageHash
+	return root != types.EmptyRootHash && root != (common.Hash{}), nil
+}
+
+// probeAccounts retrieves the code and storage hashes of the given accounts
+// via eth_getProof, splitting the accounts into bounded batches.
+func (ec *Client) probeAccounts(ctx context.Context, accounts []common.Address, blockNumber *big.Int) ([]accountProbe, error) {
+	var (
+		block  = toBlockNumArg(blockNumber)
+		probes = make([]accountProbe, len(accounts))
+	)
+	for start := 0; start < len(accounts); start += codeProbeBatchSize {
+		end := start + codeProbeBatchSize
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		reqs := make([]rpc.BatchElem, end-start)
+		for i := range reqs {
+			reqs[i] = rpc.BatchElem{
+				Method: "eth_getProof",
+				Args:   []interface{}{accounts[start+i], []string{}, block},
+				Result: &probes[start+i],
+			}
+		}
+		if err := ec.batchCall(ctx, reqs); err != nil {
+			return nil, err
+		}
+		for i := range reqs {
+			if reqs[i].Error != nil {
+				return nil, fmt.Errorf("failed to probe account %x: %w", accounts[start+i], reqs[i].Error)
+			}
+		}
+	}
+	return probes, nil
+}
+
 // NonceAt returns the account nonce of the given account.
 // The block number can be nil, in which case the nonce is taken from the latest known block.
 func (ec *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
+	err := ec.call(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
 	return uint64(result), err
 }
 
@@ -379,8 +550,15 @@ func (ec *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]typ
 	if err != nil {
 		return nil, err
 	}
-	err = ec.c.CallContext(ctx, &result, "eth_getLogs", arg)
-	return result, err
+	if err = ec.call(ctx, &result, "eth_getLogs", arg); err != nil {
+		return nil, err
+	}
+	if q.Verified {
+		if err := ec.verifyLogs(ctx, q, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 // SubscribeFilterLogs subscribes to the results of a streaming filter query.
@@ -389,7 +567,19 @@ func (ec *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuer
 	if err != nil {
 		return nil, err
 	}
-	return ec.c.EthSubscribe(ctx, ch, "logs", arg)
+	return ec.subscribe(ctx, ch, "logs", arg)
+}
+
+// SubscribeLogTail subscribes to the logs matching the filter query, starting
+// with the historical logs from its FromBlock and continuing with the logs of new
+// blocks, without gaps or duplicates in between. Logs of blocks removed by a reorg
+// are delivered again with Removed set. The query's ToBlock must be nil.
+func (ec *Client) SubscribeLogTail(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	return ec.subscribe(ctx, ch, "logTail", arg)
 }
 
 func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
@@ -418,21 +608,27 @@ func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
 // PendingBalanceAt returns the wei balance of the given account in the pending state.
 func (ec *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, "pending")
+	err := ec.call(ctx, &result, "eth_getBalance", account, "pending")
 	return (*big.Int)(&result), err
 }
 
+// PendingAccountAt returns the balance, nonce, code hash and storage root of the
+// given account in the pending state.
+func (ec *Client) PendingAccountAt(ctx context.Context, account common.Address) (*Account, error) {
+	return ec.getAccount(ctx, account, "pending")
+}
+
 // PendingStorageAt returns the value of key in the contract storage of the given account in the pending state.
 func (ec *Client) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getStorageAt", account, key, "pending")
+	err := ec.call(ctx, &result, "eth_getStorageAt", account, key, "pending")
 	return result, err
 }
 
 // PendingCodeAt returns the contract code of the given account in the pending state.
 func (ec *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getCode", account, "pending")
+	err := ec.call(ctx, &result, "eth_getCode", account, "pending")
 	return result, err
 }
 
@@ -440,14 +636,14 @@ func (ec *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]
 // This is the nonce that should be used for the next transaction.
 func (ec *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, "pending")
+	err := ec.call(ctx, &result, "eth_getTransactionCount", account, "pending")
 	return uint64(result), err
 }
 
 // PendingTransactionCount returns the total number of transactions in the pending state.
 func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 	var num hexutil.Uint
-	err := ec.c.CallContext(ctx, &num, "eth_getBlockTransactionCountByNumber", "pending")
+	err := ec.call(ctx, &num, "eth_getBlockTransactionCountByNumber", "pending")
 	return uint(num), err
 }
 
@@ -461,7 +657,7 @@ func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 // blocks might not be available.
 func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	var hex hexutil.Bytes
-	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+	err := ec.call(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
 	if err != nil {
 		return nil, err
 	}
@@ -472,7 +668,7 @@ func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockN
 // the block by block hash instead of block height.
 func (ec *Client) CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
 	var hex hexutil.Bytes
-	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), rpc.BlockNumberOrHashWithHash(blockHash, false))
+	err := ec.call(ctx, &hex, "eth_call", toCallArg(msg), rpc.BlockNumberOrHashWithHash(blockHash, false))
 	if err != nil {
 		return nil, err
 	}
@@ -483,7 +679,7 @@ func (ec *Client) CallContractAtHash(ctx context.Context, msg ethereum.CallMsg,
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
 	var hex hexutil.Bytes
-	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), "pending")
+	err := ec.call(ctx, &hex, "eth_call", toCallArg(msg), "pending")
 	if err != nil {
 		return nil, err
 	}
@@ -494,7 +690,7 @@ func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg)
 // execution of a transaction.
 func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	var hex hexutil.Big
-	if err := ec.c.CallContext(ctx, &hex, "eth_gasPrice"); err != nil {
+	if err := ec.call(ctx, &hex, "eth_gasPrice"); err != nil {
 		return nil, err
 	}
 	return (*big.Int)(&hex), nil
@@ -504,7 +700,7 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 // allow a timely execution of a transaction.
 func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	var hex hexutil.Big
-	if err := ec.c.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
+	if err := ec.call(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
 		return nil, err
 	}
 	return (*big.Int)(&hex), nil
@@ -515,12 +711,15 @@ type feeHistoryResultMarshaling struct {
 	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
 	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
 	GasUsedRatio []float64        `json:"gasUsedRatio"`
+
+	BlobBaseFee      []*hexutil.Big `json:"baseFeePerBlobGas,omitempty"`
+	BlobGasUsedRatio []float64      `json:"blobGasUsedRatio,omitempty"`
 }
 
 // FeeHistory retrieves the fee market history.
 func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
 	var res feeHistoryResultMarshaling
-	if err := ec.c.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
+	if err := ec.call(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
 		return nil, err
 	}
 	reward := make([][]*big.Int, len(res.Reward))
@@ -534,21 +733,64 @@ func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *
 	for i, b := range res.BaseFee {
 		baseFee[i] = (*big.Int)(b)
 	}
+	var blobBaseFee []*big.Int
+	if len(res.BlobBaseFee) > 0 {
+		blobBaseFee = make([]*big.Int, len(res.BlobBaseFee))
+		for i, b := range res.BlobBaseFee {
+			blobBaseFee[i] = (*big.Int)(b)
+		}
+	}
 	return &ethereum.FeeHistory{
-		OldestBlock:  (*big.Int)(res.OldestBlock),
-		Reward:       reward,
-		BaseFee:      baseFee,
-		GasUsedRatio: res.GasUsedRatio,
+		OldestBlock:      (*big.Int)(res.OldestBlock),
+		Reward:           reward,
+		BaseFee:          baseFee,
+		GasUsedRatio:     res.GasUsedRatio,
+		BlobBaseFee:      blobBaseFee,
+		BlobGasUsedRatio: res.BlobGasUsedRatio,
 	}, nil
 }
 
+// MedianTip returns the median of the priority fee rewards reported for the
+// given percentile index across all blocks of a fee history. Blocks without
+// reward data (e.g. empty blocks) are skipped. Nil is returned if there are
+// no rewards at the requested index.
+func MedianTip(history *ethereum.FeeHistory, percentile int) *big.Int {
+	var tips []*big.Int
+	for _, rewards := range history.Reward {
+		if percentile < len(rewards) && rewards[percentile] != nil {
+			tips = append(tips, rewards[percentile])
+		}
+	}
+	if len(tips) == 0 {
+		return nil
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return new(big.Int).Set(tips[len(tips)/2])
+}
+
+// SuggestGasTipCapFromHistory retrieves the fee history of the last blocks and
+// returns the median of the requested reward percentile across them. Contrary
+// to SuggestGasTipCap, the aggregation is done client side, which allows using
+// a custom block window and percentile.
+func (ec *Client) SuggestGasTipCapFromHistory(ctx context.Context, blocks uint64, percentile float64) (*big.Int, error) {
+	history, err := ec.FeeHistory(ctx, blocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, err
+	}
+	tip := MedianTip(history, 0)
+	if tip == nil {
+		return ec.SuggestGasTipCap(ctx)
+	}
+	return tip, nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the backend blockchain. There is no guarantee that this is
 // the true gas limit requirement as other transactions may be added or removed by miners,
 // but it should provide a basis for setting a reasonable default.
 func (ec *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
 	var hex hexutil.Uint64
-	err := ec.c.CallContext(ctx, &hex, "eth_estimateGas", toCallArg(msg))
+	err := ec.call(ctx, &hex, "eth_estimateGas", toCallArg(msg))
 	if err != nil {
 		return 0, err
 	}
@@ -564,7 +806,7 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	if err != nil {
 		return err
 	}
-	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
+	return ec.call(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
 }
 
 func toBlockNumArg(number *big.Int) string {
@@ -30,10 +30,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -187,8 +189,17 @@ var (
 )
 
 var genesis = &core.Genesis{
-	Config:    params.AllEthashProtocolChanges,
-	Alloc:     core.GenesisAlloc{testAddr: {Balance: testBalance}},
+	Config: params.AllEthashProtocolChanges,
+	Alloc: core.GenesisAlloc{
+		testAddr: {Balance: testBalance},
+		params.BeaconRootsStorageAddress: {
+			Balance: common.Big0,
+			Storage: map[common.Hash]common.Hash{
+				testBeaconTimeSlot: common.BigToHash(big.NewInt(testBeaconTime)),
+				testBeaconRootSlot: testBeaconRoot,
+			},
+		},
+	},
 	ExtraData: []byte("test genesis"),
 	Timestamp: 9000,
 	BaseFee:   big.NewInt(params.InitialBaseFee),
@@ -220,12 +231,18 @@ func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 		t.Fatalf("can't create new node: %v", err)
 	}
 	// Create Ethereum Service
-	config := &ethconfig.Config{Genesis: genesis}
+	config := &ethconfig.Config{Genesis: genesis, TxSenderIndex: true}
 	config.Ethash.PowMode = ethash.ModeFake
 	ethservice, err := eth.New(n, config)
 	if err != nil {
 		t.Fatalf("can't create new ethereum service: %v", err)
 	}
+	filterSystem := filters.NewFilterSystem(ethservice.APIBackend, filters.Config{})
+	n.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   filters.NewFilterAPI(filterSystem, false),
+	}})
+
 	// Import the test chain.
 	if err := n.Start(); err != nil {
 		t.Fatalf("can't start test node: %v", err)
@@ -289,6 +306,30 @@ func TestEthClient(t *testing.T) {
 		"TransactionSender": {
 			func(t *testing.T) { testTransactionSender(t, client) },
 		},
+		"TransactionBySenderAndNonce": {
+			func(t *testing.T) { testTransactionBySenderAndNonce(t, client) },
+		},
+		"Withdrawals": {
+			func(t *testing.T) { testWithdrawals(t, client) },
+		},
+		"BeaconRoot": {
+			func(t *testing.T) { testBeaconRootAt(t, client) },
+		},
+		"NormalizedBlock": {
+			func(t *testing.T) { testNormalizedBlock(t, chain, client) },
+		},
+		"InclusionProof": {
+			func(t *testing.T) { testInclusionProof(t, chain, client) },
+		},
+		"VerifiedFilterLogs": {
+			func(t *testing.T) { testVerifiedFilterLogs(t, chain, client) },
+		},
+		"Capabilities": {
+			func(t *testing.T) { testCapabilities(t, chain, client) },
+		},
+		"CheckChain": {
+			func(t *testing.T) { testCheckChain(t, chain, client) },
+		},
 	}
 
 	t.Parallel()
@@ -528,6 +569,26 @@ func testStatusFunctions(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func TestMedianTip(t *testing.T) {
+	history := &ethereum.FeeHistory{
+		Reward: [][]*big.Int{
+			{big.NewInt(3), big.NewInt(30)},
+			{},
+			{big.NewInt(1), big.NewInt(10)},
+			{big.NewInt(2), big.NewInt(20)},
+		},
+	}
+	if tip := MedianTip(history, 0); tip.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("median tip mismatch: have %v, want 2", tip)
+	}
+	if tip := MedianTip(history, 1); tip.Cmp(big.NewInt(20)) != 0 {
+		t.Errorf("median tip mismatch: have %v, want 20", tip)
+	}
+	if tip := MedianTip(history, 2); tip != nil {
+		t.Errorf("expected nil tip for missing percentile, have %v", tip)
+	}
+}
+
 func testCallContractAtHash(t *testing.T, client *rpc.Client) {
 	ec := NewClient(client)
 
@@ -609,6 +670,35 @@ func testAtFunctions(t *testing.T, client *rpc.Client) {
 	if balance.Cmp(penBalance) == 0 {
 		t.Fatalf("unexpected balance: %v %v", balance, penBalance)
 	}
+	balances, err := ec.BalancesAt(context.Background(), []common.Address{{0xff}, testAddr, {2}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(balances) != 3 || balances[0].Sign() != 0 || balances[1].Cmp(balance) != 0 || balances[2].Cmp(big.NewInt(20)) != 0 {
+		t.Fatalf("unexpected balances: %v", balances)
+	}
+	// AccountAt
+	acc, err := ec.AccountAt(context.Background(), testAddr, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.Balance.Cmp(balance) != 0 || acc.CodeHash != types.EmptyCodeHash || acc.StorageRoot != types.EmptyRootHash {
+		t.Fatalf("unexpected account: %+v", acc)
+	}
+	penAcc, err := ec.PendingAccountAt(context.Background(), testAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if penAcc.Balance.Cmp(penBalance) != 0 || penAcc.Nonce != acc.Nonce+1 {
+		t.Fatalf("unexpected pending account: %+v", penAcc)
+	}
+	missing, err := ec.AccountAt(context.Background(), common.Address{0xff}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if missing.Balance.Sign() != 0 || missing.Nonce != 0 || missing.CodeHash != types.EmptyCodeHash || missing.StorageRoot != types.EmptyRootHash {
+		t.Fatalf("unexpected missing account: %+v", missing)
+	}
 	// NonceAt
 	nonce, err := ec.NonceAt(context.Background(), testAddr, nil)
 	if err != nil {
@@ -645,6 +735,28 @@ func testAtFunctions(t *testing.T, client *rpc.Client) {
 	if !bytes.Equal(code, penCode) {
 		t.Fatalf("unexpected code: %v %v", code, penCode)
 	}
+	// Code and storage probes
+	hasCode, err := ec.HasCode(context.Background(), testAddr, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasCode {
+		t.Fatalf("unexpected code reported for externally owned account")
+	}
+	hashes, err := ec.CodeHashes(context.Background(), []common.Address{testAddr, {0xff}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0] != types.EmptyCodeHash || hashes[1] != types.EmptyCodeHash {
+		t.Fatalf("unexpected code hashes: %v", hashes)
+	}
+	hasStorage, err := ec.HasStorage(context.Background(), testAddr, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasStorage {
+		t.Fatalf("unexpected storage reported for externally owned account")
+	}
 }
 
 func testTransactionSender(t *testing.T, client *rpc.Client) {
@@ -687,6 +799,22 @@ func testTransactionSender(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testTransactionBySenderAndNonce(t *testing.T, client *rpc.Client) {
+	ec := NewClient(client)
+	ctx := context.Background()
+
+	tx, pending, err := ec.TransactionBySenderAndNonce(ctx, testAddr, testTx2.Nonce())
+	if err != nil {
+		t.Fatal("can't get tx:", err)
+	}
+	if tx.Hash() != testTx2.Hash() || pending {
+		t.Fatalf("wrong tx %v (pending %v), want %v", tx.Hash(), pending, testTx2.Hash())
+	}
+	if _, _, err := ec.TransactionBySenderAndNonce(ctx, testAddr, 100); err != ethereum.NotFound {
+		t.Fatalf("unknown nonce error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+}
+
 func sendTransaction(ec *Client) error {
 	chainID, err := ec.ChainID(context.Background())
 	if err != nil {
@@ -710,3 +838,59 @@ func sendTransaction(ec *Client) error {
 	}
 	return ec.SendTransaction(context.Background(), tx)
 }
+
+func testCapabilities(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	supported, err := ec.DetectCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("failed to detect capabilities: %v", err)
+	}
+	// The test node registers neither the tracers nor eth_getBlockReceipts
+	want := []Capability{CapFeeHistoryRewards, CapPendingTransactions}
+	if !reflect.DeepEqual(supported, want) {
+		t.Fatalf("capabilities mismatch: have %v, want %v", supported, want)
+	}
+	if ec.Supports(context.Background(), CapTrace) {
+		t.Fatalf("trace namespace reported as supported")
+	}
+	// Receipts must be retrievable without eth_getBlockReceipts
+	receipts, err := ec.BlockReceipts(context.Background(), chain[2].Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve block receipts: %v", err)
+	}
+	if len(receipts) != 2 || receipts[0].TxHash != testTx1.Hash() || receipts[1].TxHash != testTx2.Hash() {
+		t.Fatalf("block receipts mismatch: %v", receipts)
+	}
+}
+
+func testCheckChain(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+	ctx := context.Background()
+
+	head := chain[len(chain)-1].Header()
+	id, err := ec.ForkID(ctx, genesis.Config)
+	if err != nil {
+		t.Fatalf("failed to compute fork ID: %v", err)
+	}
+	if want := forkid.NewID(genesis.Config, chain[0].Hash(), head.Number.Uint64(), head.Time); id != want {
+		t.Fatalf("fork ID mismatch: have %v, want %v", id, want)
+	}
+	if err := ec.CheckChain(ctx, genesis.Config, chain[0].Hash()); err != nil {
+		t.Fatalf("matching chain rejected: %v", err)
+	}
+	if err := ec.CheckChain(ctx, genesis.Config, common.Hash{1}); !errors.Is(err, ErrGenesisMismatch) {
+		t.Fatalf("genesis mismatch error: have %v, want %v", err, ErrGenesisMismatch)
+	}
+	config := *genesis.Config
+	config.ChainID = big.NewInt(1)
+	if err := ec.CheckChain(ctx, &config, chain[0].Hash()); !errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("chain ID mismatch error: have %v, want %v", err, ErrChainIDMismatch)
+	}
+	// A fork passed locally but unknown to the provider must be detected
+	config = *genesis.Config
+	config.GrayGlacierBlock = big.NewInt(1)
+	if err := ec.CheckChain(ctx, &config, chain[0].Hash()); !errors.Is(err, forkid.ErrRemoteStale) {
+		t.Fatalf("fork schedule mismatch error: have %v, want %v", err, forkid.ErrRemoteStale)
+	}
+}
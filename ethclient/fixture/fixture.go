@@ -0,0 +1,360 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package fixture records the JSON-RPC traffic of HTTP clients into fixture
+// files and replays it offline, making tests against live endpoints hermetic.
+//
+// Tests typically dial the fixture in replay mode, and re-record it against a
+// live endpoint on demand:
+//
+//	client, closeFn, err := fixture.Dial(ctx, "testdata/balance.json", os.Getenv("RPC_URL"), apiKey)
+//	defer closeFn()
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// redacted replaces the secrets in recorded fixtures.
+const redacted = "REDACTED"
+
+// Fixture is a recorded sequence of JSON-RPC exchanges.
+type Fixture struct {
+	URL          string        `json:"url"` // Sanitized endpoint the fixture was recorded from
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single HTTP exchange, containing a JSON-RPC request or
+// batch and the corresponding response.
+type Interaction struct {
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Load reads a fixture file.
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := new(Fixture)
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Save writes the fixture to a file.
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Recorder is a http.RoundTripper forwarding requests to the next transport,
+// recording all exchanges. Secrets, e.g. API keys embedded in the endpoint URL
+// or in request parameters, are redacted from the recording.
+type Recorder struct {
+	next    http.RoundTripper
+	secrets []string
+
+	lock    sync.Mutex
+	fixture Fixture
+}
+
+// NewRecorder creates a recorder on top of the given transport. If next is nil,
+// http.DefaultTransport is used.
+func NewRecorder(next http.RoundTripper, secrets ...string) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, secrets: secrets}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var request []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	response, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(response))
+
+	// Only record successful exchanges, errors would be misleading on replay
+	if resp.StatusCode == http.StatusOK && json.Valid(request) && json.Valid(response) {
+		r.lock.Lock()
+		if r.fixture.URL == "" {
+			r.fixture.URL = r.sanitizeURL(req.URL)
+		}
+		r.fixture.Interactions = append(r.fixture.Interactions, Interaction{
+			Request:  r.sanitize(request),
+			Response: r.sanitize(response),
+		})
+		r.lock.Unlock()
+	}
+	return resp, nil
+}
+
+// Fixture returns the exchanges recorded so far.
+func (r *Recorder) Fixture() *Fixture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return &Fixture{
+		URL:          r.fixture.URL,
+		Interactions: append([]Interaction(nil), r.fixture.Interactions...),
+	}
+}
+
+// sanitize redacts the secrets from a JSON message.
+func (r *Recorder) sanitize(data []byte) json.RawMessage {
+	return redact(data, r.secrets)
+}
+
+// redact replaces all occurrences of the secrets in the data.
+func redact(data []byte, secrets []string) []byte {
+	for _, secret := range secrets {
+		if secret != "" {
+			data = bytes.ReplaceAll(data, []byte(secret), []byte(redacted))
+		}
+	}
+	return data
+}
+
+// sanitizeURL strips the credentials and query parameters of the URL and
+// redacts the secrets from its path.
+func (r *Recorder) sanitizeURL(u *url.URL) string {
+	clean := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	for _, secret := range r.secrets {
+		if secret != "" {
+			clean.Path = strings.ReplaceAll(clean.Path, secret, redacted)
+		}
+	}
+	return clean.String()
+}
+
+// Replayer is a http.RoundTripper serving the responses of a fixture. Requests
+// are matched by their content, ignoring the JSON-RPC request IDs. Repeated
+// identical requests are answered with the recorded responses in order.
+type Replayer struct {
+	secrets []string
+
+	lock      sync.Mutex
+	responses map[string][]json.RawMessage
+}
+
+// NewReplayer creates a replayer serving the interactions of the fixture. The
+// secrets redacted during recording must be given to match requests containing
+// them.
+func NewReplayer(f *Fixture, secrets ...string) (*Replayer, error) {
+	r := &Replayer{
+		secrets:   secrets,
+		responses: make(map[string][]json.RawMessage),
+	}
+	for i, interaction := range f.Interactions {
+		key, err := requestKey(interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in interaction %d: %v", i, err)
+		}
+		r.responses[key] = append(r.responses[key], interaction.Response)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return nil, errors.New("fixture: missing request body")
+	}
+	request, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	key, err := requestKey(redact(request, r.secrets))
+	if err != nil {
+		return nil, fmt.Errorf("fixture: invalid request: %v", err)
+	}
+	r.lock.Lock()
+	responses := r.responses[key]
+	if len(responses) == 0 {
+		r.lock.Unlock()
+		return nil, fmt.Errorf("fixture: no recorded response for %s", request)
+	}
+	response := responses[0]
+	r.responses[key] = responses[1:]
+	r.lock.Unlock()
+
+	response, err = withRequestIDs(response, request)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: invalid recorded response: %v", err)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       req,
+	}, nil
+}
+
+// message is the subset of a JSON-RPC message relevant for matching.
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// parseMessages decodes a single JSON-RPC message or a batch.
+func parseMessages(data []byte) ([]*message, bool, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var msgs []*message
+		err := json.Unmarshal(data, &msgs)
+		return msgs, true, err
+	}
+	msg := new(message)
+	err := json.Unmarshal(data, msg)
+	return []*message{msg}, false, err
+}
+
+// requestKey returns the identity of a request, which is its content without
+// the request IDs.
+func requestKey(data []byte) (string, error) {
+	msgs, batch, err := parseMessages(data)
+	if err != nil {
+		return "", err
+	}
+	var key bytes.Buffer
+	if batch {
+		key.WriteByte('[')
+	}
+	for _, msg := range msgs {
+		var params bytes.Buffer
+		if len(msg.Params) > 0 {
+			if err := json.Compact(&params, msg.Params); err != nil {
+				return "", err
+			}
+		}
+		fmt.Fprintf(&key, "%s(%s);", msg.Method, params.Bytes())
+	}
+	return key.String(), nil
+}
+
+// withRequestIDs rewrites the IDs of the recorded response to the IDs of the
+// replayed request, which are assigned by the client and differ between runs.
+func withRequestIDs(response, request []byte) ([]byte, error) {
+	reqs, _, err := parseMessages(request)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		batch bool
+		resps []map[string]json.RawMessage
+	)
+	if data := bytes.TrimSpace(response); len(data) > 0 && data[0] == '[' {
+		batch = true
+		err = json.Unmarshal(data, &resps)
+	} else {
+		resp := make(map[string]json.RawMessage)
+		err = json.Unmarshal(data, &resp)
+		resps = append(resps, resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resps) != len(reqs) {
+		return nil, fmt.Errorf("have %d responses for %d requests", len(resps), len(reqs))
+	}
+	for i, resp := range resps {
+		if reqs[i].ID != nil {
+			resp["id"] = reqs[i].ID
+		}
+	}
+	if batch {
+		return json.Marshal(resps)
+	}
+	return json.Marshal(resps[0])
+}
+
+// Dial creates a client backed by the fixture file at path. If rawurl is set,
+// requests are sent to the live endpoint and recorded, writing the fixture when
+// the returned close function is called. Otherwise the fixture is replayed and
+// no network access is made. Only HTTP endpoints can be recorded.
+func Dial(ctx context.Context, path, rawurl string, secrets ...string) (*rpc.Client, func() error, error) {
+	if rawurl == "" {
+		f, err := Load(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		replayer, err := NewReplayer(f, secrets...)
+		if err != nil {
+			return nil, nil, err
+		}
+		client, err := rpc.DialOptions(ctx, "http://fixture", rpc.WithHTTPClient(&http.Client{Transport: replayer}))
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() error { client.Close(); return nil }, nil
+	}
+	if u, err := url.Parse(rawurl); err != nil {
+		return nil, nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil, fmt.Errorf("fixture: can't record %s endpoint", u.Scheme)
+	}
+	recorder := NewRecorder(nil, secrets...)
+	client, err := rpc.DialOptions(ctx, rawurl, rpc.WithHTTPClient(&http.Client{Transport: recorder}))
+	if err != nil {
+		return nil, nil, err
+	}
+	closeFn := func() error {
+		client.Close()
+		return recorder.Fixture().Save(path)
+	}
+	return client, closeFn, nil
+}
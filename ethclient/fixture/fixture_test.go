@@ -0,0 +1,168 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fixture
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testService struct{ calls int }
+
+func (s *testService) ChainId() *hexutil.Big {
+	s.calls++
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *testService) GetBalance(addr common.Address, block string) *hexutil.Big {
+	s.calls++
+	return (*hexutil.Big)(new(big.Int).SetBytes(addr[:2]))
+}
+
+// exercise runs the same calls against the client during recording and replay.
+func exercise(t *testing.T, client *rpc.Client) {
+	t.Helper()
+
+	ec := ethclient.NewClient(client)
+	for i := 0; i < 2; i++ {
+		id, err := ec.ChainID(context.Background())
+		if err != nil {
+			t.Fatalf("failed to retrieve chain id: %v", err)
+		}
+		if id.Uint64() != 1337 {
+			t.Fatalf("chain id mismatch: have %d, want 1337", id)
+		}
+	}
+	balance, err := ec.BalanceAt(context.Background(), common.HexToAddress("0x0102000000000000000000000000000000000000"), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve balance: %v", err)
+	}
+	if balance.Uint64() != 0x0102 {
+		t.Fatalf("balance mismatch: have %d, want %d", balance, 0x0102)
+	}
+	var ids [2]hexutil.Big
+	batch := []rpc.BatchElem{
+		{Method: "eth_chainId", Result: &ids[0]},
+		{Method: "eth_chainId", Result: &ids[1]},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	for i, id := range ids {
+		if id.ToInt().Uint64() != 1337 || batch[i].Error != nil {
+			t.Fatalf("batch element %d mismatch: have %v (%v), want 1337", i, id.ToInt(), batch[i].Error)
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	var (
+		path    = filepath.Join(t.TempDir(), "fixture.json")
+		secret  = "secret-api-key"
+		service = new(testService)
+	)
+	server := rpc.NewServer()
+	server.RegisterName("eth", service)
+	httpsrv := httptest.NewServer(server)
+
+	// Record the calls against the live endpoint
+	client, closeFn, err := Dial(context.Background(), path, httpsrv.URL+"/v2/"+secret+"?key="+secret, secret)
+	if err != nil {
+		t.Fatalf("failed to dial live endpoint: %v", err)
+	}
+	exercise(t, client)
+	if err := closeFn(); err != nil {
+		t.Fatalf("failed to save fixture: %v", err)
+	}
+	httpsrv.Close()
+	server.Stop()
+
+	calls := service.calls
+	if calls != 5 {
+		t.Fatalf("have %d live calls, want 5", calls)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Fatalf("fixture leaks secret: %s", data)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	if want := httpsrv.URL + "/v2/" + redacted; f.URL != want {
+		t.Errorf("fixture url mismatch: have %s, want %s", f.URL, want)
+	}
+	if len(f.Interactions) != 4 {
+		t.Errorf("have %d interactions, want 4", len(f.Interactions))
+	}
+	// Replay the calls offline
+	client, closeFn, err = Dial(context.Background(), path, "", secret)
+	if err != nil {
+		t.Fatalf("failed to dial fixture: %v", err)
+	}
+	defer closeFn()
+
+	exercise(t, client)
+	if service.calls != calls {
+		t.Fatalf("replay reached live endpoint")
+	}
+	// Unrecorded requests, and recorded ones used up, fail
+	if _, err := ethclient.NewClient(client).ChainID(context.Background()); err == nil {
+		t.Fatalf("exhausted request succeeded")
+	}
+	var number hexutil.Uint64
+	if err := client.Call(&number, "eth_blockNumber"); err == nil {
+		t.Fatalf("unrecorded request succeeded")
+	}
+}
+
+func TestReplayIDs(t *testing.T) {
+	f := &Fixture{Interactions: []Interaction{{
+		Request:  []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x01"}, "latest"]}`),
+		Response: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x02"}`),
+	}}}
+	replayer, err := NewReplayer(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest("POST", "http://fixture", strings.NewReader(`{"jsonrpc":"2.0","id":42,"method":"eth_call","params":[{"to": "0x01"},"latest"]}`))
+	resp, err := replayer.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":42,"jsonrpc":"2.0","result":"0x02"}`; string(data) != want {
+		t.Errorf("response mismatch: have %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ErrChainIDMismatch is returned by CheckChain if the provider reports a
+	// different chain ID than expected.
+	ErrChainIDMismatch = errors.New("chain ID mismatch")
+
+	// ErrGenesisMismatch is returned by CheckChain if the genesis block of the
+	// provider differs from the expected one.
+	ErrGenesisMismatch = errors.New("genesis mismatch")
+)
+
+// ForkID computes the EIP-2124 fork identifier of the provider's chain, given
+// the chain config it is expected to run. The genesis hash and the head used for
+// the computation are retrieved from the provider.
+func (ec *Client) ForkID(ctx context.Context, config *params.ChainConfig) (forkid.ID, error) {
+	genesis, err := ec.HeaderByNumber(ctx, common.Big0)
+	if err != nil {
+		return forkid.ID{}, err
+	}
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return forkid.ID{}, err
+	}
+	return forkid.NewID(config, genesis.Hash(), head.Number.Uint64(), head.Time), nil
+}
+
+// nodeInfo is the subset of admin_nodeInfo needed to compute the fork ID of the
+// provider based on its own chain config.
+type nodeInfo struct {
+	Protocols struct {
+		Eth *struct {
+			Genesis common.Hash         `json:"genesis"`
+			Config  *params.ChainConfig `json:"config"`
+		} `json:"eth"`
+	} `json:"protocols"`
+}
+
+// CheckChain verifies that the provider serves the chain described by the given
+// config and genesis hash, guarding against querying the wrong chain when chain
+// IDs are ambiguous, e.g. on forks or private networks reusing a chain ID.
+//
+// The chain ID and genesis hash of the provider must match the expected ones. If
+// the provider also exposes its chain config via admin_nodeInfo, the fork ID it
+// derives from it is validated against the expected fork schedule, as a peer on
+// the p2p network would be. Providers not exposing the admin API are accepted
+// based on the first two checks only.
+func (ec *Client) CheckChain(ctx context.Context, config *params.ChainConfig, genesis common.Hash) error {
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	if config.ChainID != nil && chainID.Cmp(config.ChainID) != 0 {
+		return fmt.Errorf("%w: have %v, want %v", ErrChainIDMismatch, chainID, config.ChainID)
+	}
+	header, err := ec.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return err
+	}
+	if header.Hash() != genesis {
+		return fmt.Errorf("%w: have %x, want %x", ErrGenesisMismatch, header.Hash(), genesis)
+	}
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var info nodeInfo
+	if err := ec.call(ctx, &info, "admin_nodeInfo"); err != nil {
+		if _, err := methodSupported(err); err != nil {
+			return err
+		}
+		return nil // admin namespace not exposed, nothing more to check
+	}
+	if info.Protocols.Eth == nil || info.Protocols.Eth.Config == nil {
+		return nil
+	}
+	remote := forkid.NewID(info.Protocols.Eth.Config, info.Protocols.Eth.Genesis, head.Number.Uint64(), head.Time)
+	if err := forkid.NewFilterAt(config, genesis, head.Number.Uint64(), head.Time)(remote); err != nil {
+		return fmt.Errorf("fork ID %v rejected: %w", remote, err)
+	}
+	return nil
+}
@@ -20,9 +20,11 @@ package gethclient
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"math/big"
 	"runtime"
 	"runtime/debug"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -30,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 // Client is a wrapper around rpc.Client that implements geth-specific functionality.
@@ -157,6 +160,48 @@ func (ec *Client) MemStats(ctx context.Context) (*runtime.MemStats, error) {
 	return &result, err
 }
 
+// CPUProfile runs a CPU profile on the node for the given duration, rounded up
+// to whole seconds, and writes the profile data to w. The profile is in pprof
+// protobuf format.
+func (ec *Client) CPUProfile(ctx context.Context, duration time.Duration, w io.Writer) error {
+	nsec := uint((duration + time.Second - 1) / time.Second)
+
+	var data hexutil.Bytes
+	if err := ec.c.CallContext(ctx, &data, "debug_cpuProfileData", nsec); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// Profile writes the named runtime profile of the node (e.g. "heap",
+// "goroutine", "block" or "mutex") to w. The profile is in pprof protobuf
+// format.
+func (ec *Client) Profile(ctx context.Context, name string, w io.Writer) error {
+	var data hexutil.Bytes
+	if err := ec.c.CallContext(ctx, &data, "debug_profileData", name); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// Stacks retrieves a printed representation of the stacks of all goroutines of
+// the node. If filter is not empty, only goroutines matching the boolean
+// expression of package names are included, e.g. "(eth || snap) && !p2p".
+func (ec *Client) Stacks(ctx context.Context, filter string) (string, error) {
+	var (
+		result string
+		err    error
+	)
+	if filter == "" {
+		err = ec.c.CallContext(ctx, &result, "debug_stacks")
+	} else {
+		err = ec.c.CallContext(ctx, &result, "debug_stacks", filter)
+	}
+	return result, err
+}
+
 // SetHead sets the current head of the local chain by block number.
 // Note, this is a destructive action and may severely damage your chain.
 // Use with extreme caution.
@@ -181,6 +226,42 @@ func (ec *Client) SubscribePendingTransactions(ctx context.Context, ch chan<- co
 	return ec.c.EthSubscribe(ctx, ch, "newPendingTransactions")
 }
 
+// TxPropagation is a transaction announcement or transfer received by the node
+// from a remote peer.
+type TxPropagation struct {
+	Peer string      `json:"peer"` // ID of the remote peer
+	Kind string      `json:"kind"` // Kind of the event: announcement, broadcast or delivery
+	Hash common.Hash `json:"hash"` // Hash of the transaction
+	Time time.Time   `json:"time"` // Time the node received the packet at
+}
+
+// SubscribeTxPropagation subscribes to every transaction announcement and transfer
+// the node receives from its peers, e.g. to measure transaction propagation. The
+// node must expose the debug namespace over a connection supporting subscriptions.
+func (ec *Client) SubscribeTxPropagation(ctx context.Context, ch chan<- TxPropagation) (*rpc.ClientSubscription, error) {
+	return ec.c.Subscribe(ctx, "debug", ch, "txPropagation")
+}
+
+// SignTypedData requests a signature over EIP-712 typed data from an external
+// signer (e.g. clef) exposing the account namespace.
+func (ec *Client) SignTypedData(ctx context.Context, account common.Address, data apitypes.TypedData) ([]byte, error) {
+	var signature hexutil.Bytes
+	err := ec.c.CallContext(ctx, &signature, "account_signTypedData", account, data)
+	return signature, err
+}
+
+// SignTypedDataWithHashes requests a signature over EIP-712 typed data from an
+// external signer, which validates the data against its schema before signing.
+// Along with the signature, the signed digest, the domain separator and the
+// struct hash are returned for audit purposes.
+func (ec *Client) SignTypedDataWithHashes(ctx context.Context, account common.Address, data apitypes.TypedData) (*apitypes.SignTypedDataResult, error) {
+	var result apitypes.SignTypedDataResult
+	if err := ec.c.CallContext(ctx, &result, "account_signTypedDataWithHashes", account, data); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -21,7 +21,9 @@ import (
 	"context"
 	"encoding/json"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -115,6 +117,9 @@ func TestGethClient(t *testing.T) {
 		}, {
 			"TestMemStats",
 			func(t *testing.T) { testMemStats(t, client) },
+		}, {
+			"TestProfiles",
+			func(t *testing.T) { testProfiles(t, client) },
 		}, {
 			"TestGetNodeInfo",
 			func(t *testing.T) { testGetNodeInfo(t, client) },
@@ -124,6 +129,9 @@ func TestGethClient(t *testing.T) {
 		}, {
 			"TestSubscribePendingTxs",
 			func(t *testing.T) { testSubscribeFullPendingTransactions(t, client) },
+		}, {
+			"TestSubscribeTxPropagation",
+			func(t *testing.T) { testSubscribeTxPropagation(t, client) },
 		}, {
 			"TestCallContract",
 			func(t *testing.T) { testCallContract(t, client) },
@@ -252,6 +260,39 @@ func testMemStats(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testProfiles(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+
+	// Profiles are gzipped protobuf messages
+	gzipped := func(data []byte) bool {
+		return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+	}
+	var buf bytes.Buffer
+	if err := ec.Profile(context.Background(), "heap", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !gzipped(buf.Bytes()) {
+		t.Fatalf("invalid heap profile: %x", buf.Bytes())
+	}
+	if err := ec.Profile(context.Background(), "nonexistent", &buf); err == nil {
+		t.Fatal("unknown profile retrieved")
+	}
+	buf.Reset()
+	if err := ec.CPUProfile(context.Background(), time.Millisecond, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !gzipped(buf.Bytes()) {
+		t.Fatalf("invalid CPU profile: %x", buf.Bytes())
+	}
+	stacks, err := ec.Stacks(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stacks, "goroutine") {
+		t.Fatalf("invalid goroutine dump: %s", stacks)
+	}
+}
+
 func testGetNodeInfo(t *testing.T, client *rpc.Client) {
 	ec := New(client)
 	info, err := ec.GetNodeInfo(context.Background())
@@ -413,3 +454,15 @@ func TestOverrideAccountMarshal(t *testing.T) {
 		t.Error("want:", expected)
 	}
 }
+
+func testSubscribeTxPropagation(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+
+	// The test node has no peers, so only check that the subscription is served
+	ch := make(chan TxPropagation)
+	sub, err := ec.SubscribeTxPropagation(context.Background(), ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe to transaction propagation: %v", err)
+	}
+	sub.Unsubscribe()
+}
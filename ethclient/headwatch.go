@@ -0,0 +1,302 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// headWatcherBackoff is the maximum time between attempts to re-establish a
+// failed head subscription.
+const headWatcherBackoff = 10 * time.Second
+
+// HeadWatcherBackend is the chain access required by a HeadWatcher. It is
+// implemented by Client.
+type HeadWatcherBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// depthWatch is a pending OnDepthReached registration.
+type depthWatch struct {
+	hash  common.Hash
+	depth uint64
+	fn    func(*types.Receipt)
+}
+
+// HeadWatcher follows the head of the chain through a single subscription and
+// tracks the latest, safe and finalized block heights, invoking the registered
+// callbacks as the chain progresses. It lets any number of consumers share the
+// confirmation logic without each of them subscribing to the node.
+//
+// Callbacks are invoked sequentially from the watcher's event loop and must not
+// block; in particular they must not call Stop.
+type HeadWatcher struct {
+	backend HeadWatcherBackend
+
+	latest    *types.Header
+	safe      *types.Header
+	finalized *types.Header
+
+	headFns  map[uint64]func(*types.Header)
+	finalFns map[uint64]func(*types.Header)
+	depths   map[uint64]*depthWatch
+	nextID   uint64
+	lock     sync.RWMutex
+
+	sub  event.Subscription
+	quit chan struct{}
+	term chan struct{}
+}
+
+// NewHeadWatcher creates a head watcher on top of the given backend. It does not
+// track the chain until started.
+func NewHeadWatcher(backend HeadWatcherBackend) *HeadWatcher {
+	return &HeadWatcher{
+		backend:  backend,
+		headFns:  make(map[uint64]func(*types.Header)),
+		finalFns: make(map[uint64]func(*types.Header)),
+		depths:   make(map[uint64]*depthWatch),
+	}
+}
+
+// Start subscribes to new chain heads and starts processing them in the
+// background. The subscription is re-established automatically if it fails.
+func (w *HeadWatcher) Start(ctx context.Context) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.sub != nil {
+		return errors.New("head watcher already started")
+	}
+	// Retrieve the current head before subscribing so the heights are known
+	// immediately, even if no new block arrives for a while.
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	w.latest = head
+
+	heads := make(chan *types.Header, 16)
+	w.sub = event.ResubscribeErr(headWatcherBackoff, func(ctx context.Context, err error) (event.Subscription, error) {
+		if err != nil {
+			log.Warn("Head subscription failed, resubscribing", "err", err)
+		}
+		return w.backend.SubscribeNewHead(ctx, heads)
+	})
+	w.quit, w.term = make(chan struct{}), make(chan struct{})
+
+	go w.loop(ctx, heads, w.quit, w.term)
+	return nil
+}
+
+// Stop terminates the head subscription and waits for the event loop to exit.
+// Registered callbacks are kept, so the watcher may be started again.
+func (w *HeadWatcher) Stop() {
+	w.lock.Lock()
+	if w.sub == nil {
+		w.lock.Unlock()
+		return
+	}
+	sub, quit, term := w.sub, w.quit, w.term
+	w.sub = nil
+	w.lock.Unlock()
+
+	sub.Unsubscribe()
+	close(quit)
+	<-term
+}
+
+// Latest returns the most recent chain head seen by the watcher, or nil if it
+// was never started.
+func (w *HeadWatcher) Latest() *types.Header {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.latest
+}
+
+// Safe returns the most recent safe block, or nil if the backend does not report
+// one (e.g. pre-merge chains).
+func (w *HeadWatcher) Safe() *types.Header {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.safe
+}
+
+// Finalized returns the most recent finalized block, or nil if the backend does
+// not report one (e.g. pre-merge chains).
+func (w *HeadWatcher) Finalized() *types.Header {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.finalized
+}
+
+// OnNewHead registers a callback invoked with every new chain head. The returned
+// function removes the registration.
+func (w *HeadWatcher) OnNewHead(fn func(head *types.Header)) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.headFns[id] = fn
+
+	return func() { w.remove(id) }
+}
+
+// OnFinalized registers a callback invoked whenever the finalized block
+// advances. If finality jumps multiple blocks at once, the callback is only
+// invoked with the newest finalized block. The returned function removes the
+// registration.
+func (w *HeadWatcher) OnFinalized(fn func(block *types.Header)) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.finalFns[id] = fn
+
+	return func() { w.remove(id) }
+}
+
+// OnDepthReached registers a one-shot callback invoked with the receipt of the
+// given transaction once its block is buried under n blocks, counting the block
+// itself (i.e. n = 1 fires as soon as the transaction is included). The receipt
+// is retrieved anew on every head, so a transaction reorged out of the chain
+// only fires once it reaches the depth on the new canonical chain. The returned
+// function removes the registration if it has not fired yet.
+func (w *HeadWatcher) OnDepthReached(txHash common.Hash, n uint64, fn func(receipt *types.Receipt)) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.depths[id] = &depthWatch{hash: txHash, depth: n, fn: fn}
+
+	return func() { w.remove(id) }
+}
+
+// remove drops the callback registered with the given id.
+func (w *HeadWatcher) remove(id uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.headFns, id)
+	delete(w.finalFns, id)
+	delete(w.depths, id)
+}
+
+// loop processes new chain heads until the watcher is stopped.
+func (w *HeadWatcher) loop(ctx context.Context, heads chan *types.Header, quit chan struct{}, term chan struct{}) {
+	defer close(term)
+
+	for {
+		select {
+		case head := <-heads:
+			w.process(ctx, head)
+		case <-quit:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process updates the tracked heights with a new chain head and fires all the
+// callbacks which became due.
+func (w *HeadWatcher) process(ctx context.Context, head *types.Header) {
+	// Retrieve the safe and finalized blocks, which are not available from all
+	// backends and are thus best effort
+	safe, err := w.backend.HeaderByNumber(ctx, big.NewInt(int64(rpc.SafeBlockNumber)))
+	if err != nil {
+		safe = nil
+	}
+	finalized, err := w.backend.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	if err != nil {
+		finalized = nil
+	}
+	w.lock.Lock()
+	w.latest = head
+	if safe != nil {
+		w.safe = safe
+	}
+	var advanced bool
+	if finalized != nil && (w.finalized == nil || finalized.Number.Cmp(w.finalized.Number) > 0) {
+		w.finalized, advanced = finalized, true
+	}
+	headFns := make([]func(*types.Header), 0, len(w.headFns))
+	for _, fn := range w.headFns {
+		headFns = append(headFns, fn)
+	}
+	var finalFns []func(*types.Header)
+	if advanced {
+		for _, fn := range w.finalFns {
+			finalFns = append(finalFns, fn)
+		}
+	}
+	depths := make(map[uint64]*depthWatch, len(w.depths))
+	for id, watch := range w.depths {
+		depths[id] = watch
+	}
+	w.lock.Unlock()
+
+	for _, fn := range headFns {
+		fn(head)
+	}
+	for _, fn := range finalFns {
+		fn(finalized)
+	}
+	for id, watch := range depths {
+		receipt, err := w.backend.TransactionReceipt(ctx, watch.hash)
+		if err != nil {
+			if !errors.Is(err, ethereum.NotFound) {
+				log.Debug("Failed to retrieve receipt", "hash", watch.hash, "err", err)
+			}
+			continue
+		}
+		if receipt.BlockNumber == nil || head.Number.Cmp(receipt.BlockNumber) < 0 {
+			continue
+		}
+		if new(big.Int).Sub(head.Number, receipt.BlockNumber).Uint64()+1 < watch.depth {
+			continue
+		}
+		// Depth reached, fire the callback unless it was removed meanwhile
+		w.lock.Lock()
+		_, ok := w.depths[id]
+		delete(w.depths, id)
+		w.lock.Unlock()
+
+		if ok {
+			watch.fn(receipt)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testHeadBackend is a chain backend whose head, finalized block and receipts
+// are set by the test.
+type testHeadBackend struct {
+	heads     event.Feed
+	head      uint64
+	finalized uint64
+	receipts  map[common.Hash]*types.Receipt
+	subs      int
+	lock      sync.Mutex
+}
+
+func (b *testHeadBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch {
+	case number == nil:
+		return &types.Header{Number: new(big.Int).SetUint64(b.head)}, nil
+	case number.Int64() == int64(rpc.SafeBlockNumber), number.Int64() == int64(rpc.FinalizedBlockNumber):
+		if b.finalized == 0 {
+			return nil, ethereum.NotFound
+		}
+		return &types.Header{Number: new(big.Int).SetUint64(b.finalized)}, nil
+	}
+	return &types.Header{Number: number}, nil
+}
+
+func (b *testHeadBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	b.lock.Lock()
+	b.subs++
+	b.lock.Unlock()
+
+	return b.heads.Subscribe(ch), nil
+}
+
+func (b *testHeadBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if receipt := b.receipts[txHash]; receipt != nil {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// advance moves the chain head, optionally finalizing blocks too.
+func (b *testHeadBackend) advance(head uint64, finalized uint64) {
+	b.lock.Lock()
+	b.head, b.finalized = head, finalized
+	b.lock.Unlock()
+
+	b.heads.Send(&types.Header{Number: new(big.Int).SetUint64(head)})
+}
+
+func TestHeadWatcher(t *testing.T) {
+	var (
+		tx      = common.Hash{0x01}
+		backend = &testHeadBackend{head: 10, receipts: make(map[common.Hash]*types.Receipt)}
+		watcher = NewHeadWatcher(backend)
+	)
+	var (
+		heads     = make(chan uint64, 16)
+		finals    = make(chan uint64, 16)
+		confirmed = make(chan uint64, 16)
+	)
+	watcher.OnNewHead(func(head *types.Header) { heads <- head.Number.Uint64() })
+	watcher.OnFinalized(func(block *types.Header) { finals <- block.Number.Uint64() })
+	watcher.OnDepthReached(tx, 3, func(receipt *types.Receipt) { confirmed <- receipt.BlockNumber.Uint64() })
+	cancel := watcher.OnDepthReached(common.Hash{0x02}, 1, func(*types.Receipt) { t.Error("removed callback invoked") })
+	cancel()
+
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	if head := watcher.Latest(); head == nil || head.Number.Uint64() != 10 {
+		t.Fatalf("initial head mismatch: have %v, want 10", head)
+	}
+	// Wait for the subscription to be established before feeding heads
+	for {
+		backend.lock.Lock()
+		subs := backend.subs
+		backend.lock.Unlock()
+		if subs > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	backend.lock.Lock()
+	backend.receipts[tx] = &types.Receipt{TxHash: tx, BlockNumber: big.NewInt(11)}
+	backend.receipts[common.Hash{0x02}] = &types.Receipt{BlockNumber: big.NewInt(11)}
+	backend.lock.Unlock()
+
+	// Advance the chain block by block, finalizing from block 13 on. Every head
+	// is awaited before the next so the backend state matches it.
+	for _, step := range []struct{ head, finalized uint64 }{{11, 0}, {12, 0}, {13, 8}, {14, 8}, {15, 12}} {
+		backend.advance(step.head, step.finalized)
+		if have := readUint64(t, heads); have != step.head {
+			t.Fatalf("head mismatch: have %d, want %d", have, step.head)
+		}
+	}
+	for _, want := range []uint64{8, 12} {
+		if have := readUint64(t, finals); have != want {
+			t.Fatalf("finalized mismatch: have %d, want %d", have, want)
+		}
+	}
+	if have := readUint64(t, confirmed); have != 11 {
+		t.Fatalf("confirmed block mismatch: have %d, want 11", have)
+	}
+	select {
+	case n := <-confirmed:
+		t.Fatalf("depth callback fired again for block %d", n)
+	case n := <-finals:
+		t.Fatalf("finalized callback fired again for block %d", n)
+	default:
+	}
+	if final := watcher.Finalized(); final == nil || final.Number.Uint64() != 12 {
+		t.Fatalf("finalized block mismatch: have %v, want 12", final)
+	}
+	if backend.subs != 1 {
+		t.Fatalf("subscription count mismatch: have %d, want 1", backend.subs)
+	}
+}
+
+func readUint64(t *testing.T, ch chan uint64) uint64 {
+	t.Helper()
+	select {
+	case n := <-ch:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for callback")
+		return 0
+	}
+}
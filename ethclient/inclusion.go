@@ -0,0 +1,174 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// InclusionProof is a Merkle proof of a transaction or receipt against the
+// transactions or receipts root of its block.
+type InclusionProof struct {
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Root        common.Hash // Transactions or receipts root of the block
+	Index       uint        // Position of the transaction in the block
+	Value       []byte      // Consensus encoding of the transaction or receipt
+	Proof       [][]byte    // Trie nodes on the path from the root to the value
+}
+
+// Verify checks that the proof proves Value at Index in the trie with the given
+// root. Callers must check Root against a trusted header of the block.
+func (p *InclusionProof) Verify() error {
+	db := memorydb.New()
+	for _, node := range p.Proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, err := trie.VerifyProof(p.Root, rlp.AppendUint64(nil, uint64(p.Index)), db)
+	if err != nil {
+		return fmt.Errorf("invalid inclusion proof: %v", err)
+	}
+	if !bytes.Equal(value, p.Value) {
+		return fmt.Errorf("inclusion proof value mismatch: have %x, want %x", value, p.Value)
+	}
+	return nil
+}
+
+// TransactionInclusionProof returns a proof of the transaction with the given
+// hash against the transactions root of its block. Pending transactions have no
+// proof, in which case NotFound is returned.
+func (ec *Client) TransactionInclusionProof(ctx context.Context, txHash common.Hash) (*InclusionProof, error) {
+	block, index, err := ec.blockOfTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if root := types.DeriveSha(txs, trie.NewStackTrie(nil)); root != block.TxHash() {
+		return nil, fmt.Errorf("transactions root mismatch: have %x, want %x", root, block.TxHash())
+	}
+	return newInclusionProof(block, block.TxHash(), txs, index)
+}
+
+// ReceiptInclusionProof returns a proof of the receipt of the transaction with
+// the given hash against the receipts root of its block. Pending transactions
+// have no receipt, in which case NotFound is returned.
+func (ec *Client) ReceiptInclusionProof(ctx context.Context, txHash common.Hash) (*InclusionProof, error) {
+	block, index, err := ec.blockOfTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	// The receipts root commits to all receipts of the block, fetch them in a
+	// single batch.
+	var (
+		txs      = block.Transactions()
+		receipts = make(types.Receipts, len(txs))
+		reqs     = make([]rpc.BatchElem, len(txs))
+	)
+	for i, tx := range txs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{tx.Hash()},
+			Result: &receipts[i],
+		}
+	}
+	if err := ec.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			return nil, reqs[i].Error
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("missing receipt of transaction %x", txs[i].Hash())
+		}
+	}
+	if root := types.DeriveSha(receipts, trie.NewStackTrie(nil)); root != block.ReceiptHash() {
+		return nil, fmt.Errorf("receipts root mismatch: have %x, want %x", root, block.ReceiptHash())
+	}
+	return newInclusionProof(block, block.ReceiptHash(), receipts, index)
+}
+
+// blockOfTransaction retrieves the block including the transaction with the
+// given hash, along with the position of the transaction in it.
+func (ec *Client) blockOfTransaction(ctx context.Context, txHash common.Hash) (*types.Block, uint, error) {
+	receipt, err := ec.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := ec.BlockByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	txs := block.Transactions()
+	if receipt.TransactionIndex >= uint(len(txs)) || txs[receipt.TransactionIndex].Hash() != txHash {
+		return nil, 0, ethereum.NotFound // reorged out between the requests
+	}
+	return block, receipt.TransactionIndex, nil
+}
+
+// newInclusionProof creates a proof of the list item at the given index.
+func newInclusionProof(block *types.Block, root common.Hash, list types.DerivableList, index uint) (*InclusionProof, error) {
+	tr := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase()))
+
+	var buf bytes.Buffer
+	for i := 0; i < list.Len(); i++ {
+		buf.Reset()
+		list.EncodeIndex(i, &buf)
+		tr.Update(rlp.AppendUint64(nil, uint64(i)), common.CopyBytes(buf.Bytes()))
+	}
+	key := rlp.AppendUint64(nil, uint64(index))
+
+	nodes := new(proofList)
+	if err := tr.Prove(key, 0, nodes); err != nil {
+		return nil, err
+	}
+	buf.Reset()
+	list.EncodeIndex(int(index), &buf)
+
+	return &InclusionProof{
+		BlockHash:   block.Hash(),
+		BlockNumber: block.NumberU64(),
+		Root:        root,
+		Index:       index,
+		Value:       common.CopyBytes(buf.Bytes()),
+		Proof:       *nodes,
+	}, nil
+}
+
+// proofList collects the trie nodes of a proof in order.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func testInclusionProof(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	// Prove the second transaction of block 2 and its receipt
+	txProof, err := ec.TransactionInclusionProof(context.Background(), testTx2.Hash())
+	if err != nil {
+		t.Fatalf("transaction proof failed: %v", err)
+	}
+	if txProof.BlockHash != chain[2].Hash() || txProof.Root != chain[2].TxHash() || txProof.Index != 1 {
+		t.Fatalf("transaction proof mismatch: block %x, root %x, index %d", txProof.BlockHash, txProof.Root, txProof.Index)
+	}
+	if err := txProof.Verify(); err != nil {
+		t.Fatalf("transaction proof rejected: %v", err)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(txProof.Value); err != nil || tx.Hash() != testTx2.Hash() {
+		t.Fatalf("proven transaction mismatch: have %x, want %x (err %v)", tx.Hash(), testTx2.Hash(), err)
+	}
+	receiptProof, err := ec.ReceiptInclusionProof(context.Background(), testTx2.Hash())
+	if err != nil {
+		t.Fatalf("receipt proof failed: %v", err)
+	}
+	if receiptProof.Root != chain[2].ReceiptHash() || receiptProof.Index != 1 {
+		t.Fatalf("receipt proof mismatch: root %x, index %d", receiptProof.Root, receiptProof.Index)
+	}
+	if err := receiptProof.Verify(); err != nil {
+		t.Fatalf("receipt proof rejected: %v", err)
+	}
+	// Tampered proofs must be rejected
+	tampered := *txProof
+	tampered.Index = 0
+	if err := tampered.Verify(); err == nil {
+		t.Error("proof accepted at wrong index")
+	}
+	tampered = *txProof
+	tampered.Root = common.Hash{0x01}
+	if err := tampered.Verify(); err == nil {
+		t.Error("proof accepted against wrong root")
+	}
+	// Unknown transactions have no proof
+	if _, err := ec.TransactionInclusionProof(context.Background(), common.Hash{0x01}); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// LenientMode is a set of nonstandard number encodings a client accepts from
+// its provider. The JSON-RPC specification requires quantities to be encoded as
+// 0x prefixed hex strings without leading zeros, which some third-party
+// providers do not adhere to. In lenient mode such numbers are normalized
+// before decoding, logging a warning, instead of failing the whole response.
+//
+// Numbers are only normalized where the API defines a quantity: in the results
+// of methods returning bare quantities, and in object fields known to hold one.
+type LenientMode uint
+
+const (
+	// LenientPadding accepts hex quantities with leading zeros, such as "0x001a",
+	// and the empty quantity "0x".
+	LenientPadding LenientMode = 1 << iota
+
+	// LenientMissingPrefix accepts hex quantities without the 0x prefix, such as
+	// "1a".
+	LenientMissingPrefix
+
+	// LenientDecimal accepts quantities as decimal strings, such as "26", or JSON
+	// numbers. Unprefixed strings consisting only of decimal digits are decoded as
+	// decimal even if LenientMissingPrefix is set.
+	LenientDecimal
+
+	// LenientAll accepts all the nonstandard encodings above.
+	LenientAll = LenientPadding | LenientMissingPrefix | LenientDecimal
+)
+
+// quantityFields are the object fields of the API results holding quantities.
+var quantityFields = map[string]bool{
+	// Blocks
+	"number": true, "gasLimit": true, "gasUsed": true, "timestamp": true, "difficulty": true,
+	"totalDifficulty": true, "baseFeePerGas": true, "size": true, "excessDataGas": true,
+	// Transactions
+	"blockNumber": true, "gas": true, "gasPrice": true, "maxFeePerGas": true, "maxPriorityFeePerGas": true,
+	"maxFeePerDataGas": true, "nonce": true, "transactionIndex": true, "value": true, "type": true,
+	"v": true, "r": true, "s": true, "chainId": true, "yParity": true,
+	// Receipts and logs
+	"status": true, "cumulativeGasUsed": true, "effectiveGasPrice": true, "logIndex": true,
+	// Withdrawals
+	"index": true, "validatorIndex": true, "amount": true,
+	// Accounts
+	"balance": true,
+	// Fee history
+	"oldestBlock": true, "reward": true,
+	// Sync progress
+	"startingBlock": true, "currentBlock": true, "highestBlock": true,
+}
+
+// SetLenientMode sets the nonstandard number encodings accepted from the provider.
+// Zero restores strict decoding.
+func (ec *Client) SetLenientMode(mode LenientMode) {
+	ec.lenientLock.Lock()
+	defer ec.lenientLock.Unlock()
+
+	ec.lenient = mode
+}
+
+// call performs a JSON-RPC call once the rate limiter allows, normalizing
+// nonstandard numbers in the result if the client is lenient.
+func (ec *Client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := ec.wait(ctx, 1); err != nil {
+		return err
+	}
+	ec.lenientLock.Lock()
+	mode := ec.lenient
+	ec.lenientLock.Unlock()
+
+	if mode == 0 || result == nil {
+		return ec.c.CallContext(ctx, result, method, args...)
+	}
+	var raw json.RawMessage
+	if err := ec.c.CallContext(ctx, &raw, method, args...); err != nil {
+		return err
+	}
+	norm, err := normalizeNumbers(raw, mode, isQuantityType(reflect.TypeOf(result)), func(path, have, want string) {
+		ec.warnNonstandard(method, path, have, want)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to normalize %s result: %v", method, err)
+	}
+	return json.Unmarshal(norm, result)
+}
+
+// warnNonstandard logs a nonstandard number, once per method and field.
+func (ec *Client) warnNonstandard(method, path, have, want string) {
+	key := method + " " + path
+	if _, warned := ec.lenientWarned.LoadOrStore(key, true); warned {
+		return
+	}
+	log.Warn("Normalized nonstandard number from provider", "method", method, "field", path, "have", have, "want", want)
+}
+
+var (
+	hexBigType    = reflect.TypeOf(hexutil.Big{})
+	hexUint64Type = reflect.TypeOf(hexutil.Uint64(0))
+	hexUintType   = reflect.TypeOf(hexutil.Uint(0))
+)
+
+// isQuantityType returns whether values of the given type, or the elements of
+// slices of it, decode from quantities.
+func isQuantityType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ == hexBigType || typ == hexUint64Type || typ == hexUintType
+}
+
+// normalizeNumbers rewrites the nonstandard quantities in the given JSON value
+// accepted by the mode into their standard encoding, reporting every rewrite.
+// If quantity is set, the value itself or its elements are quantities.
+func normalizeNumbers(raw json.RawMessage, mode LenientMode, quantity bool, report func(path, have, want string)) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	var (
+		changed bool
+		walk    func(value interface{}, path string, quantity bool) interface{}
+	)
+	walk = func(value interface{}, path string, quantity bool) interface{} {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			// Block nonces are fixed size data, not quantities
+			_, isHeader := v["parentHash"]
+			for key, field := range v {
+				v[key] = walk(field, path+"."+key, quantityFields[key] && !(isHeader && key == "nonce"))
+			}
+		case []interface{}:
+			for i, elem := range v {
+				v[i] = walk(elem, fmt.Sprintf("%s[%d]", path, i), quantity)
+			}
+		default:
+			if !quantity {
+				return value
+			}
+			if norm, ok := normalizeQuantity(value, mode); ok {
+				have := fmt.Sprint(value)
+				if _, isString := value.(string); isString {
+					have = fmt.Sprintf("%q", value)
+				}
+				report(strings.TrimPrefix(path, "."), have, norm)
+				changed = true
+				return norm
+			}
+		}
+		return value
+	}
+	value = walk(value, "", quantity)
+	if !changed {
+		return raw, nil
+	}
+	return json.Marshal(value)
+}
+
+// normalizeQuantity converts a nonstandard quantity accepted by the mode into
+// its standard encoding. Standard quantities and values not accepted are left
+// to the decoder.
+func normalizeQuantity(value interface{}, mode LenientMode) (string, bool) {
+	var (
+		n  = new(big.Int)
+		ok bool
+	)
+	switch v := value.(type) {
+	case json.Number:
+		if mode&LenientDecimal != 0 {
+			_, ok = n.SetString(string(v), 10)
+		}
+	case string:
+		switch {
+		case strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X"):
+			var (
+				digits = v[2:]
+				padded = digits == "" || len(digits) > 1 && digits[0] == '0'
+			)
+			if padded && mode&LenientPadding != 0 || v[1] == 'X' && mode&LenientMissingPrefix != 0 {
+				if digits == "" {
+					digits = "0"
+				}
+				_, ok = n.SetString(digits, 16)
+			}
+		case mode&LenientDecimal != 0 && isDecimal(v):
+			_, ok = n.SetString(v, 10)
+		case mode&LenientMissingPrefix != 0 && v != "":
+			_, ok = n.SetString(v, 16)
+		}
+	}
+	if !ok || n.Sign() < 0 {
+		return "", false
+	}
+	return hexutil.EncodeBig(n), true
+}
+
+// isDecimal returns whether the string consists only of decimal digits.
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
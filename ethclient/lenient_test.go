@@ -0,0 +1,153 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// nonstandardService mimics a provider encoding numbers in nonstandard ways.
+type nonstandardService struct {
+	header *types.Header
+}
+
+func (s *nonstandardService) BlockNumber() json.RawMessage {
+	return json.RawMessage(`"0x001a"`)
+}
+
+func (s *nonstandardService) GetBalance(account common.Address, block string) json.RawMessage {
+	return json.RawMessage(`1000000000000000000000`)
+}
+
+func (s *nonstandardService) GetBalances(accounts []common.Address, block string) json.RawMessage {
+	return json.RawMessage(`["10", "0x0b", "0xc"]`)
+}
+
+func (s *nonstandardService) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	blob, err := json.Marshal(s.header)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, err
+	}
+	fields["number"] = "26"              // Decimal string
+	fields["gasLimit"] = 30000000        // JSON number
+	fields["gasUsed"] = "0x00005208"     // Padded
+	fields["baseFeePerGas"] = "3b9aca00" // Missing prefix
+	fields["difficulty"] = "0x"          // Empty
+	return json.Marshal(fields)
+}
+
+func TestLenientNumbers(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(26),
+		GasLimit:   30_000_000,
+		GasUsed:    21000,
+		BaseFee:    big.NewInt(1_000_000_000),
+		Difficulty: new(big.Int),
+		Nonce:      types.EncodeNonce(0),
+		Extra:      []byte{},
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &nonstandardService{header: header}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	client := NewClient(rpc.DialInProc(server))
+	defer client.Close()
+
+	// Strict clients must reject the nonstandard numbers
+	if _, err := client.BlockNumber(context.Background()); err == nil {
+		t.Fatal("strict client accepted padded quantity")
+	}
+	client.SetLenientMode(LenientAll)
+
+	number, err := client.BlockNumber(context.Background())
+	if err != nil || number != 26 {
+		t.Fatalf("block number mismatch: have %d (%v), want 26", number, err)
+	}
+	balance, err := client.BalanceAt(context.Background(), common.Address{}, nil)
+	if want, _ := new(big.Int).SetString("1000000000000000000000", 10); err != nil || balance.Cmp(want) != 0 {
+		t.Fatalf("balance mismatch: have %v (%v), want %v", balance, err, want)
+	}
+	balances, err := client.BalancesAt(context.Background(), []common.Address{{}, {}, {}}, nil)
+	if err != nil {
+		t.Fatalf("balances query failed: %v", err)
+	}
+	for i, want := range []int64{10, 11, 12} {
+		if balances[i].Int64() != want {
+			t.Errorf("balance %d mismatch: have %v, want %d", i, balances[i], want)
+		}
+	}
+	have, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("header query failed: %v", err)
+	}
+	if have.Hash() != header.Hash() {
+		t.Fatalf("header mismatch: have %+v, want %+v", have, header)
+	}
+	// Modes only accept the encodings they are enabled for
+	client.SetLenientMode(LenientPadding)
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("padded quantity rejected: %v", err)
+	}
+	if _, err := client.BalanceAt(context.Background(), common.Address{}, nil); err == nil {
+		t.Fatal("decimal quantity accepted without decimal mode")
+	}
+}
+
+func TestNormalizeQuantity(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		mode  LenientMode
+		want  string
+		ok    bool
+	}{
+		{"0x1a", LenientAll, "", false},
+		{"0x0", LenientAll, "", false},
+		{"0x001a", LenientPadding, "0x1a", true},
+		{"0x001a", LenientDecimal, "", false},
+		{"0x", LenientPadding, "0x0", true},
+		{"0X1A", LenientMissingPrefix, "0x1a", true},
+		{"1a", LenientMissingPrefix, "0x1a", true},
+		{"1a", LenientDecimal, "", false},
+		{"26", LenientDecimal, "0x1a", true},
+		{"26", LenientAll, "0x1a", true},
+		{"26", LenientMissingPrefix, "0x26", true},
+		{json.Number("26"), LenientDecimal, "0x1a", true},
+		{json.Number("-1"), LenientDecimal, "", false},
+		{json.Number("1.5"), LenientDecimal, "", false},
+		{"zz", LenientAll, "", false},
+		{true, LenientAll, "", false},
+	}
+	for i, tt := range tests {
+		have, ok := normalizeQuantity(tt.value, tt.mode)
+		if have != tt.want || ok != tt.ok {
+			t.Errorf("test %d: normalized %v mismatch: have %q (%v), want %q (%v)", i, tt.value, have, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// ABIRegistry maps contract addresses to the ABI used to decode their logs. It
+// is safe for concurrent use.
+type ABIRegistry struct {
+	abis map[common.Address]*abi.ABI
+	lock sync.RWMutex
+}
+
+// NewABIRegistry creates an empty ABI registry.
+func NewABIRegistry() *ABIRegistry {
+	return &ABIRegistry{abis: make(map[common.Address]*abi.ABI)}
+}
+
+// Register associates a contract address with its ABI, replacing any previous
+// association.
+func (r *ABIRegistry) Register(address common.Address, contract *abi.ABI) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.abis[address] = contract
+}
+
+// Addresses returns all contract addresses with a registered ABI.
+func (r *ABIRegistry) Addresses() []common.Address {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	addresses := make([]common.Address, 0, len(r.abis))
+	for address := range r.abis {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// Decode resolves the event a log was emitted for and unpacks its indexed and
+// non-indexed fields. Logs of unknown contracts or events, and logs which do not
+// match the event definition, are returned undecoded with a nil Event.
+func (r *ABIRegistry) Decode(log types.Log) *DecodedLog {
+	decoded := &DecodedLog{Log: log, Removed: log.Removed}
+
+	r.lock.RLock()
+	contract := r.abis[log.Address]
+	r.lock.RUnlock()
+
+	if contract == nil || len(log.Topics) == 0 {
+		return decoded
+	}
+	ev, err := contract.EventByID(log.Topics[0])
+	if err != nil {
+		return decoded
+	}
+	fields := make(map[string]interface{})
+	if len(log.Data) > 0 {
+		if err := contract.UnpackIntoMap(fields, ev.Name, log.Data); err != nil {
+			return decoded
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range ev.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, log.Topics[1:]); err != nil {
+		return decoded
+	}
+	decoded.Event, decoded.Fields = ev, fields
+	return decoded
+}
+
+// DecodedLog is a contract log together with the event it was decoded into.
+type DecodedLog struct {
+	Log     types.Log              // Raw log as delivered by the node
+	Event   *abi.Event             // Event definition, nil if the log could not be decoded
+	Fields  map[string]interface{} // Decoded event fields keyed by argument name
+	Removed bool                   // Compensating event for a log reverted by a reorg
+}
+
+// LogCursor is the position in the chain at which a log stream resumes. It
+// points to the first log which has not been delivered yet.
+type LogCursor struct {
+	Number uint64 `json:"number"` // Block number of the next log to deliver
+	Index  uint   `json:"index"`  // Index within the block of the next log to deliver
+}
+
+// before reports whether the log at the given position precedes the cursor.
+func (c LogCursor) before(number uint64, index uint) bool {
+	return number < c.Number || (number == c.Number && index < c.Index)
+}
+
+// CursorStore persists the position of a log stream across restarts.
+type CursorStore interface {
+	// LoadCursor returns the stored cursor, or nil if none was stored yet.
+	LoadCursor() (*LogCursor, error)
+
+	// StoreCursor persists the cursor, replacing any previous one.
+	StoreCursor(cursor LogCursor) error
+}
+
+// FileCursorStore is a CursorStore which keeps the cursor in a JSON file.
+type FileCursorStore struct {
+	path string
+}
+
+// NewFileCursorStore creates a cursor store backed by the file at path.
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+// LoadCursor implements CursorStore.
+func (s *FileCursorStore) LoadCursor() (*LogCursor, error) {
+	blob, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cursor := new(LogCursor)
+	if err := json.Unmarshal(blob, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// StoreCursor implements CursorStore. The file is replaced atomically so that a
+// crash never leaves a partially written cursor behind.
+func (s *FileCursorStore) StoreCursor(cursor LogCursor) error {
+	blob, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// LogStream delivers decoded contract events from the logs matching a filter
+// query. Historical logs since the last stored cursor are backfilled before
+// switching over to live logs, logs reverted by chain reorganisations are
+// delivered again as compensating events with Removed set, and the cursor is
+// persisted after every delivered log so that a restarted stream resumes where
+// it left off.
+type LogStream struct {
+	backend  ethereum.LogFilterer
+	registry *ABIRegistry
+	query    ethereum.FilterQuery
+	store    CursorStore
+}
+
+// NewLogStream creates a log stream for the given query. If the query does not
+// restrict the contract addresses, the addresses of the registry are used. The
+// store is optional; without one, the stream starts at the query's FromBlock on
+// every subscription.
+func NewLogStream(backend ethereum.LogFilterer, registry *ABIRegistry, query ethereum.FilterQuery, store CursorStore) *LogStream {
+	if len(query.Addresses) == 0 {
+		query.Addresses = registry.Addresses()
+	}
+	return &LogStream{
+		backend:  backend,
+		registry: registry,
+		query:    query,
+		store:    store,
+	}
+}
+
+// Subscribe starts streaming decoded logs into sink. The live subscription is
+// established before backfilling historical logs, so that no logs are missed
+// in between; logs delivered by both are only sent once.
+func (s *LogStream) Subscribe(ctx context.Context, sink chan<- *DecodedLog) (event.Subscription, error) {
+	query := s.query
+	if query.BlockHash != nil {
+		return nil, errors.New("log streams cannot be restricted to a single block")
+	}
+	var cursor LogCursor
+	if query.FromBlock != nil {
+		cursor.Number = query.FromBlock.Uint64()
+	}
+	if s.store != nil {
+		stored, err := s.store.LoadCursor()
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			cursor = *stored
+			query.FromBlock = new(big.Int).SetUint64(cursor.Number)
+		}
+	}
+	// Subscribe to live logs first, buffering them until the backfill is done
+	live := make(chan types.Log, 128)
+	sub, err := s.backend.SubscribeFilterLogs(ctx, ethereum.FilterQuery{Addresses: query.Addresses, Topics: query.Topics}, live)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+
+		var (
+			next = cursor // Position of the next new log to deliver
+			high = cursor // Position past the last ever delivered log
+		)
+		deliver := func(log types.Log) (bool, error) {
+			if log.Removed {
+				// Only compensate logs which were actually delivered, and rewind
+				// the cursor so their replacements are delivered too.
+				if !high.before(log.BlockNumber, log.Index) {
+					return true, nil
+				}
+				if next.before(log.BlockNumber, log.Index) {
+					next = LogCursor{Number: log.BlockNumber, Index: log.Index}
+				}
+			} else {
+				if next.before(log.BlockNumber, log.Index) {
+					return true, nil
+				}
+				next = LogCursor{Number: log.BlockNumber, Index: log.Index + 1}
+				if !high.before(next.Number, next.Index) {
+					high = next
+				}
+			}
+			select {
+			case sink <- s.registry.Decode(log):
+			case <-quit:
+				return false, nil
+			}
+			if s.store != nil {
+				if err := s.store.StoreCursor(next); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+		// Backfill all historical logs since the cursor
+		logs, err := s.backend.FilterLogs(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if ok, err := deliver(log); !ok || err != nil {
+				return err
+			}
+		}
+		// Switch over to the live subscription
+		for {
+			select {
+			case log := <-live:
+				if ok, err := deliver(log); !ok || err != nil {
+					return err
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const transferABI = `[{"type":"event","name":"Transfer","inputs":[
+	{"name":"from","type":"address","indexed":true},
+	{"name":"to","type":"address","indexed":true},
+	{"name":"value","type":"uint256","indexed":false}
+]}]`
+
+// testLogFilterer is a log backend serving a fixed set of historical logs and
+// forwarding live logs fed through a channel.
+type testLogFilterer struct {
+	history []types.Log
+	live    chan types.Log
+	from    *big.Int // FromBlock of the last historical query
+}
+
+func (b *testLogFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	b.from = q.FromBlock
+
+	var logs []types.Log
+	for _, log := range b.history {
+		if q.FromBlock == nil || log.BlockNumber >= q.FromBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (b *testLogFilterer) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case log := <-b.live:
+				select {
+				case ch <- log:
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func newTransferLog(contract *abi.ABI, address common.Address, number uint64, index uint, value int64) types.Log {
+	data, _ := contract.Events["Transfer"].Inputs.NonIndexed().Pack(big.NewInt(value))
+	return types.Log{
+		Address:     address,
+		Topics:      []common.Hash{contract.Events["Transfer"].ID, common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0x02})},
+		Data:        data,
+		BlockNumber: number,
+		Index:       index,
+	}
+}
+
+func readDecodedLog(t *testing.T, sink chan *DecodedLog) *DecodedLog {
+	t.Helper()
+	select {
+	case log := <-sink:
+		return log
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for log")
+		return nil
+	}
+}
+
+// Tests that a log stream decodes logs, deduplicates logs delivered by both the
+// backfill and the live subscription, compensates reorged logs and resumes from
+// the persisted cursor.
+func TestLogStream(t *testing.T) {
+	contract, err := abi.JSON(strings.NewReader(transferABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := common.Address{0xaa}
+	registry := NewABIRegistry()
+	registry.Register(address, &contract)
+
+	backend := &testLogFilterer{
+		history: []types.Log{
+			newTransferLog(&contract, address, 1, 0, 10),
+			newTransferLog(&contract, address, 2, 0, 20),
+		},
+		live: make(chan types.Log),
+	}
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursor.json"))
+
+	stream := NewLogStream(backend, registry, ethereum.FilterQuery{}, store)
+	sink := make(chan *DecodedLog)
+	sub, err := stream.Subscribe(context.Background(), sink)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for _, want := range []int64{10, 20} {
+		log := readDecodedLog(t, sink)
+		if log.Event == nil || log.Event.Name != "Transfer" {
+			t.Fatalf("log not decoded: %+v", log)
+		}
+		if have := log.Fields["value"].(*big.Int); have.Int64() != want {
+			t.Errorf("value mismatch: have %v, want %v", have, want)
+		}
+		if have := log.Fields["to"].(common.Address); have != common.BytesToAddress([]byte{0x02}) {
+			t.Errorf("indexed field mismatch: have %x", have)
+		}
+	}
+	// Replay an already backfilled log, followed by a new one
+	backend.live <- backend.history[1]
+	backend.live <- newTransferLog(&contract, address, 3, 0, 30)
+	if log := readDecodedLog(t, sink); log.Log.BlockNumber != 3 || log.Removed {
+		t.Fatalf("unexpected log: have block %d removed %v, want block 3", log.Log.BlockNumber, log.Removed)
+	}
+	// Reorg out block 3 and replace it with a different log
+	removed := newTransferLog(&contract, address, 3, 0, 30)
+	removed.Removed = true
+	backend.live <- removed
+	if log := readDecodedLog(t, sink); !log.Removed || log.Fields["value"].(*big.Int).Int64() != 30 {
+		t.Fatalf("missing compensating log: %+v", log)
+	}
+	backend.live <- newTransferLog(&contract, address, 3, 0, 31)
+	if log := readDecodedLog(t, sink); log.Removed || log.Fields["value"].(*big.Int).Int64() != 31 {
+		t.Fatalf("missing replacement log: %+v", log)
+	}
+	sub.Unsubscribe()
+
+	cursor, err := store.LoadCursor()
+	if err != nil {
+		t.Fatalf("failed to load cursor: %v", err)
+	}
+	if want := (LogCursor{Number: 3, Index: 1}); cursor == nil || *cursor != want {
+		t.Fatalf("cursor mismatch: have %v, want %v", cursor, want)
+	}
+	// Restart the stream and ensure it resumes after the last delivered log
+	backend.history = append(backend.history, newTransferLog(&contract, address, 3, 0, 31), newTransferLog(&contract, address, 3, 1, 32))
+	sub, err = stream.Subscribe(context.Background(), sink)
+	if err != nil {
+		t.Fatalf("failed to resubscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if log := readDecodedLog(t, sink); log.Fields["value"].(*big.Int).Int64() != 32 {
+		t.Fatalf("unexpected resumed log: %+v", log)
+	}
+	if backend.from == nil || backend.from.Uint64() != 3 {
+		t.Errorf("backfill start mismatch: have %v, want 3", backend.from)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ErrLogsMismatch is returned by verified log queries if the logs returned by the
+// provider are inconsistent with the receipts committed to in the block headers.
+var ErrLogsMismatch = errors.New("logs do not match block receipts")
+
+// verifyBatchBlocks is the number of blocks whose headers and receipts are
+// requested in a single batch call during log verification.
+const verifyBatchBlocks = 16
+
+// verifyLogs checks the result of a log query by recomputing the receipts root
+// and logs bloom of every block that logs were returned for, and by matching the
+// filter against the full receipt list of those blocks. If the query targets a
+// single block, that block is checked even if no logs were returned.
+//
+// Note the headers themselves are trusted, omitted logs are only detected in the
+// blocks which are verified.
+func (ec *Client) verifyLogs(ctx context.Context, q ethereum.FilterQuery, logs []types.Log) error {
+	var (
+		hashes []common.Hash
+		byHash = make(map[common.Hash][]types.Log)
+	)
+	if q.BlockHash != nil {
+		hashes = append(hashes, *q.BlockHash)
+		byHash[*q.BlockHash] = nil
+	}
+	for _, log := range logs {
+		if _, ok := byHash[log.BlockHash]; !ok {
+			hashes = append(hashes, log.BlockHash)
+		}
+		byHash[log.BlockHash] = append(byHash[log.BlockHash], log)
+	}
+	for start := 0; start < len(hashes); start += verifyBatchBlocks {
+		end := start + verifyBatchBlocks
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		blocks, err := ec.blockReceipts(ctx, hashes[start:end])
+		if err != nil {
+			return err
+		}
+		for i, hash := range hashes[start:end] {
+			if err := verifyBlockLogs(q, hash, blocks[i].header, blocks[i].receipts, byHash[hash]); err != nil {
+				return fmt.Errorf("%w: block %x: %v", ErrLogsMismatch, hash, err)
+			}
+		}
+	}
+	return nil
+}
+
+type blockReceipts struct {
+	header   *types.Header
+	receipts types.Receipts
+}
+
+// blockReceipts retrieves the headers and all receipts of the given blocks using
+// two batch calls.
+func (ec *Client) blockReceipts(ctx context.Context, hashes []common.Hash) ([]blockReceipts, error) {
+	var (
+		raws = make([]json.RawMessage, len(hashes))
+		reqs = make([]rpc.BatchElem, len(hashes))
+	)
+	for i, hash := range hashes {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBlockByHash",
+			Args:   []interface{}{hash, false},
+			Result: &raws[i],
+		}
+	}
+	if err := ec.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	var (
+		blocks  = make([]blockReceipts, len(hashes))
+		txs     = make([][]common.Hash, len(hashes))
+		rreqs   []rpc.BatchElem
+		results []*types.Receipt
+	)
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			return nil, reqs[i].Error
+		}
+		if len(raws[i]) == 0 || bytes.Equal(raws[i], []byte("null")) {
+			return nil, fmt.Errorf("block %x: %w", hashes[i], ethereum.NotFound)
+		}
+		var body struct {
+			Transactions []common.Hash `json:"transactions"`
+		}
+		if err := json.Unmarshal(raws[i], &blocks[i].header); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raws[i], &body); err != nil {
+			return nil, err
+		}
+		txs[i] = body.Transactions
+		for _, tx := range body.Transactions {
+			rreqs = append(rreqs, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{tx},
+			})
+		}
+	}
+	results = make([]*types.Receipt, len(rreqs))
+	for i := range rreqs {
+		rreqs[i].Result = &results[i]
+	}
+	if len(rreqs) > 0 {
+		if err := ec.batchCall(ctx, rreqs); err != nil {
+			return nil, err
+		}
+	}
+	for i, n := 0, 0; i < len(blocks); i++ {
+		for _, tx := range txs[i] {
+			if rreqs[n].Error != nil {
+				return nil, rreqs[n].Error
+			}
+			receipt := results[n]
+			if receipt == nil {
+				return nil, fmt.Errorf("receipt %x: %w", tx, ethereum.NotFound)
+			}
+			if receipt.TxHash != tx || receipt.BlockHash != hashes[i] {
+				return nil, fmt.Errorf("%w: receipt %x does not belong to block %x", ErrLogsMismatch, tx, hashes[i])
+			}
+			blocks[i].receipts = append(blocks[i].receipts, receipt)
+			n++
+		}
+	}
+	return blocks, nil
+}
+
+// verifyBlockLogs checks the receipts of a block against its header and the logs
+// returned for the block against the filter matches in the receipts.
+func verifyBlockLogs(q ethereum.FilterQuery, hash common.Hash, header *types.Header, receipts types.Receipts, logs []types.Log) error {
+	if have := header.Hash(); have != hash {
+		return fmt.Errorf("header hash mismatch: have %x", have)
+	}
+	if root := types.DeriveSha(receipts, trie.NewStackTrie(nil)); root != header.ReceiptHash {
+		return fmt.Errorf("receipts root mismatch: have %x, want %x", root, header.ReceiptHash)
+	}
+	if bloom := types.CreateBloom(receipts); bloom != header.Bloom {
+		return errors.New("logs bloom mismatch")
+	}
+	// Collect the logs matching the filter, numbering them locally
+	var (
+		want  []*types.Log
+		index uint
+	)
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if matchLog(q, log) {
+				want = append(want, &types.Log{
+					Address:     log.Address,
+					Topics:      log.Topics,
+					Data:        log.Data,
+					BlockNumber: header.Number.Uint64(),
+					TxHash:      receipt.TxHash,
+					TxIndex:     uint(i),
+					BlockHash:   hash,
+					Index:       index,
+				})
+			}
+			index++
+		}
+	}
+	if len(logs) != len(want) {
+		return fmt.Errorf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i := range logs {
+		if !equalLogs(&logs[i], want[i]) {
+			return fmt.Errorf("log %d mismatch", want[i].Index)
+		}
+	}
+	return nil
+}
+
+// matchLog reports whether the log is selected by the address and topic criteria
+// of the filter.
+func matchLog(q ethereum.FilterQuery, log *types.Log) bool {
+	if len(q.Addresses) > 0 {
+		var found bool
+		for _, addr := range q.Addresses {
+			if addr == log.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range q.Topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		var found bool
+		for _, topic := range sub {
+			if topic == log.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func equalLogs(a, b *types.Log) bool {
+	if a.Address != b.Address || !bytes.Equal(a.Data, b.Data) || len(a.Topics) != len(b.Topics) {
+		return false
+	}
+	for i := range a.Topics {
+		if a.Topics[i] != b.Topics[i] {
+			return false
+		}
+	}
+	return a.BlockNumber == b.BlockNumber && a.BlockHash == b.BlockHash &&
+		a.TxHash == b.TxHash && a.TxIndex == b.TxIndex && a.Index == b.Index && !a.Removed
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func testVerifiedFilterLogs(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	// Block #2 contains transactions without logs, all receipts are checked
+	hash := chain[2].Hash()
+	logs, err := ec.FilterLogs(context.Background(), ethereum.FilterQuery{BlockHash: &hash, Verified: true})
+	if err != nil {
+		t.Fatalf("verified query failed: %v", err)
+	}
+	if len(logs) != 0 {
+		t.Fatalf("unexpected logs: %v", logs)
+	}
+}
+
+func TestVerifyBlockLogs(t *testing.T) {
+	var (
+		token    = common.Address{0xaa}
+		other    = common.Address{0xbb}
+		transfer = common.Hash{0x01}
+		approval = common.Hash{0x02}
+	)
+	receipts := types.Receipts{
+		{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 50000,
+			TxHash:            common.Hash{0x10},
+			Logs: []*types.Log{
+				{Address: token, Topics: []common.Hash{transfer}, Data: []byte{1}},
+				{Address: other, Topics: []common.Hash{transfer}, Data: []byte{2}},
+			},
+		},
+		{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 90000,
+			TxHash:            common.Hash{0x11},
+			Logs: []*types.Log{
+				{Address: token, Topics: []common.Hash{approval}, Data: []byte{3}},
+				{Address: token, Topics: []common.Hash{transfer}, Data: []byte{4}},
+			},
+		},
+	}
+	for _, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
+	header := &types.Header{
+		Number:      big.NewInt(7),
+		Difficulty:  big.NewInt(1),
+		ReceiptHash: types.DeriveSha(receipts, trie.NewStackTrie(nil)),
+		Bloom:       types.CreateBloom(receipts),
+	}
+	hash := header.Hash()
+	query := ethereum.FilterQuery{Addresses: []common.Address{token}, Topics: [][]common.Hash{{transfer}}}
+
+	valid := func() []types.Log {
+		return []types.Log{
+			{Address: token, Topics: []common.Hash{transfer}, Data: []byte{1}, BlockNumber: 7, BlockHash: hash, TxHash: common.Hash{0x10}, TxIndex: 0, Index: 0},
+			{Address: token, Topics: []common.Hash{transfer}, Data: []byte{4}, BlockNumber: 7, BlockHash: hash, TxHash: common.Hash{0x11}, TxIndex: 1, Index: 3},
+		}
+	}
+	if err := verifyBlockLogs(query, hash, header, receipts, valid()); err != nil {
+		t.Fatalf("valid logs rejected: %v", err)
+	}
+	// Omitted, forged and misplaced logs must be detected
+	if err := verifyBlockLogs(query, hash, header, receipts, valid()[:1]); err == nil {
+		t.Error("omitted log accepted")
+	}
+	forged := valid()
+	forged[1].Data = []byte{5}
+	if err := verifyBlockLogs(query, hash, header, receipts, forged); err == nil {
+		t.Error("forged log data accepted")
+	}
+	forged = valid()
+	forged[1].Index = 2
+	if err := verifyBlockLogs(query, hash, header, receipts, forged); err == nil {
+		t.Error("wrong log index accepted")
+	}
+	// Tampered receipts and headers must be detected
+	if err := verifyBlockLogs(query, hash, header, receipts[:1], valid()[:1]); err == nil {
+		t.Error("incomplete receipts accepted")
+	}
+	if err := verifyBlockLogs(query, common.Hash{0x01}, header, receipts, valid()); err == nil {
+		t.Error("foreign header accepted")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/time/rate"
+)
+
+// MultiChainClient is a set of clients connected to different chains, keyed by
+// their chain IDs, for services monitoring multiple chains. The requests of all
+// clients in the set share a common rate limit.
+type MultiChainClient struct {
+	limiter *rate.Limiter // Limiter shared by all clients, nil if unlimited
+
+	lock    sync.RWMutex
+	clients map[uint64]*Client
+}
+
+// NewMultiChainClient creates an empty client set, whose clients may send at
+// most limit requests per second in total with the given burst size. A limit of
+// rate.Inf disables rate limiting.
+func NewMultiChainClient(limit rate.Limit, burst int) *MultiChainClient {
+	m := &MultiChainClient{clients: make(map[uint64]*Client)}
+	if limit != rate.Inf {
+		m.limiter = rate.NewLimiter(limit, burst)
+	}
+	return m
+}
+
+// Dial connects a client to the given URL and adds it to the set under the chain
+// ID reported by the provider, which is returned.
+func (m *MultiChainClient) Dial(ctx context.Context, rawurl string) (uint64, error) {
+	c, err := DialContext(ctx, rawurl)
+	if err != nil {
+		return 0, err
+	}
+	id, err := m.Add(ctx, c)
+	if err != nil {
+		c.Close()
+		return 0, err
+	}
+	return id, nil
+}
+
+// Add adds a client to the set under the chain ID reported by its provider, which
+// is returned. The client becomes subject to the rate limit of the set. Adding a
+// client for a chain already in the set fails.
+func (m *MultiChainClient) Add(ctx context.Context, c *Client) (uint64, error) {
+	c.SetRateLimiter(m.limiter)
+
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !chainID.IsUint64() {
+		return 0, fmt.Errorf("chain ID %v out of range", chainID)
+	}
+	id := chainID.Uint64()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.clients[id]; ok {
+		return 0, fmt.Errorf("chain %d already connected", id)
+	}
+	m.clients[id] = c
+	return id, nil
+}
+
+// Remove closes the client of the given chain and removes it from the set.
+func (m *MultiChainClient) Remove(chainID uint64) {
+	m.lock.Lock()
+	c := m.clients[chainID]
+	delete(m.clients, chainID)
+	m.lock.Unlock()
+
+	if c != nil {
+		c.Close()
+	}
+}
+
+// On returns the client of the given chain, or nil if the chain is not in the
+// set.
+func (m *MultiChainClient) On(chainID uint64) *Client {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.clients[chainID]
+}
+
+// ChainIDs returns the IDs of the chains in the set, in ascending order.
+func (m *MultiChainClient) ChainIDs() []uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	ids := make([]uint64, 0, len(m.clients))
+	for id := range m.clients {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Close closes the clients of all chains and empties the set.
+func (m *MultiChainClient) Close() {
+	m.lock.Lock()
+	clients := m.clients
+	m.clients = make(map[uint64]*Client)
+	m.lock.Unlock()
+
+	for _, c := range clients {
+		c.Close()
+	}
+}
+
+// BalanceAt returns the latest balance of the given account on every chain in
+// the set.
+func (m *MultiChainClient) BalanceAt(ctx context.Context, account common.Address) (map[uint64]*big.Int, error) {
+	return QueryAll(ctx, m, func(ctx context.Context, c *Client) (*big.Int, error) {
+		return c.BalanceAt(ctx, account, nil)
+	})
+}
+
+// NonceAt returns the latest nonce of the given account on every chain in the set.
+func (m *MultiChainClient) NonceAt(ctx context.Context, account common.Address) (map[uint64]uint64, error) {
+	return QueryAll(ctx, m, func(ctx context.Context, c *Client) (uint64, error) {
+		return c.NonceAt(ctx, account, nil)
+	})
+}
+
+// CodeAt returns the latest code of the given account on every chain in the set.
+func (m *MultiChainClient) CodeAt(ctx context.Context, account common.Address) (map[uint64][]byte, error) {
+	return QueryAll(ctx, m, func(ctx context.Context, c *Client) ([]byte, error) {
+		return c.CodeAt(ctx, account, nil)
+	})
+}
+
+// BlockNumber returns the most recent block number of every chain in the set.
+func (m *MultiChainClient) BlockNumber(ctx context.Context) (map[uint64]uint64, error) {
+	return QueryAll(ctx, m, func(ctx context.Context, c *Client) (uint64, error) {
+		return c.BlockNumber(ctx)
+	})
+}
+
+// MultiChainError holds the errors of the chains whose queries failed in an
+// aggregate query, keyed by chain ID.
+type MultiChainError map[uint64]error
+
+func (e MultiChainError) Error() string {
+	ids := make([]uint64, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("chain %d: %v", id, e[id])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// QueryAll runs a query on every chain of the set concurrently, returning the
+// results keyed by chain ID. If some queries fail, the results of the others are
+// returned along with a MultiChainError.
+func QueryAll[T any](ctx context.Context, m *MultiChainClient, query func(context.Context, *Client) (T, error)) (map[uint64]T, error) {
+	m.lock.RLock()
+	clients := make(map[uint64]*Client, len(m.clients))
+	for id, c := range m.clients {
+		clients[id] = c
+	}
+	m.lock.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		results = make(map[uint64]T, len(clients))
+		errs    = make(MultiChainError)
+	)
+	for id, c := range clients {
+		wg.Add(1)
+		go func(id uint64, c *Client) {
+			defer wg.Done()
+
+			res, err := query(ctx, c)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[id] = err
+			} else {
+				results[id] = res
+			}
+		}(id, c)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
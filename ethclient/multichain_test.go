@@ -0,0 +1,136 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// chainService mimics the provider of a chain with a single funded account.
+type chainService struct {
+	id      uint64
+	balance *big.Int
+	failing bool
+}
+
+func (s *chainService) ChainId() hexutil.Uint64 {
+	return hexutil.Uint64(s.id)
+}
+
+func (s *chainService) GetBalance(account common.Address, block string) (*hexutil.Big, error) {
+	if s.failing {
+		return nil, errors.New("unavailable")
+	}
+	return (*hexutil.Big)(s.balance), nil
+}
+
+// newChainClient creates a client connected to a fake chain provider.
+func newChainClient(t *testing.T, service *chainService) *Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return NewClient(rpc.DialInProc(server))
+}
+
+func TestMultiChainClient(t *testing.T) {
+	m := NewMultiChainClient(rate.Inf, 0)
+	defer m.Close()
+
+	ctx := context.Background()
+	for _, service := range []*chainService{
+		{id: 1, balance: big.NewInt(100)},
+		{id: 10, balance: big.NewInt(200)},
+		{id: 137, failing: true},
+	} {
+		if _, err := m.Add(ctx, newChainClient(t, service)); err != nil {
+			t.Fatalf("failed to add chain %d: %v", service.id, err)
+		}
+	}
+	if _, err := m.Add(ctx, newChainClient(t, &chainService{id: 1})); err == nil {
+		t.Fatal("added duplicate chain")
+	}
+	if ids := m.ChainIDs(); !reflect.DeepEqual(ids, []uint64{1, 10, 137}) {
+		t.Fatalf("chain IDs mismatch: have %v, want [1 10 137]", ids)
+	}
+	// Chains must be individually accessible
+	account := common.Address{0x01}
+	balance, err := m.On(10).BalanceAt(ctx, account, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve balance: %v", err)
+	}
+	if balance.Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("balance mismatch: have %v, want 200", balance)
+	}
+	if m.On(5) != nil {
+		t.Fatal("client returned for unknown chain")
+	}
+	// Aggregate queries must return the successful results along with the errors
+	balances, err := m.BalanceAt(ctx, account)
+	var errs MultiChainError
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[137] == nil {
+		t.Fatalf("aggregate error mismatch: have %v, want chain 137 failure", err)
+	}
+	want := map[uint64]*big.Int{1: big.NewInt(100), 10: big.NewInt(200)}
+	if !reflect.DeepEqual(balances, want) {
+		t.Fatalf("balances mismatch: have %v, want %v", balances, want)
+	}
+	m.Remove(137)
+	if _, err := m.BalanceAt(ctx, account); err != nil {
+		t.Fatalf("aggregate query failed: %v", err)
+	}
+}
+
+func TestMultiChainRateLimit(t *testing.T) {
+	// Allow one request per 50ms in total, the chain ID queries included
+	m := NewMultiChainClient(rate.Every(50*time.Millisecond), 1)
+	defer m.Close()
+
+	ctx := context.Background()
+	for id := uint64(1); id <= 2; id++ {
+		if _, err := m.Add(ctx, newChainClient(t, &chainService{id: id, balance: big.NewInt(1)})); err != nil {
+			t.Fatalf("failed to add chain %d: %v", id, err)
+		}
+	}
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := m.BalanceAt(ctx, common.Address{}); err != nil {
+			t.Fatalf("aggregate query failed: %v", err)
+		}
+	}
+	// Four requests after the two chain ID queries drained the burst
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("requests not rate limited: 4 requests took %v", elapsed)
+	}
+	// Canceled requests must not wait for the limiter
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := m.On(1).BalanceAt(cctx, common.Address{}, nil); err == nil {
+		t.Fatal("canceled request succeeded")
+	}
+}
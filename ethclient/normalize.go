@@ -0,0 +1,115 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NormalizedBlock is a fork independent view of a block. Fields introduced by
+// later forks are always populated, with flags reporting whether the block
+// actually carries them. Fields absent in a block hold zero values, never nil.
+type NormalizedBlock struct {
+	Number       uint64
+	Hash         common.Hash
+	ParentHash   common.Hash
+	Time         uint64
+	Coinbase     common.Address
+	GasLimit     uint64
+	GasUsed      uint64
+	Extra        []byte
+	Transactions types.Transactions
+
+	// PostMerge is set for blocks produced by proof-of-stake. Before the merge,
+	// Difficulty holds the proof-of-work difficulty and PrevRandao is zero.
+	// After it, Difficulty is zero and PrevRandao holds the beacon chain
+	// randomness, which reuses the mix digest field of the header.
+	PostMerge  bool
+	Difficulty *big.Int
+	PrevRandao common.Hash
+
+	// HasBaseFee is set for blocks after London.
+	HasBaseFee bool
+	BaseFee    *big.Int
+
+	// HasWithdrawals is set for blocks after Shanghai, which may still contain
+	// no withdrawals.
+	HasWithdrawals bool
+	Withdrawals    types.Withdrawals
+}
+
+// NormalizeBlock converts a block into its fork independent view.
+func NormalizeBlock(block *types.Block) *NormalizedBlock {
+	header := block.Header()
+	norm := &NormalizedBlock{
+		Number:       header.Number.Uint64(),
+		Hash:         block.Hash(),
+		ParentHash:   header.ParentHash,
+		Time:         header.Time,
+		Coinbase:     header.Coinbase,
+		GasLimit:     header.GasLimit,
+		GasUsed:      header.GasUsed,
+		Extra:        header.Extra,
+		Transactions: block.Transactions(),
+		Difficulty:   new(big.Int),
+		BaseFee:      new(big.Int),
+		Withdrawals:  types.Withdrawals{},
+	}
+	// Post-merge blocks are identified by their zero difficulty, the genesis
+	// block of a proof-of-stake network being no exception.
+	if header.Difficulty != nil && header.Difficulty.Sign() > 0 {
+		norm.Difficulty.Set(header.Difficulty)
+	} else {
+		norm.PostMerge = true
+		norm.PrevRandao = header.MixDigest
+	}
+	if header.BaseFee != nil {
+		norm.HasBaseFee = true
+		norm.BaseFee.Set(header.BaseFee)
+	}
+	if header.WithdrawalsHash != nil {
+		norm.HasWithdrawals = true
+		if withdrawals := block.Withdrawals(); withdrawals != nil {
+			norm.Withdrawals = withdrawals
+		}
+	}
+	return norm
+}
+
+// NormalizedBlockByHash returns the fork independent view of the block with the
+// given hash.
+func (ec *Client) NormalizedBlockByHash(ctx context.Context, hash common.Hash) (*NormalizedBlock, error) {
+	block, err := ec.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeBlock(block), nil
+}
+
+// NormalizedBlockByNumber returns the fork independent view of a block from the
+// current canonical chain. If number is nil, the latest known block is returned.
+func (ec *Client) NormalizedBlockByNumber(ctx context.Context, number *big.Int) (*NormalizedBlock, error) {
+	block, err := ec.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeBlock(block), nil
+}
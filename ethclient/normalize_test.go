@@ -0,0 +1,98 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func testNormalizedBlock(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	block, err := ec.NormalizedBlockByNumber(context.Background(), big.NewInt(2))
+	if err != nil {
+		t.Fatalf("normalized block query failed: %v", err)
+	}
+	if block.Hash != chain[2].Hash() || len(block.Transactions) != 2 {
+		t.Fatalf("block mismatch: have %x with %d txs, want %x with 2 txs", block.Hash, len(block.Transactions), chain[2].Hash())
+	}
+	if block.PostMerge || block.Difficulty.Cmp(chain[2].Difficulty()) != 0 {
+		t.Errorf("proof-of-work block misclassified: postmerge %v, difficulty %v", block.PostMerge, block.Difficulty)
+	}
+	if !block.HasBaseFee || block.BaseFee.Cmp(chain[2].BaseFee()) != 0 {
+		t.Errorf("base fee mismatch: have %v (%v), want %v", block.BaseFee, block.HasBaseFee, chain[2].BaseFee())
+	}
+	if block.HasWithdrawals || block.Withdrawals == nil {
+		t.Errorf("unexpected withdrawals: %v (%v)", block.Withdrawals, block.HasWithdrawals)
+	}
+	if _, err := ec.NormalizedBlockByHash(context.Background(), chain[1].Hash()); err != nil {
+		t.Fatalf("normalized block query by hash failed: %v", err)
+	}
+}
+
+func TestNormalizeBlock(t *testing.T) {
+	var (
+		randao      = common.Hash{0x42}
+		withdrawals = types.Withdrawals{{Index: 1, Validator: 2, Address: common.Address{0xaa}, Amount: 3}}
+		emptyRoot   = types.EmptyWithdrawalsHash
+	)
+	// Pre-London proof-of-work block
+	legacy := NormalizeBlock(types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(131072),
+		MixDigest:  common.Hash{0x01},
+	}))
+	if legacy.PostMerge || legacy.Difficulty.Uint64() != 131072 || legacy.PrevRandao != (common.Hash{}) {
+		t.Errorf("legacy block: unexpected consensus fields: %+v", legacy)
+	}
+	if legacy.HasBaseFee || legacy.BaseFee == nil || legacy.BaseFee.Sign() != 0 {
+		t.Errorf("legacy block: unexpected base fee %v (%v)", legacy.BaseFee, legacy.HasBaseFee)
+	}
+	if legacy.HasWithdrawals || legacy.Withdrawals == nil {
+		t.Errorf("legacy block: unexpected withdrawals %v (%v)", legacy.Withdrawals, legacy.HasWithdrawals)
+	}
+	// Post-merge block without withdrawals
+	merged := NormalizeBlock(types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(2),
+		Difficulty: new(big.Int),
+		MixDigest:  randao,
+		BaseFee:    big.NewInt(7),
+	}))
+	if !merged.PostMerge || merged.Difficulty.Sign() != 0 || merged.PrevRandao != randao {
+		t.Errorf("merged block: unexpected consensus fields: %+v", merged)
+	}
+	if !merged.HasBaseFee || merged.BaseFee.Uint64() != 7 {
+		t.Errorf("merged block: unexpected base fee %v (%v)", merged.BaseFee, merged.HasBaseFee)
+	}
+	// Post-Shanghai blocks, with and without withdrawals
+	header := &types.Header{Number: big.NewInt(3), Difficulty: new(big.Int), BaseFee: big.NewInt(7), WithdrawalsHash: &emptyRoot}
+	empty := NormalizeBlock(types.NewBlockWithHeader(header))
+	if !empty.HasWithdrawals || empty.Withdrawals == nil || len(empty.Withdrawals) != 0 {
+		t.Errorf("shanghai block: unexpected withdrawals %v (%v)", empty.Withdrawals, empty.HasWithdrawals)
+	}
+	full := NormalizeBlock(types.NewBlockWithWithdrawals(header, nil, nil, nil, withdrawals, trie.NewStackTrie(nil)))
+	if !full.HasWithdrawals || len(full.Withdrawals) != 1 || full.Withdrawals[0].Amount != 3 {
+		t.Errorf("shanghai block: unexpected withdrawals %v (%v)", full.Withdrawals, full.HasWithdrawals)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package nsclient provides typed RPC clients for custom API namespaces.
+//
+// The methods of a namespace are declared as the function fields of a struct,
+// which are bound to the corresponding RPC methods by reflection:
+//
+//	type ResearchAPI struct {
+//		Balance func(ctx context.Context, addr common.Address) (*hexutil.Big, error)
+//		Reset   func(ctx context.Context) error
+//		Watch   func(ctx context.Context, ch chan<- *Event, addr common.Address) (*rpc.ClientSubscription, error)
+//		Version func() (string, error) `rpc:"clientVersion"`
+//	}
+//
+//	client, err := nsclient.New[ResearchAPI](c, "research")
+//	balance, err := client.API.Balance(ctx, addr)
+//
+// A function field calls the method named after the field with its first letter
+// lowercased, as the server side names the methods of a service, unless the name
+// is given by an rpc struct tag. The context parameter is optional and must come
+// first. A function returning only an error discards the result of the call,
+// while one returning a value and an error decodes the result into that value.
+//
+// Subscriptions are declared by a channel parameter, following the optional
+// context, and a *rpc.ClientSubscription and error result. The name of the field
+// is the subscription name, the channel receives the notifications.
+//
+// Fields that are not functions, or that are unexported or tagged with rpc:"-",
+// are left untouched.
+package nsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	contextType      = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	subscriptionType = reflect.TypeOf((*rpc.ClientSubscription)(nil))
+)
+
+// Client is a typed client of an RPC namespace, whose methods are the function
+// fields of API.
+type Client[T any] struct {
+	API T
+
+	c         *rpc.Client
+	namespace string
+}
+
+// New creates a client of the given namespace, binding the function fields of T
+// to the methods of the namespace. An error is returned if T is not a struct or
+// any of its function fields has an unsupported signature.
+func New[T any](c *rpc.Client, namespace string) (*Client[T], error) {
+	client := &Client[T]{c: c, namespace: namespace}
+
+	api := reflect.ValueOf(&client.API).Elem()
+	if api.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("namespace API must be a struct, have %v", api.Type())
+	}
+	for i := 0; i < api.NumField(); i++ {
+		field := api.Type().Field(i)
+		if field.Type.Kind() != reflect.Func || !field.IsExported() {
+			continue
+		}
+		name, ok := field.Tag.Lookup("rpc")
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = formatName(field.Name)
+		}
+		fn, err := client.bind(name, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		api.Field(i).Set(fn)
+	}
+	return client, nil
+}
+
+// Namespace returns the namespace the client calls the methods of.
+func (c *Client[T]) Namespace() string {
+	return c.namespace
+}
+
+// Client returns the underlying RPC client.
+func (c *Client[T]) Client() *rpc.Client {
+	return c.c
+}
+
+// bind creates the implementation of a function field of the given type calling
+// the named method.
+func (c *Client[T]) bind(name string, typ reflect.Type) (reflect.Value, error) {
+	if typ.IsVariadic() {
+		return reflect.Value{}, errors.New("variadic parameters are not supported")
+	}
+	// Split off the optional context parameter
+	params := 0
+	hasCtx := typ.NumIn() > 0 && typ.In(0) == contextType
+	if hasCtx {
+		params = 1
+	}
+	for i := params; i < typ.NumIn(); i++ {
+		if typ.In(i) == contextType {
+			return reflect.Value{}, errors.New("context must be the first parameter")
+		}
+	}
+	// Check the results, a subscription being told apart by its result
+	if typ.NumOut() == 0 || typ.Out(typ.NumOut()-1) != errorType {
+		return reflect.Value{}, errors.New("last result must be an error")
+	}
+	if typ.NumOut() > 2 {
+		return reflect.Value{}, fmt.Errorf("too many results: %d", typ.NumOut())
+	}
+	if typ.NumOut() == 2 && typ.Out(0) == subscriptionType {
+		if typ.NumIn() <= params || typ.In(params).Kind() != reflect.Chan || typ.In(params).ChanDir()&reflect.SendDir == 0 {
+			return reflect.Value{}, errors.New("subscription requires a sendable channel parameter")
+		}
+		return reflect.MakeFunc(typ, func(in []reflect.Value) []reflect.Value {
+			ctx, args := splitArgs(in, hasCtx)
+			sub, err := c.c.Subscribe(ctx, c.namespace, args[0], append([]interface{}{name}, args[1:]...)...)
+			return []reflect.Value{reflect.ValueOf(sub), errorValue(err)}
+		}), nil
+	}
+	method := c.namespace + "_" + name
+	if typ.NumOut() == 1 {
+		return reflect.MakeFunc(typ, func(in []reflect.Value) []reflect.Value {
+			ctx, args := splitArgs(in, hasCtx)
+			return []reflect.Value{errorValue(c.c.CallContext(ctx, nil, method, args...))}
+		}), nil
+	}
+	out := typ.Out(0)
+	return reflect.MakeFunc(typ, func(in []reflect.Value) []reflect.Value {
+		ctx, args := splitArgs(in, hasCtx)
+
+		result := reflect.New(out)
+		if err := c.c.CallContext(ctx, result.Interface(), method, args...); err != nil {
+			return []reflect.Value{reflect.Zero(out), errorValue(err)}
+		}
+		return []reflect.Value{result.Elem(), errorValue(nil)}
+	}), nil
+}
+
+// splitArgs separates the context from the arguments of a call.
+func splitArgs(in []reflect.Value, hasCtx bool) (context.Context, []interface{}) {
+	ctx := context.Background()
+	if hasCtx {
+		if !in[0].IsNil() {
+			ctx = in[0].Interface().(context.Context)
+		}
+		in = in[1:]
+	}
+	args := make([]interface{}, len(in))
+	for i, arg := range in {
+		args[i] = arg.Interface()
+	}
+	return ctx, args
+}
+
+// errorValue converts an error into a value of the error type, which is the zero
+// value for a nil error.
+func errorValue(err error) reflect.Value {
+	if err == nil {
+		return reflect.Zero(errorType)
+	}
+	return reflect.ValueOf(&err).Elem()
+}
+
+// formatName converts the first character of name to lowercase, as the RPC server
+// does for the method names of services.
+func formatName(name string) string {
+	ret := []rune(name)
+	if len(ret) > 0 {
+		ret[0] = unicode.ToLower(ret[0])
+	}
+	return string(ret)
+}
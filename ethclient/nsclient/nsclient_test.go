@@ -0,0 +1,164 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nsclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testService struct {
+	resets int
+}
+
+func (s *testService) Add(a, b hexutil.Uint64) hexutil.Uint64 { return a + b }
+func (s *testService) Reset()                                 { s.resets++ }
+func (s *testService) Resets() int                            { return s.resets }
+func (s *testService) Fail() error                            { return errors.New("failed") }
+
+func (s *testService) Count(ctx context.Context, n int) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < n; i++ {
+			notifier.Notify(sub.ID, i)
+		}
+	}()
+	return sub, nil
+}
+
+type testAPI struct {
+	Add    func(ctx context.Context, a, b hexutil.Uint64) (hexutil.Uint64, error)
+	Reset  func(ctx context.Context) error
+	Total  func() (int, error) `rpc:"resets"`
+	Fail   func(ctx context.Context) error
+	Count  func(ctx context.Context, ch chan<- int, n int) (*rpc.ClientSubscription, error)
+	Ignore func() error `rpc:"-"`
+
+	unexported func() error
+	Other      int
+}
+
+func newTestClient(t *testing.T) *Client[testAPI] {
+	server := rpc.NewServer()
+	if err := server.RegisterName("test", new(testService)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+
+	c := rpc.DialInProc(server)
+	t.Cleanup(c.Close)
+
+	client, err := New[testAPI](c, "test")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestCalls(t *testing.T) {
+	var (
+		client = newTestClient(t)
+		ctx    = context.Background()
+	)
+	if sum, err := client.API.Add(ctx, 2, 3); err != nil || sum != 5 {
+		t.Fatalf("add: have %v, %v, want 5", sum, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.API.Reset(ctx); err != nil {
+			t.Fatalf("reset: %v", err)
+		}
+	}
+	if resets, err := client.API.Total(); err != nil || resets != 2 {
+		t.Fatalf("resets: have %v, %v, want 2", resets, err)
+	}
+	if err := client.API.Fail(ctx); err == nil || err.Error() != "failed" {
+		t.Fatalf("fail: have error %v, want %q", err, "failed")
+	}
+	if client.API.Ignore != nil || client.API.unexported != nil {
+		t.Fatalf("ignored fields bound")
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	var (
+		client = newTestClient(t)
+		ch     = make(chan int)
+	)
+	sub, err := client.API.Count(context.Background(), ch, 3)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for want := 0; want < 3; want++ {
+		select {
+		case have := <-ch:
+			if have != want {
+				t.Fatalf("notification mismatch: have %d, want %d", have, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("notification %d not received", want)
+		}
+	}
+}
+
+func TestInvalidAPI(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func() error
+		want string
+	}{
+		{"not a struct", func() error { _, err := New[int](nil, "test"); return err }, "must be a struct"},
+		{"no error", func() error {
+			_, err := New[struct{ F func() int }](nil, "test")
+			return err
+		}, "last result must be an error"},
+		{"variadic", func() error {
+			_, err := New[struct{ F func(...int) error }](nil, "test")
+			return err
+		}, "variadic"},
+		{"late context", func() error {
+			_, err := New[struct {
+				F func(int, context.Context) error
+			}](nil, "test")
+			return err
+		}, "context must be the first parameter"},
+		{"too many results", func() error {
+			_, err := New[struct{ F func() (int, int, error) }](nil, "test")
+			return err
+		}, "too many results"},
+		{"no channel", func() error {
+			_, err := New[struct {
+				F func(context.Context, int) (*rpc.ClientSubscription, error)
+			}](nil, "test")
+			return err
+		}, "sendable channel"},
+	}
+	for _, tt := range tests {
+		err := tt.new()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: have error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
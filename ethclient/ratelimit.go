@@ -0,0 +1,76 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// SetRateLimiter sets the limiter that requests to the provider must pass, nil
+// disables rate limiting. Every call, batch element and subscription counts as
+// one request. The limiter may be shared by multiple clients to limit their
+// requests in total.
+func (ec *Client) SetRateLimiter(limiter *rate.Limiter) {
+	ec.limiterLock.Lock()
+	defer ec.limiterLock.Unlock()
+
+	ec.limiter = limiter
+}
+
+// wait blocks until the given number of requests may be sent to the provider.
+func (ec *Client) wait(ctx context.Context, n int) error {
+	ec.limiterLock.Lock()
+	limiter := ec.limiter
+	ec.limiterLock.Unlock()
+
+	if limiter == nil || n == 0 {
+		return nil
+	}
+	// Batches larger than the burst can never pass at once, so wait for their
+	// elements in burst-sized chunks
+	for n > 0 {
+		chunk := n
+		if burst := limiter.Burst(); chunk > burst && burst > 0 {
+			chunk = burst
+		}
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// batchCall sends a batch of requests once the rate limiter allows.
+func (ec *Client) batchCall(ctx context.Context, reqs []rpc.BatchElem) error {
+	if err := ec.wait(ctx, len(reqs)); err != nil {
+		return err
+	}
+	return ec.c.BatchCallContext(ctx, reqs)
+}
+
+// subscribe creates a subscription in the eth namespace once the rate limiter
+// allows.
+func (ec *Client) subscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+	if err := ec.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	return ec.c.EthSubscribe(ctx, channel, args...)
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tokens
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ERC1155URI returns the metadata URI of the token type. Clients must replace
+// the {id} placeholder of the URI with the hex encoded id.
+func (c *Client) ERC1155URI(opts *bind.CallOpts, token common.Address, id *big.Int) (string, error) {
+	data, err := erc1155ABI.Pack("uri", id)
+	if err != nil {
+		return "", err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return "", err
+	}
+	return unpack[string](erc1155ABI, "uri", output)
+}
+
+// ERC1155BalanceOf returns the balance of the account in the token type.
+func (c *Client) ERC1155BalanceOf(opts *bind.CallOpts, token, account common.Address, id *big.Int) (*big.Int, error) {
+	data, err := erc1155ABI.Pack("balanceOf", account, id)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return unpack[*big.Int](erc1155ABI, "balanceOf", output)
+}
+
+// ERC1155BalanceOfBatch returns the balances of multiple account and token
+// type pairs.
+func (c *Client) ERC1155BalanceOfBatch(opts *bind.CallOpts, token common.Address, accounts []common.Address, ids []*big.Int) ([]*big.Int, error) {
+	if len(accounts) != len(ids) {
+		return nil, errors.New("accounts and ids length mismatch")
+	}
+	data, err := erc1155ABI.Pack("balanceOfBatch", accounts, ids)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := unpack[[]*big.Int](erc1155ABI, "balanceOfBatch", output)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) != len(ids) {
+		return nil, ErrCallFailed
+	}
+	return balances, nil
+}
+
+// ERC1155IsApprovedForAll reports whether the operator may transfer all tokens
+// of the account.
+func (c *Client) ERC1155IsApprovedForAll(opts *bind.CallOpts, token, account, operator common.Address) (bool, error) {
+	data, err := erc1155ABI.Pack("isApprovedForAll", account, operator)
+	if err != nil {
+		return false, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return false, err
+	}
+	return unpack[bool](erc1155ABI, "isApprovedForAll", output)
+}
+
+// ERC1155SafeTransferFrom transfers amount tokens of a type from the owner to
+// the recipient, which is checked to accept them if it is a contract.
+func (c *Client) ERC1155SafeTransferFrom(opts *bind.TransactOpts, token, from, to common.Address, id, amount *big.Int, data []byte) (*types.Transaction, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	return c.transact(opts, erc1155ABI, token, "safeTransferFrom", from, to, id, amount, data)
+}
+
+// ERC1155SafeBatchTransferFrom transfers tokens of multiple types from the
+// owner to the recipient.
+func (c *Client) ERC1155SafeBatchTransferFrom(opts *bind.TransactOpts, token, from, to common.Address, ids, amounts []*big.Int, data []byte) (*types.Transaction, error) {
+	if len(ids) != len(amounts) {
+		return nil, errors.New("ids and amounts length mismatch")
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return c.transact(opts, erc1155ABI, token, "safeBatchTransferFrom", from, to, ids, amounts, data)
+}
+
+// ERC1155SetApprovalForAll allows or disallows the operator to transfer all
+// tokens of the sender.
+func (c *Client) ERC1155SetApprovalForAll(opts *bind.TransactOpts, token, operator common.Address, approved bool) (*types.Transaction, error) {
+	return c.transact(opts, erc1155ABI, token, "setApprovalForAll", operator, approved)
+}
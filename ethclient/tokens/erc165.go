@@ -0,0 +1,249 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tokens
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const erc165ABIJSON = `[
+	{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+var erc165ABI = mustParseABI(erc165ABIJSON)
+
+// InterfaceID is an ERC-165 interface identifier, the XOR of the selectors of
+// all the functions in the interface.
+type InterfaceID [4]byte
+
+// String implements fmt.Stringer.
+func (id InterfaceID) String() string {
+	return fmt.Sprintf("%#x", id[:])
+}
+
+// Interface is a well known contract interface.
+type Interface struct {
+	Name      string        // Name of the interface, e.g. ERC721
+	ID        InterfaceID   // ERC-165 identifier of the interface
+	Selectors []InterfaceID // Selectors of the functions in the interface
+}
+
+// newInterface creates an interface from the signatures of its functions,
+// deriving the selectors and the ERC-165 identifier.
+func newInterface(name string, signatures ...string) Interface {
+	iface := Interface{Name: name, Selectors: make([]InterfaceID, len(signatures))}
+	for i, signature := range signatures {
+		copy(iface.Selectors[i][:], crypto.Keccak256([]byte(signature))[:4])
+		for j := range iface.ID {
+			iface.ID[j] ^= iface.Selectors[i][j]
+		}
+	}
+	return iface
+}
+
+// Well known interfaces. ERC-20 predates ERC-165, so tokens do not advertise it
+// and can only be detected from their bytecode.
+var (
+	ERC165 = newInterface("ERC165", "supportsInterface(bytes4)")
+
+	ERC20 = newInterface("ERC20",
+		"totalSupply()", "balanceOf(address)", "transfer(address,uint256)",
+		"transferFrom(address,address,uint256)", "approve(address,uint256)",
+		"allowance(address,address)")
+
+	ERC721 = newInterface("ERC721",
+		"balanceOf(address)", "ownerOf(uint256)",
+		"safeTransferFrom(address,address,uint256,bytes)",
+		"safeTransferFrom(address,address,uint256)",
+		"transferFrom(address,address,uint256)", "approve(address,uint256)",
+		"setApprovalForAll(address,bool)", "getApproved(uint256)",
+		"isApprovedForAll(address,address)")
+	ERC721Metadata   = newInterface("ERC721Metadata", "name()", "symbol()", "tokenURI(uint256)")
+	ERC721Enumerable = newInterface("ERC721Enumerable", "totalSupply()", "tokenOfOwnerByIndex(address,uint256)", "tokenByIndex(uint256)")
+
+	ERC1155 = newInterface("ERC1155",
+		"safeTransferFrom(address,address,uint256,uint256,bytes)",
+		"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+		"balanceOf(address,uint256)", "balanceOfBatch(address[],uint256[])",
+		"setApprovalForAll(address,bool)", "isApprovedForAll(address,address)")
+	ERC1155MetadataURI = newInterface("ERC1155MetadataURI", "uri(uint256)")
+
+	ERC2981 = newInterface("ERC2981", "royaltyInfo(uint256,uint256)")
+)
+
+// KnownInterfaces is the registry of interfaces probed by Interfaces.
+var KnownInterfaces = []Interface{
+	ERC165, ERC20, ERC721, ERC721Metadata, ERC721Enumerable, ERC1155, ERC1155MetadataURI, ERC2981,
+}
+
+// invalidInterface is the identifier ERC-165 compliant contracts must reject.
+var invalidInterface = InterfaceID{0xff, 0xff, 0xff, 0xff}
+
+// supportsInterfaceCalls returns the calls determining whether the contract
+// supports the interface, as specified by ERC-165: the contract must claim
+// support for ERC-165 itself, reject the invalid identifier and claim support
+// for the interface.
+func supportsInterfaceCalls(contract common.Address, id InterfaceID) []call {
+	calls := make([]call, 3)
+	for i, query := range []InterfaceID{ERC165.ID, invalidInterface, id} {
+		data, _ := erc165ABI.Pack("supportsInterface", query)
+		calls[i] = call{target: contract, data: data}
+	}
+	return calls
+}
+
+// supportsInterfaceResult evaluates the outputs of the supportsInterfaceCalls.
+// Contracts reverting or returning malformed data do not support ERC-165.
+func supportsInterfaceResult(outputs [][]byte) bool {
+	for i, want := range []bool{true, false, true} {
+		have, err := unpack[bool](erc165ABI, "supportsInterface", outputs[i])
+		if err != nil || have != want {
+			return false
+		}
+	}
+	return true
+}
+
+// SupportsInterface reports whether the contract supports the interface, as
+// advertised through ERC-165. Contracts not implementing ERC-165 are reported
+// as not supporting any interface, use DetectInterfaces to classify them.
+func (c *Client) SupportsInterface(opts *bind.CallOpts, contract common.Address, id InterfaceID) (bool, error) {
+	calls := supportsInterfaceCalls(contract, id)
+	outputs := make([][]byte, len(calls))
+	for i, cl := range calls {
+		output, err := c.call(opts, cl.target, cl.data)
+		if err != nil {
+			if isCallError(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		outputs[i] = output
+	}
+	return supportsInterfaceResult(outputs), nil
+}
+
+// SupportsInterfaceBatch reports for each of the contracts whether it supports
+// the interface, queried in a single batch.
+func (c *Client) SupportsInterfaceBatch(opts *bind.CallOpts, contracts []common.Address, id InterfaceID) ([]bool, error) {
+	calls := make([]call, 0, 3*len(contracts))
+	for _, contract := range contracts {
+		calls = append(calls, supportsInterfaceCalls(contract, id)...)
+	}
+	outputs, err := c.batch(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	supported := make([]bool, len(contracts))
+	for i := range contracts {
+		supported[i] = supportsInterfaceResult(outputs[3*i:])
+	}
+	return supported, nil
+}
+
+// Interfaces classifies the contract against the known interfaces. Contracts
+// implementing ERC-165 are queried for each of them, which is authoritative.
+// Other contracts are classified heuristically from their bytecode.
+func (c *Client) Interfaces(opts *bind.CallOpts, contract common.Address) ([]Interface, error) {
+	calls := make([]call, 0, 3*len(KnownInterfaces))
+	for _, iface := range KnownInterfaces {
+		calls = append(calls, supportsInterfaceCalls(contract, iface.ID)...)
+	}
+	outputs, err := c.batch(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	if supportsInterfaceResult(outputs) {
+		var ifaces []Interface
+		for i, iface := range KnownInterfaces {
+			if supportsInterfaceResult(outputs[3*i:]) {
+				ifaces = append(ifaces, iface)
+			}
+		}
+		return ifaces, nil
+	}
+	// Contract does not implement ERC-165, fall back to the bytecode
+	if opts == nil {
+		opts = new(bind.CallOpts)
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var code []byte
+	if opts.Pending {
+		pb, ok := c.backend.(bind.PendingContractCaller)
+		if !ok {
+			return nil, bind.ErrNoPendingState
+		}
+		code, err = pb.PendingCodeAt(ctx, contract)
+	} else {
+		code, err = c.backend.CodeAt(ctx, contract, opts.BlockNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	return DetectInterfaces(code), nil
+}
+
+// DetectInterfaces heuristically classifies contract bytecode against the known
+// interfaces. An interface is reported if the selectors of all its functions are
+// pushed onto the stack somewhere in the code, as done by the function dispatch
+// of compiled contracts. The result may include false positives, e.g. for proxies
+// embedding selectors of their targets, and misses contracts dispatching calls
+// in unusual ways.
+func DetectInterfaces(code []byte) []Interface {
+	// Collect all pushed values which may be selectors. Compilers omit the
+	// leading zero bytes of selectors, so narrower pushes are included too.
+	pushed := make(map[InterfaceID]struct{})
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		if !op.IsPush() {
+			continue
+		}
+		size := int(op - vm.PUSH1 + 1)
+		if size <= 4 && pc+size < len(code) {
+			var value InterfaceID
+			copy(value[4-size:], code[pc+1:pc+1+size])
+			pushed[value] = struct{}{}
+		}
+		pc += size
+	}
+	var ifaces []Interface
+	for _, iface := range KnownInterfaces {
+		found := true
+		for _, selector := range iface.Selectors {
+			if _, ok := pushed[selector]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	return ifaces
+}
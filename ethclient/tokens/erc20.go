@@ -0,0 +1,221 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errTransferRejected is returned if an ERC-20 token signals the failure of an
+// operation by returning false instead of reverting.
+var errTransferRejected = errors.New("token rejected the operation")
+
+// Metadata is the descriptive information of an ERC-20 token. The name, symbol
+// and decimals are optional in the standard, missing ones are left empty.
+type Metadata struct {
+	Name        string
+	Symbol      string
+	Decimals    uint8
+	TotalSupply *big.Int
+}
+
+// ERC20Name returns the name of the token.
+func (c *Client) ERC20Name(opts *bind.CallOpts, token common.Address) (string, error) {
+	output, err := c.call(opts, token, erc20ABI.Methods["name"].ID)
+	if err != nil {
+		return "", err
+	}
+	return unpackString(erc20ABI, "name", output)
+}
+
+// ERC20Symbol returns the symbol of the token.
+func (c *Client) ERC20Symbol(opts *bind.CallOpts, token common.Address) (string, error) {
+	output, err := c.call(opts, token, erc20ABI.Methods["symbol"].ID)
+	if err != nil {
+		return "", err
+	}
+	return unpackString(erc20ABI, "symbol", output)
+}
+
+// ERC20Decimals returns the number of decimals of the token.
+func (c *Client) ERC20Decimals(opts *bind.CallOpts, token common.Address) (uint8, error) {
+	output, err := c.call(opts, token, erc20ABI.Methods["decimals"].ID)
+	if err != nil {
+		return 0, err
+	}
+	return unpack[uint8](erc20ABI, "decimals", output)
+}
+
+// ERC20TotalSupply returns the total supply of the token.
+func (c *Client) ERC20TotalSupply(opts *bind.CallOpts, token common.Address) (*big.Int, error) {
+	output, err := c.call(opts, token, erc20ABI.Methods["totalSupply"].ID)
+	if err != nil {
+		return nil, err
+	}
+	return unpack[*big.Int](erc20ABI, "totalSupply", output)
+}
+
+// ERC20BalanceOf returns the token balance of the owner.
+func (c *Client) ERC20BalanceOf(opts *bind.CallOpts, token, owner common.Address) (*big.Int, error) {
+	data, err := erc20ABI.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return unpack[*big.Int](erc20ABI, "balanceOf", output)
+}
+
+// ERC20Allowance returns the amount of tokens the spender may transfer on behalf
+// of the owner.
+func (c *Client) ERC20Allowance(opts *bind.CallOpts, token, owner, spender common.Address) (*big.Int, error) {
+	data, err := erc20ABI.Pack("allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return unpack[*big.Int](erc20ABI, "allowance", output)
+}
+
+// ERC20Metadata returns the metadata of the tokens, queried in a single batch.
+// The total supply is mandatory, tokens without it fail with ErrCallFailed.
+func (c *Client) ERC20Metadata(opts *bind.CallOpts, tokens []common.Address) ([]*Metadata, []error, error) {
+	methods := []string{"name", "symbol", "decimals", "totalSupply"}
+
+	calls := make([]call, 0, len(tokens)*len(methods))
+	for _, token := range tokens {
+		for _, method := range methods {
+			calls = append(calls, call{target: token, data: erc20ABI.Methods[method].ID})
+		}
+	}
+	outputs, err := c.batch(opts, calls)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		metadata = make([]*Metadata, len(tokens))
+		errs     = make([]error, len(tokens))
+	)
+	for i := range tokens {
+		output := outputs[i*len(methods):]
+
+		supply, err := unpack[*big.Int](erc20ABI, "totalSupply", output[3])
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		meta := &Metadata{TotalSupply: supply}
+		meta.Name, _ = unpackString(erc20ABI, "name", output[0])
+		meta.Symbol, _ = unpackString(erc20ABI, "symbol", output[1])
+		meta.Decimals, _ = unpack[uint8](erc20ABI, "decimals", output[2])
+		metadata[i] = meta
+	}
+	return metadata, errs, nil
+}
+
+// ERC20BalancesOf returns the balances of the owner in each of the tokens,
+// queried in a single batch. Balances that could not be retrieved are nil, with
+// the reason in the corresponding error.
+func (c *Client) ERC20BalancesOf(opts *bind.CallOpts, tokens []common.Address, owner common.Address) ([]*big.Int, []error, error) {
+	data, err := erc20ABI.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	calls := make([]call, len(tokens))
+	for i, token := range tokens {
+		calls[i] = call{target: token, data: data}
+	}
+	outputs, err := c.batch(opts, calls)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		balances = make([]*big.Int, len(tokens))
+		errs     = make([]error, len(tokens))
+	)
+	for i, output := range outputs {
+		balances[i], errs[i] = unpack[*big.Int](erc20ABI, "balanceOf", output)
+	}
+	return balances, errs, nil
+}
+
+// ERC20Transfer sends amount tokens to the recipient.
+func (c *Client) ERC20Transfer(opts *bind.TransactOpts, token, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	if err := c.checkERC20Call(opts, token, "transfer", to, amount); err != nil {
+		return nil, err
+	}
+	return c.transact(opts, erc20ABI, token, "transfer", to, amount)
+}
+
+// ERC20TransferFrom sends amount tokens from the owner to the recipient, using
+// the allowance of the sender.
+func (c *Client) ERC20TransferFrom(opts *bind.TransactOpts, token, from, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	if err := c.checkERC20Call(opts, token, "transferFrom", from, to, amount); err != nil {
+		return nil, err
+	}
+	return c.transact(opts, erc20ABI, token, "transferFrom", from, to, amount)
+}
+
+// ERC20Approve sets the allowance of the spender to amount.
+func (c *Client) ERC20Approve(opts *bind.TransactOpts, token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	if err := c.checkERC20Call(opts, token, "approve", spender, amount); err != nil {
+		return nil, err
+	}
+	return c.transact(opts, erc20ABI, token, "approve", spender, amount)
+}
+
+// checkERC20Call simulates a state changing ERC-20 call before it is sent. Like
+// the SafeERC20 library, it accepts tokens returning nothing, but rejects ones
+// returning false.
+func (c *Client) checkERC20Call(opts *bind.TransactOpts, token common.Address, method string, params ...interface{}) error {
+	data, err := erc20ABI.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	output, err := c.call(&bind.CallOpts{Pending: true, From: opts.From, Context: opts.Context}, token, data)
+	if errors.Is(err, bind.ErrNoPendingState) {
+		output, err = c.call(&bind.CallOpts{From: opts.From, Context: opts.Context}, token, data)
+	}
+	if errors.Is(err, bind.ErrNoCode) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	if len(output) == 0 {
+		return nil
+	}
+	ok, err := unpack[bool](erc20ABI, method, output)
+	if err != nil {
+		return fmt.Errorf("%s returned malformed data: %v", method, err)
+	}
+	if !ok {
+		return errTransferRejected
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tokens
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ERC721Name returns the name of the collection.
+func (c *Client) ERC721Name(opts *bind.CallOpts, token common.Address) (string, error) {
+	output, err := c.call(opts, token, erc721ABI.Methods["name"].ID)
+	if err != nil {
+		return "", err
+	}
+	return unpackString(erc721ABI, "name", output)
+}
+
+// ERC721Symbol returns the symbol of the collection.
+func (c *Client) ERC721Symbol(opts *bind.CallOpts, token common.Address) (string, error) {
+	output, err := c.call(opts, token, erc721ABI.Methods["symbol"].ID)
+	if err != nil {
+		return "", err
+	}
+	return unpackString(erc721ABI, "symbol", output)
+}
+
+// ERC721TokenURI returns the metadata URI of the token.
+func (c *Client) ERC721TokenURI(opts *bind.CallOpts, token common.Address, id *big.Int) (string, error) {
+	data, err := erc721ABI.Pack("tokenURI", id)
+	if err != nil {
+		return "", err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return "", err
+	}
+	return unpack[string](erc721ABI, "tokenURI", output)
+}
+
+// ERC721BalanceOf returns the number of tokens of the collection held by the
+// owner.
+func (c *Client) ERC721BalanceOf(opts *bind.CallOpts, token, owner common.Address) (*big.Int, error) {
+	data, err := erc721ABI.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return unpack[*big.Int](erc721ABI, "balanceOf", output)
+}
+
+// ERC721OwnerOf returns the owner of the token.
+func (c *Client) ERC721OwnerOf(opts *bind.CallOpts, token common.Address, id *big.Int) (common.Address, error) {
+	data, err := erc721ABI.Pack("ownerOf", id)
+	if err != nil {
+		return common.Address{}, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return unpack[common.Address](erc721ABI, "ownerOf", output)
+}
+
+// ERC721GetApproved returns the account approved to transfer the token.
+func (c *Client) ERC721GetApproved(opts *bind.CallOpts, token common.Address, id *big.Int) (common.Address, error) {
+	data, err := erc721ABI.Pack("getApproved", id)
+	if err != nil {
+		return common.Address{}, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return unpack[common.Address](erc721ABI, "getApproved", output)
+}
+
+// ERC721IsApprovedForAll reports whether the operator may transfer all tokens
+// of the owner.
+func (c *Client) ERC721IsApprovedForAll(opts *bind.CallOpts, token, owner, operator common.Address) (bool, error) {
+	data, err := erc721ABI.Pack("isApprovedForAll", owner, operator)
+	if err != nil {
+		return false, err
+	}
+	output, err := c.call(opts, token, data)
+	if err != nil {
+		return false, err
+	}
+	return unpack[bool](erc721ABI, "isApprovedForAll", output)
+}
+
+// ERC721OwnersOf returns the owners of the tokens, queried in a single batch.
+// Owners that could not be retrieved, e.g. of burnt tokens, are zero with the
+// reason in the corresponding error.
+func (c *Client) ERC721OwnersOf(opts *bind.CallOpts, token common.Address, ids []*big.Int) ([]common.Address, []error, error) {
+	calls := make([]call, len(ids))
+	for i, id := range ids {
+		data, err := erc721ABI.Pack("ownerOf", id)
+		if err != nil {
+			return nil, nil, err
+		}
+		calls[i] = call{target: token, data: data}
+	}
+	outputs, err := c.batch(opts, calls)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		owners = make([]common.Address, len(ids))
+		errs   = make([]error, len(ids))
+	)
+	for i, output := range outputs {
+		owners[i], errs[i] = unpack[common.Address](erc721ABI, "ownerOf", output)
+	}
+	return owners, errs, nil
+}
+
+// ERC721SafeTransferFrom transfers the token from the owner to the recipient,
+// which is checked to accept it if it is a contract.
+func (c *Client) ERC721SafeTransferFrom(opts *bind.TransactOpts, token, from, to common.Address, id *big.Int, data []byte) (*types.Transaction, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	return c.transact(opts, erc721ABI, token, "safeTransferFrom", from, to, id, data)
+}
+
+// ERC721Approve approves the account to transfer the token.
+func (c *Client) ERC721Approve(opts *bind.TransactOpts, token, to common.Address, id *big.Int) (*types.Transaction, error) {
+	return c.transact(opts, erc721ABI, token, "approve", to, id)
+}
+
+// ERC721SetApprovalForAll allows or disallows the operator to transfer all
+// tokens of the sender.
+func (c *Client) ERC721SetApprovalForAll(opts *bind.TransactOpts, token, operator common.Address, approved bool) (*types.Transaction, error) {
+	return c.transact(opts, erc721ABI, token, "setApprovalForAll", operator, approved)
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tokens provides typed clients for the standard ERC-20, ERC-721 and
+// ERC-1155 token interfaces, without the need to generate contract bindings,
+// along with ERC-165 interface detection for classifying arbitrary contracts.
+//
+// Queries spanning many tokens are batched into a single call through the
+// Multicall3 contract if its address is configured.
+package tokens
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const erc20ABIJSON = `[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"allowance","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+const erc721ABIJSON = `[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"tokenURI","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getApproved","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"isApprovedForAll","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"setApprovalForAll","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+const erc1155ABIJSON = `[
+	{"type":"function","name":"uri","stateMutability":"view","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOfBatch","stateMutability":"view","inputs":[{"name":"accounts","type":"address[]"},{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"","type":"uint256[]"}]},
+	{"type":"function","name":"isApprovedForAll","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"setApprovalForAll","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"safeBatchTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"ids","type":"uint256[]"},{"name":"amounts","type":"uint256[]"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+const multicallABIJSON = `[
+	{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}
+]`
+
+var (
+	erc20ABI     = mustParseABI(erc20ABIJSON)
+	erc721ABI    = mustParseABI(erc721ABIJSON)
+	erc1155ABI   = mustParseABI(erc1155ABIJSON)
+	multicallABI = mustParseABI(multicallABIJSON)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// ErrCallFailed is returned for queries within a batch that reverted or
+// returned malformed data.
+var ErrCallFailed = errors.New("token call failed")
+
+// Client provides access to token contracts through a contract backend.
+type Client struct {
+	backend   bind.ContractBackend
+	multicall common.Address // Multicall3 contract batching queries, zero if disabled
+}
+
+// NewClient creates a token client. If multicall is non-zero, queries spanning
+// multiple tokens are batched through the Multicall3 contract at that address,
+// e.g. chains.Multicall3Address. Otherwise they are issued one by one.
+func NewClient(backend bind.ContractBackend, multicall common.Address) *Client {
+	return &Client{backend: backend, multicall: multicall}
+}
+
+// call is a single contract call of a batch.
+type call struct {
+	target common.Address
+	data   []byte
+}
+
+// call executes a contract call, failing with bind.ErrNoCode if the target is
+// not a contract.
+func (c *Client) call(opts *bind.CallOpts, to common.Address, data []byte) ([]byte, error) {
+	if opts == nil {
+		opts = new(bind.CallOpts)
+	}
+	var (
+		msg    = ethereum.CallMsg{From: opts.From, To: &to, Data: data}
+		ctx    = opts.Context
+		output []byte
+		code   []byte
+		err    error
+	)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Pending {
+		pb, ok := c.backend.(bind.PendingContractCaller)
+		if !ok {
+			return nil, bind.ErrNoPendingState
+		}
+		if output, err = pb.PendingCallContract(ctx, msg); err != nil {
+			return nil, err
+		}
+		if len(output) == 0 {
+			if code, err = pb.PendingCodeAt(ctx, to); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if output, err = c.backend.CallContract(ctx, msg, opts.BlockNumber); err != nil {
+			return nil, err
+		}
+		if len(output) == 0 {
+			if code, err = c.backend.CodeAt(ctx, to, opts.BlockNumber); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(output) == 0 && len(code) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	return output, nil
+}
+
+// batch executes multiple contract calls, through the Multicall3 contract if
+// configured. The outputs of failed calls are nil, only errors of the backend
+// itself fail the whole batch.
+func (c *Client) batch(opts *bind.CallOpts, calls []call) ([][]byte, error) {
+	outputs := make([][]byte, len(calls))
+	if c.multicall == (common.Address{}) {
+		for i, cl := range calls {
+			output, err := c.call(opts, cl.target, cl.data)
+			if err != nil {
+				if isCallError(err) {
+					continue
+				}
+				return nil, err
+			}
+			outputs[i] = output
+		}
+		return outputs, nil
+	}
+	type aggregateCall struct {
+		Target       common.Address
+		AllowFailure bool
+		CallData     []byte
+	}
+	type aggregateResult struct {
+		Success    bool
+		ReturnData []byte
+	}
+	input := make([]aggregateCall, len(calls))
+	for i, cl := range calls {
+		input[i] = aggregateCall{Target: cl.target, AllowFailure: true, CallData: cl.data}
+	}
+	data, err := multicallABI.Pack("aggregate3", input)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, c.multicall, data)
+	if err != nil {
+		return nil, err
+	}
+	unpacked, err := multicallABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, err
+	}
+	results := *abi.ConvertType(unpacked[0], new([]aggregateResult)).(*[]aggregateResult)
+	if len(results) != len(calls) {
+		return nil, errors.New("multicall result count mismatch")
+	}
+	for i, result := range results {
+		if result.Success && len(result.ReturnData) > 0 {
+			outputs[i] = result.ReturnData
+		}
+	}
+	return outputs, nil
+}
+
+// isCallError reports whether the error is caused by the executed call rather
+// than by the backend, i.e. a revert or a missing contract.
+func isCallError(err error) bool {
+	if errors.Is(err, bind.ErrNoCode) {
+		return true
+	}
+	var dataErr interface{ ErrorData() interface{} }
+	return errors.As(err, &dataErr)
+}
+
+// unpack decodes the single return value of a method.
+func unpack[T any](contract abi.ABI, method string, output []byte) (T, error) {
+	var zero T
+	if output == nil {
+		return zero, ErrCallFailed
+	}
+	values, err := contract.Unpack(method, output)
+	if err != nil {
+		return zero, err
+	}
+	value, ok := values[0].(T)
+	if !ok {
+		return zero, ErrCallFailed
+	}
+	return value, nil
+}
+
+// unpackString decodes a string return value. Some tokens predating the
+// standards return bytes32 instead, which is accepted as well.
+func unpackString(contract abi.ABI, method string, output []byte) (string, error) {
+	if len(output) == 32 {
+		return strings.TrimRight(string(output), "\x00"), nil
+	}
+	return unpack[string](contract, method, output)
+}
+
+// transact sends a transaction invoking the method of the token.
+func (c *Client) transact(opts *bind.TransactOpts, contract abi.ABI, token common.Address, method string, params ...interface{}) (*types.Transaction, error) {
+	bound := bind.NewBoundContract(token, contract, c.backend, c.backend, c.backend)
+	return bound.Transact(opts, method, params...)
+}
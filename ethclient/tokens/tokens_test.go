@@ -0,0 +1,477 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tokens
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	multicallAddr = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	standardAddr  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	legacyAddr    = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	brokenAddr    = common.HexToAddress("0x1000000000000000000000000000000000000003")
+	nftAddr       = common.HexToAddress("0x1000000000000000000000000000000000000004")
+	multiAddr     = common.HexToAddress("0x1000000000000000000000000000000000000005")
+	introAddr     = common.HexToAddress("0x1000000000000000000000000000000000000006")
+	lyingAddr     = common.HexToAddress("0x1000000000000000000000000000000000000007")
+	emptyAddr     = common.HexToAddress("0x2000000000000000000000000000000000000000")
+
+	owner = common.HexToAddress("0x3000000000000000000000000000000000000000")
+)
+
+// revertError mimics the revert errors returned by RPC backends.
+type revertError struct{}
+
+func (revertError) Error() string          { return "execution reverted" }
+func (revertError) ErrorData() interface{} { return "0x" }
+
+// contract handles calls of a mock contract.
+type contract func(method string, args []interface{}) ([]byte, error)
+
+// testBackend is a contract backend dispatching calls to mock contracts. It
+// implements the Multicall3 contract at the canonical address.
+type testBackend struct {
+	contracts map[common.Address]contract
+	abis      map[common.Address]abi.ABI
+	codes     map[common.Address][]byte
+	calls     int
+	sent      []*types.Transaction
+}
+
+func newTestBackend() *testBackend {
+	b := &testBackend{
+		contracts: make(map[common.Address]contract),
+		abis:      make(map[common.Address]abi.ABI),
+		codes:     make(map[common.Address][]byte),
+	}
+	// Standard token returning proper ABI encoded values
+	b.add(standardAddr, erc20ABI, func(method string, args []interface{}) ([]byte, error) {
+		switch method {
+		case "name":
+			return pack(erc20ABI, method, "Standard Token")
+		case "symbol":
+			return pack(erc20ABI, method, "STD")
+		case "decimals":
+			return pack(erc20ABI, method, uint8(18))
+		case "totalSupply":
+			return pack(erc20ABI, method, big.NewInt(1000))
+		case "balanceOf":
+			return pack(erc20ABI, method, big.NewInt(42))
+		case "allowance":
+			return pack(erc20ABI, method, big.NewInt(7))
+		case "transfer", "approve", "transferFrom":
+			return pack(erc20ABI, method, args[len(args)-1].(*big.Int).Sign() > 0)
+		}
+		return nil, revertError{}
+	})
+	// Legacy token returning bytes32 strings, no decimals and nothing from
+	// state changing methods
+	b.add(legacyAddr, erc20ABI, func(method string, args []interface{}) ([]byte, error) {
+		switch method {
+		case "name":
+			return common.RightPadBytes([]byte("Legacy Token"), 32), nil
+		case "symbol":
+			return common.RightPadBytes([]byte("LGC"), 32), nil
+		case "totalSupply":
+			return pack(erc20ABI, method, big.NewInt(500))
+		case "balanceOf":
+			return pack(erc20ABI, method, big.NewInt(3))
+		case "transfer":
+			return []byte{}, nil
+		}
+		return nil, revertError{}
+	})
+	// Contract which is not a token at all
+	b.add(brokenAddr, erc20ABI, func(method string, args []interface{}) ([]byte, error) {
+		return nil, revertError{}
+	})
+	b.add(nftAddr, erc721ABI, func(method string, args []interface{}) ([]byte, error) {
+		switch method {
+		case "ownerOf":
+			if args[0].(*big.Int).Uint64() == 1 {
+				return pack(erc721ABI, method, owner)
+			}
+		case "tokenURI":
+			return pack(erc721ABI, method, "ipfs://token")
+		}
+		return nil, revertError{}
+	})
+	b.add(multiAddr, erc1155ABI, func(method string, args []interface{}) ([]byte, error) {
+		switch method {
+		case "balanceOfBatch":
+			ids := args[1].([]*big.Int)
+			balances := make([]*big.Int, len(ids))
+			for i, id := range ids {
+				balances[i] = new(big.Int).Mul(id, big.NewInt(10))
+			}
+			return pack(erc1155ABI, method, balances)
+		case "uri":
+			return pack(erc1155ABI, method, "https://token/{id}.json")
+		}
+		return nil, revertError{}
+	})
+	// Contract advertising its interfaces through ERC-165
+	b.add(introAddr, erc165ABI, func(method string, args []interface{}) ([]byte, error) {
+		switch InterfaceID(args[0].([4]byte)) {
+		case ERC165.ID, ERC721.ID, ERC721Metadata.ID:
+			return pack(erc165ABI, method, true)
+		}
+		return pack(erc165ABI, method, false)
+	})
+	// Contract claiming support for every interface, violating ERC-165
+	b.add(lyingAddr, erc165ABI, func(method string, args []interface{}) ([]byte, error) {
+		return pack(erc165ABI, method, true)
+	})
+	return b
+}
+
+func (b *testBackend) add(addr common.Address, contract abi.ABI, handler contract) {
+	b.contracts[addr] = handler
+	b.abis[addr] = contract
+}
+
+func pack(contract abi.ABI, method string, value interface{}) ([]byte, error) {
+	return contract.Methods[method].Outputs.Pack(value)
+}
+
+func (b *testBackend) execute(to common.Address, data []byte) ([]byte, error) {
+	if to == multicallAddr {
+		return b.aggregate(data)
+	}
+	handler, ok := b.contracts[to]
+	if !ok {
+		return nil, nil
+	}
+	contract := b.abis[to]
+	method, err := contract.MethodById(data)
+	if err != nil {
+		return nil, revertError{}
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, revertError{}
+	}
+	return handler(method.Name, args)
+}
+
+func (b *testBackend) aggregate(data []byte) ([]byte, error) {
+	type aggregateCall struct {
+		Target       common.Address
+		AllowFailure bool
+		CallData     []byte
+	}
+	type aggregateResult struct {
+		Success    bool
+		ReturnData []byte
+	}
+	method := multicallABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]aggregateCall)).(*[]aggregateCall)
+	results := make([]aggregateResult, len(calls))
+	for i, call := range calls {
+		output, err := b.execute(call.Target, call.CallData)
+		results[i] = aggregateResult{Success: err == nil, ReturnData: output}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (b *testBackend) CodeAt(ctx context.Context, addr common.Address, number *big.Int) ([]byte, error) {
+	if code, ok := b.codes[addr]; ok {
+		return code, nil
+	}
+	if _, ok := b.contracts[addr]; ok || addr == multicallAddr {
+		return []byte{0x01}, nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	b.calls++
+	return b.execute(*call.To, call.Data)
+}
+
+func (b *testBackend) PendingCodeAt(ctx context.Context, addr common.Address) ([]byte, error) {
+	return b.CodeAt(ctx, addr, nil)
+}
+
+func (b *testBackend) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return b.CallContract(ctx, call, nil)
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int)}, nil
+}
+
+func (b *testBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (b *testBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *testBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (b *testBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *testBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (b *testBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestERC20Queries(t *testing.T) {
+	client := NewClient(newTestBackend(), common.Address{})
+
+	if name, err := client.ERC20Name(nil, standardAddr); err != nil || name != "Standard Token" {
+		t.Errorf("standard name: have %q (%v), want %q", name, err, "Standard Token")
+	}
+	if symbol, err := client.ERC20Symbol(nil, legacyAddr); err != nil || symbol != "LGC" {
+		t.Errorf("legacy symbol: have %q (%v), want %q", symbol, err, "LGC")
+	}
+	if decimals, err := client.ERC20Decimals(nil, standardAddr); err != nil || decimals != 18 {
+		t.Errorf("decimals: have %d (%v), want 18", decimals, err)
+	}
+	if allowance, err := client.ERC20Allowance(nil, standardAddr, owner, owner); err != nil || allowance.Int64() != 7 {
+		t.Errorf("allowance: have %v (%v), want 7", allowance, err)
+	}
+	if _, err := client.ERC20BalanceOf(nil, emptyAddr, owner); !errors.Is(err, bind.ErrNoCode) {
+		t.Errorf("balance of non-contract: have error %v, want %v", err, bind.ErrNoCode)
+	}
+}
+
+func TestERC20Batched(t *testing.T) {
+	for _, multicall := range []common.Address{{}, multicallAddr} {
+		backend := newTestBackend()
+		client := NewClient(backend, multicall)
+
+		tokens := []common.Address{standardAddr, legacyAddr, brokenAddr, emptyAddr}
+		metadata, errs, err := client.ERC20Metadata(nil, tokens)
+		if err != nil {
+			t.Fatalf("multicall %x: failed to retrieve metadata: %v", multicall, err)
+		}
+		if backend.calls != 16 && multicall == (common.Address{}) {
+			t.Errorf("unbatched: have %d calls, want 16", backend.calls)
+		}
+		if backend.calls != 1 && multicall != (common.Address{}) {
+			t.Errorf("batched: have %d calls, want 1", backend.calls)
+		}
+		want := []*Metadata{
+			{Name: "Standard Token", Symbol: "STD", Decimals: 18, TotalSupply: big.NewInt(1000)},
+			{Name: "Legacy Token", Symbol: "LGC", TotalSupply: big.NewInt(500)},
+		}
+		for i, meta := range want {
+			have := metadata[i]
+			if errs[i] != nil || have == nil || have.Name != meta.Name || have.Symbol != meta.Symbol || have.Decimals != meta.Decimals || have.TotalSupply.Cmp(meta.TotalSupply) != 0 {
+				t.Errorf("multicall %x token %d: have %+v (%v), want %+v", multicall, i, have, errs[i], meta)
+			}
+		}
+		for i := 2; i < len(tokens); i++ {
+			if metadata[i] != nil || errs[i] == nil {
+				t.Errorf("multicall %x token %d: have %+v, want error", multicall, i, metadata[i])
+			}
+		}
+		balances, errs, err := client.ERC20BalancesOf(nil, tokens, owner)
+		if err != nil {
+			t.Fatalf("multicall %x: failed to retrieve balances: %v", multicall, err)
+		}
+		if balances[0].Int64() != 42 || balances[1].Int64() != 3 || errs[0] != nil || errs[1] != nil {
+			t.Errorf("multicall %x: have balances %v (%v), want [42 3]", multicall, balances[:2], errs[:2])
+		}
+		if balances[2] != nil || errs[2] == nil || balances[3] != nil || errs[3] == nil {
+			t.Errorf("multicall %x: have balances %v of non-tokens, want errors", multicall, balances[2:])
+		}
+	}
+}
+
+func TestERC20Transfer(t *testing.T) {
+	backend := newTestBackend()
+	client := NewClient(backend, multicallAddr)
+
+	key, _ := crypto.GenerateKey()
+	opts, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	opts.GasPrice, opts.GasLimit, opts.Nonce = big.NewInt(1), 100000, big.NewInt(0)
+
+	if _, err := client.ERC20Transfer(opts, standardAddr, owner, big.NewInt(1)); err != nil {
+		t.Fatalf("transfer of standard token failed: %v", err)
+	}
+	if _, err := client.ERC20Transfer(opts, legacyAddr, owner, big.NewInt(1)); err != nil {
+		t.Fatalf("transfer of legacy token failed: %v", err)
+	}
+	if _, err := client.ERC20Transfer(opts, standardAddr, owner, big.NewInt(0)); !errors.Is(err, errTransferRejected) {
+		t.Fatalf("rejected transfer: have error %v, want %v", err, errTransferRejected)
+	}
+	if _, err := client.ERC20Approve(opts, brokenAddr, owner, big.NewInt(1)); err == nil {
+		t.Fatalf("reverting approval succeeded")
+	}
+	if len(backend.sent) != 2 {
+		t.Fatalf("have %d transactions sent, want 2", len(backend.sent))
+	}
+	want, _ := erc20ABI.Pack("transfer", owner, big.NewInt(1))
+	if tx := backend.sent[0]; *tx.To() != standardAddr || string(tx.Data()) != string(want) {
+		t.Errorf("transaction mismatch: to %x, data %x", tx.To(), tx.Data())
+	}
+}
+
+func TestERC721(t *testing.T) {
+	client := NewClient(newTestBackend(), multicallAddr)
+
+	if uri, err := client.ERC721TokenURI(nil, nftAddr, big.NewInt(1)); err != nil || uri != "ipfs://token" {
+		t.Errorf("token URI: have %q (%v)", uri, err)
+	}
+	owners, errs, err := client.ERC721OwnersOf(nil, nftAddr, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil {
+		t.Fatalf("failed to retrieve owners: %v", err)
+	}
+	if owners[0] != owner || errs[0] != nil {
+		t.Errorf("owner of token 1: have %x (%v), want %x", owners[0], errs[0], owner)
+	}
+	if owners[1] != (common.Address{}) || errs[1] == nil {
+		t.Errorf("owner of burnt token: have %x, want error", owners[1])
+	}
+}
+
+func TestERC1155(t *testing.T) {
+	client := NewClient(newTestBackend(), common.Address{})
+
+	balances, err := client.ERC1155BalanceOfBatch(nil, multiAddr, []common.Address{owner, owner}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil {
+		t.Fatalf("failed to retrieve balances: %v", err)
+	}
+	if len(balances) != 2 || balances[0].Int64() != 10 || balances[1].Int64() != 20 {
+		t.Errorf("have balances %v, want [10 20]", balances)
+	}
+	if _, err := client.ERC1155BalanceOfBatch(nil, multiAddr, []common.Address{owner}, nil); err == nil {
+		t.Errorf("mismatched batch succeeded")
+	}
+	if uri, err := client.ERC1155URI(nil, multiAddr, big.NewInt(1)); err != nil || uri != "https://token/{id}.json" {
+		t.Errorf("uri: have %q (%v)", uri, err)
+	}
+}
+
+func TestSupportsInterface(t *testing.T) {
+	for _, multicall := range []common.Address{{}, multicallAddr} {
+		client := NewClient(newTestBackend(), multicall)
+
+		if ok, err := client.SupportsInterface(nil, introAddr, ERC721.ID); err != nil || !ok {
+			t.Errorf("multicall %x: ERC721 support: have %v (%v), want true", multicall, ok, err)
+		}
+		if ok, err := client.SupportsInterface(nil, introAddr, ERC1155.ID); err != nil || ok {
+			t.Errorf("multicall %x: ERC1155 support: have %v (%v), want false", multicall, ok, err)
+		}
+		contracts := []common.Address{introAddr, lyingAddr, standardAddr, emptyAddr}
+		supported, err := client.SupportsInterfaceBatch(nil, contracts, ERC721Metadata.ID)
+		if err != nil {
+			t.Fatalf("multicall %x: failed to query interfaces: %v", multicall, err)
+		}
+		if want := []bool{true, false, false, false}; !reflect.DeepEqual(supported, want) {
+			t.Errorf("multicall %x: have support %v, want %v", multicall, supported, want)
+		}
+		ifaces, err := client.Interfaces(nil, introAddr)
+		if err != nil {
+			t.Fatalf("multicall %x: failed to classify contract: %v", multicall, err)
+		}
+		if names := interfaceNames(ifaces); !reflect.DeepEqual(names, []string{"ERC165", "ERC721", "ERC721Metadata"}) {
+			t.Errorf("multicall %x: have interfaces %v", multicall, names)
+		}
+	}
+}
+
+func TestInterfaceIDs(t *testing.T) {
+	tests := []struct {
+		iface Interface
+		id    string
+	}{
+		{ERC165, "0x01ffc9a7"},
+		{ERC20, "0x36372b07"},
+		{ERC721, "0x80ac58cd"},
+		{ERC721Metadata, "0x5b5e139f"},
+		{ERC721Enumerable, "0x780e9d63"},
+		{ERC1155, "0xd9b67a26"},
+		{ERC1155MetadataURI, "0x0e89341c"},
+		{ERC2981, "0x2a55205a"},
+	}
+	for _, tt := range tests {
+		if tt.iface.ID.String() != tt.id {
+			t.Errorf("%s: have ID %v, want %s", tt.iface.Name, tt.iface.ID, tt.id)
+		}
+	}
+}
+
+func TestDetectInterfaces(t *testing.T) {
+	// Assemble a function dispatch over the ERC-20 selectors, with the selector
+	// of ERC-2981 hidden in push data which must not be mistaken for code
+	var code []byte
+	for _, selector := range ERC20.Selectors {
+		code = append(code, byte(vm.DUP1), byte(vm.PUSH4))
+		code = append(code, selector[:]...)
+		code = append(code, byte(vm.EQ))
+	}
+	hidden := append([]byte{byte(vm.PUSH4)}, ERC2981.Selectors[0][:]...)
+	code = append(code, byte(vm.PUSH32))
+	code = append(code, common.RightPadBytes(hidden, 32)...)
+
+	backend := newTestBackend()
+	backend.codes[brokenAddr] = code
+	client := NewClient(backend, multicallAddr)
+
+	ifaces, err := client.Interfaces(nil, brokenAddr)
+	if err != nil {
+		t.Fatalf("failed to classify contract: %v", err)
+	}
+	if names := interfaceNames(ifaces); !reflect.DeepEqual(names, []string{"ERC20"}) {
+		t.Errorf("have interfaces %v, want [ERC20]", names)
+	}
+	if _, err := client.Interfaces(nil, emptyAddr); !errors.Is(err, bind.ErrNoCode) {
+		t.Errorf("classifying non-contract: have error %v, want %v", err, bind.ErrNoCode)
+	}
+}
+
+func interfaceNames(ifaces []Interface) []string {
+	names := make([]string, len(ifaces))
+	for i, iface := range ifaces {
+		names[i] = iface.Name
+	}
+	return names
+}
@@ -44,9 +44,22 @@ var (
 // Database is an ephemeral key-value store. Apart from basic data storage
 // functionality it also supports batch writes and iterating over the keyspace in
 // binary-alphabetical order.
+//
+// Besides the plain mode, a database may be size-bounded, evicting its oldest
+// entries beyond a size limit (see NewWithLimit), or overlay a read-only disk
+// database, buffering all writes in memory (see NewOverlay).
 type Database struct {
 	db   map[string][]byte
 	lock sync.RWMutex
+
+	size  int               // Total size of the stored keys and values
+	limit int               // Maximum size of the stored keys and values, 0 if unlimited
+	queue []write           // Writes in chronological order for eviction, possibly stale
+	gens  map[string]uint64 // Generation of the latest write of each key, nil if unlimited
+	gen   uint64            // Generation counter of the writes
+
+	disk    ethdb.KeyValueStore // Database overlaid by the memory one, nil if none
+	deleted map[string]struct{} // Keys deleted from the overlaid database
 }
 
 // New returns a wrapped map with all the required database interface methods
@@ -65,6 +78,26 @@ func NewWithCap(size int) *Database {
 	}
 }
 
+// NewWithLimit returns a size-bounded in-memory database. Whenever the total size
+// of the stored keys and values exceeds the limit, the least recently written
+// entries are evicted, i.e. silently lost. It is meant for caches and for
+// simulations which can tolerate losing old data in exchange for bounded
+// memory usage.
+func NewWithLimit(limit int) *Database {
+	return &Database{
+		db:    make(map[string][]byte),
+		limit: limit,
+		gens:  make(map[string]uint64),
+	}
+}
+
+// write is a write to a size-bounded database, tracked for eviction. It is stale
+// if the key was written again or deleted since.
+type write struct {
+	key string
+	gen uint64
+}
+
 // Close deallocates the internal map and ensures any consecutive data access op
 // fails with an error.
 func (db *Database) Close() error {
@@ -83,8 +116,16 @@ func (db *Database) Has(key []byte) (bool, error) {
 	if db.db == nil {
 		return false, errMemorydbClosed
 	}
-	_, ok := db.db[string(key)]
-	return ok, nil
+	if _, ok := db.db[string(key)]; ok {
+		return true, nil
+	}
+	if db.disk == nil {
+		return false, nil
+	}
+	if _, ok := db.deleted[string(key)]; ok {
+		return false, nil
+	}
+	return db.disk.Has(key)
 }
 
 // Get retrieves the given key if it's present in the key-value store.
@@ -98,6 +139,11 @@ func (db *Database) Get(key []byte) ([]byte, error) {
 	if entry, ok := db.db[string(key)]; ok {
 		return common.CopyBytes(entry), nil
 	}
+	if db.disk != nil {
+		if _, ok := db.deleted[string(key)]; !ok {
+			return db.disk.Get(key)
+		}
+	}
 	return nil, errMemorydbNotFound
 }
 
@@ -109,7 +155,7 @@ func (db *Database) Put(key []byte, value []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
-	db.db[string(key)] = common.CopyBytes(value)
+	db.put(string(key), common.CopyBytes(value))
 	return nil
 }
 
@@ -121,10 +167,69 @@ func (db *Database) Delete(key []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
-	delete(db.db, string(key))
+	db.delete(string(key))
 	return nil
 }
 
+// put inserts a value into the key-value store, evicting the oldest entries if
+// the database grows beyond its size limit. The caller must hold the write lock.
+func (db *Database) put(key string, value []byte) {
+	if old, ok := db.db[key]; ok {
+		db.size -= len(key) + len(old)
+	}
+	db.db[key] = value
+	db.size += len(key) + len(value)
+
+	if db.disk != nil {
+		delete(db.deleted, key)
+	}
+	if db.gens == nil {
+		return
+	}
+	db.gen++
+	db.gens[key] = db.gen
+	db.queue = append(db.queue, write{key: key, gen: db.gen})
+
+	// Evict the oldest entries beyond the limit, keeping the one just written
+	for db.size > db.limit && len(db.queue) > 1 {
+		oldest := db.queue[0]
+		db.queue = db.queue[1:]
+
+		if db.gens[oldest.key] != oldest.gen {
+			continue // stale write
+		}
+		db.size -= len(oldest.key) + len(db.db[oldest.key])
+		delete(db.db, oldest.key)
+		delete(db.gens, oldest.key)
+	}
+	// Drop the stale writes if they dominate the queue, e.g. due to many
+	// rewrites of the same keys
+	if len(db.queue) > 2*len(db.gens)+64 {
+		live := make([]write, 0, len(db.gens))
+		for _, w := range db.queue {
+			if db.gens[w.key] == w.gen {
+				live = append(live, w)
+			}
+		}
+		db.queue = live
+	}
+}
+
+// delete removes a key from the key-value store, shadowing it in the overlaid
+// database if there is one. The caller must hold the write lock.
+func (db *Database) delete(key string) {
+	if old, ok := db.db[key]; ok {
+		db.size -= len(key) + len(old)
+		delete(db.db, key)
+	}
+	if db.disk != nil {
+		db.deleted[key] = struct{}{}
+	}
+	if db.gens != nil {
+		delete(db.gens, key)
+	}
+}
+
 // NewBatch creates a write-only key-value store that buffers changes to its host
 // database until a final write is called.
 func (db *Database) NewBatch() ethdb.Batch {
@@ -168,18 +273,22 @@ func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
 	for _, key := range keys {
 		values = append(values, db.db[key])
 	}
-	return &iterator{
+	it := &iterator{
 		index:  -1,
 		keys:   keys,
 		values: values,
 	}
+	if db.disk == nil {
+		return it
+	}
+	return newOverlayIterator(it, db.disk.NewIterator(prefix, start), db.deleted)
 }
 
 // NewSnapshot creates a database snapshot based on the current state.
 // The created snapshot will not be affected by all following mutations
 // happened on the database.
 func (db *Database) NewSnapshot() (ethdb.Snapshot, error) {
-	return newSnapshot(db), nil
+	return newSnapshot(db)
 }
 
 // Stat returns a particular internal stat of the database.
@@ -193,6 +302,14 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return nil
 }
 
+// Size returns the total size of the keys and values held in memory.
+func (db *Database) Size() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.size
+}
+
 // Len returns the number of entries currently present in the memory database.
 //
 // Note, this method is only used for testing (i.e. not public in general) and
@@ -246,10 +363,10 @@ func (b *batch) Write() error {
 
 	for _, keyvalue := range b.writes {
 		if keyvalue.delete {
-			delete(b.db.db, string(keyvalue.key))
+			b.db.delete(string(keyvalue.key))
 			continue
 		}
-		b.db.db[string(keyvalue.key)] = keyvalue.value
+		b.db.put(string(keyvalue.key), keyvalue.value)
 	}
 	return nil
 }
@@ -335,10 +452,13 @@ func (it *iterator) Release() {
 type snapshot struct {
 	db   map[string][]byte
 	lock sync.RWMutex
+
+	disk    ethdb.Snapshot      // Snapshot of the overlaid database, nil if none
+	deleted map[string]struct{} // Keys deleted from the overlaid database
 }
 
 // newSnapshot initializes the snapshot with the given database instance.
-func newSnapshot(db *Database) *snapshot {
+func newSnapshot(db *Database) (*snapshot, error) {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
@@ -346,7 +466,19 @@ func newSnapshot(db *Database) *snapshot {
 	for key, val := range db.db {
 		copied[key] = common.CopyBytes(val)
 	}
-	return &snapshot{db: copied}
+	snap := &snapshot{db: copied}
+	if db.disk != nil {
+		disk, err := db.disk.NewSnapshot()
+		if err != nil {
+			return nil, err
+		}
+		snap.disk = disk
+		snap.deleted = make(map[string]struct{}, len(db.deleted))
+		for key := range db.deleted {
+			snap.deleted[key] = struct{}{}
+		}
+	}
+	return snap, nil
 }
 
 // Has retrieves if a key is present in the snapshot backing by a key-value
@@ -358,8 +490,16 @@ func (snap *snapshot) Has(key []byte) (bool, error) {
 	if snap.db == nil {
 		return false, errSnapshotReleased
 	}
-	_, ok := snap.db[string(key)]
-	return ok, nil
+	if _, ok := snap.db[string(key)]; ok {
+		return true, nil
+	}
+	if snap.disk == nil {
+		return false, nil
+	}
+	if _, ok := snap.deleted[string(key)]; ok {
+		return false, nil
+	}
+	return snap.disk.Has(key)
 }
 
 // Get retrieves the given key if it's present in the snapshot backing by
@@ -374,6 +514,11 @@ func (snap *snapshot) Get(key []byte) ([]byte, error) {
 	if entry, ok := snap.db[string(key)]; ok {
 		return common.CopyBytes(entry), nil
 	}
+	if snap.disk != nil {
+		if _, ok := snap.deleted[string(key)]; !ok {
+			return snap.disk.Get(key)
+		}
+	}
 	return nil, errMemorydbNotFound
 }
 
@@ -383,5 +528,9 @@ func (snap *snapshot) Release() {
 	snap.lock.Lock()
 	defer snap.lock.Unlock()
 
-	snap.db = nil
+	if snap.disk != nil {
+		snap.disk.Release()
+		snap.disk = nil
+	}
+	snap.db, snap.deleted = nil, nil
 }
@@ -17,6 +17,8 @@
 package memorydb
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -29,4 +31,99 @@ func TestMemoryDB(t *testing.T) {
 			return New()
 		})
 	})
+	t.Run("LimitedDatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+			return NewWithLimit(1 << 30)
+		})
+	})
+	t.Run("OverlayDatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+			return NewOverlay(New())
+		})
+	})
+}
+
+func TestLimit(t *testing.T) {
+	db := NewWithLimit(30)
+	for i := 0; i < 5; i++ {
+		db.Put([]byte{byte(i)}, make([]byte, 9)) // 10 bytes per entry
+	}
+	// Rewriting an entry makes it the most recent one
+	db.Put([]byte{2}, make([]byte, 9))
+
+	for i, want := range []bool{false, false, true, true, true} {
+		if have, _ := db.Has([]byte{byte(i)}); have != want {
+			t.Errorf("entry %d: presence mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if size := db.Size(); size != 30 {
+		t.Errorf("size mismatch: have %d, want 30", size)
+	}
+	db.Delete([]byte{3})
+	db.Put([]byte{5}, make([]byte, 9))
+	if has, _ := db.Has([]byte{4}); !has {
+		t.Errorf("entry evicted despite free space")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	disk := New()
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		disk.Put([]byte(key), []byte("disk-"+key))
+	}
+	db := NewOverlay(disk)
+	db.Put([]byte("a2"), []byte("mem-a2"))
+	db.Put([]byte("a4"), []byte("mem-a4"))
+	db.Delete([]byte("a3"))
+
+	batch := db.NewBatch()
+	batch.Put([]byte("a0"), []byte("mem-a0"))
+	batch.Delete([]byte("b1"))
+	batch.Write()
+
+	// Reads must see the merged view
+	for key, want := range map[string]string{"a1": "disk-a1", "a2": "mem-a2", "a3": "", "a4": "mem-a4", "b1": ""} {
+		have, err := db.Get([]byte(key))
+		if want == "" {
+			if err == nil {
+				t.Errorf("%s: deleted key retrieved: %s", key, have)
+			}
+			continue
+		}
+		if string(have) != want {
+			t.Errorf("%s: value mismatch: have %s, want %s", key, have, want)
+		}
+	}
+	var entries []string
+	it := db.NewIterator([]byte("a"), nil)
+	for it.Next() {
+		entries = append(entries, fmt.Sprintf("%s=%s", it.Key(), it.Value()))
+	}
+	it.Release()
+	if have, want := strings.Join(entries, ","), "a0=mem-a0,a1=disk-a1,a2=mem-a2,a4=mem-a4"; have != want {
+		t.Errorf("iteration mismatch: have %s, want %s", have, want)
+	}
+	// Snapshots must see the merged view too
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	db.Put([]byte("a3"), []byte("mem-a3"))
+	if has, _ := snap.Has([]byte("a3")); has {
+		t.Errorf("snapshot sees later write")
+	}
+	if val, _ := snap.Get([]byte("a1")); string(val) != "disk-a1" {
+		t.Errorf("snapshot value mismatch: have %s, want disk-a1", val)
+	}
+	snap.Release()
+
+	// The disk database must be left untouched
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		if val, _ := disk.Get([]byte(key)); string(val) != "disk-"+key {
+			t.Errorf("disk entry %s modified: %s", key, val)
+		}
+	}
+	if disk.Len() != 4 {
+		t.Errorf("disk entry count mismatch: have %d, want 4", disk.Len())
+	}
 }
@@ -0,0 +1,127 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package memorydb
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// NewOverlay returns an in-memory database layered on top of a disk database.
+// Reads fall through to the disk database unless the key was written or deleted
+// in memory, whereas all writes are buffered in memory, leaving the disk database
+// untouched. This allows running simulations against real chain data without
+// mutating it.
+//
+// The disk database is never written to, nor closed when the overlay is closed.
+func NewOverlay(disk ethdb.KeyValueStore) *Database {
+	return &Database{
+		db:      make(map[string][]byte),
+		disk:    disk,
+		deleted: make(map[string]struct{}),
+	}
+}
+
+// overlayIterator merges the iteration over the in-memory entries of an overlay
+// database with the iteration over the disk database, the former shadowing the
+// latter.
+type overlayIterator struct {
+	mem     *iterator           // Iterator over the in-memory entries
+	disk    ethdb.Iterator      // Iterator over the disk database
+	deleted map[string]struct{} // Keys deleted from the disk database
+
+	memOk  bool // Whether the memory iterator is positioned at an entry
+	diskOk bool // Whether the disk iterator is positioned at an entry
+
+	key   []byte // Key of the current entry
+	value []byte // Value of the current entry
+}
+
+// newOverlayIterator creates an iterator merging the given memory and disk
+// iterators, skipping the disk entries of the deleted keys.
+func newOverlayIterator(mem *iterator, disk ethdb.Iterator, deleted map[string]struct{}) *overlayIterator {
+	copied := make(map[string]struct{}, len(deleted))
+	for key := range deleted {
+		copied[key] = struct{}{}
+	}
+	return &overlayIterator{
+		mem:     mem,
+		disk:    disk,
+		deleted: copied,
+		memOk:   mem.Next(),
+		diskOk:  disk.Next(),
+	}
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *overlayIterator) Next() bool {
+	for it.memOk || it.diskOk {
+		// Take the memory entry if it precedes or shadows the disk entry
+		if it.memOk {
+			cmp := -1
+			if it.diskOk {
+				cmp = bytes.Compare(it.mem.Key(), it.disk.Key())
+			}
+			if cmp <= 0 {
+				if cmp == 0 {
+					it.diskOk = it.disk.Next()
+				}
+				it.key, it.value = it.mem.Key(), it.mem.Value()
+				it.memOk = it.mem.Next()
+				return true
+			}
+		}
+		// Otherwise take the disk entry, unless it was deleted
+		key := it.disk.Key()
+		if _, deleted := it.deleted[string(key)]; deleted {
+			it.diskOk = it.disk.Next()
+			continue
+		}
+		it.key, it.value = common.CopyBytes(key), common.CopyBytes(it.disk.Value())
+		it.diskOk = it.disk.Next()
+		return true
+	}
+	it.key, it.value = nil, nil
+	return false
+}
+
+// Error returns any accumulated error of the disk iterator.
+func (it *overlayIterator) Error() error {
+	return it.disk.Error()
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *overlayIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *overlayIterator) Value() []byte {
+	return it.value
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *overlayIterator) Release() {
+	it.mem.Release()
+	it.disk.Release()
+	it.memOk, it.diskOk = false, false
+	it.key, it.value = nil, nil
+}
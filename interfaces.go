@@ -171,6 +171,11 @@ type FilterQuery struct {
 	// {{A}, {B}}         matches topic A in first position AND B in second position
 	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
 	Topics [][]common.Hash
+
+	// Verified requests that the returned logs are checked against the receipts of
+	// the blocks they were taken from, detecting providers which return forged or
+	// incomplete results. It is only honoured by one-off queries.
+	Verified bool
 }
 
 // LogFilterer provides access to contract log events using a one-off query or continuous
@@ -208,6 +213,9 @@ type FeeHistory struct {
 	Reward       [][]*big.Int // list every txs priority fee per block
 	BaseFee      []*big.Int   // list of each block's base fee
 	GasUsedRatio []float64    // ratio of gas used out of the total available limit
+
+	BlobBaseFee      []*big.Int // list of each block's blob base fee (nil if unsupported by the node)
+	BlobGasUsedRatio []float64  // ratio of blob gas used out of the total available limit
 }
 
 // A PendingStateReader provides access to the pending state, which is the result of all
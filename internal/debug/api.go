@@ -23,6 +23,7 @@ package debug
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/user"
@@ -35,7 +36,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/hashicorp/go-bexpr"
 )
 
@@ -85,6 +88,11 @@ func (*HandlerT) GcStats() *debug.GCStats {
 	return s
 }
 
+// RpcStats returns the serving statistics of all RPC methods called so far.
+func (*HandlerT) RpcStats() map[string]rpc.MethodStats {
+	return rpc.Stats()
+}
+
 // CpuProfile turns on CPU profiling for nsec seconds and writes
 // profile data to file.
 func (h *HandlerT) CpuProfile(file string, nsec uint) error {
@@ -96,6 +104,31 @@ func (h *HandlerT) CpuProfile(file string, nsec uint) error {
 	return nil
 }
 
+// CpuProfileData turns on CPU profiling for nsec seconds and returns the
+// profile data, for retrieval by remote callers.
+func (h *HandlerT) CpuProfileData(nsec uint) (hexutil.Bytes, error) {
+	h.mu.Lock()
+	if h.cpuW != nil {
+		h.mu.Unlock()
+		return nil, errors.New("CPU profiling already in progress")
+	}
+	buf := new(bytes.Buffer)
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		h.mu.Unlock()
+		return nil, err
+	}
+	h.cpuW = nopCloser{buf}
+	h.cpuFile = "<rpc>"
+	log.Info("CPU profiling started", "dump", h.cpuFile)
+	h.mu.Unlock()
+
+	time.Sleep(time.Duration(nsec) * time.Second)
+	if err := h.StopCPUProfile(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // StartCPUProfile turns on CPU profiling, writing to the given file.
 func (h *HandlerT) StartCPUProfile(file string) error {
 	h.mu.Lock()
@@ -191,6 +224,20 @@ func (*HandlerT) WriteMemProfile(file string) error {
 	return writeProfile("heap", file)
 }
 
+// ProfileData returns the named runtime profile (e.g. "heap", "goroutine",
+// "block" or "mutex") in protobuf format, for retrieval by remote callers.
+func (*HandlerT) ProfileData(name string) (hexutil.Bytes, error) {
+	p := pprof.Lookup(name)
+	if p == nil {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	buf := new(bytes.Buffer)
+	if err := p.WriteTo(buf, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // Stacks returns a printed representation of the stacks of all goroutines. It
 // also permits the following optional filters to be used:
 //   - filter: boolean expression of packages to filter for
@@ -254,6 +301,14 @@ func writeProfile(name, file string) error {
 	return p.WriteTo(f, 0)
 }
 
+// nopCloser is a writer with a no-op Close method, used for profiles that are
+// collected in memory.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
 // expands home directory in file paths.
 // ~someuser/tmp will not be expanded.
 func expandHome(p string) string {
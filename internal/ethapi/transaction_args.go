@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -111,7 +112,7 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 			AccessList:           args.AccessList,
 		}
 		pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, b.RPCGasCap())
+		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, nil, b.RPCGasCap())
 		if err != nil {
 			return err
 		}
@@ -199,8 +200,9 @@ func (args *TransactionArgs) setLondonFeeDefaults(ctx context.Context, head *typ
 
 // ToMessage converts the transaction arguments to the Message type used by the
 // core evm. This method is used in calls and traces that do not require a real
-// live transaction.
-func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int) (*core.Message, error) {
+// live transaction. A non-zero txGasLimit is the maximum gas of a transaction
+// under the active chain rules, which caps the gas of calls not specifying any.
+func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int, txGasLimit uint64) (*core.Message, error) {
 	// Reject invalid combinations of pre- and post-1559 fee styles
 	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
 		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
@@ -213,6 +215,9 @@ func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int) (*
 	if gas == 0 {
 		gas = uint64(math.MaxUint64 / 2)
 	}
+	if txGasLimit != 0 && gas > txGasLimit {
+		gas = txGasLimit
+	}
 	if args.Gas != nil {
 		gas = uint64(*args.Gas)
 	}
@@ -324,6 +329,14 @@ func (args *TransactionArgs) toTransaction() *types.Transaction {
 	return types.NewTx(data)
 }
 
+// TxGasLimit returns the maximum gas a transaction executed in the context of
+// the given header may use, or zero if unlimited.
+func TxGasLimit(config *params.ChainConfig, header *types.Header) uint64 {
+	isMerge := header.Difficulty != nil && header.Difficulty.Sign() == 0
+	rules := config.Rules(header.Number, isMerge, header.Time)
+	return rules.TxGasLimit()
+}
+
 // ToTransaction converts the arguments to a transaction.
 // This assumes that setDefaults has been called.
 func (args *TransactionArgs) ToTransaction() *types.Transaction {
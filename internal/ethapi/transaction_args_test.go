@@ -207,6 +207,24 @@ func TestSetFeeDefaults(t *testing.T) {
 	}
 }
 
+// Tests that the default gas of calls is capped by the transaction gas limit,
+// while explicitly requested gas is not.
+func TestToMessageTxGasLimit(t *testing.T) {
+	var args TransactionArgs
+	msg, err := args.ToMessage(50_000_000, nil, 1_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.GasLimit != 1_000_000 {
+		t.Errorf("default gas mismatch: have %d, want %d", msg.GasLimit, 1_000_000)
+	}
+	gas := hexutil.Uint64(2_000_000)
+	args.Gas = &gas
+	if msg, _ = args.ToMessage(50_000_000, nil, 1_000_000); msg.GasLimit != 2_000_000 {
+		t.Errorf("explicit gas mismatch: have %d, want %d", msg.GasLimit, 2_000_000)
+	}
+}
+
 type backendMock struct {
 	current *types.Header
 	config  *params.ChainConfig
@@ -319,6 +337,9 @@ func (b *backendMock) SendTx(ctx context.Context, signedTx *types.Transaction) e
 func (b *backendMock) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	return nil, [32]byte{}, 0, 0, nil
 }
+func (b *backendMock) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, [32]byte{}, 0, 0, nil
+}
 func (b *backendMock) GetPoolTransactions() (types.Transactions, error)         { return nil, nil }
 func (b *backendMock) GetPoolTransaction(txHash common.Hash) *types.Transaction { return nil }
 func (b *backendMock) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
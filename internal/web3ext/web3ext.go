@@ -79,6 +79,12 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'getInturnSigner',
+			call: 'clique_getInturnSigner',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -152,6 +158,11 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'evictLocalTransaction',
+			call: 'admin_evictLocalTransaction',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -189,12 +200,32 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'reloadHTTP',
+			call: 'admin_reloadHTTP',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'reloadWS',
+			call: 'admin_reloadWS',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'remapNAT',
+			call: 'admin_remapNAT'
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'nodeInfo',
 			getter: 'admin_nodeInfo'
 		}),
+		new web3._extend.Property({
+			name: 'natStatus',
+			getter: 'admin_natStatus'
+		}),
 		new web3._extend.Property({
 			name: 'peers',
 			getter: 'admin_peers'
@@ -203,6 +234,10 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'localTransactions',
+			getter: 'admin_localTransactions'
+		}),
 	]
 });
 `
@@ -217,6 +252,18 @@ web3._extend({
 			params: 6,
 			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null, null, null],
 		}),
+		new web3._extend.Method({
+			name: 'accountRangeProof',
+			call: 'debug_accountRangeProof',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null],
+		}),
+		new web3._extend.Method({
+			name: 'storageRangeProof',
+			call: 'debug_storageRangeProof',
+			params: 5,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null, null],
+		}),
 		new web3._extend.Method({
 			name: 'printBlock',
 			call: 'debug_printBlock',
@@ -291,6 +338,11 @@ web3._extend({
 			inputFormatter: [null],
 			outputFormatter: console.log
 		}),
+		new web3._extend.Method({
+			name: 'rpcStats',
+			call: 'debug_rpcStats',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'freeOSMemory',
 			call: 'debug_freeOSMemory',
@@ -316,6 +368,16 @@ web3._extend({
 			call: 'debug_cpuProfile',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'cpuProfileData',
+			call: 'debug_cpuProfileData',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'profileData',
+			call: 'debug_profileData',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'startCPUProfile',
 			call: 'debug_startCPUProfile',
@@ -447,6 +509,45 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getBlockStateDiffs',
+			call: 'debug_getBlockStateDiffs',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setOpcodeCounting',
+			call: 'debug_setOpcodeCounting',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'opcodeCounters',
+			call: 'debug_opcodeCounters',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'opcodeTotals',
+			call: 'debug_opcodeTotals',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'executionWitness',
+			call: 'debug_executionWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'stateRetention',
+			call: 'debug_stateRetention',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'isStateRetained',
+			call: 'debug_isStateRetained',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -495,6 +596,22 @@ web3._extend({
 			call: 'debug_setTrieFlushInterval',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'startIndexRebuild',
+			call: 'debug_startIndexRebuild',
+			params: 3,
+			inputFormatter: [null, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'stopIndexRebuild',
+			call: 'debug_stopIndexRebuild',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'indexRebuildStatus',
+			call: 'debug_indexRebuildStatus',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -582,6 +699,26 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getTransactionBySenderAndNonce',
+			call: 'eth_getTransactionBySenderAndNonce',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.toHex],
+			outputFormatter: web3._extend.formatters.outputTransactionFormatter
+		}),
+		new web3._extend.Method({
+			name: 'getBalances',
+			call: 'eth_getBalances',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter],
+			outputFormatter: function(balances) { return balances.map(web3._extend.utils.toBigNumber); }
+		}),
+		new web3._extend.Method({
+			name: 'getAccount',
+			call: 'eth_getAccount',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter],
+		}),
 		new web3._extend.Method({
 			name: 'getProof',
 			call: 'eth_getProof',
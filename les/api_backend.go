@@ -213,6 +213,12 @@ func (b *LesApiBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return light.GetTransaction(ctx, b.eth.odr, txHash)
 }
 
+// GetTransactionBySenderAndNonce is not supported by light clients, the sender
+// nonce index is not available via ODR.
+func (b *LesApiBackend) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, common.Hash{}, 0, 0, nil
+}
+
 func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.eth.txPool.GetNonce(ctx, addr)
 }
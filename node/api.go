@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -154,6 +155,67 @@ func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error)
 	return rpcSub, nil
 }
 
+// NATStatus retrieves the external IP discovered through the NAT interface and
+// the state of the port mappings, or nil if NAT traversal is not configured.
+func (api *adminAPI) NATStatus() (*nat.Status, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.NATStatus(), nil
+}
+
+// RemapNAT forces the port mappings to be refreshed and the external IP to be
+// queried again. The outcome can be followed through NATStatus and NATEvents.
+func (api *adminAPI) RemapNAT() (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.RemapNAT(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NATEvents creates an RPC subscription which receives port mapping events,
+// posted when mapping attempts fail and when mappings are (re)established.
+func (api *adminAPI) NATEvents(ctx context.Context) (*rpc.Subscription, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+
+	// Create the subscription
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *nat.MappingEvent)
+		sub := server.SubscribeNATEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-events:
+				notifier.Notify(rpcSub.ID, event)
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // StartHTTP starts the HTTP RPC API server.
 func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *string, vhosts *string) (bool, error) {
 	api.node.lock.Lock()
@@ -176,6 +238,7 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		tokens:             api.node.apiTokens,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -228,6 +291,53 @@ func (api *adminAPI) StopRPC() (bool, error) {
 	return api.StopHTTP()
 }
 
+// ReloadHTTP replaces the CORS origins, modules and virtual hosts of the running
+// HTTP RPC API server, keeping the current values of omitted parameters.
+func (api *adminAPI) ReloadHTTP(cors *string, apis *string, vhosts *string) (bool, error) {
+	config := api.node.Config()
+	corsList, modules, vhostList := config.HTTPCors, config.HTTPModules, config.HTTPVirtualHosts
+	if cors != nil {
+		corsList = splitList(*cors)
+	}
+	if apis != nil {
+		modules = splitList(*apis)
+	}
+	if vhosts != nil {
+		vhostList = splitList(*vhosts)
+	}
+	if err := api.node.ReloadHTTP(corsList, vhostList, modules); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReloadWS replaces the allowed origins and modules of the running websocket RPC
+// API server, keeping the current values of omitted parameters. Connected clients
+// keep their previous modules until they reconnect.
+func (api *adminAPI) ReloadWS(allowedOrigins *string, apis *string) (bool, error) {
+	config := api.node.Config()
+	origins, modules := config.WSOrigins, config.WSModules
+	if allowedOrigins != nil {
+		origins = splitList(*allowedOrigins)
+	}
+	if apis != nil {
+		modules = splitList(*apis)
+	}
+	if err := api.node.ReloadWS(origins, modules); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// splitList splits a comma separated list, trimming the spaces around items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
 // StartWS starts the websocket RPC API server.
 func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
@@ -246,9 +356,15 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 	}
 
 	// Determine config.
+	subscriptions, err := api.node.wsSubscriptionConfig()
+	if err != nil {
+		return false, err
+	}
 	config := wsConfig{
-		Modules: api.node.config.WSModules,
-		Origins: api.node.config.WSOrigins,
+		Modules:       api.node.config.WSModules,
+		Origins:       api.node.config.WSOrigins,
+		tokens:        api.node.apiTokens,
+		subscriptions: subscriptions,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
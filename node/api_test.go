@@ -152,6 +152,21 @@ func TestStartRPC(t *testing.T) {
 			wantRPC:       false,
 			wantWS:        true,
 		},
+		{
+			name: "ws enabled through API with subscription limits",
+			cfg:  Config{WSMaxSubscriptions: 3, WSSubscriptionBuffer: 8, WSSlowConsumerPolicy: "drop"},
+			fn: func(t *testing.T, n *Node, api *adminAPI) {
+				_, err := api.StartWS(sp("127.0.0.1"), ip(0), nil, nil)
+				assert.NoError(t, err)
+
+				want := rpc.SubscriptionConfig{MaxSubscriptions: 3, BufferSize: 8, Policy: rpc.SlowConsumerDropOldest}
+				assert.Equal(t, want, n.http.wsConfig.subscriptions)
+			},
+			wantReachable: true,
+			wantHandlers:  false,
+			wantRPC:       false,
+			wantWS:        true,
+		},
 		{
 			name: "ws stopped through API",
 			cfg:  Config{WSHost: "127.0.0.1"},
@@ -241,6 +256,42 @@ func TestStartRPC(t *testing.T) {
 			wantRPC:       true,
 			wantWS:        true,
 		},
+		{
+			name: "rpc reloaded with ws enabled",
+			cfg:  Config{HTTPHost: "127.0.0.1"},
+			fn: func(t *testing.T, n *Node, api *adminAPI) {
+				wsport := n.http.port
+				_, err := api.StartWS(sp("127.0.0.1"), ip(wsport), nil, nil)
+				assert.NoError(t, err)
+
+				ws := n.http.wsHandler.Load().(*rpcHandler)
+				_, err = api.ReloadHTTP(sp("*"), nil, nil)
+				assert.NoError(t, err)
+				assert.Same(t, ws, n.http.wsHandler.Load().(*rpcHandler), "websocket handler replaced by http reload")
+			},
+			wantReachable: true,
+			wantHandlers:  true,
+			wantRPC:       true,
+			wantWS:        true,
+		},
+		{
+			name: "ws reloaded with rpc enabled",
+			cfg:  Config{HTTPHost: "127.0.0.1"},
+			fn: func(t *testing.T, n *Node, api *adminAPI) {
+				wsport := n.http.port
+				_, err := api.StartWS(sp("127.0.0.1"), ip(wsport), nil, nil)
+				assert.NoError(t, err)
+
+				rpc := n.http.httpHandler.Load().(*rpcHandler)
+				_, err = api.ReloadWS(sp("*"), nil)
+				assert.NoError(t, err)
+				assert.Same(t, rpc, n.http.httpHandler.Load().(*rpcHandler), "http handler replaced by websocket reload")
+			},
+			wantReachable: true,
+			wantHandlers:  true,
+			wantRPC:       true,
+			wantWS:        true,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,243 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// ScopeRead grants the read-only methods of the eth, net, web3 and txpool
+	// namespaces.
+	ScopeRead = "read"
+
+	// ScopeAdmin grants all methods exposed by the server.
+	ScopeAdmin = "admin"
+)
+
+var (
+	// readModules are the namespaces granted by ScopeRead.
+	readModules = []string{"eth", "net", "web3", "txpool"}
+
+	// writeMethods are the methods of readModules which are not granted by
+	// ScopeRead, as they change the state of the node or the chain.
+	writeMethods = map[string]bool{
+		"eth_sendTransaction":    true,
+		"eth_sendRawTransaction": true,
+		"eth_sign":               true,
+		"eth_signTransaction":    true,
+		"eth_resend":             true,
+		"eth_submitWork":         true,
+		"eth_submitHashrate":     true,
+	}
+)
+
+// APIToken authorizes HTTP and WebSocket clients presenting it as bearer token
+// to call a subset of the exposed methods.
+type APIToken struct {
+	Name    string     `json:"name"`              // Name of the token holder, reported in the logs
+	Token   string     `json:"token"`             // Secret presented by the client
+	Scope   string     `json:"scope,omitempty"`   // Predefined set of granted methods, "read" or "admin"
+	Modules []string   `json:"modules,omitempty"` // Namespaces granted in addition to the scope
+	Methods []string   `json:"methods,omitempty"` // Methods granted in addition to the scope
+	Expires *time.Time `json:"expires,omitempty"` // Time after which the token is rejected
+}
+
+// validate checks the token definition for errors.
+func (t *APIToken) validate() error {
+	switch {
+	case t.Name == "":
+		return errors.New("missing name")
+	case len(t.Token) < 16:
+		return fmt.Errorf("token of %q too short, need at least 16 characters", t.Name)
+	case t.Scope != "" && t.Scope != ScopeRead && t.Scope != ScopeAdmin:
+		return fmt.Errorf("unknown scope %q of %q", t.Scope, t.Name)
+	}
+	return nil
+}
+
+// Allow reports whether the token grants the method. The methods of the rpc
+// namespace, describing the server, are always granted.
+func (t *APIToken) Allow(method string) bool {
+	namespace := method
+	if i := strings.IndexByte(method, '_'); i >= 0 {
+		namespace = method[:i]
+	}
+	if namespace == rpc.MetadataApi {
+		return true
+	}
+	for _, m := range t.Methods {
+		if m == method {
+			return true
+		}
+	}
+	for _, m := range t.Modules {
+		if m == namespace {
+			return true
+		}
+	}
+	switch t.Scope {
+	case ScopeAdmin:
+		return true
+	case ScopeRead:
+		if writeMethods[method] {
+			return false
+		}
+		for _, m := range readModules {
+			if m == namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiTokens is the set of tokens defined in a JSON file. The file is reloaded
+// whenever it changes, so tokens can be added, rotated and revoked without a
+// restart.
+type apiTokens struct {
+	path string
+
+	lock    sync.Mutex
+	modTime time.Time
+	tokens  []*APIToken
+}
+
+// newAPITokens loads the tokens of the file.
+func newAPITokens(path string) (*apiTokens, error) {
+	t := &apiTokens{path: path}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reload reads the token file if it changed since the last load. The caller
+// must hold the lock, unless the set isn't shared yet.
+func (t *apiTokens) reload() error {
+	stat, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+	if stat.ModTime().Equal(t.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	var tokens []*APIToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return fmt.Errorf("invalid API token file %s: %v", t.path, err)
+	}
+	for _, token := range tokens {
+		if err := token.validate(); err != nil {
+			return fmt.Errorf("invalid API token file %s: %v", t.path, err)
+		}
+	}
+	t.modTime, t.tokens = stat.ModTime(), tokens
+	log.Info("Loaded API tokens", "path", t.path, "tokens", len(tokens))
+	return nil
+}
+
+// lookup returns the token matching the secret.
+func (t *apiTokens) lookup(secret string) (*APIToken, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Keep serving the previous tokens if the file got broken
+	if err := t.reload(); err != nil {
+		log.Error("Failed to reload API tokens", "err", err)
+	}
+	var match *APIToken
+	for _, token := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Token), []byte(secret)) == 1 {
+			match = token
+		}
+	}
+	switch {
+	case match == nil:
+		return nil, errors.New("invalid token")
+	case match.Expires != nil && time.Now().After(*match.Expires):
+		return nil, errors.New("token is expired")
+	}
+	return match, nil
+}
+
+// auditLog records the calls of all token authenticated clients. It is tagged
+// so that the audit trail can be filtered out of the node logs.
+var auditLog = log.New("audit", "api")
+
+// tokenPolicy is the access policy of an authenticated client, recording all
+// its calls in the audit log.
+type tokenPolicy struct {
+	token  *APIToken
+	remote string
+}
+
+// Allow implements rpc.AccessPolicy.
+func (p *tokenPolicy) Allow(method string) bool {
+	if !p.token.Allow(method) {
+		auditLog.Warn("Denied API call", "token", p.token.Name, "method", method, "remote", p.remote)
+		return false
+	}
+	auditLog.Info("Authorized API call", "token", p.token.Name, "method", method, "remote", p.remote)
+	return true
+}
+
+type tokenHandler struct {
+	tokens *apiTokens
+	next   http.Handler
+}
+
+// newTokenHandler creates a http.Handler authenticating clients by API tokens
+// and restricting their calls to the methods granted by the token.
+func newTokenHandler(tokens *apiTokens, next http.Handler) http.Handler {
+	return &tokenHandler{tokens: tokens, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (handler *tokenHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
+	var secret string
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		secret = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if len(secret) == 0 {
+		auditLog.Warn("Rejected API request", "remote", r.RemoteAddr, "err", "missing token")
+		http.Error(out, "missing token", http.StatusUnauthorized)
+		return
+	}
+	token, err := handler.tokens.lookup(secret)
+	if err != nil {
+		auditLog.Warn("Rejected API request", "remote", r.RemoteAddr, "err", err)
+		http.Error(out, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	policy := &tokenPolicy{token: token, remote: r.RemoteAddr}
+	handler.next.ServeHTTP(out, r.WithContext(rpc.NewContextWithAccessPolicy(r.Context(), policy)))
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenAllow(t *testing.T) {
+	tests := []struct {
+		token  APIToken
+		method string
+		want   bool
+	}{
+		{APIToken{}, "rpc_modules", true},
+		{APIToken{}, "eth_blockNumber", false},
+		{APIToken{Scope: ScopeRead}, "eth_blockNumber", true},
+		{APIToken{Scope: ScopeRead}, "net_version", true},
+		{APIToken{Scope: ScopeRead}, "eth_sendRawTransaction", false},
+		{APIToken{Scope: ScopeRead}, "debug_traceTransaction", false},
+		{APIToken{Scope: ScopeRead}, "admin_addPeer", false},
+		{APIToken{Scope: ScopeRead, Methods: []string{"debug_traceTransaction"}}, "debug_traceTransaction", true},
+		{APIToken{Scope: ScopeRead, Methods: []string{"debug_traceTransaction"}}, "debug_traceCall", false},
+		{APIToken{Scope: ScopeRead, Modules: []string{"debug"}}, "debug_traceCall", true},
+		{APIToken{Scope: ScopeRead, Methods: []string{"eth_sendRawTransaction"}}, "eth_sendRawTransaction", true},
+		{APIToken{Scope: ScopeAdmin}, "admin_addPeer", true},
+	}
+	for i, tt := range tests {
+		if have := tt.token.Allow(tt.method); have != tt.want {
+			t.Errorf("test %d: %s allowed %v, want %v", i, tt.method, have, tt.want)
+		}
+	}
+}
+
+func writeAPITokens(t *testing.T, path string, tokens []*APIToken, modTime time.Time) {
+	t.Helper()
+
+	blob, err := json.Marshal(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	var (
+		path    = filepath.Join(t.TempDir(), "tokens.json")
+		expired = time.Now().Add(-time.Hour)
+		start   = time.Now().Add(-time.Minute)
+	)
+	writeAPITokens(t, path, []*APIToken{
+		{Name: "alice", Token: "alice-secret-token", Methods: []string{"test_greet"}},
+		{Name: "bob", Token: "bob-secret-token-1", Modules: []string{"test"}, Expires: &expired},
+		{Name: "carol", Token: "carol-secret-token", Scope: ScopeRead},
+	}, start)
+
+	tokens, err := newAPITokens(path)
+	if err != nil {
+		t.Fatalf("failed to load tokens: %v", err)
+	}
+	srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+	if err := srv.enableRPC(apis(), httpConfig{tokens: tokens}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.enableWS(apis(), wsConfig{Origins: []string{"*"}, tokens: tokens}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.setListenAddr("localhost", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.stop()
+
+	call := func(url, token string) error {
+		client, err := rpc.DialOptions(context.Background(), url, rpc.WithHeader("Authorization", "Bearer "+token))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var greeting string
+		return client.Call(&greeting, "test_greet")
+	}
+	for _, url := range []string{fmt.Sprintf("http://%v", srv.listenAddr()), fmt.Sprintf("ws://%v", srv.listenAddr())} {
+		if err := call(url, "alice-secret-token"); err != nil {
+			t.Errorf("%s: granted call failed: %v", url, err)
+		}
+		if err := call(url, "bob-secret-token-1"); err == nil {
+			t.Errorf("%s: call with expired token succeeded", url)
+		}
+		if err := call(url, "carol-secret-token"); err == nil {
+			t.Errorf("%s: call of method outside of scope succeeded", url)
+		} else if rpcErr, ok := err.(rpc.Error); !ok || rpcErr.ErrorCode() != -32003 {
+			t.Errorf("%s: unexpected error of denied call: %v", url, err)
+		}
+		if err := call(url, "unknown-secret-token"); err == nil {
+			t.Errorf("%s: call with unknown token succeeded", url)
+		}
+	}
+	// Rotate the token of bob, the file is picked up without restart
+	writeAPITokens(t, path, []*APIToken{
+		{Name: "bob", Token: "bob-secret-token-2", Modules: []string{"test"}},
+	}, start.Add(time.Second))
+
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+	if err := call(url, "bob-secret-token-2"); err != nil {
+		t.Errorf("call with rotated token failed: %v", err)
+	}
+	if err := call(url, "alice-secret-token"); err == nil {
+		t.Errorf("call with revoked token succeeded")
+	}
+	// Broken files are rejected, retaining the previous tokens
+	if err := os.WriteFile(path, []byte("["), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := call(url, "bob-secret-token-2"); err != nil {
+		t.Errorf("call after broken reload failed: %v", err)
+	}
+}
+
+// Tests that browser preflight requests, which carry no credentials, are served
+// by the CORS handler and that rejected requests carry CORS headers.
+func TestAPITokensCors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	writeAPITokens(t, path, []*APIToken{
+		{Name: "alice", Token: "alice-secret-token", Scope: ScopeAdmin},
+	}, time.Now())
+
+	tokens, err := newAPITokens(path)
+	if err != nil {
+		t.Fatalf("failed to load tokens: %v", err)
+	}
+	srv := createAndStartServer(t, &httpConfig{CorsAllowedOrigins: []string{"test.com"}, tokens: tokens}, false, &wsConfig{}, nil)
+	defer srv.stop()
+	url := "http://" + srv.listenAddr()
+
+	req, err := http.NewRequest(http.MethodOptions, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", "test.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Less(t, resp.StatusCode, 300)
+	assert.Equal(t, "test.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	resp = rpcRequest(t, url, testMethod, "origin", "test.com")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "test.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	resp = rpcRequest(t, url, testMethod, "origin", "test.com", "authorization", "Bearer alice-secret-token")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -175,6 +176,20 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSMaxSubscriptions is the maximum number of active subscriptions of a single
+	// websocket connection. Zero means no limit.
+	WSMaxSubscriptions int `toml:",omitempty"`
+
+	// WSSubscriptionBuffer is the number of notifications per subscription queued
+	// for a slow websocket client before WSSlowConsumerPolicy takes effect. Zero
+	// disables queueing.
+	WSSubscriptionBuffer int `toml:",omitempty"`
+
+	// WSSlowConsumerPolicy selects how subscriptions of slow websocket clients are
+	// handled when their queue is full: "backpressure" (default), "drop" to drop
+	// the oldest notifications or "disconnect".
+	WSSlowConsumerPolicy string `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
@@ -200,6 +215,15 @@ type Config struct {
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
+	// APITokens is the path to a JSON file of API tokens. If set, clients of the
+	// HTTP and WebSocket APIs must authenticate with one of the tokens, which
+	// limit the methods they may call.
+	APITokens string `toml:",omitempty"`
+
+	// RPCSlowCallThreshold is the serving time above which RPC calls are logged
+	// as slow. Zero disables slow call logging.
+	RPCSlowCallThreshold time.Duration `toml:",omitempty"`
+
 	// EnablePersonal enables the deprecated personal namespace.
 	EnablePersonal bool `toml:"-"`
 
@@ -63,6 +63,7 @@ type Node struct {
 	wsAuth        *httpServer //
 	ipc           *ipcServer  // Stores information about the ipc http server
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	apiTokens     *apiTokens  // Tokens authorizing HTTP and WebSocket clients, nil if open
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -73,8 +74,10 @@ const (
 	closedState
 )
 
-// New creates a new P2P node, ready for protocol registration.
-func New(conf *Config) (*Node, error) {
+// New creates a new P2P node, ready for protocol registration. Any options are
+// applied to the node before it is returned, allowing embedders to register
+// their own services, APIs and protocols in a single step.
+func New(conf *Config, opts ...Option) (*Node, error) {
 	// Copy config and resolve the datadir so future changes to the current
 	// working directory don't affect the node.
 	confCopy := *conf
@@ -115,6 +118,9 @@ func New(conf *Config) (*Node, error) {
 	// Register built-in APIs.
 	node.rpcAPIs = append(node.rpcAPIs, node.apis()...)
 
+	// Slow call logging applies to all RPC servers of the process.
+	rpc.SetSlowCallThreshold(conf.RPCSlowCallThreshold)
+
 	// Acquire the instance directory lock.
 	if err := node.openDataDir(); err != nil {
 		return nil, err
@@ -153,6 +159,14 @@ func New(conf *Config) (*Node, error) {
 	node.wsAuth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
 
+	// Apply any embedder supplied options, releasing the resources acquired
+	// above if any of them fails.
+	for _, opt := range opts {
+		if err := opt(node); err != nil {
+			node.doClose(nil)
+			return nil, err
+		}
+	}
 	return node, nil
 }
 
@@ -402,6 +416,13 @@ func (n *Node) startRPC() error {
 		servers           []*httpServer
 		openAPIs, allAPIs = n.getAPIs()
 	)
+	if n.config.APITokens != "" {
+		tokens, err := newAPITokens(n.config.APITokens)
+		if err != nil {
+			return err
+		}
+		n.apiTokens = tokens
+	}
 
 	initHttp := func(server *httpServer, port int) error {
 		if err := server.setListenAddr(n.config.HTTPHost, port); err != nil {
@@ -412,6 +433,7 @@ func (n *Node) startRPC() error {
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
+			tokens:             n.apiTokens,
 		}); err != nil {
 			return err
 		}
@@ -424,10 +446,16 @@ func (n *Node) startRPC() error {
 		if err := server.setListenAddr(n.config.WSHost, port); err != nil {
 			return err
 		}
+		subscriptions, err := n.wsSubscriptionConfig()
+		if err != nil {
+			return err
+		}
 		if err := server.enableWS(openAPIs, wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
-			prefix:  n.config.WSPathPrefix,
+			Modules:       n.config.WSModules,
+			Origins:       n.config.WSOrigins,
+			prefix:        n.config.WSPathPrefix,
+			tokens:        n.apiTokens,
+			subscriptions: subscriptions,
 		}); err != nil {
 			return err
 		}
@@ -512,6 +540,109 @@ func (n *Node) wsServerForPort(port int, authenticated bool) *httpServer {
 	return wsServer
 }
 
+// ReloadRPC applies the HTTP CORS origins, virtual hosts and modules, and the
+// WebSocket origins and modules of the given config to the running RPC endpoints
+// without restarting them. Endpoints that are not enabled are left disabled, and
+// all other settings of the config are ignored.
+func (n *Node) ReloadRPC(conf *Config) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != runningState {
+		return ErrNodeStopped
+	}
+	if n.http.rpcAllowed() {
+		if err := n.reloadHTTP(conf.HTTPCors, conf.HTTPVirtualHosts, conf.HTTPModules); err != nil {
+			return err
+		}
+	}
+	if n.http.wsAllowed() || n.ws.wsAllowed() {
+		if err := n.reloadWS(conf.WSOrigins, conf.WSModules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReloadHTTP replaces the CORS origins, virtual hosts and modules of the running
+// HTTP RPC endpoint without restarting it. The WebSocket endpoint is unaffected.
+func (n *Node) ReloadHTTP(cors, vhosts, modules []string) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != runningState {
+		return ErrNodeStopped
+	}
+	if !n.http.rpcAllowed() {
+		return errors.New("HTTP RPC not running")
+	}
+	return n.reloadHTTP(cors, vhosts, modules)
+}
+
+// ReloadWS replaces the allowed origins and modules of the running WebSocket RPC
+// endpoint without restarting it. Established connections are kept, the new
+// settings apply to connections made afterwards. The HTTP endpoint is unaffected.
+func (n *Node) ReloadWS(origins, modules []string) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != runningState {
+		return ErrNodeStopped
+	}
+	if !n.http.wsAllowed() && !n.ws.wsAllowed() {
+		return errors.New("WebSocket RPC not running")
+	}
+	return n.reloadWS(origins, modules)
+}
+
+// reloadHTTP applies the given settings to the HTTP RPC endpoint. The caller must
+// hold n.lock and ensure the endpoint is enabled.
+func (n *Node) reloadHTTP(cors, vhosts, modules []string) error {
+	openAPIs, _ := n.getAPIs()
+	if err := n.http.reloadRPC(openAPIs, cors, vhosts, modules); err != nil {
+		return err
+	}
+	n.config.HTTPCors, n.config.HTTPVirtualHosts, n.config.HTTPModules = cors, vhosts, modules
+
+	n.log.Info("Reloaded HTTP RPC configuration", "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","), "modules", strings.Join(modules, ","))
+	return nil
+}
+
+// reloadWS applies the given settings to the WebSocket RPC endpoint, which is
+// served either on its own port or on the HTTP one. The caller must hold n.lock
+// and ensure the endpoint is enabled.
+func (n *Node) reloadWS(origins, modules []string) error {
+	openAPIs, _ := n.getAPIs()
+	for _, server := range []*httpServer{n.http, n.ws} {
+		if server.wsAllowed() {
+			if err := server.reloadWS(openAPIs, origins, modules); err != nil {
+				return err
+			}
+		}
+	}
+	n.config.WSOrigins, n.config.WSModules = origins, modules
+
+	n.log.Info("Reloaded WebSocket RPC configuration", "origins", strings.Join(origins, ","), "modules", strings.Join(modules, ","))
+	return nil
+}
+
+// wsSubscriptionConfig returns the subscription limits of the WebSocket RPC
+// endpoints.
+func (n *Node) wsSubscriptionConfig() (rpc.SubscriptionConfig, error) {
+	config := rpc.SubscriptionConfig{
+		MaxSubscriptions: n.config.WSMaxSubscriptions,
+		BufferSize:       n.config.WSSubscriptionBuffer,
+	}
+	if n.config.WSSlowConsumerPolicy != "" {
+		policy, err := rpc.ParseSlowConsumerPolicy(n.config.WSSlowConsumerPolicy)
+		if err != nil {
+			return config, err
+		}
+		config.Policy = policy
+	}
+	return config, nil
+}
+
 func (n *Node) stopRPC() {
 	n.http.stop()
 	n.ws.stop()
@@ -147,6 +147,59 @@ func TestRegisterProtocols(t *testing.T) {
 	}
 }
 
+// Tests that services, APIs and modules can be registered via construction options.
+func TestNodeOptions(t *testing.T) {
+	var (
+		noop = NewNoop()
+		api  = rpc.API{Namespace: "research"}
+	)
+	stack, err := New(testNodeConfig(),
+		WithService(func(stack *Node) error {
+			_, err := NewFullService(stack)
+			return err
+		}),
+		WithLifecycle(noop),
+		WithAPI(api),
+		WithHTTPModule("research", "research"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	defer stack.Close()
+
+	if !containsLifecycle(stack.lifecycles, noop) {
+		t.Fatal("lifecycle was not registered via options")
+	}
+	if !containsAPI(stack.rpcAPIs, api) {
+		t.Fatal("api was not registered via options")
+	}
+	for _, protocol := range new(FullService).Protocols() {
+		if !containsProtocol(stack.server.Protocols, protocol) {
+			t.Fatalf("protocol %v was not registered via service option", protocol)
+		}
+	}
+	if modules := stack.config.HTTPModules; len(modules) != 1 || modules[0] != "research" {
+		t.Fatalf("http modules mismatch: have %v, want [research]", modules)
+	}
+}
+
+// Tests that a failing construction option aborts node creation and releases
+// the data directory.
+func TestNodeOptionsFailure(t *testing.T) {
+	dir := t.TempDir()
+	failure := errors.New("fail")
+
+	_, err := New(&Config{DataDir: dir}, WithService(func(*Node) error { return failure }))
+	if err != failure {
+		t.Fatalf("option failure mismatch: have %v, want %v", err, failure)
+	}
+	stack, err := New(&Config{DataDir: dir})
+	if err != nil {
+		t.Fatalf("failed to reuse data dir after failed construction: %v", err)
+	}
+	stack.Close()
+}
+
 // This test checks that open databases are closed with node.
 func TestNodeCloseClosesDB(t *testing.T) {
 	stack, _ := New(testNodeConfig())
@@ -0,0 +1,113 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Option is a construction time hook that configures a Node being created by
+// New. Options are applied in order, after the node has been fully initialized
+// but before it is returned to the caller, so they may register anything that
+// could otherwise be registered on a freshly created node.
+type Option func(stack *Node) error
+
+// WithService runs a service constructor against the node being created. The
+// constructor is expected to register its lifecycles, APIs and protocols on
+// the node itself, the same way the built-in services do.
+func WithService(constructor func(stack *Node) error) Option {
+	return func(stack *Node) error {
+		return constructor(stack)
+	}
+}
+
+// WithLifecycle registers the given lifecycles on the node.
+func WithLifecycle(lifecycles ...Lifecycle) Option {
+	return func(stack *Node) error {
+		for _, lifecycle := range lifecycles {
+			stack.RegisterLifecycle(lifecycle)
+		}
+		return nil
+	}
+}
+
+// WithAPI registers the given RPC APIs on the node. Note, APIs are only exposed
+// over HTTP and WebSocket if their namespace is whitelisted in the config (or
+// via WithHTTPModule and WithWSModule).
+func WithAPI(apis ...rpc.API) Option {
+	return func(stack *Node) error {
+		stack.RegisterAPIs(apis)
+		return nil
+	}
+}
+
+// WithProtocol registers the given devp2p protocols on the node's p2p server.
+func WithProtocol(protocols ...p2p.Protocol) Option {
+	return func(stack *Node) error {
+		stack.RegisterProtocols(protocols)
+		return nil
+	}
+}
+
+// WithHandler mounts a handler on the given path on the node's HTTP server.
+func WithHandler(name, path string, handler http.Handler) Option {
+	return func(stack *Node) error {
+		stack.RegisterHandler(name, path, handler)
+		return nil
+	}
+}
+
+// WithHTTPModule exposes the given API namespaces over the HTTP RPC interface,
+// in addition to the ones already configured.
+func WithHTTPModule(modules ...string) Option {
+	return func(stack *Node) error {
+		stack.config.HTTPModules = appendModules(stack.config.HTTPModules, modules)
+		return nil
+	}
+}
+
+// WithWSModule exposes the given API namespaces over the WebSocket RPC interface,
+// in addition to the ones already configured.
+func WithWSModule(modules ...string) Option {
+	return func(stack *Node) error {
+		stack.config.WSModules = appendModules(stack.config.WSModules, modules)
+		return nil
+	}
+}
+
+// appendModules returns a new module list with all the extra modules appended
+// which are not yet present. The original list is never modified since it may
+// be shared with the user supplied config.
+func appendModules(modules []string, extra []string) []string {
+	result := append([]string(nil), modules...)
+	for _, module := range extra {
+		present := false
+		for _, have := range result {
+			if have == module {
+				present = true
+				break
+			}
+		}
+		if !present {
+			result = append(result, module)
+		}
+	}
+	return result
+}
@@ -40,21 +40,110 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
-	prefix             string // path prefix on which to mount http handler
-	jwtSecret          []byte // optional JWT secret
+	prefix             string     // path prefix on which to mount http handler
+	jwtSecret          []byte     // optional JWT secret
+	tokens             *apiTokens // optional API tokens to authorize clients with
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
-	Origins   []string
-	Modules   []string
-	prefix    string // path prefix on which to mount ws handler
-	jwtSecret []byte // optional JWT secret
-}
-
+	Origins       []string
+	Modules       []string
+	prefix        string                 // path prefix on which to mount ws handler
+	jwtSecret     []byte                 // optional JWT secret
+	tokens        *apiTokens             // optional API tokens to authorize clients with
+	subscriptions rpc.SubscriptionConfig // subscription limits of the connections
+}
+
+// rpcHandler is an RPC server along with the HTTP handler serving it. A handler
+// replaced by a configuration reload is retired: it keeps serving the requests
+// and websocket connections it already accepted, stopping the server once they
+// are all done.
 type rpcHandler struct {
 	http.Handler
 	server *rpc.Server
+
+	lock    sync.Mutex
+	active  int           // Number of requests and connections being served
+	retired bool          // Whether the handler was replaced, stopping when idle
+	stopped bool          // Whether the server was stopped
+	prev    []*rpcHandler // Retired handlers still serving, stopped along this one
+}
+
+// acquire registers a request served by the handler, failing if the server was
+// already stopped.
+func (h *rpcHandler) acquire() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.stopped {
+		return false
+	}
+	h.active++
+	return true
+}
+
+// release unregisters a served request, stopping the server if the handler was
+// retired and this was the last request in flight.
+func (h *rpcHandler) release() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.active--; h.active == 0 && h.retired && !h.stopped {
+		h.stopped = true
+		h.server.Stop()
+	}
+}
+
+// retire marks the handler replaced, stopping the server right away if it has
+// nothing in flight, or once the last request finishes otherwise.
+func (h *rpcHandler) retire() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.retired = true
+	if h.active == 0 && !h.stopped {
+		h.stopped = true
+		h.server.Stop()
+	}
+}
+
+// replace retires the handler in favor of the given one, which takes over the
+// duty of stopping it if it is still serving when the new one gets stopped.
+func (h *rpcHandler) replace(next *rpcHandler) {
+	for _, prev := range append(h.prev, h) {
+		prev.lock.Lock()
+		if !prev.stopped {
+			next.prev = append(next.prev, prev)
+		}
+		prev.lock.Unlock()
+	}
+	h.retire()
+}
+
+// stop terminates the server, along with the retired ones it replaced.
+func (h *rpcHandler) stop() {
+	for _, handler := range append(h.prev, h) {
+		handler.lock.Lock()
+		handler.stopped = true
+		handler.lock.Unlock()
+
+		handler.server.Stop()
+	}
+}
+
+// serveRPC serves the request with the handler currently stored in the given
+// slot. If that handler got retired and stopped meanwhile, the request is served
+// by its replacement. False is returned if there is no handler to serve with.
+func serveRPC(slot *atomic.Value, w http.ResponseWriter, r *http.Request) bool {
+	for handler := slot.Load().(*rpcHandler); handler != nil; handler = slot.Load().(*rpcHandler) {
+		if handler.acquire() {
+			defer handler.release()
+			handler.ServeHTTP(w, r)
+			return true
+		}
+	}
+	return false
 }
 
 type httpServer struct {
@@ -193,7 +282,7 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ws := h.wsHandler.Load().(*rpcHandler)
 	if ws != nil && isWebsocket(r) {
 		if checkPath(r, h.wsConfig.prefix) {
-			ws.ServeHTTP(w, r)
+			serveRPC(&h.wsHandler, w, r)
 		}
 		return
 	}
@@ -211,8 +300,7 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if checkPath(r, h.httpConfig.prefix) {
-			rpc.ServeHTTP(w, r)
+		if checkPath(r, h.httpConfig.prefix) && serveRPC(&h.httpHandler, w, r) {
 			return
 		}
 	}
@@ -263,11 +351,11 @@ func (h *httpServer) doStop() {
 	wsHandler := h.wsHandler.Load().(*rpcHandler)
 	if httpHandler != nil {
 		h.httpHandler.Store((*rpcHandler)(nil))
-		httpHandler.server.Stop()
+		httpHandler.stop()
 	}
 	if wsHandler != nil {
 		h.wsHandler.Store((*rpcHandler)(nil))
-		wsHandler.server.Stop()
+		wsHandler.stop()
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
@@ -295,25 +383,62 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 		return fmt.Errorf("JSON-RPC over HTTP is already enabled")
 	}
 
-	// Create RPC server and handler.
-	srv := rpc.NewServer()
-	if err := RegisterApis(apis, config.Modules, srv); err != nil {
+	handler, err := newHTTPRPCHandler(apis, config)
+	if err != nil {
 		return err
 	}
 	h.httpConfig = config
-	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
-		server:  srv,
-	})
+	h.httpHandler.Store(handler)
+	return nil
+}
+
+// reloadRPC replaces the CORS origins, virtual hosts and modules of the enabled
+// JSON-RPC over HTTP handler. Requests in flight finish on the previous handler,
+// whose server is stopped afterwards.
+func (h *httpServer) reloadRPC(apis []rpc.API, cors, vhosts, modules []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.rpcAllowed() {
+		return fmt.Errorf("JSON-RPC over HTTP is not enabled")
+	}
+	config := h.httpConfig
+	config.CorsAllowedOrigins, config.Vhosts, config.Modules = cors, vhosts, modules
+
+	handler, err := newHTTPRPCHandler(apis, config)
+	if err != nil {
+		return err
+	}
+	old := h.httpHandler.Load().(*rpcHandler)
+	h.httpConfig = config
+	h.httpHandler.Store(handler)
+	old.replace(handler)
 	return nil
 }
 
+// newHTTPRPCHandler creates an RPC server serving the given APIs over HTTP.
+func newHTTPRPCHandler(apis []rpc.API, config httpConfig) (*rpcHandler, error) {
+	// Create RPC server and handler.
+	srv := rpc.NewServer()
+	if err := RegisterApis(apis, config.Modules, srv); err != nil {
+		return nil, err
+	}
+	// Authenticate API tokens within the CORS handler, as browsers send preflight
+	// requests without credentials and need CORS headers on rejections too.
+	var handler http.Handler = srv
+	if config.tokens != nil {
+		handler = newTokenHandler(config.tokens, handler)
+	}
+	handler = NewHTTPHandlerStack(handler, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret)
+	return &rpcHandler{Handler: handler, server: srv}, nil
+}
+
 // disableRPC stops the HTTP RPC handler. This is internal, the caller must hold h.mu.
 func (h *httpServer) disableRPC() bool {
 	handler := h.httpHandler.Load().(*rpcHandler)
 	if handler != nil {
 		h.httpHandler.Store((*rpcHandler)(nil))
-		handler.server.Stop()
+		handler.stop()
 	}
 	return handler != nil
 }
@@ -326,19 +451,55 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if h.wsAllowed() {
 		return fmt.Errorf("JSON-RPC over WebSocket is already enabled")
 	}
-	// Create RPC server and handler.
-	srv := rpc.NewServer()
-	if err := RegisterApis(apis, config.Modules, srv); err != nil {
+	handler, err := newWSRPCHandler(apis, config)
+	if err != nil {
 		return err
 	}
 	h.wsConfig = config
-	h.wsHandler.Store(&rpcHandler{
-		Handler: NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret),
-		server:  srv,
-	})
+	h.wsHandler.Store(handler)
 	return nil
 }
 
+// reloadWS replaces the allowed origins and modules of the enabled JSON-RPC over
+// WebSocket handler. Connections established before keep being served with the
+// previous modules until they are closed, the new configuration applies to the
+// connections established afterwards.
+func (h *httpServer) reloadWS(apis []rpc.API, origins, modules []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.wsAllowed() {
+		return fmt.Errorf("JSON-RPC over WebSocket is not enabled")
+	}
+	config := h.wsConfig
+	config.Origins, config.Modules = origins, modules
+
+	handler, err := newWSRPCHandler(apis, config)
+	if err != nil {
+		return err
+	}
+	old := h.wsHandler.Load().(*rpcHandler)
+	h.wsConfig = config
+	h.wsHandler.Store(handler)
+	old.replace(handler)
+	return nil
+}
+
+// newWSRPCHandler creates an RPC server serving the given APIs over WebSocket.
+func newWSRPCHandler(apis []rpc.API, config wsConfig) (*rpcHandler, error) {
+	// Create RPC server and handler.
+	srv := rpc.NewServer()
+	srv.SetSubscriptionConfig(config.subscriptions)
+	if err := RegisterApis(apis, config.Modules, srv); err != nil {
+		return nil, err
+	}
+	handler := NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret)
+	if config.tokens != nil {
+		handler = newTokenHandler(config.tokens, handler)
+	}
+	return &rpcHandler{Handler: handler, server: srv}, nil
+}
+
 // stopWS disables JSON-RPC over WebSocket and also stops the server if it only serves WebSocket.
 func (h *httpServer) stopWS() {
 	h.mu.Lock()
@@ -356,7 +517,7 @@ func (h *httpServer) disableWS() bool {
 	ws := h.wsHandler.Load().(*rpcHandler)
 	if ws != nil {
 		h.wsHandler.Store((*rpcHandler)(nil))
-		ws.server.Stop()
+		ws.stop()
 	}
 	return ws != nil
 }
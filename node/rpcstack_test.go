@@ -64,6 +64,109 @@ func TestVhosts(t *testing.T) {
 	assert.Equal(t, resp2.StatusCode, http.StatusForbidden)
 }
 
+// TestReloadRPC makes sure CORS origins, vhosts and modules can be changed on a
+// running http server.
+func TestReloadRPC(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{Vhosts: []string{"test"}, Modules: []string{"test"}}, false, &wsConfig{}, nil)
+	defer srv.stop()
+	url := "http://" + srv.listenAddr()
+
+	resp := rpcRequest(t, url, testMethod, "origin", "test.com", "host", "test")
+	assert.Equal(t, "", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.NoError(t, srv.reloadRPC(apis(), []string{"test.com"}, []string{"other"}, []string{"other"}))
+
+	resp = rpcRequest(t, url, testMethod, "origin", "test.com", "host", "test")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = rpcRequest(t, url, "test_greet", "origin", "test.com", "host", "other")
+	assert.Equal(t, "test.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "does not exist")
+
+	srv.disableRPC()
+	assert.Error(t, srv.reloadRPC(apis(), nil, nil, nil))
+}
+
+// TestReloadRPCDrain makes sure a reloaded http handler finishes the requests in
+// flight before its server is stopped.
+func TestReloadRPCDrain(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{Vhosts: []string{"test"}, Modules: []string{"test"}}, false, &wsConfig{}, nil)
+	defer srv.stop()
+	url := "http://" + srv.listenAddr()
+
+	old := srv.httpHandler.Load().(*rpcHandler)
+	done := make(chan *http.Response)
+	go func() {
+		done <- rpcRequest(t, url, "test_sleep", "host", "test")
+	}()
+	waitHandler(t, old, func(h *rpcHandler) bool { return h.active == 1 })
+
+	assert.NoError(t, srv.reloadRPC(apis(), nil, []string{"test"}, []string{"test"}))
+	assert.False(t, handlerStopped(old), "retired handler stopped with a request in flight")
+
+	resp := <-done
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"result":null`)
+
+	waitHandler(t, old, func(h *rpcHandler) bool { return h.stopped })
+}
+
+// TestReloadWS makes sure websocket origins and modules can be changed on a
+// running server without dropping the established connections.
+func TestReloadWS(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{Modules: []string{"test"}}, true, &wsConfig{Origins: []string{"*"}}, nil)
+	defer srv.stop()
+	url := "ws://" + srv.listenAddr()
+
+	client, err := rpc.Dial(url)
+	assert.NoError(t, err)
+	old := srv.wsHandler.Load().(*rpcHandler)
+
+	assert.NoError(t, srv.reloadWS(apis(), []string{"*"}, []string{"test"}))
+
+	// The established connection is served with the previous modules
+	var greeting string
+	assert.Error(t, client.Call(&greeting, "test_greet"))
+	assert.False(t, handlerStopped(old), "retired handler stopped with a connection open")
+
+	// New connections are served with the new modules
+	fresh, err := rpc.Dial(url)
+	assert.NoError(t, err)
+	defer fresh.Close()
+	assert.NoError(t, fresh.Call(&greeting, "test_greet"))
+	assert.Equal(t, "Hello", greeting)
+
+	client.Close()
+	waitHandler(t, old, func(h *rpcHandler) bool { return h.stopped })
+}
+
+// handlerStopped reports whether the server of a handler has been stopped.
+func handlerStopped(h *rpcHandler) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.stopped
+}
+
+// waitHandler waits until the state of a handler satisfies the given condition.
+func waitHandler(t *testing.T, h *rpcHandler, cond func(*rpcHandler) bool) {
+	t.Helper()
+
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		h.lock.Lock()
+		ok := cond(h)
+		h.lock.Unlock()
+		if ok {
+			return
+		}
+	}
+	t.Fatal("timed out waiting for handler state")
+}
+
 type originTest struct {
 	spec    string
 	expOk   []string
@@ -562,7 +665,7 @@ func TestHTTPWriteTimeout(t *testing.T) {
 	// Set-up server
 	timeouts := rpc.DefaultHTTPTimeouts
 	timeouts.WriteTimeout = time.Second
-	srv := createAndStartServer(t, &httpConfig{Modules: []string{"test"}}, false, &wsConfig{}, &timeouts)
+	srv := createAndStartServer(t, &httpConfig{Vhosts: []string{"test"}, Modules: []string{"test"}}, false, &wsConfig{}, &timeouts)
 	url := fmt.Sprintf("http://%v", srv.listenAddr())
 
 	// Send normal request
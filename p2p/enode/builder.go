@@ -0,0 +1,265 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package enode
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Builder assembles signed node records. The zero value is an empty record with
+// sequence number zero. Setter methods return the builder so calls can be
+// chained:
+//
+//	node, err := new(enode.Builder).IP(ip).TCP(30303).UDP(30303).Sign(key)
+type Builder struct {
+	r enr.Record
+}
+
+// NewBuilder creates a builder initialized with all entries of the given record,
+// e.g. to update a record before signing it with an increased sequence number.
+func NewBuilder(r *enr.Record) *Builder {
+	b := new(Builder)
+	if r != nil {
+		b.r = *r
+	}
+	return b
+}
+
+// Seq sets the sequence number of the record.
+func (b *Builder) Seq(seq uint64) *Builder {
+	b.r.SetSeq(seq)
+	return b
+}
+
+// IP sets the "ip" or "ip6" entry, depending on the address family.
+func (b *Builder) IP(ip net.IP) *Builder {
+	b.r.Set(enr.IP(ip))
+	return b
+}
+
+// TCP sets the TCP port of the record.
+func (b *Builder) TCP(port uint16) *Builder {
+	b.r.Set(enr.TCP(port))
+	return b
+}
+
+// UDP sets the UDP port of the record.
+func (b *Builder) UDP(port uint16) *Builder {
+	b.r.Set(enr.UDP(port))
+	return b
+}
+
+// Eth sets the EIP-2124 fork identifier announced by eth protocol nodes.
+func (b *Builder) Eth(forkHash [4]byte, forkNext uint64) *Builder {
+	b.r.Set(enr.Eth{ForkHash: forkHash, ForkNext: forkNext})
+	return b
+}
+
+// Eth2 sets the fork digest and next scheduled fork announced by consensus
+// layer nodes.
+func (b *Builder) Eth2(digest [4]byte, nextVersion [4]byte, nextEpoch uint64) *Builder {
+	b.r.Set(enr.Eth2{ForkDigest: digest, NextForkVersion: nextVersion, NextForkEpoch: nextEpoch})
+	return b
+}
+
+// Set adds an arbitrary entry to the record, replacing any existing entry with
+// the same key.
+func (b *Builder) Set(e enr.Entry) *Builder {
+	b.r.Set(e)
+	return b
+}
+
+// SetValue adds a custom key/value pair to the record. The value must be
+// encodable by package rlp.
+func (b *Builder) SetValue(key string, value interface{}) *Builder {
+	b.r.Set(enr.WithEntry(key, value))
+	return b
+}
+
+// Record returns a copy of the unsigned record assembled so far.
+func (b *Builder) Record() *enr.Record {
+	r := b.r
+	return &r
+}
+
+// Sign signs the record using the "v4" identity scheme and returns it as a node.
+func (b *Builder) Sign(key *ecdsa.PrivateKey) (*Node, error) {
+	r := b.r
+	if err := SignV4(&r, key); err != nil {
+		return nil, err
+	}
+	return New(ValidSchemes, &r)
+}
+
+// ParseRecord decodes a node record from its textual "enr:" representation, hex
+// or raw binary input. The signature of the record is not verified, use New or
+// VerifyRecord to check it.
+func ParseRecord(source string) (*enr.Record, error) {
+	bin := []byte(source)
+	if d, ok := decodeRecordHex(bytes.TrimSpace(bin)); ok {
+		bin = d
+	} else if d, ok := decodeRecordBase64(bytes.TrimSpace(bin)); ok {
+		bin = d
+	}
+	var r enr.Record
+	err := rlp.DecodeBytes(bin, &r)
+	return &r, err
+}
+
+// VerifyRecord parses a node record in any of the formats accepted by ParseRecord
+// and verifies its signature.
+func VerifyRecord(source string) (*Node, error) {
+	r, err := ParseRecord(source)
+	if err != nil {
+		return nil, err
+	}
+	return New(ValidSchemes, r)
+}
+
+func decodeRecordHex(b []byte) ([]byte, bool) {
+	if bytes.HasPrefix(b, []byte("0x")) {
+		b = b[2:]
+	}
+	dec := make([]byte, hex.DecodedLen(len(b)))
+	_, err := hex.Decode(dec, b)
+	return dec, err == nil
+}
+
+func decodeRecordBase64(b []byte) ([]byte, bool) {
+	if bytes.HasPrefix(b, []byte("enr:")) {
+		b = b[4:]
+	}
+	dec := make([]byte, base64.RawURLEncoding.DecodedLen(len(b)))
+	n, err := base64.RawURLEncoding.Decode(dec, b)
+	return dec[:n], err == nil
+}
+
+// FormatRecord creates a human-readable description of the given node record,
+// listing the node identity (if the signature is valid) and all key/value pairs.
+// Values of well-known keys are decoded, others are printed as hex.
+func FormatRecord(r *enr.Record) string {
+	var out strings.Builder
+	n, err := New(ValidSchemes, r)
+	if err != nil {
+		fmt.Fprintf(&out, "INVALID: %v\n", err)
+	} else {
+		fmt.Fprintf(&out, "Node ID: %v\n", n.ID())
+		var key Secp256k1
+		if n.Load(&key) == nil {
+			fmt.Fprintf(&out, "URLv4:   %s\n", n.URLv4())
+		}
+	}
+	kv := r.AppendElements(nil)[1:]
+	fmt.Fprintf(&out, "Record has sequence number %d and %d key/value pairs.\n", r.Seq(), len(kv)/2)
+	out.WriteString(formatRecordKV(kv, 2))
+	return out.String()
+}
+
+func formatRecordKV(kv []interface{}, indent int) string {
+	// Determine the longest key name for alignment.
+	var out string
+	var longestKey = 0
+	for i := 0; i < len(kv); i += 2 {
+		key := kv[i].(string)
+		if len(key) > longestKey {
+			longestKey = len(key)
+		}
+	}
+	// Print the keys, invoking formatters for known keys.
+	for i := 0; i < len(kv); i += 2 {
+		key := kv[i].(string)
+		val := kv[i+1].(rlp.RawValue)
+		pad := longestKey - len(key)
+		out += strings.Repeat(" ", indent) + strconv.Quote(key) + strings.Repeat(" ", pad+1)
+		formatter := attrFormatters[key]
+		if formatter == nil {
+			formatter = formatAttrRaw
+		}
+		fmtval, ok := formatter(val)
+		if ok {
+			out += fmtval + "\n"
+		} else {
+			out += hex.EncodeToString(val) + " (!)\n"
+		}
+	}
+	return out
+}
+
+// attrFormatters contains formatting functions for well-known ENR keys.
+var attrFormatters = map[string]func(rlp.RawValue) (string, bool){
+	"id":   formatAttrString,
+	"ip":   formatAttrIP,
+	"ip6":  formatAttrIP,
+	"tcp":  formatAttrUint,
+	"tcp6": formatAttrUint,
+	"udp":  formatAttrUint,
+	"udp6": formatAttrUint,
+	"eth":  formatAttrEth,
+	"eth2": formatAttrEth2,
+}
+
+func formatAttrRaw(v rlp.RawValue) (string, bool) {
+	s := hex.EncodeToString(v)
+	return s, true
+}
+
+func formatAttrString(v rlp.RawValue) (string, bool) {
+	content, _, err := rlp.SplitString(v)
+	return strconv.Quote(string(content)), err == nil
+}
+
+func formatAttrIP(v rlp.RawValue) (string, bool) {
+	content, _, err := rlp.SplitString(v)
+	if err != nil || len(content) != 4 && len(content) != 16 {
+		return "", false
+	}
+	return net.IP(content).String(), true
+}
+
+func formatAttrUint(v rlp.RawValue) (string, bool) {
+	var x uint64
+	if err := rlp.DecodeBytes(v, &x); err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(x, 10), true
+}
+
+func formatAttrEth(v rlp.RawValue) (string, bool) {
+	var entry enr.Eth
+	if err := rlp.DecodeBytes(v, &entry); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("fork hash %x, next %d", entry.ForkHash, entry.ForkNext), true
+}
+
+func formatAttrEth2(v rlp.RawValue) (string, bool) {
+	var entry enr.Eth2
+	if err := rlp.DecodeBytes(v, &entry); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("fork digest %x, next version %x at epoch %d", entry.ForkDigest, entry.NextForkVersion, entry.NextForkEpoch), true
+}
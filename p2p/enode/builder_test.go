@@ -0,0 +1,98 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package enode
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+func TestBuilder(t *testing.T) {
+	n, err := new(Builder).
+		Seq(5).
+		IP(net.IP{127, 0, 0, 1}).
+		TCP(30303).
+		UDP(30301).
+		Eth([4]byte{0xfc, 0x64, 0xec, 0x04}, 1150000).
+		Eth2([4]byte{0xbb, 0xa4, 0xda, 0x96}, [4]byte{0x02, 0x00, 0x00, 0x00}, 194048).
+		SetValue("custom", []byte{0xca, 0xfe}).
+		Sign(privkey)
+	if err != nil {
+		t.Fatalf("can't sign record: %v", err)
+	}
+	// Round trip the record through its textual representation
+	parsed, err := VerifyRecord(n.String())
+	if err != nil {
+		t.Fatalf("can't verify record: %v", err)
+	}
+	if parsed.ID() != n.ID() || parsed.Seq() != 5 {
+		t.Fatalf("record mismatch: have %v seq %d, want %v seq 5", parsed.ID(), parsed.Seq(), n.ID())
+	}
+	if !parsed.IP().Equal(net.IP{127, 0, 0, 1}) || parsed.TCP() != 30303 || parsed.UDP() != 30301 {
+		t.Errorf("endpoint mismatch: have %v:%d/%d", parsed.IP(), parsed.TCP(), parsed.UDP())
+	}
+	var eth enr.Eth
+	if err := parsed.Load(&eth); err != nil {
+		t.Fatalf("can't load eth entry: %v", err)
+	}
+	if eth != (enr.Eth{ForkHash: [4]byte{0xfc, 0x64, 0xec, 0x04}, ForkNext: 1150000}) {
+		t.Errorf("eth entry mismatch: have %+v", eth)
+	}
+	var eth2 enr.Eth2
+	if err := parsed.Load(&eth2); err != nil {
+		t.Fatalf("can't load eth2 entry: %v", err)
+	}
+	if eth2.NextForkEpoch != 194048 || eth2.NextForkVersion != [4]byte{0x02} {
+		t.Errorf("eth2 entry mismatch: have %+v", eth2)
+	}
+	var custom []byte
+	if err := parsed.Load(enr.WithEntry("custom", &custom)); err != nil || string(custom) != "\xca\xfe" {
+		t.Errorf("custom entry mismatch: have %x (%v)", custom, err)
+	}
+	// Check that the description decodes the well-known keys
+	desc := FormatRecord(parsed.Record())
+	for _, want := range []string{
+		"Node ID: " + n.ID().String(),
+		"sequence number 5 and 8 key/value pairs",
+		`"eth"       fork hash fc64ec04, next 1150000`,
+		`"eth2"      fork digest bba4da96, next version 02000000 at epoch 194048`,
+		`"ip"        127.0.0.1`,
+		`"custom"    82cafe`,
+	} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("description missing %q:\n%s", want, desc)
+		}
+	}
+}
+
+func TestBuilderTamperedRecord(t *testing.T) {
+	n, err := new(Builder).UDP(30303).Sign(privkey)
+	if err != nil {
+		t.Fatalf("can't sign record: %v", err)
+	}
+	// Modify the signed record without re-signing it
+	r := NewBuilder(n.Record()).UDP(30304).Record()
+	if _, err := New(ValidSchemes, r); err == nil {
+		t.Fatal("tampered record verified")
+	}
+	if desc := FormatRecord(r); !strings.HasPrefix(desc, "INVALID: ") {
+		t.Errorf("tampered record not flagged:\n%s", desc)
+	}
+}
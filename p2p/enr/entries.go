@@ -17,6 +17,7 @@
 package enr
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -167,6 +168,77 @@ func (v *IPv6) DecodeRLP(s *rlp.Stream) error {
 	return nil
 }
 
+// Eth is the "eth" key, which holds the EIP-2124 fork identifier of nodes
+// running the eth protocol.
+type Eth struct {
+	ForkHash [4]byte // CRC32 checksum of the genesis block and passed fork block numbers
+	ForkNext uint64  // Block number of the next upcoming fork, or 0 if no forks are known
+}
+
+func (v Eth) ENRKey() string { return "eth" }
+
+// ethEntry is the RLP encoding of the "eth" key. Additional list elements are
+// ignored for forward compatibility.
+type ethEntry struct {
+	Fork struct {
+		Hash [4]byte
+		Next uint64
+	}
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (v Eth) EncodeRLP(w io.Writer) error {
+	var entry ethEntry
+	entry.Fork.Hash, entry.Fork.Next = v.ForkHash, v.ForkNext
+	return rlp.Encode(w, &entry)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (v *Eth) DecodeRLP(s *rlp.Stream) error {
+	var entry ethEntry
+	if err := s.Decode(&entry); err != nil {
+		return err
+	}
+	v.ForkHash, v.ForkNext = entry.Fork.Hash, entry.Fork.Next
+	return nil
+}
+
+// Eth2 is the "eth2" key, which holds the fork digest and the next scheduled
+// fork of consensus layer nodes. It is stored in SSZ encoding as required by
+// the consensus layer p2p specification.
+type Eth2 struct {
+	ForkDigest      [4]byte
+	NextForkVersion [4]byte
+	NextForkEpoch   uint64
+}
+
+func (v Eth2) ENRKey() string { return "eth2" }
+
+// EncodeRLP implements rlp.Encoder.
+func (v Eth2) EncodeRLP(w io.Writer) error {
+	var enc [16]byte
+	copy(enc[:4], v.ForkDigest[:])
+	copy(enc[4:8], v.NextForkVersion[:])
+	binary.LittleEndian.PutUint64(enc[8:], v.NextForkEpoch)
+	return rlp.Encode(w, enc[:])
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (v *Eth2) DecodeRLP(s *rlp.Stream) error {
+	enc, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	if len(enc) != 16 {
+		return fmt.Errorf("invalid eth2 entry, want 16 bytes: %x", enc)
+	}
+	copy(v.ForkDigest[:], enc[:4])
+	copy(v.NextForkVersion[:], enc[4:8])
+	v.NextForkEpoch = binary.LittleEndian.Uint64(enc[8:])
+	return nil
+}
+
 // KeyError is an error related to a key.
 type KeyError struct {
 	Key string
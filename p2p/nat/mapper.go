@@ -0,0 +1,248 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Mapping is the state of a port mapping maintained by a Mapper.
+type Mapping struct {
+	Protocol    string    `json:"protocol"`
+	ExtPort     int       `json:"externalPort"`
+	IntPort     int       `json:"internalPort"`
+	Name        string    `json:"name"`
+	Mapped      bool      `json:"mapped"`          // Whether the last mapping attempt succeeded
+	Expires     time.Time `json:"expires"`         // End of the lease of the mapping, zero if not mapped
+	LastAttempt time.Time `json:"lastAttempt"`     // Time of the last mapping attempt
+	Failures    int       `json:"failures"`        // Number of consecutive failed attempts
+	Error       string    `json:"error,omitempty"` // Error of the last attempt, if it failed
+}
+
+// MappingEvent is posted by a Mapper whenever a mapping attempt fails, and when
+// a mapping is established for the first time or after failures.
+type MappingEvent struct {
+	Mapping Mapping `json:"mapping"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Status is the state of a Mapper.
+type Status struct {
+	Interface  string    `json:"interface"`            // Name of the mapping mechanism
+	ExternalIP net.IP    `json:"externalIP,omitempty"` // Last discovered external IP
+	Error      string    `json:"error,omitempty"`      // Error of the last external IP query, if it failed
+	Mappings   []Mapping `json:"mappings"`             // Port mappings maintained
+}
+
+// mappingKey identifies a mapping.
+type mappingKey struct {
+	protocol string
+	extport  int
+}
+
+// mapping is a port mapping kept alive by the Mapper.
+type mapping struct {
+	state Mapping
+	remap chan struct{} // Signals the maintaining goroutine to refresh the mapping
+}
+
+// Mapper maintains port mappings on a NAT interface, keeping track of their
+// state. It allows inspecting the mappings and forcing them to be refreshed,
+// e.g. after the network configuration changed, and notifies subscribers of
+// mapping failures.
+type Mapper struct {
+	nat  Interface
+	feed event.Feed
+
+	lock     sync.Mutex
+	extIP    net.IP
+	extErr   error
+	mappings map[mappingKey]*mapping
+}
+
+// NewMapper creates a Mapper on top of the NAT interface.
+func NewMapper(nat Interface) *Mapper {
+	return &Mapper{
+		nat:      nat,
+		mappings: make(map[mappingKey]*mapping),
+	}
+}
+
+// Interface returns the NAT interface of the mapper.
+func (m *Mapper) Interface() Interface {
+	return m.nat
+}
+
+// ExternalIP queries the external address of the gateway device, recording it
+// in the status of the mapper.
+func (m *Mapper) ExternalIP() (net.IP, error) {
+	ip, err := m.nat.ExternalIP()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if err == nil {
+		m.extIP = ip
+	}
+	m.extErr = err
+	return ip, err
+}
+
+// Status returns the last discovered external IP and the state of all the port
+// mappings maintained. It does not query the gateway device.
+func (m *Mapper) Status() *Status {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	status := &Status{
+		Interface:  m.nat.String(),
+		ExternalIP: m.extIP,
+		Mappings:   make([]Mapping, 0, len(m.mappings)),
+	}
+	if m.extErr != nil {
+		status.Error = m.extErr.Error()
+	}
+	for _, mapping := range m.mappings {
+		status.Mappings = append(status.Mappings, mapping.state)
+	}
+	sort.Slice(status.Mappings, func(i, j int) bool {
+		a, b := status.Mappings[i], status.Mappings[j]
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.ExtPort < b.ExtPort
+	})
+	return status
+}
+
+// Remap forces all maintained mappings to be refreshed right away, instead of
+// waiting for the next periodic refresh. It does not wait for the refreshes to
+// complete; their outcome is reported through Status and the event feed.
+func (m *Mapper) Remap() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, mapping := range m.mappings {
+		select {
+		case mapping.remap <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents subscribes the given channel to mapping events.
+func (m *Mapper) SubscribeEvents(ch chan<- *MappingEvent) event.Subscription {
+	return m.feed.Subscribe(ch)
+}
+
+// Map adds a port mapping and keeps it alive until c is closed, deleting it
+// afterwards. This function is typically invoked in its own goroutine.
+func (m *Mapper) Map(c <-chan struct{}, protocol string, extport, intport int, name string) {
+	key := mappingKey{protocol, extport}
+	mp := &mapping{
+		state: Mapping{Protocol: protocol, ExtPort: extport, IntPort: intport, Name: name},
+		remap: make(chan struct{}, 1),
+	}
+	m.lock.Lock()
+	m.mappings[key] = mp
+	m.lock.Unlock()
+
+	log := log.New("proto", protocol, "extport", extport, "intport", intport, "interface", m.nat)
+	refresh := time.NewTimer(mapTimeout)
+	defer func() {
+		refresh.Stop()
+		log.Debug("Deleting port mapping")
+		m.nat.DeleteMapping(protocol, extport, intport)
+
+		m.lock.Lock()
+		if m.mappings[key] == mp {
+			delete(m.mappings, key)
+		}
+		m.lock.Unlock()
+	}()
+	if err := m.add(mp); err != nil {
+		log.Debug("Couldn't add port mapping", "err", err)
+	} else {
+		log.Info("Mapped network port")
+	}
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-mp.remap:
+			log.Debug("Remapping port")
+			if err := m.add(mp); err != nil {
+				log.Debug("Couldn't add port mapping", "err", err)
+			}
+			if !refresh.Stop() {
+				select {
+				case <-refresh.C:
+				default:
+				}
+			}
+			refresh.Reset(mapTimeout)
+		case <-refresh.C:
+			log.Trace("Refreshing port mapping")
+			if err := m.add(mp); err != nil {
+				log.Debug("Couldn't add port mapping", "err", err)
+			}
+			refresh.Reset(mapTimeout)
+		}
+	}
+}
+
+// add attempts to establish or refresh a mapping, updating its state and posting
+// an event if the attempt failed or recovered the mapping.
+func (m *Mapper) add(mp *mapping) error {
+	var (
+		state = mp.state
+		now   = time.Now()
+		err   = m.nat.AddMapping(state.Protocol, state.ExtPort, state.IntPort, state.Name, mapTimeout)
+	)
+	m.lock.Lock()
+	wasMapped := mp.state.Mapped
+	mp.state.LastAttempt = now
+	if err != nil {
+		mp.state.Mapped = false
+		mp.state.Expires = time.Time{}
+		mp.state.Failures++
+		mp.state.Error = err.Error()
+	} else {
+		mp.state.Mapped = true
+		mp.state.Expires = now.Add(mapTimeout)
+		mp.state.Failures = 0
+		mp.state.Error = ""
+	}
+	state = mp.state
+	m.lock.Unlock()
+
+	switch {
+	case err != nil:
+		m.feed.Send(&MappingEvent{Mapping: state, Error: err.Error()})
+	case !wasMapped:
+		m.feed.Send(&MappingEvent{Mapping: state})
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyNAT is a NAT interface whose mapping attempts fail on demand.
+type flakyNAT struct {
+	lock    sync.Mutex
+	fail    bool
+	mapped  map[int]bool
+	deleted chan int
+}
+
+func (n *flakyNAT) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.fail {
+		return errors.New("gateway unreachable")
+	}
+	n.mapped[extport] = true
+	return nil
+}
+
+func (n *flakyNAT) DeleteMapping(protocol string, extport, intport int) error {
+	n.lock.Lock()
+	delete(n.mapped, extport)
+	n.lock.Unlock()
+
+	n.deleted <- extport
+	return nil
+}
+
+func (n *flakyNAT) ExternalIP() (net.IP, error) { return net.IP{1, 2, 3, 4}, nil }
+func (n *flakyNAT) String() string              { return "flaky" }
+
+func (n *flakyNAT) setFail(fail bool) {
+	n.lock.Lock()
+	n.fail = fail
+	n.lock.Unlock()
+}
+
+func TestMapper(t *testing.T) {
+	var (
+		gateway = &flakyNAT{fail: true, mapped: make(map[int]bool), deleted: make(chan int, 1)}
+		mapper  = NewMapper(gateway)
+		events  = make(chan *MappingEvent, 10)
+		quit    = make(chan struct{})
+	)
+	sub := mapper.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	go mapper.Map(quit, "tcp", 30303, 30303, "test")
+
+	// The initial mapping attempt fails
+	select {
+	case ev := <-events:
+		if ev.Error == "" || ev.Mapping.Mapped || ev.Mapping.Failures != 1 {
+			t.Fatalf("unexpected event for failed mapping: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event for failed mapping")
+	}
+	status := mapper.Status()
+	if len(status.Mappings) != 1 || status.Mappings[0].Mapped || status.Mappings[0].Error == "" {
+		t.Fatalf("unexpected status after failure: %+v", status)
+	}
+	// Remapping after the gateway recovered establishes the mapping
+	gateway.setFail(false)
+	mapper.Remap()
+
+	select {
+	case ev := <-events:
+		if ev.Error != "" || !ev.Mapping.Mapped || ev.Mapping.Expires.IsZero() {
+			t.Fatalf("unexpected event for recovered mapping: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event for recovered mapping")
+	}
+	if ip, err := mapper.ExternalIP(); err != nil || !ip.Equal(net.IP{1, 2, 3, 4}) {
+		t.Fatalf("external IP mismatch: have %v (%v)", ip, err)
+	}
+	status = mapper.Status()
+	if !status.ExternalIP.Equal(net.IP{1, 2, 3, 4}) || len(status.Mappings) != 1 || !status.Mappings[0].Mapped || status.Mappings[0].Failures != 0 {
+		t.Fatalf("unexpected status after recovery: %+v", status)
+	}
+	// Closing the mapping deletes it
+	close(quit)
+	select {
+	case port := <-gateway.deleted:
+		if port != 30303 {
+			t.Fatalf("deleted port mismatch: have %d, want 30303", port)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("mapping not deleted")
+	}
+}
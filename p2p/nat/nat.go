@@ -25,7 +25,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/log"
 	natpmp "github.com/jackpal/go-nat-pmp"
 )
 
@@ -96,33 +95,10 @@ const (
 
 // Map adds a port mapping on m and keeps it alive until c is closed.
 // This function is typically invoked in its own goroutine.
+//
+// Use a Mapper instead to keep track of the state of the mapping.
 func Map(m Interface, c <-chan struct{}, protocol string, extport, intport int, name string) {
-	log := log.New("proto", protocol, "extport", extport, "intport", intport, "interface", m)
-	refresh := time.NewTimer(mapTimeout)
-	defer func() {
-		refresh.Stop()
-		log.Debug("Deleting port mapping")
-		m.DeleteMapping(protocol, extport, intport)
-	}()
-	if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-		log.Debug("Couldn't add port mapping", "err", err)
-	} else {
-		log.Info("Mapped network port")
-	}
-	for {
-		select {
-		case _, ok := <-c:
-			if !ok {
-				return
-			}
-		case <-refresh.C:
-			log.Trace("Refreshing port mapping")
-			if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-				log.Debug("Couldn't add port mapping", "err", err)
-			}
-			refresh.Reset(mapTimeout)
-		}
-	}
+	NewMapper(m).Map(c, protocol, extport, intport, name)
 }
 
 // ExtIP assumes that the local machine is reachable on the given
@@ -64,7 +64,10 @@ const (
 	frameWriteTimeout = 20 * time.Second
 )
 
-var errServerStopped = errors.New("server stopped")
+var (
+	errServerStopped = errors.New("server stopped")
+	errNoNAT         = errors.New("NAT port mapping not configured")
+)
 
 // Config holds Server options.
 type Config struct {
@@ -188,6 +191,7 @@ type Server struct {
 	DiscV5    *discover.UDPv5
 	discmix   *enode.FairMix
 	dialsched *dialScheduler
+	natMapper *nat.Mapper // Port mappings on the NAT interface, nil if not configured
 
 	// Channels into the run loop.
 	quit                    chan struct{}
@@ -379,6 +383,52 @@ func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
 }
 
+// NATStatus returns the external IP discovered through the NAT interface and the
+// state of the port mappings, or nil if no NAT interface is configured.
+func (srv *Server) NATStatus() *nat.Status {
+	if srv.natMapper == nil {
+		return nil
+	}
+	return srv.natMapper.Status()
+}
+
+// RemapNAT forces the port mappings to be refreshed and the external IP to be
+// queried again, e.g. after the network configuration changed. The refresh is
+// done in the background, its outcome is reported through NATStatus and the
+// NAT events.
+func (srv *Server) RemapNAT() error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return errServerStopped
+	}
+	if srv.natMapper == nil {
+		return errNoNAT
+	}
+	srv.natMapper.Remap()
+	if _, ok := srv.NAT.(nat.ExtIP); !ok {
+		srv.loopWG.Add(1)
+		go func() {
+			defer srv.loopWG.Done()
+			srv.updateExternalIP()
+		}()
+	}
+	return nil
+}
+
+// SubscribeNATEvents subscribes the given channel to port mapping events, posted
+// when mapping attempts fail and when mappings are (re)established.
+func (srv *Server) SubscribeNATEvents(ch chan<- *nat.MappingEvent) event.Subscription {
+	if srv.natMapper == nil {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		})
+	}
+	return srv.natMapper.SubscribeEvents(ch)
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *enode.Node {
 	srv.lock.Lock()
@@ -515,12 +565,15 @@ func (srv *Server) setupLocalNode() error {
 			srv.localnode.Set(e)
 		}
 	}
+	if srv.NAT != nil {
+		srv.natMapper = nat.NewMapper(srv.NAT)
+	}
 	switch srv.NAT.(type) {
 	case nil:
 		// No NAT interface, do nothing.
 	case nat.ExtIP:
 		// ExtIP doesn't block, set the IP right away.
-		ip, _ := srv.NAT.ExternalIP()
+		ip, _ := srv.natMapper.ExternalIP()
 		srv.localnode.SetStaticIP(ip)
 	default:
 		// Ask the router about the IP. This takes a while and blocks startup,
@@ -528,14 +581,22 @@ func (srv *Server) setupLocalNode() error {
 		srv.loopWG.Add(1)
 		go func() {
 			defer srv.loopWG.Done()
-			if ip, err := srv.NAT.ExternalIP(); err == nil {
-				srv.localnode.SetStaticIP(ip)
-			}
+			srv.updateExternalIP()
 		}()
 	}
 	return nil
 }
 
+// updateExternalIP queries the external IP from the NAT interface and announces
+// it in the local node record.
+func (srv *Server) updateExternalIP() {
+	if ip, err := srv.natMapper.ExternalIP(); err == nil {
+		srv.localnode.SetStaticIP(ip)
+	} else {
+		srv.log.Debug("Couldn't query external IP", "interface", srv.NAT, "err", err)
+	}
+}
+
 func (srv *Server) setupDiscovery() error {
 	srv.discmix = enode.NewFairMix(discmixTimeout)
 
@@ -575,7 +636,7 @@ func (srv *Server) setupDiscovery() error {
 		if !realaddr.IP.IsLoopback() {
 			srv.loopWG.Add(1)
 			go func() {
-				nat.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "ethereum discovery")
+				srv.natMapper.Map(srv.quit, "udp", realaddr.Port, realaddr.Port, "ethereum discovery")
 				srv.loopWG.Done()
 			}()
 		}
@@ -682,7 +743,7 @@ func (srv *Server) setupListening() error {
 		if !tcp.IP.IsLoopback() && srv.NAT != nil {
 			srv.loopWG.Add(1)
 			go func() {
-				nat.Map(srv.NAT, srv.quit, "tcp", tcp.Port, tcp.Port, "ethereum p2p")
+				srv.natMapper.Map(srv.quit, "tcp", tcp.Port, tcp.Port, "ethereum p2p")
 				srv.loopWG.Done()
 			}()
 		}
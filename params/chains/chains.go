@@ -0,0 +1,214 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package chains contains a registry of well-known networks and their metadata,
+// such as the native currency, block explorers, public RPC endpoints and the
+// addresses of commonly used canonical contracts.
+package chains
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Names of canonical contracts tracked by the registry.
+const (
+	Multicall3            = "multicall3"
+	WETH                  = "weth"
+	DeterministicDeployer = "deterministic-deployer"
+)
+
+var (
+	// Multicall3Address is the address of the Multicall3 contract, which is
+	// deployed at the same address on most EVM networks.
+	Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+	// DeterministicDeployerAddress is the address of the keyless CREATE2
+	// deployment proxy, which is deployed at the same address on most networks.
+	DeterministicDeployerAddress = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+)
+
+var (
+	errNilChain      = errors.New("nil chain")
+	errAlreadyExists = errors.New("chain already registered")
+)
+
+// Currency describes the native currency of a network.
+type Currency struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// Chain contains the metadata of a single network.
+type Chain struct {
+	ID        uint64                    `json:"chainId"`
+	Name      string                    `json:"name"`
+	Currency  Currency                  `json:"nativeCurrency"`
+	Explorers []string                  `json:"explorers,omitempty"`
+	RPC       []string                  `json:"rpc,omitempty"`
+	Contracts map[string]common.Address `json:"contracts,omitempty"`
+
+	// Config is the consensus configuration of the network, if known to geth.
+	Config *params.ChainConfig `json:"-"`
+}
+
+// Contract returns the address of the named canonical contract on the chain.
+func (c *Chain) Contract(name string) (common.Address, bool) {
+	addr, ok := c.Contracts[name]
+	return addr, ok
+}
+
+// Copy returns a deep copy of the chain metadata. The consensus configuration
+// is shared, as it is treated as immutable.
+func (c *Chain) Copy() *Chain {
+	cpy := *c
+	cpy.Explorers = append([]string(nil), c.Explorers...)
+	cpy.RPC = append([]string(nil), c.RPC...)
+	if c.Contracts != nil {
+		cpy.Contracts = make(map[string]common.Address, len(c.Contracts))
+		for name, addr := range c.Contracts {
+			cpy.Contracts[name] = addr
+		}
+	}
+	return &cpy
+}
+
+var ether = Currency{Name: "Ether", Symbol: "ETH", Decimals: 18}
+
+// Well-known networks.
+var (
+	Mainnet = &Chain{
+		ID:        1,
+		Name:      "mainnet",
+		Currency:  ether,
+		Explorers: []string{"https://etherscan.io"},
+		RPC:       []string{"https://cloudflare-eth.com", "https://ethereum.publicnode.com"},
+		Contracts: map[string]common.Address{
+			Multicall3:            Multicall3Address,
+			WETH:                  common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+			DeterministicDeployer: DeterministicDeployerAddress,
+		},
+		Config: params.MainnetChainConfig,
+	}
+	Sepolia = &Chain{
+		ID:        11155111,
+		Name:      "sepolia",
+		Currency:  Currency{Name: "Sepolia Ether", Symbol: "ETH", Decimals: 18},
+		Explorers: []string{"https://sepolia.etherscan.io"},
+		RPC:       []string{"https://rpc.sepolia.org"},
+		Contracts: map[string]common.Address{
+			Multicall3:            Multicall3Address,
+			WETH:                  common.HexToAddress("0x7b79995e5f793A07Bc00c21412e50Ecae098E7f9"),
+			DeterministicDeployer: DeterministicDeployerAddress,
+		},
+		Config: params.SepoliaChainConfig,
+	}
+	Goerli = &Chain{
+		ID:        5,
+		Name:      "goerli",
+		Currency:  Currency{Name: "Goerli Ether", Symbol: "ETH", Decimals: 18},
+		Explorers: []string{"https://goerli.etherscan.io"},
+		RPC:       []string{"https://ethereum-goerli.publicnode.com"},
+		Contracts: map[string]common.Address{
+			Multicall3:            Multicall3Address,
+			WETH:                  common.HexToAddress("0xB4FBF271143F4FBf7B91A5ded31805e42b2208d6"),
+			DeterministicDeployer: DeterministicDeployerAddress,
+		},
+		Config: params.GoerliChainConfig,
+	}
+	Rinkeby = &Chain{
+		ID:        4,
+		Name:      "rinkeby",
+		Currency:  Currency{Name: "Rinkeby Ether", Symbol: "ETH", Decimals: 18},
+		Explorers: []string{"https://rinkeby.etherscan.io"},
+		Contracts: map[string]common.Address{
+			Multicall3:            Multicall3Address,
+			WETH:                  common.HexToAddress("0xc778417E063141139Fce010982780140Aa0cD5Ab"),
+			DeterministicDeployer: DeterministicDeployerAddress,
+		},
+		Config: params.RinkebyChainConfig,
+	}
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[uint64]*Chain{
+		Mainnet.ID: Mainnet,
+		Sepolia.ID: Sepolia,
+		Goerli.ID:  Goerli,
+		Rinkeby.ID: Rinkeby,
+	}
+)
+
+// Register adds a new chain to the registry, so it can be looked up by other
+// tools at runtime. An error is returned if the chain ID is already taken.
+func Register(chain *Chain) error {
+	if chain == nil {
+		return errNilChain
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[chain.ID]; ok {
+		return errAlreadyExists
+	}
+	registry[chain.ID] = chain.Copy()
+	return nil
+}
+
+// Lookup retrieves a copy of the metadata of the chain with the given ID.
+func Lookup(id uint64) (*Chain, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	chain, ok := registry[id]
+	if !ok {
+		return nil, false
+	}
+	return chain.Copy(), true
+}
+
+// LookupByName retrieves a copy of the metadata of the chain with the given
+// name.
+func LookupByName(name string) (*Chain, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	for _, chain := range registry {
+		if chain.Name == name {
+			return chain.Copy(), true
+		}
+	}
+	return nil, false
+}
+
+// All returns a copy of every registered chain, ordered by chain ID.
+func All() []*Chain {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	chains := make([]*Chain, 0, len(registry))
+	for _, chain := range registry {
+		chains = append(chains, chain.Copy())
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ID < chains[j].ID })
+	return chains
+}
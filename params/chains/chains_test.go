@@ -0,0 +1,62 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that the built-in chains are consistent with the geth chain configs.
+func TestBuiltinChains(t *testing.T) {
+	for _, chain := range All() {
+		if chain.Config == nil {
+			continue
+		}
+		if have := chain.Config.ChainID.Uint64(); have != chain.ID {
+			t.Errorf("%s: chain ID mismatch: have %d, want %d", chain.Name, have, chain.ID)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	custom := &Chain{
+		ID:        1337133713,
+		Name:      "testnet",
+		Contracts: map[string]common.Address{Multicall3: Multicall3Address},
+	}
+	if err := Register(custom); err != nil {
+		t.Fatalf("failed to register chain: %v", err)
+	}
+	if err := Register(custom); err != errAlreadyExists {
+		t.Fatalf("duplicate registration error mismatch: have %v, want %v", err, errAlreadyExists)
+	}
+	// Mutating the original must not affect the registry
+	custom.Contracts[Multicall3] = common.Address{}
+
+	chain, ok := Lookup(custom.ID)
+	if !ok {
+		t.Fatal("registered chain not found")
+	}
+	if addr, _ := chain.Contract(Multicall3); addr != Multicall3Address {
+		t.Errorf("contract address mismatch: have %x, want %x", addr, Multicall3Address)
+	}
+	if _, ok := LookupByName("testnet"); !ok {
+		t.Error("registered chain not found by name")
+	}
+}
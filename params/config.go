@@ -438,6 +438,16 @@ type ChainConfig struct {
 	CancunTime   *uint64 `json:"cancunTime,omitempty"`   // Cancun switch time (nil = no fork, 0 = already on cancun)
 	PragueTime   *uint64 `json:"pragueTime,omitempty"`   // Prague switch time (nil = no fork, 0 = already on prague)
 
+	// EOFTime enables the EVM Object Format (EIPs 3540, 3670, 4200, 4750 and
+	// 5450) at the given timestamp. The fork is experimental and not scheduled
+	// on any network, so it is independent of the fork ordering above.
+	EOFTime *uint64 `json:"eofTime,omitempty"`
+
+	// Upgrades are custom network upgrades overriding protocol parameters, for
+	// private networks to schedule experiments without code changes. They are
+	// independent of the fork ordering above.
+	Upgrades []*Upgrade `json:"upgrades,omitempty"`
+
 	// TerminalTotalDifficulty is the amount of total difficulty reached by
 	// the network that triggers the consensus upgrade.
 	TerminalTotalDifficulty *big.Int `json:"terminalTotalDifficulty,omitempty"`
@@ -557,6 +567,20 @@ func (c *ChainConfig) Description() string {
 	if c.PragueTime != nil {
 		banner += fmt.Sprintf(" - Prague:                      @%-10v\n", *c.PragueTime)
 	}
+	if c.EOFTime != nil {
+		banner += fmt.Sprintf(" - EOF (experimental):          @%-10v\n", *c.EOFTime)
+	}
+	if len(c.Upgrades) > 0 {
+		banner += "\n"
+		banner += "Custom upgrades:\n"
+		for _, u := range c.Upgrades {
+			if u.Block != nil {
+				banner += fmt.Sprintf(" - %-28v #%-8v\n", u.Name+":", u.Block)
+			} else {
+				banner += fmt.Sprintf(" - %-28v @%-10v\n", u.Name+":", *u.Time)
+			}
+		}
+	}
 	return banner
 }
 
@@ -655,6 +679,12 @@ func (c *ChainConfig) IsPrague(time uint64) bool {
 	return isTimestampForked(c.PragueTime, time)
 }
 
+// IsEOF returns whether time is either equal to the experimental EOF fork time
+// or greater.
+func (c *ChainConfig) IsEOF(time uint64) bool {
+	return isTimestampForked(c.EOFTime, time)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64, time uint64) *ConfigCompatError {
@@ -744,7 +774,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
-	return nil
+	return c.checkUpgrades()
 }
 
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
@@ -812,7 +842,10 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.PragueTime, newcfg.PragueTime, headTimestamp) {
 		return newTimestampCompatError("Prague fork timestamp", c.PragueTime, newcfg.PragueTime)
 	}
-	return nil
+	if isForkTimestampIncompatible(c.EOFTime, newcfg.EOFTime, headTimestamp) {
+		return newTimestampCompatError("EOF fork timestamp", c.EOFTime, newcfg.EOFTime)
+	}
+	return c.checkUpgradesCompatible(newcfg, headNumber, headTimestamp)
 }
 
 // BaseFeeChangeDenominator bounds the amount the base fee can change between blocks.
@@ -957,6 +990,10 @@ type Rules struct {
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai, IsCancun, IsPrague                 bool
+	IsEOF                                                   bool
+
+	// Upgrades are the custom upgrades active, in configuration order
+	Upgrades []*Upgrade
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -981,5 +1018,7 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsShanghai:       c.IsShanghai(timestamp),
 		IsCancun:         c.IsCancun(timestamp),
 		IsPrague:         c.IsPrague(timestamp),
+		IsEOF:            c.IsEOF(timestamp),
+		Upgrades:         c.activeUpgrades(num, timestamp),
 	}
 }
@@ -136,3 +136,82 @@ func TestConfigRules(t *testing.T) {
 		t.Errorf("expected %v to be shanghai", stamp)
 	}
 }
+
+func TestCustomUpgrades(t *testing.T) {
+	c := &ChainConfig{
+		Upgrades: []*Upgrade{
+			{Name: "small", Block: big.NewInt(10), TxGasLimit: newUint64(1_000_000), MaxCodeSize: newUint64(1024)},
+			{Name: "large", Time: newUint64(500), MaxCodeSize: newUint64(65536)},
+		},
+	}
+	if err := c.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("valid upgrades rejected: %v", err)
+	}
+	tests := []struct {
+		number      int64
+		time        uint64
+		upgrades    int
+		txGasLimit  uint64
+		maxCodeSize int
+	}{
+		{9, 0, 0, 0, MaxCodeSize},
+		{10, 0, 1, 1_000_000, 1024},
+		{10, 500, 2, 1_000_000, 65536},
+		{0, 500, 1, 0, 65536},
+	}
+	for i, tt := range tests {
+		r := c.Rules(big.NewInt(tt.number), true, tt.time)
+		if len(r.Upgrades) != tt.upgrades {
+			t.Errorf("test %d: active upgrades mismatch: have %d, want %d", i, len(r.Upgrades), tt.upgrades)
+		}
+		if have := r.TxGasLimit(); have != tt.txGasLimit {
+			t.Errorf("test %d: tx gas limit mismatch: have %d, want %d", i, have, tt.txGasLimit)
+		}
+		if have := r.MaxCodeSize(); have != tt.maxCodeSize {
+			t.Errorf("test %d: max code size mismatch: have %d, want %d", i, have, tt.maxCodeSize)
+		}
+	}
+	if !c.IsUpgrade("small", big.NewInt(10), 0) || c.IsUpgrade("large", big.NewInt(10), 0) || c.IsUpgrade("unknown", big.NewInt(10), 500) {
+		t.Errorf("upgrade activation mismatch")
+	}
+}
+
+func TestCustomUpgradesInvalid(t *testing.T) {
+	tests := [][]*Upgrade{
+		{{Block: big.NewInt(1)}},
+		{{Name: "a", Block: big.NewInt(1)}, {Name: "a", Time: newUint64(1)}},
+		{{Name: "a"}},
+		{{Name: "a", Block: big.NewInt(1), Time: newUint64(1)}},
+	}
+	for i, upgrades := range tests {
+		if err := (&ChainConfig{Upgrades: upgrades}).CheckConfigForkOrder(); err == nil {
+			t.Errorf("test %d: invalid upgrades accepted", i)
+		}
+	}
+}
+
+func TestCustomUpgradesCompatible(t *testing.T) {
+	stored := &ChainConfig{Upgrades: []*Upgrade{{Name: "a", Block: big.NewInt(10), TxGasLimit: newUint64(1)}}}
+
+	// Changes to upgrades not yet active are allowed
+	changed := &ChainConfig{Upgrades: []*Upgrade{{Name: "a", Block: big.NewInt(20), TxGasLimit: newUint64(2)}}}
+	if err := stored.CheckCompatible(changed, 5, 0); err != nil {
+		t.Fatalf("pending upgrade change rejected: %v", err)
+	}
+	// Rescheduling active upgrades is not
+	err := stored.CheckCompatible(changed, 15, 0)
+	if err == nil || err.RewindToBlock != 9 {
+		t.Fatalf("active upgrade reschedule mismatch: have %v", err)
+	}
+	// Neither is changing their parameters
+	changed = &ChainConfig{Upgrades: []*Upgrade{{Name: "a", Block: big.NewInt(10), TxGasLimit: newUint64(2)}}}
+	err = stored.CheckCompatible(changed, 15, 0)
+	if err == nil || err.RewindToBlock != 9 {
+		t.Fatalf("active upgrade parameter change mismatch: have %v", err)
+	}
+	// Nor dropping them
+	err = stored.CheckCompatible(&ChainConfig{}, 15, 0)
+	if err == nil || err.RewindToBlock != 9 {
+		t.Fatalf("active upgrade removal mismatch: have %v", err)
+	}
+}
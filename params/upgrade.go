@@ -0,0 +1,204 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Upgrade is a custom network upgrade scheduled by a chain configuration. It is
+// meant for private and research networks experimenting with protocol changes:
+// the upgrade is activated by either a block number or a timestamp, and from
+// then on overrides a set of protocol parameters consumed by the EVM.
+//
+// Multiple upgrades may be active at the same time, in which case they are
+// applied in the order of the configuration, later upgrades overriding the
+// parameters of earlier ones.
+type Upgrade struct {
+	Name  string   `json:"name"`
+	Block *big.Int `json:"block,omitempty"` // Activation block (nil = time based)
+	Time  *uint64  `json:"time,omitempty"`  // Activation timestamp (nil = block based)
+
+	TxGasLimit  *uint64 `json:"txGasLimit,omitempty"`  // Maximum gas a single transaction may use
+	MaxCodeSize *uint64 `json:"maxCodeSize,omitempty"` // Maximum size of deployed contract code
+
+	Precompiles     []common.Address `json:"precompiles,omitempty"`     // Known precompiles to enable, regardless of the hard fork
+	EIPs            []int            `json:"eips,omitempty"`            // EIPs to activate in the instruction set
+	DisabledOpcodes []string         `json:"disabledOpcodes,omitempty"` // Opcodes removed from the instruction set
+}
+
+// active returns whether the upgrade is activated at the given block number and
+// timestamp.
+func (u *Upgrade) active(num *big.Int, time uint64) bool {
+	if u.Block != nil {
+		return isBlockForked(u.Block, num)
+	}
+	return isTimestampForked(u.Time, time)
+}
+
+// overridesEqual returns whether two upgrades override the same parameters.
+func (u *Upgrade) overridesEqual(other *Upgrade) bool {
+	return configTimestampEqual(u.TxGasLimit, other.TxGasLimit) &&
+		configTimestampEqual(u.MaxCodeSize, other.MaxCodeSize) &&
+		reflect.DeepEqual(u.Precompiles, other.Precompiles) &&
+		reflect.DeepEqual(u.EIPs, other.EIPs) &&
+		reflect.DeepEqual(u.DisabledOpcodes, other.DisabledOpcodes)
+}
+
+// IsUpgrade returns whether the custom upgrade with the given name is activated
+// at the given block number and timestamp.
+func (c *ChainConfig) IsUpgrade(name string, num *big.Int, time uint64) bool {
+	for _, u := range c.Upgrades {
+		if u.Name == name {
+			return u.active(num, time)
+		}
+	}
+	return false
+}
+
+// activeUpgrades returns the custom upgrades activated at the given block number
+// and timestamp, in configuration order.
+func (c *ChainConfig) activeUpgrades(num *big.Int, time uint64) []*Upgrade {
+	var active []*Upgrade
+	for _, u := range c.Upgrades {
+		if u.active(num, time) {
+			active = append(active, u)
+		}
+	}
+	return active
+}
+
+// upgradeChecks validate the instruction set changes of custom upgrades. The
+// instruction set is defined by the EVM, which depends on this package, so it
+// installs them via RegisterUpgradeChecks.
+var upgradeChecks struct {
+	validEIP    func(eip int) bool
+	validOpcode func(name string) bool
+}
+
+// RegisterUpgradeChecks sets the functions reporting whether an EIP can be
+// activated and whether an opcode exists, used to reject custom upgrades with
+// unknown instruction set changes when the chain configuration is checked.
+func RegisterUpgradeChecks(validEIP func(eip int) bool, validOpcode func(name string) bool) {
+	upgradeChecks.validEIP = validEIP
+	upgradeChecks.validOpcode = validOpcode
+}
+
+// checkUpgrades verifies that the custom upgrades are uniquely named, are
+// scheduled by exactly one of a block number or a timestamp and only change
+// the instruction set in ways known to the EVM.
+func (c *ChainConfig) checkUpgrades() error {
+	names := make(map[string]bool)
+	for _, u := range c.Upgrades {
+		if u.Name == "" {
+			return fmt.Errorf("unnamed custom upgrade")
+		}
+		if names[u.Name] {
+			return fmt.Errorf("duplicate custom upgrade %q", u.Name)
+		}
+		names[u.Name] = true
+
+		if (u.Block == nil) == (u.Time == nil) {
+			return fmt.Errorf("custom upgrade %q must be scheduled by exactly one of block or time", u.Name)
+		}
+		for _, eip := range u.EIPs {
+			if upgradeChecks.validEIP != nil && !upgradeChecks.validEIP(eip) {
+				return fmt.Errorf("custom upgrade %q activates unknown EIP %d", u.Name, eip)
+			}
+		}
+		for _, name := range u.DisabledOpcodes {
+			if upgradeChecks.validOpcode != nil && !upgradeChecks.validOpcode(name) {
+				return fmt.Errorf("custom upgrade %q disables unknown opcode %q", u.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkUpgradesCompatible checks whether the custom upgrades of the new config
+// can replace the stored ones at the given head. Upgrades already activated may
+// neither be rescheduled nor have their parameters changed.
+func (c *ChainConfig) checkUpgradesCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
+	var (
+		names   []string
+		stored  = make(map[string]*Upgrade)
+		updated = make(map[string]*Upgrade)
+	)
+	for _, u := range c.Upgrades {
+		names = append(names, u.Name)
+		stored[u.Name] = u
+	}
+	for _, u := range newcfg.Upgrades {
+		if stored[u.Name] == nil {
+			names = append(names, u.Name)
+		}
+		updated[u.Name] = u
+	}
+	for _, name := range names {
+		var (
+			stored, updated = stored[name], updated[name]
+			what            = fmt.Sprintf("custom upgrade %q", name)
+		)
+		if stored == nil {
+			stored = new(Upgrade)
+		}
+		if updated == nil {
+			updated = new(Upgrade)
+		}
+		if isForkBlockIncompatible(stored.Block, updated.Block, headNumber) {
+			return newBlockCompatError(what+" block", stored.Block, updated.Block)
+		}
+		if isForkTimestampIncompatible(stored.Time, updated.Time, headTimestamp) {
+			return newTimestampCompatError(what+" timestamp", stored.Time, updated.Time)
+		}
+		if stored.active(headNumber, headTimestamp) && !stored.overridesEqual(updated) {
+			if stored.Block != nil {
+				return newBlockCompatError(what+" parameters", stored.Block, nil)
+			}
+			return newTimestampCompatError(what+" parameters", stored.Time, nil)
+		}
+	}
+	return nil
+}
+
+// TxGasLimit returns the maximum gas a single transaction may use under the
+// active custom upgrades, or zero if unlimited.
+func (r *Rules) TxGasLimit() uint64 {
+	var limit uint64
+	for _, u := range r.Upgrades {
+		if u.TxGasLimit != nil {
+			limit = *u.TxGasLimit
+		}
+	}
+	return limit
+}
+
+// MaxCodeSize returns the maximum size of deployed contract code under the
+// active custom upgrades.
+func (r *Rules) MaxCodeSize() int {
+	size := MaxCodeSize
+	for _, u := range r.Upgrades {
+		if u.MaxCodeSize != nil {
+			size = int(*u.MaxCodeSize)
+		}
+	}
+	return size
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bytediff compares EVM runtime bytecode. Contracts are normalised
+// before being compared, so that compiler metadata and deployment specific
+// constants don't obscure structural similarities, e.g. when looking for
+// modified forks of known contracts or reviewing proxy implementation upgrades.
+package bytediff
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Instruction is a single disassembled EVM instruction.
+type Instruction struct {
+	PC  uint64    // Offset of the instruction in the original bytecode
+	Op  vm.OpCode // Opcode of the instruction
+	Arg []byte    // Immediate argument of PUSH instructions
+}
+
+// String implements fmt.Stringer.
+func (in Instruction) String() string {
+	if len(in.Arg) > 0 {
+		return fmt.Sprintf("%v 0x%x", in.Op, in.Arg)
+	}
+	return in.Op.String()
+}
+
+// key returns the representation of the instruction used for comparisons,
+// which ignores its position in the code.
+func (in Instruction) key() string {
+	return string(append([]byte{byte(in.Op)}, in.Arg...))
+}
+
+// StripMetadata removes the CBOR encoded metadata appended by the Solidity
+// compiler to the runtime bytecode. The metadata is followed by its two byte
+// big endian length and always encodes a small CBOR map. Code without such a
+// trailer is returned unchanged.
+func StripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	size := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - size
+	if size == 0 || start < 0 {
+		return code
+	}
+	// CBOR maps with 1 to 15 entries are encoded as 0xa1-0xaf
+	if code[start] < 0xa1 || code[start] > 0xaf {
+		return code
+	}
+	return code[:start]
+}
+
+// Disassemble splits the bytecode into instructions. A trailing PUSH missing
+// part of its argument, which usually means the tail is data rather than code,
+// is dropped.
+func Disassemble(code []byte) []Instruction {
+	var (
+		instrs []Instruction
+		it     = asm.NewInstructionIterator(code)
+	)
+	for it.Next() {
+		instrs = append(instrs, Instruction{PC: it.PC(), Op: it.Op(), Arg: it.Arg()})
+	}
+	return instrs
+}
+
+// Normalize strips the compiler metadata from the bytecode, disassembles it and
+// canonicalises the PUSH arguments. Arguments are zeroed out, as they mostly hold
+// jump destinations, addresses and other values which change with every
+// recompilation or deployment. PUSH4 arguments are kept if keepSelectors is
+// set, since they usually are function selectors identifying the interface.
+func Normalize(code []byte, keepSelectors bool) []Instruction {
+	instrs := Disassemble(StripMetadata(code))
+	for i, in := range instrs {
+		if len(in.Arg) == 0 || (keepSelectors && in.Op == vm.PUSH4) {
+			continue
+		}
+		instrs[i].Arg = make([]byte, len(in.Arg))
+	}
+	return instrs
+}
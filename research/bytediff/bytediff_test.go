@@ -0,0 +1,166 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bytediff
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestStripMetadata(t *testing.T) {
+	code := common.FromHex("6080604052")
+	meta := common.FromHex("a264697066735822" + strings.Repeat("11", 34) + "64736f6c63430008110033")
+
+	tests := []struct {
+		code []byte
+		want []byte
+	}{
+		{nil, nil},
+		{code, code},
+		{append(append([]byte{}, code...), meta...), code},
+		{meta[len(meta)-2:], meta[len(meta)-2:]}, // length overflowing the code
+	}
+	for i, tt := range tests {
+		if have := StripMetadata(tt.code); !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	// PUSH2 0x1234 PUSH4 0xa9059cbb JUMPI PUSH1 (truncated)
+	code := common.FromHex("611234" + "63a9059cbb" + "57" + "60")
+
+	instrs := Normalize(code, true)
+	if len(instrs) != 3 {
+		t.Fatalf("instruction count mismatch: have %d, want 3", len(instrs))
+	}
+	if !bytes.Equal(instrs[0].Arg, []byte{0, 0}) {
+		t.Errorf("push argument not canonicalised: %x", instrs[0].Arg)
+	}
+	if !bytes.Equal(instrs[1].Arg, common.FromHex("a9059cbb")) {
+		t.Errorf("selector not kept: %x", instrs[1].Arg)
+	}
+	if instrs[2].Op != vm.JUMPI || instrs[2].PC != 8 {
+		t.Errorf("instruction mismatch: have %v at %d, want JUMPI at 8", instrs[2].Op, instrs[2].PC)
+	}
+	if instrs := Normalize(code, false); !bytes.Equal(instrs[1].Arg, make([]byte, 4)) {
+		t.Errorf("selector not canonicalised: %x", instrs[1].Arg)
+	}
+}
+
+// lcs computes the length of the longest common subsequence with the classic
+// quadratic dynamic programming algorithm, as a reference for the diff.
+func lcs(a, b []Instruction) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i].key() == b[j].key():
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] > dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+// Tests that diffs of random instruction lists are valid and minimal.
+func TestDiffRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []Instruction {
+		instrs := make([]Instruction, rng.Intn(40))
+		for i := range instrs {
+			instrs[i] = Instruction{PC: uint64(i), Op: vm.OpCode(rng.Intn(4))}
+		}
+		return instrs
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		edits := Diff(a, b)
+
+		var ra, rb []Instruction
+		var equal int
+		for _, edit := range edits {
+			switch edit.Kind {
+			case Equal:
+				if edit.A.key() != edit.B.key() {
+					t.Fatalf("test %d: mismatching equal edit: %v != %v", i, edit.A, edit.B)
+				}
+				ra, rb = append(ra, *edit.A), append(rb, *edit.B)
+				equal++
+			case Delete:
+				ra = append(ra, *edit.A)
+			case Insert:
+				rb = append(rb, *edit.B)
+			}
+		}
+		if len(ra) != len(a) || len(rb) != len(b) {
+			t.Fatalf("test %d: edits don't cover inputs: have %d/%d, want %d/%d", i, len(ra), len(rb), len(a), len(b))
+		}
+		for j := range ra {
+			if ra[j].PC != a[j].PC {
+				t.Fatalf("test %d: edits out of order", i)
+			}
+		}
+		for j := range rb {
+			if rb[j].PC != b[j].PC {
+				t.Fatalf("test %d: edits out of order", i)
+			}
+		}
+		if want := lcs(a, b); equal != want {
+			t.Fatalf("test %d: diff not minimal: have %d matches, want %d", i, equal, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Same contract deployed with a different constant and an extra check
+	a := common.FromHex("6080604052" + "73" + strings.Repeat("11", 20) + "33" + "14" + "00")
+	b := common.FromHex("6080604052" + "73" + strings.Repeat("22", 20) + "33" + "14" + "15" + "00")
+
+	score, _ := Compare(a, a, false)
+	if score != 1 {
+		t.Errorf("identical code similarity: have %v, want 1", score)
+	}
+	score, edits := Compare(a, b, false)
+	if want := 14.0 / 15.0; score != want {
+		t.Errorf("similarity mismatch: have %v, want %v", score, want)
+	}
+	var out bytes.Buffer
+	if err := Format(&out, edits, 1); err != nil {
+		t.Fatalf("failed to format diff: %v", err)
+	}
+	want := "@@ 5 common instructions @@\n" +
+		"  0001b 0001b  EQ\n" +
+		"+       0001c  ISZERO\n" +
+		"  0001c 0001d  STOP\n"
+	if out.String() != want {
+		t.Errorf("formatted diff mismatch:\nhave:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
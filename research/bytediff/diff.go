@@ -0,0 +1,253 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bytediff
+
+import (
+	"fmt"
+	"io"
+)
+
+// EditKind is the type of a diff operation.
+type EditKind int
+
+const (
+	Equal  EditKind = iota // Instruction present in both contracts
+	Delete                 // Instruction only present in the first contract
+	Insert                 // Instruction only present in the second contract
+)
+
+// Edit is a single instruction level diff operation. For Equal edits, both
+// instructions are set; otherwise only the one of the contract it belongs to.
+type Edit struct {
+	Kind EditKind
+	A, B *Instruction
+}
+
+// Diff computes the shortest edit script transforming the instructions of the
+// first contract into the ones of the second. Instructions are compared by
+// opcode and argument, ignoring their position in the code.
+//
+// The diff is computed with the linear space variant of Myers' algorithm, which
+// runs in O((N+M)D) time for contracts of N and M instructions and D edits.
+func Diff(a, b []Instruction) []Edit {
+	d := &differ{a: keys(a), b: keys(b)}
+	d.compare(0, len(a), 0, len(b))
+
+	var (
+		edits []Edit
+		i, j  int
+	)
+	for _, match := range d.matches {
+		for ; i < match[0]; i++ {
+			edits = append(edits, Edit{Kind: Delete, A: &a[i]})
+		}
+		for ; j < match[1]; j++ {
+			edits = append(edits, Edit{Kind: Insert, B: &b[j]})
+		}
+		edits = append(edits, Edit{Kind: Equal, A: &a[i], B: &b[j]})
+		i, j = i+1, j+1
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, Edit{Kind: Delete, A: &a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Kind: Insert, B: &b[j]})
+	}
+	return edits
+}
+
+// Similarity returns a score in [0, 1] of how similar two instruction lists are,
+// defined as the ratio of matching instructions in the shortest edit script.
+// Identical lists score 1, lists without any common instruction score 0.
+func Similarity(a, b []Instruction) float64 {
+	return score(Diff(a, b), len(a), len(b))
+}
+
+// Compare normalises two runtime bytecodes and returns their similarity along
+// with the instruction level diff between them.
+func Compare(a, b []byte, keepSelectors bool) (float64, []Edit) {
+	na, nb := Normalize(a, keepSelectors), Normalize(b, keepSelectors)
+	edits := Diff(na, nb)
+	return score(edits, len(na), len(nb)), edits
+}
+
+// score returns the ratio of matching instructions in an edit script between
+// lists of n and m instructions.
+func score(edits []Edit, n, m int) float64 {
+	if n+m == 0 {
+		return 1
+	}
+	var equal int
+	for _, edit := range edits {
+		if edit.Kind == Equal {
+			equal++
+		}
+	}
+	return float64(2*equal) / float64(n+m)
+}
+
+// Format writes the diff in a unified diff like format, prefixing removed
+// instructions with '-', added ones with '+' and common ones with a space. Runs
+// of common instructions longer than twice the context are elided.
+func Format(w io.Writer, edits []Edit, context int) error {
+	for i := 0; i < len(edits); i++ {
+		if edits[i].Kind == Equal {
+			// Find the end of the run of common instructions
+			end := i
+			for end < len(edits) && edits[end].Kind == Equal {
+				end++
+			}
+			lead, tail := context, context
+			if i == 0 {
+				lead = 0
+			}
+			if end == len(edits) {
+				tail = 0
+			}
+			if end-i > lead+tail {
+				for k := i; k < i+lead; k++ {
+					if err := formatEdit(w, edits[k]); err != nil {
+						return err
+					}
+				}
+				if _, err := fmt.Fprintf(w, "@@ %d common instructions @@\n", end-i-lead-tail); err != nil {
+					return err
+				}
+				for k := end - tail; k < end; k++ {
+					if err := formatEdit(w, edits[k]); err != nil {
+						return err
+					}
+				}
+				i = end - 1
+				continue
+			}
+		}
+		if err := formatEdit(w, edits[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatEdit(w io.Writer, edit Edit) error {
+	var err error
+	switch edit.Kind {
+	case Equal:
+		_, err = fmt.Fprintf(w, "  %05x %05x  %v\n", edit.A.PC, edit.B.PC, edit.A)
+	case Delete:
+		_, err = fmt.Fprintf(w, "- %05x        %v\n", edit.A.PC, edit.A)
+	case Insert:
+		_, err = fmt.Fprintf(w, "+       %05x  %v\n", edit.B.PC, edit.B)
+	}
+	return err
+}
+
+func keys(instrs []Instruction) []string {
+	keys := make([]string, len(instrs))
+	for i, in := range instrs {
+		keys[i] = in.key()
+	}
+	return keys
+}
+
+// differ finds the longest common subsequence of two key lists, recording the
+// matching index pairs in ascending order.
+type differ struct {
+	a, b    []string
+	matches [][2]int
+}
+
+// compare records the matches between a[aLo:aHi] and b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Strip the common prefix and suffix, they are part of any optimal diff
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.matches = append(d.matches, [2]int{aLo, bLo})
+		aLo, bLo = aLo+1, bLo+1
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	// Split the remainder along the middle snake and recurse into the halves
+	if aLo < aHi && bLo < bHi {
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.matches = append(d.matches, [2]int{x, y})
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+	for i := 0; i < suffix; i++ {
+		d.matches = append(d.matches, [2]int{aHi + i, bHi + i})
+	}
+}
+
+// middleSnake finds the middle snake of an optimal edit path through the given
+// ranges, which must not share a common prefix or suffix. The snake runs from
+// (x, y) to (u, v).
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	var (
+		n, m   = aHi - aLo, bHi - bLo
+		delta  = n - m
+		odd    = delta%2 != 0
+		max    = (n + m + 1) / 2
+		offset = max + 1
+		vf     = make([]int, 2*max+3) // Furthest forward reaching x per diagonal
+		vb     = make([]int, 2*max+3) // Furthest backward reaching x per diagonal
+	)
+	for depth := 0; depth <= max; depth++ {
+		// Extend the forward paths, checking for overlaps with the backward ones
+		for k := -depth; k <= depth; k += 2 {
+			var x int
+			if k == -depth || (k != depth && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x, y = x+1, y+1
+			}
+			vf[offset+k] = x
+			if kr := delta - k; odd && kr >= -(depth-1) && kr <= depth-1 && x+vb[offset+kr] >= n {
+				return aLo + sx, bLo + sy, aLo + x, bLo + y
+			}
+		}
+		// Extend the backward paths, checking for overlaps with the forward ones
+		for k := -depth; k <= depth; k += 2 {
+			var x int
+			if k == -depth || (k != depth && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x, y = x+1, y+1
+			}
+			vb[offset+k] = x
+			if kf := delta - k; !odd && kf >= -depth && kf <= depth && x+vf[offset+kf] >= n {
+				return aHi - x, bHi - y, aHi - sx, bHi - sy
+			}
+		}
+	}
+	panic("bytediff: no middle snake found")
+}
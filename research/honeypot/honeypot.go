@@ -0,0 +1,474 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package honeypot detects tokens which can be bought but not (profitably) sold.
+//
+// The analyzer simulates a buy, a wallet-to-wallet transfer and a sell of the
+// token through its Uniswap V2 compatible WETH pair on a copy of the given state,
+// measuring the taxes taken at every step and probing for common anti-bot
+// restrictions such as blacklisting buyers or limiting the transaction size.
+package honeypot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const contractsABI = `[
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[]},
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getReserves","stateMutability":"view","inputs":[],"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]},
+	{"type":"function","name":"swap","stateMutability":"nonpayable","inputs":[{"name":"amount0Out","type":"uint256"},{"name":"amount1Out","type":"uint256"},{"name":"to","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+var (
+	// contracts is the combined ABI of the ERC-20, WETH and pair methods used.
+	contracts abi.ABI
+
+	// Accounts used to simulate the trades, derived so they hold no state.
+	buyer     = common.BytesToAddress(crypto.Keccak256([]byte("honeypot buyer")))
+	recipient = common.BytesToAddress(crypto.Keccak256([]byte("honeypot recipient")))
+	whale     = common.BytesToAddress(crypto.Keccak256([]byte("honeypot whale")))
+
+	// defaultAmount is the ether amount to buy tokens with if none is configured.
+	defaultAmount = big.NewInt(params.Ether / 10)
+
+	// callGasLimit is the gas allowance of every simulated call.
+	callGasLimit = uint64(10_000_000)
+)
+
+func init() {
+	var err error
+	if contracts, err = abi.JSON(strings.NewReader(contractsABI)); err != nil {
+		panic(err)
+	}
+}
+
+// Config describes the token to analyze.
+type Config struct {
+	Token  common.Address // ERC-20 token to analyze
+	Pair   common.Address // Uniswap V2 compatible pair of the token and WETH
+	WETH   common.Address // Wrapped ether contract
+	Amount *big.Int       // Ether to buy tokens with, defaults to 0.1 ether
+}
+
+// Severity classifies the risk of a finding.
+type Severity string
+
+const (
+	Info   Severity = "info"
+	Medium Severity = "medium"
+	High   Severity = "high"
+)
+
+// Finding is a single suspicious behaviour observed during the simulation.
+type Finding struct {
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+}
+
+// Report is the outcome of the round trip simulation of a token. Taxes are
+// fractions in [0, 1] of the value lost on top of the pool fees.
+type Report struct {
+	Token common.Address `json:"token"`
+	Pair  common.Address `json:"pair"`
+
+	CanBuy      bool `json:"canBuy"`
+	CanTransfer bool `json:"canTransfer"`
+	CanSell     bool `json:"canSell"`
+
+	BuyTax      float64 `json:"buyTax"`
+	TransferTax float64 `json:"transferTax"`
+	SellTax     float64 `json:"sellTax"`
+	RoundTrip   float64 `json:"roundTripLoss"` // Fraction of ether lost buying and selling right away
+
+	BuyGas  uint64 `json:"buyGas"`
+	SellGas uint64 `json:"sellGas"`
+
+	Findings []Finding `json:"findings"`
+	Risk     Severity  `json:"risk"`
+}
+
+func (r *Report) add(severity Severity, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Description: fmt.Sprintf(format, args...)})
+}
+
+// Analyzer simulates token trades on top of a fixed chain state.
+type Analyzer struct {
+	config *params.ChainConfig
+	chain  core.ChainContext
+	header *types.Header
+	state  *state.StateDB
+}
+
+// New creates an analyzer simulating on top of the given block and its state,
+// e.g. the head of a simulated backend or of a local node. The state is never
+// modified, every analysis runs on a copy of it.
+func New(config *params.ChainConfig, chain core.ChainContext, header *types.Header, statedb *state.StateDB) *Analyzer {
+	return &Analyzer{
+		config: config,
+		chain:  chain,
+		header: header,
+		state:  statedb,
+	}
+}
+
+// Analyze simulates the round trip for the configured token and reports the
+// observed taxes and restrictions.
+func (a *Analyzer) Analyze(cfg Config) (*Report, error) {
+	amount := cfg.Amount
+	if amount == nil {
+		amount = defaultAmount
+	}
+	report := &Report{Token: cfg.Token, Pair: cfg.Pair}
+
+	s := a.session(cfg)
+	for _, addr := range []common.Address{cfg.Token, cfg.Pair, cfg.WETH} {
+		if len(s.state.GetCode(addr)) == 0 {
+			return nil, fmt.Errorf("no contract at %x", addr)
+		}
+	}
+	token0, err := s.address(cfg.Pair, "token0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pair: %w", err)
+	}
+	s.tokenIs0 = token0 == cfg.Token
+
+	// Buy tokens with the configured amount of ether
+	bought, gas, err := s.buy(buyer, amount)
+	if err != nil {
+		report.add(High, "buying reverted: %v", err)
+		report.Risk = classify(report)
+		return report, nil
+	}
+	report.CanBuy, report.BuyGas = true, gas
+	report.BuyTax = lossRatio(bought.received, bought.expected)
+	if bought.received.Sign() == 0 {
+		report.add(High, "buying yields no tokens")
+		report.Risk = classify(report)
+		return report, nil
+	}
+
+	// Transfer half of the tokens to a fresh wallet
+	moved := new(big.Int).Div(bought.received, big.NewInt(2))
+	received, _, err := s.transfer(buyer, recipient, moved)
+	if err != nil {
+		report.add(High, "wallet transfers reverted: %v", err)
+	} else {
+		report.CanTransfer = true
+		report.TransferTax = lossRatio(received, moved)
+	}
+	// Sell all remaining tokens of the buyer
+	balance, err := s.balance(cfg.Token, buyer)
+	if err != nil {
+		return nil, err
+	}
+	sold, gas, err := s.sell(buyer, balance)
+	switch {
+	case err == nil:
+		report.CanSell, report.SellGas = true, gas
+		report.SellTax = lossRatio(sold.arrived, balance)
+
+		// Compare the ether returned with the ether spent on the sold tokens
+		spent := new(big.Int).Mul(amount, balance)
+		report.RoundTrip = lossRatio(sold.received, spent.Div(spent, bought.received))
+	case report.CanTransfer:
+		// The buyer can't sell, check whether an account which never bought can
+		if _, _, rerr := s.sell(recipient, received); rerr == nil {
+			report.add(High, "buyers are blacklisted from selling: %v", err)
+		} else {
+			report.add(High, "selling reverted: %v", err)
+		}
+	default:
+		report.add(High, "selling reverted: %v", err)
+	}
+	// Probe whether large buys are rejected, hinting at a max transaction limit
+	if err := a.probeLimit(cfg); err != nil {
+		report.add(Medium, "large buy reverted, likely limited transaction size: %v", err)
+	}
+	for _, tax := range []struct {
+		name string
+		rate float64
+	}{{"buy", report.BuyTax}, {"transfer", report.TransferTax}, {"sell", report.SellTax}} {
+		switch {
+		case tax.rate >= 0.5:
+			report.add(High, "%s tax of %.1f%%", tax.name, tax.rate*100)
+		case tax.rate >= 0.1:
+			report.add(Medium, "%s tax of %.1f%%", tax.name, tax.rate*100)
+		case tax.rate > 0.001:
+			report.add(Info, "%s tax of %.1f%%", tax.name, tax.rate*100)
+		}
+	}
+	report.Risk = classify(report)
+	return report, nil
+}
+
+// probeLimit attempts to buy with a tenth of the pool's WETH liquidity on a
+// fresh copy of the state.
+func (a *Analyzer) probeLimit(cfg Config) error {
+	s := a.session(cfg)
+	token0, err := s.address(cfg.Pair, "token0")
+	if err != nil {
+		return err
+	}
+	s.tokenIs0 = token0 == cfg.Token
+
+	_, wethReserve, err := s.reserves()
+	if err != nil {
+		return err
+	}
+	amount := new(big.Int).Div(wethReserve, big.NewInt(10))
+	if amount.Sign() == 0 {
+		return nil
+	}
+	_, _, err = s.buy(whale, amount)
+	return err
+}
+
+// classify derives the overall risk of a report from its findings.
+func classify(report *Report) Severity {
+	risk := Info
+	for _, finding := range report.Findings {
+		switch finding.Severity {
+		case High:
+			return High
+		case Medium:
+			risk = Medium
+		}
+	}
+	return risk
+}
+
+// lossRatio returns the fraction of the expected amount which was not received.
+func lossRatio(received, expected *big.Int) float64 {
+	if expected.Sign() == 0 {
+		return 0
+	}
+	r, _ := new(big.Float).Quo(new(big.Float).SetInt(received), new(big.Float).SetInt(expected)).Float64()
+	return math.Max(0, 1-r)
+}
+
+// amountOut returns the output of a Uniswap V2 swap, including the 0.3% fee.
+func amountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	in := new(big.Int).Mul(amountIn, big.NewInt(997))
+	num := new(big.Int).Mul(in, reserveOut)
+	den := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(1000)), in)
+	if den.Sign() == 0 {
+		return new(big.Int)
+	}
+	return num.Div(num, den)
+}
+
+// session is a sequence of simulated calls on a private copy of the state.
+type session struct {
+	analyzer *Analyzer
+	cfg      Config
+	state    *state.StateDB
+	tokenIs0 bool
+}
+
+func (a *Analyzer) session(cfg Config) *session {
+	return &session{analyzer: a, cfg: cfg, state: a.state.Copy()}
+}
+
+// call executes a message on the session state, committing its effects.
+func (s *session) call(from, to common.Address, value *big.Int, method string, args ...interface{}) ([]byte, uint64, error) {
+	input, err := contracts.Pack(method, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	msg := &core.Message{
+		From:              from,
+		To:                &to,
+		Nonce:             s.state.GetNonce(from),
+		Value:             value,
+		GasLimit:          callGasLimit,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              input,
+		SkipAccountChecks: true,
+	}
+	var (
+		a        = s.analyzer
+		coinbase = a.header.Coinbase
+		blockCtx = core.NewEVMBlockContext(a.header, a.chain, &coinbase)
+		evm      = vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), s.state, a.config, vm.Config{NoBaseFee: true})
+	)
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, 0, err
+	}
+	s.state.Finalise(true)
+	if result.Failed() {
+		if reason, uerr := abi.UnpackRevert(result.Revert()); uerr == nil {
+			return nil, result.UsedGas, fmt.Errorf("%w: %s", result.Err, reason)
+		}
+		return nil, result.UsedGas, result.Err
+	}
+	return result.ReturnData, result.UsedGas, nil
+}
+
+func (s *session) address(contract common.Address, method string) (common.Address, error) {
+	out, _, err := s.call(buyer, contract, nil, method)
+	if err != nil {
+		return common.Address{}, err
+	}
+	res, err := contracts.Unpack(method, out)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return res[0].(common.Address), nil
+}
+
+func (s *session) balance(token, owner common.Address) (*big.Int, error) {
+	out, _, err := s.call(owner, token, nil, "balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	res, err := contracts.Unpack("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	return res[0].(*big.Int), nil
+}
+
+// reserves returns the token and WETH reserves of the pair.
+func (s *session) reserves() (*big.Int, *big.Int, error) {
+	out, _, err := s.call(buyer, s.cfg.Pair, nil, "getReserves")
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := contracts.Unpack("getReserves", out)
+	if err != nil {
+		return nil, nil, err
+	}
+	r0, r1 := res[0].(*big.Int), res[1].(*big.Int)
+	if s.tokenIs0 {
+		return r0, r1, nil
+	}
+	return r1, r0, nil
+}
+
+// swap requests the given token or WETH amount from the pair.
+func (s *session) swap(from common.Address, out *big.Int, wantToken bool) (uint64, error) {
+	amount0, amount1 := new(big.Int), new(big.Int)
+	if wantToken == s.tokenIs0 {
+		amount0 = out
+	} else {
+		amount1 = out
+	}
+	_, gas, err := s.call(from, s.cfg.Pair, nil, "swap", amount0, amount1, from, []byte{})
+	return gas, err
+}
+
+// transfer moves tokens between accounts, returning the amount received.
+func (s *session) transfer(from, to common.Address, amount *big.Int) (*big.Int, uint64, error) {
+	before, err := s.balance(s.cfg.Token, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, gas, err := s.call(from, s.cfg.Token, nil, "transfer", to, amount)
+	if err != nil {
+		return nil, gas, err
+	}
+	after, err := s.balance(s.cfg.Token, to)
+	if err != nil {
+		return nil, gas, err
+	}
+	return after.Sub(after, before), gas, nil
+}
+
+type trade struct {
+	expected *big.Int // Output expected from the pool reserves
+	arrived  *big.Int // Input which actually arrived at the pool
+	received *big.Int // Output actually received by the trader
+}
+
+// buy swaps ether for tokens through the pair.
+func (s *session) buy(trader common.Address, amount *big.Int) (*trade, uint64, error) {
+	s.state.AddBalance(trader, amount)
+	if _, _, err := s.call(trader, s.cfg.WETH, amount, "deposit"); err != nil {
+		return nil, 0, fmt.Errorf("failed to wrap ether: %w", err)
+	}
+	tokenReserve, wethReserve, err := s.reserves()
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, _, err := s.call(trader, s.cfg.WETH, nil, "transfer", s.cfg.Pair, amount); err != nil {
+		return nil, 0, fmt.Errorf("failed to send WETH: %w", err)
+	}
+	expected := amountOut(amount, wethReserve, tokenReserve)
+	before, err := s.balance(s.cfg.Token, trader)
+	if err != nil {
+		return nil, 0, err
+	}
+	gas, err := s.swap(trader, expected, true)
+	if err != nil {
+		return nil, gas, err
+	}
+	after, err := s.balance(s.cfg.Token, trader)
+	if err != nil {
+		return nil, gas, err
+	}
+	return &trade{expected: expected, arrived: amount, received: after.Sub(after, before)}, gas, nil
+}
+
+// sell swaps tokens for WETH through the pair.
+func (s *session) sell(trader common.Address, amount *big.Int) (*trade, uint64, error) {
+	if amount.Sign() == 0 {
+		return nil, 0, errors.New("no tokens to sell")
+	}
+	tokenReserve, wethReserve, err := s.reserves()
+	if err != nil {
+		return nil, 0, err
+	}
+	arrived, gas, err := s.transfer(trader, s.cfg.Pair, amount)
+	if err != nil {
+		return nil, gas, err
+	}
+	expected := amountOut(arrived, tokenReserve, wethReserve)
+	before, err := s.balance(s.cfg.WETH, trader)
+	if err != nil {
+		return nil, gas, err
+	}
+	swapGas, err := s.swap(trader, expected, false)
+	if err != nil {
+		return nil, gas + swapGas, err
+	}
+	after, err := s.balance(s.cfg.WETH, trader)
+	if err != nil {
+		return nil, gas + swapGas, err
+	}
+	return &trade{expected: expected, arrived: arrived, received: after.Sub(after, before)}, gas + swapGas, nil
+}
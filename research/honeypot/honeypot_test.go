@@ -0,0 +1,94 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package honeypot
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func TestAmountOut(t *testing.T) {
+	tests := []struct {
+		in, reserveIn, reserveOut int64
+		want                      int64
+	}{
+		{0, 1000, 1000, 0},
+		{100, 0, 0, 0},
+		{1000, 1_000_000, 1_000_000, 996},
+		{1_000_000, 1_000_000, 1_000_000, 499_248},
+	}
+	for i, tt := range tests {
+		have := amountOut(big.NewInt(tt.in), big.NewInt(tt.reserveIn), big.NewInt(tt.reserveOut))
+		if have.Int64() != tt.want {
+			t.Errorf("test %d: have %v, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestLossRatio(t *testing.T) {
+	tests := []struct {
+		received, expected int64
+		want               float64
+	}{
+		{100, 100, 0},
+		{90, 100, 0.1},
+		{0, 100, 1},
+		{110, 100, 0},
+		{0, 0, 0},
+	}
+	for i, tt := range tests {
+		if have := lossRatio(big.NewInt(tt.received), big.NewInt(tt.expected)); have < tt.want-1e-9 || have > tt.want+1e-9 {
+			t.Errorf("test %d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	report := new(Report)
+	if risk := classify(report); risk != Info {
+		t.Errorf("empty report risk: have %v, want %v", risk, Info)
+	}
+	report.add(Medium, "sell tax of %.1f%%", 12.0)
+	if risk := classify(report); risk != Medium {
+		t.Errorf("taxed report risk: have %v, want %v", risk, Medium)
+	}
+	report.add(High, "selling reverted")
+	if risk := classify(report); risk != High {
+		t.Errorf("honeypot report risk: have %v, want %v", risk, High)
+	}
+}
+
+// Tests that analyzing accounts without code is rejected instead of reporting
+// bogus results.
+func TestAnalyzeMissingContracts(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, 10_000_000)
+	defer sim.Close()
+
+	chain := sim.Blockchain()
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := New(chain.Config(), chain, chain.CurrentHeader(), statedb)
+	if _, err := analyzer.Analyze(Config{Token: common.Address{1}, Pair: common.Address{2}, WETH: common.Address{3}}); err == nil {
+		t.Fatal("analysis of missing contracts succeeded")
+	}
+}
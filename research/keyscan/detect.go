@@ -0,0 +1,258 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keyscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// minKeyEntropy is the minimum Shannon entropy, in bits per hex digit, of a
+// private key candidate. Random keys have close to 4, while it rules out
+// placeholders like 0x00..01 or repeated patterns.
+const minKeyEntropy = 3.0
+
+// developmentMnemonic is the well known mnemonic of development tooling, whose
+// accounts are public knowledge.
+const developmentMnemonic = "test test test test test test test test test test test junk"
+
+var (
+	hexKeyRegexp = regexp.MustCompile(`\b(?:0x)?([0-9a-fA-F]{64})\b`)
+
+	// keyWords hint at a secret on a line or in a file name.
+	keyWords = []string{"priv", "secret", "key", "pk", "seed", "mnemonic", "wallet", "signer", "account", ".env"}
+
+	// mnemonicLengths are the valid BIP-39 mnemonic word counts, longest first.
+	mnemonicLengths = []int{24, 21, 18, 15, 12}
+
+	wordlist     map[string]bool
+	knownAddrs   map[common.Address]bool
+	wordlistOnce sync.Once
+	knownOnce    sync.Once
+)
+
+// line is a line of text along with its line number.
+type line struct {
+	number int
+	text   string
+}
+
+// splitLines splits content into lines, numbered from the given first one.
+func splitLines(content []byte, first int) []line {
+	texts := strings.Split(string(content), "\n")
+	lines := make([]line, len(texts))
+	for i, text := range texts {
+		lines[i] = line{number: first + i, text: text}
+	}
+	return lines
+}
+
+// detect runs all detectors on the lines of a file. The name is the path of the
+// file within its tree, the source prefixes the reported locations.
+func (sc *scan) detect(source, name string, lines []line) {
+	sc.detectKeys(source, name, lines)
+	sc.detectMnemonics(source, lines)
+	sc.detectKeystore(source, lines)
+}
+
+// detectKeys finds the hex encoded private keys in the lines.
+func (sc *scan) detectKeys(source, name string, lines []line) {
+	fileContext := hasKeyWord(filepath.Base(name))
+	for _, l := range lines {
+		matches := hexKeyRegexp.FindAllStringSubmatch(l.text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		if !sc.config.AllHex && !fileContext && !hasKeyWord(l.text) {
+			continue
+		}
+		for _, match := range matches {
+			hexkey := strings.ToLower(match[1])
+			if entropy(hexkey) < minKeyEntropy {
+				continue
+			}
+			key, err := crypto.HexToECDSA(hexkey)
+			if err != nil {
+				continue
+			}
+			location := fmt.Sprintf("%s:%d", source, l.number)
+			sc.add("key:"+hexkey, PrivateKey, "0x"+hexkey[:4]+"…"+hexkey[60:], location, func() []Account {
+				return []Account{{Address: crypto.PubkeyToAddress(key.PublicKey)}}
+			})
+			crypto.ZeroKey(key)
+		}
+	}
+}
+
+// hasKeyWord reports whether the text contains a word hinting at a secret.
+func hasKeyWord(text string) bool {
+	text = strings.ToLower(text)
+	for _, word := range keyWords {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy returns the Shannon entropy of the string in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / float64(len(s))
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// word is a word of text along with the number of its line.
+type word struct {
+	text string
+	line int
+}
+
+// detectMnemonics finds the BIP-39 mnemonics in the lines. Mnemonics may span
+// lines and be interspersed with numbers and punctuation, e.g. in numbered lists.
+func (sc *scan) detectMnemonics(source string, lines []line) {
+	wordlistOnce.Do(func() {
+		wordlist = make(map[string]bool)
+		for _, w := range bip39.GetWordList() {
+			wordlist[w] = true
+		}
+	})
+	var run []word
+	flush := func() {
+		sc.mnemonicRun(source, run)
+		run = run[:0]
+	}
+	for _, l := range lines {
+		for _, text := range strings.FieldsFunc(l.text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			text = strings.ToLower(text)
+			if !wordlist[text] {
+				flush()
+				continue
+			}
+			run = append(run, word{text: text, line: l.number})
+		}
+	}
+	flush()
+}
+
+// mnemonicRun finds the valid mnemonics in a run of word list words.
+func (sc *scan) mnemonicRun(source string, run []word) {
+	for i := 0; i+mnemonicLengths[len(mnemonicLengths)-1] <= len(run); {
+		found := false
+		for _, n := range mnemonicLengths {
+			if i+n > len(run) {
+				continue
+			}
+			words := make([]string, n)
+			for j := range words {
+				words[j] = run[i+j].text
+			}
+			mnemonic := strings.Join(words, " ")
+			if !bip39.IsMnemonicValid(mnemonic) {
+				continue
+			}
+			location := fmt.Sprintf("%s:%d", source, run[i].line)
+			secret := fmt.Sprintf("%s … %s (%d words)", words[0], words[n-1], n)
+			sc.add("mnemonic:"+mnemonic, Mnemonic, secret, location, func() []Account {
+				return deriveAccounts(mnemonic, sc.config.MnemonicAccounts)
+			})
+			i, found = i+n, true
+			break
+		}
+		if !found {
+			i++
+		}
+	}
+}
+
+// deriveAccounts derives the first n accounts of a mnemonic at the default
+// derivation path.
+func deriveAccounts(mnemonic string, n int) []Account {
+	var (
+		seed = bip39.NewSeed(mnemonic, "")
+		next = accounts.DefaultIterator(accounts.DefaultBaseDerivationPath)
+		accs []Account
+	)
+	for i := 0; i < n; i++ {
+		path := next()
+		key, err := accounts.DeriveKey(seed, path)
+		if err != nil {
+			continue
+		}
+		accs = append(accs, Account{Address: crypto.PubkeyToAddress(key.PublicKey), Path: path.String()})
+		crypto.ZeroKey(key)
+	}
+	return accs
+}
+
+// knownAccount reports whether the address belongs to a well known development
+// account.
+func knownAccount(addr common.Address) bool {
+	knownOnce.Do(func() {
+		knownAddrs = make(map[common.Address]bool)
+		for _, a := range deriveAccounts(developmentMnemonic, 20) {
+			knownAddrs[a.Address] = true
+		}
+	})
+	return knownAddrs[addr]
+}
+
+// detectKeystore reports the lines if they make up a keystore file. Keystores
+// are encrypted, so the account of the file is at stake only if its password
+// is weak or exposed as well.
+func (sc *scan) detectKeystore(source string, lines []line) {
+	if len(lines) == 0 || !strings.HasPrefix(strings.TrimSpace(lines[0].text), "{") {
+		return
+	}
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	var keyfile struct {
+		Address string          `json:"address"`
+		Crypto  json.RawMessage `json:"crypto"`
+	}
+	if err := json.Unmarshal([]byte(strings.Join(texts, "\n")), &keyfile); err != nil {
+		return
+	}
+	if len(keyfile.Crypto) == 0 || !common.IsHexAddress(keyfile.Address) {
+		return
+	}
+	addr := common.HexToAddress(keyfile.Address)
+	location := fmt.Sprintf("%s:%d", source, lines[0].number)
+	sc.add("keystore:"+addr.Hex(), Keystore, "keystore of "+addr.Hex(), location, func() []Account {
+		return []Account{{Address: addr}}
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keyscan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// isRepo reports whether the path is the root of a git repository.
+func isRepo(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+// history scans the lines added by all commits of a git repository, so secrets
+// removed from the working tree are still found. The repository is read with
+// the git command, which must be installed.
+func (sc *scan) history(ctx context.Context, repo string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repo, "log", "--all", "-p", "--no-color", "--no-ext-diff", "--format=commit %H")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run git: %w", err)
+	}
+	if err := sc.parseLog(repo, bufio.NewReader(out)); err != nil {
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to read history of %s: %w", repo, err)
+	}
+	return nil
+}
+
+// parseLog scans the added lines of the patches in a git log, grouped by commit
+// and file.
+func (sc *scan) parseLog(repo string, r *bufio.Reader) error {
+	var (
+		commit string
+		file   string
+		next   int  // Line number of the next added line
+		header bool // Whether the diff header of the file is being read
+		added  []line
+	)
+	flush := func() {
+		if file != "" && len(added) > 0 {
+			source := fmt.Sprintf("%s@%s:%s", repo, commit[:12], file)
+			sc.detect(source, file, added)
+		}
+		added = nil
+	}
+	for {
+		text, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if text == "" && err == io.EOF {
+			break
+		}
+		text = strings.TrimSuffix(text, "\n")
+
+		switch {
+		case strings.HasPrefix(text, "commit ") && len(text) >= len("commit ")+12:
+			flush()
+			commit, file = text[len("commit "):], ""
+			sc.report.Commits++
+		case strings.HasPrefix(text, "diff --git "):
+			flush()
+			file, header = "", true
+		case header && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@ "):
+			// Hunk header: @@ -old,count +new,count @@
+			header = false
+			if fields := strings.Fields(text); len(fields) >= 3 {
+				start := strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)[0]
+				next, _ = strconv.Atoi(start)
+			}
+		case header:
+			// Remaining diff header lines: index, mode and --- lines
+		case strings.HasPrefix(text, "+"):
+			added = append(added, line{number: next, text: text[1:]})
+			next++
+		case strings.HasPrefix(text, " "):
+			next++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package keyscan detects Ethereum secrets exposed in source trees and git
+// repositories.
+//
+// The scanner looks for raw private keys, BIP-39 mnemonics and keystore files,
+// derives the accounts they control and checks their balances and nonces on
+// chain, so the exposures can be triaged by what is actually at stake. Secrets
+// are only ever reported in redacted form.
+//
+// Private keys are recognized as 64 hex digit strings of sufficient entropy in
+// a key related context, e.g. next to "private" or in a .env file. Mnemonics are
+// runs of 12 to 24 words of the BIP-39 English word list with a valid checksum.
+// Keystores are JSON files holding an address and encrypted key material.
+package keyscan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Kind is the format of an exposed secret.
+type Kind string
+
+const (
+	PrivateKey Kind = "private-key"
+	Mnemonic   Kind = "mnemonic"
+	Keystore   Kind = "keystore"
+)
+
+// Severity classifies the urgency of a finding.
+type Severity string
+
+const (
+	Info     Severity = "info"
+	Medium   Severity = "medium"
+	High     Severity = "high"
+	Critical Severity = "critical"
+)
+
+// rank orders severities from the least to the most urgent.
+var rank = map[Severity]int{Info: 0, Medium: 1, High: 2, Critical: 3}
+
+// Backend is the chain access needed to check the accounts of exposed secrets,
+// e.g. an ethclient.Client.
+type Backend interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// Config contains the policies of a scan.
+type Config struct {
+	MnemonicAccounts int      // Accounts derived from each mnemonic, defaults to 5
+	MaxFileSize      int64    // Larger files are skipped, defaults to 1 MiB
+	SkipDirs         []string // Names of directories not to descend into, besides .git and node_modules
+	History          bool     // Also scan the history of git repositories
+	AllHex           bool     // Report 64 hex digit strings regardless of their context
+}
+
+// Account is an account controlled by an exposed secret.
+type Account struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path,omitempty"`    // Derivation path of mnemonic accounts
+	Balance *big.Int       `json:"balance,omitempty"` // Nil if not checked
+	Nonce   uint64         `json:"nonce"`
+}
+
+// Finding is an exposed secret along with the accounts it controls.
+type Finding struct {
+	Kind      Kind      `json:"kind"`
+	Secret    string    `json:"secret"`    // Redacted form of the secret
+	Locations []string  `json:"locations"` // file:line, or commit:file:line in history
+	Accounts  []Account `json:"accounts"`
+	Known     bool      `json:"known"` // Well known development secret
+	Severity  Severity  `json:"severity"`
+	Action    string    `json:"action"` // Recommended remediation
+}
+
+// Report is the outcome of a scan, with findings ordered by severity.
+type Report struct {
+	Files    int        `json:"files"`
+	Commits  int        `json:"commits"`
+	Findings []*Finding `json:"findings"`
+}
+
+// WriteText writes the report as a human readable table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Scanned %d files and %d commits, %d exposed secrets\n\n", r.Files, r.Commits, len(r.Findings))
+	for _, f := range r.Findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", strings.ToUpper(string(f.Severity)), f.Kind, f.Secret)
+		for _, loc := range f.Locations {
+			fmt.Fprintf(tw, "\tfound in\t%s\n", loc)
+		}
+		for _, a := range f.Accounts {
+			balance := "unchecked"
+			if a.Balance != nil {
+				balance = fmt.Sprintf("%v wei, nonce %d", a.Balance, a.Nonce)
+			}
+			fmt.Fprintf(tw, "\t%s\t%s %s\n", a.Path, a.Address, balance)
+		}
+		fmt.Fprintf(tw, "\taction\t%s\n\n", f.Action)
+	}
+	return tw.Flush()
+}
+
+// Scanner searches files and git histories for exposed secrets.
+type Scanner struct {
+	backend Backend
+	config  Config
+}
+
+// New creates a scanner checking the accounts of exposed secrets on the given
+// backend. The backend may be nil to skip the on-chain checks.
+func New(backend Backend, config Config) *Scanner {
+	if config.MnemonicAccounts <= 0 {
+		config.MnemonicAccounts = 5
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 1 << 20
+	}
+	return &Scanner{backend: backend, config: config}
+}
+
+// Scan searches the given files and directories, recursively, for exposed
+// secrets. With History enabled, the full history of the given directories
+// which are git repositories is scanned too, using the git command.
+func (s *Scanner) Scan(ctx context.Context, paths ...string) (*Report, error) {
+	sc := &scan{Scanner: s, report: new(Report), findings: make(map[string]*Finding)}
+	for _, path := range paths {
+		if err := sc.walk(ctx, path); err != nil {
+			return nil, err
+		}
+		if s.config.History && isRepo(path) {
+			if err := sc.history(ctx, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, f := range sc.report.Findings {
+		if err := s.check(ctx, f); err != nil {
+			return nil, err
+		}
+		classify(f)
+	}
+	sort.SliceStable(sc.report.Findings, func(i, j int) bool {
+		return rank[sc.report.Findings[i].Severity] > rank[sc.report.Findings[j].Severity]
+	})
+	return sc.report, nil
+}
+
+// check retrieves the balances and nonces of the accounts of a finding.
+func (s *Scanner) check(ctx context.Context, f *Finding) error {
+	if s.backend == nil {
+		return nil
+	}
+	for i := range f.Accounts {
+		a := &f.Accounts[i]
+		balance, err := s.backend.BalanceAt(ctx, a.Address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to check balance of %s: %w", a.Address, err)
+		}
+		nonce, err := s.backend.NonceAt(ctx, a.Address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to check nonce of %s: %w", a.Address, err)
+		}
+		a.Balance, a.Nonce = balance, nonce
+	}
+	return nil
+}
+
+// classify derives the severity and the recommended action of a finding from
+// the state of its accounts.
+func classify(f *Finding) {
+	var funded, used bool
+	for _, a := range f.Accounts {
+		funded = funded || (a.Balance != nil && a.Balance.Sign() > 0)
+		used = used || a.Nonce > 0
+	}
+	switch {
+	case funded && f.Kind != Keystore:
+		f.Severity = Critical
+		f.Action = "move the funds to a fresh account immediately, e.g. with research/sweeper, then revoke token approvals and retire the secret"
+	case funded || used:
+		f.Severity = High
+		f.Action = "retire the secret: the account is in use and may hold tokens or approvals; remove it from the files and the git history"
+	case f.Known:
+		f.Severity = Info
+		f.Action = "well known development secret, make sure it is never used on a public network"
+	default:
+		f.Severity = Medium
+		f.Action = "remove the secret from the files and the git history, and never fund its accounts"
+	}
+}
+
+// scan is the state of a single scan run.
+type scan struct {
+	*Scanner
+	report   *Report
+	findings map[string]*Finding // Findings by canonical secret, to merge locations
+}
+
+// walk scans a file or the files below a directory.
+func (sc *scan) walk(ctx context.Context, root string) error {
+	skip := map[string]bool{".git": true, "node_modules": true}
+	for _, dir := range sc.config.SkipDirs {
+		skip[dir] = true
+	}
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skip[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > sc.config.MaxFileSize {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || isBinary(content) {
+			return nil
+		}
+		sc.report.Files++
+		sc.detect(path, path, splitLines(content, 1))
+		return nil
+	})
+}
+
+// add records a secret found at the given location, merging it with previous
+// sightings of the same secret.
+func (sc *scan) add(id string, kind Kind, secret, location string, accounts func() []Account) {
+	if f, ok := sc.findings[id]; ok {
+		f.Locations = append(f.Locations, location)
+		return
+	}
+	f := &Finding{Kind: kind, Secret: secret, Locations: []string{location}, Accounts: accounts()}
+	for _, a := range f.Accounts {
+		f.Known = f.Known || knownAccount(a.Address)
+	}
+	sc.findings[id] = f
+	sc.report.Findings = append(sc.report.Findings, f)
+}
+
+// isBinary reports whether the content looks like a binary file.
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return strings.IndexByte(string(content), 0) >= 0
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keyscan
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	testKey      = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	hardhatKey   = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+)
+
+var mnemonicAddr = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+
+// testBackend serves balances and nonces from maps.
+type testBackend struct {
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+}
+
+func (b *testBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if balance, ok := b.balances[account]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+func (b *testBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.nonces[account], nil
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"deploy/config.env": "RPC=http://localhost:8545\nPRIVATE_KEY=0x" + testKey + "\n",
+		"test/accounts.js":  "const key = '" + hardhatKey + "'\n",
+		"notes.md":          "# Wallet\n\n1. " + strings.Replace(testMnemonic, " ", "\n2. ", 1) + "\n",
+		"keys/UTC--x":       `{"address":"f466859ead1932d743d622cb74fc058882e8648a","crypto":{"cipher":"aes-128-ctr"},"version":3}`,
+		"chain.txt":         "parent 0x5e2bd4b6f7a9c8e1d3f0a2b4c6e8f1a3b5d7e9f0c2a4b6d8e0f1a3c5e7b9d1f3\n",
+		"weak.go":           "privKey := \"0x0000000000000000000000000000000000000000000000000000000000000001\"\n",
+		"node_modules/x.js": "const privateKey = '" + testKey + "'\n",
+	})
+	key, _ := crypto.HexToECDSA(testKey)
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+
+	backend := &testBackend{
+		balances: map[common.Address]*big.Int{keyAddr: big.NewInt(1)},
+		nonces:   map[common.Address]uint64{mnemonicAddr: 2},
+	}
+	report, err := New(backend, Config{MnemonicAccounts: 2}).Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if report.Files != 6 {
+		t.Fatalf("wrong number of scanned files: have %d, want 6", report.Files)
+	}
+	if len(report.Findings) != 4 {
+		t.Fatalf("wrong number of findings: have %d, want 4: %+v", len(report.Findings), report.Findings)
+	}
+	tests := []struct {
+		kind     Kind
+		severity Severity
+		location string
+		address  common.Address
+	}{
+		{PrivateKey, Critical, "config.env:2", keyAddr},
+		{Mnemonic, High, "notes.md:3", mnemonicAddr},
+		{Keystore, Medium, "UTC--x:1", common.HexToAddress("0xf466859ead1932d743d622cb74fc058882e8648a")},
+		{PrivateKey, Info, "accounts.js:1", common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")},
+	}
+	for i, test := range tests {
+		f := report.Findings[i]
+		if f.Kind != test.kind || f.Severity != test.severity {
+			t.Errorf("finding %d: have %s %s, want %s %s", i, f.Severity, f.Kind, test.severity, test.kind)
+		}
+		if len(f.Locations) != 1 || !strings.HasSuffix(f.Locations[0], test.location) {
+			t.Errorf("finding %d: wrong locations %v, want %s", i, f.Locations, test.location)
+		}
+		if len(f.Accounts) == 0 || f.Accounts[0].Address != test.address {
+			t.Errorf("finding %d: wrong accounts %+v, want %s", i, f.Accounts, test.address)
+		}
+		if strings.Contains(f.Secret, testKey) || strings.Contains(f.Secret, hardhatKey) || strings.Contains(f.Secret, testMnemonic) {
+			t.Errorf("finding %d: secret not redacted: %s", i, f.Secret)
+		}
+	}
+	if n := len(report.Findings[1].Accounts); n != 2 {
+		t.Errorf("wrong number of mnemonic accounts: have %d, want 2", n)
+	}
+	if !report.Findings[3].Known {
+		t.Error("development key not recognized")
+	}
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	if !strings.Contains(out.String(), "CRITICAL") || strings.Contains(out.String(), testKey) {
+		t.Fatalf("wrong text report:\n%s", out.String())
+	}
+}
+
+func TestScanHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeFiles(t, dir, map[string]string{"README": "hello\n", "secret.env": "# local\nSIGNER_KEY=" + testKey + "\n"})
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	os.Remove(filepath.Join(dir, "secret.env"))
+	git("add", "-A")
+	git("commit", "-q", "-m", "remove secret")
+
+	report, err := New(nil, Config{}).Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Fatalf("found secrets in working tree: %+v", report.Findings)
+	}
+	report, err = New(nil, Config{History: true}).Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("history scan failed: %v", err)
+	}
+	if report.Commits != 2 {
+		t.Fatalf("wrong number of scanned commits: have %d, want 2", report.Commits)
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("wrong number of findings: have %d, want 1", len(report.Findings))
+	}
+	if f := report.Findings[0]; len(f.Locations) != 1 || !strings.HasSuffix(f.Locations[0], ":secret.env:2") || f.Accounts[0].Balance != nil {
+		t.Fatalf("wrong finding: %+v", f)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package slotmine recovers the origin of hashed storage slots. Solidity stores
+// the value of mapping key k of a mapping declared at slot p at keccak256(k . p),
+// nesting further mappings by using that hash as the next slot. Given the slots
+// a contract touches, slotmine searches the combinations of likely mapping slots
+// and keys for the one producing each slot, identifying e.g. which balance or
+// allowance entry a storage write belongs to.
+package slotmine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Config defines the search space of mapping slots and keys.
+type Config struct {
+	Bases     uint64           // Mapping declaration slots searched, 0 up to Bases-1
+	Integers  uint64           // Small integer keys searched, 0 up to Integers-1
+	Addresses []common.Address // Address keys searched, e.g. all accounts of a transaction
+	Keys      []common.Hash    // Further raw 32 byte keys searched
+	Depth     int              // Maximum nesting depth of mappings (1 = plain mapping)
+	Workers   int              // Number of parallel workers (0 = number of CPUs)
+}
+
+// Match identifies the origin of a storage slot.
+type Match struct {
+	Slot common.Hash   // Storage slot searched for
+	Base uint64        // Declaration slot of the outermost mapping
+	Keys []common.Hash // Mapping keys, outermost first
+}
+
+// String implements fmt.Stringer, formatting the match like a Solidity mapping
+// access. Keys are displayed as integers if small and as addresses if they are
+// zero padded to 20 bytes.
+func (m Match) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "slot(%d)", m.Base)
+	for _, key := range m.Keys {
+		n := new(big.Int).SetBytes(key[:])
+		switch {
+		case n.BitLen() <= 64:
+			fmt.Fprintf(&b, "[%d]", n)
+		case n.BitLen() <= 160:
+			fmt.Fprintf(&b, "[%s]", common.BytesToAddress(key[:]).Hex())
+		default:
+			fmt.Fprintf(&b, "[%s]", key.Hex())
+		}
+	}
+	return b.String()
+}
+
+// candidateKeys assembles the mapping keys to search, in their padded form.
+func (cfg *Config) candidateKeys() []common.Hash {
+	keys := make([]common.Hash, 0, cfg.Integers+uint64(len(cfg.Addresses)+len(cfg.Keys)))
+	for i := uint64(0); i < cfg.Integers; i++ {
+		keys = append(keys, common.BigToHash(new(big.Int).SetUint64(i)))
+	}
+	for _, addr := range cfg.Addresses {
+		keys = append(keys, common.BytesToHash(addr[:]))
+	}
+	return append(keys, cfg.Keys...)
+}
+
+// mappingSlots returns the storage slots of the keys of the mapping stored at
+// slot, given the preimages of the slots with the keys already filled in.
+func mappingSlots(preimages [][]byte, slot common.Hash) [][32]byte {
+	for _, preimage := range preimages {
+		copy(preimage[common.HashLength:], slot[:])
+	}
+	return crypto.Keccak256Batch(preimages)
+}
+
+// searchTask is the subtree of the search rooted at a mapping slot.
+type searchTask struct {
+	slot  common.Hash   // Slot of the mapping whose keys are searched
+	base  uint64        // Declaration slot of the outermost mapping
+	keys  []common.Hash // Keys leading from the declaration to slot
+	depth int           // Remaining nesting depth
+}
+
+// Search looks for the mapping slot and keys producing each of the targets. It
+// returns a match for every target found, in no particular order, stopping as
+// soon as all targets are found or the search space is exhausted. The search
+// space grows with Bases * keys^Depth, which quickly becomes prohibitive for
+// depths beyond two.
+func Search(ctx context.Context, targets []common.Hash, cfg Config) ([]Match, error) {
+	if cfg.Depth < 1 {
+		return nil, errors.New("mapping depth must be at least 1")
+	}
+	keys := cfg.candidateKeys()
+	if len(keys) == 0 || cfg.Bases == 0 {
+		return nil, errors.New("empty search space")
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var (
+		lock    sync.Mutex
+		pending = make(map[common.Hash]bool)
+		matches []Match
+		done    = make(chan struct{})
+	)
+	for _, target := range targets {
+		pending[target] = true
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	// Hand out the search subtrees of the top level mapping keys to the workers,
+	// which search all nested mappings below them.
+	tasks := make(chan searchTask)
+	go func() {
+		defer close(tasks)
+		for base := uint64(0); base < cfg.Bases; base++ {
+			select {
+			case tasks <- searchTask{slot: common.BigToHash(new(big.Int).SetUint64(base)), base: base, depth: cfg.Depth}:
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Hash the slots of all keys of a mapping at once, the preimage
+			// buffers being reusable as soon as the hashes are calculated
+			preimages := make([][]byte, len(keys))
+			for i, key := range keys {
+				preimages[i] = make([]byte, 2*common.HashLength)
+				copy(preimages[i], key[:])
+			}
+			var search func(task searchTask) bool
+			search = func(task searchTask) bool {
+				slots := mappingSlots(preimages, task.slot)
+				for i, key := range keys {
+					slot := common.Hash(slots[i])
+					path := append(task.keys[:len(task.keys):len(task.keys)], key)
+
+					lock.Lock()
+					if pending[slot] {
+						delete(pending, slot)
+						matches = append(matches, Match{Slot: slot, Base: task.base, Keys: path})
+						if len(pending) == 0 {
+							close(done)
+						}
+					}
+					finished := len(pending) == 0
+					lock.Unlock()
+
+					if finished || ctx.Err() != nil {
+						return false
+					}
+					if task.depth > 1 {
+						if !search(searchTask{slot: slot, base: task.base, keys: path, depth: task.depth - 1}) {
+							return false
+						}
+					}
+				}
+				return true
+			}
+			for task := range tasks {
+				if !search(task) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return matches, err
+	}
+	return matches, nil
+}
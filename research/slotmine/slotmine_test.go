@@ -0,0 +1,84 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package slotmine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// solidityMapping computes the slot of a nested mapping entry the way solc does.
+func solidityMapping(base uint64, keys ...common.Hash) common.Hash {
+	slot := common.BigToHash(new(big.Int).SetUint64(base))
+	for _, key := range keys {
+		slot = crypto.Keccak256Hash(key[:], slot[:])
+	}
+	return slot
+}
+
+func TestSearch(t *testing.T) {
+	var (
+		owner   = common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+		spender = common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+		other   = common.HexToAddress("0x0000000000000000000000000000000000000abc")
+
+		balance   = solidityMapping(0, common.BytesToHash(owner[:]))
+		allowance = solidityMapping(1, common.BytesToHash(owner[:]), common.BytesToHash(spender[:]))
+		indexed   = solidityMapping(5, common.BigToHash(big.NewInt(7)))
+		unknown   = common.HexToHash("0xdeadbeef")
+	)
+	matches, err := Search(context.Background(), []common.Hash{balance, allowance, indexed, unknown}, Config{
+		Bases:     8,
+		Integers:  16,
+		Addresses: []common.Address{other, owner, spender},
+		Depth:     2,
+		Workers:   4,
+	})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	want := map[common.Hash]string{
+		balance:   "slot(0)[" + owner.Hex() + "]",
+		allowance: "slot(1)[" + owner.Hex() + "][" + spender.Hex() + "]",
+		indexed:   "slot(5)[7]",
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("match count mismatch: have %d, want %d", len(matches), len(want))
+	}
+	for _, match := range matches {
+		if have := match.String(); have != want[match.Slot] {
+			t.Errorf("slot %x: have %s, want %s", match.Slot, have, want[match.Slot])
+		}
+		if slot := solidityMapping(match.Base, match.Keys...); slot != match.Slot {
+			t.Errorf("slot %x: match recomputes to %x", match.Slot, slot)
+		}
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Search(ctx, []common.Hash{{}}, Config{Bases: 1 << 20, Integers: 1 << 10, Depth: 3})
+	if err != context.Canceled {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sweeper
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Relay is a private transaction relay accepting atomic bundles.
+type Relay interface {
+	// SimulateBundle executes the bundle on top of the given state block
+	// without submitting it.
+	SimulateBundle(ctx context.Context, bundle *Bundle, stateBlock uint64) (*SimulationResult, error)
+
+	// SendBundle submits the bundle for inclusion in its target block.
+	SendBundle(ctx context.Context, bundle *Bundle) error
+}
+
+// SimulationResult is the outcome of a bundle simulation.
+type SimulationResult struct {
+	GasUsed uint64               `json:"totalGasUsed"`
+	Results []TxSimulationResult `json:"results"`
+}
+
+// TxSimulationResult is the outcome of a single simulated bundle transaction.
+type TxSimulationResult struct {
+	TxHash  common.Hash `json:"txHash"`
+	GasUsed uint64      `json:"gasUsed"`
+	Error   string      `json:"error,omitempty"`
+	Revert  string      `json:"revert,omitempty"`
+}
+
+// Err returns an error if any transaction of the bundle failed.
+func (r *SimulationResult) Err() error {
+	for _, res := range r.Results {
+		if res.Error != "" || res.Revert != "" {
+			return fmt.Errorf("transaction %x failed: %s %s", res.TxHash, res.Error, res.Revert)
+		}
+	}
+	return nil
+}
+
+// FlashbotsRelay submits bundles to a relay implementing the Flashbots bundle
+// API (eth_callBundle and eth_sendBundle).
+type FlashbotsRelay struct {
+	url    string
+	key    *ecdsa.PrivateKey // Searcher identity used to sign requests
+	client *http.Client
+}
+
+// NewFlashbotsRelay creates a relay client for the given endpoint. The key only
+// authenticates the requests; it does not need to hold any funds.
+func NewFlashbotsRelay(url string, key *ecdsa.PrivateKey) *FlashbotsRelay {
+	return &FlashbotsRelay{url: url, key: key, client: http.DefaultClient}
+}
+
+// SimulateBundle implements Relay.
+func (r *FlashbotsRelay) SimulateBundle(ctx context.Context, bundle *Bundle, stateBlock uint64) (*SimulationResult, error) {
+	txs, err := encodeBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"txs":              txs,
+		"blockNumber":      hexutil.Uint64(bundle.BlockNumber),
+		"stateBlockNumber": hexutil.Uint64(stateBlock),
+	}
+	result := new(SimulationResult)
+	if err := r.call(ctx, result, "eth_callBundle", params); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SendBundle implements Relay.
+func (r *FlashbotsRelay) SendBundle(ctx context.Context, bundle *Bundle) error {
+	txs, err := encodeBundle(bundle)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"txs":         txs,
+		"blockNumber": hexutil.Uint64(bundle.BlockNumber),
+	}
+	return r.call(ctx, nil, "eth_sendBundle", params)
+}
+
+// call sends a single JSON-RPC request to the relay. Requests are authenticated
+// with an X-Flashbots-Signature header, which holds the searcher address and
+// its EIP-191 signature over the hex encoded hash of the request body.
+func (r *FlashbotsRelay) call(ctx context.Context, result interface{}, method string, params interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  []interface{}{params},
+	})
+	if err != nil {
+		return err
+	}
+	digest := hexutil.Encode(crypto.Keccak256(body))
+	sig, err := crypto.Sign(accounts.TextHash([]byte(digest)), r.key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Flashbots-Signature", crypto.PubkeyToAddress(r.key.PublicKey).Hex()+":"+hexutil.Encode(sig))
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("invalid relay response (status %s): %w", res.Status, err)
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("relay error %d: %s", reply.Error.Code, reply.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// encodeBundle returns the hex encoded binary form of the bundle transactions.
+func encodeBundle(bundle *Bundle) ([]hexutil.Bytes, error) {
+	txs := make([]hexutil.Bytes, len(bundle.Transactions))
+	for i, tx := range bundle.Transactions {
+		blob, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		txs[i] = blob
+	}
+	return txs, nil
+}
@@ -0,0 +1,310 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sweeper rescues the assets of an account whose private key has been
+// compromised.
+//
+// Once an attacker holds the key of an account, any ether sent to it for paying
+// gas is usually swept by the attacker's bots before the owner gets a chance to
+// move the remaining tokens. The sweeper avoids the race by packing the gas
+// funding and the rescue transfers into a single atomic bundle, which is
+// submitted to a private relay (e.g. Flashbots) instead of the public mempool.
+package sweeper
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// erc20ABI is the subset of the ERC-20 interface needed to rescue tokens.
+const erc20ABI = `[
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+var (
+	errNothingToSweep = errors.New("nothing to sweep")
+	errNoDestination  = errors.New("no rescue destination configured")
+)
+
+// Backend is the chain access needed by the sweeper to monitor the compromised
+// account and to price the rescue transactions.
+type Backend interface {
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// Config contains the accounts and policies of a rescue operation.
+type Config struct {
+	Compromised *ecdsa.PrivateKey   // Key of the account to rescue assets from
+	Funder      *ecdsa.PrivateKey   // Key of the account paying for the rescue gas
+	Destination common.Address      // Safe account receiving the rescued assets
+	Tokens      []common.Address    // ERC-20 tokens to rescue alongside ether
+	ChainConfig *params.ChainConfig // Chain configuration used to sign and price transactions
+
+	TipCap *big.Int // Priority fee per gas; suggested by the backend if nil
+	DryRun bool     // Only simulate bundles, never submit them
+}
+
+// Bundle is a list of transactions which must be included atomically in the
+// given block.
+type Bundle struct {
+	Transactions []*types.Transaction
+	BlockNumber  uint64
+}
+
+// Sweeper monitors a compromised account and assembles rescue bundles for it.
+type Sweeper struct {
+	backend Backend
+	relay   Relay
+	config  Config
+	erc20   abi.ABI
+}
+
+// New creates a sweeper rescuing assets according to the given config.
+func New(backend Backend, relay Relay, config Config) (*Sweeper, error) {
+	if config.Compromised == nil || config.Funder == nil {
+		return nil, errors.New("both compromised and funder keys are required")
+	}
+	if config.Destination == (common.Address{}) {
+		return nil, errNoDestination
+	}
+	if config.ChainConfig == nil {
+		return nil, errors.New("no chain config")
+	}
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &Sweeper{
+		backend: backend,
+		relay:   relay,
+		config:  config,
+		erc20:   parsed,
+	}, nil
+}
+
+// Build assembles the rescue bundle for the block following the given parent.
+// The bundle starts with a transfer from the funder covering exactly the gas
+// of the rescue transactions not already payable from the compromised account,
+// followed by transfers of all token balances and finally of the remaining
+// ether to the destination.
+//
+// The fee cap of every transaction is pinned to the base fee of the target
+// block plus the tip, so the ether sweep leaves no gas money behind. The bundle
+// is thus only valid for the targeted block, which is what relays expect.
+func (s *Sweeper) Build(ctx context.Context, parent *types.Header) (*Bundle, error) {
+	var (
+		number      = new(big.Int).Add(parent.Number, common.Big1)
+		compromised = crypto.PubkeyToAddress(s.config.Compromised.PublicKey)
+		funder      = crypto.PubkeyToAddress(s.config.Funder.PublicKey)
+		signer      = types.MakeSigner(s.config.ChainConfig, number)
+	)
+	tip := s.config.TipCap
+	if tip == nil {
+		var err error
+		if tip, err = s.backend.SuggestGasTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+	feeCap := new(big.Int).Add(misc.CalcBaseFee(s.config.ChainConfig, parent), tip)
+
+	// Assemble the token transfers and tally up the gas needed to execute them
+	var (
+		calls []ethereum.CallMsg
+		gas   uint64
+	)
+	for _, token := range s.config.Tokens {
+		token := token
+		balance, err := s.tokenBalance(ctx, token, compromised, parent.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve %x balance: %w", token, err)
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		input, err := s.erc20.Pack("transfer", s.config.Destination, balance)
+		if err != nil {
+			return nil, err
+		}
+		// Estimate without fees, the account is likely unable to pay for gas yet
+		call := ethereum.CallMsg{From: compromised, To: &token, Data: input}
+		if call.Gas, err = s.backend.EstimateGas(ctx, call); err != nil {
+			return nil, fmt.Errorf("failed to estimate %x transfer: %w", token, err)
+		}
+		call.GasFeeCap, call.GasTipCap = feeCap, tip
+		calls, gas = append(calls, call), gas+call.Gas
+	}
+	balance, err := s.backend.BalanceAt(ctx, compromised, parent.Number)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		cost  = new(big.Int).Mul(new(big.Int).SetUint64(gas), feeCap)
+		sweep = new(big.Int).Mul(new(big.Int).SetUint64(params.TxGas), feeCap)
+		fund  = new(big.Int)
+	)
+	switch {
+	case balance.Cmp(new(big.Int).Add(cost, sweep)) > 0:
+		// Enough ether to pay for everything and still rescue some of it
+		calls = append(calls, ethereum.CallMsg{
+			From:      compromised,
+			To:        &s.config.Destination,
+			Gas:       params.TxGas,
+			GasFeeCap: feeCap,
+			GasTipCap: tip,
+			Value:     new(big.Int).Sub(balance, new(big.Int).Add(cost, sweep)),
+		})
+	case balance.Cmp(cost) < 0:
+		fund.Sub(cost, balance)
+	}
+	if len(calls) == 0 {
+		return nil, errNothingToSweep
+	}
+	// Sign the funding transaction followed by the rescue transactions
+	bundle := &Bundle{BlockNumber: number.Uint64()}
+	if fund.Sign() > 0 {
+		nonce, err := s.backend.NonceAt(ctx, funder, parent.Number)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := types.SignNewTx(s.config.Funder, signer, &types.DynamicFeeTx{
+			ChainID:   s.config.ChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       params.TxGas,
+			To:        &compromised,
+			Value:     fund,
+		})
+		if err != nil {
+			return nil, err
+		}
+		bundle.Transactions = append(bundle.Transactions, tx)
+	}
+	nonce, err := s.backend.NonceAt(ctx, compromised, parent.Number)
+	if err != nil {
+		return nil, err
+	}
+	for i, call := range calls {
+		tx, err := types.SignNewTx(s.config.Compromised, signer, &types.DynamicFeeTx{
+			ChainID:   s.config.ChainConfig.ChainID,
+			Nonce:     nonce + uint64(i),
+			GasTipCap: call.GasTipCap,
+			GasFeeCap: call.GasFeeCap,
+			Gas:       call.Gas,
+			To:        call.To,
+			Value:     call.Value,
+			Data:      call.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		bundle.Transactions = append(bundle.Transactions, tx)
+	}
+	return bundle, nil
+}
+
+// tokenBalance retrieves the ERC-20 token balance of an account.
+func (s *Sweeper) tokenBalance(ctx context.Context, token, owner common.Address, number *big.Int) (*big.Int, error) {
+	input, err := s.erc20.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.backend.CallContract(ctx, ethereum.CallMsg{To: &token, Data: input}, number)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.erc20.Unpack("balanceOf", output)
+	if err != nil {
+		return nil, err
+	}
+	return result[0].(*big.Int), nil
+}
+
+// Rescue builds the bundle on top of the given parent, simulates it and, unless
+// running in dry-run mode, submits it to the relay. The simulation result is
+// returned either way.
+func (s *Sweeper) Rescue(ctx context.Context, parent *types.Header) (*Bundle, *SimulationResult, error) {
+	bundle, err := s.Build(ctx, parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := s.relay.SimulateBundle(ctx, bundle, parent.Number.Uint64())
+	if err != nil {
+		return bundle, nil, fmt.Errorf("bundle simulation failed: %w", err)
+	}
+	if err := result.Err(); err != nil {
+		return bundle, result, err
+	}
+	if s.config.DryRun {
+		return bundle, result, nil
+	}
+	return bundle, result, s.relay.SendBundle(ctx, bundle)
+}
+
+// Run monitors the chain and submits a fresh rescue bundle for every new block
+// until there is nothing left to sweep or the context is cancelled. In dry-run
+// mode it returns after the first simulated bundle.
+func (s *Sweeper) Run(ctx context.Context) error {
+	heads := make(chan *types.Header, 16)
+	sub, err := s.backend.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-heads:
+			bundle, result, err := s.Rescue(ctx, head)
+			if errors.Is(err, errNothingToSweep) {
+				log.Info("Compromised account swept", "number", head.Number)
+				return nil
+			}
+			if err != nil {
+				log.Warn("Failed to submit rescue bundle", "number", head.Number, "err", err)
+				continue
+			}
+			log.Info("Rescue bundle prepared", "number", bundle.BlockNumber, "txs", len(bundle.Transactions), "gas", result.GasUsed, "dryrun", s.config.DryRun)
+			if s.config.DryRun {
+				return nil
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sweeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// simulatedRelay is a relay which includes bundles into a simulated chain.
+type simulatedRelay struct {
+	backend *backends.SimulatedBackend
+	sent    int
+}
+
+func (r *simulatedRelay) SimulateBundle(ctx context.Context, bundle *Bundle, stateBlock uint64) (*SimulationResult, error) {
+	result := new(SimulationResult)
+	for _, tx := range bundle.Transactions {
+		result.GasUsed += tx.Gas()
+		result.Results = append(result.Results, TxSimulationResult{TxHash: tx.Hash(), GasUsed: tx.Gas()})
+	}
+	return result, nil
+}
+
+func (r *simulatedRelay) SendBundle(ctx context.Context, bundle *Bundle) error {
+	for _, tx := range bundle.Transactions {
+		if err := r.backend.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+	}
+	r.backend.Commit()
+	r.sent++
+	return nil
+}
+
+// Tests that the ether of a compromised account is swept in full, and that the
+// sweeper stops once nothing is left.
+func TestSweepEther(t *testing.T) {
+	var (
+		compromised, _ = crypto.GenerateKey()
+		funder, _      = crypto.GenerateKey()
+		destination    = common.Address{0xde, 0xad}
+		ctx            = context.Background()
+		balance        = big.NewInt(1_000_000_000_000_000_000)
+	)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(compromised.PublicKey): {Balance: balance},
+	}, 10_000_000)
+	defer sim.Close()
+
+	relay := &simulatedRelay{backend: sim}
+	sweeper, err := New(sim, relay, Config{
+		Compromised: compromised,
+		Funder:      funder,
+		Destination: destination,
+		ChainConfig: sim.Blockchain().Config(),
+		TipCap:      big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("failed to create sweeper: %v", err)
+	}
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	bundle, _, err := sweeper.Rescue(ctx, head)
+	if err != nil {
+		t.Fatalf("failed to rescue: %v", err)
+	}
+	if len(bundle.Transactions) != 1 {
+		t.Fatalf("bundle size mismatch: have %d, want 1", len(bundle.Transactions))
+	}
+	if have, _ := sim.BalanceAt(ctx, crypto.PubkeyToAddress(compromised.PublicKey), nil); have.Sign() != 0 {
+		t.Errorf("compromised account not swept: %v left", have)
+	}
+	if have, _ := sim.BalanceAt(ctx, destination, nil); have.Cmp(bundle.Transactions[0].Value()) != 0 {
+		t.Errorf("destination balance mismatch: have %v, want %v", have, bundle.Transactions[0].Value())
+	}
+	head, _ = sim.HeaderByNumber(ctx, nil)
+	if _, err := sweeper.Build(ctx, head); !errors.Is(err, errNothingToSweep) {
+		t.Errorf("swept account error mismatch: have %v, want %v", err, errNothingToSweep)
+	}
+}
+
+// Tests that dry-run mode simulates bundles without sending them.
+func TestSweepDryRun(t *testing.T) {
+	var (
+		compromised, _ = crypto.GenerateKey()
+		funder, _      = crypto.GenerateKey()
+		ctx            = context.Background()
+	)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(compromised.PublicKey): {Balance: big.NewInt(1_000_000_000_000_000_000)},
+	}, 10_000_000)
+	defer sim.Close()
+
+	relay := &simulatedRelay{backend: sim}
+	sweeper, err := New(sim, relay, Config{
+		Compromised: compromised,
+		Funder:      funder,
+		Destination: common.Address{0x01},
+		ChainConfig: sim.Blockchain().Config(),
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create sweeper: %v", err)
+	}
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if _, result, err := sweeper.Rescue(ctx, head); err != nil || result == nil {
+		t.Fatalf("failed to simulate rescue: %v", err)
+	}
+	if relay.sent != 0 {
+		t.Errorf("dry-run sent %d bundles", relay.sent)
+	}
+}
+
+// Tests that requests to a Flashbots relay are signed by the searcher key.
+func TestFlashbotsRelaySignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		parts := strings.Split(r.Header.Get("X-Flashbots-Signature"), ":")
+		if len(parts) != 2 {
+			t.Errorf("malformed signature header: %q", r.Header.Get("X-Flashbots-Signature"))
+			return
+		}
+		sig, err := hexutil.Decode(parts[1])
+		if err != nil {
+			t.Errorf("malformed signature: %v", err)
+			return
+		}
+		digest := hexutil.Encode(crypto.Keccak256(body))
+		pub, err := crypto.SigToPub(accounts.TextHash([]byte(digest)), sig)
+		if err != nil {
+			t.Errorf("failed to recover signer: %v", err)
+			return
+		}
+		if have := crypto.PubkeyToAddress(*pub); have != common.HexToAddress(parts[0]) {
+			t.Errorf("signer mismatch: have %x, want %s", have, parts[0])
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  map[string]interface{}{"totalGasUsed": 21000, "results": []interface{}{map[string]interface{}{"gasUsed": 21000, "revert": "nope"}}},
+		})
+	}))
+	defer server.Close()
+
+	relay := NewFlashbotsRelay(server.URL, key)
+	result, err := relay.SimulateBundle(context.Background(), &Bundle{BlockNumber: 1}, 0)
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if result.GasUsed != 21000 {
+		t.Errorf("gas used mismatch: have %d, want 21000", result.GasUsed)
+	}
+	if result.Err() == nil {
+		t.Error("reverted simulation reported as successful")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txgraph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// asset returns the display name of the asset transferred along an edge.
+func (e *Edge) asset() string {
+	if e.Token == (common.Address{}) {
+		return "ETH"
+	}
+	return e.Token.Hex()
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph txgraph {"); err != nil {
+		return err
+	}
+	for _, node := range g.Nodes() {
+		if _, err := fmt.Fprintf(w, "\t%q;\n", node.Hex()); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.Edges() {
+		label := fmt.Sprintf("%v %s (%d txs)", edge.Value, edge.asset(), len(edge.Txs))
+		if _, err := fmt.Fprintf(w, "\t%q -> %q [label=%q];\n", edge.From.Hex(), edge.To.Hex(), label); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format. Every edge carries the
+// transferred asset, the total value and the number of transactions.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "asset", For: "edge", Name: "asset", Type: "string"},
+			{ID: "value", For: "edge", Name: "value", Type: "string"},
+			{ID: "txs", For: "edge", Name: "txs", Type: "int"},
+		},
+		Graph: graphMLGraph{ID: "txgraph", EdgeDefault: "directed"},
+	}
+	for _, node := range g.Nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: node.Hex()})
+	}
+	for _, edge := range g.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.From.Hex(),
+			Target: edge.To.Hex(),
+			Data: []graphMLData{
+				{Key: "asset", Value: edge.asset()},
+				{Key: "value", Value: edge.Value.String()},
+				{Key: "txs", Value: fmt.Sprint(len(edge.Txs))},
+			},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package txgraph builds directed value-flow graphs between accounts, tracking
+// both ether transfers (including internal calls) and ERC-20 token transfers.
+// It is meant to support investigations following the movement of funds from a
+// set of seed addresses.
+package txgraph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Transfer is a single movement of value between two accounts.
+type Transfer struct {
+	TxHash common.Hash
+	Block  uint64
+	From   common.Address
+	To     common.Address
+	Token  common.Address // Token contract, zero for ether transfers
+	Value  *big.Int
+}
+
+// Source retrieves the value transfers contained in a block.
+type Source interface {
+	Transfers(ctx context.Context, number uint64) ([]Transfer, error)
+}
+
+// Edge aggregates all transfers of a single asset from one account to another.
+type Edge struct {
+	From  common.Address
+	To    common.Address
+	Token common.Address // Token contract, zero for ether transfers
+	Value *big.Int       // Total value transferred
+	Txs   []common.Hash  // Transactions containing the transfers, in order
+}
+
+type edgeKey struct {
+	from, to, token common.Address
+}
+
+// Graph is a directed value-flow graph between accounts.
+type Graph struct {
+	edges map[edgeKey]*Edge
+	out   map[common.Address]map[common.Address]struct{} // Outgoing neighbours
+	in    map[common.Address]map[common.Address]struct{} // Incoming neighbours
+}
+
+// New creates an empty value-flow graph.
+func New() *Graph {
+	return &Graph{
+		edges: make(map[edgeKey]*Edge),
+		out:   make(map[common.Address]map[common.Address]struct{}),
+		in:    make(map[common.Address]map[common.Address]struct{}),
+	}
+}
+
+// Add inserts a transfer into the graph, merging it into the edge of the same
+// sender, recipient and asset. Zero value transfers are ignored.
+func (g *Graph) Add(t Transfer) {
+	if t.Value == nil || t.Value.Sign() <= 0 {
+		return
+	}
+	key := edgeKey{t.From, t.To, t.Token}
+	edge := g.edges[key]
+	if edge == nil {
+		edge = &Edge{From: t.From, To: t.To, Token: t.Token, Value: new(big.Int)}
+		g.edges[key] = edge
+	}
+	edge.Value.Add(edge.Value, t.Value)
+	if n := len(edge.Txs); n == 0 || edge.Txs[n-1] != t.TxHash {
+		edge.Txs = append(edge.Txs, t.TxHash)
+	}
+	link(g.out, t.From, t.To)
+	link(g.in, t.To, t.From)
+}
+
+func link(adj map[common.Address]map[common.Address]struct{}, from, to common.Address) {
+	if adj[from] == nil {
+		adj[from] = make(map[common.Address]struct{})
+	}
+	adj[from][to] = struct{}{}
+}
+
+// Nodes returns all accounts in the graph, sorted.
+func (g *Graph) Nodes() []common.Address {
+	set := make(map[common.Address]struct{})
+	for addr := range g.out {
+		set[addr] = struct{}{}
+	}
+	for addr := range g.in {
+		set[addr] = struct{}{}
+	}
+	return sortedAddresses(set)
+}
+
+// Edges returns all edges in the graph, sorted by sender, recipient and asset.
+func (g *Graph) Edges() []*Edge {
+	edges := make([]*Edge, 0, len(g.edges))
+	for _, edge := range g.edges {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if c := bytes.Compare(edges[i].From[:], edges[j].From[:]); c != 0 {
+			return c < 0
+		}
+		if c := bytes.Compare(edges[i].To[:], edges[j].To[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(edges[i].Token[:], edges[j].Token[:]) < 0
+	})
+	return edges
+}
+
+// ShortestPath returns the shortest chain of accounts through which value flowed
+// from one account to another, following the direction of the transfers. Nil is
+// returned if no such path exists.
+func (g *Graph) ShortestPath(from, to common.Address) []common.Address {
+	if from == to {
+		return []common.Address{from}
+	}
+	prev := map[common.Address]common.Address{from: from}
+	queue := []common.Address{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		// Iterate the neighbours in a deterministic order for stable results
+		for _, next := range sortedAddresses(g.out[node]) {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			prev[next] = node
+			if next == to {
+				path := []common.Address{to}
+				for cur := to; cur != from; {
+					cur = prev[cur]
+					path = append([]common.Address{cur}, path...)
+				}
+				return path
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// Counterparties returns all accounts which sent value to or received value from
+// the given account.
+func (g *Graph) Counterparties(addr common.Address) []common.Address {
+	set := make(map[common.Address]struct{})
+	for peer := range g.out[addr] {
+		set[peer] = struct{}{}
+	}
+	for peer := range g.in[addr] {
+		set[peer] = struct{}{}
+	}
+	delete(set, addr)
+	return sortedAddresses(set)
+}
+
+// CommonCounterparties returns the accounts which exchanged value with both of
+// the given accounts, in either direction.
+func (g *Graph) CommonCounterparties(a, b common.Address) []common.Address {
+	set := make(map[common.Address]struct{})
+	for _, peer := range g.Counterparties(a) {
+		set[peer] = struct{}{}
+	}
+	var shared []common.Address
+	for _, peer := range g.Counterparties(b) {
+		if _, ok := set[peer]; ok {
+			shared = append(shared, peer)
+		}
+	}
+	return shared
+}
+
+// Config defines the scope of a graph built from chain data.
+type Config struct {
+	Seeds []common.Address // Accounts to start following the funds from
+	From  uint64           // First block to include
+	To    uint64           // Last block to include
+	Hops  int              // Number of hops to expand beyond the seeds (0 = direct transfers only)
+}
+
+// Build retrieves all transfers in the configured block range and assembles the
+// graph of transfers reachable from the seed addresses within the configured
+// number of hops, regardless of the transfer direction.
+func Build(ctx context.Context, source Source, config Config) (*Graph, error) {
+	if config.To < config.From {
+		return nil, fmt.Errorf("invalid block range %d-%d", config.From, config.To)
+	}
+	var transfers []Transfer
+	for number := config.From; number <= config.To; number++ {
+		txs, err := source.Transfers(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve transfers of block %d: %w", number, err)
+		}
+		transfers = append(transfers, txs...)
+		if number%1000 == 0 {
+			log.Info("Collecting value transfers", "number", number, "transfers", len(transfers))
+		}
+	}
+	// Expand the set of tracked accounts hop by hop, starting with the seeds
+	tracked := make(map[common.Address]struct{})
+	for _, seed := range config.Seeds {
+		tracked[seed] = struct{}{}
+	}
+	graph := New()
+	for hop := 0; hop <= config.Hops; hop++ {
+		var (
+			found = make(map[common.Address]struct{})
+			rest  = transfers[:0]
+		)
+		for _, t := range transfers {
+			_, from := tracked[t.From]
+			_, to := tracked[t.To]
+			if !from && !to {
+				rest = append(rest, t)
+				continue
+			}
+			graph.Add(t)
+			found[t.From], found[t.To] = struct{}{}, struct{}{}
+		}
+		for addr := range found {
+			tracked[addr] = struct{}{}
+		}
+		transfers = rest
+	}
+	return graph, nil
+}
+
+// sortedAddresses returns the addresses of a set in ascending order.
+func sortedAddresses(set map[common.Address]struct{}) []common.Address {
+	addrs := make([]common.Address, 0, len(set))
+	for addr := range set {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testSource serves transfers from a static per-block list.
+type testSource map[uint64][]Transfer
+
+func (s testSource) Transfers(ctx context.Context, number uint64) ([]Transfer, error) {
+	return s[number], nil
+}
+
+var (
+	thief  = common.Address{0x01}
+	mule1  = common.Address{0x02}
+	mule2  = common.Address{0x03}
+	mixer  = common.Address{0x04}
+	token  = common.Address{0xaa}
+	random = common.Address{0xff}
+)
+
+func newTestSource() testSource {
+	return testSource{
+		1: {
+			{TxHash: common.Hash{0x01}, From: thief, To: mule1, Value: big.NewInt(10)},
+			{TxHash: common.Hash{0x02}, From: thief, To: mule2, Token: token, Value: big.NewInt(500)},
+		},
+		2: {
+			{TxHash: common.Hash{0x03}, From: mule1, To: mixer, Value: big.NewInt(9)},
+			{TxHash: common.Hash{0x04}, From: mule2, To: mixer, Token: token, Value: big.NewInt(500)},
+			{TxHash: common.Hash{0x05}, From: random, To: common.Address{0xfe}, Value: big.NewInt(1)},
+		},
+		3: {
+			{TxHash: common.Hash{0x06}, From: thief, To: mule1, Value: big.NewInt(5)},
+		},
+	}
+}
+
+// Tests that graphs only contain transfers within the configured hop distance
+// from the seeds, and that transfers along an edge are aggregated.
+func TestBuild(t *testing.T) {
+	source := newTestSource()
+
+	graph, err := Build(context.Background(), source, Config{Seeds: []common.Address{thief}, From: 1, To: 3})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	if have, want := len(graph.Edges()), 2; have != want {
+		t.Fatalf("direct edge count mismatch: have %d, want %d", have, want)
+	}
+	edge := graph.Edges()[0]
+	if edge.To != mule1 || edge.Value.Int64() != 15 || len(edge.Txs) != 2 {
+		t.Errorf("edge not aggregated: have %+v", edge)
+	}
+	graph, err = Build(context.Background(), source, Config{Seeds: []common.Address{thief}, From: 1, To: 3, Hops: 1})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	if have, want := graph.Nodes(), []common.Address{thief, mule1, mule2, mixer}; !reflect.DeepEqual(have, want) {
+		t.Errorf("node mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests the graph queries.
+func TestQueries(t *testing.T) {
+	graph, err := Build(context.Background(), newTestSource(), Config{Seeds: []common.Address{thief}, From: 1, To: 3, Hops: 2})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	if have, want := graph.ShortestPath(thief, mixer), []common.Address{thief, mule1, mixer}; !reflect.DeepEqual(have, want) {
+		t.Errorf("path mismatch: have %x, want %x", have, want)
+	}
+	if path := graph.ShortestPath(mixer, thief); path != nil {
+		t.Errorf("path against flow direction: %x", path)
+	}
+	if have, want := graph.CommonCounterparties(mule1, mule2), []common.Address{thief, mixer}; !reflect.DeepEqual(have, want) {
+		t.Errorf("common counterparties mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that graphs can be exported.
+func TestExport(t *testing.T) {
+	graph, err := Build(context.Background(), newTestSource(), Config{Seeds: []common.Address{thief}, From: 1, To: 3})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatalf("failed to export dot: %v", err)
+	}
+	if want := `"` + thief.Hex() + `" -> "` + mule1.Hex() + `" [label="15 ETH (2 txs)"];`; !strings.Contains(dot.String(), want) {
+		t.Errorf("dot edge missing, want %s in:\n%s", want, dot.String())
+	}
+	var ml bytes.Buffer
+	if err := graph.WriteGraphML(&ml); err != nil {
+		t.Fatalf("failed to export graphml: %v", err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal(ml.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid graphml: %v", err)
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Errorf("graphml size mismatch: have %d nodes, %d edges", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txgraph
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// transferTopic is the event signature of ERC-20 (and ERC-721) Transfer events.
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// RPCSource retrieves value transfers from a node over RPC. Ether transfers are
+// collected from call traces, so the node must expose the debug namespace, and
+// token transfers from the Transfer events in the block.
+type RPCSource struct {
+	client *rpc.Client
+	eth    *ethclient.Client
+}
+
+// NewRPCSource creates a transfer source backed by the given RPC client.
+func NewRPCSource(client *rpc.Client) *RPCSource {
+	return &RPCSource{client: client, eth: ethclient.NewClient(client)}
+}
+
+// callFrame is the subset of the callTracer output needed to extract transfers.
+type callFrame struct {
+	Type  string          `json:"type"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Error string          `json:"error"`
+	Calls []callFrame     `json:"calls"`
+}
+
+// Transfers implements Source.
+func (s *RPCSource) Transfers(ctx context.Context, number uint64) ([]Transfer, error) {
+	var block struct {
+		Transactions []common.Hash `json:"transactions"`
+	}
+	if err := s.client.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.Uint64(number), false); err != nil {
+		return nil, err
+	}
+	var traces []struct {
+		Result callFrame `json:"result"`
+		Error  string    `json:"error"`
+	}
+	config := map[string]interface{}{"tracer": "callTracer"}
+	if err := s.client.CallContext(ctx, &traces, "debug_traceBlockByNumber", hexutil.Uint64(number), config); err != nil {
+		return nil, err
+	}
+	if len(traces) != len(block.Transactions) {
+		return nil, fmt.Errorf("trace count mismatch: have %d, want %d", len(traces), len(block.Transactions))
+	}
+	var transfers []Transfer
+	for i, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %x: %s", block.Transactions[i], trace.Error)
+		}
+		transfers = appendCallTransfers(transfers, block.Transactions[i], number, &trace.Result)
+	}
+	// Append the token transfers, logs are only present for successful calls
+	n := new(big.Int).SetUint64(number)
+	logs, err := s.eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: n,
+		ToBlock:   n,
+		Topics:    [][]common.Hash{{transferTopic}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		// ERC-721 transfers share the signature but index the token id
+		if len(log.Topics) != 3 || len(log.Data) != 32 {
+			continue
+		}
+		transfers = append(transfers, Transfer{
+			TxHash: log.TxHash,
+			Block:  number,
+			From:   common.BytesToAddress(log.Topics[1][:]),
+			To:     common.BytesToAddress(log.Topics[2][:]),
+			Token:  log.Address,
+			Value:  new(big.Int).SetBytes(log.Data),
+		})
+	}
+	return transfers, nil
+}
+
+// appendCallTransfers appends the ether transfers of a call frame and all its
+// successful subcalls. Failed frames are skipped along with their subcalls as
+// all their effects are reverted.
+func appendCallTransfers(transfers []Transfer, tx common.Hash, number uint64, frame *callFrame) []Transfer {
+	if frame.Error != "" {
+		return transfers
+	}
+	// Delegate and static calls cannot move ether of their own
+	if frame.Type != "DELEGATECALL" && frame.Type != "STATICCALL" && frame.To != nil && frame.Value != nil && frame.Value.ToInt().Sign() > 0 {
+		transfers = append(transfers, Transfer{
+			TxHash: tx,
+			Block:  number,
+			From:   frame.From,
+			To:     *frame.To,
+			Value:  frame.Value.ToInt(),
+		})
+	}
+	for i := range frame.Calls {
+		transfers = appendCallTransfers(transfers, tx, number, &frame.Calls[i])
+	}
+	return transfers
+}
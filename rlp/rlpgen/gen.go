@@ -440,7 +440,11 @@ func (op ptrOp) genWrite(ctx *genContext, v string) string {
 
 	var b bytes.Buffer
 	fmt.Fprintf(&b, "if %s == nil {\n", v)
-	fmt.Fprintf(&b, "  w.Write([]byte{0x%X})\n", op.nilValue)
+	if op.nilValue == rlpstruct.NilKindList {
+		fmt.Fprintf(&b, "  w.Write(rlp.EmptyList)\n")
+	} else {
+		fmt.Fprintf(&b, "  w.Write(rlp.EmptyString)\n")
+	}
 	fmt.Fprintf(&b, "} else {\n")
 	fmt.Fprintf(&b, "  %s", op.elem.genWrite(ctx, vv))
 	fmt.Fprintf(&b, "}\n")
@@ -484,13 +488,14 @@ type structOp struct {
 	named          *types.Named
 	typ            *types.Struct
 	fields         []*structField
-	optionalFields []*structField
+	optionalFields []*structField // trailing optional fields, including the tail field
 }
 
 type structField struct {
 	name string
 	typ  types.Type
 	elem op
+	tail bool // the elements of a "tail" slice are encoded into the enclosing list
 }
 
 func (bctx *buildContext) makeStructOp(named *types.Named, typ *types.Struct) (op, error) {
@@ -516,18 +521,19 @@ func (bctx *buildContext) makeStructOp(named *types.Named, typ *types.Struct) (o
 	// Create field ops.
 	var op = structOp{named: named, typ: typ}
 	for i, field := range fields {
-		// Advanced struct tags are not supported yet.
 		tag := tags[i]
-		if err := checkUnsupportedTags(field.Name, tag); err != nil {
-			return nil, err
-		}
 		typ := typ.Field(field.Index).Type()
 		elem, err := bctx.makeOp(nil, typ, tags[i])
 		if err != nil {
 			return nil, fmt.Errorf("field %s: %v", field.Name, err)
 		}
-		f := &structField{name: field.Name, typ: typ, elem: elem}
-		if tag.Optional {
+		f := &structField{name: field.Name, typ: typ, elem: elem, tail: tag.Tail}
+		if _, isSlice := elem.(sliceOp); tag.Tail && !isSlice {
+			return nil, fmt.Errorf(`field %s: "tail" tag is not supported on byte slices`, field.Name)
+		}
+		// The tail field behaves like an optional field: preceding optional
+		// fields must be written if it is non-empty.
+		if tag.Optional || tag.Tail {
 			op.optionalFields = append(op.optionalFields, f)
 		} else {
 			op.fields = append(op.fields, f)
@@ -536,13 +542,6 @@ func (bctx *buildContext) makeStructOp(named *types.Named, typ *types.Struct) (o
 	return op, nil
 }
 
-func checkUnsupportedTags(field string, tag rlpstruct.Tags) error {
-	if tag.Tail {
-		return fmt.Errorf(`field %s has unsupported struct tag "tail"`, field)
-	}
-	return nil
-}
-
 func (op structOp) genWrite(ctx *genContext, v string) string {
 	var b bytes.Buffer
 	var listMarker = ctx.temp()
@@ -578,7 +577,11 @@ func (op structOp) writeOptionalFields(b *bytes.Buffer, ctx *genContext, v strin
 			cond += zeroV[j]
 		}
 		fmt.Fprintf(b, "if %s {\n", cond)
-		fmt.Fprint(b, field.elem.genWrite(ctx, selector))
+		if field.tail {
+			fmt.Fprint(b, field.elem.(sliceOp).genWriteElems(ctx, selector))
+		} else {
+			fmt.Fprint(b, field.elem.genWrite(ctx, selector))
+		}
 		fmt.Fprintf(b, "}\n")
 	}
 }
@@ -617,7 +620,12 @@ func (op structOp) genDecode(ctx *genContext) (string, string) {
 func (op structOp) decodeOptionalFields(b *bytes.Buffer, ctx *genContext, resultV string) {
 	var suffix bytes.Buffer
 	for _, field := range op.optionalFields {
-		result, code := field.elem.genDecode(ctx)
+		var result, code string
+		if field.tail {
+			result, code = field.elem.(sliceOp).genDecodeElems(ctx)
+		} else {
+			result, code = field.elem.genDecode(ctx)
+		}
 		fmt.Fprintf(b, "// %s:\n", field.name)
 		fmt.Fprintf(b, "if dec.MoreDataInList() {\n")
 		fmt.Fprint(b, code)
@@ -642,33 +650,50 @@ func (bctx *buildContext) makeSliceOp(typ *types.Slice) (op, error) {
 }
 
 func (op sliceOp) genWrite(ctx *genContext, v string) string {
+	var listMarker = ctx.temp() // holds return value of w.List()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s := w.List()\n", listMarker)
+	fmt.Fprint(&b, op.genWriteElems(ctx, v))
+	fmt.Fprintf(&b, "w.ListEnd(%s)\n", listMarker)
+	return b.String()
+}
+
+// genWriteElems writes the slice elements without the enclosing list.
+func (op sliceOp) genWriteElems(ctx *genContext, v string) string {
 	var (
-		listMarker = ctx.temp() // holds return value of w.List()
-		iterElemV  = ctx.temp() // iteration variable
-		elemCode   = op.elemOp.genWrite(ctx, iterElemV)
+		iterElemV = ctx.temp() // iteration variable
+		elemCode  = op.elemOp.genWrite(ctx, iterElemV)
 	)
 
 	var b bytes.Buffer
-	fmt.Fprintf(&b, "%s := w.List()\n", listMarker)
 	fmt.Fprintf(&b, "for _, %s := range %s {\n", iterElemV, v)
 	fmt.Fprint(&b, elemCode)
 	fmt.Fprintf(&b, "}\n")
-	fmt.Fprintf(&b, "w.ListEnd(%s)\n", listMarker)
 	return b.String()
 }
 
 func (op sliceOp) genDecode(ctx *genContext) (string, string) {
+	sliceV, elemsCode := op.genDecodeElems(ctx)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "if _, err := dec.List(); err != nil { return err }\n")
+	fmt.Fprint(&b, elemsCode)
+	fmt.Fprintf(&b, "if err := dec.ListEnd(); err != nil { return err }\n")
+	return sliceV, b.String()
+}
+
+// genDecodeElems decodes elements until the end of the current list.
+func (op sliceOp) genDecodeElems(ctx *genContext) (string, string) {
 	var sliceV = ctx.temp() // holds the output slice
 	elemResult, elemCode := op.elemOp.genDecode(ctx)
 
 	var b bytes.Buffer
 	fmt.Fprintf(&b, "var %s %s\n", sliceV, types.TypeString(op.typ, ctx.qualify))
-	fmt.Fprintf(&b, "if _, err := dec.List(); err != nil { return err }\n")
 	fmt.Fprintf(&b, "for dec.MoreDataInList() {\n")
 	fmt.Fprintf(&b, "  %s", elemCode)
 	fmt.Fprintf(&b, "  %s = append(%s, %s)\n", sliceV, sliceV, elemResult)
 	fmt.Fprintf(&b, "}\n")
-	fmt.Fprintf(&b, "if err := dec.ListEnd(); err != nil { return err }\n")
 	return sliceV, b.String()
 }
 
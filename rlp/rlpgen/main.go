@@ -14,6 +14,22 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
+// Command rlpgen generates reflection-free RLP encoders and decoders for Go
+// struct types. It is meant to be invoked through go:generate:
+//
+//	//go:generate go run github.com/ethereum/go-ethereum/rlp/rlpgen -type MyStruct -out gen_mystruct_rlp.go -decoder
+//
+// The generated methods produce the same encoding as package rlp and honour its
+// struct tags:
+//
+//	rlp:"-"          ignores the field
+//	rlp:"optional"   omits the field if it and all following fields are zero
+//	rlp:"tail"       encodes the elements of the last slice field into the enclosing list
+//	rlp:"nil"        decodes empty values into nil pointers, see also "nilString" and "nilList"
+//
+// Generated encoders do not allocate when writing into an rlp.EncoderBuffer or
+// through rlp.Encode. The generated file is excluded by the 'norlpgen' build tag,
+// which rlpgen uses when loading the input package.
 package main
 
 import (
@@ -0,0 +1,44 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import "context"
+
+// AccessPolicy restricts the methods a client may call. Policies are set by HTTP
+// middleware authenticating the client, and are enforced by the server for all
+// requests of the HTTP request or WebSocket connection.
+type AccessPolicy interface {
+	// Allow reports whether the client may call the given method, e.g.
+	// "eth_blockNumber". Subscriptions are checked by their subscribe and
+	// unsubscribe methods.
+	Allow(method string) bool
+}
+
+type accessPolicyContextKey struct{}
+
+// NewContextWithAccessPolicy returns a copy of the context carrying the access
+// policy. Pass it to the server as the context of the HTTP request.
+func NewContextWithAccessPolicy(ctx context.Context, policy AccessPolicy) context.Context {
+	return context.WithValue(ctx, accessPolicyContextKey{}, policy)
+}
+
+// accessPolicyFromContext returns the access policy of the context, or nil if
+// the client is not restricted.
+func accessPolicyFromContext(ctx context.Context) AccessPolicy {
+	policy, _ := ctx.Value(accessPolicyContextKey{}).(AccessPolicy)
+	return policy
+}
@@ -78,6 +78,7 @@ type Client struct {
 	idgen    func() ID // for subscriptions
 	isHTTP   bool      // connection type: http, ws or ipc
 	services *serviceRegistry
+	subConf  SubscriptionConfig // for subscriptions served by the connection
 
 	idCounter uint32
 
@@ -115,6 +116,7 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
 	handler := newHandler(ctx, conn, c.idgen, c.services)
+	handler.subConfig = c.subConf
 	return &clientConn{conn, handler}
 }
 
@@ -226,17 +228,18 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), SubscriptionConfig{})
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, subConf SubscriptionConfig) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
 		isHTTP:      isHTTP,
 		idgen:       idgen,
 		services:    services,
+		subConf:     subConf,
 		writeConn:   conn,
 		close:       make(chan struct{}),
 		closing:     make(chan struct{}),
@@ -49,7 +49,10 @@ type DataError interface {
 
 var (
 	_ Error = new(methodNotFoundError)
+	_ Error = new(methodNotAllowedError)
 	_ Error = new(subscriptionNotFoundError)
+	_ Error = new(subscriptionLimitError)
+	_ Error = new(subscriptionOverflowError)
 	_ Error = new(parseError)
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
@@ -61,6 +64,8 @@ const (
 	errcodeDefault                  = -32000
 	errcodeNotificationsUnsupported = -32001
 	errcodeTimeout                  = -32002
+	errcodeNotAllowed               = -32003
+	errcodeSubscriptionLimit        = -32005
 	errcodePanic                    = -32603
 	errcodeMarshalError             = -32603
 )
@@ -77,6 +82,14 @@ func (e *methodNotFoundError) Error() string {
 	return fmt.Sprintf("the method %s does not exist/is not available", e.method)
 }
 
+type methodNotAllowedError struct{ method string }
+
+func (e *methodNotAllowedError) ErrorCode() int { return errcodeNotAllowed }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed", e.method)
+}
+
 type subscriptionNotFoundError struct{ namespace, subscription string }
 
 func (e *subscriptionNotFoundError) ErrorCode() int { return -32601 }
@@ -85,6 +98,22 @@ func (e *subscriptionNotFoundError) Error() string {
 	return fmt.Sprintf("no %q subscription in %s namespace", e.subscription, e.namespace)
 }
 
+type subscriptionLimitError struct{ limit int }
+
+func (e *subscriptionLimitError) ErrorCode() int { return errcodeSubscriptionLimit }
+
+func (e *subscriptionLimitError) Error() string {
+	return fmt.Sprintf("subscription limit of %d reached", e.limit)
+}
+
+type subscriptionOverflowError struct{ buffer int }
+
+func (e *subscriptionOverflowError) ErrorCode() int { return errcodeSubscriptionLimit }
+
+func (e *subscriptionOverflowError) Error() string {
+	return fmt.Sprintf("subscription notification buffer of %d exceeded", e.buffer)
+}
+
 // Invalid JSON was received by the server.
 type parseError struct{ message string }
 
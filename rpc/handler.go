@@ -61,8 +61,10 @@ type handler struct {
 	log            log.Logger
 	allowSubscribe bool
 
-	subLock    sync.Mutex
-	serverSubs map[ID]*Subscription
+	subLock      sync.Mutex
+	serverSubs   map[ID]*Subscription
+	reservedSubs int                // subscribe calls in progress
+	subConfig    SubscriptionConfig // subscription limits of the connection
 }
 
 type callProc struct {
@@ -324,6 +326,7 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 			h.serverSubs[sub.ID] = sub
 		}
 	}
+	h.reservedSubs -= len(nn)
 }
 
 // cancelServerSubscriptions removes all subscriptions and closes their error channels.
@@ -334,6 +337,9 @@ func (h *handler) cancelServerSubscriptions(err error) {
 	for id, s := range h.serverSubs {
 		s.err <- err
 		close(s.err)
+		if s.queue != nil {
+			s.queue.close()
+		}
 		delete(h.serverSubs, id)
 	}
 }
@@ -376,9 +382,20 @@ func (h *handler) handleSubscriptionResult(msg *jsonrpcMessage) {
 		h.log.Debug("Dropping invalid subscription message")
 		return
 	}
-	if h.clientSubs[result.ID] != nil {
-		h.clientSubs[result.ID].deliver(result.Result)
+	sub := h.clientSubs[result.ID]
+	if sub == nil {
+		return
+	}
+	if result.Dropped > 0 {
+		h.log.Warn("Server dropped subscription notifications", "id", result.ID, "dropped", result.Dropped)
+	}
+	if result.Error != nil {
+		// The server ended the subscription, there's nothing to unsubscribe from
+		delete(h.clientSubs, result.ID)
+		sub.close(result.Error)
+		return
 	}
+	sub.deliver(result.Result)
 }
 
 // handleResponse processes method call responses.
@@ -439,6 +456,9 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if policy := PeerInfoFromContext(cp.ctx).policy; policy != nil && !policy.Allow(msg.Method) {
+		return msg.errorResponse(&methodNotAllowedError{method: msg.Method})
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -466,8 +486,17 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		} else {
 			successfulRequestGauge.Inc(1)
 		}
-		rpcServingTimer.UpdateSince(start)
-		updateServeTimeHistogram(msg.Method, answer.Error == nil, time.Since(start))
+		elapsed := time.Since(start)
+		rpcServingTimer.Update(elapsed)
+		updateServeTimeHistogram(msg.Method, answer.Error == nil, elapsed)
+		updateMethodStats(msg.Method, answer.Error == nil, elapsed)
+
+		if isSlowCall(elapsed) {
+			slowCallMeter.Mark(1)
+			info := PeerInfoFromContext(cp.ctx)
+			h.log.Warn("Slow RPC call", "method", msg.Method, "params", len(msg.Params), "duration", elapsed,
+				"transport", info.Transport, "remote", info.RemoteAddr, "origin", info.HTTP.Origin)
+		}
 	}
 	return answer
 }
@@ -500,6 +529,9 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	}
 	args = args[1:]
 
+	if !h.reserveSubscription() {
+		return msg.errorResponse(&subscriptionLimitError{h.subConfig.MaxSubscriptions})
+	}
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
 	cp.notifiers = append(cp.notifiers, n)
@@ -527,6 +559,9 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 		return false, ErrSubscriptionNotFound
 	}
 	close(s.err)
+	if s.queue != nil {
+		s.queue.close()
+	}
 	delete(h.serverSubs, id)
 	return true, nil
 }
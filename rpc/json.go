@@ -42,8 +42,10 @@ const (
 var null = json.RawMessage("null")
 
 type subscriptionResult struct {
-	ID     string          `json:"subscription"`
-	Result json.RawMessage `json:"result,omitempty"`
+	ID      string          `json:"subscription"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Dropped uint64          `json:"dropped,omitempty"` // Notifications dropped by the server before this one
+	Error   *jsonError      `json:"error,omitempty"`   // Reason the server ended the subscription
 }
 
 // A value of this type can a JSON-RPC request, notification, successful response or
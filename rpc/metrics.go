@@ -18,6 +18,8 @@ package rpc
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/metrics"
@@ -32,6 +34,20 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+	slowCallMeter   = metrics.NewRegisteredMeter("rpc/slow", nil)
+)
+
+// latencyBuckets are the upper bounds of the serving time buckets tracked for
+// each method. Calls exceeding the last bound are counted in an extra bucket.
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond, time.Second, 10 * time.Second,
+}
+
+var (
+	slowCallThreshold int64 // Serving time above which calls are logged, 0 if disabled
+
+	methodStatsLock sync.Mutex
+	methodStats     = make(map[string]*MethodStats)
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -48,3 +64,74 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Microseconds())
 }
+
+// SetSlowCallThreshold sets the serving time above which calls are logged as slow,
+// along with the size of their parameters and the origin of the request. Zero
+// disables slow call logging.
+func SetSlowCallThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&slowCallThreshold, int64(threshold))
+}
+
+// MethodStats are the serving statistics of an RPC method since startup. Unlike
+// the metrics histograms, they are tracked regardless of whether metrics
+// collection is enabled.
+type MethodStats struct {
+	Calls    uint64            `json:"calls"`
+	Failures uint64            `json:"failures"`
+	Total    time.Duration     `json:"total"`   // Total serving time of all calls
+	Max      time.Duration     `json:"max"`     // Longest serving time of a single call
+	Buckets  map[string]uint64 `json:"buckets"` // Number of calls by serving time upper bound
+}
+
+// Stats returns the serving statistics of all RPC methods called so far.
+func Stats() map[string]MethodStats {
+	methodStatsLock.Lock()
+	defer methodStatsLock.Unlock()
+
+	stats := make(map[string]MethodStats, len(methodStats))
+	for method, s := range methodStats {
+		cpy := *s
+		cpy.Buckets = make(map[string]uint64, len(s.Buckets))
+		for bucket, n := range s.Buckets {
+			cpy.Buckets[bucket] = n
+		}
+		stats[method] = cpy
+	}
+	return stats
+}
+
+// updateMethodStats tracks the serving time of a remote RPC call in the method
+// statistics.
+func updateMethodStats(method string, success bool, elapsed time.Duration) {
+	bucket := "+Inf"
+	for _, bound := range latencyBuckets {
+		if elapsed <= bound {
+			bucket = bound.String()
+			break
+		}
+	}
+	methodStatsLock.Lock()
+	defer methodStatsLock.Unlock()
+
+	s := methodStats[method]
+	if s == nil {
+		s = &MethodStats{Buckets: make(map[string]uint64)}
+		methodStats[method] = s
+	}
+	s.Calls++
+	if !success {
+		s.Failures++
+	}
+	s.Total += elapsed
+	if elapsed > s.Max {
+		s.Max = elapsed
+	}
+	s.Buckets[bucket]++
+}
+
+// isSlowCall reports whether a call with the given serving time should be logged
+// as slow.
+func isSlowCall(elapsed time.Duration) bool {
+	threshold := time.Duration(atomic.LoadInt64(&slowCallThreshold))
+	return threshold > 0 && elapsed > threshold
+}
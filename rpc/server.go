@@ -46,9 +46,10 @@ type Server struct {
 	services serviceRegistry
 	idgen    func() ID
 
-	mutex  sync.Mutex
-	codecs map[ServerCodec]struct{}
-	run    int32
+	mutex     sync.Mutex
+	codecs    map[ServerCodec]struct{}
+	subConfig SubscriptionConfig
+	run       int32
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -81,25 +82,28 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.close()
 
-	if !s.trackCodec(codec) {
+	subConfig, ok := s.trackCodec(codec)
+	if !ok {
 		return
 	}
 	defer s.untrackCodec(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, subConfig)
 	<-codec.closed()
 	c.Close()
 }
 
-func (s *Server) trackCodec(codec ServerCodec) bool {
+// trackCodec registers a served codec, returning the subscription limits that
+// apply to it.
+func (s *Server) trackCodec(codec ServerCodec) (SubscriptionConfig, bool) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	if atomic.LoadInt32(&s.run) == 0 {
-		return false // Don't serve if server is stopped.
+		return SubscriptionConfig{}, false // Don't serve if server is stopped.
 	}
 	s.codecs[codec] = struct{}{}
-	return true
+	return s.subConfig, true
 }
 
 func (s *Server) untrackCodec(codec ServerCodec) {
@@ -192,6 +196,8 @@ type PeerInfo struct {
 		Origin    string
 		Host      string
 	}
+
+	policy AccessPolicy // Methods the client may call, nil if unrestricted
 }
 
 type peerInfoContextKey struct{}
@@ -152,3 +152,50 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the serving statistics of methods are tracked.
+func TestServerMethodStats(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	before := Stats()["test_returnError"]
+	for i := 0; i < 3; i++ {
+		if err := client.Call(nil, "test_returnError"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	after := Stats()["test_returnError"]
+	if calls := after.Calls - before.Calls; calls < 3 {
+		t.Errorf("calls mismatch: have %d, want at least 3", calls)
+	}
+	if failures := after.Failures - before.Failures; failures < 3 {
+		t.Errorf("failures mismatch: have %d, want at least 3", failures)
+	}
+	var bucketed uint64
+	for _, n := range after.Buckets {
+		bucketed += n
+	}
+	if bucketed != after.Calls {
+		t.Errorf("bucketed calls mismatch: have %d, want %d", bucketed, after.Calls)
+	}
+	if after.Max <= 0 || after.Total < after.Max {
+		t.Errorf("invalid serving times: total %v, max %v", after.Total, after.Max)
+	}
+}
+
+func TestSlowCallThreshold(t *testing.T) {
+	defer SetSlowCallThreshold(0)
+
+	if isSlowCall(time.Hour) {
+		t.Error("slow call logged with logging disabled")
+	}
+	SetSlowCallThreshold(time.Second)
+	if isSlowCall(time.Second) {
+		t.Error("call at threshold logged as slow")
+	}
+	if !isSlowCall(time.Second + 1) {
+		t.Error("call above threshold not logged as slow")
+	}
+}
@@ -97,6 +97,7 @@ type Notifier struct {
 	mu           sync.Mutex
 	sub          *Subscription
 	buffer       []json.RawMessage
+	queue        *notificationQueue // Queue of notifications for slow clients, if configured
 	callReturned bool
 	activated    bool
 }
@@ -115,11 +116,20 @@ func (n *Notifier) CreateSubscription() *Subscription {
 		panic("can't create subscription after subscribe call has returned")
 	}
 	n.sub = &Subscription{ID: n.h.idgen(), namespace: n.namespace, err: make(chan error, 1)}
+	if n.h.subConfig.BufferSize > 0 {
+		n.queue = newNotificationQueue(n, n.h.subConfig)
+		n.sub.queue = n.queue
+	}
 	return n.sub
 }
 
 // Notify sends a notification to the client with the given data as payload.
 // If an error occurs the RPC connection is closed and the error is returned.
+//
+// If the server queues notifications, Notify returns once the notification is
+// queued, handling slow clients according to the server's slow consumer policy.
+// Notifications sent before activation are queued as well, blocking Notify while
+// the queue is full.
 func (n *Notifier) Notify(id ID, data interface{}) error {
 	enc, err := json.Marshal(data)
 	if err != nil {
@@ -127,13 +137,20 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	}
 
 	n.mu.Lock()
-	defer n.mu.Unlock()
-
 	if n.sub == nil {
+		n.mu.Unlock()
 		panic("can't Notify before subscription is created")
 	} else if n.sub.ID != id {
+		n.mu.Unlock()
 		panic("Notify with wrong ID")
 	}
+	if n.queue != nil {
+		// Don't hold the lock while the queue applies backpressure
+		n.mu.Unlock()
+		return n.queue.push(enc)
+	}
+	defer n.mu.Unlock()
+
 	if n.activated {
 		return n.send(n.sub, enc)
 	}
@@ -163,6 +180,12 @@ func (n *Notifier) activate() error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if n.queue != nil {
+		// Queued notifications are held back until the queue starts sending
+		n.activated = true
+		n.queue.start()
+		return nil
+	}
 	for _, data := range n.buffer {
 		if err := n.send(n.sub, data); err != nil {
 			return err
@@ -173,7 +196,12 @@ func (n *Notifier) activate() error {
 }
 
 func (n *Notifier) send(sub *Subscription, data json.RawMessage) error {
-	params, _ := json.Marshal(&subscriptionResult{ID: string(sub.ID), Result: data})
+	return n.sendResult(&subscriptionResult{ID: string(sub.ID), Result: data})
+}
+
+// sendResult writes a subscription notification with the given content.
+func (n *Notifier) sendResult(result *subscriptionResult) error {
+	params, _ := json.Marshal(result)
 	ctx := context.Background()
 
 	msg := &jsonrpcMessage{
@@ -189,7 +217,8 @@ func (n *Notifier) send(sub *Subscription, data json.RawMessage) error {
 type Subscription struct {
 	ID        ID
 	namespace string
-	err       chan error // closed on unsubscribe
+	err       chan error         // closed on unsubscribe
+	queue     *notificationQueue // notification queue, stopped on unsubscribe
 }
 
 // Err returns a channel that is closed when the client send an unsubscribe request.
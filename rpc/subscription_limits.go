@@ -0,0 +1,266 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	droppedNotificationsMeter = metrics.NewRegisteredMeter("rpc/subscriptions/dropped", nil)
+	disconnectedSubsMeter     = metrics.NewRegisteredMeter("rpc/subscriptions/disconnected", nil)
+
+	// errSubscriptionClosed is returned by Notify once the subscription has been
+	// unsubscribed or its connection closed.
+	errSubscriptionClosed = errors.New("subscription closed")
+)
+
+// SlowConsumerPolicy selects what happens to the notifications of a subscription
+// whose client does not keep up with reading them.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBackpressure blocks Notify until the client has caught up.
+	SlowConsumerBackpressure SlowConsumerPolicy = iota
+
+	// SlowConsumerDropOldest discards the oldest queued notifications in favor
+	// of new ones.
+	SlowConsumerDropOldest
+
+	// SlowConsumerDisconnect closes the connection of the client.
+	SlowConsumerDisconnect
+)
+
+// String implements fmt.Stringer.
+func (p SlowConsumerPolicy) String() string {
+	switch p {
+	case SlowConsumerBackpressure:
+		return "backpressure"
+	case SlowConsumerDropOldest:
+		return "drop"
+	case SlowConsumerDisconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("SlowConsumerPolicy(%d)", int(p))
+	}
+}
+
+// ParseSlowConsumerPolicy parses the name of a slow consumer policy, as returned
+// by its String method.
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	for _, p := range []SlowConsumerPolicy{SlowConsumerBackpressure, SlowConsumerDropOldest, SlowConsumerDisconnect} {
+		if strings.EqualFold(name, p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown slow consumer policy %q", name)
+}
+
+// SubscriptionConfig limits the subscriptions of the connections of a server.
+type SubscriptionConfig struct {
+	// MaxSubscriptions is the maximum number of active subscriptions of a single
+	// connection. Zero means no limit.
+	MaxSubscriptions int
+
+	// BufferSize is the number of notifications of a subscription queued for a
+	// slow client before the policy takes effect. Zero means notifications are
+	// not queued but written by Notify directly, which applies backpressure.
+	BufferSize int
+
+	// Policy selects what happens when the queue of a subscription is full.
+	Policy SlowConsumerPolicy
+}
+
+// SetSubscriptionConfig sets the subscription limits of the connections served
+// from now on.
+func (s *Server) SetSubscriptionConfig(config SubscriptionConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subConfig = config
+}
+
+// reserveSubscription reserves a subscription slot for a subscribe call, which
+// is released by addSubscriptions once the call is done.
+func (h *handler) reserveSubscription() bool {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	if limit := h.subConfig.MaxSubscriptions; limit > 0 && len(h.serverSubs)+h.reservedSubs >= limit {
+		return false
+	}
+	h.reservedSubs++
+	return true
+}
+
+// notificationQueue queues the notifications of a subscription for a client that
+// is slow to read them, applying the slow consumer policy when it is full.
+type notificationQueue struct {
+	n      *Notifier
+	config SubscriptionConfig
+
+	mu         sync.Mutex
+	items      []json.RawMessage
+	started    bool   // Whether the subscription is active and notifications are sent
+	dropped    uint64 // Number of notifications dropped so far
+	unreported uint64 // Number of dropped notifications not yet reported to the client
+
+	wake  chan struct{} // Signals queued notifications to the send loop
+	space chan struct{} // Signals free space to a blocked Notify
+	quit  chan struct{} // Closed when the subscription ends
+	once  sync.Once
+}
+
+func newNotificationQueue(n *Notifier, config SubscriptionConfig) *notificationQueue {
+	return &notificationQueue{
+		n:      n,
+		config: config,
+		wake:   make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+}
+
+// push queues a notification, applying the slow consumer policy if the queue is
+// full. Before the subscription is active the client cannot be slow yet, so push
+// waits for the queue to start sending instead.
+func (q *notificationQueue) push(data json.RawMessage) error {
+	for {
+		q.mu.Lock()
+		select {
+		case <-q.quit:
+			q.mu.Unlock()
+			return errSubscriptionClosed
+		default:
+		}
+		if len(q.items) < q.config.BufferSize {
+			q.items = append(q.items, data)
+			q.mu.Unlock()
+			signal(q.wake)
+			return nil
+		}
+		policy := q.config.Policy
+		if !q.started {
+			policy = SlowConsumerBackpressure
+		}
+		switch policy {
+		case SlowConsumerDropOldest:
+			q.items = append(q.items[1:], data)
+			q.dropped++
+			q.unreported++
+			dropped := q.dropped
+			q.mu.Unlock()
+
+			droppedNotificationsMeter.Mark(1)
+			if dropped == 1 {
+				q.n.h.log.Warn("Dropping notifications of slow subscriber", "id", q.n.sub.ID, "buffer", q.config.BufferSize)
+			}
+			return nil
+
+		case SlowConsumerDisconnect:
+			q.mu.Unlock()
+
+			disconnectedSubsMeter.Mark(1)
+			q.n.h.log.Warn("Disconnecting slow subscriber", "id", q.n.sub.ID, "buffer", q.config.BufferSize)
+			q.close()
+
+			// Tell the client why it's disconnected. The write may take until its
+			// timeout with the client not reading, so don't block the notifier.
+			go func() {
+				err := &subscriptionOverflowError{buffer: q.config.BufferSize}
+				q.n.sendResult(&subscriptionResult{
+					ID:    string(q.n.sub.ID),
+					Error: &jsonError{Code: err.ErrorCode(), Message: err.Error()},
+				})
+				if c, ok := q.n.h.conn.(interface{ close() }); ok {
+					c.close()
+				}
+			}()
+			return ErrSubscriptionQueueOverflow
+		}
+		q.mu.Unlock()
+
+		// Apply backpressure until the client catches up
+		select {
+		case <-q.space:
+		case <-q.quit:
+			return errSubscriptionClosed
+		case <-q.n.h.conn.closed():
+			return errSubscriptionClosed
+		}
+	}
+}
+
+// start activates the queue, sending the queued notifications to the client.
+func (q *notificationQueue) start() {
+	q.mu.Lock()
+	q.started = true
+	q.mu.Unlock()
+
+	go q.loop()
+}
+
+// loop sends the queued notifications to the client until the subscription or
+// the connection is closed.
+func (q *notificationQueue) loop() {
+	for {
+		q.mu.Lock()
+		if len(q.items) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-q.wake:
+				continue
+			case <-q.quit:
+				return
+			case <-q.n.h.conn.closed():
+				q.close()
+				return
+			}
+		}
+		// Report the notifications dropped meanwhile along with the next one
+		result := &subscriptionResult{ID: string(q.n.sub.ID), Result: q.items[0], Dropped: q.unreported}
+		q.unreported = 0
+		q.items[0] = nil
+		q.items = q.items[1:]
+		q.mu.Unlock()
+		signal(q.space)
+
+		if err := q.n.sendResult(result); err != nil {
+			q.close()
+			return
+		}
+	}
+}
+
+// close stops the send loop and fails all future pushes.
+func (q *notificationQueue) close() {
+	q.once.Do(func() { close(q.quit) })
+}
+
+// signal notifies a waiter on a channel of capacity one, without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
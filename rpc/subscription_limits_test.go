@@ -0,0 +1,275 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// burstService sends a burst of notifications, reporting the result of the burst
+// on a channel.
+type burstService struct {
+	done chan error
+}
+
+func (s *burstService) Burst(ctx context.Context, n int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := notifier.Notify(sub.ID, i); err != nil {
+				s.done <- err
+				return
+			}
+		}
+		s.done <- nil
+	}()
+	return sub, nil
+}
+
+// startBurst serves a burst service with the given subscription limits over a
+// pipe and subscribes to a burst of n notifications, returning the decoder of
+// the notifications once the subscription is confirmed.
+func startBurst(t *testing.T, config SubscriptionConfig, n int) (*burstService, net.Conn, *json.Decoder) {
+	t.Helper()
+
+	server := NewServer()
+	service := &burstService{done: make(chan error, 1)}
+	server.RegisterName("burst", service)
+	server.SetSubscriptionConfig(config)
+	t.Cleanup(server.Stop)
+
+	p1, p2 := net.Pipe()
+	t.Cleanup(func() { p2.Close() })
+	go server.ServeCodec(NewCodec(p1), 0)
+
+	p2.SetDeadline(time.Now().Add(10 * time.Second))
+	fmt.Fprintf(p2, `{"jsonrpc":"2.0","id":1,"method":"burst_subscribe","params":["burst",%d]}`, n)
+
+	in := json.NewDecoder(p2)
+	resp, _, err := readAndValidateMessage(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil {
+		t.Fatal("notification before subscription confirmation")
+	}
+	return service, p2, in
+}
+
+// readBurst reads n notifications of a burst, returning their values and the
+// number of dropped notifications reported along with them.
+func readBurst(t *testing.T, in *json.Decoder, n int) ([]int, uint64) {
+	t.Helper()
+
+	var (
+		values  []int
+		dropped uint64
+	)
+	for i := 0; i < n; i++ {
+		_, notification, err := readAndValidateMessage(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if notification == nil {
+			t.Fatal("unexpected response")
+		}
+		var value int
+		if err := json.Unmarshal(notification.Result, &value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+		dropped += notification.Dropped
+	}
+	return values, dropped
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	server := newTestServer()
+	server.SetSubscriptionConfig(SubscriptionConfig{MaxSubscriptions: 2})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	var subs []*ClientSubscription
+	for i := 0; i < 2; i++ {
+		sub, err := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+		if err != nil {
+			t.Fatalf("subscription %d failed: %v", i, err)
+		}
+		subs = append(subs, sub)
+	}
+	_, err := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeSubscriptionLimit {
+		t.Fatalf("subscription beyond limit: have %v, want code %d", err, errcodeSubscriptionLimit)
+	}
+	// Unsubscribing frees up a slot
+	subs[0].Unsubscribe()
+	sub, err := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+	if err != nil {
+		t.Fatalf("subscription after unsubscribe failed: %v", err)
+	}
+	sub.Unsubscribe()
+	subs[1].Unsubscribe()
+}
+
+func TestSlowConsumerBackpressure(t *testing.T) {
+	config := SubscriptionConfig{BufferSize: 2, Policy: SlowConsumerBackpressure}
+	service, _, in := startBurst(t, config, 10)
+
+	values, dropped := readBurst(t, in, 10)
+	if dropped != 0 {
+		t.Fatalf("dropped notifications reported: %d", dropped)
+	}
+	for i, value := range values {
+		if value != i {
+			t.Fatalf("notification %d: have %d, want %d", i, value, i)
+		}
+	}
+	if err := <-service.done; err != nil {
+		t.Fatalf("burst failed: %v", err)
+	}
+}
+
+func TestSlowConsumerDropOldest(t *testing.T) {
+	config := SubscriptionConfig{BufferSize: 2, Policy: SlowConsumerDropOldest}
+	service, _, in := startBurst(t, config, 100)
+
+	// The burst must not be blocked by the client not reading
+	select {
+	case err := <-service.done:
+		if err != nil {
+			t.Fatalf("burst failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("burst blocked by slow consumer")
+	}
+	// At most the notification being sent and the queued ones remain, the latter
+	// being the newest
+	var (
+		values  []int
+		dropped uint64
+	)
+	for len(values) == 0 || values[len(values)-1] != 99 {
+		value, drops := readBurst(t, in, 1)
+		values, dropped = append(values, value...), dropped+drops
+		if len(values) > 3 {
+			t.Fatalf("too many notifications delivered: %v", values)
+		}
+	}
+	if len(values) < 2 || values[len(values)-2] != 98 {
+		t.Fatalf("wrong notifications delivered: have %v, want [... 98 99]", values)
+	}
+	// The client must be told about all the notifications it missed
+	if want := uint64(100 - len(values)); dropped != want {
+		t.Fatalf("reported drops mismatch: have %d, want %d", dropped, want)
+	}
+}
+
+func TestSlowConsumerDisconnect(t *testing.T) {
+	config := SubscriptionConfig{BufferSize: 2, Policy: SlowConsumerDisconnect}
+	service, _, in := startBurst(t, config, 100)
+
+	select {
+	case err := <-service.done:
+		if err != ErrSubscriptionQueueOverflow {
+			t.Fatalf("wrong burst error: have %v, want %v", err, ErrSubscriptionQueueOverflow)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("burst blocked by slow consumer")
+	}
+	// The client must be told about the disconnect before the connection is closed
+	for {
+		_, notification, err := readAndValidateMessage(in)
+		if err != nil {
+			t.Fatalf("connection closed without error notification: %v", err)
+		}
+		if notification == nil {
+			t.Fatal("unexpected response")
+		}
+		if notification.Error != nil {
+			if notification.Error.Code != errcodeSubscriptionLimit {
+				t.Fatalf("wrong error code: have %d, want %d", notification.Error.Code, errcodeSubscriptionLimit)
+			}
+			break
+		}
+	}
+	if _, _, err := readAndValidateMessage(in); err == nil {
+		t.Fatal("connection not closed after error notification")
+	}
+}
+
+// Tests that the client ends a subscription with the error reported by the
+// server when it disconnects the slow client.
+func TestSubscriptionErrorNotification(t *testing.T) {
+	p1, p2 := net.Pipe()
+	client, err := DialIO(context.Background(), p1, p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	defer p2.Close() // Unblocks the read loop of the client
+
+	// Act as the server, confirming the subscription and ending it with an error
+	go func() {
+		var req jsonrpcMessage
+		if err := json.NewDecoder(p2).Decode(&req); err != nil {
+			return
+		}
+		fmt.Fprintf(p2, `{"jsonrpc":"2.0","id":%s,"result":"0x1"}`, req.ID)
+		fmt.Fprintf(p2, `{"jsonrpc":"2.0","method":"burst_subscription","params":{"subscription":"0x1","error":{"code":%d,"message":"overflow"}}}`, errcodeSubscriptionLimit)
+	}()
+	sub, err := client.Subscribe(context.Background(), "burst", make(chan int), "burst", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-sub.Err():
+		var rpcErr Error
+		if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeSubscriptionLimit {
+			t.Fatalf("wrong subscription error: have %v, want code %d", err, errcodeSubscriptionLimit)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not ended")
+	}
+}
+
+func TestParseSlowConsumerPolicy(t *testing.T) {
+	for _, policy := range []SlowConsumerPolicy{SlowConsumerBackpressure, SlowConsumerDropOldest, SlowConsumerDisconnect} {
+		have, err := ParseSlowConsumerPolicy(policy.String())
+		if err != nil {
+			t.Fatalf("failed to parse %v: %v", policy, err)
+		}
+		if have != policy {
+			t.Fatalf("wrong policy: have %v, want %v", have, policy)
+		}
+	}
+	if _, err := ParseSlowConsumerPolicy("foo"); err == nil {
+		t.Fatal("parsed unknown policy")
+	}
+}
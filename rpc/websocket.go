@@ -61,6 +61,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header)
+		codec.info.policy = accessPolicyFromContext(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -281,7 +282,7 @@ type websocketCodec struct {
 	pingReset chan struct{}
 }
 
-func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header) ServerCodec {
+func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header) *websocketCodec {
 	conn.SetReadLimit(wsMessageSizeLimit)
 	conn.SetPongHandler(func(appData string) error {
 		conn.SetReadDeadline(time.Time{})
@@ -44,7 +44,7 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.1.0"
+	ExternalAPIVersion = "6.2.0"
 	// InternalAPIVersion -- see intapi_changelog.md
 	InternalAPIVersion = "7.0.1"
 )
@@ -61,6 +61,9 @@ type ExternalAPI interface {
 	SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error)
 	// SignTypedData - request to sign the given structured data (plus prefix)
 	SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (hexutil.Bytes, error)
+	// SignTypedDataWithHashes - request to sign the given structured data after validating it,
+	// returning the signature along with the signed hashes
+	SignTypedDataWithHashes(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (*apitypes.SignTypedDataResult, error)
 	// EcRecover - recover public key from given message and signature
 	EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error)
 	// Version info about the APIs
@@ -247,11 +247,7 @@ type TypedDataDomain struct {
 //
 // This gives context to the signed typed data and prevents signing of transactions.
 func TypedDataAndHash(typedData TypedData) ([]byte, string, error) {
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return nil, "", err
-	}
-	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	domainSeparator, typedDataHash, err := TypedDataHashes(typedData)
 	if err != nil {
 		return nil, "", err
 	}
@@ -259,6 +255,93 @@ func TypedDataAndHash(typedData TypedData) ([]byte, string, error) {
 	return crypto.Keccak256([]byte(rawData)), rawData, nil
 }
 
+// TypedDataHashes returns the two components of the EIP-712 signing digest: the
+// domain separator and the hash of the primary message struct.
+func TypedDataHashes(typedData TypedData) (domainSeparator, structHash hexutil.Bytes, err error) {
+	domainSeparator, err = typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, nil, err
+	}
+	structHash, err = typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return domainSeparator, structHash, nil
+}
+
+// SignTypedDataResult is the signature over EIP-712 typed data, along with the
+// hashes it commits to, allowing callers to audit what was actually signed.
+type SignTypedDataResult struct {
+	Signature       hexutil.Bytes `json:"signature"`
+	Hash            hexutil.Bytes `json:"hash"`
+	DomainSeparator hexutil.Bytes `json:"domainSeparator"`
+	StructHash      hexutil.Bytes `json:"structHash"`
+}
+
+// Validate checks that the typed data is well formed and that both the domain
+// and the message conform to the declared schema: every declared field must be
+// present, no undeclared fields may be present and every value must be
+// encodable as its declared type.
+func (typedData *TypedData) Validate() error {
+	if err := typedData.validate(); err != nil {
+		return err
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		return errors.New("domain type EIP712Domain is undefined")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return fmt.Errorf("primary type %q is undefined", typedData.PrimaryType)
+	}
+	if err := typedData.validateFields("EIP712Domain", typedData.Domain.Map()); err != nil {
+		return err
+	}
+	if err := typedData.validateFields(typedData.PrimaryType, typedData.Message); err != nil {
+		return err
+	}
+	_, _, err := TypedDataHashes(*typedData)
+	return err
+}
+
+// validateFields checks that the fields of a struct value match the declaration
+// of the given type exactly, recursing into nested struct types.
+func (typedData *TypedData) validateFields(typeName string, data map[string]interface{}) error {
+	declared := make(map[string]struct{})
+	for _, field := range typedData.Types[typeName] {
+		declared[field.Name] = struct{}{}
+
+		value, ok := data[field.Name]
+		if !ok {
+			return fmt.Errorf("type %q: missing field %q", typeName, field.Name)
+		}
+		fieldType := field.typeName()
+		if _, ok := typedData.Types[fieldType]; !ok {
+			continue // Primitive values are checked during encoding
+		}
+		items := []interface{}{value}
+		if field.isArray() {
+			var err error
+			if items, err = convertDataToSlice(value); err != nil {
+				return dataMismatchError(field.Type, value)
+			}
+		}
+		for _, item := range items {
+			mapValue, ok := item.(map[string]interface{})
+			if !ok {
+				return dataMismatchError(fieldType, item)
+			}
+			if err := typedData.validateFields(fieldType, mapValue); err != nil {
+				return err
+			}
+		}
+	}
+	for name := range data {
+		if _, ok := declared[name]; !ok {
+			return fmt.Errorf("type %q: undeclared field %q", typeName, name)
+		}
+	}
+	return nil
+}
+
 // HashStruct generates a keccak256 hash of the encoding of the provided data
 func (typedData *TypedData) HashStruct(primaryType string, data TypedDataMessage) (hexutil.Bytes, error) {
 	encodedData, err := typedData.EncodeData(primaryType, data, 1)
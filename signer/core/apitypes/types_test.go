@@ -38,3 +38,50 @@ func TestIsPrimitive(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateTypedData(t *testing.T) {
+	newTypedData := func(message TypedDataMessage) TypedData {
+		return TypedData{
+			Types: Types{
+				"EIP712Domain": {{Name: "name", Type: "string"}},
+				"Person":       {{Name: "name", Type: "string"}, {Name: "age", Type: "uint8"}},
+				"Group":        {{Name: "members", Type: "Person[]"}},
+			},
+			PrimaryType: "Group",
+			Domain:      TypedDataDomain{Name: "test"},
+			Message:     message,
+		}
+	}
+	member := func(fields ...string) map[string]interface{} {
+		person := map[string]interface{}{"name": "bob", "age": "42"}
+		for _, field := range fields {
+			person[field] = "x"
+		}
+		return person
+	}
+	// Valid data
+	data := newTypedData(TypedDataMessage{"members": []interface{}{member()}})
+	if err := data.Validate(); err != nil {
+		t.Errorf("valid data rejected: %v", err)
+	}
+	// Missing, undeclared or mistyped fields
+	for i, message := range []TypedDataMessage{
+		{},
+		{"members": []interface{}{map[string]interface{}{"name": "bob"}}},
+		{"members": []interface{}{member("extra")}},
+		{"members": []interface{}{member()}, "extra": "x"},
+		{"members": member()},
+		{"members": []interface{}{map[string]interface{}{"name": "bob", "age": "old"}}},
+	} {
+		data := newTypedData(message)
+		if err := data.Validate(); err == nil {
+			t.Errorf("test %d: invalid data accepted", i)
+		}
+	}
+	// Undeclared domain field
+	data = newTypedData(TypedDataMessage{"members": []interface{}{member()}})
+	data.Domain.Version = "1"
+	if err := data.Validate(); err == nil {
+		t.Error("undeclared domain field accepted")
+	}
+}
@@ -90,13 +90,27 @@ func (l *AuditLogger) SignGnosisSafeTx(ctx context.Context, addr common.Mixedcas
 }
 
 func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (hexutil.Bytes, error) {
+	domainSeparator, structHash, _ := apitypes.TypedDataHashes(data)
 	l.log.Info("SignTypedData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"addr", addr.String(), "data", data)
+		"addr", addr.String(), "data", data, "domainSeparator", domainSeparator, "structHash", structHash)
 	b, e := l.api.SignTypedData(ctx, addr, data)
 	l.log.Info("SignTypedData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
 	return b, e
 }
 
+func (l *AuditLogger) SignTypedDataWithHashes(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (*apitypes.SignTypedDataResult, error) {
+	l.log.Info("SignTypedDataWithHashes", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "data", data)
+	res, e := l.api.SignTypedDataWithHashes(ctx, addr, data)
+	if res != nil {
+		l.log.Info("SignTypedDataWithHashes", "type", "response", "data", res.Signature,
+			"hash", res.Hash, "domainSeparator", res.DomainSeparator, "structHash", res.StructHash, "error", e)
+	} else {
+		l.log.Info("SignTypedDataWithHashes", "type", "response", "data", nil, "error", e)
+	}
+	return res, e
+}
+
 func (l *AuditLogger) EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error) {
 	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"data", common.Bytes2Hex(data), "sig", common.Bytes2Hex(sig))
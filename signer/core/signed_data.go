@@ -229,6 +229,30 @@ func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAd
 	return signature, err
 }
 
+// SignTypedDataWithHashes signs EIP-712 conformant typed data after validating
+// it against its own schema. Besides the signature, it returns the digest that
+// was signed along with the domain separator and struct hash it is built from,
+// so that callers can keep an audit log of what exactly was signed.
+func (api *SignerAPI) SignTypedDataWithHashes(ctx context.Context, addr common.MixedcaseAddress, typedData apitypes.TypedData) (*apitypes.SignTypedDataResult, error) {
+	if err := typedData.Validate(); err != nil {
+		return nil, err
+	}
+	domainSeparator, structHash, err := apitypes.TypedDataHashes(typedData)
+	if err != nil {
+		return nil, err
+	}
+	signature, hash, err := api.signTypedData(ctx, addr, typedData, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &apitypes.SignTypedDataResult{
+		Signature:       signature,
+		Hash:            hash,
+		DomainSeparator: domainSeparator,
+		StructHash:      structHash,
+	}, nil
+}
+
 // signTypedData is identical to the capitalized version, except that it also returns the hash (preimage)
 // - the signature preimage (hash)
 func (api *SignerAPI) signTypedData(ctx context.Context, addr common.MixedcaseAddress,
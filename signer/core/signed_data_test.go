@@ -247,6 +247,54 @@ func TestSignData(t *testing.T) {
 	}
 }
 
+func TestSignTypedDataWithHashes(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	// A message lacking the declared Mail.contents field must be rejected
+	incomplete := typedData
+	incomplete.Message = map[string]interface{}{
+		"from": messageStandard["from"],
+		"to":   messageStandard["to"],
+	}
+	if _, err := api.SignTypedDataWithHashes(context.Background(), a, incomplete); err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	complete := typedData
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	res, err := api.SignTypedDataWithHashes(context.Background(), a, complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domainSeparator, structHash, err := apitypes.TypedDataHashes(complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.DomainSeparator, domainSeparator) {
+		t.Errorf("domain separator mismatch: have %x, want %x", res.DomainSeparator, domainSeparator)
+	}
+	if !bytes.Equal(res.StructHash, structHash) {
+		t.Errorf("struct hash mismatch: have %x, want %x", res.StructHash, structHash)
+	}
+	hash, _, err := apitypes.TypedDataAndHash(complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Hash, hash) {
+		t.Errorf("hash mismatch: have %x, want %x", res.Hash, hash)
+	}
+	if len(res.Signature) != 65 {
+		t.Errorf("Expected 65 byte signature (got %d bytes)", len(res.Signature))
+	}
+}
+
 func TestDomainChainId(t *testing.T) {
 	withoutChainID := apitypes.TypedData{
 		Types: apitypes.Types{
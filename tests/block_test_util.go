@@ -101,7 +101,15 @@ type btHeaderMarshaling struct {
 	BaseFeePerGas *math.HexOrDecimal256
 }
 
+// Run executes the test, importing its blocks and verifying the resulting chain
+// and post state.
 func (t *BlockTest) Run(snapshotter bool) error {
+	return t.RunWithConfig(snapshotter, vm.Config{})
+}
+
+// RunWithConfig executes the test like Run, using the given EVM configuration
+// for block processing.
+func (t *BlockTest) RunWithConfig(snapshotter bool, vmconfig vm.Config) error {
 	config, ok := Forks[t.json.Network]
 	if !ok {
 		return UnsupportedForkError{t.json.Network}
@@ -131,7 +139,7 @@ func (t *BlockTest) Run(snapshotter bool) error {
 		cache.SnapshotLimit = 1
 		cache.SnapshotWait = true
 	}
-	chain, err := core.NewBlockChain(db, cache, gspec, nil, engine, vm.Config{}, nil, nil)
+	chain, err := core.NewBlockChain(db, cache, gspec, nil, engine, vmconfig, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/abifuzz"
 	fuzz "github.com/google/gofuzz"
 )
 
@@ -143,6 +144,10 @@ func runFuzzer(input []byte) int {
 		if err != nil {
 			continue
 		}
+		method := abi.Methods[name]
+		if _, _, err := abifuzz.NewFromBytes(input, abifuzz.Config{}).Calldata(&method); err != nil {
+			panic(fmt.Sprintf("failed to generate calldata for %v: %v", method, err))
+		}
 		structs, b := unpackPack(abi, name, input)
 		c := packUnpack(abi, name, &structs)
 		good = good || b || c
@@ -0,0 +1,168 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// RunConfig configures the execution of test vectors through RunStateTest and
+// RunBlockTest.
+type RunConfig struct {
+	Forks       []string // Forks (or networks) to run the tests on, all if empty
+	Snapshotter bool     // Whether to cross-check the execution with the snapshotter
+	Dump        bool     // Whether to include a state dump in failing state test results
+
+	// Tracer, if set, is invoked for every executed (sub)test to create the EVM
+	// logger attached to its execution. It may return nil to not trace a test.
+	Tracer func(name string, fork string, index int) vm.EVMLogger
+}
+
+// StateTestResult contains the execution status after running a state test
+// subtest, any error that might have occurred and a dump of the final state if
+// requested.
+type StateTestResult struct {
+	Name  string       `json:"name"`
+	Pass  bool         `json:"pass"`
+	Root  *common.Hash `json:"stateRoot,omitempty"`
+	Fork  string       `json:"fork"`
+	Index int          `json:"index"`
+	Error string       `json:"error,omitempty"`
+	State *state.Dump  `json:"state,omitempty"`
+}
+
+// BlockTestResult contains the execution status after running a blockchain test.
+type BlockTestResult struct {
+	Name    string `json:"name"`
+	Pass    bool   `json:"pass"`
+	Network string `json:"network"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RunStateTest executes all the state tests contained in the given JSON test
+// file, in the same way as the official test suite does. An error is only
+// returned if the file cannot be parsed; individual test failures are reported
+// in the results, which are ordered by test name, fork and index.
+func RunStateTest(src []byte, cfg *RunConfig) ([]StateTestResult, error) {
+	if cfg == nil {
+		cfg = new(RunConfig)
+	}
+	var tests map[string]StateTest
+	if err := json.Unmarshal(src, &tests); err != nil {
+		return nil, err
+	}
+	var results []StateTestResult
+	for _, name := range sortedKeys(tests) {
+		test := tests[name]
+
+		subtests := test.Subtests()
+		sort.Slice(subtests, func(i, j int) bool {
+			if subtests[i].Fork != subtests[j].Fork {
+				return subtests[i].Fork < subtests[j].Fork
+			}
+			return subtests[i].Index < subtests[j].Index
+		})
+		for _, st := range subtests {
+			if !cfg.runsFork(st.Fork) {
+				continue
+			}
+			var vmconfig vm.Config
+			if cfg.Tracer != nil {
+				if tracer := cfg.Tracer(name, st.Fork, st.Index); tracer != nil {
+					vmconfig.Tracer, vmconfig.Debug = tracer, true
+				}
+			}
+			result := StateTestResult{Name: name, Fork: st.Fork, Index: st.Index, Pass: true}
+
+			_, statedb, err := test.Run(st, vmconfig, cfg.Snapshotter)
+			if statedb != nil {
+				root := statedb.IntermediateRoot(false)
+				result.Root = &root
+			}
+			if err != nil {
+				result.Pass, result.Error = false, err.Error()
+				if cfg.Dump && statedb != nil {
+					dump := statedb.RawDump(nil)
+					result.State = &dump
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// RunBlockTest executes all the blockchain tests contained in the given JSON
+// test file, in the same way as the official test suite does. An error is only
+// returned if the file cannot be parsed; individual test failures are reported
+// in the results, which are ordered by test name.
+func RunBlockTest(src []byte, cfg *RunConfig) ([]BlockTestResult, error) {
+	if cfg == nil {
+		cfg = new(RunConfig)
+	}
+	var tests map[string]BlockTest
+	if err := json.Unmarshal(src, &tests); err != nil {
+		return nil, err
+	}
+	var results []BlockTestResult
+	for _, name := range sortedKeys(tests) {
+		test := tests[name]
+		if !cfg.runsFork(test.json.Network) {
+			continue
+		}
+		var vmconfig vm.Config
+		if cfg.Tracer != nil {
+			if tracer := cfg.Tracer(name, test.json.Network, 0); tracer != nil {
+				vmconfig.Tracer, vmconfig.Debug = tracer, true
+			}
+		}
+		result := BlockTestResult{Name: name, Network: test.json.Network, Pass: true}
+		if err := test.RunWithConfig(cfg.Snapshotter, vmconfig); err != nil {
+			result.Pass, result.Error = false, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runsFork returns whether tests of the given fork should be executed.
+func (cfg *RunConfig) runsFork(fork string) bool {
+	if len(cfg.Forks) == 0 {
+		return true
+	}
+	for _, f := range cfg.Forks {
+		if f == fork {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the test names of a test file in alphabetical order.
+func sortedKeys[T any](tests map[string]T) []string {
+	keys := make([]string, 0, len(tests))
+	for key := range tests {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
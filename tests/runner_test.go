@@ -0,0 +1,114 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+)
+
+// runnerStateTest is a state test calling a contract storing 1 into slot 0. The
+// post state root is filled in by the test.
+const runnerStateTest = `{
+  "sstore": {
+    "env": {
+      "currentCoinbase": "2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+      "currentDifficulty": "0x020000",
+      "currentGasLimit": "0x05f5e100",
+      "currentNumber": "0x01",
+      "currentTimestamp": "0x03e8",
+      "currentBaseFee": "0x0a"
+    },
+    "pre": {
+      "a94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0x0de0b6b3a7640000", "code": "0x", "nonce": "0x00", "storage": {}},
+      "095e7baea6a6c7c4c2dfeb977efac326af552d87": {"balance": "0x00", "code": "0x600160005500", "nonce": "0x00", "storage": {}}
+    },
+    "transaction": {
+      "data": ["0x"],
+      "gasLimit": ["0x0186a0"],
+      "gasPrice": "0x0a",
+      "nonce": "0x00",
+      "secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+      "to": "0x095e7baea6a6c7c4c2dfeb977efac326af552d87",
+      "value": ["0x00"]
+    },
+    "post": {
+      "London": [{"hash": "ROOT", "logs": "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", "indexes": {"data": 0, "gas": 0, "value": 0}}],
+      "Berlin": [{"hash": "ROOT", "logs": "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", "indexes": {"data": 0, "gas": 0, "value": 0}}]
+    }
+  }
+}`
+
+func TestRunStateTest(t *testing.T) {
+	// Run with a bogus root to find out the real one
+	src := strings.ReplaceAll(runnerStateTest, "ROOT", strings.Repeat("00", 32))
+	results, err := RunStateTest([]byte(src), &RunConfig{Forks: []string{"London"}, Dump: true})
+	if err != nil {
+		t.Fatalf("failed to run test: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("result count mismatch: have %d, want 1", len(results))
+	}
+	if results[0].Pass || results[0].Root == nil || results[0].State == nil {
+		t.Fatalf("expected failure with dumped state, got %+v", results[0])
+	}
+	// Run again with the correct root and a tracer attached
+	src = strings.ReplaceAll(runnerStateTest, "ROOT", results[0].Root.Hex()[2:])
+
+	tracers := make(map[string]*logger.StructLogger)
+	results, err = RunStateTest([]byte(src), &RunConfig{
+		Tracer: func(name string, fork string, index int) vm.EVMLogger {
+			tracers[fork] = logger.NewStructLogger(nil)
+			return tracers[fork]
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to run test: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	// Berlin has no base fee, so the fees differ and thus the root too
+	if results[0].Fork != "Berlin" || results[0].Pass {
+		t.Errorf("unexpected Berlin result: %+v", results[0])
+	}
+	if results[1].Fork != "London" || !results[1].Pass {
+		t.Errorf("unexpected London result: %+v", results[1])
+	}
+	for fork, tracer := range tracers {
+		if len(tracer.StructLogs()) != 4 {
+			t.Errorf("%s: traced step count mismatch: have %d, want 4", fork, len(tracer.StructLogs()))
+		}
+	}
+}
+
+func TestRunStateTestInvalid(t *testing.T) {
+	if _, err := RunStateTest([]byte("{"), nil); err == nil {
+		t.Fatalf("malformed test file accepted")
+	}
+	src := strings.ReplaceAll(runnerStateTest, "ROOT", strings.Repeat("00", 32))
+	results, err := RunStateTest([]byte(strings.Replace(src, "London", "Unknown", 1)), &RunConfig{Forks: []string{"Unknown"}})
+	if err != nil {
+		t.Fatalf("failed to run test: %v", err)
+	}
+	if len(results) != 1 || results[0].Pass {
+		t.Fatalf("unsupported fork passed: %+v", results)
+	}
+}